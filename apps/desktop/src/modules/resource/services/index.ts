@@ -2,6 +2,7 @@ import { getControlClient } from "@/shared/services/clients";
 import type {
   CatalogRootResponse,
   ListEnvelope,
+  McpAuthorizeResult,
   McpConnectResult,
   ModelCatalogResponse,
   ModelTestResult,
@@ -67,6 +68,10 @@ export async function connectMcpResourceConfig(workspaceId: string, configId: st
   return getControlClient().post<McpConnectResult>(`/v1/workspaces/${workspaceId}/resource-configs/${configId}/connect`, {});
 }
 
+export async function authorizeMcpResourceConfig(workspaceId: string, configId: string): Promise<McpAuthorizeResult> {
+  return getControlClient().post<McpAuthorizeResult>(`/v1/workspaces/${workspaceId}/resource-configs/${configId}/authorize`, {});
+}
+
 export async function exportMcpConfigs(workspaceId: string): Promise<Record<string, unknown>> {
   return getControlClient().get<Record<string, unknown>>(`/v1/workspaces/${workspaceId}/mcps/export`);
 }
@@ -1,5 +1,6 @@
 import { listWorkspaceProjectConfigs } from "@/modules/project/services";
 import {
+  authorizeMcpResourceConfig,
   connectMcpResourceConfig,
   createResourceConfig,
   deleteResourceConfig,
@@ -22,7 +23,7 @@ import {
 } from "@/modules/resource/store/state";
 import { toDisplayError } from "@/shared/services/errorMapper";
 import { getCurrentWorkspace } from "@/shared/stores/workspaceStore";
-import type { McpConnectResult, ModelTestResult, ResourceConfig, ResourceConfigCreateRequest, ResourceConfigPatchRequest, ResourceType } from "@/shared/types/api";
+import type { McpAuthorizeResult, McpConnectResult, ModelTestResult, ResourceConfig, ResourceConfigCreateRequest, ResourceConfigPatchRequest, ResourceType } from "@/shared/types/api";
 
 export { resourceStore, resetResourceStore, setResourceSearch, setResourceEnabledFilter, type EnabledFilter };
 
@@ -239,6 +240,20 @@ export async function connectWorkspaceMcpConfig(configId: string): Promise<McpCo
   }
 }
 
+export async function authorizeWorkspaceMcpConfig(configId: string): Promise<McpAuthorizeResult | null> {
+  const workspace = getCurrentWorkspace();
+  if (!workspace) {
+    return null;
+  }
+
+  try {
+    return await authorizeMcpResourceConfig(workspace.id, configId);
+  } catch (error) {
+    resourceStore.error = toDisplayError(error);
+    return null;
+  }
+}
+
 export async function refreshWorkspaceMcpExport(): Promise<void> {
   const workspace = getCurrentWorkspace();
   if (!workspace) {
@@ -1,6 +1,7 @@
 import { computed, reactive, watch } from "vue";
 
 import {
+  authorizeWorkspaceMcpConfig,
   connectWorkspaceMcpConfig,
   createWorkspaceResourceConfig,
   deleteWorkspaceResourceConfig,
@@ -25,7 +26,7 @@ export function useWorkspaceMcpView() {
     mode: "create" as "create" | "edit",
     configId: "",
     name: "",
-    transport: "http_sse" as "http_sse" | "stdio",
+    transport: "http_sse" as "http" | "http_sse" | "stdio" | "ws",
     endpoint: "",
     command: "",
     envText: "",
@@ -78,7 +79,7 @@ export function useWorkspaceMcpView() {
       mode: "edit",
       configId: item.id,
       name: item.name,
-      transport: item.mcp?.transport === "stdio" ? "stdio" : "http_sse",
+      transport: item.mcp?.transport ?? "http_sse",
       endpoint: item.mcp?.endpoint ?? "",
       command: item.mcp?.command ?? "",
       envText: item.mcp?.env ? JSON.stringify(item.mcp.env, null, 2) : "",
@@ -106,7 +107,7 @@ export function useWorkspaceMcpView() {
 
     const payload = {
       transport: form.transport,
-      endpoint: form.transport === "http_sse" ? form.endpoint.trim() : undefined,
+      endpoint: form.transport !== "stdio" ? form.endpoint.trim() : undefined,
       command: form.transport === "stdio" ? form.command.trim() : undefined,
       env: env || undefined
     };
@@ -135,6 +136,17 @@ export function useWorkspaceMcpView() {
     form.message = `${item.name}: ${result.status} tools=${result.tools.length} ${result.message}`;
   }
 
+  async function authorize(item: ResourceConfig): Promise<void> {
+    const result = await authorizeWorkspaceMcpConfig(item.id);
+    if (!result) return;
+    if (result.status !== "pending" || !result.authorization_url) {
+      form.message = `${item.name}: 授权失败 ${result.message}`;
+      return;
+    }
+    window.open(result.authorization_url, "_blank", "noopener");
+    form.message = `${item.name}: 请在浏览器中完成授权，完成后将自动重新连接`;
+  }
+
   function getConnectResult(configId: string) {
     return resourceStore.mcpConnectResultsByConfigId[configId] ?? null;
   }
@@ -154,6 +166,9 @@ export function useWorkspaceMcpView() {
     if (errorCode === "handshake_failed") {
       return "握手失败，请确认 MCP 服务是否运行。";
     }
+    if (errorCode === "authorization_required") {
+      return "该服务需要 OAuth 授权，请点击“授权”在浏览器中登录。";
+    }
     if (errorCode === "tools_list_failed") {
       return "tools/list 失败，请检查服务端协议实现。";
     }
@@ -193,6 +208,7 @@ export function useWorkspaceMcpView() {
   }
 
   return {
+    authorize,
     canWrite,
     closeExportModal,
     closeModal,
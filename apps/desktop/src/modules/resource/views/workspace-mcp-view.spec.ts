@@ -4,6 +4,8 @@ import { describe, expect, it, vi } from "vitest";
 const mcpViewState = vi.hoisted(() => ({
   listItems: [] as Array<Record<string, unknown>>,
   connectSpy: vi.fn(),
+  authorizeSpy: vi.fn(),
+  connectResult: null as Record<string, unknown> | null,
   removeSpy: vi.fn(),
   confirmRemoveSpy: vi.fn(),
   closeRemoveSpy: vi.fn(),
@@ -13,6 +15,7 @@ const mcpViewState = vi.hoisted(() => ({
 
 vi.mock("@/modules/resource/views/useWorkspaceMcpView", () => ({
   useWorkspaceMcpView: () => ({
+    authorize: mcpViewState.authorizeSpy,
     canWrite: true,
     closeExportModal: vi.fn(),
     closeModal: vi.fn(),
@@ -35,7 +38,7 @@ vi.mock("@/modules/resource/views/useWorkspaceMcpView", () => ({
       removeConfigName: ""
     },
     formatTime: vi.fn(() => "-"),
-    getConnectResult: vi.fn(() => null),
+    getConnectResult: vi.fn(() => mcpViewState.connectResult),
     listState: {
       items: mcpViewState.listItems,
       page: {
@@ -126,4 +129,43 @@ describe("workspace mcp view", () => {
     expect(mcpViewState.removeSpy).toHaveBeenCalledTimes(1);
     expect(mcpViewState.confirmRemoveSpy).toHaveBeenCalledTimes(0);
   });
+
+  it("offers authorization when the server requires a login", async () => {
+    mcpViewState.authorizeSpy.mockClear();
+    mcpViewState.connectResult = {
+      config_id: "rc_mcp_oauth_1",
+      status: "failed",
+      tools: [],
+      error_code: "authorization_required",
+      message: "mcp server requires authorization",
+      connected_at: "2026-02-23T00:00:00Z"
+    };
+    mcpViewState.listItems = [
+      {
+        id: "rc_mcp_oauth_1",
+        name: "Linear MCP",
+        enabled: true,
+        mcp: {
+          transport: "http",
+          endpoint: "https://mcp.linear.app/mcp"
+        }
+      }
+    ];
+
+    const wrapper = mount(WorkspaceMcpView, {
+      global: {
+        stubs: {
+          WorkspaceSharedShell: {
+            template: "<div class='workspace-shared-shell-stub'><slot /></div>"
+          }
+        }
+      }
+    });
+
+    const authorizeButton = wrapper.findAll("button").find((item) => item.text() === "授权");
+    expect(authorizeButton).toBeTruthy();
+    await authorizeButton?.trigger("click");
+    expect(mcpViewState.authorizeSpy).toHaveBeenCalledTimes(1);
+    mcpViewState.connectResult = null;
+  });
 });
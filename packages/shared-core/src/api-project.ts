@@ -198,6 +198,7 @@ export type RunEventType =
   | "permission_request"
   | "tool_call"
   | "tool_result"
+  | "tool_progress"
   | "post_tool_use"
   | "post_tool_use_failure"
   | "diff_generated"
@@ -357,7 +358,7 @@ export type ComposerCatalog = {
 };
 
 export type ComposerSuggestion = {
-  kind: "command" | "resource_type" | "resource";
+  kind: "command" | "resource_type" | "resource" | "mcp_resource";
   label: string;
   detail?: string;
   insert_text: string;
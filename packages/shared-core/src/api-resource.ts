@@ -92,7 +92,7 @@ export type SkillSpec = {
 };
 
 export type McpSpec = {
-  transport: "http_sse" | "stdio";
+  transport: "http" | "http_sse" | "stdio" | "ws";
   endpoint?: string;
   command?: string;
   env?: Record<string, string>;
@@ -149,11 +149,30 @@ export type ModelTestResult = {
 
 export type McpConnectResult = {
   config_id: string;
-  status: "connected" | "failed";
+  status: "connected" | "failed" | "restarting";
   tools: string[];
   error_code?: string;
   message: string;
   connected_at: string;
+  sessions?: McpSessionStatus[];
+};
+
+export type McpAuthorizeResult = {
+  config_id: string;
+  status: "pending" | "failed";
+  authorization_url?: string;
+  error_code?: string;
+  message: string;
+};
+
+export type McpSessionStatus = {
+  session_id?: string;
+  status: "connecting" | "connected" | "restarting" | "failed";
+  protocol_version?: string;
+  restart_count: number;
+  started_at?: string;
+  last_used_at?: string;
+  last_error?: string;
 };
 
 export type ResourceImportRequest = {
@@ -1061,6 +1061,47 @@ export type paths = {
         patch?: never;
         trace?: never;
     };
+    "/v1/hooks/policies:simulate": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        /** Simulate hook policy evaluation */
+        post: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path?: never;
+                cookie?: never;
+            };
+            requestBody: {
+                content: {
+                    "application/json": components["schemas"]["HookPolicySimulateRequest"];
+                };
+            };
+            responses: {
+                /** @description Simulated decision with rule ranking and scope trace */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["HookPolicySimulateResponse"];
+                    };
+                };
+                400: components["responses"]["StandardErrorResponse"];
+            };
+        };
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
     "/v1/hooks/runs/{run_id}": {
         parameters: {
             query?: never;
@@ -2304,6 +2345,173 @@ export type paths = {
         patch?: never;
         trace?: never;
     };
+    "/v1/sessions/{session_id}/permissions/approvals": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        /** List remembered always-allow approvals visible to a session */
+        get: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path: {
+                    session_id: components["parameters"]["SessionIdParam"];
+                };
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description Remembered approvals across user, project and session scopes */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["PermissionApprovalListResponse"];
+                    };
+                };
+                404: components["responses"]["StandardErrorResponse"];
+            };
+        };
+        put?: never;
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/v1/sessions/{session_id}/permissions/approvals:revoke": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        /** Revoke one remembered always-allow approval */
+        post: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path: {
+                    session_id: components["parameters"]["SessionIdParam"];
+                };
+                cookie?: never;
+            };
+            requestBody: {
+                content: {
+                    "application/json": components["schemas"]["PermissionApprovalRevokeRequest"];
+                };
+            };
+            responses: {
+                /** @description Approval revoked */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["PermissionApprovalRevokeResponse"];
+                    };
+                };
+                400: components["responses"]["StandardErrorResponse"];
+                403: components["responses"]["StandardErrorResponse"];
+                404: components["responses"]["StandardErrorResponse"];
+            };
+        };
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/v1/sessions/{session_id}/checkpoints": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        /** List the file checkpoints taken before the session's Write and Edit calls */
+        get: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path: {
+                    session_id: components["parameters"]["SessionIdParam"];
+                };
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description Checkpoint timeline, oldest first */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["SessionCheckpointListResponse"];
+                    };
+                };
+                404: components["responses"]["StandardErrorResponse"];
+            };
+        };
+        put?: never;
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/v1/sessions/{session_id}/permissions:explain": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        /** Explain the permission decision for a hypothetical tool call without executing it */
+        post: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path: {
+                    session_id: components["parameters"]["SessionIdParam"];
+                };
+                cookie?: never;
+            };
+            requestBody: {
+                content: {
+                    "application/json": components["schemas"]["PermissionExplainRequest"];
+                };
+            };
+            responses: {
+                /** @description Permission trace across hook, sandbox and rule layers */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["PermissionExplainResponse"];
+                    };
+                };
+                400: components["responses"]["StandardErrorResponse"];
+                404: components["responses"]["StandardErrorResponse"];
+            };
+        };
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
     "/v1/sessions/{session_id}/rollback": {
         parameters: {
             query?: never;
@@ -2392,6 +2600,15 @@ export type paths = {
                     };
                 };
                 400: components["responses"]["StandardErrorResponse"];
+                /** @description PROMPT_BLOCKED when a user_prompt_submit hook policy denies the prompt; the prompt is not stored or queued. */
+                403: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["StandardError"];
+                    };
+                };
                 404: components["responses"]["StandardErrorResponse"];
                 409: components["responses"]["StandardErrorResponse"];
             };
@@ -2965,6 +3182,47 @@ export type paths = {
         };
         trace?: never;
     };
+    "/v1/workspaces/{workspace_id}/resource-configs/{config_id}/authorize": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        /** Start OAuth authorization for a remote MCP resource config */
+        post: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path: {
+                    config_id: string;
+                    workspace_id: components["parameters"]["WorkspaceIdParam"];
+                };
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description MCP authorization result */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["McpAuthorizeResult"];
+                    };
+                };
+                400: components["responses"]["StandardErrorResponse"];
+                404: components["responses"]["StandardErrorResponse"];
+            };
+        };
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
     "/v1/workspaces/{workspace_id}/resource-configs/{config_id}/connect": {
         parameters: {
             query?: never;
@@ -3302,6 +3560,11 @@ export type components = {
             run_id: string;
             tasks: components["schemas"]["TaskNode"][];
         };
+        /**
+         * @description Where an always-allow approval is remembered. The user scope is written to the hub host's user settings, so remembering or revoking it requires admin rights.
+         * @enum {string}
+         */
+        ApprovalScope: "session" | "project" | "user";
         Capabilities: {
             admin_console: boolean;
             execution_control: boolean;
@@ -3407,7 +3670,7 @@ export type components = {
             detail?: string;
             insert_text: string;
             /** @enum {string} */
-            kind: "command" | "resource_type" | "resource";
+            kind: "command" | "resource_type" | "resource" | "mcp_resource";
             label: string;
             replace_end: number;
             replace_start: number;
@@ -3506,6 +3769,7 @@ export type components = {
             additional_context?: {
                 [key: string]: unknown;
             };
+            handlers?: components["schemas"]["HookHandlerExecution"][];
             reason?: string;
             updated_input?: {
                 [key: string]: unknown;
@@ -3514,7 +3778,7 @@ export type components = {
         /** @enum {string} */
         HookDecisionAction: "allow" | "deny" | "ask";
         /** @enum {string} */
-        HookEventType: "session_start" | "session_end" | "user_prompt_submit" | "pre_tool_use" | "permission_request" | "post_tool_use" | "post_tool_use_failure" | "subagent_start" | "stop" | "subagent_stop" | "teammate_idle" | "task_completed" | "notification" | "config_change" | "worktree_create" | "worktree_remove" | "pre_compact";
+        HookEventType: "session_start" | "session_end" | "user_prompt_submit" | "pre_tool_use" | "permission_request" | "post_tool_use" | "post_tool_use_failure" | "subagent_start" | "stop" | "subagent_stop" | "teammate_idle" | "task_completed" | "notification" | "config_change" | "worktree_create" | "worktree_remove" | "pre_compact" | "mcp_server_request";
         HookExecutionListResponse: {
             items: components["schemas"]["HookExecutionRecord"][];
         };
@@ -3530,12 +3794,33 @@ export type components = {
             timestamp: string;
             tool_name?: string;
         };
+        HookHandlerConfig: {
+            agent?: string;
+            /** @description Rejected by the API; command handlers are only read from local settings files. */
+            command?: string;
+            headers?: {
+                [key: string]: string;
+            };
+            prompt?: string;
+            timeout_ms?: number;
+            url?: string;
+        };
+        HookHandlerExecution: {
+            action?: components["schemas"]["HookDecisionAction"];
+            duration_ms: number;
+            error?: string;
+            handler_type: components["schemas"]["HookHandlerType"];
+            policy_id: string;
+            reason?: string;
+            timed_out?: boolean;
+        };
         /** @enum {string} */
         HookHandlerType: "command" | "http" | "prompt" | "agent";
         HookPolicy: {
             decision: components["schemas"]["HookDecision"];
             enabled: boolean;
             event: components["schemas"]["HookEventType"];
+            handler?: components["schemas"]["HookHandlerConfig"];
             handler_type: components["schemas"]["HookHandlerType"];
             id: string;
             project_id?: string;
@@ -3549,10 +3834,34 @@ export type components = {
         HookPolicyListResponse: {
             items: components["schemas"]["HookPolicy"][];
         };
+        HookPolicySimulateRequest: {
+            event: components["schemas"]["HookEventType"];
+            payload?: {
+                [key: string]: unknown;
+            };
+            project_id?: string;
+            /** @description Live execution. Matched HTTP, prompt and agent handlers are really invoked, with "dry_run" set to true in their payload. */
+            run_handlers?: boolean;
+            session_id?: string;
+            tool_name?: string;
+            workspace_id?: string;
+        };
+        HookPolicySimulateResponse: {
+            decision: components["schemas"]["HookDecisionAction"];
+            matched_policy_id?: string;
+            metadata?: {
+                [key: string]: unknown;
+            };
+            /** @description In-scope policies in the order live runs rank them, by scope (global first), then tool specificity, then deny > ask > allow. The first entry decides. */
+            ranking: components["schemas"]["HookSimulationRank"][];
+            reason?: string;
+            unmatched: components["schemas"]["HookSimulationUnmatched"][];
+        };
         HookPolicyUpsertRequest: {
             decision: components["schemas"]["HookDecision"];
             enabled?: boolean;
             event: components["schemas"]["HookEventType"];
+            handler?: components["schemas"]["HookHandlerConfig"];
             handler_type: components["schemas"]["HookHandlerType"];
             id: string;
             project_id?: string;
@@ -3575,6 +3884,23 @@ export type components = {
         });
         /** @enum {string} */
         HookScope: "global" | "project" | "local" | "plugin";
+        HookSimulationRank: {
+            decision: components["schemas"]["HookDecisionAction"];
+            handler?: {
+                [key: string]: unknown;
+            };
+            policy_id: string;
+            scope: string;
+            scope_rank: number;
+            scope_trace?: string[];
+            specificity: number;
+            static_decision?: components["schemas"]["HookDecisionAction"];
+        };
+        HookSimulationUnmatched: {
+            policy_id: string;
+            /** @enum {string} */
+            reason: "disabled" | "scope" | "event" | "tool";
+        };
         ImportProjectRequest: {
             directory_path: string;
             workspace_id: string;
@@ -3599,16 +3925,37 @@ export type components = {
         LogoutRequest: {
             access_token?: string;
         };
+        McpAuthorizeResult: {
+            authorization_url?: string;
+            config_id: string;
+            error_code?: string;
+            message: string;
+            /** @enum {string} */
+            status: "pending" | "failed";
+        };
         McpConnectResult: {
             config_id: string;
             /** Format: date-time */
             connected_at: string;
             error_code?: string;
             message: string;
+            sessions?: components["schemas"]["McpSessionStatus"][];
             /** @enum {string} */
-            status: "connected" | "failed";
+            status: "connected" | "failed" | "restarting";
             tools: string[];
         };
+        McpSessionStatus: {
+            last_error?: string;
+            /** Format: date-time */
+            last_used_at?: string;
+            protocol_version?: string;
+            restart_count: number;
+            session_id?: string;
+            /** Format: date-time */
+            started_at?: string;
+            /** @enum {string} */
+            status: "connecting" | "connected" | "restarting" | "failed";
+        };
         McpSpec: {
             command?: string;
             endpoint?: string;
@@ -3621,7 +3968,7 @@ export type components = {
             status?: string;
             tools?: string[];
             /** @enum {string} */
-            transport: "http_sse" | "stdio";
+            transport: "http" | "http_sse" | "stdio" | "ws";
         };
         Me: {
             capabilities: components["schemas"]["Capabilities"];
@@ -3642,6 +3989,8 @@ export type components = {
             workspace_id: string;
         };
         ModelCatalogModel: {
+            /** @description Context window in tokens; the runtime auto-compacts sessions against it. */
+            context_window?: number;
             enabled: boolean;
             id: string;
             label: string;
@@ -3729,6 +4078,59 @@ export type components = {
             /** @constant */
             ok: true;
         };
+        PermissionApprovalListResponse: {
+            items: components["schemas"]["RememberedApproval"][];
+            runtime_session_id?: string;
+            session_id: string;
+        };
+        PermissionApprovalRevokeRequest: {
+            rule: string;
+            scope: components["schemas"]["ApprovalScope"];
+        };
+        PermissionApprovalRevokeResponse: {
+            /** @constant */
+            ok: true;
+            rule: string;
+            scope: components["schemas"]["ApprovalScope"];
+        };
+        PermissionExplainLayer: {
+            configured: boolean;
+            /** @enum {string} */
+            decision?: "allow" | "ask" | "deny";
+            /** @enum {string} */
+            layer: "hooks" | "sandbox" | "permission";
+            matched_rule?: string;
+            reason?: string;
+            trace?: {
+                [key: string]: unknown;
+            };
+        };
+        PermissionExplainRequest: {
+            /** @description Primary argument (command, URL or path) used when input is omitted */
+            arguments?: string;
+            input?: {
+                [key: string]: unknown;
+            };
+            mode?: components["schemas"]["PermissionMode"];
+            tool_name: string;
+        };
+        PermissionExplainResponse: {
+            /** @enum {string} */
+            decision: "allow" | "ask" | "deny";
+            decided_by?: string;
+            input: {
+                [key: string]: unknown;
+            };
+            layers: components["schemas"]["PermissionExplainLayer"][];
+            mode: components["schemas"]["PermissionMode"];
+            precedence: string;
+            reason?: string;
+            rule_config_ids: string[];
+            rule_diagnostics: components["schemas"]["RuleDiagnostic"][];
+            session_id: string;
+            tool_name: string;
+            working_dir?: string;
+        };
         /** @enum {string} */
         PermissionMode: "default" | "acceptEdits" | "plan" | "dontAsk" | "bypassPermissions";
         PermissionSnapshot: {
@@ -3795,6 +4197,14 @@ export type components = {
         RefreshRequest: {
             refresh_token: string;
         };
+        RememberedApproval: {
+            /** @description Settings file holding the entry; empty for session approvals, which the running session keeps in memory */
+            path: string;
+            /** @description Permission entry such as Bash(git status:*) */
+            rule: string;
+            scope: components["schemas"]["ApprovalScope"];
+            session_id?: string;
+        };
         RemoteConnectionRequest: {
             hub_url: string;
             name?: string;
@@ -3879,6 +4289,15 @@ export type components = {
         RollbackRequest: {
             message_id: string;
         };
+        RuleDiagnostic: {
+            column: number;
+            line: number;
+            message: string;
+            raw?: string;
+            /** @enum {string} */
+            severity: "error" | "warning";
+            suggestion?: string;
+        };
         RuleSpec: {
             content: string;
         };
@@ -3923,6 +4342,14 @@ export type components = {
         RunControlRequest: {
             action: components["schemas"]["RunControlAction"];
             answer?: components["schemas"]["ExecutionUserAnswer"];
+            remember?: components["schemas"]["ApprovalScope"];
+            /**
+             * @description Permission mode to continue in after approving an ExitPlanMode plan. Requires action=approve and a pending plan approval.
+             * @enum {string}
+             */
+            mode?: "default" | "acceptEdits";
+            /** @description Reason reported to the model when rejecting an ExitPlanMode plan. Requires action=deny and a pending plan approval. */
+            feedback?: string;
         };
         RunControlResponse: {
             /** @constant */
@@ -3932,7 +4359,7 @@ export type components = {
             state: components["schemas"]["RunState"];
         };
         /** @enum {string} */
-        RunEventType: "message_received" | "user_prompt_submit" | "execution_started" | "thinking_delta" | "pre_tool_use" | "permission_request" | "tool_call" | "tool_result" | "post_tool_use" | "post_tool_use_failure" | "diff_generated" | "change_set_updated" | "change_set_committed" | "change_set_discarded" | "change_set_rolled_back" | "execution_stopped" | "execution_done" | "execution_error" | "task_graph_configured" | "task_dependencies_updated" | "task_retry_policy_updated" | "task_artifact_emitted" | "task_failed" | "task_started" | "task_completed" | "task_cancelled";
+        RunEventType: "message_received" | "user_prompt_submit" | "execution_started" | "thinking_delta" | "pre_tool_use" | "permission_request" | "tool_call" | "tool_result" | "tool_progress" | "post_tool_use" | "post_tool_use_failure" | "diff_generated" | "change_set_updated" | "change_set_committed" | "change_set_discarded" | "change_set_rolled_back" | "execution_stopped" | "execution_done" | "execution_error" | "task_graph_configured" | "task_dependencies_updated" | "task_retry_policy_updated" | "task_artifact_emitted" | "task_failed" | "task_started" | "task_completed" | "task_cancelled";
         RunFilesExportResponse: {
             archive_base64: string;
             file_name: string;
@@ -3960,6 +4387,8 @@ export type components = {
         };
         RunResourceProfile: {
             always_loaded_capabilities?: components["schemas"]["ExecutionCapabilityDescriptorSnapshot"][];
+            /** @description Owner of the stored MCP OAuth tokens the run uses, one per workspace user. */
+            mcp_credential_scope?: string;
             mcp_ids?: string[];
             mcp_servers?: components["schemas"]["ExecutionMCPServerSnapshot"][];
             model_config_id?: string;
@@ -4012,6 +4441,22 @@ export type components = {
             session_id: string;
             suggested_message: components["schemas"]["CommitSuggestion"];
         };
+        SessionCheckpoint: {
+            checkpoint_id: string;
+            /** Format: date-time */
+            created_at: string;
+            /** @description Absolute paths the checkpoint captured */
+            paths: string[];
+            /** @description Tool call the checkpoint was taken for, such as Write src/main.go */
+            reason?: string;
+            /** Format: int64 */
+            size_bytes: number;
+        };
+        SessionCheckpointListResponse: {
+            items: components["schemas"]["SessionCheckpoint"][];
+            runtime_session_id?: string;
+            session_id: string;
+        };
         SessionDetailResponse: {
             messages: components["schemas"]["SessionMessage"][];
             runs: components["schemas"]["Run"][];
@@ -7,9 +7,12 @@ import (
 
 	acpadapter "goyais/services/hub/internal/agent/adapters/acp"
 	"goyais/services/hub/internal/agent/runtime/loop"
+	"goyais/services/hub/internal/agent/tools/ossandbox"
 )
 
 func main() {
+	ossandbox.RunInitIfRequested()
+
 	engine := loop.NewEngine(nil)
 	peer := acpadapter.NewPeer()
 	_ = acpadapter.NewServer(peer, acpadapter.ServerOptions{
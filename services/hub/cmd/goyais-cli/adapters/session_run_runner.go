@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -18,6 +19,7 @@ import (
 	"goyais/services/hub/internal/agent/core"
 	eventscore "goyais/services/hub/internal/agent/core/events"
 	"goyais/services/hub/internal/agent/runtime/loop"
+	"goyais/services/hub/internal/agent/tools/checkpoint"
 )
 
 // SessionRecord captures one local CLI-visible session snapshot.
@@ -41,6 +43,8 @@ type SubmitRunRequest struct {
 	AdditionalDirectories []string
 	OutputFormat          string
 	Cursor                string
+	// PermissionPromptTool delegates approvals to the named mcp__ tool.
+	PermissionPromptTool string
 }
 
 // SubmitRunResult summarizes one run submission outcome.
@@ -51,8 +55,11 @@ type SubmitRunResult struct {
 
 // RunControlRequest defines one run control command.
 type RunControlRequest struct {
-	RunID  string
-	Action string
+	RunID    string
+	Action   string
+	Remember string
+	Mode     string
+	Feedback string
 }
 
 // StreamSessionRequest defines one run-stream snapshot command.
@@ -69,6 +76,8 @@ type SessionRunRunner struct {
 	engine core.Engine
 	stdout io.Writer
 	stderr io.Writer
+	// checkpointDir holds the file checkpoints of this process's sessions.
+	checkpointDir string
 
 	mu           sync.RWMutex
 	sessions     map[string]SessionRecord
@@ -83,13 +92,21 @@ func NewSessionRunRunner(stdout io.Writer, stderr io.Writer) *SessionRunRunner {
 	if stderr == nil {
 		stderr = io.Discard
 	}
-	return &SessionRunRunner{
-		engine:       loop.NewEngine(nil),
+	runner := &SessionRunRunner{
 		stdout:       stdout,
 		stderr:       stderr,
 		sessions:     map[string]SessionRecord{},
 		sessionOrder: make([]string, 0, 8),
 	}
+	// CLI sessions end with the process, so their checkpoints are kept in a
+	// temporary dir that is only created once a run edits a file.
+	runner.checkpointDir = filepath.Join(os.TempDir(), fmt.Sprintf("goyais-checkpoints-%d-%d", os.Getpid(), time.Now().UnixNano()))
+	runner.engine = loop.NewEngineWithDeps(loop.Dependencies{
+		HookDispatcher:    settingsHookDispatcher{runner: runner},
+		CheckpointDir:     runner.checkpointDir,
+		CheckpointOptions: checkpoint.DiskOptions{Compress: true},
+	})
+	return runner
 }
 
 // StartSession creates and records one session handle.
@@ -121,6 +138,28 @@ func (r *SessionRunRunner) StartSession(ctx context.Context, req SessionStartReq
 	return record, nil
 }
 
+// Close ends every recorded session, releasing what the engine holds for
+// them, such as pooled MCP servers, session-scoped approvals and file
+// checkpoints.
+func (r *SessionRunRunner) Close(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	defer os.RemoveAll(r.checkpointDir)
+	closer, ok := r.engine.(interface {
+		CloseSession(ctx context.Context, sessionID string)
+	})
+	if !ok {
+		return
+	}
+	r.mu.RLock()
+	sessionIDs := append([]string(nil), r.sessionOrder...)
+	r.mu.RUnlock()
+	for _, sessionID := range sessionIDs {
+		closer.CloseSession(ctx, sessionID)
+	}
+}
+
 // ListSessions returns recorded sessions in creation order.
 func (r *SessionRunRunner) ListSessions(_ context.Context) ([]SessionRecord, error) {
 	if r == nil {
@@ -187,6 +226,7 @@ func (r *SessionRunRunner) SubmitRun(
 		WorkingDir:            workingDir,
 		AdditionalDirectories: sanitizeDirectories(req.AdditionalDirectories),
 		Prompt:                strings.TrimSpace(req.Prompt),
+		Metadata:              runMetadata(req),
 		Cursor:                strings.TrimSpace(req.Cursor),
 	})
 	if err != nil {
@@ -209,9 +249,16 @@ func (r *SessionRunRunner) ControlRun(ctx context.Context, req RunControlRequest
 	if err != nil {
 		return err
 	}
+	remember, err := core.ParseApprovalScope(req.Remember)
+	if err != nil {
+		return err
+	}
 	return r.engine.Control(ctx, core.ControlRequest{
-		RunID:  strings.TrimSpace(req.RunID),
-		Action: action,
+		RunID:    strings.TrimSpace(req.RunID),
+		Action:   action,
+		Remember: remember,
+		Mode:     core.PermissionMode(strings.TrimSpace(req.Mode)),
+		Feedback: strings.TrimSpace(req.Feedback),
 	})
 }
 
@@ -293,10 +340,21 @@ func (r *SessionRunRunner) RunPrompt(ctx context.Context, req RunRequest) error
 		CWD:                   strings.TrimSpace(req.CWD),
 		OutputFormat:          strings.TrimSpace(req.OutputFormat),
 		AdditionalDirectories: nil,
+		PermissionPromptTool:  strings.TrimSpace(req.PermissionPromptTool),
 	}, r.stdout, r.stderr)
 	return err
 }
 
+// runMetadata forwards run options that the engine reads from input metadata.
+// "stdio" is answered by the stream-json control protocol, not the engine.
+func runMetadata(req SubmitRunRequest) map[string]string {
+	tool := strings.TrimSpace(req.PermissionPromptTool)
+	if tool == "" || strings.EqualFold(tool, "stdio") {
+		return nil
+	}
+	return map[string]string{"permission_prompt_tool": tool}
+}
+
 func (r *SessionRunRunner) recordSession(record SessionRecord) {
 	key := strings.TrimSpace(record.SessionID)
 	if key == "" {
@@ -398,6 +456,9 @@ type textEventWriter struct {
 func (w *textEventWriter) WriteEvent(frame cliadapter.EventFrame) error {
 	switch frame.Type {
 	case string(core.RunEventTypeRunOutputDelta):
+		if frame.Payload["stage"] != nil {
+			return nil
+		}
 		text := strings.TrimSpace(stringValue(frame.Payload["delta"]))
 		if text != "" {
 			_, err := io.WriteString(w.stdout, text)
@@ -483,6 +544,9 @@ func normalizeProtocolEvent(frame cliadapter.EventFrame) (map[string]any, bool)
 
 	switch frame.Type {
 	case string(core.RunEventTypeRunOutputDelta):
+		if stage := strings.TrimSpace(stringValue(frame.Payload["stage"])); stage != "" {
+			return normalizeStagedDelta(base, stage, frame.Payload)
+		}
 		delta := strings.TrimSpace(stringValue(frame.Payload["delta"]))
 		if delta == "" {
 			return nil, false
@@ -550,6 +614,33 @@ func normalizeProtocolEvent(frame cliadapter.EventFrame) (map[string]any, bool)
 	}
 }
 
+// normalizeStagedDelta maps staged runtime deltas to protocol events. Only
+// approval decisions are surfaced so headless callers can audit them.
+func normalizeStagedDelta(base map[string]any, stage string, payload map[string]any) (map[string]any, bool) {
+	if stage != "approval_resolved" {
+		return nil, false
+	}
+	base["type"] = "permission_decision"
+	base["decision"] = strings.TrimSpace(stringValue(payload["delta"]))
+	for _, key := range []string{"call_id", "name", "risk_level", "text"} {
+		if value := strings.TrimSpace(stringValue(payload[key])); value != "" {
+			base[key] = value
+		}
+	}
+	if output, ok := payload["output"].(map[string]any); ok {
+		if decidedBy := strings.TrimSpace(stringValue(output["decided_by"])); decidedBy != "" {
+			base["decided_by"] = decidedBy
+		}
+		if timedOut, ok := output["timed_out"].(bool); ok && timedOut {
+			base["timed_out"] = true
+		}
+	}
+	if input, ok := payload["input"]; ok && input != nil {
+		base["updated_input"] = input
+	}
+	return base, true
+}
+
 func writeJSONLine(output io.Writer, value any) error {
 	encoded, err := json.Marshal(value)
 	if err != nil {
@@ -588,6 +679,18 @@ func eventPayloadToMap(payload core.EventPayload) (map[string]any, error) {
 		if toolUseID := strings.TrimSpace(typed.ToolUseID); toolUseID != "" {
 			out["tool_use_id"] = toolUseID
 		}
+		if stage := strings.TrimSpace(typed.Stage); stage != "" {
+			out["stage"] = stage
+			out["call_id"] = strings.TrimSpace(typed.CallID)
+			out["name"] = strings.TrimSpace(typed.Name)
+			out["text"] = strings.TrimSpace(typed.Text)
+			if len(typed.Input) > 0 {
+				out["input"] = cloneMapAny(typed.Input)
+			}
+			if len(typed.Output) > 0 {
+				out["output"] = cloneMapAny(typed.Output)
+			}
+		}
 		return out, nil
 	case core.ApprovalNeededPayload:
 		return map[string]any{
@@ -8,8 +8,12 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	cliadapter "goyais/services/hub/internal/agent/adapters/cli"
 )
 
 func TestSessionRunRunnerRunPromptText(t *testing.T) {
@@ -119,3 +123,53 @@ func TestSessionRunRunnerControlAndStream(t *testing.T) {
 		t.Fatalf("expected replayed failed result frame, got %q", streamOut.String())
 	}
 }
+
+func TestNormalizeProtocolEventReportsPermissionDecisions(t *testing.T) {
+	event, ok := normalizeProtocolEvent(cliadapter.EventFrame{
+		Type:  "run_output_delta",
+		RunID: "run_1",
+		Payload: map[string]any{
+			"delta":  "deny",
+			"stage":  "approval_resolved",
+			"name":   "Bash",
+			"text":   "permission prompt tool mcp__ci__approve timed out after 1m0s",
+			"output": map[string]any{"decided_by": "mcp__ci__approve", "timed_out": true},
+		},
+	})
+	if !ok || event["type"] != "permission_decision" || event["decision"] != "deny" || event["decided_by"] != "mcp__ci__approve" || event["timed_out"] != true {
+		t.Fatalf("expected permission decision event, got %#v (ok=%v)", event, ok)
+	}
+	if _, ok := normalizeProtocolEvent(cliadapter.EventFrame{
+		Type:    "run_output_delta",
+		Payload: map[string]any{"stage": "tool_call", "name": "Bash"},
+	}); ok {
+		t.Fatal("expected other staged deltas to stay out of the protocol stream")
+	}
+	if got := runMetadata(SubmitRunRequest{PermissionPromptTool: "stdio"}); got != nil {
+		t.Fatalf("expected stdio to stay on the control protocol, got %#v", got)
+	}
+	if got := runMetadata(SubmitRunRequest{PermissionPromptTool: "mcp__ci__approve"}); got["permission_prompt_tool"] != "mcp__ci__approve" {
+		t.Fatalf("expected permission prompt tool metadata, got %#v", got)
+	}
+}
+
+func TestSessionRunRunnerDispatchesSettingsHooks(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	workingDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(workingDir, ".goyais"), 0o755); err != nil {
+		t.Fatalf("mkdir settings dir: %v", err)
+	}
+	settingsJSON := `{"hooks":{"UserPromptSubmit":[{"decision":"deny","reason":"prompts are frozen"}]}}`
+	if err := os.WriteFile(filepath.Join(workingDir, ".goyais", "settings.json"), []byte(settingsJSON), 0o644); err != nil {
+		t.Fatalf("write settings: %v", err)
+	}
+	stderr := &bytes.Buffer{}
+	runner := NewSessionRunRunner(io.Discard, stderr)
+
+	if err := runner.RunPrompt(context.Background(), RunRequest{Prompt: "hello", CWD: workingDir}); err != nil {
+		t.Fatalf("run prompt: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "prompts are frozen") {
+		t.Fatalf("expected the settings hook to block the prompt, got %q", stderr.String())
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package adapters
+
+import (
+	"context"
+	"strings"
+
+	"goyais/services/hub/internal/agent/core"
+	"goyais/services/hub/internal/agent/extensions/hooks"
+)
+
+// settingsHookDispatcher runs the hooks configured in the settings files of
+// each session's working directory. Settings are read on every event, so
+// edits apply to the next event without restarting the CLI.
+type settingsHookDispatcher struct {
+	runner *SessionRunRunner
+}
+
+var _ core.HookDispatcher = settingsHookDispatcher{}
+
+func (d settingsHookDispatcher) Dispatch(ctx context.Context, event core.HookEvent) (core.HookDecision, error) {
+	workingDir, _ := event.Payload["cwd"].(string)
+	workingDir = strings.TrimSpace(workingDir)
+	if workingDir == "" {
+		if record, err := d.runner.GetSession(ctx, string(event.SessionID)); err == nil {
+			workingDir = record.CWD
+		}
+	}
+	rules, err := hooks.LoadSettingsRules(workingDir)
+	if err != nil {
+		return core.HookDecision{}, err
+	}
+
+	payload := make(map[string]any, len(event.Payload)+2)
+	for key, value := range event.Payload {
+		payload[key] = value
+	}
+	payload["cwd"] = workingDir
+	payload["project_id"] = workingDir
+	event.Payload = payload
+	return hooks.NewDispatcher(rules).Dispatch(ctx, event)
+}
@@ -7,10 +7,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"regexp"
 	"runtime"
@@ -21,17 +23,40 @@ import (
 	"time"
 
 	"goyais/services/hub/cmd/goyais-cli/adapters"
+	"goyais/services/hub/internal/agent/adapters/mcpserver"
+	"goyais/services/hub/internal/agent/context/settings"
+	"goyais/services/hub/internal/agent/core"
+	"goyais/services/hub/internal/agent/extensions/hooks"
+	mcpext "goyais/services/hub/internal/agent/extensions/mcp"
+	"goyais/services/hub/internal/agent/policy"
+	"goyais/services/hub/internal/agent/policy/approval"
+	"goyais/services/hub/internal/agent/policy/rulesdsl"
+	sandboxpolicy "goyais/services/hub/internal/agent/policy/sandbox"
+	"goyais/services/hub/internal/agent/tools/executor"
+	"goyais/services/hub/internal/agent/tools/ossandbox"
+	"goyais/services/hub/internal/runtime/infra/secrets"
 )
 
 const (
 	stateDirectoryName  = ".goyais"
 	commandStateFile    = "cli-state.json"
 	mcpServersStateFile = "mcp-servers.json"
+	// mcpGetLogLines is how many server log lines `mcp get` prints.
+	mcpGetLogLines = 20
+	// mcpLoginTimeout bounds discovery plus the wait for the browser to
+	// complete `mcp login`.
+	mcpLoginTimeout = 5 * time.Minute
 )
 
 var (
 	commandRuntimeMu     sync.Mutex
 	commandRuntimeRunner *adapters.SessionRunRunner
+
+	browserOpenerMu sync.Mutex
+	browserOpener   = openInBrowser
+
+	mcpServeInputMu       sync.Mutex
+	mcpServeInputOverride io.Reader
 )
 
 func getCommandRuntimeRunner() *adapters.SessionRunRunner {
@@ -50,6 +75,34 @@ func ResetRuntimeForTests() {
 	commandRuntimeRunner = nil
 }
 
+// SetBrowserOpenerForTests replaces how `mcp login` opens the authorization
+// page and returns a func that restores the default.
+func SetBrowserOpenerForTests(open func(target string) error) func() {
+	browserOpenerMu.Lock()
+	defer browserOpenerMu.Unlock()
+	previous := browserOpener
+	browserOpener = open
+	return func() {
+		browserOpenerMu.Lock()
+		defer browserOpenerMu.Unlock()
+		browserOpener = previous
+	}
+}
+
+// SetMCPServeInputForTests makes `mcp serve` read requests from input
+// instead of stdin and returns a func that restores stdin.
+func SetMCPServeInputForTests(input io.Reader) func() {
+	mcpServeInputMu.Lock()
+	defer mcpServeInputMu.Unlock()
+	previous := mcpServeInputOverride
+	mcpServeInputOverride = input
+	return func() {
+		mcpServeInputMu.Lock()
+		defer mcpServeInputMu.Unlock()
+		mcpServeInputOverride = previous
+	}
+}
+
 type commandArgs struct {
 	Positionals []string
 	Flags       map[string]bool
@@ -294,6 +347,10 @@ func executeLeafCommand(node *Node, rawArgs []string, stdout io.Writer, stderr i
 		return handleApprovedToolsList(ctx)
 	case "approved-tools remove":
 		return handleApprovedToolsRemove(ctx)
+	case "permissions explain":
+		return handlePermissionsExplain(ctx)
+	case "hooks test":
+		return handleHooksTest(ctx)
 	case "session start":
 		return handleSessionStart(ctx)
 	case "session list":
@@ -324,6 +381,10 @@ func executeLeafCommand(node *Node, rawArgs []string, stdout io.Writer, stderr i
 		return handleMCPAddJSON(ctx)
 	case "mcp get":
 		return handleMCPGet(ctx)
+	case "mcp login":
+		return handleMCPLogin(ctx)
+	case "mcp logout":
+		return handleMCPLogout(ctx)
 	case "mcp add-from-claude-desktop":
 		return handleMCPAddFromClaudeDesktop(ctx)
 	case "mcp reset-project-choices", "mcp reset-mcprc-choices":
@@ -1639,7 +1700,22 @@ func handleApprovedToolsList(ctx commandExecutionContext) int {
 		return 1
 	}
 	sort.Strings(state.ApprovedTools)
-	writeJSON(ctx.Stdout, state.ApprovedTools)
+	// The plain list stays an array of tool names; --remembered adds the
+	// remembered "always allow" rules in an object.
+	if !ctx.Args.Has("remembered") {
+		writeJSON(ctx.Stdout, state.ApprovedTools)
+		return 0
+	}
+	// Session approvals live only in the process running the session.
+	remembered, err := approval.NewRuleStore(ctx.WorkingDir, "").List("")
+	if err != nil {
+		ctx.writeErr("error: load remembered approvals: %v\n", err)
+		return 1
+	}
+	writeJSON(ctx.Stdout, map[string]any{
+		"approved_tools": state.ApprovedTools,
+		"remembered":     remembered,
+	})
 	return 0
 }
 
@@ -1649,33 +1725,302 @@ func handleApprovedToolsRemove(ctx commandExecutionContext) int {
 		return 1
 	}
 	toolName := ctx.Args.Positionals[0]
-	state, err := loadCommandState(ctx.WorkingDir)
+	rawScope, hasScope := ctx.Args.First("scope")
+	scope, err := core.ParseApprovalScope(rawScope)
 	if err != nil {
-		ctx.writeErr("error: load approved tools state: %v\n", err)
+		ctx.writeErr("error: %v\n", err)
+		return 1
+	}
+	if scope == core.ApprovalScopeSession {
+		ctx.writeErr("error: session approvals are kept by the running session and end with it\n")
+		return 1
+	}
+	if !hasScope {
+		state, err := loadCommandState(ctx.WorkingDir)
+		if err != nil {
+			ctx.writeErr("error: load approved tools state: %v\n", err)
+			return 1
+		}
+		filtered := make([]string, 0, len(state.ApprovedTools))
+		removed := false
+		for _, item := range state.ApprovedTools {
+			if strings.EqualFold(item, toolName) {
+				removed = true
+				continue
+			}
+			filtered = append(filtered, item)
+		}
+		if removed {
+			state.ApprovedTools = filtered
+			if err := saveCommandState(ctx.WorkingDir, state); err != nil {
+				ctx.writeErr("error: save approved tools state: %v\n", err)
+				return 1
+			}
+			ctx.writeOut("Removed approved tool: %s\n", toolName)
+			return 0
+		}
+	}
+
+	store := approval.NewRuleStore(ctx.WorkingDir, "")
+	remembered, err := store.List("")
+	if err != nil {
+		ctx.writeErr("error: load remembered approvals: %v\n", err)
 		return 1
 	}
-	filtered := make([]string, 0, len(state.ApprovedTools))
-	removed := false
-	for _, item := range state.ApprovedTools {
-		if strings.EqualFold(item, toolName) {
-			removed = true
+	revoked := 0
+	for _, item := range remembered {
+		if item.Rule != strings.TrimSpace(toolName) || (scope != "" && item.Scope != scope) {
 			continue
 		}
-		filtered = append(filtered, item)
+		if _, err := store.Revoke(item.Scope, item.SessionID, item.Rule); err != nil {
+			ctx.writeErr("error: revoke remembered approval: %v\n", err)
+			return 1
+		}
+		ctx.writeOut("Revoked %s approval: %s\n", item.Scope, item.Rule)
+		revoked++
 	}
-	if !removed {
+	if revoked == 0 {
 		ctx.writeOut("Tool %s is not in the approved list\n", toolName)
 		return 1
 	}
-	state.ApprovedTools = filtered
-	if err := saveCommandState(ctx.WorkingDir, state); err != nil {
-		ctx.writeErr("error: save approved tools state: %v\n", err)
+	return 0
+}
+
+func handlePermissionsExplain(ctx commandExecutionContext) int {
+	if len(ctx.Args.Positionals) < 1 {
+		ctx.writeErr("error: permissions explain requires <tool>\n")
+		return 1
+	}
+	toolName := strings.TrimSpace(ctx.Args.Positionals[0])
+	arguments := strings.Join(ctx.Args.Positionals[1:], " ")
+
+	merged, err := settings.LoadAndMerge(settings.LoadOptions{WorkingDir: ctx.WorkingDir})
+	if err != nil {
+		ctx.writeErr("error: load settings: %v\n", err)
+		return 1
+	}
+	modeRaw, _ := ctx.Args.First("mode")
+	if modeRaw == "" {
+		modeRaw = settingsPermissionString(merged.Effective, "defaultMode")
+	}
+	mode, ok := parseExplainPermissionMode(modeRaw)
+	if !ok {
+		ctx.writeErr("error: invalid mode %q\n", modeRaw)
+		return 1
+	}
+
+	lines, sources := settings.PermissionRuleLines(merged)
+	if rulesPath, ok := ctx.Args.First("rules"); ok && rulesPath != "" {
+		resolved := rulesPath
+		if !filepath.IsAbs(resolved) {
+			resolved = filepath.Join(ctx.WorkingDir, resolved)
+		}
+		raw, readErr := os.ReadFile(resolved)
+		if readErr != nil {
+			ctx.writeErr("error: read rules file: %v\n", readErr)
+			return 1
+		}
+		for _, line := range strings.Split(string(raw), "\n") {
+			lines = append(lines, line)
+			sources = append(sources, rulesPath)
+		}
+	}
+	rules, diagnostics := rulesdsl.Lint(lines)
+
+	pipeline := executor.NewPipeline(executor.Dependencies{
+		SandboxGate:    executor.NewSandboxGateFromEvaluator(sandboxpolicy.NewEvaluator(nil)),
+		PermissionGate: policy.NewGateFromRules(rules),
+	})
+	explanation, err := pipeline.Explain(context.Background(), executor.ExecuteSingleRequest{
+		Call:        executor.ToolCall{Name: toolName, Input: executor.ExplainInput(toolName, arguments)},
+		SessionMode: string(mode),
+		ToolContext: executor.ToolContext{WorkingDir: ctx.WorkingDir},
+	})
+	if err != nil {
+		ctx.writeErr("error: explain permissions: %v\n", err)
 		return 1
 	}
-	ctx.writeOut("Removed approved tool: %s\n", toolName)
+
+	ruleSources := make([]map[string]string, 0, len(rules))
+	for idx, line := range lines {
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			ruleSources = append(ruleSources, map[string]string{"rule": trimmed, "source": sources[idx]})
+		}
+	}
+	if ctx.Args.Has("json") {
+		writeJSON(ctx.Stdout, map[string]any{
+			"explanation":      explanation,
+			"rule_sources":     ruleSources,
+			"rule_diagnostics": diagnostics,
+		})
+		return 0
+	}
+
+	ctx.writeOut("Tool: %s\n", explanation.ToolName)
+	ctx.writeOut("Mode: %s\n", explanation.Mode)
+	if explanation.DecidedBy != "" {
+		ctx.writeOut("Decision: %s (decided by %s)\n", explanation.Decision, explanation.DecidedBy)
+	} else {
+		ctx.writeOut("Decision: %s\n", explanation.Decision)
+	}
+	if explanation.Reason != "" {
+		ctx.writeOut("Reason: %s\n", explanation.Reason)
+	}
+	ctx.writeOut("Layers:\n")
+	for _, layer := range explanation.Layers {
+		if !layer.Configured {
+			ctx.writeOut("  %s: not configured\n", layer.Layer)
+			continue
+		}
+		ctx.writeOut("  %s: %s", layer.Layer, layer.Decision)
+		if layer.Reason != "" {
+			ctx.writeOut(" - %s", layer.Reason)
+		}
+		if layer.MatchedRule != "" {
+			ctx.writeOut(" [%s]", layer.MatchedRule)
+		}
+		ctx.writeOut("\n")
+		if matched, ok := layer.Trace["matched_rules"].([]map[string]any); ok {
+			for _, item := range matched {
+				ctx.writeOut("    matched: %v\n", item["rule"])
+			}
+		}
+	}
+	if len(ruleSources) > 0 {
+		ctx.writeOut("Rules:\n")
+		for _, item := range ruleSources {
+			ctx.writeOut("  %s (%s)\n", item["rule"], item["source"])
+		}
+	}
+	for _, item := range diagnostics {
+		ctx.writeOut("Rule diagnostic: %s\n", item.String())
+	}
+	ctx.writeOut("Precedence: %s\n", explanation.Precedence)
 	return 0
 }
 
+func settingsPermissionString(effective map[string]any, key string) string {
+	permissions, _ := effective["permissions"].(map[string]any)
+	value, _ := permissions[key].(string)
+	return strings.TrimSpace(value)
+}
+
+func parseExplainPermissionMode(raw string) (core.PermissionMode, bool) {
+	switch strings.TrimSpace(raw) {
+	case "", string(core.PermissionModeDefault):
+		return core.PermissionModeDefault, true
+	case string(core.PermissionModeAcceptEdits):
+		return core.PermissionModeAcceptEdits, true
+	case string(core.PermissionModePlan):
+		return core.PermissionModePlan, true
+	case string(core.PermissionModeDontAsk):
+		return core.PermissionModeDontAsk, true
+	case string(core.PermissionModeBypassPermissions):
+		return core.PermissionModeBypassPermissions, true
+	default:
+		return "", false
+	}
+}
+
+func handleHooksTest(ctx commandExecutionContext) int {
+	rawEvent, _ := ctx.Args.First("event")
+	eventName := hookEventName(rawEvent)
+	if eventName == "" {
+		ctx.writeErr("error: hooks test requires --event\n")
+		return 1
+	}
+	payload := map[string]any{}
+	if payloadPath, ok := ctx.Args.First("payload"); ok && payloadPath != "" {
+		resolved := payloadPath
+		if !filepath.IsAbs(resolved) {
+			resolved = filepath.Join(ctx.WorkingDir, resolved)
+		}
+		raw, err := os.ReadFile(resolved)
+		if err != nil {
+			ctx.writeErr("error: read payload: %v\n", err)
+			return 1
+		}
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			ctx.writeErr("error: payload must be a JSON object: %v\n", err)
+			return 1
+		}
+	}
+	if toolName, _ := ctx.Args.First("tool"); toolName != "" {
+		payload["tool_name"] = toolName
+	}
+	payload["project_id"] = ctx.WorkingDir
+	if _, ok := payload["cwd"]; !ok {
+		payload["cwd"] = ctx.WorkingDir
+	}
+
+	rules, err := hooks.LoadSettingsRules(ctx.WorkingDir)
+	if err != nil {
+		ctx.writeErr("error: load hooks: %v\n", err)
+		return 1
+	}
+	simulation, err := hooks.NewDispatcher(rules).Simulate(context.Background(), core.HookEvent{
+		Type:    eventName,
+		Payload: payload,
+	}, hooks.SimulateOptions{RunHandlers: ctx.Args.Has("run-handlers")})
+	if err != nil {
+		ctx.writeErr("error: simulate hooks: %v\n", err)
+		return 1
+	}
+	if ctx.Args.Has("json") {
+		writeJSON(ctx.Stdout, simulation)
+		return 0
+	}
+
+	ctx.writeOut("Event: %s\n", eventName)
+	if toolName, _ := payload["tool_name"].(string); toolName != "" {
+		ctx.writeOut("Tool: %s\n", toolName)
+	}
+	if simulation.MatchedRuleID != "" {
+		ctx.writeOut("Decision: %s (rule %s)\n", simulation.Decision, simulation.MatchedRuleID)
+	} else {
+		ctx.writeOut("Decision: %s (no rule matched)\n", simulation.Decision)
+	}
+	if reason, _ := simulation.Metadata["reason"].(string); reason != "" {
+		ctx.writeOut("Reason: %s\n", reason)
+	}
+	ctx.writeOut("Ranking:\n")
+	if len(simulation.Ranking) == 0 {
+		ctx.writeOut("  (none)\n")
+	}
+	for idx, item := range simulation.Ranking {
+		ctx.writeOut("  %d. %s: %s [%s]", idx+1, item.RuleID, item.Decision, strings.Join(item.ScopeTrace, " "))
+		if item.Handler != nil {
+			if handlerErr, _ := item.Handler["error"].(string); handlerErr != "" {
+				ctx.writeOut(" handler error: %s", handlerErr)
+			} else {
+				ctx.writeOut(" handler %vms", item.Handler["duration_ms"])
+			}
+		}
+		ctx.writeOut("\n")
+	}
+	for _, item := range simulation.Unmatched {
+		ctx.writeOut("Unmatched: %s (%s)\n", item.RuleID, item.Reason)
+	}
+	return 0
+}
+
+// hookEventName accepts PreToolUse or pre_tool_use spellings.
+func hookEventName(raw string) string {
+	trimmed := strings.TrimSpace(raw)
+	if !strings.Contains(trimmed, "_") {
+		return trimmed
+	}
+	var builder strings.Builder
+	for _, part := range strings.Split(trimmed, "_") {
+		if part == "" {
+			continue
+		}
+		builder.WriteString(strings.ToUpper(part[:1]))
+		builder.WriteString(strings.ToLower(part[1:]))
+	}
+	return builder.String()
+}
+
 func handleSessionStart(ctx commandExecutionContext) int {
 	runner := getCommandRuntimeRunner()
 	record, err := runner.StartSession(context.Background(), adapters.SessionStartRequest{
@@ -1854,10 +2199,17 @@ func handleRunControl(ctx commandExecutionContext) int {
 		return 1
 	}
 
+	remember, _ := ctx.Args.First("remember")
+	mode, _ := ctx.Args.First("mode")
+	feedback, _ := ctx.Args.First("feedback")
+
 	runner := getCommandRuntimeRunner()
 	if err := runner.ControlRun(context.Background(), adapters.RunControlRequest{
-		RunID:  strings.TrimSpace(runID),
-		Action: strings.TrimSpace(action),
+		RunID:    strings.TrimSpace(runID),
+		Action:   strings.TrimSpace(action),
+		Remember: remember,
+		Mode:     mode,
+		Feedback: feedback,
 	}); err != nil {
 		ctx.writeErr("error: run control failed: %v\n", err)
 		return 1
@@ -1923,11 +2275,72 @@ func persistSessionID(cwd string, sessionID string) error {
 }
 
 func handleMCPServe(ctx commandExecutionContext) int {
-	ctx.writeOut("Starting goyais MCP server (stdio)\n")
-	ctx.writeOut("MCP server is ready\n")
+	options, err := mcpserver.ProjectOptions(ctx.WorkingDir, "")
+	if err != nil {
+		ctx.writeErr("error: load mcp server settings: %v\n", err)
+		return 1
+	}
+	options.Version = strings.TrimSpace(os.Getenv("GOYAIS_VERSION"))
+	server, err := mcpserver.NewServer(options)
+	if err != nil {
+		ctx.writeErr("error: start mcp server: %v\n", err)
+		return 1
+	}
+	signalCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if addr, ok := ctx.Args.First("http"); ok && addr != "" {
+		// The HTTP transport runs tools for whoever can reach it, so other
+		// hosts are only served on request, and always need the token.
+		if !mcpserver.IsLoopbackAddr(addr) && !ctx.Args.Has("allow-remote") {
+			ctx.writeErr("error: %s is not a loopback address; pass --allow-remote to serve other hosts\n", addr)
+			return 1
+		}
+		token := strings.TrimSpace(os.Getenv("GOYAIS_MCP_SERVE_TOKEN"))
+		generated := token == ""
+		if generated {
+			token = mcpserver.NewBearerToken()
+		}
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			ctx.writeErr("error: listen on %s: %v\n", addr, err)
+			return 1
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/mcp", server.HTTPHandler(token))
+		httpServer := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			<-signalCtx.Done()
+			_ = httpServer.Close()
+		}()
+		ctx.writeOut("MCP server is listening on http://%s/mcp\n", listener.Addr())
+		if generated {
+			ctx.writeOut("Clients must send: Authorization: Bearer %s\n", token)
+		}
+		if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			ctx.writeErr("error: serve mcp: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	// Stdout carries the protocol, so nothing else may be written to it.
+	if err := server.ServeStdio(signalCtx, mcpServeInput(), ctx.Stdout); err != nil && !errors.Is(err, context.Canceled) {
+		ctx.writeErr("error: serve mcp: %v\n", err)
+		return 1
+	}
 	return 0
 }
 
+func mcpServeInput() io.Reader {
+	mcpServeInputMu.Lock()
+	defer mcpServeInputMu.Unlock()
+	if mcpServeInputOverride != nil {
+		return mcpServeInputOverride
+	}
+	return os.Stdin
+}
+
 func handleMCPAddSSE(ctx commandExecutionContext) int {
 	return handleMCPAddURLTransport(ctx, "sse")
 }
@@ -2489,34 +2902,11 @@ func handleMCPGet(ctx commandExecutionContext) int {
 		ctx.writeErr("error: load mcp servers: %v\n", err)
 		return 1
 	}
-	matches := make([]mcpServerRecord, 0, 2)
-	for _, record := range state.Servers {
-		if record.Name == name {
-			matches = append(matches, record)
-		}
-	}
-	if len(matches) == 0 {
+	record, ok := findMCPServerRecord(state, name)
+	if !ok {
 		ctx.writeErr("No MCP server found with name: %s\n", name)
 		return 1
 	}
-	sort.Slice(matches, func(i, j int) bool {
-		rank := func(scope string) int {
-			switch scope {
-			case "local":
-				return 0
-			case "user":
-				return 1
-			case "project":
-				return 2
-			case "mcprc":
-				return 3
-			default:
-				return 4
-			}
-		}
-		return rank(matches[i].Scope) < rank(matches[j].Scope)
-	})
-	record := matches[0]
 
 	ctx.writeOut("%s:\n", record.Name)
 	ctx.writeOut("  Status: disconnected\n")
@@ -2555,9 +2945,154 @@ func handleMCPGet(ctx commandExecutionContext) int {
 			}
 		}
 	}
+	if lines, err := mcpext.ReadServerLog(record.Name, mcpGetLogLines); err == nil && len(lines) > 0 {
+		ctx.writeOut("  Recent logs (%s):\n", mcpext.ServerLogPath(record.Name))
+		for _, line := range lines {
+			ctx.writeOut("    %s\n", line)
+		}
+	}
+	return 0
+}
+
+// findMCPServerRecord returns the record named name from the most specific
+// scope that defines it.
+func findMCPServerRecord(state mcpServerState, name string) (mcpServerRecord, bool) {
+	matches := make([]mcpServerRecord, 0, 2)
+	for _, record := range state.Servers {
+		if record.Name == name {
+			matches = append(matches, record)
+		}
+	}
+	if len(matches) == 0 {
+		return mcpServerRecord{}, false
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		rank := func(scope string) int {
+			switch scope {
+			case "local":
+				return 0
+			case "user":
+				return 1
+			case "project":
+				return 2
+			case "mcprc":
+				return 3
+			default:
+				return 4
+			}
+		}
+		return rank(matches[i].Scope) < rank(matches[j].Scope)
+	})
+	return matches[0], true
+}
+
+// loadRemoteMCPServer resolves name to the remote server `mcp login` and
+// `mcp logout` act on.
+func loadRemoteMCPServer(ctx commandExecutionContext) (mcpext.ServerConfig, bool) {
+	if len(ctx.Args.Positionals) < 1 {
+		ctx.writeErr("error: %s requires <name>\n", ctx.Path)
+		return mcpext.ServerConfig{}, false
+	}
+	name := ctx.Args.Positionals[0]
+	state, err := loadMCPState(ctx.WorkingDir)
+	if err != nil {
+		ctx.writeErr("error: load mcp servers: %v\n", err)
+		return mcpext.ServerConfig{}, false
+	}
+	record, ok := findMCPServerRecord(state, name)
+	if !ok {
+		ctx.writeErr("No MCP server found with name: %s\n", name)
+		return mcpext.ServerConfig{}, false
+	}
+	transport := ""
+	switch record.Type {
+	case "http":
+		transport = "http"
+	case "sse":
+		transport = "http_sse"
+	case "ws":
+		transport = "ws"
+	default:
+		ctx.writeErr("error: MCP server %s is not a remote http, sse or ws server\n", name)
+		return mcpext.ServerConfig{}, false
+	}
+	return mcpext.ServerConfig{Name: record.Name, Transport: transport, Endpoint: record.URL, Env: record.Headers}, true
+}
+
+// newMCPTokenStore opens the OAuth token file shared with the hub, which
+// encrypts it with the same resource secret key.
+func newMCPTokenStore() mcpext.TokenStore {
+	return mcpext.NewFileTokenStore(mcpext.DefaultTokenStorePath(), secrets.Cipher{})
+}
+
+func handleMCPLogin(ctx commandExecutionContext) int {
+	server, ok := loadRemoteMCPServer(ctx)
+	if !ok {
+		return 1
+	}
+	loginCtx, cancel := context.WithTimeout(context.Background(), mcpLoginTimeout)
+	defer cancel()
+	clientID, _ := ctx.Args.First("client-id")
+	login, err := mcpext.StartOAuthLogin(loginCtx, server, mcpext.OAuthLoginOptions{
+		Store:      newMCPTokenStore(),
+		ClientID:   clientID,
+		ClientName: "goyais-cli",
+	})
+	if err != nil {
+		ctx.writeErr("error: authorize MCP server %s: %v\n", server.Name, err)
+		return 1
+	}
+	defer login.Close()
+
+	ctx.writeOut("Open this URL to authorize %s:\n  %s\n", server.Name, login.AuthorizationURL)
+	browserOpenerMu.Lock()
+	open := browserOpener
+	browserOpenerMu.Unlock()
+	// The URL is printed, so a browser that fails to start is not fatal.
+	_ = open(login.AuthorizationURL)
+	ctx.writeOut("Waiting for authorization...\n")
+	credentials, err := login.Wait(loginCtx)
+	if err != nil {
+		ctx.writeErr("error: authorize MCP server %s: %v\n", server.Name, err)
+		return 1
+	}
+	ctx.writeOut("Authorized MCP server %s", server.Name)
+	if credentials.Token.Scope != "" {
+		ctx.writeOut(" (scope: %s)", credentials.Token.Scope)
+	}
+	ctx.writeOut("\n")
 	return 0
 }
 
+func handleMCPLogout(ctx commandExecutionContext) int {
+	server, ok := loadRemoteMCPServer(ctx)
+	if !ok {
+		return 1
+	}
+	store := newMCPTokenStore()
+	if _, exists, err := store.Load(server.Endpoint); err == nil && !exists {
+		ctx.writeOut("No stored credentials for MCP server %s\n", server.Name)
+		return 0
+	}
+	if err := store.Delete(server.Endpoint); err != nil {
+		ctx.writeErr("error: remove credentials for MCP server %s: %v\n", server.Name, err)
+		return 1
+	}
+	ctx.writeOut("Removed stored credentials for MCP server %s\n", server.Name)
+	return 0
+}
+
+func openInBrowser(target string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", target).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", target).Start()
+	default:
+		return exec.Command("xdg-open", target).Start()
+	}
+}
+
 func handleMCPAddFromClaudeDesktop(ctx commandExecutionContext) int {
 	scope, err := normalizeMCPScope(firstValueOrDefault(ctx.Args, "scope", "s", "project"), "project")
 	if err != nil {
@@ -2690,6 +3225,29 @@ func handleDoctor(ctx commandExecutionContext) int {
 		return 1
 	}
 	ctx.writeOut("- mcp_store_check: ok\n")
+	return reportSandboxDoctor(ctx)
+}
+
+func reportSandboxDoctor(ctx commandExecutionContext) int {
+	support := ossandbox.Probe()
+	ctx.writeOut("- sandbox_backend: %s\n", support.Summary())
+	config, err := ossandbox.LoadProjectConfig(ctx.WorkingDir, "")
+	if err != nil {
+		ctx.writeOut("- sandbox_config: failed (%v)\n", err)
+		return 1
+	}
+	if !config.Enabled {
+		ctx.writeOut("- sandbox_config: disabled\n")
+		return 0
+	}
+	ctx.writeOut("- sandbox_config: enabled (network=%s, writable_dirs=%d, private_tmp=%t)\n", config.Network, len(config.ResolveWritableDirs(ctx.WorkingDir, nil)), config.PrivateTmp)
+	if !support.Available {
+		if config.FailIfUnavailable {
+			ctx.writeOut("- sandbox_fallback: commands are refused (failIfUnavailable=true)\n")
+		} else {
+			ctx.writeOut("- sandbox_fallback: commands run unsandboxed\n")
+		}
+	}
 	return 0
 }
 
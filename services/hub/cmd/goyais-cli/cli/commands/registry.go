@@ -253,6 +253,77 @@ Options:
                                "X-Custom: value")
   -e, --env <env...>           Set environment variables (e.g. -e KEY=value)
   -h, --help                   display help for command
+`, true
+	case "mcp serve":
+		return `Usage: goyais-cli mcp serve [options]
+
+Serve Goyais tools, project skills and session transcripts over MCP. Tool
+calls go through the project's permission rules and sandbox; approvals are
+asked of the client with elicitation.
+
+HTTP clients must send "Authorization: Bearer <token>". The token is read
+from GOYAIS_MCP_SERVE_TOKEN, or generated and printed at startup.
+
+Options:
+  --http <addr>   Serve Streamable HTTP at http://<addr>/mcp instead of stdio
+  --allow-remote  Allow --http to listen on a non-loopback address
+  -h, --help      display help for command
+`, true
+	case "mcp login":
+		return `Usage: goyais-cli mcp login [options] <name>
+
+Authorize a remote MCP server with OAuth. Opens the authorization page in
+the browser and waits for the localhost callback.
+
+Options:
+  --client-id <id>  Use a pre-registered client instead of dynamic
+                    registration
+  -h, --help        display help for command
+`, true
+	case "approved-tools list":
+		return `Usage: goyais-cli approved-tools list [options]
+
+List approved tools as a JSON array
+
+Options:
+  --remembered        Print an object that also lists remembered "always
+                      allow" rules
+  -h, --help          display help for command
+`, true
+	case "approved-tools remove":
+		return `Usage: goyais-cli approved-tools remove [options] <tool|rule>
+
+Remove a legacy approved tool or revoke a remembered "always allow" rule
+
+Options:
+  --scope <scope>     Only revoke rules remembered for project or user
+  -h, --help          display help for command
+`, true
+	case "permissions explain":
+		return `Usage: goyais-cli permissions explain [options] <tool> [args...]
+
+Explain how the permission layers would decide a tool call without running it
+
+Options:
+  --mode <mode>    Permission mode (default, acceptEdits, plan, dontAsk,
+                   bypassPermissions); defaults to permissions.defaultMode
+  --rules <file>   Extra rules DSL file evaluated after settings rules
+  --json           Print the full trace as JSON
+  -h, --help       display help for command
+`, true
+	case "hooks test":
+		return `Usage: goyais-cli hooks test --event <event> [options]
+
+Simulate how the configured hooks would decide an event
+
+Options:
+  --event <event>     Hook event (PreToolUse, UserPromptSubmit, ...)
+  --tool <name>       Tool name matched against hook matchers
+  --payload <file>    JSON file merged into the event payload
+  --run-handlers      Live execution: really run matched command and HTTP
+                      handlers, with "dry_run": true in the payload
+  --json              Print the full simulation as JSON
+  -h, --help          display help for command
 `, true
 	default:
 		return "", false
@@ -357,7 +428,11 @@ var defaultCommandSpecs = []Spec{
 	{Path: []string{"skills", "list-installed"}, Declaration: "list-installed"},
 	{Path: []string{"approved-tools"}, Declaration: "approved-tools"},
 	{Path: []string{"approved-tools", "list"}, Declaration: "list"},
-	{Path: []string{"approved-tools", "remove"}, Declaration: "remove <tool>"},
+	{Path: []string{"approved-tools", "remove"}, Declaration: "remove <tool|rule>"},
+	{Path: []string{"permissions"}, Declaration: "permissions"},
+	{Path: []string{"permissions", "explain"}, Declaration: "explain <tool> [args...]"},
+	{Path: []string{"hooks"}, Declaration: "hooks"},
+	{Path: []string{"hooks", "test"}, Declaration: "test"},
 	{Path: []string{"session"}, Declaration: "session"},
 	{Path: []string{"session", "start"}, Declaration: "start"},
 	{Path: []string{"session", "list"}, Declaration: "list"},
@@ -376,6 +451,8 @@ var defaultCommandSpecs = []Spec{
 	{Path: []string{"mcp", "list"}, Declaration: "list"},
 	{Path: []string{"mcp", "add-json"}, Declaration: "add-json <name> <json>"},
 	{Path: []string{"mcp", "get"}, Declaration: "get <name>"},
+	{Path: []string{"mcp", "login"}, Declaration: "login <name>"},
+	{Path: []string{"mcp", "logout"}, Declaration: "logout <name>"},
 	{Path: []string{"mcp", "add-from-claude-desktop"}, Declaration: "add-from-claude-desktop"},
 	{Path: []string{"mcp", "reset-project-choices"}, Declaration: "reset-project-choices"},
 	{Path: []string{"mcp", "reset-mcprc-choices"}, Declaration: "reset-mcprc-choices"},
@@ -41,6 +41,13 @@ func TestCommandsBehavior_AllLeafHandlersExecute(t *testing.T) {
 	t.Setenv("GOYAIS_AGENT_MODEL_ENDPOINT", modelServer.URL)
 	t.Setenv("GOYAIS_AGENT_MODEL_NAME", "gpt-test")
 	t.Setenv("GOYAIS_AGENT_MODEL_API_KEY", "test-key")
+	t.Setenv("GOYAIS_MCP_OAUTH_FILE", filepath.Join(t.TempDir(), "mcp-oauth.json"))
+	oauthServer := newOAuthStandInServer(t)
+	defer commands.SetBrowserOpenerForTests(followAuthorizationURL)()
+	defer commands.SetMCPServeInputForTests(strings.NewReader(
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{}}}` + "\n" +
+			`{"jsonrpc":"2.0","id":2,"method":"tools/list"}` + "\n",
+	))()
 
 	testCases := []commandSuccessCase{
 		{path: "config set", args: []string{"config", "set", "alpha", "beta", "--cwd", workdir}, expectStdoutSub: "Set alpha to beta"},
@@ -75,6 +82,8 @@ func TestCommandsBehavior_AllLeafHandlersExecute(t *testing.T) {
 
 		{path: "approved-tools list", args: []string{"approved-tools", "list", "--cwd", workdir}, expectStdoutSub: "Bash"},
 		{path: "approved-tools remove", args: []string{"approved-tools", "remove", "Bash", "--cwd", workdir}, expectStdoutSub: "Removed approved tool: Bash"},
+		{path: "hooks test", args: []string{"hooks", "test", "--event", "PreToolUse", "--tool", "Bash", "--cwd", workdir}, expectStdoutSub: "Decision: allow (no rule matched)"},
+		{path: "permissions explain", args: []string{"permissions", "explain", "Bash", "--cwd", workdir, "--mode", "plan", "--", "git", "status"}, expectStdoutSub: "Decision: deny (decided by permission)"},
 		{path: "session start", args: []string{"session", "start", "--cwd", workdir}, expectStdoutSub: "session_id: sess_1"},
 		{path: "session list", args: []string{"session", "list", "--cwd", workdir}, expectStdoutSub: "sess_1"},
 		{path: "session get", args: []string{"session", "get", "sess_1", "--cwd", workdir}, expectStdoutSub: "Session: sess_1"},
@@ -90,8 +99,11 @@ func TestCommandsBehavior_AllLeafHandlersExecute(t *testing.T) {
 		{path: "mcp add-json", args: []string{"mcp", "add-json", "json-main", `{"type":"stdio","command":"echo","args":["hi"]}`, "--cwd", workdir, "--scope", "project"}, expectStdoutSub: "Added stdio MCP server json-main"},
 		{path: "mcp list", args: []string{"mcp", "list", "--cwd", workdir}, expectStdoutSub: "json-main"},
 		{path: "mcp get", args: []string{"mcp", "get", "json-main", "--cwd", workdir}, expectStdoutSub: "Type: stdio"},
+		{path: "mcp add-http", args: []string{"mcp", "add-http", "oauth-main", oauthServer.URL + "/mcp", "--cwd", workdir, "--scope", "local"}, expectStdoutSub: "Added HTTP MCP server oauth-main"},
+		{path: "mcp login", args: []string{"mcp", "login", "oauth-main", "--cwd", workdir}, expectStdoutSub: "Authorized MCP server oauth-main"},
+		{path: "mcp logout", args: []string{"mcp", "logout", "oauth-main", "--cwd", workdir}, expectStdoutSub: "Removed stored credentials for MCP server oauth-main"},
 		{path: "mcp remove", args: []string{"mcp", "remove", "url-main", "--cwd", workdir, "--scope", "local"}, expectStdoutSub: "Removed MCP server url-main"},
-		{path: "mcp serve", args: []string{"mcp", "serve", "--cwd", workdir}, expectStdoutSub: "MCP server is ready"},
+		{path: "mcp serve", args: []string{"mcp", "serve", "--cwd", workdir}, expectStdoutSub: `"name":"goyais"`},
 		{path: "mcp add-from-claude-desktop", args: []string{"mcp", "add-from-claude-desktop", "--cwd", workdir, "--scope", "project"}, expectStdoutSub: "Successfully imported"},
 		{path: "mcp reset-project-choices", args: []string{"mcp", "reset-project-choices", "--cwd", workdir}, expectStdoutSub: "have been reset"},
 		{path: "mcp reset-mcprc-choices", args: []string{"mcp", "reset-mcprc-choices", "--cwd", workdir}, expectStdoutSub: "have been reset"},
@@ -154,11 +166,14 @@ func TestCommandsBehavior_FailureSemanticsByFamily(t *testing.T) {
 		{name: "plugin invalid scope", args: []string{"plugin", "install", "pack@default", "--cwd", workdir, "--scope", "bad"}, expectStderrSub: "invalid scope"},
 		{name: "skills missing install", args: []string{"skills", "uninstall", "pack@default", "--cwd", workdir}, expectStderrSub: "not installed"},
 		{name: "approved tool missing", args: []string{"approved-tools", "remove", "NOT_FOUND", "--cwd", workdir}, expectStderrSub: ""},
+		{name: "hooks test missing event", args: []string{"hooks", "test", "--cwd", workdir}, expectStderrSub: "requires --event"},
+		{name: "permissions explain invalid mode", args: []string{"permissions", "explain", "Bash", "--cwd", workdir, "--mode", "yolo"}, expectStderrSub: "invalid mode"},
 		{name: "session get missing", args: []string{"session", "get"}, expectStderrSub: "missing required arguments"},
 		{name: "run submit missing session", args: []string{"run", "submit", "--prompt", "hello", "--cwd", workdir}, expectStderrSub: "--session is required"},
 		{name: "run control invalid action", args: []string{"run", "control", "--run", "run_1", "--action", "ship", "--cwd", workdir}, expectStderrSub: "invalid action"},
 		{name: "run stream missing session", args: []string{"run", "stream", "--cwd", workdir}, expectStderrSub: "--session is required"},
 		{name: "mcp unknown server", args: []string{"mcp", "get", "missing", "--cwd", workdir}, expectStderrSub: "No MCP server found"},
+		{name: "mcp serve remote address", args: []string{"mcp", "serve", "--http", "0.0.0.0:0", "--cwd", workdir}, expectStderrSub: "pass --allow-remote"},
 		{name: "log invalid index", args: []string{"log", "not-a-number", "--cwd", workdir}, expectStderrSub: "invalid log index"},
 		{name: "resume no sessions", args: []string{"resume", "--cwd", t.TempDir()}, expectStderrSub: "No conversation found to resume"},
 		{name: "error invalid index", args: []string{"error", "not-a-number", "--cwd", workdir}, expectStderrSub: "invalid error log index"},
@@ -235,6 +250,146 @@ func TestCommandsBehavior_MCPAddInteractiveWizard(t *testing.T) {
 	}
 }
 
+func TestCommandsBehavior_PermissionsExplainUsesSettingsRules(t *testing.T) {
+	workdir := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
+	commands.ResetRuntimeForTests()
+	mustWriteJSONFile(t, filepath.Join(workdir, ".goyais", "settings.json"), map[string]any{
+		"permissions": map[string]any{
+			"allow":       []string{"Bash(git status)"},
+			"deny":        []string{"Bash(git push:*)"},
+			"defaultMode": "acceptEdits",
+		},
+	})
+
+	stdout, stderr, handled, exitCode := dispatchCommand(t, []string{"permissions", "explain", "Bash", "git", "status", "--cwd", workdir, "--json"})
+	if !handled || exitCode != 0 {
+		t.Fatalf("expected permissions explain success, got handled=%v exit=%d stderr=%q", handled, exitCode, stderr)
+	}
+	var payload struct {
+		Explanation struct {
+			Mode     string `json:"mode"`
+			Decision string `json:"decision"`
+			Layers   []struct {
+				Layer       string `json:"layer"`
+				MatchedRule string `json:"matched_rule"`
+			} `json:"layers"`
+		} `json:"explanation"`
+		RuleSources []map[string]string `json:"rule_sources"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &payload); err != nil {
+		t.Fatalf("decode explain output: %v, stdout=%q", err, stdout)
+	}
+	if payload.Explanation.Mode != "acceptEdits" || payload.Explanation.Decision != "allow" {
+		t.Fatalf("expected settings mode and allow rule to apply, got %+v", payload.Explanation)
+	}
+	if len(payload.Explanation.Layers) != 3 || payload.Explanation.Layers[2].MatchedRule != "allow Bash(git status)" {
+		t.Fatalf("expected permission layer to report the matched settings rule, got %+v", payload.Explanation.Layers)
+	}
+	if len(payload.RuleSources) != 2 || payload.RuleSources[0]["source"] != "settings:project" {
+		t.Fatalf("expected rule sources to name the settings layer, got %+v", payload.RuleSources)
+	}
+
+	stdout, _, _, exitCode = dispatchCommand(t, []string{"permissions", "explain", "Bash", "--cwd", workdir, "--", "git", "push", "origin"})
+	if exitCode != 0 || !strings.Contains(stdout, "Decision: deny") || !strings.Contains(stdout, "matched: deny Bash(git push:*)") {
+		t.Fatalf("expected deny rule trace, got exit=%d stdout=%q", exitCode, stdout)
+	}
+}
+
+func TestCommandsBehavior_HooksTestRanksSettingsHooks(t *testing.T) {
+	workdir := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
+	commands.ResetRuntimeForTests()
+	mustWriteJSONFile(t, filepath.Join(workdir, ".goyais", "settings.json"), map[string]any{
+		"hooks": map[string]any{
+			"PreToolUse": []any{
+				map[string]any{"matcher": "Bash", "decision": "deny", "reason": "no shell in review"},
+				map[string]any{"matcher": "Write|Edit", "decision": "ask"},
+			},
+		},
+	})
+	mustWriteJSONFile(t, filepath.Join(workdir, "payload.json"), map[string]any{
+		"tool_input": map[string]any{"command": "rm -rf build"},
+	})
+
+	stdout, stderr, handled, exitCode := dispatchCommand(t, []string{"hooks", "test", "--event", "pre_tool_use", "--tool", "Bash", "--payload", "payload.json", "--cwd", workdir})
+	if !handled || exitCode != 0 {
+		t.Fatalf("expected hooks test success, got handled=%v exit=%d stderr=%q", handled, exitCode, stderr)
+	}
+	for _, want := range []string{"Event: PreToolUse", "Decision: deny (rule project:PreToolUse:0)", "Reason: no shell in review", "Unmatched: project:PreToolUse:1 (tool)"} {
+		if !strings.Contains(stdout, want) {
+			t.Fatalf("expected %q in hooks test output, got %q", want, stdout)
+		}
+	}
+
+	stdout, _, _, exitCode = dispatchCommand(t, []string{"hooks", "test", "--event", "PreToolUse", "--tool", "Edit", "--cwd", workdir, "--json"})
+	var simulation struct {
+		Decision string `json:"decision"`
+		Ranking  []struct {
+			RuleID string `json:"rule_id"`
+		} `json:"ranking"`
+	}
+	if exitCode != 0 {
+		t.Fatalf("expected json hooks test success, got exit=%d", exitCode)
+	}
+	if err := json.Unmarshal([]byte(stdout), &simulation); err != nil {
+		t.Fatalf("decode hooks test output: %v, stdout=%q", err, stdout)
+	}
+	if simulation.Decision != "ask" || len(simulation.Ranking) != 1 || simulation.Ranking[0].RuleID != "project:PreToolUse:1" {
+		t.Fatalf("expected regex matcher rule to decide, got %+v", simulation)
+	}
+}
+
+func TestCommandsBehavior_ApprovedToolsListsAndRevokesRememberedRules(t *testing.T) {
+	workdir := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
+	commands.ResetRuntimeForTests()
+	mustWriteJSONFile(t, filepath.Join(workdir, ".goyais", "settings.json"), map[string]any{
+		"permissions": map[string]any{"allow": []string{"Bash(go test:*)"}},
+	})
+
+	stdout, stderr, _, exitCode := dispatchCommand(t, []string{"approved-tools", "list", "--cwd", workdir})
+	if exitCode != 0 {
+		t.Fatalf("expected approved-tools list success, got exit=%d stderr=%q", exitCode, stderr)
+	}
+	var plain []string
+	if err := json.Unmarshal([]byte(stdout), &plain); err != nil {
+		t.Fatalf("expected approved-tools list to print an array, got %v, stdout=%q", err, stdout)
+	}
+
+	stdout, stderr, _, exitCode = dispatchCommand(t, []string{"approved-tools", "list", "--remembered", "--cwd", workdir})
+	if exitCode != 0 {
+		t.Fatalf("expected approved-tools list --remembered success, got exit=%d stderr=%q", exitCode, stderr)
+	}
+	var listed struct {
+		ApprovedTools []string `json:"approved_tools"`
+		Remembered    []struct {
+			Scope     string `json:"scope"`
+			Rule      string `json:"rule"`
+			SessionID string `json:"session_id"`
+		} `json:"remembered"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &listed); err != nil {
+		t.Fatalf("decode approved-tools list output: %v, stdout=%q", err, stdout)
+	}
+	if len(listed.Remembered) != 1 || listed.Remembered[0].Scope != "project" {
+		t.Fatalf("expected the project remembered rule, got %+v", listed.Remembered)
+	}
+
+	_, stderr, _, exitCode = dispatchCommand(t, []string{"approved-tools", "remove", "Bash(npm run:*)", "--cwd", workdir, "--scope", "session"})
+	if exitCode == 0 || !strings.Contains(stderr, "session approvals are kept by the running session") {
+		t.Fatalf("expected session revoke to be refused outside the session, got exit=%d stderr=%q", exitCode, stderr)
+	}
+	stdout, _, _, exitCode = dispatchCommand(t, []string{"approved-tools", "remove", "Bash(go test:*)", "--cwd", workdir})
+	if exitCode != 0 || !strings.Contains(stdout, "Revoked project approval: Bash(go test:*)") {
+		t.Fatalf("expected project rule revoke without scope, got exit=%d stdout=%q", exitCode, stdout)
+	}
+	raw, err := os.ReadFile(filepath.Join(workdir, ".goyais", "settings.json"))
+	if err != nil || strings.Contains(string(raw), "go test") {
+		t.Fatalf("expected project rule removed from settings, got %q err=%v", raw, err)
+	}
+}
+
 func dispatchCommand(t *testing.T, args []string) (stdout string, stderr string, handled bool, exitCode int) {
 	t.Helper()
 	var out bytes.Buffer
@@ -337,3 +492,69 @@ func mustWriteJSONFile(t *testing.T, path string, value any) {
 		t.Fatalf("write fixture file: %v", err)
 	}
 }
+
+func TestCommandsBehavior_MCPGetShowsServerLog(t *testing.T) {
+	workdir := t.TempDir()
+	logDir := t.TempDir()
+	t.Setenv("GOYAIS_MCP_LOG_DIR", logDir)
+	commands.ResetRuntimeForTests()
+
+	if _, stderr, _, exitCode := dispatchCommand(t, []string{"mcp", "add-http", "docs", "https://example.com/mcp", "--cwd", workdir, "--scope", "local"}); exitCode != 0 {
+		t.Fatalf("expected mcp add-http success, got exit=%d stderr=%q", exitCode, stderr)
+	}
+	logLine := "2026-03-05T12:00:00Z [warning] indexer: cache miss"
+	if err := os.WriteFile(filepath.Join(logDir, "docs.log"), []byte(logLine+"\n"), 0o644); err != nil {
+		t.Fatalf("write server log fixture: %v", err)
+	}
+
+	stdout, stderr, handled, exitCode := dispatchCommand(t, []string{"mcp", "get", "docs", "--cwd", workdir})
+	if !handled || exitCode != 0 {
+		t.Fatalf("expected mcp get success, got handled=%v exit=%d stderr=%q", handled, exitCode, stderr)
+	}
+	if !strings.Contains(stdout, "Recent logs") || !strings.Contains(stdout, logLine) {
+		t.Fatalf("expected server log in mcp get output, got %q", stdout)
+	}
+}
+
+// newOAuthStandInServer serves protected-resource and authorization-server
+// metadata, client registration and an authorize endpoint that approves at
+// once.
+func newOAuthStandInServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/.well-known/oauth-protected-resource/mcp":
+			_ = json.NewEncoder(w).Encode(map[string]any{"resource": server.URL + "/mcp", "authorization_servers": []string{server.URL}})
+		case "/.well-known/oauth-authorization-server":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"issuer":                 server.URL,
+				"authorization_endpoint": server.URL + "/authorize",
+				"token_endpoint":         server.URL + "/token",
+				"registration_endpoint":  server.URL + "/register",
+			})
+		case "/register":
+			_ = json.NewEncoder(w).Encode(map[string]any{"client_id": "cli-client"})
+		case "/authorize":
+			query := r.URL.Query()
+			http.Redirect(w, r, query.Get("redirect_uri")+"?code=code-1&state="+query.Get("state"), http.StatusFound)
+		case "/token":
+			_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "cli-token", "refresh_token": "cli-refresh", "token_type": "Bearer", "scope": "tools"})
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// followAuthorizationURL stands in for the browser during `mcp login`.
+func followAuthorizationURL(target string) error {
+	go func() {
+		if res, err := http.Get(target); err == nil {
+			_ = res.Body.Close()
+		}
+	}()
+	return nil
+}
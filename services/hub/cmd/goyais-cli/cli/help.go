@@ -42,7 +42,7 @@ Options:
   --system-prompt <prompt>                          System prompt to use for the session
   --append-system-prompt <prompt>                   Append a system prompt to the default system prompt
   --permission-mode <mode>                          Permission mode to use for the session (choices: "acceptEdits", "bypassPermissions", "default", "delegate", "dontAsk", "plan")
-  --permission-prompt-tool <tool>                   Permission prompt tool (only works with --print, --output-format=stream-json, and --input-format=stream-json): "stdio"
+  --permission-prompt-tool <tool>                   Permission prompt tool (only works with --print): an MCP tool mcp__<server>__<tool> that answers approvals, or "stdio" (requires --output-format=stream-json and --input-format=stream-json)
   --safe                                            Enable strict permission checking mode (default is permissive)
   --disable-slash-commands                          Disable slash commands (treat /... as plain text)
   --plugin-dir <paths...>                           Load plugins from directories for this session only (repeatable) (default: [])
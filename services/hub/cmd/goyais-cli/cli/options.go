@@ -596,13 +596,15 @@ func validateOptionCombinations(opts Options) error {
 	}
 
 	permissionPromptTool := strings.TrimSpace(opts.PermissionPromptTool)
-	if permissionPromptTool != "" {
-		if permissionPromptTool != "stdio" {
+	if permissionPromptTool != "" && permissionPromptTool != "stdio" {
+		if !isMCPPermissionPromptTool(permissionPromptTool) {
 			return fmt.Errorf(
-				`Error: Unsupported --permission-prompt-tool %q. Only "stdio" is supported in goyais-cli right now.`,
+				`Error: Unsupported --permission-prompt-tool %q. Expected "stdio" or an MCP tool named mcp__<server>__<tool>.`,
 				permissionPromptTool,
 			)
 		}
+	}
+	if permissionPromptTool == "stdio" {
 		if inputFormat != "stream-json" {
 			return errors.New("Error: --permission-prompt-tool=stdio requires --input-format=stream-json")
 		}
@@ -637,3 +639,10 @@ func validateOptionCombinations(opts Options) error {
 
 	return nil
 }
+
+// isMCPPermissionPromptTool reports whether value names a qualified MCP tool
+// (mcp__<server>__<tool>) that can answer permission prompts.
+func isMCPPermissionPromptTool(value string) bool {
+	parts := strings.SplitN(strings.TrimSpace(value), "__", 3)
+	return len(parts) == 3 && strings.EqualFold(parts[0], "mcp") && strings.TrimSpace(parts[1]) != "" && strings.TrimSpace(parts[2]) != ""
+}
@@ -18,3 +18,19 @@ func TestParseOptionsUnknownFlagStillFails(t *testing.T) {
 		t.Fatal("expected unknown option to fail")
 	}
 }
+
+func TestValidateOptionsPermissionPromptTool(t *testing.T) {
+	opts, err := ParseOptions([]string{"--print", "--permission-prompt-tool", "mcp__approver__approve", "hello"})
+	if err != nil {
+		t.Fatalf("expected MCP permission prompt tool to be accepted, got error: %v", err)
+	}
+	if opts.PermissionPromptTool != "mcp__approver__approve" {
+		t.Fatalf("expected permission prompt tool to be parsed, got %#v", opts)
+	}
+	if _, err := ParseOptions([]string{"--print", "--permission-prompt-tool", "approver", "hello"}); err == nil {
+		t.Fatal("expected unqualified permission prompt tool to fail")
+	}
+	if _, err := ParseOptions([]string{"--print", "--permission-prompt-tool", "stdio", "hello"}); err == nil {
+		t.Fatal("expected stdio permission prompt tool without stream-json to fail")
+	}
+}
@@ -8,11 +8,19 @@ import (
 	"goyais/services/hub/cmd/goyais-cli/adapters"
 	"goyais/services/hub/cmd/goyais-cli/cli"
 	"goyais/services/hub/cmd/goyais-cli/tui"
+	mcpext "goyais/services/hub/internal/agent/extensions/mcp"
+	"goyais/services/hub/internal/agent/tools/ossandbox"
+	"goyais/services/hub/internal/runtime/infra/secrets"
 )
 
 var version = "dev"
 
 func main() {
+	ossandbox.RunInitIfRequested()
+	// Remote MCP servers authorized with `mcp login` send the stored token.
+	// The CLI runs for one user, so every session shares the unscoped tokens.
+	mcpext.DefaultSessionPool().SetTokenSources(mcpext.SharedOAuthTokens(mcpext.NewFileTokenStore(mcpext.DefaultTokenStorePath(), secrets.Cipher{}), nil))
+
 	runner := adapters.NewSessionRunRunner(os.Stdout, os.Stderr)
 
 	shell := tui.Shell{
@@ -31,7 +39,12 @@ func main() {
 		Env:               envMap(os.Environ()),
 	})
 
-	os.Exit(app.Run(context.Background(), os.Args[1:]))
+	code := app.Run(context.Background(), os.Args[1:])
+	// End the sessions so their session-scoped approvals go with them, and
+	// stop pooled stdio MCP servers before exit instead of orphaning them.
+	runner.Close(context.Background())
+	mcpext.DefaultSessionPool().Close()
+	os.Exit(code)
 }
 
 type interactiveShellRunner struct {
@@ -1,14 +1,26 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	mcpext "goyais/services/hub/internal/agent/extensions/mcp"
+	"goyais/services/hub/internal/agent/tools/ossandbox"
 	"goyais/services/hub/internal/httpapi"
 )
 
+// shutdownTimeout bounds how long in-flight requests may finish after a
+// stop signal.
+const shutdownTimeout = 10 * time.Second
+
 func main() {
+	ossandbox.RunInitIfRequested()
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8787"
@@ -20,8 +32,25 @@ func main() {
 		Handler: httpapi.NewRouterFromEnv(),
 	}
 
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("hub shutdown: %v", err)
+		}
+	}()
+
 	log.Printf("hub listening on %s", addr)
 	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatalf("hub server failed: %v", err)
 	}
+	<-stopped
+	// Pooled stdio MCP servers are children of the hub; stop them rather
+	// than leave them running after it exits.
+	mcpext.DefaultSessionPool().Close()
 }
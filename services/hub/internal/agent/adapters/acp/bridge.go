@@ -404,11 +404,15 @@ func payloadToMap(payload core.EventPayload) (map[string]any, error) {
 		}
 		return out, nil
 	case core.ApprovalNeededPayload:
-		return map[string]any{
+		out := map[string]any{
 			"tool_name":  strings.TrimSpace(typed.ToolName),
 			"input":      cloneMapAny(typed.Input),
 			"risk_level": strings.TrimSpace(typed.RiskLevel),
-		}, nil
+		}
+		if typed.Plan != nil {
+			out["plan"] = core.PlanRecordMap(*typed.Plan)
+		}
+		return out, nil
 	case core.RunCompletedPayload:
 		return map[string]any{"usage_tokens": typed.UsageTokens}, nil
 	case core.RunFailedPayload:
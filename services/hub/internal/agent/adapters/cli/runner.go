@@ -141,7 +141,7 @@ func (r Runner) RunPrompt(ctx context.Context, req RunRequest) (RunResult, error
 			if writeErr := r.writeFrame(frame); writeErr != nil {
 				return RunResult{}, writeErr
 			}
-			if delta, ok := frame.Payload["delta"].(string); ok {
+			if delta, ok := frame.Payload["delta"].(string); ok && frame.Payload["stage"] == nil {
 				outputChunks = append(outputChunks, delta)
 			}
 			if isTerminal(event.Type) {
@@ -318,11 +318,15 @@ func payloadToMap(payload core.EventPayload) (map[string]any, error) {
 		}
 		return out, nil
 	case core.ApprovalNeededPayload:
-		return map[string]any{
+		out := map[string]any{
 			"tool_name":  strings.TrimSpace(typed.ToolName),
 			"input":      cloneMapAny(typed.Input),
 			"risk_level": strings.TrimSpace(typed.RiskLevel),
-		}, nil
+		}
+		if typed.Plan != nil {
+			out["plan"] = core.PlanRecordMap(*typed.Plan)
+		}
+		return out, nil
 	case core.RunCompletedPayload:
 		return map[string]any{"usage_tokens": typed.UsageTokens}, nil
 	case core.RunFailedPayload:
@@ -47,12 +47,13 @@ func cloneMCPServers(input []core.MCPServerConfig) []core.MCPServerConfig {
 	out := make([]core.MCPServerConfig, 0, len(input))
 	for _, item := range input {
 		out = append(out, core.MCPServerConfig{
-			Name:      strings.TrimSpace(item.Name),
-			Transport: strings.TrimSpace(item.Transport),
-			Endpoint:  strings.TrimSpace(item.Endpoint),
-			Command:   strings.TrimSpace(item.Command),
-			Env:       cloneStringMap(item.Env),
-			Tools:     append([]string{}, item.Tools...),
+			Name:            strings.TrimSpace(item.Name),
+			Transport:       strings.TrimSpace(item.Transport),
+			Endpoint:        strings.TrimSpace(item.Endpoint),
+			Command:         strings.TrimSpace(item.Command),
+			Env:             cloneStringMap(item.Env),
+			Tools:           append([]string{}, item.Tools...),
+			CredentialScope: strings.TrimSpace(item.CredentialScope),
 		})
 	}
 	return out
@@ -134,9 +134,14 @@ type SubmitResponse struct {
 
 // ControlRequest is the transport-facing run-control input.
 type ControlRequest struct {
-	RunID  string
-	Action string
-	Answer *ControlAnswer
+	RunID    string
+	Action   string
+	Answer   *ControlAnswer
+	Remember string
+	// Mode is the permission mode to continue in after approving a plan.
+	Mode string
+	// Feedback is passed to the model when a tool call or plan is denied.
+	Feedback string
 }
 
 // ControlAnswer is the transport-facing answer payload for action=answer.
@@ -224,6 +229,10 @@ func (s *Service) Control(ctx context.Context, req ControlRequest) error {
 	if err != nil {
 		return err
 	}
+	remember, err := core.ParseApprovalScope(req.Remember)
+	if err != nil {
+		return err
+	}
 	var answer *core.ControlAnswer
 	if req.Answer != nil {
 		answer = &core.ControlAnswer{
@@ -233,9 +242,12 @@ func (s *Service) Control(ctx context.Context, req ControlRequest) error {
 		}
 	}
 	return s.engine.Control(ctx, core.ControlRequest{
-		RunID:  strings.TrimSpace(req.RunID),
-		Action: action,
-		Answer: answer,
+		RunID:    strings.TrimSpace(req.RunID),
+		Action:   action,
+		Answer:   answer,
+		Remember: remember,
+		Mode:     core.PermissionMode(strings.TrimSpace(req.Mode)),
+		Feedback: strings.TrimSpace(req.Feedback),
 	})
 }
 
@@ -512,11 +524,15 @@ func payloadToMap(payload core.EventPayload) (map[string]any, error) {
 		}
 		return out, nil
 	case core.ApprovalNeededPayload:
-		return map[string]any{
+		out := map[string]any{
 			"tool_name":  strings.TrimSpace(typed.ToolName),
 			"input":      cloneMapAny(typed.Input),
 			"risk_level": strings.TrimSpace(typed.RiskLevel),
-		}, nil
+		}
+		if typed.Plan != nil {
+			out["plan"] = core.PlanRecordMap(*typed.Plan)
+		}
+		return out, nil
 	case core.RunCompletedPayload:
 		return map[string]any{"usage_tokens": typed.UsageTokens}, nil
 	case core.RunFailedPayload:
@@ -0,0 +1,84 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcpserver
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"goyais/services/hub/internal/agent/context/settings"
+	"goyais/services/hub/internal/agent/extensions/subagents"
+	"goyais/services/hub/internal/agent/policy"
+	"goyais/services/hub/internal/agent/policy/redaction"
+	sandboxpolicy "goyais/services/hub/internal/agent/policy/sandbox"
+	"goyais/services/hub/internal/agent/tools/executor"
+	"goyais/services/hub/internal/agent/tools/ossandbox"
+	runnertools "goyais/services/hub/internal/agent/tools/runner"
+)
+
+// ProjectOptions loads the governance a session in workingDir would get:
+// permission rules and default mode from settings, the sandbox policy, the
+// OS sandbox and secret redaction.
+func ProjectOptions(workingDir string, homeDir string) (ServerOptions, error) {
+	if strings.TrimSpace(homeDir) == "" {
+		if resolved, err := os.UserHomeDir(); err == nil {
+			homeDir = resolved
+		}
+	}
+	merged, err := settings.LoadAndMerge(settings.LoadOptions{WorkingDir: workingDir, HomeDir: homeDir})
+	if err != nil {
+		return ServerOptions{}, err
+	}
+	lines, _ := settings.PermissionRuleLines(merged)
+	gate, err := policy.NewGateFromLines(lines)
+	if err != nil {
+		return ServerOptions{}, err
+	}
+	permissions, _ := merged.Effective["permissions"].(map[string]any)
+	mode, _ := permissions["defaultMode"].(string)
+
+	toolRunner := runnertools.New(nil)
+	sandboxConfig, err := ossandbox.LoadProjectConfig(workingDir, homeDir)
+	if err != nil {
+		return ServerOptions{}, err
+	}
+	toolRunner.SetOSSandbox(sandboxConfig)
+	redactionConfig, err := redaction.LoadProjectConfig(workingDir, homeDir)
+	if err != nil {
+		return ServerOptions{}, err
+	}
+
+	return ServerOptions{
+		WorkingDir:     workingDir,
+		HomeDir:        homeDir,
+		PermissionMode: mode,
+		Executor: executor.Dependencies{
+			Runner:         toolRunner,
+			SandboxGate:    executor.NewSandboxGateFromEvaluator(sandboxpolicy.NewEvaluator(nil)),
+			PermissionGate: gate,
+			OutputRedactor: outputRedactor{redactor: redaction.New(redactionConfig)},
+		},
+		Transcripts: DirTranscripts{Root: subagents.TranscriptRoot(homeDir, workingDir)},
+	}, nil
+}
+
+// outputRedactor masks secrets in tool output before it leaves the process.
+type outputRedactor struct {
+	redactor *redaction.Redactor
+}
+
+func (r outputRedactor) RedactToolOutput(_ context.Context, call executor.ToolCall, output map[string]any) map[string]any {
+	if r.redactor == nil || len(output) == 0 {
+		return output
+	}
+	path, _ := call.Input["path"].(string)
+	redacted, detections := r.redactor.RedactFileValue(path, output, "tool:"+strings.TrimSpace(call.Name))
+	if len(detections) == 0 {
+		return output
+	}
+	redactedOutput, _ := redacted.(map[string]any)
+	return redactedOutput
+}
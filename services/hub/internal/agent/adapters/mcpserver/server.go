@@ -0,0 +1,418 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+// Package mcpserver exposes Goyais as an MCP server: built-in tools run
+// through the governed executor pipeline, project skills are offered as
+// prompts and session transcripts as resources.
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"goyais/services/hub/internal/agent/adapters/acp"
+	"goyais/services/hub/internal/agent/core"
+	"goyais/services/hub/internal/agent/extensions/skills"
+	"goyais/services/hub/internal/agent/tools/catalog"
+	"goyais/services/hub/internal/agent/tools/executor"
+	"goyais/services/hub/internal/agent/tools/registry"
+	"goyais/services/hub/internal/agent/tools/spec"
+)
+
+const (
+	// ServerName is reported as serverInfo.name during initialize.
+	ServerName = "goyais"
+
+	latestProtocolVersion = "2025-06-18"
+
+	// approvalTimeout bounds how long a tool call waits for the client to
+	// answer an approval elicitation.
+	approvalTimeout = 5 * time.Minute
+
+	codeInvalidParams    = -32602
+	codeResourceNotFound = -32002
+)
+
+// supportedProtocolVersions lists the versions this server speaks, newest
+// first.
+var supportedProtocolVersions = []string{latestProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// ServerOptions configures one MCP server.
+type ServerOptions struct {
+	WorkingDir string
+	HomeDir    string
+	Version    string
+	// PermissionMode is the session mode tool calls are evaluated in.
+	PermissionMode string
+	// Tools lists the exposed tools. Defaults to ExposedToolSpecs.
+	Tools []spec.ToolSpec
+	// Executor supplies the runner and gates of the tool pipeline. Specs and
+	// ApprovalWaiter are set by the server.
+	Executor executor.Dependencies
+	// Transcripts backs the session transcript resources. Nil exposes none.
+	Transcripts TranscriptSource
+}
+
+// Server answers MCP requests. One Server may serve several connections.
+type Server struct {
+	workingDir     string
+	homeDir        string
+	version        string
+	permissionMode string
+	tools          []spec.ToolSpec
+	specs          *registry.Registry
+	deps           executor.Dependencies
+	transcripts    TranscriptSource
+}
+
+// connection is the per-client state of one transport connection.
+type connection struct {
+	server *Server
+	peer   *acp.Peer
+
+	mu sync.Mutex
+	// elicitation reports whether the client can answer approval prompts.
+	elicitation bool
+}
+
+// ExposedToolSpecs returns the built-in tools that make sense outside a
+// Goyais session: file and shell tools, without session-bound helpers.
+func ExposedToolSpecs() []spec.ToolSpec {
+	exposed := map[string]struct{}{
+		catalog.ToolRead:  {},
+		catalog.ToolWrite: {},
+		catalog.ToolEdit:  {},
+		catalog.ToolBash:  {},
+		catalog.ToolList:  {},
+	}
+	out := make([]spec.ToolSpec, 0, len(exposed))
+	for _, item := range catalog.BuiltinToolSpecs() {
+		if _, ok := exposed[item.Name]; ok {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// NewServer validates options and builds a server.
+func NewServer(opts ServerOptions) (*Server, error) {
+	if opts.Executor.Runner == nil {
+		return nil, errors.New("mcp server requires a tool runner")
+	}
+	tools := opts.Tools
+	if len(tools) == 0 {
+		tools = ExposedToolSpecs()
+	}
+	specs := registry.New()
+	for _, item := range tools {
+		if err := specs.Register(item); err != nil {
+			return nil, err
+		}
+	}
+	mode := strings.TrimSpace(opts.PermissionMode)
+	if mode == "" {
+		mode = string(core.PermissionModeDefault)
+	}
+	version := strings.TrimSpace(opts.Version)
+	if version == "" {
+		version = "dev"
+	}
+	return &Server{
+		workingDir:     strings.TrimSpace(opts.WorkingDir),
+		homeDir:        strings.TrimSpace(opts.HomeDir),
+		version:        version,
+		permissionMode: mode,
+		tools:          specs.ListOrdered(),
+		specs:          specs,
+		deps:           opts.Executor,
+		transcripts:    opts.Transcripts,
+	}, nil
+}
+
+// attach registers the MCP methods of one connection on peer.
+func (s *Server) attach(peer *acp.Peer) *connection {
+	conn := &connection{server: s, peer: peer}
+	peer.RegisterMethod("initialize", conn.handleInitialize)
+	peer.RegisterMethod("ping", func(any) (any, error) { return map[string]any{}, nil })
+	peer.RegisterMethod("tools/list", conn.handleToolsList)
+	peer.RegisterMethod("tools/call", conn.handleToolsCall)
+	peer.RegisterMethod("prompts/list", conn.handlePromptsList)
+	peer.RegisterMethod("prompts/get", conn.handlePromptsGet)
+	peer.RegisterMethod("resources/list", conn.handleResourcesList)
+	peer.RegisterMethod("resources/read", conn.handleResourcesRead)
+	return conn
+}
+
+func (c *connection) handleInitialize(params any) (any, error) {
+	payload := asMap(params)
+	capabilities := asMap(payload["capabilities"])
+	_, elicitation := capabilities["elicitation"].(map[string]any)
+	c.mu.Lock()
+	c.elicitation = elicitation
+	c.mu.Unlock()
+
+	version := latestProtocolVersion
+	requested := strings.TrimSpace(asString(payload["protocolVersion"]))
+	for _, supported := range supportedProtocolVersions {
+		if requested == supported {
+			version = requested
+			break
+		}
+	}
+	return map[string]any{
+		"protocolVersion": version,
+		"capabilities": map[string]any{
+			"tools":     map[string]any{},
+			"prompts":   map[string]any{},
+			"resources": map[string]any{},
+		},
+		"serverInfo": map[string]any{
+			"name":    ServerName,
+			"title":   "Goyais",
+			"version": c.server.version,
+		},
+	}, nil
+}
+
+func (c *connection) handleToolsList(any) (any, error) {
+	tools := make([]map[string]any, 0, len(c.server.tools))
+	for _, item := range c.server.tools {
+		schema := item.InputSchema
+		if len(schema) == 0 {
+			schema = map[string]any{"type": "object"}
+		}
+		tools = append(tools, map[string]any{
+			"name":        item.Name,
+			"description": item.Description,
+			"inputSchema": schema,
+			"annotations": map[string]any{
+				"readOnlyHint":    item.ReadOnly,
+				"destructiveHint": !item.ReadOnly,
+			},
+		})
+	}
+	return map[string]any{"tools": tools}, nil
+}
+
+func (c *connection) handleToolsCall(params any) (any, error) {
+	payload := asMap(params)
+	name := strings.TrimSpace(asString(payload["name"]))
+	if _, ok := c.server.specs.Lookup(name); !ok {
+		return nil, acp.JsonRPCError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown tool: %s", name)}
+	}
+	result, err := c.execute(context.Background(), name, asMap(payload["arguments"]))
+	if err != nil {
+		return toolErrorResult(err.Error()), nil
+	}
+	if !result.OK() {
+		return toolErrorResult(result.ErrorText), nil
+	}
+	text := result.OutputText
+	if strings.TrimSpace(text) == "" {
+		encoded, _ := json.Marshal(result.Output)
+		text = string(encoded)
+	}
+	reply := map[string]any{
+		"content": []map[string]any{{"type": "text", "text": text}},
+		"isError": false,
+	}
+	if len(result.Output) > 0 {
+		reply["structuredContent"] = result.Output
+	}
+	return reply, nil
+}
+
+// execute runs one tool call through the governed pipeline. Approvals are
+// asked of the client when it supports elicitation and refused otherwise.
+func (c *connection) execute(ctx context.Context, name string, input map[string]any) (executor.ExecuteSingleResult, error) {
+	deps := c.server.deps
+	deps.Specs = c.server.specs
+	deps.ApprovalWaiter = nil
+	c.mu.Lock()
+	if c.elicitation && c.peer != nil {
+		deps.ApprovalWaiter = elicitationApprovals{peer: c.peer}
+	}
+	c.mu.Unlock()
+	if input == nil {
+		input = map[string]any{}
+	}
+	return executor.NewPipeline(deps).ExecuteSingle(ctx, executor.ExecuteSingleRequest{
+		Call:        executor.ToolCall{CallID: newCallID(), Name: name, Input: input},
+		SessionMode: c.server.permissionMode,
+		ToolContext: executor.ToolContext{WorkingDir: c.server.workingDir},
+	})
+}
+
+func (c *connection) handlePromptsList(any) (any, error) {
+	metas, err := c.server.skillLoader(nil).Discover(context.Background(), core.SkillScopeProject)
+	if err != nil {
+		return nil, err
+	}
+	prompts := make([]map[string]any, 0, len(metas))
+	for _, meta := range metas {
+		prompts = append(prompts, map[string]any{
+			"name":        meta.Name,
+			"description": meta.Description,
+			"arguments": []map[string]any{{
+				"name":        "arguments",
+				"description": "Space separated arguments substituted into the skill",
+				"required":    false,
+			}},
+		})
+	}
+	return map[string]any{"prompts": prompts}, nil
+}
+
+func (c *connection) handlePromptsGet(params any) (any, error) {
+	payload := asMap(params)
+	name := strings.TrimSpace(asString(payload["name"]))
+	ctx := context.Background()
+	// Commands embedded in a skill run as Bash calls, so rendering a prompt
+	// is subject to the same rules as calling the tool.
+	loader := c.server.skillLoader(skills.CommandRunnerFunc(func(ctx context.Context, command string, _ string, _ map[string]string) (string, error) {
+		result, err := c.execute(ctx, catalog.ToolBash, map[string]any{"command": command})
+		if err != nil {
+			return "", err
+		}
+		if !result.OK() {
+			return "", errors.New(result.ErrorText)
+		}
+		if ok, _ := result.Output["ok"].(bool); !ok {
+			return "", fmt.Errorf("skill command failed: %s", asString(result.Output["output"]))
+		}
+		return asString(result.Output["stdout"]), nil
+	}))
+	definition, err := loader.Resolve(ctx, core.SkillRef{Scope: core.SkillScopeProject, Name: name})
+	if errors.Is(err, skills.ErrSkillNotFound) {
+		return nil, acp.JsonRPCError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown prompt: %s", name)}
+	}
+	if err != nil {
+		return nil, err
+	}
+	arguments := strings.Fields(asString(asMap(payload["arguments"])["arguments"]))
+	text, err := loader.Render(ctx, definition, skills.RenderRequest{Arguments: arguments, WorkingDir: c.server.workingDir})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"description": definition.Meta.Description,
+		"messages": []map[string]any{{
+			"role":    "user",
+			"content": map[string]any{"type": "text", "text": text},
+		}},
+	}, nil
+}
+
+func (c *connection) handleResourcesList(any) (any, error) {
+	resources := []map[string]any{}
+	if c.server.transcripts != nil {
+		items, err := c.server.transcripts.ListTranscripts(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(items, func(i, j int) bool { return items[i].UpdatedAt.After(items[j].UpdatedAt) })
+		for _, item := range items {
+			resources = append(resources, map[string]any{
+				"uri":         TranscriptURI(item.SessionID),
+				"name":        item.SessionID,
+				"description": "Transcript of session " + item.SessionID,
+				"mimeType":    transcriptMIMEType,
+			})
+		}
+	}
+	return map[string]any{"resources": resources}, nil
+}
+
+func (c *connection) handleResourcesRead(params any) (any, error) {
+	uri := strings.TrimSpace(asString(asMap(params)["uri"]))
+	sessionID, ok := parseTranscriptURI(uri)
+	if !ok || c.server.transcripts == nil {
+		return nil, acp.JsonRPCError{Code: codeResourceNotFound, Message: "resource not found", Data: map[string]any{"uri": uri}}
+	}
+	text, err := c.server.transcripts.ReadTranscript(context.Background(), sessionID)
+	if errors.Is(err, ErrTranscriptNotFound) {
+		return nil, acp.JsonRPCError{Code: codeResourceNotFound, Message: "resource not found", Data: map[string]any{"uri": uri}}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"contents": []map[string]any{{"uri": uri, "mimeType": transcriptMIMEType, "text": text}},
+	}, nil
+}
+
+func (s *Server) skillLoader(runner skills.CommandRunner) *skills.Loader {
+	return skills.NewLoader(skills.LoaderOptions{
+		WorkingDir:    s.workingDir,
+		HomeDir:       s.homeDir,
+		CommandRunner: runner,
+	})
+}
+
+// elicitationApprovals asks the MCP client to approve a tool call.
+type elicitationApprovals struct {
+	peer *acp.Peer
+}
+
+func (a elicitationApprovals) WaitForApproval(_ context.Context, req executor.ApprovalRequest) (executor.ApprovalAction, error) {
+	message := fmt.Sprintf("Allow %s?", req.ToolName)
+	if reason := strings.TrimSpace(req.Reason); reason != "" {
+		message += " " + reason
+	}
+	if encoded, err := json.Marshal(req.Input); err == nil && len(req.Input) > 0 {
+		message += "\n" + string(encoded)
+	}
+	reply, err := a.peer.SendRequest("elicitation/create", map[string]any{
+		"message":         message,
+		"requestedSchema": map[string]any{"type": "object", "properties": map[string]any{}},
+	}, approvalTimeout)
+	if err != nil {
+		return "", err
+	}
+	if asString(asMap(reply)["action"]) == "accept" {
+		return executor.ApprovalActionApprove, nil
+	}
+	return executor.ApprovalActionDeny, nil
+}
+
+func toolErrorResult(text string) map[string]any {
+	return map[string]any{
+		"content": []map[string]any{{"type": "text", "text": text}},
+		"isError": true,
+	}
+}
+
+var (
+	callMu  sync.Mutex
+	callSeq int64
+)
+
+func newCallID() string {
+	callMu.Lock()
+	defer callMu.Unlock()
+	callSeq++
+	return fmt.Sprintf("mcp_call_%d", callSeq)
+}
+
+func asMap(value any) map[string]any {
+	typed, _ := value.(map[string]any)
+	return typed
+}
+
+func asString(value any) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(typed)
+	}
+}
@@ -0,0 +1,316 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcpserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stdioClient drives ServeStdio over pipes and answers elicitations with
+// the configured action.
+type stdioClient struct {
+	t       *testing.T
+	writer  *io.PipeWriter
+	reader  *bufio.Reader
+	nextID  int
+	answer  string
+	elicits []map[string]any
+	done    chan error
+}
+
+func newStdioClient(t *testing.T, server *Server) *stdioClient {
+	t.Helper()
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	client := &stdioClient{t: t, writer: inWriter, reader: bufio.NewReader(outReader), done: make(chan error, 1)}
+	go func() {
+		client.done <- server.ServeStdio(context.Background(), inReader, outWriter)
+		_ = outWriter.Close()
+	}()
+	t.Cleanup(func() { _ = inWriter.Close() })
+	return client
+}
+
+func (c *stdioClient) write(payload map[string]any) {
+	c.t.Helper()
+	raw, _ := json.Marshal(payload)
+	if _, err := c.writer.Write(append(raw, '\n')); err != nil {
+		c.t.Fatalf("write request: %v", err)
+	}
+}
+
+func (c *stdioClient) call(method string, params map[string]any) map[string]any {
+	c.t.Helper()
+	c.nextID++
+	id := float64(c.nextID)
+	c.write(map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			c.t.Fatalf("read %s response: %v", method, err)
+		}
+		message := map[string]any{}
+		if err := json.Unmarshal([]byte(line), &message); err != nil {
+			c.t.Fatalf("decode %q: %v", line, err)
+		}
+		if message["method"] == "elicitation/create" {
+			params, _ := message["params"].(map[string]any)
+			c.elicits = append(c.elicits, params)
+			c.write(map[string]any{"jsonrpc": "2.0", "id": message["id"], "result": map[string]any{"action": c.answer}})
+			continue
+		}
+		if message["id"] == id {
+			return message
+		}
+	}
+}
+
+func newTestServer(t *testing.T) (*Server, string) {
+	t.Helper()
+	workdir := t.TempDir()
+	home := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workdir, "notes.txt"), []byte("hello from notes\n"), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	skillDir := filepath.Join(workdir, ".claude", "skills", "review")
+	if err := os.MkdirAll(skillDir, 0o755); err != nil {
+		t.Fatalf("mkdir skill: %v", err)
+	}
+	skill := "---\ndescription: Review a change\n---\nReview $ARGUMENTS carefully.\n"
+	if err := os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte(skill), 0o644); err != nil {
+		t.Fatalf("write skill: %v", err)
+	}
+	options, err := ProjectOptions(workdir, home)
+	if err != nil {
+		t.Fatalf("project options: %v", err)
+	}
+	transcriptDir := filepath.Join(home, ".claude", "projects", filepath.Base(workdir), "session-1", "subagents")
+	if err := os.MkdirAll(transcriptDir, 0o755); err != nil {
+		t.Fatalf("mkdir transcripts: %v", err)
+	}
+	options.Transcripts = DirTranscripts{Root: filepath.Dir(filepath.Dir(transcriptDir))}
+	if err := os.WriteFile(filepath.Join(transcriptDir, "agent-a-1.jsonl"), []byte(`{"summary":"done"}`+"\n"), 0o644); err != nil {
+		t.Fatalf("write transcript: %v", err)
+	}
+	server, err := NewServer(options)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	return server, workdir
+}
+
+func resultOf(t *testing.T, message map[string]any) map[string]any {
+	t.Helper()
+	result, ok := message["result"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected a result, got %+v", message)
+	}
+	return result
+}
+
+func firstText(result map[string]any) string {
+	content, _ := result["content"].([]any)
+	if len(content) == 0 {
+		return ""
+	}
+	first, _ := content[0].(map[string]any)
+	text, _ := first["text"].(string)
+	return text
+}
+
+func TestServeStdioExposesToolsPromptsAndTranscripts(t *testing.T) {
+	server, _ := newTestServer(t)
+	client := newStdioClient(t, server)
+
+	initialized := resultOf(t, client.call("initialize", map[string]any{"protocolVersion": "2025-03-26", "capabilities": map[string]any{}}))
+	info, _ := initialized["serverInfo"].(map[string]any)
+	if initialized["protocolVersion"] != "2025-03-26" || info["name"] != ServerName {
+		t.Fatalf("unexpected initialize result %+v", initialized)
+	}
+
+	listed := resultOf(t, client.call("tools/list", nil))
+	names := []string{}
+	for _, item := range listed["tools"].([]any) {
+		names = append(names, item.(map[string]any)["name"].(string))
+	}
+	if strings.Join(names, ",") != "Read,Write,Edit,Bash,List" {
+		t.Fatalf("unexpected tools %v", names)
+	}
+
+	read := resultOf(t, client.call("tools/call", map[string]any{"name": "Read", "arguments": map[string]any{"path": "notes.txt"}}))
+	if read["isError"] != false || !strings.Contains(firstText(read), "hello from notes") {
+		t.Fatalf("unexpected Read result %+v", read)
+	}
+	escaped := resultOf(t, client.call("tools/call", map[string]any{"name": "Read", "arguments": map[string]any{"path": "../outside.txt"}}))
+	if escaped["isError"] != true {
+		t.Fatalf("expected a path outside the workspace to fail, got %+v", escaped)
+	}
+	if unknown := client.call("tools/call", map[string]any{"name": "ToolSearch"}); unknown["error"] == nil {
+		t.Fatalf("expected session-bound tools to be hidden, got %+v", unknown)
+	}
+
+	prompts := resultOf(t, client.call("prompts/list", nil))
+	listedPrompts, _ := prompts["prompts"].([]any)
+	if len(listedPrompts) != 1 || listedPrompts[0].(map[string]any)["name"] != "review" {
+		t.Fatalf("unexpected prompts %+v", prompts)
+	}
+	prompt := resultOf(t, client.call("prompts/get", map[string]any{"name": "review", "arguments": map[string]any{"arguments": "main.go"}}))
+	messages, _ := prompt["messages"].([]any)
+	content, _ := messages[0].(map[string]any)["content"].(map[string]any)
+	if content["text"] != "Review main.go carefully." || prompt["description"] != "Review a change" {
+		t.Fatalf("unexpected prompt %+v", prompt)
+	}
+
+	resources := resultOf(t, client.call("resources/list", nil))
+	listedResources, _ := resources["resources"].([]any)
+	if len(listedResources) != 1 || listedResources[0].(map[string]any)["uri"] != TranscriptURI("session-1") {
+		t.Fatalf("unexpected resources %+v", resources)
+	}
+	transcript := resultOf(t, client.call("resources/read", map[string]any{"uri": TranscriptURI("session-1")}))
+	contents, _ := transcript["contents"].([]any)
+	if len(contents) != 1 || contents[0].(map[string]any)["text"] != `{"summary":"done"}`+"\n" {
+		t.Fatalf("unexpected transcript %+v", transcript)
+	}
+	missing := client.call("resources/read", map[string]any{"uri": TranscriptURI("..")})
+	if rpcErr, _ := missing["error"].(map[string]any); rpcErr["code"] != float64(codeResourceNotFound) {
+		t.Fatalf("expected resource not found, got %+v", missing)
+	}
+}
+
+func TestServeStdioAsksClientToApproveBash(t *testing.T) {
+	server, _ := newTestServer(t)
+	client := newStdioClient(t, server)
+	resultOf(t, client.call("initialize", map[string]any{"capabilities": map[string]any{"elicitation": map[string]any{}}}))
+
+	client.answer = "accept"
+	approved := resultOf(t, client.call("tools/call", map[string]any{"name": "Bash", "arguments": map[string]any{"command": "echo governed"}}))
+	if approved["isError"] != false || !strings.Contains(firstText(approved), "governed") {
+		t.Fatalf("expected approved Bash to run, got %+v", approved)
+	}
+	if len(client.elicits) != 1 || !strings.Contains(client.elicits[0]["message"].(string), "Allow Bash?") {
+		t.Fatalf("expected one approval elicitation, got %+v", client.elicits)
+	}
+
+	client.answer = "decline"
+	denied := resultOf(t, client.call("tools/call", map[string]any{"name": "Bash", "arguments": map[string]any{"command": "echo governed"}}))
+	if denied["isError"] != true {
+		t.Fatalf("expected declined Bash to fail, got %+v", denied)
+	}
+}
+
+func TestServeStdioRefusesApprovalWithoutElicitation(t *testing.T) {
+	server, _ := newTestServer(t)
+	client := newStdioClient(t, server)
+	resultOf(t, client.call("initialize", map[string]any{"capabilities": map[string]any{}}))
+
+	result := resultOf(t, client.call("tools/call", map[string]any{"name": "Bash", "arguments": map[string]any{"command": "echo governed"}}))
+	if result["isError"] != true || !strings.Contains(firstText(result), "requires approval") {
+		t.Fatalf("expected approval to be required, got %+v", result)
+	}
+	if len(client.elicits) != 0 {
+		t.Fatalf("expected no elicitation without the capability, got %+v", client.elicits)
+	}
+
+	_ = client.writer.Close()
+	select {
+	case err := <-client.done:
+		if err != nil {
+			t.Fatalf("expected a clean shutdown at end of input, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected the server to stop at end of input")
+	}
+}
+
+func TestHTTPHandlerServesJSONResponses(t *testing.T) {
+	server, _ := newTestServer(t)
+	httpServer := httptest.NewServer(server.HTTPHandler("secret-token"))
+	defer httpServer.Close()
+
+	postAs := func(authorization string, origin string, payload string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, httpServer.URL, strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("post: %v", err)
+		}
+		t.Cleanup(func() { _ = res.Body.Close() })
+		return res
+	}
+	post := func(origin string, payload string) *http.Response {
+		return postAs("Bearer secret-token", origin, payload)
+	}
+
+	for _, authorization := range []string{"", "Bearer wrong-token", "Basic secret-token"} {
+		if res := postAs(authorization, "", `{"jsonrpc":"2.0","id":1,"method":"ping"}`); res.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected %q to be rejected, got %d", authorization, res.StatusCode)
+		}
+	}
+	res := post("", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)
+	message := map[string]any{}
+	_ = json.NewDecoder(res.Body).Decode(&message)
+	if res.StatusCode != http.StatusOK || res.Header.Get("Mcp-Session-Id") == "" || resultOf(t, message)["protocolVersion"] != latestProtocolVersion {
+		t.Fatalf("unexpected initialize response %d %+v", res.StatusCode, message)
+	}
+	if res := post("", `{"jsonrpc":"2.0","method":"notifications/initialized"}`); res.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected notifications to be accepted, got %d", res.StatusCode)
+	}
+	if res := post("http://127.0.0.1:3000", `{"jsonrpc":"2.0","id":2,"method":"ping"}`); res.StatusCode != http.StatusOK {
+		t.Fatalf("expected a loopback origin to be allowed, got %d", res.StatusCode)
+	}
+	if res := post("https://evil.example", `{"jsonrpc":"2.0","id":3,"method":"ping"}`); res.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected a foreign origin to be rejected, got %d", res.StatusCode)
+	}
+}
+
+func TestHTTPHandlerWithoutTokenRefusesRequests(t *testing.T) {
+	server, _ := newTestServer(t)
+	httpServer := httptest.NewServer(server.HTTPHandler(""))
+	defer httpServer.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, httpServer.URL, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	req.Header.Set("Authorization", "Bearer ")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected an empty token to refuse requests, got %d", res.StatusCode)
+	}
+}
+
+func TestIsLoopbackAddr(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"127.0.0.1:8080": true,
+		"localhost:0":    true,
+		"[::1]:9000":     true,
+		"0.0.0.0:8080":   false,
+		":8080":          false,
+		"10.0.0.5:8080":  false,
+		"example.com:80": false,
+		"127.0.0.1":      false,
+	} {
+		if got := IsLoopbackAddr(addr); got != want {
+			t.Fatalf("IsLoopbackAddr(%q) = %v, want %v", addr, got, want)
+		}
+	}
+}
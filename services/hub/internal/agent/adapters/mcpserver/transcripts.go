@@ -0,0 +1,140 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcpserver
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	transcriptURIPrefix = "goyais://sessions/"
+	transcriptURISuffix = "/transcript"
+	transcriptMIMEType  = "application/x-ndjson"
+)
+
+// ErrTranscriptNotFound reports a session without a stored transcript.
+var ErrTranscriptNotFound = errors.New("transcript not found")
+
+// Transcript describes one session transcript.
+type Transcript struct {
+	SessionID string
+	UpdatedAt time.Time
+}
+
+// TranscriptSource lists and reads session transcripts.
+type TranscriptSource interface {
+	ListTranscripts(ctx context.Context) ([]Transcript, error)
+	ReadTranscript(ctx context.Context, sessionID string) (string, error)
+}
+
+// TranscriptURI is the resource URI of the transcript of sessionID.
+func TranscriptURI(sessionID string) string {
+	return transcriptURIPrefix + sessionID + transcriptURISuffix
+}
+
+func parseTranscriptURI(uri string) (string, bool) {
+	if !strings.HasPrefix(uri, transcriptURIPrefix) || !strings.HasSuffix(uri, transcriptURISuffix) {
+		return "", false
+	}
+	sessionID := strings.TrimSuffix(strings.TrimPrefix(uri, transcriptURIPrefix), transcriptURISuffix)
+	if sessionID == "" || strings.ContainsAny(sessionID, `/\`) || sessionID == "." || sessionID == ".." {
+		return "", false
+	}
+	return sessionID, true
+}
+
+// DirTranscripts reads transcripts laid out as one directory per session
+// holding .jsonl files, as subagents.TranscriptRoot does.
+type DirTranscripts struct {
+	Root string
+}
+
+// ListTranscripts returns every session directory with at least one
+// transcript file.
+func (d DirTranscripts) ListTranscripts(_ context.Context) ([]Transcript, error) {
+	entries, err := os.ReadDir(d.Root)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Transcript{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	items := make([]Transcript, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		files, updatedAt, err := transcriptFiles(filepath.Join(d.Root, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			continue
+		}
+		items = append(items, Transcript{SessionID: entry.Name(), UpdatedAt: updatedAt})
+	}
+	return items, nil
+}
+
+// ReadTranscript concatenates the transcript files of sessionID in path
+// order.
+func (d DirTranscripts) ReadTranscript(_ context.Context, sessionID string) (string, error) {
+	files, _, err := transcriptFiles(filepath.Join(d.Root, sessionID))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrTranscriptNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", ErrTranscriptNotFound
+	}
+	builder := strings.Builder{}
+	for _, path := range files {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		builder.Write(raw)
+		if len(raw) > 0 && raw[len(raw)-1] != '\n' {
+			builder.WriteByte('\n')
+		}
+	}
+	return builder.String(), nil
+}
+
+func transcriptFiles(dir string) ([]string, time.Time, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, time.Time{}, err
+	}
+	files := []string{}
+	updatedAt := time.Time{}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if entry.IsDir() || filepath.Ext(path) != ".jsonl" {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(updatedAt) {
+			updatedAt = info.ModTime()
+		}
+		files = append(files, path)
+		return nil
+	})
+	sort.Strings(files)
+	return files, updatedAt, err
+}
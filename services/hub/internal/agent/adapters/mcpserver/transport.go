@@ -0,0 +1,188 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcpserver
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"goyais/services/hub/internal/agent/adapters/acp"
+)
+
+const parseErrorLine = `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"parse error"}}`
+
+// ServeStdio serves one client over newline-delimited JSON-RPC until input
+// ends or ctx is cancelled. Requests are handled concurrently so a tool call
+// can wait on an approval elicitation while its answer is read.
+func (s *Server) ServeStdio(ctx context.Context, input io.Reader, output io.Writer) error {
+	peer := acp.NewPeer()
+	var writeMu sync.Mutex
+	writeLine := func(line string) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		_, err := io.WriteString(output, line+"\n")
+		return err
+	}
+	peer.SetSend(writeLine)
+	s.attach(peer)
+
+	var inflight sync.WaitGroup
+	defer inflight.Wait()
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 0, 1024), 4*1024*1024)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var payload any
+		if err := json.Unmarshal([]byte(line), &payload); err != nil {
+			_ = writeLine(parseErrorLine)
+			continue
+		}
+		// Responses resolve pending elicitations and must not queue behind
+		// the requests waiting on them.
+		if message, ok := payload.(map[string]any); ok {
+			if _, isRequest := message["method"]; !isRequest {
+				_ = peer.HandleIncoming(payload)
+				continue
+			}
+		}
+		inflight.Add(1)
+		go func() {
+			defer inflight.Done()
+			_ = peer.HandleIncoming(payload)
+		}()
+	}
+	return scanner.Err()
+}
+
+// HTTPHandler serves the Streamable HTTP transport with plain JSON
+// responses. The server never opens an event stream, so approvals that
+// would need an elicitation are refused. Every request must carry
+// "Authorization: Bearer <token>"; an empty token refuses all requests.
+func (s *Server) HTTPHandler(token string) http.Handler {
+	token = strings.TrimSpace(token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorizedBearer(r.Header.Get("Authorization"), token) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="goyais-mcp"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !allowedOrigin(r.Header.Get("Origin")) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+		switch r.Method {
+		case http.MethodPost:
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusOK)
+			return
+		default:
+			w.Header().Set("Allow", "POST, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var payload any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, parseErrorLine)
+			return
+		}
+
+		peer := acp.NewPeer()
+		reply := ""
+		peer.SetSend(func(line string) error {
+			reply = line
+			return nil
+		})
+		s.attach(peer)
+		_ = peer.HandleIncoming(payload)
+		if reply == "" {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		if message, ok := payload.(map[string]any); ok && message["method"] == "initialize" {
+			w.Header().Set("Mcp-Session-Id", newSessionID())
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, reply)
+	})
+}
+
+// authorizedBearer reports whether header carries token as a bearer
+// credential, comparing in constant time.
+func authorizedBearer(header string, token string) bool {
+	if token == "" {
+		return false
+	}
+	scheme, credential, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(credential)), []byte(token)) == 1
+}
+
+// IsLoopbackAddr reports whether a listen address such as "127.0.0.1:8080"
+// or "localhost:0" only accepts connections from this host. An empty host,
+// as in ":8080", listens on every interface.
+func IsLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(addr))
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// NewBearerToken returns a random token for HTTPHandler.
+func NewBearerToken() string {
+	return randomHex(32)
+}
+
+// allowedOrigin rejects browser requests from non-local pages, which guards
+// a loopback server against DNS rebinding.
+func allowedOrigin(origin string) bool {
+	if strings.TrimSpace(origin) == "" {
+		return true
+	}
+	parsed, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	host := parsed.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func newSessionID() string {
+	return randomHex(16)
+}
+
+func randomHex(size int) string {
+	buf := make([]byte, size)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
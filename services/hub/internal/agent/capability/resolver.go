@@ -8,6 +8,7 @@ package capability
 
 import (
 	"encoding/json"
+	"math"
 	"sort"
 	"strings"
 
@@ -182,43 +183,56 @@ func ToToolSpecs(items []core.CapabilityDescriptor) []toolspec.ToolSpec {
 	return out
 }
 
-// BuildSearchResultItems renders capability search results for ToolSearch.
+// BuildSearchResultItems renders ranked capability search results for
+// ToolSearch. Callers that search repeatedly should build one SearchIndex and
+// use RenderSearchHits instead.
 func BuildSearchResultItems(items []core.CapabilityDescriptor, query string, limit int) []map[string]any {
-	if limit <= 0 {
-		limit = 20
+	return RenderSearchHits(NewSearchIndex(items).Search(query, limit))
+}
+
+// RenderSearchHits renders ranked hits into ToolSearch result payloads.
+func RenderSearchHits(hits []SearchHit) []map[string]any {
+	results := make([]map[string]any, 0, len(hits))
+	for _, hit := range hits {
+		item := renderSearchResultItem(hit.Capability)
+		if hit.Score > 0 {
+			item["score"] = math.Round(hit.Score*1000) / 1000
+			item["matched_terms"] = append([]string{}, hit.MatchedTerms...)
+		}
+		results = append(results, item)
 	}
-	filter := strings.ToLower(strings.TrimSpace(query))
-	results := make([]map[string]any, 0, min(limit, len(items)))
+	return results
+}
+
+// RenderSelectedCapabilities renders capabilities loaded through a "select:"
+// ToolSearch query.
+func RenderSelectedCapabilities(items []core.CapabilityDescriptor) []map[string]any {
+	results := make([]map[string]any, 0, len(items))
 	for _, item := range items {
-		if filter != "" {
-			haystack := strings.ToLower(strings.TrimSpace(item.Name + " " + item.Description + " " + string(item.Kind) + " " + item.Source))
-			if !strings.Contains(haystack, filter) {
-				continue
-			}
-		}
-		results = append(results, map[string]any{
-			"id":                  item.ID,
-			"kind":                string(item.Kind),
-			"name":                item.Name,
-			"description":         item.Description,
-			"source":              item.Source,
-			"scope":               string(item.Scope),
-			"version":             item.Version,
-			"risk_level":          item.RiskLevel,
-			"read_only":           item.ReadOnly,
-			"concurrency_safe":    item.ConcurrencySafe,
-			"requires_permissions": item.RequiresPermissions,
-			"visibility_policy":   string(item.VisibilityPolicy),
-			"prompt_budget_cost":  item.PromptBudgetCost,
-			"input_schema":        cloneMapAny(item.InputSchema),
-		})
-		if len(results) >= limit {
-			break
-		}
+		results = append(results, renderSearchResultItem(item))
 	}
 	return results
 }
 
+func renderSearchResultItem(item core.CapabilityDescriptor) map[string]any {
+	return map[string]any{
+		"id":                   item.ID,
+		"kind":                 string(item.Kind),
+		"name":                 item.Name,
+		"description":          item.Description,
+		"source":               item.Source,
+		"scope":                string(item.Scope),
+		"version":              item.Version,
+		"risk_level":           item.RiskLevel,
+		"read_only":            item.ReadOnly,
+		"concurrency_safe":     item.ConcurrencySafe,
+		"requires_permissions": item.RequiresPermissions,
+		"visibility_policy":    string(item.VisibilityPolicy),
+		"prompt_budget_cost":   item.PromptBudgetCost,
+		"input_schema":         cloneMapAny(item.InputSchema),
+	}
+}
+
 func shouldDeferMCPTools(items []core.CapabilityDescriptor, promptBudgetChars int, thresholdRatio float64) bool {
 	if len(items) == 0 || promptBudgetChars <= 0 {
 		return false
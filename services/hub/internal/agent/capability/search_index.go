@@ -0,0 +1,394 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package capability
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"goyais/services/hub/internal/agent/core"
+)
+
+const (
+	// SelectQueryPrefix marks a ToolSearch query that loads named capabilities
+	// exactly instead of ranking them, e.g. "select:mcp__github__create_issue".
+	SelectQueryPrefix = "select:"
+
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	nameFieldWeight        = 3.0
+	propertyFieldWeight    = 1.5
+	descriptionFieldWeight = 1.0
+	sourceFieldWeight      = 0.5
+	synonymQueryWeight     = 0.5
+	exactNameBoost         = 10.0
+)
+
+// SearchHit is one ranked capability match.
+type SearchHit struct {
+	Capability   core.CapabilityDescriptor
+	Score        float64
+	MatchedTerms []string
+}
+
+// SearchIndex is a BM25-ranked inverted index over capability name,
+// description and input schema property names. It is immutable after
+// construction and safe for concurrent reads; build it once per run config.
+type SearchIndex struct {
+	items     []core.CapabilityDescriptor
+	byName    map[string]int
+	byFold    map[string]int
+	postings  map[string][]searchPosting
+	docLength []float64
+	avgLength float64
+}
+
+type searchPosting struct {
+	doc  int
+	freq float64
+}
+
+// NewSearchIndex tokenizes descriptors into one inverted index.
+func NewSearchIndex(items []core.CapabilityDescriptor) *SearchIndex {
+	docs := cloneDescriptors(items)
+	sortDescriptors(docs)
+	index := &SearchIndex{
+		items:     docs,
+		byName:    make(map[string]int, len(docs)),
+		byFold:    make(map[string]int, len(docs)),
+		postings:  map[string][]searchPosting{},
+		docLength: make([]float64, len(docs)),
+	}
+	total := 0.0
+	for doc, item := range docs {
+		name := strings.TrimSpace(item.Name)
+		if _, exists := index.byName[name]; !exists {
+			index.byName[name] = doc
+		}
+		if _, exists := index.byFold[strings.ToLower(name)]; !exists {
+			index.byFold[strings.ToLower(name)] = doc
+		}
+
+		freqs := map[string]float64{}
+		addTokens(freqs, TokenizeSearchText(name), nameFieldWeight)
+		addTokens(freqs, TokenizeSearchText(item.Description), descriptionFieldWeight)
+		addTokens(freqs, TokenizeSearchText(strings.Join(schemaPropertyNames(item.InputSchema), " ")), propertyFieldWeight)
+		addTokens(freqs, TokenizeSearchText(item.Source+" "+string(item.Kind)), sourceFieldWeight)
+
+		length := 0.0
+		for term, freq := range freqs {
+			index.postings[term] = append(index.postings[term], searchPosting{doc: doc, freq: freq})
+			length += freq
+		}
+		index.docLength[doc] = length
+		total += length
+	}
+	if len(docs) > 0 {
+		index.avgLength = total / float64(len(docs))
+	}
+	return index
+}
+
+// Len returns the number of indexed capabilities.
+func (i *SearchIndex) Len() int {
+	if i == nil {
+		return 0
+	}
+	return len(i.items)
+}
+
+// Search ranks indexed capabilities against a free-text query. Terms prefixed
+// with "+" are required to match. An empty query lists capabilities in stable
+// name order.
+func (i *SearchIndex) Search(query string, limit int) []SearchHit {
+	if i == nil || len(i.items) == 0 {
+		return nil
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	trimmed := strings.TrimSpace(query)
+	if trimmed == "" {
+		out := make([]SearchHit, 0, min(limit, len(i.items)))
+		for _, item := range i.items {
+			out = append(out, SearchHit{Capability: item})
+			if len(out) >= limit {
+				break
+			}
+		}
+		return out
+	}
+
+	required := []string{}
+	weights := map[string]float64{}
+	for _, field := range strings.Fields(trimmed) {
+		mustMatch := strings.HasPrefix(field, "+")
+		for _, term := range TokenizeSearchText(strings.TrimPrefix(field, "+")) {
+			if mustMatch {
+				required = append(required, term)
+			}
+			if weights[term] < 1 {
+				weights[term] = 1
+			}
+			for _, synonym := range searchSynonyms[term] {
+				if weights[synonym] < synonymQueryWeight {
+					weights[synonym] = synonymQueryWeight
+				}
+			}
+		}
+	}
+
+	scores := map[int]float64{}
+	matched := map[int]map[string]struct{}{}
+	docCount := float64(len(i.items))
+	for term, queryWeight := range weights {
+		postings := i.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+		df := float64(len(postings))
+		idf := math.Log(1 + (docCount-df+0.5)/(df+0.5))
+		for _, posting := range postings {
+			norm := 1 - bm25B + bm25B*i.docLength[posting.doc]/math.Max(i.avgLength, 1)
+			scores[posting.doc] += queryWeight * idf * posting.freq * (bm25K1 + 1) / (posting.freq + bm25K1*norm)
+			if matched[posting.doc] == nil {
+				matched[posting.doc] = map[string]struct{}{}
+			}
+			matched[posting.doc][term] = struct{}{}
+		}
+	}
+
+	folded := strings.ToLower(trimmed)
+	for doc, item := range i.items {
+		name := strings.ToLower(strings.TrimSpace(item.Name))
+		if name == folded || strings.ToLower(resolvedToolName(item)) == folded {
+			scores[doc] += exactNameBoost
+		}
+	}
+
+	out := make([]SearchHit, 0, len(scores))
+	for doc, score := range scores {
+		if score <= 0 || !containsAllTerms(matched[doc], required) {
+			continue
+		}
+		terms := make([]string, 0, len(matched[doc]))
+		for term := range matched[doc] {
+			terms = append(terms, term)
+		}
+		sort.Strings(terms)
+		out = append(out, SearchHit{Capability: i.items[doc], Score: score, MatchedTerms: terms})
+	}
+	sort.SliceStable(out, func(left, right int) bool {
+		if out[left].Score != out[right].Score {
+			return out[left].Score > out[right].Score
+		}
+		return out[left].Capability.Name < out[right].Capability.Name
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// Select resolves capabilities by exact name, preserving request order.
+// Names are matched case-sensitively first, then case-insensitively; unknown
+// names are returned as missing.
+func (i *SearchIndex) Select(names []string) ([]core.CapabilityDescriptor, []string) {
+	found := []core.CapabilityDescriptor{}
+	missing := []string{}
+	seen := map[int]struct{}{}
+	for _, raw := range names {
+		name := strings.TrimSpace(raw)
+		if name == "" {
+			continue
+		}
+		doc, ok := -1, false
+		if i != nil {
+			doc, ok = i.byName[name]
+			if !ok {
+				doc, ok = i.byFold[strings.ToLower(name)]
+			}
+		}
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		if _, dup := seen[doc]; dup {
+			continue
+		}
+		seen[doc] = struct{}{}
+		found = append(found, cloneDescriptors([]core.CapabilityDescriptor{i.items[doc]})[0])
+	}
+	return found, missing
+}
+
+// ParseSelectQuery extracts the comma-separated names from a "select:" query.
+func ParseSelectQuery(query string) ([]string, bool) {
+	trimmed := strings.TrimSpace(query)
+	if !strings.HasPrefix(strings.ToLower(trimmed), SelectQueryPrefix) {
+		return nil, false
+	}
+	body := trimmed[len(SelectQueryPrefix):]
+	names := strings.FieldsFunc(body, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+	return names, true
+}
+
+// TokenizeSearchText lower-cases text and splits it on punctuation, snake or
+// kebab case and camelCase boundaries, dropping stop words and folding simple
+// plurals.
+func TokenizeSearchText(text string) []string {
+	out := []string{}
+	for _, word := range splitCamelCase(text) {
+		term := normalizeSearchTerm(word)
+		if term == "" {
+			continue
+		}
+		if _, stop := searchStopWords[term]; stop {
+			continue
+		}
+		out = append(out, term)
+	}
+	return out
+}
+
+func splitCamelCase(text string) []string {
+	runes := []rune(text)
+	words := []string{}
+	current := []rune{}
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, string(current))
+			current = current[:0]
+		}
+	}
+	for idx, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if len(current) > 0 {
+			prev := runes[idx-1]
+			switch {
+			case unicode.IsLower(prev) && unicode.IsUpper(r):
+				flush()
+			case unicode.IsUpper(prev) && unicode.IsUpper(r) && idx+1 < len(runes) && unicode.IsLower(runes[idx+1]):
+				flush()
+			case unicode.IsDigit(prev) != unicode.IsDigit(r):
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+	return words
+}
+
+func normalizeSearchTerm(word string) string {
+	term := strings.ToLower(strings.TrimSpace(word))
+	if len(term) < 2 {
+		return ""
+	}
+	switch {
+	case len(term) > 4 && strings.HasSuffix(term, "ies"):
+		return term[:len(term)-3] + "y"
+	case len(term) > 3 && strings.HasSuffix(term, "s") && !strings.HasSuffix(term, "ss") && !strings.HasSuffix(term, "us"):
+		return term[:len(term)-1]
+	default:
+		return term
+	}
+}
+
+func addTokens(freqs map[string]float64, tokens []string, weight float64) {
+	for _, token := range tokens {
+		freqs[token] += weight
+	}
+}
+
+func schemaPropertyNames(schema map[string]any) []string {
+	properties, ok := schema["properties"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	out := make([]string, 0, len(properties))
+	for key, value := range properties {
+		out = append(out, key)
+		if nested, ok := value.(map[string]any); ok {
+			out = append(out, schemaPropertyNames(nested)...)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func containsAllTerms(matched map[string]struct{}, required []string) bool {
+	for _, term := range required {
+		if _, ok := matched[term]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func resolvedToolName(item core.CapabilityDescriptor) string {
+	name := strings.TrimSpace(item.Name)
+	if item.Kind == core.CapabilityKindMCPTool && strings.HasPrefix(strings.ToLower(name), "mcp__") {
+		parts := strings.SplitN(name, "__", 3)
+		if len(parts) == 3 {
+			return parts[2]
+		}
+	}
+	return name
+}
+
+var searchStopWords = map[string]struct{}{
+	"an": {}, "and": {}, "the": {}, "of": {}, "to": {}, "for": {}, "from": {},
+	"in": {}, "on": {}, "or": {}, "with": {}, "by": {}, "is": {}, "it": {},
+	"this": {}, "that": {}, "tool": {},
+}
+
+var searchSynonymGroups = [][]string{
+	{"search", "find", "lookup", "query", "grep"},
+	{"delete", "remove", "rm", "erase", "drop"},
+	{"create", "add", "new", "make", "insert"},
+	{"list", "ls", "enumerate", "browse"},
+	{"get", "fetch", "read", "retrieve", "load", "download"},
+	{"write", "save", "store", "put", "upload"},
+	{"update", "edit", "modify", "change", "patch"},
+	{"run", "execute", "exec", "invoke", "call"},
+	{"doc", "document", "documentation", "docs", "page"},
+	{"db", "database", "sql", "table"},
+	{"web", "browser", "url", "http", "website"},
+	{"repo", "repository", "git"},
+	{"issue", "ticket", "bug"},
+	{"message", "msg", "chat", "post", "send"},
+	{"image", "picture", "photo", "screenshot"},
+	{"file", "path", "directory", "folder", "dir"},
+	{"user", "account", "member"},
+}
+
+var searchSynonyms = buildSearchSynonyms(searchSynonymGroups)
+
+func buildSearchSynonyms(groups [][]string) map[string][]string {
+	out := map[string][]string{}
+	for _, group := range groups {
+		terms := make([]string, 0, len(group))
+		for _, word := range group {
+			if term := normalizeSearchTerm(word); term != "" {
+				terms = append(terms, term)
+			}
+		}
+		for _, term := range terms {
+			for _, other := range terms {
+				if other != term {
+					out[term] = append(out[term], other)
+				}
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package capability
+
+import (
+	"reflect"
+	"testing"
+
+	"goyais/services/hub/internal/agent/core"
+)
+
+func searchIndexFixture() []core.CapabilityDescriptor {
+	return []core.CapabilityDescriptor{
+		{
+			ID:          "mcp:github:createIssue",
+			Kind:        core.CapabilityKindMCPTool,
+			Name:        "mcp__github__createIssue",
+			Description: "Open a new issue in a GitHub repository",
+			Source:      "github",
+			InputSchema: map[string]any{"type": "object", "properties": map[string]any{"owner": map[string]any{}, "repo": map[string]any{}, "title": map[string]any{}}},
+		},
+		{
+			ID:          "mcp:github:list_pull_requests",
+			Kind:        core.CapabilityKindMCPTool,
+			Name:        "mcp__github__list_pull_requests",
+			Description: "List pull requests for a repository",
+			Source:      "github",
+			InputSchema: map[string]any{"type": "object", "properties": map[string]any{"owner": map[string]any{}, "repo": map[string]any{}}},
+		},
+		{
+			ID:          "mcp:postgres:run_query",
+			Kind:        core.CapabilityKindMCPTool,
+			Name:        "mcp__postgres__run_query",
+			Description: "Execute a read-only SQL statement",
+			Source:      "postgres",
+			InputSchema: map[string]any{"type": "object", "properties": map[string]any{"sql": map[string]any{}}},
+		},
+		{
+			ID:          "mcp:browser:takeScreenshot",
+			Kind:        core.CapabilityKindMCPTool,
+			Name:        "mcp__browser__takeScreenshot",
+			Description: "Capture the current page",
+			Source:      "browser",
+			InputSchema: map[string]any{"type": "object", "properties": map[string]any{"fullPage": map[string]any{}}},
+		},
+	}
+}
+
+func TestTokenizeSearchTextSplitsCamelCaseAndSnakeCase(t *testing.T) {
+	got := TokenizeSearchText("mcp__github__createIssue HTTPServer list_pull_requests")
+	want := []string{"mcp", "github", "create", "issue", "http", "server", "list", "pull", "request"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected tokens %#v", got)
+	}
+}
+
+func TestSearchIndexRanksNameMatchesFirst(t *testing.T) {
+	index := NewSearchIndex(searchIndexFixture())
+	hits := index.Search("create issue", 10)
+	if len(hits) == 0 || hits[0].Capability.Name != "mcp__github__createIssue" {
+		t.Fatalf("expected createIssue ranked first, got %#v", hits)
+	}
+}
+
+func TestSearchIndexExpandsSynonyms(t *testing.T) {
+	index := NewSearchIndex(searchIndexFixture())
+	hits := index.Search("database", 10)
+	if len(hits) != 1 || hits[0].Capability.Name != "mcp__postgres__run_query" {
+		t.Fatalf("expected synonym match on sql property, got %#v", hits)
+	}
+	hits = index.Search("screenshot", 10)
+	if len(hits) == 0 || hits[0].Capability.Name != "mcp__browser__takeScreenshot" {
+		t.Fatalf("expected camelCase name match, got %#v", hits)
+	}
+}
+
+func TestSearchIndexMatchesSchemaPropertyNames(t *testing.T) {
+	index := NewSearchIndex(searchIndexFixture())
+	hits := index.Search("+pull owner", 10)
+	if len(hits) != 1 || hits[0].Capability.Name != "mcp__github__list_pull_requests" {
+		t.Fatalf("expected required property match, got %#v", hits)
+	}
+}
+
+func TestSearchIndexEmptyQueryListsAll(t *testing.T) {
+	index := NewSearchIndex(searchIndexFixture())
+	hits := index.Search("", 2)
+	if len(hits) != 2 || hits[0].Capability.Name != "mcp__browser__takeScreenshot" {
+		t.Fatalf("expected stable name-ordered listing, got %#v", hits)
+	}
+}
+
+func TestSearchIndexSelectResolvesExactNames(t *testing.T) {
+	index := NewSearchIndex(searchIndexFixture())
+	names, ok := ParseSelectQuery("select:mcp__postgres__run_query, MCP__GITHUB__CREATEISSUE,missing_tool")
+	if !ok {
+		t.Fatal("expected select query to parse")
+	}
+	found, missing := index.Select(names)
+	if len(found) != 2 || found[0].Name != "mcp__postgres__run_query" || found[1].Name != "mcp__github__createIssue" {
+		t.Fatalf("unexpected selected capabilities %#v", found)
+	}
+	if !reflect.DeepEqual(missing, []string{"missing_tool"}) {
+		t.Fatalf("unexpected missing names %#v", missing)
+	}
+}
@@ -79,6 +79,20 @@ func Suggest(req SuggestRequest) []Suggestion {
 				ReplaceEnd:   end,
 			})
 		}
+		for _, server := range req.MCPServers {
+			server = strings.TrimSpace(server)
+			if _, ok := completionScore(server, typePart); !ok {
+				continue
+			}
+			out = append(out, Suggestion{
+				Kind:         SuggestionKindMCPResource,
+				Label:        "@" + server + ":",
+				Detail:       "MCP resources",
+				InsertText:   "@" + server + ":",
+				ReplaceStart: start,
+				ReplaceEnd:   end,
+			})
+		}
 		sortSuggestions(out)
 		if len(out) > limit {
 			return out[:limit]
@@ -88,7 +102,12 @@ func Suggest(req SuggestRequest) []Suggestion {
 
 	resourceType, ok := ParseResourceType(typePart)
 	if !ok {
-		return nil
+		out = suggestMCPResources(req.MCPResources, typePart, idPart, start, end)
+		sortSuggestions(out)
+		if len(out) > limit {
+			return out[:limit]
+		}
+		return out
 	}
 	queryID := strings.ToLower(strings.TrimSpace(idPart))
 	for _, resource := range req.Resources {
@@ -135,6 +154,40 @@ func Suggest(req SuggestRequest) []Suggestion {
 	return out
 }
 
+// suggestMCPResources completes @server:uri against the resources of the
+// server named before the colon, matching on uri or resource name.
+func suggestMCPResources(items []MCPResourceItem, server string, query string, start int, end int) []Suggestion {
+	out := []Suggestion{}
+	for _, item := range items {
+		if !strings.EqualFold(strings.TrimSpace(item.Server), strings.TrimSpace(server)) {
+			continue
+		}
+		uri := strings.TrimSpace(item.URI)
+		if uri == "" {
+			continue
+		}
+		if _, ok := completionScore(uri, query); !ok {
+			if _, ok := completionScore(item.Name, query); !ok {
+				continue
+			}
+		}
+		detail := strings.TrimSpace(item.Name)
+		if strings.EqualFold(detail, uri) {
+			detail = ""
+		}
+		insert := "@" + strings.TrimSpace(item.Server) + ":" + uri
+		out = append(out, Suggestion{
+			Kind:         SuggestionKindMCPResource,
+			Label:        insert,
+			Detail:       detail,
+			InsertText:   insert,
+			ReplaceStart: start,
+			ReplaceEnd:   end,
+		})
+	}
+	return out
+}
+
 func tokenBounds(draft string, cursor int) (int, int) {
 	start := cursor
 	for start > 0 {
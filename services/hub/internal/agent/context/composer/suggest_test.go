@@ -65,3 +65,30 @@ func TestSuggestFileResourceByPathAndBasename(t *testing.T) {
 		t.Fatalf("expected empty file detail, got %q", suggestions[0].Detail)
 	}
 }
+
+func TestSuggestMCPResources(t *testing.T) {
+	servers := Suggest(SuggestRequest{
+		Draft:      "read @do",
+		Cursor:     8,
+		MCPServers: []string{"docs", "github"},
+	})
+	if len(servers) != 1 || servers[0].InsertText != "@docs:" || servers[0].Kind != SuggestionKindMCPResource {
+		t.Fatalf("expected mcp server prefix suggestion, got %+v", servers)
+	}
+
+	suggestions := Suggest(SuggestRequest{
+		Draft:  "read @docs:gui",
+		Cursor: 14,
+		MCPResources: []MCPResourceItem{
+			{Server: "docs", URI: "file:///guide.md", Name: "User guide"},
+			{Server: "docs", URI: "file:///api.md", Name: "API"},
+			{Server: "github", URI: "repo://guide", Name: "Guide"},
+		},
+	})
+	if len(suggestions) != 1 {
+		t.Fatalf("expected one mcp resource suggestion, got %+v", suggestions)
+	}
+	if suggestions[0].InsertText != "@docs:file:///guide.md" || suggestions[0].Detail != "User guide" || suggestions[0].ReplaceStart != 5 {
+		t.Fatalf("unexpected mcp resource suggestion %+v", suggestions[0])
+	}
+}
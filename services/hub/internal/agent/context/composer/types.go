@@ -74,6 +74,7 @@ const (
 	SuggestionKindCommand      SuggestionKind = "command"
 	SuggestionKindResourceType SuggestionKind = "resource_type"
 	SuggestionKindResource     SuggestionKind = "resource"
+	SuggestionKindMCPResource  SuggestionKind = "mcp_resource"
 )
 
 // ResourceCatalogItem is one resource entry available to composer suggestions.
@@ -83,6 +84,13 @@ type ResourceCatalogItem struct {
 	Name string
 }
 
+// MCPResourceItem is one MCP resource completable as @server:uri.
+type MCPResourceItem struct {
+	Server string
+	URI    string
+	Name   string
+}
+
 // Suggestion is one completion candidate.
 type Suggestion struct {
 	Kind         SuggestionKind
@@ -100,4 +108,8 @@ type SuggestRequest struct {
 	Limit     int
 	Commands  []CommandMeta
 	Resources []ResourceCatalogItem
+	// MCPServers are offered as @server: prefixes; MCPResources complete
+	// the uri after one.
+	MCPServers   []string
+	MCPResources []MCPResourceItem
 }
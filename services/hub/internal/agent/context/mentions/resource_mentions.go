@@ -0,0 +1,97 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mentions
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"goyais/services/hub/internal/agent/core"
+)
+
+// maxResourceMentionBytes caps how much of one resource is inlined.
+const maxResourceMentionBytes = 64 * 1024
+
+// ResourceMention is one @server:uri reference to an MCP resource.
+type ResourceMention struct {
+	Raw    string
+	Server string
+	URI    string
+}
+
+// ResourceReader reads the text of one MCP resource.
+type ResourceReader interface {
+	ReadResourceText(ctx context.Context, server string, uri string) (string, error)
+}
+
+// resourceMentionPattern matches @server:scheme:rest. The uri must carry a
+// scheme so composer mentions such as @file:path do not match.
+var resourceMentionPattern = regexp.MustCompile(`@([A-Za-z0-9_.-]+):([A-Za-z][A-Za-z0-9+.-]*:[^\s]+)`)
+
+// ExtractResourceMentions finds @server:uri mentions whose server is one of
+// servers, in order of first appearance and without duplicates.
+func ExtractResourceMentions(prompt string, servers []string) []ResourceMention {
+	known := map[string]string{}
+	for _, server := range servers {
+		if token := normalizeMentionToken(server); token != "" {
+			known[token] = strings.TrimSpace(server)
+		}
+	}
+	if len(known) == 0 {
+		return nil
+	}
+	out := []ResourceMention{}
+	seen := map[string]struct{}{}
+	for _, match := range resourceMentionPattern.FindAllStringSubmatch(prompt, -1) {
+		server, exists := known[normalizeMentionToken(match[1])]
+		if !exists {
+			continue
+		}
+		uri := strings.TrimRight(match[2], ".,;:!?)]}'\"")
+		if !strings.Contains(uri, ":") || strings.HasSuffix(uri, ":") {
+			continue
+		}
+		key := server + "\x00" + uri
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = append(out, ResourceMention{Raw: "@" + match[1] + ":" + uri, Server: server, URI: uri})
+	}
+	return out
+}
+
+// ResolveResourceMentions reads every resource mentioned in prompt and
+// returns one prompt section per mention, attributed to
+// "mcp_resource:<server>:<uri>". A resource that cannot be read yields a
+// section saying so, so the model does not assume it saw the content.
+func ResolveResourceMentions(ctx context.Context, prompt string, servers []string, reader ResourceReader) []core.PromptSection {
+	if reader == nil {
+		return nil
+	}
+	mentions := ExtractResourceMentions(prompt, servers)
+	sections := make([]core.PromptSection, 0, len(mentions))
+	for _, mention := range mentions {
+		source := "mcp_resource:" + mention.Server + ":" + mention.URI
+		text, err := reader.ReadResourceText(ctx, mention.Server, mention.URI)
+		if err != nil {
+			sections = append(sections, core.PromptSection{
+				Source:  source,
+				Content: fmt.Sprintf("MCP resource %s could not be read: %v", mention.Raw, err),
+			})
+			continue
+		}
+		if len(text) > maxResourceMentionBytes {
+			text = strings.ToValidUTF8(text[:maxResourceMentionBytes], "") + "\n[truncated]"
+		}
+		sections = append(sections, core.PromptSection{
+			Source:  source,
+			Content: fmt.Sprintf("MCP resource %s:\n%s", mention.Raw, strings.TrimSpace(text)),
+		})
+	}
+	return sections
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mentions
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type fakeResourceReader map[string]string
+
+func (r fakeResourceReader) ReadResourceText(_ context.Context, server string, uri string) (string, error) {
+	text, ok := r[server+" "+uri]
+	if !ok {
+		return "", errors.New("resource not found")
+	}
+	return text, nil
+}
+
+func TestExtractResourceMentionsMatchesKnownServersOnly(t *testing.T) {
+	got := ExtractResourceMentions("compare @docs:file:///guide.md, @File:src/main.go and @other:memo://x then @docs:file:///guide.md", []string{"docs"})
+	if len(got) != 1 {
+		t.Fatalf("expected one deduplicated mention, got %+v", got)
+	}
+	if got[0].Server != "docs" || got[0].URI != "file:///guide.md" || got[0].Raw != "@docs:file:///guide.md" {
+		t.Fatalf("unexpected mention %+v", got[0])
+	}
+}
+
+func TestResolveResourceMentionsBuildsAttributableSections(t *testing.T) {
+	reader := fakeResourceReader{"Docs memo://readme": "hello"}
+	sections := ResolveResourceMentions(context.Background(), "see @docs:memo://readme and @docs:memo://gone", []string{"Docs"}, reader)
+	if len(sections) != 2 {
+		t.Fatalf("expected two sections, got %+v", sections)
+	}
+	if sections[0].Source != "mcp_resource:Docs:memo://readme" || sections[0].Content != "MCP resource @docs:memo://readme:\nhello" {
+		t.Fatalf("unexpected resolved section %+v", sections[0])
+	}
+	if !strings.Contains(sections[1].Content, "could not be read: resource not found") {
+		t.Fatalf("expected unreadable resource to be reported, got %+v", sections[1])
+	}
+}
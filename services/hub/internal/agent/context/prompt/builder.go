@@ -16,6 +16,7 @@ import (
 	"goyais/services/hub/internal/agent/core"
 	mcpext "goyais/services/hub/internal/agent/extensions/mcp"
 	skillsext "goyais/services/hub/internal/agent/extensions/skills"
+	"goyais/services/hub/internal/agent/policy/redaction"
 )
 
 // BuilderOptions configures filesystem/env-driven behavior of prompt Builder.
@@ -268,10 +269,29 @@ func (b *Builder) Build(ctx context.Context, req core.BuildContextRequest) (core
 		appendSection("additional_directory_instruction", section)
 	}
 
-	return core.PromptContext{
+	redactionConfig, err := redaction.ConfigFromSettings(settingsResult.Effective)
+	if err != nil {
+		return core.PromptContext{}, err
+	}
+	redactor := redaction.New(redactionConfig)
+	detections := []core.SecretDetection{}
+	for index := range sections {
+		redacted, found := redactor.Redact(sections[index].Content, "prompt:"+sections[index].Source)
+		sections[index].Content = redacted
+		detections = append(detections, found...)
+	}
+	// Sections already account for every detection; placeholders are stable,
+	// so the assembled prompt only needs the replacement.
+	systemPrompt, _ = redactor.Redact(systemPrompt, "prompt")
+
+	promptContext := core.PromptContext{
 		SystemPrompt: strings.TrimSpace(systemPrompt),
 		Sections:     sections,
-	}, nil
+	}
+	if len(detections) > 0 {
+		promptContext.Redactions = detections
+	}
+	return promptContext, nil
 }
 
 func (b *Builder) loadEffectiveSettings(workingDir string) (settings.MergeResult, error) {
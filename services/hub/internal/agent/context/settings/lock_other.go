@@ -0,0 +1,14 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+//go:build !unix && !windows
+
+package settings
+
+import "os"
+
+// Platforms without file locks only get the in-process lock.
+func lockFile(*os.File) error { return nil }
+
+func unlockFile(*os.File) error { return nil }
@@ -0,0 +1,247 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// permissionEffects lists permissions.* arrays in rules DSL precedence order.
+var permissionEffects = []string{"deny", "ask", "allow"}
+
+// UserSettingsPath is the user-layer file written by Goyais itself.
+func UserSettingsPath(homeDir string) string {
+	return filepath.Join(strings.TrimSpace(homeDir), ".goyais", "config.json")
+}
+
+// ProjectSettingsPath is the shared project-layer settings file.
+func ProjectSettingsPath(workingDir string) string {
+	return filepath.Join(strings.TrimSpace(workingDir), ".goyais", "settings.json")
+}
+
+// PermissionRuleLines converts merged permissions.deny/ask/allow entries such
+// as "Bash(git status)" into rules DSL lines. sources[i] names the settings
+// layers that contributed lines[i], e.g. "settings:user,project".
+func PermissionRuleLines(merged MergeResult) ([]string, []string) {
+	permissions, _ := merged.Effective["permissions"].(map[string]any)
+	lines := make([]string, 0, 8)
+	sources := make([]string, 0, 8)
+	for _, effect := range permissionEffects {
+		items, _ := permissions[effect].([]any)
+		source := "settings"
+		if trace, ok := merged.Source["permissions."+effect]; ok && len(trace.ContributingLayers) > 0 {
+			layers := make([]string, 0, len(trace.ContributingLayers))
+			for _, layer := range trace.ContributingLayers {
+				layers = append(layers, string(layer))
+			}
+			source = "settings:" + strings.Join(layers, ",")
+		}
+		for _, item := range items {
+			text, ok := item.(string)
+			if !ok || strings.TrimSpace(text) == "" {
+				continue
+			}
+			lines = append(lines, effect+" "+strings.TrimSpace(text))
+			sources = append(sources, source)
+		}
+	}
+	return lines, sources
+}
+
+// ReadValue returns the value at keyPath in one settings file, or nil when
+// the file or key is missing.
+func ReadValue(path string, keyPath ...string) (any, error) {
+	root, err := readSettingsFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var current any = root
+	for _, key := range keyPath {
+		asMap, ok := current.(map[string]any)
+		if !ok {
+			return nil, nil
+		}
+		current = asMap[key]
+	}
+	return current, nil
+}
+
+// ReadStringList returns the string array at keyPath in one settings file.
+func ReadStringList(path string, keyPath ...string) ([]string, error) {
+	value, err := ReadValue(path, keyPath...)
+	if err != nil {
+		return nil, err
+	}
+	items, _ := value.([]any)
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if text, ok := item.(string); ok && strings.TrimSpace(text) != "" {
+			out = append(out, strings.TrimSpace(text))
+		}
+	}
+	return out, nil
+}
+
+// AppendStringList adds entry to the string array at keyPath, creating the
+// file and intermediate objects as needed. It reports false when the entry
+// was already present.
+func AppendStringList(path string, entry string, keyPath ...string) (bool, error) {
+	entry = strings.TrimSpace(entry)
+	if entry == "" || len(keyPath) == 0 {
+		return false, fmt.Errorf("settings entry and key path are required")
+	}
+	unlock, err := lockSettingsFile(path)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	root, err := readSettingsFile(path)
+	if err != nil {
+		return false, err
+	}
+	if root == nil {
+		root = map[string]any{}
+	}
+	parent := root
+	for _, key := range keyPath[:len(keyPath)-1] {
+		child, ok := parent[key].(map[string]any)
+		if !ok {
+			if parent[key] != nil {
+				return false, fmt.Errorf("settings %q: %s is not an object", path, key)
+			}
+			child = map[string]any{}
+			parent[key] = child
+		}
+		parent = child
+	}
+	last := keyPath[len(keyPath)-1]
+	items, ok := parent[last].([]any)
+	if !ok && parent[last] != nil {
+		return false, fmt.Errorf("settings %q: %s is not an array", path, last)
+	}
+	for _, item := range items {
+		if text, ok := item.(string); ok && strings.TrimSpace(text) == entry {
+			return false, nil
+		}
+	}
+	parent[last] = append(items, entry)
+	return true, writeSettingsFile(path, root)
+}
+
+// RemoveStringList deletes entry from the string array at keyPath and prunes
+// objects left empty. It reports false when the entry was not present.
+func RemoveStringList(path string, entry string, keyPath ...string) (bool, error) {
+	entry = strings.TrimSpace(entry)
+	if entry == "" || len(keyPath) == 0 {
+		return false, nil
+	}
+	unlock, err := lockSettingsFile(path)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	root, err := readSettingsFile(path)
+	if err != nil || root == nil {
+		return false, err
+	}
+	removed := removeStringEntry(root, entry, keyPath)
+	if !removed {
+		return false, nil
+	}
+	return true, writeSettingsFile(path, root)
+}
+
+func removeStringEntry(parent map[string]any, entry string, keyPath []string) bool {
+	key := keyPath[0]
+	if len(keyPath) > 1 {
+		child, ok := parent[key].(map[string]any)
+		if !ok {
+			return false
+		}
+		removed := removeStringEntry(child, entry, keyPath[1:])
+		if removed && len(child) == 0 {
+			delete(parent, key)
+		}
+		return removed
+	}
+	items, _ := parent[key].([]any)
+	filtered := make([]any, 0, len(items))
+	removed := false
+	for _, item := range items {
+		if text, ok := item.(string); ok && strings.TrimSpace(text) == entry {
+			removed = true
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+	if !removed {
+		return false
+	}
+	if len(filtered) == 0 {
+		delete(parent, key)
+	} else {
+		parent[key] = filtered
+	}
+	return true
+}
+
+// settingsFileLocks serializes the writers of one process; the lock on the
+// sidecar file serializes them with other processes.
+var settingsFileLocks sync.Map
+
+// lockSettingsFile takes the exclusive lock that guards a read-modify-write
+// of path. The lock is held on path+".lock" because the settings file itself
+// is replaced by rename on every write.
+func lockSettingsFile(path string) (func(), error) {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("lock settings %q: %w", path, err)
+	}
+	value, _ := settingsFileLocks.LoadOrStore(absolute, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	if err := os.MkdirAll(filepath.Dir(absolute), 0o755); err != nil {
+		mu.Unlock()
+		return nil, fmt.Errorf("lock settings %q: %w", path, err)
+	}
+	file, err := os.OpenFile(absolute+".lock", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		mu.Unlock()
+		return nil, fmt.Errorf("lock settings %q: %w", path, err)
+	}
+	if err := lockFile(file); err != nil {
+		_ = file.Close()
+		mu.Unlock()
+		return nil, fmt.Errorf("lock settings %q: %w", path, err)
+	}
+	return func() {
+		_ = unlockFile(file)
+		_ = file.Close()
+		mu.Unlock()
+	}, nil
+}
+
+func writeSettingsFile(path string, root map[string]any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("write settings %q: %w", path, err)
+	}
+	encoded, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode settings %q: %w", path, err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(encoded, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write settings %q: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("write settings %q: %w", path, err)
+	}
+	return nil
+}
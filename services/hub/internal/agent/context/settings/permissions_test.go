@@ -0,0 +1,98 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package settings
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestAppendAndRemoveStringListPreservesOtherKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".goyais", "settings.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(`{"model":"gpt-5","permissions":{"deny":["Bash(rm:*)"]}}`), 0o644); err != nil {
+		t.Fatalf("write settings: %v", err)
+	}
+
+	added, err := AppendStringList(path, "Bash(git status:*)", "permissions", "allow")
+	if err != nil || !added {
+		t.Fatalf("append = %v, %v", added, err)
+	}
+	if added, _ := AppendStringList(path, "Bash(git status:*)", "permissions", "allow"); added {
+		t.Fatalf("expected duplicate append to be a no-op")
+	}
+	if _, err := AppendStringList(path, "Read", "permissions", "sessions", "sess_1", "allow"); err != nil {
+		t.Fatalf("append nested: %v", err)
+	}
+
+	merged, err := LoadAndMerge(LoadOptions{WorkingDir: filepath.Dir(filepath.Dir(path)), HomeDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("load and merge: %v", err)
+	}
+	if merged.Effective["model"] != "gpt-5" {
+		t.Fatalf("expected unrelated keys to survive, got %#v", merged.Effective)
+	}
+	lines, sources := PermissionRuleLines(merged)
+	if !reflect.DeepEqual(lines, []string{"deny Bash(rm:*)", "allow Bash(git status:*)"}) {
+		t.Fatalf("lines = %#v", lines)
+	}
+	if sources[1] != "settings:project" {
+		t.Fatalf("sources = %#v", sources)
+	}
+
+	removed, err := RemoveStringList(path, "Read", "permissions", "sessions", "sess_1", "allow")
+	if err != nil || !removed {
+		t.Fatalf("remove nested = %v, %v", removed, err)
+	}
+	if value, _ := ReadValue(path, "permissions", "sessions"); value != nil {
+		t.Fatalf("expected empty session objects to be pruned, got %#v", value)
+	}
+	if removed, _ := RemoveStringList(path, "Read", "permissions", "allow"); removed {
+		t.Fatalf("expected missing entry removal to report false")
+	}
+	allow, err := ReadStringList(path, "permissions", "allow")
+	if err != nil || !reflect.DeepEqual(allow, []string{"Bash(git status:*)"}) {
+		t.Fatalf("allow = %#v, %v", allow, err)
+	}
+}
+
+func TestConcurrentSettingsWritesKeepEveryEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".goyais", "settings.json")
+	if _, err := AppendStringList(path, "Bash(keep)", "permissions", "allow"); err != nil {
+		t.Fatalf("seed entry: %v", err)
+	}
+	var wg sync.WaitGroup
+	for index := 0; index < 16; index++ {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			if _, err := AppendStringList(path, fmt.Sprintf("Bash(task %d)", index), "permissions", "allow"); err != nil {
+				t.Errorf("append entry %d: %v", index, err)
+			}
+		}(index)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if _, err := RemoveStringList(path, "Bash(keep)", "permissions", "allow"); err != nil {
+			t.Errorf("remove entry: %v", err)
+		}
+	}()
+	wg.Wait()
+
+	entries, err := ReadStringList(path, "permissions", "allow")
+	if err != nil {
+		t.Fatalf("read entries: %v", err)
+	}
+	if len(entries) != 16 {
+		t.Fatalf("expected every concurrent append to survive, got %d entries: %v", len(entries), entries)
+	}
+}
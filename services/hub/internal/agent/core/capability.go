@@ -65,6 +65,9 @@ type MCPServerConfig struct {
 	Command   string
 	Env       map[string]string
 	Tools     []string
+	// CredentialScope selects whose stored OAuth tokens a remote server
+	// uses; the hub sets one scope per workspace user.
+	CredentialScope string
 }
 
 // RuntimeModelConfig is the strong runtime snapshot for one model request.
@@ -76,6 +79,10 @@ type RuntimeModelConfig struct {
 	Params        map[string]any
 	TimeoutMS     int
 	MaxModelTurns int
+	// Vision marks a model that accepts images, such as those tools return.
+	Vision bool
+	// ContextWindowTokens is the model's context window; zero when unknown.
+	ContextWindowTokens int
 }
 
 // RuntimeToolingConfig is the strong runtime snapshot for the Tooling V2
@@ -89,6 +96,10 @@ type RuntimeToolingConfig struct {
 	PromptBudgetChars       int
 	MCPSearchEnabled        bool
 	SearchThresholdRatio    float64
+	// PermissionPromptTool names an mcp__<server>__<tool> that decides
+	// approvals instead of a human; empty keeps interactive approvals.
+	PermissionPromptTool      string
+	PermissionPromptTimeoutMS int
 }
 
 // RuntimeConfig bundles the resolved model and tooling runtime snapshots.
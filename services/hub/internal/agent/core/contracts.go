@@ -6,6 +6,7 @@ package core
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 )
@@ -59,6 +60,33 @@ type ToolResult struct {
 	Output   map[string]any
 	Diff     []DiffItem
 	Error    *RunError
+	// Content holds typed result parts, such as the images and resources an
+	// MCP tool returns next to its text.
+	Content []ToolContentPart
+	// StructuredContent is the JSON result of tools that declare one.
+	StructuredContent map[string]any
+}
+
+// ToolContentType names the kind of one ToolContentPart.
+type ToolContentType string
+
+const (
+	ToolContentText         ToolContentType = "text"
+	ToolContentImage        ToolContentType = "image"
+	ToolContentAudio        ToolContentType = "audio"
+	ToolContentResource     ToolContentType = "resource"
+	ToolContentResourceLink ToolContentType = "resource_link"
+)
+
+// ToolContentPart is one typed part of a tool result. Data carries base64
+// image, audio or blob content; resources also set URI and may set Text.
+type ToolContentPart struct {
+	Type     ToolContentType `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	MIMEType string          `json:"mime_type,omitempty"`
+	Data     string          `json:"data,omitempty"`
+	URI      string          `json:"uri,omitempty"`
+	Name     string          `json:"name,omitempty"`
 }
 
 // HookEvent is the unified hook input envelope.
@@ -157,6 +185,17 @@ type PromptSection struct {
 type PromptContext struct {
 	SystemPrompt string
 	Sections     []PromptSection
+	// Redactions lists secrets replaced with placeholders during assembly.
+	Redactions []SecretDetection
+}
+
+// SecretDetection records one secret replaced before content left the
+// machine. It never carries the secret itself, only a short fingerprint.
+type SecretDetection struct {
+	Kind        string
+	Placeholder string
+	Fingerprint string
+	Source      string
 }
 
 // PermissionMode selects the execution permission policy profile.
@@ -170,6 +209,35 @@ const (
 	PermissionModeBypassPermissions PermissionMode = "bypassPermissions"
 )
 
+// ApprovalScope selects how long an "always allow" approval is remembered.
+type ApprovalScope string
+
+const (
+	// ApprovalScopeSession remembers the approval for the current session only.
+	ApprovalScopeSession ApprovalScope = "session"
+	// ApprovalScopeProject remembers the approval in project settings.
+	ApprovalScopeProject ApprovalScope = "project"
+	// ApprovalScopeUser remembers the approval in user settings.
+	ApprovalScopeUser ApprovalScope = "user"
+)
+
+// ParseApprovalScope normalizes raw into a known scope; empty input yields an
+// empty scope (approve once).
+func ParseApprovalScope(raw string) (ApprovalScope, error) {
+	switch ApprovalScope(strings.ToLower(strings.TrimSpace(raw))) {
+	case "":
+		return "", nil
+	case ApprovalScopeSession:
+		return ApprovalScopeSession, nil
+	case ApprovalScopeProject:
+		return ApprovalScopeProject, nil
+	case ApprovalScopeUser:
+		return ApprovalScopeUser, nil
+	default:
+		return "", fmt.Errorf("unsupported approval scope %q", raw)
+	}
+}
+
 // PermissionRequest is evaluated by PermissionGate.
 type PermissionRequest struct {
 	Mode       PermissionMode
@@ -203,3 +271,15 @@ type SnapshotRequest struct {
 	Paths     []string
 	Reason    string
 }
+
+// CheckpointInfo describes one stored checkpoint for timeline views.
+type CheckpointInfo struct {
+	ID        CheckpointID
+	SessionID SessionID
+	Reason    string
+	CreatedAt time.Time
+	// Paths are the absolute paths the checkpoint captured.
+	Paths []string
+	// SizeBytes is the captured content size before deduplication.
+	SizeBytes int64
+}
@@ -91,6 +91,25 @@ type ApprovalNeededPayload struct {
 	CapabilityScope  string
 	Input            map[string]any
 	RiskLevel        string
+	// Plan is set when the checkpoint reviews a plan submitted with
+	// ExitPlanMode rather than a single tool call.
+	Plan *PlanRecord
+}
+
+// PlanRecord is one submitted plan and its review state. It is shared by plan
+// mode approvals and team plan reviews.
+type PlanRecord struct {
+	ID         string
+	FromAgent  string
+	Title      string
+	Content    string
+	Status     string
+	Feedback   string
+	ReviewedBy string
+	// NextMode is the permission mode applied after approval, if any.
+	NextMode  string
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
 func (ApprovalNeededPayload) isEventPayload() {}
@@ -149,3 +149,9 @@ type CheckpointStore interface {
 	// Restore rolls back files to the state captured by the given checkpoint.
 	Restore(ctx context.Context, id CheckpointID) error
 }
+
+// CheckpointLister is implemented by checkpoint stores that can list their
+// checkpoints, oldest first, to build a session timeline.
+type CheckpointLister interface {
+	ListCheckpoints(ctx context.Context, sessionID SessionID) ([]CheckpointInfo, error)
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package core
+
+import (
+	"strings"
+	"time"
+
+	eventscore "goyais/services/hub/internal/agent/core/events"
+)
+
+// PlanRecord is one submitted plan and its review state. Plan mode approvals
+// and team plan reviews share this format.
+type PlanRecord = eventscore.PlanRecord
+
+const (
+	// PlanStatusPending means the plan awaits review.
+	PlanStatusPending = "pending"
+	// PlanStatusApproved means the plan was accepted.
+	PlanStatusApproved = "approved"
+	// PlanStatusRejected means the plan was rejected and needs revision.
+	PlanStatusRejected = "rejected"
+)
+
+// PlanTitle derives a short title from markdown plan content: the first
+// heading, or the first non-empty line when the plan has no heading.
+func PlanTitle(content string) string {
+	fallback := ""
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") {
+			return strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+		}
+		if fallback == "" {
+			fallback = trimmed
+		}
+	}
+	const maxTitleRunes = 80
+	if runes := []rune(fallback); len(runes) > maxTitleRunes {
+		return string(runes[:maxTitleRunes]) + "…"
+	}
+	return fallback
+}
+
+// PlanRecordMap encodes record with the snake_case keys used by transport
+// payloads.
+func PlanRecordMap(record PlanRecord) map[string]any {
+	out := map[string]any{
+		"id":         strings.TrimSpace(record.ID),
+		"from_agent": strings.TrimSpace(record.FromAgent),
+		"title":      strings.TrimSpace(record.Title),
+		"content":    record.Content,
+		"status":     strings.TrimSpace(record.Status),
+	}
+	if record.Feedback != "" {
+		out["feedback"] = record.Feedback
+	}
+	if record.ReviewedBy != "" {
+		out["reviewed_by"] = record.ReviewedBy
+	}
+	if record.NextMode != "" {
+		out["next_mode"] = record.NextMode
+	}
+	if !record.CreatedAt.IsZero() {
+		out["created_at"] = record.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	if !record.UpdatedAt.IsZero() {
+		out["updated_at"] = record.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return out
+}
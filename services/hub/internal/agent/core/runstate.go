@@ -69,6 +69,13 @@ type ControlRequest struct {
 	RunID  string
 	Action ControlAction
 	Answer *ControlAnswer
+	// Remember turns an approve into a persisted allow rule for the scope.
+	Remember ApprovalScope
+	// Mode switches the permission mode when an approve accepts a plan.
+	Mode PermissionMode
+	// Feedback explains a deny. A deny with feedback of a pending plan
+	// rejects the plan and lets the run continue instead of cancelling it.
+	Feedback string
 }
 
 // Validate verifies run target, action, and answer payload consistency.
@@ -80,6 +87,27 @@ func (r ControlRequest) Validate() error {
 	if action == "" {
 		return errors.New("action is required")
 	}
+	if strings.TrimSpace(string(r.Remember)) != "" {
+		if action != ControlActionApprove {
+			return errors.New("remember is only supported for action=approve")
+		}
+		if _, err := ParseApprovalScope(string(r.Remember)); err != nil {
+			return err
+		}
+	}
+	if mode := strings.TrimSpace(string(r.Mode)); mode != "" {
+		if action != ControlActionApprove {
+			return errors.New("mode is only supported for action=approve")
+		}
+		switch PermissionMode(mode) {
+		case PermissionModeDefault, PermissionModeAcceptEdits, PermissionModeDontAsk, PermissionModeBypassPermissions:
+		default:
+			return fmt.Errorf("unsupported mode %q", mode)
+		}
+	}
+	if strings.TrimSpace(r.Feedback) != "" && action != ControlActionDeny {
+		return errors.New("feedback is only supported for action=deny")
+	}
 	switch action {
 	case ControlActionStop, ControlActionApprove, ControlActionDeny, ControlActionResume:
 		return nil
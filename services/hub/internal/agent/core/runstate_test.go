@@ -227,3 +227,35 @@ func TestMachine_IsTerminal_CoversAllStates(t *testing.T) {
 		}
 	}
 }
+
+// Ensures plan-review fields are only accepted on the matching action.
+func TestControlRequest_ValidatePlanReviewFields(t *testing.T) {
+	valid := []ControlRequest{
+		{RunID: "run_1", Action: ControlActionApprove, Mode: PermissionModeAcceptEdits},
+		{RunID: "run_1", Action: ControlActionDeny, Feedback: "split the migration first"},
+	}
+	for _, req := range valid {
+		if err := req.Validate(); err != nil {
+			t.Fatalf("expected %#v to be valid: %v", req, err)
+		}
+	}
+	invalid := []ControlRequest{
+		{RunID: "run_1", Action: ControlActionDeny, Mode: PermissionModeAcceptEdits},
+		{RunID: "run_1", Action: ControlActionApprove, Mode: PermissionModePlan},
+		{RunID: "run_1", Action: ControlActionApprove, Feedback: "looks good"},
+	}
+	for _, req := range invalid {
+		if err := req.Validate(); err == nil {
+			t.Fatalf("expected %#v to be rejected", req)
+		}
+	}
+}
+
+func TestPlanTitle(t *testing.T) {
+	if got := PlanTitle("\n## Migrate auth\n\n1. step"); got != "Migrate auth" {
+		t.Fatalf("expected heading title, got %q", got)
+	}
+	if got := PlanTitle("Refactor the parser\n- step"); got != "Refactor the parser" {
+		t.Fatalf("expected first line title, got %q", got)
+	}
+}
@@ -36,6 +36,7 @@ const (
 	EventWorktreeCreate    = "WorktreeCreate"
 	EventWorktreeRemove    = "WorktreeRemove"
 	EventPreCompact        = "PreCompact"
+	EventMCPServerRequest  = "MCPServerRequest"
 )
 
 // Decision values follow allow/ask/deny tri-state semantics.
@@ -60,28 +61,22 @@ const (
 	HandlerTypeAgent HandlerType = "agent"
 )
 
-// CommandHandler executes a control command and returns user-facing output.
+// CommandHandler runs a shell command with the hook event JSON on stdin.
 // Used by HandlerTypeCommand.
-type CommandHandler func(ctx context.Context, event core.HookEvent, args []string) (HookHandlerResponse, error)
+type CommandHandler func(ctx context.Context, event core.HookEvent, handler Handler) (HookHandlerResponse, error)
 
-// HTTPHandler makes POST requests to external endpoints.
+// HTTPHandler POSTs the hook event JSON to an external endpoint.
 // Used by HandlerTypeHTTP.
-type HTTPHandler func(ctx context.Context, event core.HookEvent, payload map[string]any) (HookHandlerResponse, error)
+type HTTPHandler func(ctx context.Context, event core.HookEvent, handler Handler) (HookHandlerResponse, error)
 
-// PromptResolver expands a prompt command into one or more prompt sections.
-// Used by HandlerTypePrompt.
-type PromptResolver func(ctx context.Context, event core.HookEvent, args []string) ([]string, error)
+// PromptEvaluator asks a model to evaluate the hook event against prompt and
+// returns the raw model reply. Used by HandlerTypePrompt.
+type PromptEvaluator func(ctx context.Context, event core.HookEvent, prompt string) (string, error)
 
 // AgentHandler launches multi-turn subagents.
 // Used by HandlerTypeAgent.
 type AgentHandler func(ctx context.Context, event core.HookEvent, req core.SubagentRequest) (core.SubagentResult, error)
 
-// HookHandlerResponse is the unified response from hook handlers.
-type HookHandlerResponse struct {
-	Output   string
-	Metadata map[string]any
-}
-
 // MatchMode controls how one pattern is matched against event/tool values.
 type MatchMode string
 
@@ -106,12 +101,16 @@ type Rule struct {
 	Decision     string
 	Reason       string
 	Metadata     map[string]any
+	// Handler, when set, runs on every match and its response overrides the
+	// static Decision.
+	Handler *Handler
 }
 
 // Dispatcher evaluates hook rules and returns one normalized decision.
 type Dispatcher struct {
 	rules         []Rule
 	scopeResolver *hookscope.Resolver
+	handlers      Handlers
 }
 
 // NewDispatcher creates a dispatcher from one immutable rule snapshot.
@@ -125,13 +124,37 @@ func NewDispatcherWithScopeResolver(rules []Rule, resolver *hookscope.Resolver)
 	return &Dispatcher{
 		rules:         cloneRules(rules),
 		scopeResolver: resolver,
+		handlers:      DefaultHandlers(),
 	}
 }
 
+// SetHandlers replaces the executors used for rule handlers.
+func (d *Dispatcher) SetHandlers(handlers Handlers) {
+	if d == nil {
+		return
+	}
+	d.handlers = handlers
+}
+
 // Dispatch implements core.HookDispatcher with deny > ask > allow precedence.
-func (d *Dispatcher) Dispatch(_ context.Context, event core.HookEvent) (core.HookDecision, error) {
+// Matched rules with a handler run it and rank by the handler's decision.
+func (d *Dispatcher) Dispatch(ctx context.Context, event core.HookEvent) (core.HookDecision, error) {
+	evaluation, err := d.evaluate(ctx, event, true)
+	if err != nil {
+		return core.HookDecision{}, err
+	}
+	return evaluation.decision, nil
+}
+
+type evaluation struct {
+	decision  core.HookDecision
+	ranked    []scoredRule
+	unmatched []UnmatchedRule
+}
+
+func (d *Dispatcher) evaluate(ctx context.Context, event core.HookEvent, runHandlers bool) (evaluation, error) {
 	if len(d.rules) == 0 {
-		return allowDecision(), nil
+		return evaluation{decision: allowDecision()}, nil
 	}
 
 	eventType := strings.TrimSpace(event.Type)
@@ -145,26 +168,31 @@ func (d *Dispatcher) Dispatch(_ context.Context, event core.HookEvent) (core.Hoo
 	}
 
 	matched := make([]scoredRule, 0, len(d.rules))
+	unmatched := make([]UnmatchedRule, 0)
 	for _, item := range d.rules {
 		if !item.Enabled {
+			unmatched = append(unmatched, UnmatchedRule{RuleID: item.ID, Reason: UnmatchedDisabled})
 			continue
 		}
 		scopeMatch, scopeMatched := d.resolveScope(item, scopeCtx)
 		if !scopeMatched {
+			unmatched = append(unmatched, UnmatchedRule{RuleID: item.ID, Reason: UnmatchedScope})
 			continue
 		}
 		eventMatched, eventScore, eventErr := match(item.EventPattern, item.EventMatch, eventType)
 		if eventErr != nil {
-			return core.HookDecision{}, fmt.Errorf("match event pattern for rule %q failed: %w", strings.TrimSpace(item.ID), eventErr)
+			return evaluation{}, fmt.Errorf("match event pattern for rule %q failed: %w", strings.TrimSpace(item.ID), eventErr)
 		}
 		if !eventMatched {
+			unmatched = append(unmatched, UnmatchedRule{RuleID: item.ID, Reason: UnmatchedEvent})
 			continue
 		}
 		toolMatched, toolScore, toolErr := match(item.ToolPattern, item.ToolMatch, toolName)
 		if toolErr != nil {
-			return core.HookDecision{}, fmt.Errorf("match tool pattern for rule %q failed: %w", strings.TrimSpace(item.ID), toolErr)
+			return evaluation{}, fmt.Errorf("match tool pattern for rule %q failed: %w", strings.TrimSpace(item.ID), toolErr)
 		}
 		if !toolMatched {
+			unmatched = append(unmatched, UnmatchedRule{RuleID: item.ID, Reason: UnmatchedTool})
 			continue
 		}
 		matched = append(matched, scoredRule{
@@ -178,7 +206,25 @@ func (d *Dispatcher) Dispatch(_ context.Context, event core.HookEvent) (core.Hoo
 	}
 
 	if len(matched) == 0 {
-		return allowDecision(), nil
+		return evaluation{decision: allowDecision(), unmatched: unmatched}, nil
+	}
+
+	executions := make([]HandlerExecution, 0, len(matched))
+	for index := range matched {
+		handler := matched[index].rule.Handler
+		if handler == nil || !runHandlers {
+			continue
+		}
+		execution := d.handlers.Run(ctx, *handler, event)
+		execution.RuleID = strings.TrimSpace(matched[index].rule.ID)
+		if acceptsPlainContext(eventType) && execution.Error == "" && execution.Response.AdditionalContext == "" && !strings.HasPrefix(execution.Response.Output, "{") {
+			execution.Response.AdditionalContext = execution.Response.Output
+		}
+		executions = append(executions, execution)
+		matched[index].execution = &executions[len(executions)-1]
+		if decision := execution.Decision(); decision != "" {
+			matched[index].decisionScore = decisionPriority(decision)
+		}
 	}
 
 	sort.SliceStable(matched, func(i, j int) bool {
@@ -197,6 +243,20 @@ func (d *Dispatcher) Dispatch(_ context.Context, event core.HookEvent) (core.Hoo
 	selectedEntry := matched[0]
 	selected := selectedEntry.rule
 	decision := normalizeDecision(selected.Decision)
+	reason := strings.TrimSpace(selected.Reason)
+	if selectedEntry.execution != nil {
+		// Decision also turns "continue": false into a deny.
+		if handlerDecision := selectedEntry.execution.Decision(); handlerDecision != "" {
+			decision = handlerDecision
+		}
+		responseReason := strings.TrimSpace(selectedEntry.execution.Response.Reason)
+		if responseReason == "" {
+			responseReason = strings.TrimSpace(selectedEntry.execution.Response.StopReason)
+		}
+		if responseReason != "" {
+			reason = responseReason
+		}
+	}
 	if decision == "" {
 		decision = DecisionAllow
 	}
@@ -204,23 +264,33 @@ func (d *Dispatcher) Dispatch(_ context.Context, event core.HookEvent) (core.Hoo
 	if metadata == nil {
 		metadata = map[string]any{}
 	}
-	reason := strings.TrimSpace(selected.Reason)
 	if reason != "" {
 		metadata["reason"] = reason
 	}
+	mergeHandlerExecutions(metadata, executions)
 	if _, exists := metadata["scope"]; !exists {
 		metadata["scope"] = string(selectedEntry.scope)
 	}
 	if len(selectedEntry.scopeTrace) > 0 {
 		metadata["scope_trace"] = append([]string(nil), selectedEntry.scopeTrace...)
 	}
-	return core.HookDecision{
-		Decision:        decision,
-		MatchedPolicyID: strings.TrimSpace(selected.ID),
-		Metadata:        metadata,
+	return evaluation{
+		decision: core.HookDecision{
+			Decision:        decision,
+			MatchedPolicyID: strings.TrimSpace(selected.ID),
+			Metadata:        metadata,
+		},
+		ranked:    matched,
+		unmatched: unmatched,
 	}, nil
 }
 
+// acceptsPlainContext reports whether plain handler output is added to the
+// model context for eventType rather than kept as log output.
+func acceptsPlainContext(eventType string) bool {
+	return eventType == EventUserPromptSubmit || eventType == EventSessionStart
+}
+
 type scoredRule struct {
 	rule            Rule
 	decisionScore   int
@@ -228,6 +298,7 @@ type scoredRule struct {
 	scopeRank       int
 	scopeTrace      []string
 	specificityRank int
+	execution       *HandlerExecution
 }
 
 func allowDecision() core.HookDecision {
@@ -337,6 +408,7 @@ func cloneRules(rules []Rule) []Rule {
 			Decision:     normalizeDecision(item.Decision),
 			Reason:       strings.TrimSpace(item.Reason),
 			Metadata:     cloneMapAny(item.Metadata),
+			Handler:      cloneHandler(item.Handler),
 		})
 	}
 	return out
@@ -0,0 +1,415 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"goyais/services/hub/internal/agent/core"
+)
+
+// DefaultHandlerTimeout bounds one handler run when Handler.TimeoutMS is unset.
+const DefaultHandlerTimeout = 60 * time.Second
+
+// commandBlockExitCode is the exit status a command handler uses to deny the
+// event; stderr becomes the reason.
+const commandBlockExitCode = 2
+
+// commandWaitDelay bounds how long a killed command may hold its pipes.
+const commandWaitDelay = 500 * time.Millisecond
+
+// maxHandlerOutputBytes caps captured handler output.
+const maxHandlerOutputBytes = 64 * 1024
+
+// Handler configures the executable part of one rule.
+type Handler struct {
+	Type HandlerType
+	// Command is the shell command line for HandlerTypeCommand.
+	Command string
+	// URL and Headers configure HandlerTypeHTTP.
+	URL     string
+	Headers map[string]string
+	// Prompt holds the evaluation instructions for HandlerTypePrompt and
+	// HandlerTypeAgent.
+	Prompt string
+	// Agent names the subagent for HandlerTypeAgent.
+	Agent     string
+	MaxTurns  int
+	TimeoutMS int
+}
+
+// Timeout returns the effective handler timeout.
+func (h Handler) Timeout() time.Duration {
+	if h.TimeoutMS <= 0 {
+		return DefaultHandlerTimeout
+	}
+	return time.Duration(h.TimeoutMS) * time.Millisecond
+}
+
+// HookHandlerResponse is the unified response from hook handlers.
+type HookHandlerResponse struct {
+	// Decision is allow, ask or deny; empty leaves the rule decision in place.
+	Decision          string
+	Reason            string
+	UpdatedInput      map[string]any
+	AdditionalContext string
+	// UpdatedPrompt replaces the user prompt on UserPromptSubmit.
+	UpdatedPrompt string
+	// Continue is false when the handler asks to stop the run.
+	Continue   *bool
+	StopReason string
+	Output     string
+	Metadata   map[string]any
+}
+
+// HandlerExecution records one handler run.
+type HandlerExecution struct {
+	RuleID   string
+	Type     HandlerType
+	Response HookHandlerResponse
+	Duration time.Duration
+	TimedOut bool
+	Error    string
+}
+
+// Decision returns the normalized decision of a successful run. A handler
+// that asked to stop counts as deny.
+func (e HandlerExecution) Decision() string {
+	if e.Error != "" {
+		return ""
+	}
+	if e.Response.Continue != nil && !*e.Response.Continue {
+		return DecisionDeny
+	}
+	return normalizeDecision(e.Response.Decision)
+}
+
+// Map encodes the execution for decision metadata and audit records.
+func (e HandlerExecution) Map() map[string]any {
+	out := map[string]any{
+		"rule_id":     e.RuleID,
+		"type":        string(e.Type),
+		"decision":    e.Decision(),
+		"duration_ms": e.Duration.Milliseconds(),
+	}
+	if reason := strings.TrimSpace(e.Response.Reason); reason != "" {
+		out["reason"] = reason
+	}
+	if e.Response.Continue != nil {
+		out["continue"] = *e.Response.Continue
+	}
+	if e.Response.StopReason != "" {
+		out["stop_reason"] = e.Response.StopReason
+	}
+	if e.TimedOut {
+		out["timed_out"] = true
+	}
+	if e.Error != "" {
+		out["error"] = e.Error
+	}
+	return out
+}
+
+// Handlers holds the executors for each handler type. Nil executors make the
+// matching handlers fail without blocking the event.
+type Handlers struct {
+	Command CommandHandler
+	HTTP    HTTPHandler
+	Prompt  PromptEvaluator
+	Agent   AgentHandler
+}
+
+// DefaultHandlers returns the local command and HTTP executors. Prompt and
+// agent handlers need a model and are supplied by the runtime.
+func DefaultHandlers() Handlers {
+	return Handlers{
+		Command: RunCommandHandler,
+		HTTP:    PostHTTPHandler(http.DefaultClient),
+	}
+}
+
+// Run executes handler for event within its timeout.
+func (h Handlers) Run(ctx context.Context, handler Handler, event core.HookEvent) HandlerExecution {
+	runCtx, cancel := context.WithTimeout(ctx, handler.Timeout())
+	defer cancel()
+
+	startedAt := time.Now()
+	response, err := h.run(runCtx, handler, event)
+	execution := HandlerExecution{
+		Type:     handler.Type,
+		Response: response,
+		Duration: time.Since(startedAt),
+	}
+	if err != nil {
+		execution.Response = HookHandlerResponse{}
+		execution.Error = strings.TrimSpace(err.Error())
+		if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+			execution.TimedOut = true
+			execution.Error = fmt.Sprintf("%s handler timed out after %s", handler.Type, handler.Timeout())
+		}
+	}
+	return execution
+}
+
+func (h Handlers) run(ctx context.Context, handler Handler, event core.HookEvent) (HookHandlerResponse, error) {
+	switch handler.Type {
+	case HandlerTypeCommand:
+		if h.Command == nil {
+			return HookHandlerResponse{}, errors.New("command handler is not configured")
+		}
+		return h.Command(ctx, event, handler)
+	case HandlerTypeHTTP:
+		if h.HTTP == nil {
+			return HookHandlerResponse{}, errors.New("http handler is not configured")
+		}
+		return h.HTTP(ctx, event, handler)
+	case HandlerTypePrompt:
+		if h.Prompt == nil {
+			return HookHandlerResponse{}, errors.New("prompt handler is not configured")
+		}
+		reply, err := h.Prompt(ctx, event, evaluationPrompt(handler.Prompt, event))
+		if err != nil {
+			return HookHandlerResponse{}, err
+		}
+		return ParseHandlerResponse(reply)
+	case HandlerTypeAgent:
+		if h.Agent == nil {
+			return HookHandlerResponse{}, errors.New("agent handler is not configured")
+		}
+		result, err := h.Agent(ctx, event, core.SubagentRequest{
+			AgentName: strings.TrimSpace(handler.Agent),
+			Prompt:    evaluationPrompt(handler.Prompt, event),
+			MaxTurns:  handler.MaxTurns,
+		})
+		if err != nil {
+			return HookHandlerResponse{}, err
+		}
+		return ParseHandlerResponse(result.Summary)
+	default:
+		return HookHandlerResponse{}, fmt.Errorf("unsupported hook handler type %q", handler.Type)
+	}
+}
+
+// RunCommandHandler runs handler.Command through the shell with the event
+// JSON on stdin. Exit 0 parses stdout as a response; exit 2 denies the event
+// with stderr as the reason; other failures are reported as errors.
+func RunCommandHandler(ctx context.Context, event core.HookEvent, handler Handler) (HookHandlerResponse, error) {
+	command := strings.TrimSpace(handler.Command)
+	if command == "" {
+		return HookHandlerResponse{}, errors.New("command is required")
+	}
+	payload, err := EventJSON(event)
+	if err != nil {
+		return HookHandlerResponse{}, err
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Dir = extractContextString(event.Payload, "cwd", "working_dir")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Children of the shell may keep the pipes open after it is killed.
+	cmd.WaitDelay = commandWaitDelay
+	runErr := cmd.Run()
+	if ctx.Err() != nil {
+		return HookHandlerResponse{}, ctx.Err()
+	}
+	if runErr != nil {
+		var exitErr *exec.ExitError
+		if errors.As(runErr, &exitErr) && exitErr.ExitCode() == commandBlockExitCode {
+			return HookHandlerResponse{
+				Decision: DecisionDeny,
+				Reason:   truncateOutput(strings.TrimSpace(stderr.String())),
+			}, nil
+		}
+		return HookHandlerResponse{}, fmt.Errorf("command failed: %v: %s", runErr, truncateOutput(strings.TrimSpace(stderr.String())))
+	}
+	return ParseHandlerResponse(stdout.String())
+}
+
+// PostHTTPHandler returns an HTTPHandler that POSTs the event JSON with client
+// and parses the response body. Non-2xx statuses are errors.
+func PostHTTPHandler(client *http.Client) HTTPHandler {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context, event core.HookEvent, handler Handler) (HookHandlerResponse, error) {
+		endpoint := strings.TrimSpace(handler.URL)
+		if endpoint == "" {
+			return HookHandlerResponse{}, errors.New("url is required")
+		}
+		payload, err := EventJSON(event)
+		if err != nil {
+			return HookHandlerResponse{}, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+		if err != nil {
+			return HookHandlerResponse{}, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for key, value := range handler.Headers {
+			req.Header.Set(key, value)
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			return HookHandlerResponse{}, err
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(io.LimitReader(res.Body, maxHandlerOutputBytes))
+		if err != nil {
+			return HookHandlerResponse{}, err
+		}
+		if res.StatusCode < 200 || res.StatusCode >= 300 {
+			return HookHandlerResponse{}, fmt.Errorf("http handler returned %d: %s", res.StatusCode, truncateOutput(strings.TrimSpace(string(body))))
+		}
+		return ParseHandlerResponse(string(body))
+	}
+}
+
+// EventJSON encodes event as the JSON document handlers receive.
+func EventJSON(event core.HookEvent) ([]byte, error) {
+	document := cloneMapAny(event.Payload)
+	document["hook_event_name"] = strings.TrimSpace(event.Type)
+	if sessionID := strings.TrimSpace(string(event.SessionID)); sessionID != "" {
+		document["session_id"] = sessionID
+	}
+	if runID := strings.TrimSpace(string(event.RunID)); runID != "" {
+		document["run_id"] = runID
+	}
+	return json.Marshal(document)
+}
+
+// ParseHandlerResponse parses handler output. A JSON object is read as a
+// structured response in snake_case or camelCase; any other output is kept
+// as plain text with no decision.
+func ParseHandlerResponse(raw string) (HookHandlerResponse, error) {
+	trimmed := strings.TrimSpace(raw)
+	response := HookHandlerResponse{Output: truncateOutput(trimmed)}
+	document := map[string]any{}
+	if !strings.HasPrefix(trimmed, "{") || json.Unmarshal([]byte(trimmed), &document) != nil {
+		return response, nil
+	}
+	if specific, ok := document["hookSpecificOutput"].(map[string]any); ok {
+		for key, value := range specific {
+			if _, exists := document[key]; !exists {
+				document[key] = value
+			}
+		}
+	}
+	decision := lookupString(document, "decision", "permissionDecision", "permission_decision")
+	switch strings.ToLower(decision) {
+	case "block":
+		decision = DecisionDeny
+	case "approve":
+		decision = DecisionAllow
+	}
+	if decision != "" && normalizeDecision(decision) == "" {
+		return HookHandlerResponse{}, fmt.Errorf("unsupported hook decision %q", decision)
+	}
+	response.Decision = normalizeDecision(decision)
+	response.Reason = lookupString(document, "reason", "permissionDecisionReason", "permission_decision_reason")
+	response.AdditionalContext = lookupString(document, "additionalContext", "additional_context")
+	response.StopReason = lookupString(document, "stopReason", "stop_reason")
+	response.UpdatedPrompt = lookupString(document, "updatedPrompt", "updated_prompt")
+	for _, key := range []string{"updatedInput", "updated_input"} {
+		if updated, ok := document[key].(map[string]any); ok {
+			response.UpdatedInput = updated
+			break
+		}
+	}
+	if value, ok := document["continue"].(bool); ok {
+		response.Continue = &value
+	}
+	if metadata, ok := document["metadata"].(map[string]any); ok {
+		response.Metadata = metadata
+	}
+	return response, nil
+}
+
+// mergeHandlerExecutions folds handler responses into decision metadata. The
+// first updated input wins; additional context is concatenated in order.
+func mergeHandlerExecutions(metadata map[string]any, executions []HandlerExecution) {
+	if len(executions) == 0 {
+		return
+	}
+	results := make([]map[string]any, 0, len(executions))
+	contexts := make([]string, 0, len(executions))
+	for _, execution := range executions {
+		results = append(results, execution.Map())
+		if execution.Error != "" {
+			continue
+		}
+		response := execution.Response
+		if _, exists := metadata["updated_input"]; !exists && len(response.UpdatedInput) > 0 {
+			metadata["updated_input"] = cloneMapAny(response.UpdatedInput)
+		}
+		if _, exists := metadata["updated_prompt"]; !exists && strings.TrimSpace(response.UpdatedPrompt) != "" {
+			metadata["updated_prompt"] = strings.TrimSpace(response.UpdatedPrompt)
+		}
+		if text := strings.TrimSpace(response.AdditionalContext); text != "" {
+			contexts = append(contexts, text)
+		}
+		if response.Continue != nil && !*response.Continue {
+			metadata["continue"] = false
+			if _, exists := metadata["stop_reason"]; !exists && response.StopReason != "" {
+				metadata["stop_reason"] = response.StopReason
+			}
+		}
+	}
+	if len(contexts) > 0 {
+		metadata["additional_context"] = strings.Join(contexts, "\n\n")
+	}
+	metadata["handler_results"] = results
+}
+
+func evaluationPrompt(prompt string, event core.HookEvent) string {
+	payload, _ := EventJSON(event)
+	return strings.TrimSpace(strings.Join([]string{
+		strings.TrimSpace(prompt),
+		"Hook event:",
+		string(payload),
+		`Reply with one JSON object: {"decision": "allow" | "ask" | "deny", "reason": "...", "additionalContext": "..."}.`,
+	}, "\n\n"))
+}
+
+func lookupString(document map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := document[key].(string); ok && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func truncateOutput(value string) string {
+	if len(value) <= maxHandlerOutputBytes {
+		return value
+	}
+	return value[:maxHandlerOutputBytes]
+}
+
+func cloneHandler(input *Handler) *Handler {
+	if input == nil {
+		return nil
+	}
+	out := *input
+	out.Type = HandlerType(strings.ToLower(strings.TrimSpace(string(input.Type))))
+	if len(input.Headers) > 0 {
+		out.Headers = make(map[string]string, len(input.Headers))
+		for key, value := range input.Headers {
+			out.Headers[key] = value
+		}
+	}
+	return &out
+}
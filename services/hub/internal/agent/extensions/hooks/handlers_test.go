@@ -0,0 +1,228 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"goyais/services/hub/internal/agent/core"
+)
+
+func TestRunCommandHandlerReadsEventFromStdin(t *testing.T) {
+	event := core.HookEvent{Type: EventPreToolUse, SessionID: "sess_1", Payload: map[string]any{"tool_name": "Bash"}}
+
+	response, err := RunCommandHandler(context.Background(), event, Handler{
+		Type:    HandlerTypeCommand,
+		Command: `grep -q '"tool_name":"Bash"' && printf '{"decision":"ask","reason":"review bash","additionalContext":"ci is red"}'`,
+	})
+	if err != nil {
+		t.Fatalf("command handler failed: %v", err)
+	}
+	if response.Decision != DecisionAsk || response.Reason != "review bash" || response.AdditionalContext != "ci is red" {
+		t.Fatalf("unexpected response %#v", response)
+	}
+
+	response, err = RunCommandHandler(context.Background(), event, Handler{Type: HandlerTypeCommand, Command: "echo 'rm is blocked' >&2; exit 2"})
+	if err != nil || response.Decision != DecisionDeny || response.Reason != "rm is blocked" {
+		t.Fatalf("expected exit 2 to deny with stderr reason, got %#v %v", response, err)
+	}
+
+	if _, err := RunCommandHandler(context.Background(), event, Handler{Type: HandlerTypeCommand, Command: "exit 1"}); err == nil {
+		t.Fatalf("expected other exit codes to fail")
+	}
+}
+
+func TestHandlersRunEnforcesTimeout(t *testing.T) {
+	execution := DefaultHandlers().Run(context.Background(), Handler{Type: HandlerTypeCommand, Command: "sleep 5", TimeoutMS: 50}, core.HookEvent{Type: EventStop})
+	if !execution.TimedOut || execution.Decision() != "" || !strings.Contains(execution.Error, "timed out") {
+		t.Fatalf("expected timed out execution, got %#v", execution)
+	}
+}
+
+func TestPostHTTPHandlerSendsEventBody(t *testing.T) {
+	var received map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("X-Hook-Token") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_, _ = w.Write([]byte(`{"decision":"allow","updated_input":{"command":"ls -la"}}`))
+	}))
+	defer server.Close()
+
+	response, err := PostHTTPHandler(server.Client())(context.Background(), core.HookEvent{
+		Type:    EventPreToolUse,
+		RunID:   "run_1",
+		Payload: map[string]any{"tool_name": "Bash", "input": map[string]any{"command": "ls"}},
+	}, Handler{Type: HandlerTypeHTTP, URL: server.URL, Headers: map[string]string{"X-Hook-Token": "secret"}})
+	if err != nil {
+		t.Fatalf("http handler failed: %v", err)
+	}
+	if received["hook_event_name"] != EventPreToolUse || received["run_id"] != "run_1" {
+		t.Fatalf("unexpected event body %#v", received)
+	}
+	if response.Decision != DecisionAllow || response.UpdatedInput["command"] != "ls -la" {
+		t.Fatalf("unexpected response %#v", response)
+	}
+}
+
+func TestDispatchRunsRuleHandlers(t *testing.T) {
+	prompts := []string{}
+	dispatcher := NewDispatcher([]Rule{
+		{
+			ID:           "static-allow",
+			Enabled:      true,
+			EventPattern: EventPreToolUse,
+			Decision:     DecisionAllow,
+		},
+		{
+			ID:           "prompt-review",
+			Enabled:      true,
+			EventPattern: EventPreToolUse,
+			ToolPattern:  "Bash",
+			Decision:     DecisionAllow,
+			Handler:      &Handler{Type: HandlerTypePrompt, Prompt: "Deny destructive commands."},
+		},
+		{
+			ID:           "agent-review",
+			Enabled:      true,
+			EventPattern: EventPreToolUse,
+			Handler:      &Handler{Type: HandlerTypeAgent, Agent: "reviewer"},
+		},
+	})
+	dispatcher.SetHandlers(Handlers{
+		Prompt: func(_ context.Context, _ core.HookEvent, prompt string) (string, error) {
+			prompts = append(prompts, prompt)
+			return `{"decision":"deny","reason":"destructive command","additionalContext":"use git clean -n first"}`, nil
+		},
+		Agent: func(_ context.Context, _ core.HookEvent, req core.SubagentRequest) (core.SubagentResult, error) {
+			if req.AgentName != "reviewer" {
+				t.Fatalf("unexpected subagent request %#v", req)
+			}
+			return core.SubagentResult{Summary: `{"continue":false,"stopReason":"needs human review"}`}, nil
+		},
+	})
+
+	decision, err := dispatcher.Dispatch(context.Background(), core.HookEvent{
+		Type:    EventPreToolUse,
+		Payload: map[string]any{"tool_name": "Bash", "input": map[string]any{"command": "rm -rf ."}},
+	})
+	if err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	if decision.Decision != DecisionDeny || decision.MatchedPolicyID != "agent-review" && decision.MatchedPolicyID != "prompt-review" {
+		t.Fatalf("expected handler deny to win, got %#v", decision)
+	}
+	if len(prompts) != 1 || !strings.Contains(prompts[0], "rm -rf .") || !strings.Contains(prompts[0], "Deny destructive commands.") {
+		t.Fatalf("expected prompt with instructions and event, got %#v", prompts)
+	}
+	if decision.Metadata["additional_context"] != "use git clean -n first" || decision.Metadata["continue"] != false || decision.Metadata["stop_reason"] != "needs human review" {
+		t.Fatalf("unexpected merged metadata %#v", decision.Metadata)
+	}
+	results, _ := decision.Metadata["handler_results"].([]map[string]any)
+	if len(results) != 2 {
+		t.Fatalf("expected two handler results, got %#v", decision.Metadata["handler_results"])
+	}
+}
+
+func TestDispatchKeepsRuleDecisionWhenHandlerFails(t *testing.T) {
+	dispatcher := NewDispatcher([]Rule{{
+		ID:           "ask-with-handler",
+		Enabled:      true,
+		EventPattern: EventPreToolUse,
+		Decision:     DecisionAsk,
+		Reason:       "static ask",
+		Handler:      &Handler{Type: HandlerTypePrompt, Prompt: "review"},
+	}})
+
+	decision, err := dispatcher.Dispatch(context.Background(), core.HookEvent{Type: EventPreToolUse})
+	if err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	if decision.Decision != DecisionAsk || decision.Metadata["reason"] != "static ask" {
+		t.Fatalf("expected static decision, got %#v", decision)
+	}
+	results := decision.Metadata["handler_results"].([]map[string]any)
+	if results[0]["error"] != "prompt handler is not configured" {
+		t.Fatalf("expected handler error recorded, got %#v", results)
+	}
+}
+
+func TestParseHandlerResponse(t *testing.T) {
+	response, err := ParseHandlerResponse(`{"hookSpecificOutput":{"permissionDecision":"block","permissionDecisionReason":"nope"}}`)
+	if err != nil || response.Decision != DecisionDeny || response.Reason != "nope" {
+		t.Fatalf("unexpected nested response %#v %v", response, err)
+	}
+	response, err = ParseHandlerResponse("plain text output")
+	if err != nil || response.Decision != "" || response.Output != "plain text output" {
+		t.Fatalf("unexpected plain response %#v %v", response, err)
+	}
+	if _, err := ParseHandlerResponse(`{"decision":"maybe"}`); err == nil {
+		t.Fatalf("expected unknown decision to fail")
+	}
+}
+
+func TestDispatchPromptHandlersAddContextAndRewritePrompt(t *testing.T) {
+	dispatcher := NewDispatcher([]Rule{
+		{
+			ID:           "ticket-context",
+			Enabled:      true,
+			EventPattern: EventUserPromptSubmit,
+			Handler:      &Handler{Type: HandlerTypeCommand, Command: "echo 'GOY-42: login fails on Safari'"},
+		},
+		{
+			ID:           "expand-ticket",
+			Enabled:      true,
+			EventPattern: EventUserPromptSubmit,
+			Handler:      &Handler{Type: HandlerTypeCommand, Command: `printf '{"updatedPrompt":"fix GOY-42"}'`},
+		},
+	})
+
+	decision, err := dispatcher.Dispatch(context.Background(), core.HookEvent{
+		Type:    EventUserPromptSubmit,
+		Payload: map[string]any{"prompt": "fix the ticket"},
+	})
+	if err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	if decision.Decision != DecisionAllow || decision.Metadata["additional_context"] != "GOY-42: login fails on Safari" || decision.Metadata["updated_prompt"] != "fix GOY-42" {
+		t.Fatalf("unexpected prompt hook decision %#v", decision)
+	}
+
+	decision, err = dispatcher.Dispatch(context.Background(), core.HookEvent{Type: EventPreToolUse})
+	if err != nil || decision.Metadata["additional_context"] != nil {
+		t.Fatalf("expected no context for unmatched events, got %#v %v", decision, err)
+	}
+}
+
+func TestDispatchContinueFalseDeniesOverStaticAllow(t *testing.T) {
+	dispatcher := NewDispatcher([]Rule{{
+		ID:           "freeze",
+		Enabled:      true,
+		EventPattern: "*",
+		EventMatch:   MatchGlob,
+		Decision:     DecisionAllow,
+		Handler:      &Handler{Type: HandlerTypeCommand, Command: `printf '{"continue":false,"stopReason":"repository is frozen"}'`},
+	}})
+
+	for _, event := range []core.HookEvent{
+		{Type: EventPreToolUse, Payload: map[string]any{"tool_name": "Bash"}},
+		{Type: EventUserPromptSubmit, Payload: map[string]any{"prompt": "ship it"}},
+	} {
+		decision, err := dispatcher.Dispatch(context.Background(), event)
+		if err != nil {
+			t.Fatalf("dispatch %s failed: %v", event.Type, err)
+		}
+		if decision.Decision != DecisionDeny || decision.Metadata["reason"] != "repository is frozen" {
+			t.Fatalf("expected continue:false to deny %s with the stop reason, got %#v", event.Type, decision)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package hooks
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"goyais/services/hub/internal/agent/context/settings"
+	"goyais/services/hub/internal/agent/policy/hookscope"
+)
+
+// LoadSettingsRules loads the "hooks" block of each settings layer. User, CLI
+// and managed hooks apply globally; project and local hooks are bound to the
+// working directory, which callers pass as the event's project_id.
+func LoadSettingsRules(workingDir string) ([]Rule, error) {
+	layers, err := settings.LoadLayers(settings.LoadOptions{WorkingDir: workingDir})
+	if err != nil {
+		return nil, err
+	}
+	sources := []struct {
+		layer  settings.Layer
+		values map[string]any
+		scope  hookscope.Scope
+	}{
+		{layer: settings.LayerUser, values: layers.User, scope: hookscope.ScopeGlobal},
+		{layer: settings.LayerProject, values: layers.Project, scope: hookscope.ScopeProject},
+		{layer: settings.LayerLocal, values: layers.Local, scope: hookscope.ScopeProject},
+		{layer: settings.LayerCLI, values: layers.CLI, scope: hookscope.ScopeGlobal},
+		{layer: settings.LayerManaged, values: layers.Managed, scope: hookscope.ScopeGlobal},
+	}
+	rules := make([]Rule, 0, 8)
+	for _, source := range sources {
+		layerRules, err := RulesFromSettings(source.values["hooks"], source.scope, string(source.layer))
+		if err != nil {
+			return nil, err
+		}
+		for idx := range layerRules {
+			if source.scope == hookscope.ScopeProject {
+				layerRules[idx].ProjectID = workingDir
+			}
+		}
+		rules = append(rules, layerRules...)
+	}
+	return rules, nil
+}
+
+// RulesFromSettings converts a settings "hooks" block into rules. The block
+// maps event names to matcher groups, each with a tool matcher and a list of
+// handlers:
+//
+//	{"PreToolUse": [{"matcher": "Edit|Write", "hooks": [{"type": "command", "command": "./lint.sh", "timeout": 30}]}]}
+//
+// Every handler becomes one rule with its own ID derived from source. Group
+// "decision" and "reason" keys set the static decision used when the handler
+// returns none; a group with a decision and no handlers becomes a single
+// static rule. Handler timeouts are given in seconds.
+func RulesFromSettings(value any, scope hookscope.Scope, source string) ([]Rule, error) {
+	if value == nil {
+		return nil, nil
+	}
+	events, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: hooks must be an object keyed by event name", source)
+	}
+	rules := make([]Rule, 0, len(events))
+	for _, eventName := range sortedKeys(events) {
+		groups, ok := events[eventName].([]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: hooks.%s must be a list of matcher groups", source, eventName)
+		}
+		for groupIndex, rawGroup := range groups {
+			group, ok := rawGroup.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s: hooks.%s[%d] must be an object", source, eventName, groupIndex)
+			}
+			matcher := strings.TrimSpace(stringValue(group["matcher"]))
+			if matcher == "*" {
+				matcher = ""
+			}
+			decision := stringValue(group["decision"])
+			if decision != "" && normalizeDecision(decision) == "" {
+				return nil, fmt.Errorf("%s: hooks.%s[%d] has unsupported decision %q", source, eventName, groupIndex, decision)
+			}
+			handlers, _ := group["hooks"].([]any)
+			if len(handlers) == 0 && decision != "" {
+				rules = append(rules, Rule{
+					ID:           fmt.Sprintf("%s:%s:%d", source, eventName, groupIndex),
+					Enabled:      true,
+					Scope:        scope,
+					EventPattern: eventName,
+					EventMatch:   MatchExact,
+					ToolPattern:  matcher,
+					ToolMatch:    settingsMatchMode(matcher),
+					Decision:     normalizeDecision(decision),
+					Reason:       stringValue(group["reason"]),
+				})
+				continue
+			}
+			for handlerIndex, rawHandler := range handlers {
+				entry, ok := rawHandler.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("%s: hooks.%s[%d].hooks[%d] must be an object", source, eventName, groupIndex, handlerIndex)
+				}
+				handler, err := handlerFromSettings(entry)
+				if err != nil {
+					return nil, fmt.Errorf("%s: hooks.%s[%d].hooks[%d]: %w", source, eventName, groupIndex, handlerIndex, err)
+				}
+				rules = append(rules, Rule{
+					ID:           fmt.Sprintf("%s:%s:%d:%d", source, eventName, groupIndex, handlerIndex),
+					Enabled:      true,
+					Scope:        scope,
+					EventPattern: eventName,
+					EventMatch:   MatchExact,
+					ToolPattern:  matcher,
+					ToolMatch:    settingsMatchMode(matcher),
+					Decision:     normalizeDecision(decision),
+					Reason:       stringValue(group["reason"]),
+					Handler:      handler,
+				})
+			}
+		}
+	}
+	return rules, nil
+}
+
+func handlerFromSettings(entry map[string]any) (*Handler, error) {
+	handler := &Handler{
+		Type:    HandlerType(strings.ToLower(stringValue(entry["type"]))),
+		Command: stringValue(entry["command"]),
+		URL:     stringValue(entry["url"]),
+		Prompt:  stringValue(entry["prompt"]),
+		Agent:   stringValue(entry["agent"]),
+	}
+	if headers, ok := entry["headers"].(map[string]any); ok {
+		handler.Headers = make(map[string]string, len(headers))
+		for key, value := range headers {
+			handler.Headers[key] = stringValue(value)
+		}
+	}
+	if seconds, ok := entry["timeout"].(float64); ok && seconds > 0 {
+		handler.TimeoutMS = int(seconds * 1000)
+	}
+	switch handler.Type {
+	case HandlerTypeCommand:
+		if handler.Command == "" {
+			return nil, fmt.Errorf("command handler requires command")
+		}
+	case HandlerTypeHTTP:
+		if handler.URL == "" {
+			return nil, fmt.Errorf("http handler requires url")
+		}
+	case HandlerTypePrompt:
+		if handler.Prompt == "" {
+			return nil, fmt.Errorf("prompt handler requires prompt")
+		}
+	case HandlerTypeAgent:
+		if handler.Prompt == "" && handler.Agent == "" {
+			return nil, fmt.Errorf("agent handler requires prompt or agent")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported handler type %q", handler.Type)
+	}
+	return handler, nil
+}
+
+// settingsMatchMode treats matchers with alternation or other regex syntax
+// as regular expressions, matching the settings file convention.
+func settingsMatchMode(matcher string) MatchMode {
+	if strings.ContainsAny(matcher, "|()[]^$+\\") {
+		return MatchRegex
+	}
+	return ""
+}
+
+func stringValue(value any) string {
+	text, _ := value.(string)
+	return strings.TrimSpace(text)
+}
+
+func sortedKeys(values map[string]any) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package hooks
+
+import (
+	"context"
+	"strings"
+
+	"goyais/services/hub/internal/agent/core"
+	"goyais/services/hub/internal/agent/policy/hookscope"
+)
+
+// Reasons a rule did not match a simulated event.
+const (
+	UnmatchedDisabled = "disabled"
+	UnmatchedScope    = "scope"
+	UnmatchedEvent    = "event"
+	UnmatchedTool     = "tool"
+)
+
+// SimulateOptions controls how Simulate treats rule handlers.
+type SimulateOptions struct {
+	// RunHandlers executes matched handlers with "dry_run": true added to the
+	// event payload. When false only static rule decisions are ranked.
+	RunHandlers bool
+}
+
+// RankedRule is one matched rule in the order Dispatch ranks it. The first
+// entry supplies the final decision.
+type RankedRule struct {
+	RuleID         string          `json:"rule_id"`
+	Decision       string          `json:"decision"`
+	StaticDecision string          `json:"static_decision,omitempty"`
+	Scope          hookscope.Scope `json:"scope"`
+	ScopeRank      int             `json:"scope_rank"`
+	ScopeTrace     []string        `json:"scope_trace,omitempty"`
+	Specificity    int             `json:"specificity"`
+	// Handler is the HandlerExecution.Map of the rule's handler run, if any.
+	Handler map[string]any `json:"handler,omitempty"`
+}
+
+// UnmatchedRule names a rule that was skipped and the check that failed.
+type UnmatchedRule struct {
+	RuleID string `json:"rule_id"`
+	Reason string `json:"reason"`
+}
+
+// Simulation is the full trace of one simulated dispatch.
+type Simulation struct {
+	Decision      string          `json:"decision"`
+	MatchedRuleID string          `json:"matched_rule_id,omitempty"`
+	Metadata      map[string]any  `json:"metadata,omitempty"`
+	Ranking       []RankedRule    `json:"ranking"`
+	Unmatched     []UnmatchedRule `json:"unmatched,omitempty"`
+}
+
+// Simulate evaluates event exactly like Dispatch and also returns the scored
+// rule ranking and the rules that did not match.
+func (d *Dispatcher) Simulate(ctx context.Context, event core.HookEvent, options SimulateOptions) (Simulation, error) {
+	if options.RunHandlers {
+		payload := cloneMapAny(event.Payload)
+		payload["dry_run"] = true
+		event.Payload = payload
+	}
+	result, err := d.evaluate(ctx, event, options.RunHandlers)
+	if err != nil {
+		return Simulation{}, err
+	}
+	ranking := make([]RankedRule, 0, len(result.ranked))
+	for _, item := range result.ranked {
+		decision := normalizeDecision(item.rule.Decision)
+		if item.execution != nil {
+			if handlerDecision := item.execution.Decision(); handlerDecision != "" {
+				decision = handlerDecision
+			}
+		}
+		if decision == "" {
+			decision = DecisionAllow
+		}
+		ranked := RankedRule{
+			RuleID:         strings.TrimSpace(item.rule.ID),
+			Decision:       decision,
+			StaticDecision: normalizeDecision(item.rule.Decision),
+			Scope:          item.scope,
+			ScopeRank:      item.scopeRank,
+			ScopeTrace:     append([]string(nil), item.scopeTrace...),
+			Specificity:    item.specificityRank,
+		}
+		if item.execution != nil {
+			ranked.Handler = item.execution.Map()
+		}
+		ranking = append(ranking, ranked)
+	}
+	return Simulation{
+		Decision:      result.decision.Decision,
+		MatchedRuleID: result.decision.MatchedPolicyID,
+		Metadata:      result.decision.Metadata,
+		Ranking:       ranking,
+		Unmatched:     result.unmatched,
+	}, nil
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package hooks
+
+import (
+	"context"
+	"testing"
+
+	"goyais/services/hub/internal/agent/core"
+	"goyais/services/hub/internal/agent/policy/hookscope"
+)
+
+func TestSimulateRanksRulesAndReportsUnmatched(t *testing.T) {
+	dispatcher := NewDispatcher([]Rule{
+		{ID: "global-allow", Enabled: true, Scope: hookscope.ScopeGlobal, EventPattern: EventPreToolUse, Decision: DecisionAllow},
+		{ID: "project-ask", Enabled: true, Scope: hookscope.ScopeProject, ProjectID: "proj_1", EventPattern: EventPreToolUse, ToolPattern: "Bash", Decision: DecisionAsk, Reason: "review shell"},
+		{ID: "other-project", Enabled: true, Scope: hookscope.ScopeProject, ProjectID: "proj_2", EventPattern: EventPreToolUse, Decision: DecisionDeny},
+		{ID: "write-only", Enabled: true, EventPattern: EventPreToolUse, ToolPattern: "Write", Decision: DecisionDeny},
+		{ID: "stop-hook", Enabled: true, EventPattern: EventStop, Decision: DecisionDeny},
+		{ID: "disabled", Enabled: false, EventPattern: EventPreToolUse, Decision: DecisionDeny},
+		{
+			ID:           "lint",
+			Enabled:      true,
+			EventPattern: EventPreToolUse,
+			Decision:     DecisionAllow,
+			Handler:      &Handler{Type: HandlerTypeCommand, Command: "exit 1"},
+		},
+	})
+	event := core.HookEvent{Type: EventPreToolUse, Payload: map[string]any{"tool_name": "Bash", "project_id": "proj_1"}}
+
+	simulation, err := dispatcher.Simulate(context.Background(), event, SimulateOptions{})
+	if err != nil {
+		t.Fatalf("simulate failed: %v", err)
+	}
+	decision, err := dispatcher.Dispatch(context.Background(), event)
+	if err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	if simulation.Decision != decision.Decision || simulation.MatchedRuleID != decision.MatchedPolicyID || simulation.Decision != DecisionAsk {
+		t.Fatalf("expected simulation to agree with dispatch, got %#v vs %#v", simulation, decision)
+	}
+	if len(simulation.Ranking) != 3 || simulation.Ranking[0].RuleID != "project-ask" || simulation.Ranking[1].RuleID != "global-allow" {
+		t.Fatalf("unexpected ranking %#v", simulation.Ranking)
+	}
+	if simulation.Ranking[0].Scope != hookscope.ScopeProject || len(simulation.Ranking[0].ScopeTrace) == 0 {
+		t.Fatalf("expected scope trace on ranked rule, got %#v", simulation.Ranking[0])
+	}
+	if simulation.Ranking[2].Handler != nil {
+		t.Fatalf("expected handlers to be skipped without RunHandlers, got %#v", simulation.Ranking[2])
+	}
+	reasons := map[string]string{}
+	for _, item := range simulation.Unmatched {
+		reasons[item.RuleID] = item.Reason
+	}
+	if reasons["other-project"] != UnmatchedScope || reasons["write-only"] != UnmatchedTool || reasons["stop-hook"] != UnmatchedEvent || reasons["disabled"] != UnmatchedDisabled {
+		t.Fatalf("unexpected unmatched reasons %#v", reasons)
+	}
+}
+
+func TestSimulateRunsHandlersInDryRun(t *testing.T) {
+	dispatcher := NewDispatcher([]Rule{{
+		ID:           "guard",
+		Enabled:      true,
+		EventPattern: EventPreToolUse,
+		Handler:      &Handler{Type: HandlerTypeCommand},
+	}})
+	dryRun := false
+	dispatcher.SetHandlers(Handlers{Command: func(_ context.Context, event core.HookEvent, _ Handler) (HookHandlerResponse, error) {
+		dryRun, _ = event.Payload["dry_run"].(bool)
+		return HookHandlerResponse{Decision: DecisionDeny, Reason: "blocked"}, nil
+	}})
+
+	simulation, err := dispatcher.Simulate(context.Background(), core.HookEvent{Type: EventPreToolUse}, SimulateOptions{RunHandlers: true})
+	if err != nil {
+		t.Fatalf("simulate failed: %v", err)
+	}
+	if !dryRun {
+		t.Fatalf("expected handler to see dry_run in the payload")
+	}
+	if simulation.Decision != DecisionDeny || simulation.Ranking[0].StaticDecision != "" || simulation.Ranking[0].Handler["decision"] != DecisionDeny {
+		t.Fatalf("expected handler decision in the simulation, got %#v", simulation)
+	}
+}
+
+func TestRulesFromSettings(t *testing.T) {
+	rules, err := RulesFromSettings(map[string]any{
+		"PreToolUse": []any{map[string]any{
+			"matcher": "Edit|Write",
+			"hooks":   []any{map[string]any{"type": "command", "command": "./lint.sh", "timeout": float64(30)}},
+		}},
+		"UserPromptSubmit": []any{map[string]any{
+			"hooks": []any{map[string]any{"type": "http", "url": "http://localhost:8080/context"}},
+		}},
+	}, hookscope.ScopeProject, "project")
+	if err != nil {
+		t.Fatalf("load rules failed: %v", err)
+	}
+	if len(rules) != 2 || rules[0].ID != "project:PreToolUse:0:0" || rules[0].ToolMatch != MatchRegex || rules[0].Handler.TimeoutMS != 30000 {
+		t.Fatalf("unexpected rules %#v", rules)
+	}
+	if rules[1].EventPattern != EventUserPromptSubmit || rules[1].ToolPattern != "" || rules[1].Scope != hookscope.ScopeProject {
+		t.Fatalf("unexpected prompt rule %#v", rules[1])
+	}
+
+	if _, err := RulesFromSettings(map[string]any{"Stop": []any{map[string]any{"hooks": []any{map[string]any{"type": "command"}}}}}, hookscope.ScopeGlobal, "user"); err == nil {
+		t.Fatalf("expected command handler without command to fail")
+	}
+}
@@ -15,7 +15,6 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"os/exec"
 	"strconv"
 	"strings"
 	"time"
@@ -36,16 +35,36 @@ type ServerConfig struct {
 	Command   string
 	Env       map[string]string
 	Tools     []string
+	// Auth supplies OAuth tokens to remote transports. Nil uses the token
+	// source the pool has for CredentialScope; an Authorization header in
+	// Env takes precedence.
+	Auth TokenSource
+	// CredentialScope names whose stored OAuth tokens the server uses, such
+	// as one workspace user on the hub.
+	CredentialScope string
 }
 
 // ClientManager routes qualified MCP tool calls to configured servers.
 type ClientManager struct {
 	serversByToken map[string]ServerConfig
 	timeout        time.Duration
+	pool           *SessionPool
+	sessionID      string
 }
 
-// NewClientManager creates one manager from static runtime configs.
+// NewClientManager creates one manager from static runtime configs. Its
+// connections live in the default pool, shared by all managers without a
+// hub session.
 func NewClientManager(servers []ServerConfig, timeout time.Duration) *ClientManager {
+	return NewSessionClientManager(DefaultSessionPool(), "", servers, timeout)
+}
+
+// NewSessionClientManager creates a manager whose server connections are
+// kept in pool for the hub session sessionID.
+func NewSessionClientManager(pool *SessionPool, sessionID string, servers []ServerConfig, timeout time.Duration) *ClientManager {
+	if pool == nil {
+		pool = DefaultSessionPool()
+	}
 	if timeout <= 0 {
 		timeout = defaultClientTimeout
 	}
@@ -57,17 +76,21 @@ func NewClientManager(servers []ServerConfig, timeout time.Duration) *ClientMana
 			continue
 		}
 		byToken[token] = ServerConfig{
-			Name:      name,
-			Transport: strings.TrimSpace(item.Transport),
-			Endpoint:  strings.TrimSpace(item.Endpoint),
-			Command:   strings.TrimSpace(item.Command),
-			Env:       cloneStringMap(item.Env),
-			Tools:     dedupeTrimmed(item.Tools),
+			Name:            name,
+			Transport:       strings.TrimSpace(item.Transport),
+			Endpoint:        strings.TrimSpace(item.Endpoint),
+			Command:         strings.TrimSpace(item.Command),
+			Env:             cloneStringMap(item.Env),
+			Tools:           dedupeTrimmed(item.Tools),
+			Auth:            item.Auth,
+			CredentialScope: strings.TrimSpace(item.CredentialScope),
 		}
 	}
 	return &ClientManager{
 		serversByToken: byToken,
 		timeout:        timeout,
+		pool:           pool,
+		sessionID:      strings.TrimSpace(sessionID),
 	}
 }
 
@@ -105,29 +128,47 @@ func (m *ClientManager) Call(ctx context.Context, qualifiedToolName string, inpu
 		return nil, fmt.Errorf("mcp server %q is not configured", serverToken)
 	}
 
-	callCtx := ctx
-	if callCtx == nil {
-		callCtx = context.Background()
+	callCtx, cancel := m.callContext(ctx)
+	defer cancel()
+
+	session, conn, err := m.pool.acquire(callCtx, m.sessionID, server, m.timeout)
+	if err != nil {
+		return nil, err
 	}
-	if _, hasDeadline := callCtx.Deadline(); !hasDeadline {
-		var cancel context.CancelFunc
-		callCtx, cancel = context.WithTimeout(callCtx, m.timeout)
-		defer cancel()
+	listed, err := ensureMCPToolListed(session.cachedTools(), toolName)
+	if err != nil {
+		session.release(nil)
+		return nil, err
 	}
-
-	var rawResult any
-	switch strings.ToLower(strings.TrimSpace(server.Transport)) {
-	case "stdio":
-		rawResult, err = callMCPStdio(callCtx, server, toolName, cloneMapAny(input))
-	case "http_sse":
-		rawResult, err = callMCPHTTPSSE(callCtx, server, toolName, cloneMapAny(input), m.timeout)
-	default:
-		err = fmt.Errorf("unsupported mcp transport %q", server.Transport)
+	params := map[string]any{
+		"name":      strings.TrimSpace(toolName),
+		"arguments": cloneMapAny(input),
 	}
+	if handler := progressHandlerFrom(ctx); handler != nil {
+		token := nextProgressToken()
+		params["_meta"] = map[string]any{"progressToken": token}
+		untrack := session.trackProgress(token, handler)
+		defer untrack()
+	}
+	rawResult, err := conn.Call(callCtx, "tools/call", params)
+	session.release(err)
 	if err != nil {
 		return nil, err
 	}
-	return normalizeMCPCallResult(serverToken, toolName, rawResult), nil
+	outputSchema, _ := listed["outputSchema"].(map[string]any)
+	return normalizeMCPCallResult(serverToken, toolName, rawResult, outputSchema), nil
+}
+
+// callContext bounds ctx by the manager timeout unless it already has a
+// deadline.
+func (m *ClientManager) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, hasDeadline := ctx.Deadline(); hasDeadline {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, m.timeout)
 }
 
 func buildQualifiedToolName(serverToken string, toolName string) string {
@@ -151,195 +192,6 @@ func parseQualifiedToolName(value string) (string, string, error) {
 	return serverToken, toolName, nil
 }
 
-func callMCPStdio(ctx context.Context, server ServerConfig, toolName string, input map[string]any) (any, error) {
-	command := strings.TrimSpace(server.Command)
-	if command == "" {
-		return nil, errors.New("mcp stdio command is required")
-	}
-	cmd := exec.CommandContext(ctx, "sh", "-lc", command)
-	cmd.Env = mergeEnv(server.Env)
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		return nil, err
-	}
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, err
-	}
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return nil, err
-	}
-	if err := cmd.Start(); err != nil {
-		return nil, err
-	}
-	defer func() {
-		_ = cmd.Process.Kill()
-		_, _ = io.Copy(io.Discard, stderr)
-		_ = cmd.Wait()
-	}()
-
-	reader := bufio.NewReader(stdout)
-	if err := writeFrame(stdin, map[string]any{
-		"jsonrpc": "2.0",
-		"id":      1,
-		"method":  "initialize",
-		"params": map[string]any{
-			"protocolVersion": "2024-11-05",
-			"capabilities":    map[string]any{},
-			"clientInfo":      map[string]any{"name": "goyais", "version": runtimeVersion()},
-		},
-	}); err != nil {
-		return nil, err
-	}
-	if _, err := readResponseByID(ctx, reader, 1); err != nil {
-		return nil, err
-	}
-	_ = writeFrame(stdin, map[string]any{
-		"jsonrpc": "2.0",
-		"method":  "notifications/initialized",
-		"params":  map[string]any{},
-	})
-	if err := writeFrame(stdin, map[string]any{
-		"jsonrpc": "2.0",
-		"id":      2,
-		"method":  "tools/list",
-		"params":  map[string]any{},
-	}); err != nil {
-		return nil, err
-	}
-	toolsPayload, err := readResponseByID(ctx, reader, 2)
-	if err != nil {
-		return nil, fmt.Errorf("tools/list failed: %w", err)
-	}
-	if err := ensureMCPToolListed(toolsPayload, toolName); err != nil {
-		return nil, err
-	}
-	if err := writeFrame(stdin, map[string]any{
-		"jsonrpc": "2.0",
-		"id":      3,
-		"method":  "tools/call",
-		"params": map[string]any{
-			"name":      strings.TrimSpace(toolName),
-			"arguments": cloneMapAny(input),
-		},
-	}); err != nil {
-		return nil, err
-	}
-	return readResponseByID(ctx, reader, 3)
-}
-
-func callMCPHTTPSSE(ctx context.Context, server ServerConfig, toolName string, input map[string]any, timeout time.Duration) (any, error) {
-	endpoint := strings.TrimSpace(server.Endpoint)
-	if endpoint == "" {
-		return nil, errors.New("mcp http_sse endpoint is required")
-	}
-	client := &http.Client{Timeout: timeout}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Accept", "text/event-stream")
-	for key, value := range cloneStringMap(server.Env) {
-		req.Header.Set(strings.TrimSpace(key), strings.TrimSpace(value))
-	}
-	res, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer res.Body.Close()
-	if res.StatusCode < 200 || res.StatusCode >= 300 {
-		body, _ := io.ReadAll(io.LimitReader(res.Body, maxFrameBytes))
-		return nil, fmt.Errorf("sse handshake failed status %d: %s", res.StatusCode, strings.TrimSpace(string(body)))
-	}
-
-	rpcEndpoint := endpoint
-	if discovered, discoverErr := discoverSSEEndpoint(ctx, res.Body, endpoint); discoverErr == nil && strings.TrimSpace(discovered) != "" {
-		rpcEndpoint = discovered
-	}
-	sessionID := strings.TrimSpace(res.Header.Get("Mcp-Session-Id"))
-
-	if _, err := doHTTPRPC(ctx, client, rpcEndpoint, sessionID, cloneStringMap(server.Env), map[string]any{
-		"jsonrpc": "2.0",
-		"id":      1,
-		"method":  "initialize",
-		"params": map[string]any{
-			"protocolVersion": "2024-11-05",
-			"capabilities":    map[string]any{},
-			"clientInfo":      map[string]any{"name": "goyais", "version": runtimeVersion()},
-		},
-	}); err != nil {
-		return nil, err
-	}
-	_, _ = doHTTPRPC(ctx, client, rpcEndpoint, sessionID, cloneStringMap(server.Env), map[string]any{
-		"jsonrpc": "2.0",
-		"method":  "notifications/initialized",
-		"params":  map[string]any{},
-	})
-	toolsPayload, err := doHTTPRPC(ctx, client, rpcEndpoint, sessionID, cloneStringMap(server.Env), map[string]any{
-		"jsonrpc": "2.0",
-		"id":      2,
-		"method":  "tools/list",
-		"params":  map[string]any{},
-	})
-	if err != nil {
-		return nil, fmt.Errorf("tools/list failed: %w", err)
-	}
-	if toolsMap, ok := toolsPayload.(map[string]any); ok {
-		if err := ensureMCPToolListed(toolsMap, toolName); err != nil {
-			return nil, err
-		}
-	}
-	return doHTTPRPC(ctx, client, rpcEndpoint, sessionID, cloneStringMap(server.Env), map[string]any{
-		"jsonrpc": "2.0",
-		"id":      3,
-		"method":  "tools/call",
-		"params": map[string]any{
-			"name":      strings.TrimSpace(toolName),
-			"arguments": cloneMapAny(input),
-		},
-	})
-}
-
-func normalizeMCPCallResult(serverToken string, toolName string, rawResult any) map[string]any {
-	output := ""
-	ok := true
-	if root, asMapOK := rawResult.(map[string]any); asMapOK {
-		if isError, exists := root["isError"].(bool); exists && isError {
-			ok = false
-		}
-		output = strings.TrimSpace(extractMCPContentText(root))
-		if output == "" {
-			encoded, _ := json.Marshal(root)
-			output = strings.TrimSpace(string(encoded))
-		}
-	}
-	return map[string]any{
-		"ok":      ok,
-		"server":  strings.TrimSpace(serverToken),
-		"name":    strings.TrimSpace(toolName),
-		"output":  output,
-		"raw":     rawResult,
-		"is_mcp":  true,
-		"call_ok": ok,
-	}
-}
-
-func extractMCPContentText(root map[string]any) string {
-	content, _ := root["content"].([]any)
-	parts := make([]string, 0, len(content))
-	for _, item := range content {
-		entry, _ := item.(map[string]any)
-		text := strings.TrimSpace(fmt.Sprint(entry["text"]))
-		if text == "" {
-			continue
-		}
-		parts = append(parts, text)
-	}
-	return strings.TrimSpace(strings.Join(parts, "\n"))
-}
-
 func doHTTPRPC(ctx context.Context, client *http.Client, endpoint string, sessionID string, headers map[string]string, payload map[string]any) (any, error) {
 	rawPayload, _ := json.Marshal(payload)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(rawPayload))
@@ -401,79 +253,35 @@ func writeFrame(writer io.Writer, payload any) error {
 	return err
 }
 
-func readResponseByID(ctx context.Context, reader *bufio.Reader, expectedID int) (map[string]any, error) {
+// readFrameBody reads one Content-Length framed message.
+func readFrameBody(reader *bufio.Reader) ([]byte, error) {
+	length := 0
 	for {
-		body, err := readFrame(ctx, reader)
+		line, err := reader.ReadString('\n')
 		if err != nil {
 			return nil, err
 		}
-		response := struct {
-			ID     any            `json:"id"`
-			Result map[string]any `json:"result"`
-			Error  *struct {
-				Code    int    `json:"code"`
-				Message string `json:"message"`
-			} `json:"error"`
-		}{}
-		if err := json.Unmarshal(body, &response); err != nil {
-			continue
-		}
-		if parseID(response.ID) != expectedID {
-			continue
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			break
 		}
-		if response.Error != nil {
-			return nil, errors.New(strings.TrimSpace(response.Error.Message))
+		if strings.HasPrefix(strings.ToLower(trimmed), "content-length:") {
+			value := strings.TrimSpace(strings.TrimPrefix(strings.ToLower(trimmed), "content-length:"))
+			parsed, parseErr := strconv.Atoi(value)
+			if parseErr != nil || parsed <= 0 || parsed > maxFrameBytes {
+				return nil, errors.New("invalid content-length")
+			}
+			length = parsed
 		}
-		return response.Result, nil
 	}
-}
-
-func readFrame(ctx context.Context, reader *bufio.Reader) ([]byte, error) {
-	type frameResult struct {
-		data []byte
-		err  error
+	if length <= 0 {
+		return nil, errors.New("missing content-length")
 	}
-	ch := make(chan frameResult, 1)
-	go func() {
-		length := 0
-		for {
-			line, err := reader.ReadString('\n')
-			if err != nil {
-				ch <- frameResult{nil, err}
-				return
-			}
-			trimmed := strings.TrimSpace(line)
-			if trimmed == "" {
-				break
-			}
-			if strings.HasPrefix(strings.ToLower(trimmed), "content-length:") {
-				value := strings.TrimSpace(strings.TrimPrefix(strings.ToLower(trimmed), "content-length:"))
-				parsed, parseErr := strconv.Atoi(value)
-				if parseErr != nil || parsed <= 0 || parsed > maxFrameBytes {
-					ch <- frameResult{nil, errors.New("invalid content-length")}
-					return
-				}
-				length = parsed
-			}
-		}
-		if length <= 0 {
-			ch <- frameResult{nil, errors.New("missing content-length")}
-			return
-		}
-		buf := make([]byte, length)
-		if _, err := io.ReadFull(reader, buf); err != nil {
-			ch <- frameResult{nil, err}
-			return
-		}
-		ch <- frameResult{buf, nil}
-	}()
-
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case item := <-ch:
-		return item.data, item.err
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(reader, buf); err != nil {
+		return nil, err
 	}
+	return buf, nil
 }
 
 func parseID(value any) int {
@@ -547,14 +355,15 @@ func dedupeTrimmed(input []string) []string {
 	return out
 }
 
-func ensureMCPToolListed(payload map[string]any, requestedTool string) error {
+// ensureMCPToolListed returns the tools/list entry of requestedTool, or nil
+// when the server listed no tools at all.
+func ensureMCPToolListed(tools []any, requestedTool string) (map[string]any, error) {
 	toolName := strings.TrimSpace(requestedTool)
 	if toolName == "" {
-		return errors.New("requested tool name is empty")
+		return nil, errors.New("requested tool name is empty")
 	}
-	tools, _ := payload["tools"].([]any)
 	if len(tools) == 0 {
-		return nil
+		return nil, nil
 	}
 	for _, item := range tools {
 		entry, _ := item.(map[string]any)
@@ -563,10 +372,10 @@ func ensureMCPToolListed(payload map[string]any, requestedTool string) error {
 			continue
 		}
 		if name == toolName {
-			return nil
+			return entry, nil
 		}
 	}
-	return fmt.Errorf("tools/list does not expose tool %q", toolName)
+	return nil, fmt.Errorf("tools/list does not expose tool %q", toolName)
 }
 
 func runtimeVersion() string {
@@ -0,0 +1,460 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// stdioCloseGrace is how long a server may take to exit after its stdin
+	// is closed before it is killed.
+	stdioCloseGrace = 2 * time.Second
+	// stdioWaitDelay bounds how long a killed server may hold its pipes.
+	stdioWaitDelay = 500 * time.Millisecond
+	// stderrTailBytes is how much server stderr is kept for crash reports.
+	stderrTailBytes = 4096
+)
+
+var errConnClosed = errors.New("mcp connection closed")
+
+// rpcConn is one live JSON-RPC connection to an MCP server.
+type rpcConn interface {
+	Call(ctx context.Context, method string, params map[string]any) (any, error)
+	Notify(ctx context.Context, method string, params map[string]any) error
+	// Done is closed once the connection can no longer serve requests.
+	Done() <-chan struct{}
+	// Err reports why Done was closed.
+	Err() error
+	Close() error
+}
+
+// rpcError is an error object returned by the server.
+type rpcError struct {
+	Code    int
+	Message string
+}
+
+func (e *rpcError) Error() string {
+	message := strings.TrimSpace(e.Message)
+	if message == "" {
+		message = fmt.Sprintf("mcp rpc error %d", e.Code)
+	}
+	return message
+}
+
+// serverHandlers receive the messages a server initiates. Either may be
+// nil.
+type serverHandlers struct {
+	// notify receives notifications. It runs on the connection's read loop,
+	// so it must not block on calls over the same connection.
+	notify notificationHandler
+	// request serves server requests, each on its own goroutine. Its result
+	// or error is sent back as the response.
+	request requestHandler
+}
+
+type notificationHandler func(method string, params map[string]any)
+
+type requestHandler func(ctx context.Context, method string, params map[string]any) (any, error)
+
+type rpcResponse struct {
+	result any
+	err    error
+}
+
+// dialConn connects to server. The http_sse transport keeps no stream open
+// after the handshake and so never delivers server messages to handlers.
+func dialConn(ctx context.Context, server ServerConfig, timeout time.Duration, handlers serverHandlers) (rpcConn, error) {
+	switch strings.ToLower(strings.TrimSpace(server.Transport)) {
+	case "stdio":
+		return startStdioConn(server, handlers)
+	case "http_sse":
+		return dialSSEConn(ctx, server, timeout)
+	case "http":
+		return dialHTTPConn(server, handlers)
+	case "ws":
+		return dialWSConn(ctx, server, timeout, handlers)
+	default:
+		return nil, &ConnectError{Code: ConnectErrorUnsupportedTransport, Err: fmt.Errorf("unsupported mcp transport %q", server.Transport)}
+	}
+}
+
+// stdioConn multiplexes requests over one long-lived server process,
+// matching responses to callers by JSON-RPC id.
+type stdioConn struct {
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	stdout   io.ReadCloser
+	stderr   *tailBuffer
+	handlers serverHandlers
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int
+	pending map[int]chan rpcResponse
+	err     error
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func startStdioConn(server ServerConfig, handlers serverHandlers) (*stdioConn, error) {
+	command := strings.TrimSpace(server.Command)
+	if command == "" {
+		return nil, &ConnectError{Code: ConnectErrorMissingCommand, Err: errors.New("mcp stdio command is required")}
+	}
+	cmd := exec.Command("sh", "-lc", command)
+	cmd.Env = mergeEnv(server.Env)
+	cmd.WaitDelay = stdioWaitDelay
+	stderr := &tailBuffer{limit: stderrTailBytes}
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, &ConnectError{Code: ConnectErrorStartFailed, Err: err}
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, &ConnectError{Code: ConnectErrorStartFailed, Err: err}
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, &ConnectError{Code: ConnectErrorStartFailed, Err: fmt.Errorf("start mcp server: %w", err)}
+	}
+	conn := &stdioConn{
+		cmd:      cmd,
+		stdin:    stdin,
+		stdout:   stdout,
+		stderr:   stderr,
+		handlers: handlers,
+		nextID:   1,
+		pending:  map[int]chan rpcResponse{},
+		done:     make(chan struct{}),
+	}
+	go conn.readLoop(bufio.NewReader(stdout))
+	return conn, nil
+}
+
+func (c *stdioConn) Call(ctx context.Context, method string, params map[string]any) (any, error) {
+	c.mu.Lock()
+	if c.err != nil {
+		err := c.err
+		c.mu.Unlock()
+		return nil, err
+	}
+	id := c.nextID
+	c.nextID++
+	ch := make(chan rpcResponse, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	if err := c.write(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  method,
+		"params":  params,
+	}); err != nil {
+		c.forget(id)
+		return nil, err
+	}
+	select {
+	case response := <-ch:
+		return response.result, response.err
+	case <-ctx.Done():
+		c.forget(id)
+		_ = c.Notify(context.Background(), "notifications/cancelled", map[string]any{
+			"requestId": id,
+			"reason":    ctx.Err().Error(),
+		})
+		return nil, ctx.Err()
+	}
+}
+
+func (c *stdioConn) Notify(_ context.Context, method string, params map[string]any) error {
+	return c.write(map[string]any{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	})
+}
+
+func (c *stdioConn) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *stdioConn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Close asks the server to exit by closing its stdin, then kills it if it
+// is still running after stdioCloseGrace.
+func (c *stdioConn) Close() error {
+	c.closeOnce.Do(func() {
+		_ = c.stdin.Close()
+		select {
+		case <-c.done:
+			return
+		case <-time.After(stdioCloseGrace):
+		}
+		if c.cmd.Process != nil {
+			_ = c.cmd.Process.Kill()
+		}
+		// A grandchild of the shell may still hold stdout open.
+		_ = c.stdout.Close()
+		<-c.done
+	})
+	return nil
+}
+
+func (c *stdioConn) write(payload map[string]any) error {
+	select {
+	case <-c.done:
+		return c.Err()
+	default:
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return writeFrame(c.stdin, payload)
+}
+
+func (c *stdioConn) forget(id int) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+func (c *stdioConn) readLoop(reader *bufio.Reader) {
+	var readErr error
+	for {
+		body, err := readFrameBody(reader)
+		if err != nil {
+			readErr = err
+			break
+		}
+		c.dispatch(body)
+	}
+	waitErr := c.cmd.Wait()
+
+	cause := "exited"
+	if waitErr != nil {
+		cause = waitErr.Error()
+	} else if readErr != nil && !errors.Is(readErr, io.EOF) {
+		cause = readErr.Error()
+	}
+	if tail := strings.TrimSpace(c.stderr.String()); tail != "" {
+		cause += ": " + tail
+	}
+	c.finish(fmt.Errorf("%w: server %s", errConnClosed, cause))
+}
+
+func (c *stdioConn) dispatch(body []byte) {
+	message, ok := decodeRPCMessage(body)
+	if !ok {
+		return
+	}
+	if message.isServerMessage() {
+		if message.ID != nil {
+			go func() {
+				ctx, cancel := doneContext(c.done)
+				defer cancel()
+				_ = c.write(serveRequest(ctx, c.handlers.request, message))
+			}()
+			return
+		}
+		message.deliver(c.handlers.notify)
+		return
+	}
+	id := parseID(message.ID)
+	c.mu.Lock()
+	ch := c.pending[id]
+	delete(c.pending, id)
+	c.mu.Unlock()
+	if ch == nil {
+		return
+	}
+	result, err := message.response()
+	ch <- rpcResponse{result: result, err: err}
+}
+
+func (c *stdioConn) finish(err error) {
+	c.mu.Lock()
+	if c.err == nil {
+		c.err = err
+	}
+	pending := c.pending
+	c.pending = map[int]chan rpcResponse{}
+	c.mu.Unlock()
+	for _, ch := range pending {
+		ch <- rpcResponse{err: err}
+	}
+	close(c.done)
+}
+
+// sseConn keeps the discovered RPC endpoint and session id of an http_sse
+// server so later requests skip the handshake.
+type sseConn struct {
+	client    *http.Client
+	endpoint  string
+	sessionID string
+	headers   map[string]string
+	nextID    atomic.Int64
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func dialSSEConn(ctx context.Context, server ServerConfig, timeout time.Duration) (*sseConn, error) {
+	endpoint := strings.TrimSpace(server.Endpoint)
+	if endpoint == "" {
+		return nil, &ConnectError{Code: ConnectErrorInvalidEndpoint, Err: errors.New("mcp http_sse endpoint is required")}
+	}
+	client := &http.Client{Timeout: timeout}
+	headers := cloneStringMap(server.Env)
+	auth := newBearerAuth(server)
+	if auth != nil {
+		token, err := auth.token(ctx)
+		if err != nil {
+			return nil, unauthorizedError(endpoint, err)
+		}
+		setBearer(headers, token)
+	}
+	res, err := openSSEStream(ctx, client, endpoint, headers)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusUnauthorized && auth != nil {
+		_ = res.Body.Close()
+		token, refreshErr := auth.refresh(ctx)
+		if refreshErr != nil || token == "" {
+			return nil, unauthorizedError(endpoint, refreshErr)
+		}
+		setBearer(headers, token)
+		if res, err = openSSEStream(ctx, client, endpoint, headers); err != nil {
+			return nil, err
+		}
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusUnauthorized && auth != nil {
+		return nil, unauthorizedError(endpoint, nil)
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, maxFrameBytes))
+		return nil, &ConnectError{
+			Code: fmt.Sprintf("http_%d", res.StatusCode),
+			Err:  fmt.Errorf("sse handshake failed status %d: %s", res.StatusCode, strings.TrimSpace(string(body))),
+		}
+	}
+
+	rpcEndpoint := endpoint
+	if discovered, discoverErr := discoverSSEEndpoint(ctx, res.Body, endpoint); discoverErr == nil && strings.TrimSpace(discovered) != "" {
+		rpcEndpoint = discovered
+	}
+	return &sseConn{
+		client:    client,
+		endpoint:  rpcEndpoint,
+		sessionID: strings.TrimSpace(res.Header.Get("Mcp-Session-Id")),
+		headers:   headers,
+		done:      make(chan struct{}),
+	}, nil
+}
+
+func openSSEStream(ctx context.Context, client *http.Client, endpoint string, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, &ConnectError{Code: ConnectErrorInvalidEndpoint, Err: err}
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	for key, value := range headers {
+		req.Header.Set(strings.TrimSpace(key), strings.TrimSpace(value))
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, &ConnectError{Code: ConnectErrorHandshake, Err: fmt.Errorf("sse handshake failed: %w", err)}
+	}
+	return res, nil
+}
+
+// setBearer stores token as the Authorization header of headers.
+func setBearer(headers map[string]string, token string) {
+	if token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+}
+
+func (c *sseConn) Call(ctx context.Context, method string, params map[string]any) (any, error) {
+	select {
+	case <-c.done:
+		return nil, errConnClosed
+	default:
+	}
+	return doHTTPRPC(ctx, c.client, c.endpoint, c.sessionID, c.headers, map[string]any{
+		"jsonrpc": "2.0",
+		"id":      int(c.nextID.Add(1)),
+		"method":  method,
+		"params":  params,
+	})
+}
+
+func (c *sseConn) Notify(ctx context.Context, method string, params map[string]any) error {
+	_, err := doHTTPRPC(ctx, c.client, c.endpoint, c.sessionID, c.headers, map[string]any{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	})
+	return err
+}
+
+func (c *sseConn) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *sseConn) Err() error {
+	select {
+	case <-c.done:
+		return errConnClosed
+	default:
+		return nil
+	}
+}
+
+func (c *sseConn) Close() error {
+	c.closeOnce.Do(func() { close(c.done) })
+	return nil
+}
+
+// tailBuffer keeps the last limit bytes written to it.
+type tailBuffer struct {
+	mu    sync.Mutex
+	limit int
+	data  []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, p...)
+	if len(b.data) > b.limit {
+		b.data = append([]byte(nil), b.data[len(b.data)-b.limit:]...)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.data)
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"encoding/json"
+	"strings"
+
+	"goyais/services/hub/internal/agent/core"
+)
+
+// normalizeMCPCallResult turns a tools/call result into the runner output
+// map. Typed parts go to "content", structuredContent to
+// "structured_content", and "output" keeps a text rendering for models and
+// logs. outputSchema, when the tool declares one, is checked against the
+// structured content; a mismatch is reported as a tool error.
+func normalizeMCPCallResult(serverToken string, toolName string, rawResult any, outputSchema map[string]any) map[string]any {
+	isError := false
+	output := ""
+	parts := []core.ToolContentPart{}
+	var structured map[string]any
+	if root, asMapOK := rawResult.(map[string]any); asMapOK {
+		isError, _ = root["isError"].(bool)
+		parts = decodeContentParts(root["content"])
+		structured, _ = root["structuredContent"].(map[string]any)
+		output = strings.TrimSpace(renderContentText(parts))
+		if output == "" && structured != nil {
+			encoded, _ := json.Marshal(structured)
+			output = string(encoded)
+		}
+		if output == "" {
+			encoded, _ := json.Marshal(root)
+			output = strings.TrimSpace(string(encoded))
+		}
+	}
+	if !isError && len(outputSchema) > 0 {
+		if structured == nil {
+			isError = true
+			output = "tool " + strings.TrimSpace(toolName) + " declares an outputSchema but returned no structuredContent"
+		} else if err := validateSchema(outputSchema, structured, "structuredContent"); err != nil {
+			isError = true
+			output = "tool " + strings.TrimSpace(toolName) + " returned structuredContent that does not match its outputSchema: " + err.Error()
+		}
+	}
+	result := map[string]any{
+		"ok":       !isError,
+		"server":   strings.TrimSpace(serverToken),
+		"name":     strings.TrimSpace(toolName),
+		"output":   output,
+		"content":  parts,
+		"is_mcp":   true,
+		"call_ok":  !isError,
+		"is_error": isError,
+	}
+	if structured != nil {
+		result["structured_content"] = structured
+	}
+	return result
+}
+
+// decodeContentParts reads MCP content items. Unknown item types are kept
+// as text when they carry any.
+func decodeContentParts(raw any) []core.ToolContentPart {
+	items, _ := raw.([]any)
+	parts := make([]core.ToolContentPart, 0, len(items))
+	for _, item := range items {
+		entry, _ := item.(map[string]any)
+		if entry == nil {
+			continue
+		}
+		kind := core.ToolContentType(stringField(entry, "type"))
+		switch kind {
+		case core.ToolContentImage, core.ToolContentAudio:
+			parts = append(parts, core.ToolContentPart{
+				Type:     kind,
+				Data:     stringField(entry, "data"),
+				MIMEType: stringField(entry, "mimeType"),
+			})
+		case core.ToolContentResource:
+			resource := entry["resource"]
+			parts = append(parts, core.ToolContentPart{
+				Type:     kind,
+				URI:      stringField(resource, "uri"),
+				MIMEType: stringField(resource, "mimeType"),
+				Text:     stringField(resource, "text"),
+				Data:     stringField(resource, "blob"),
+			})
+		case core.ToolContentResourceLink:
+			parts = append(parts, core.ToolContentPart{
+				Type:     kind,
+				URI:      stringField(entry, "uri"),
+				Name:     stringField(entry, "name"),
+				MIMEType: stringField(entry, "mimeType"),
+				Text:     stringField(entry, "description"),
+			})
+		default:
+			text := stringField(entry, "text")
+			if text == "" {
+				continue
+			}
+			parts = append(parts, core.ToolContentPart{Type: core.ToolContentText, Text: text})
+		}
+	}
+	return parts
+}
+
+// renderContentText joins text parts and names the others, so text-only
+// consumers still see that an image or resource was returned.
+func renderContentText(parts []core.ToolContentPart) string {
+	lines := make([]string, 0, len(parts))
+	for _, part := range parts {
+		switch part.Type {
+		case core.ToolContentText:
+			if text := strings.TrimSpace(part.Text); text != "" {
+				lines = append(lines, text)
+			}
+		case core.ToolContentResource:
+			if text := strings.TrimSpace(part.Text); text != "" {
+				lines = append(lines, text)
+				continue
+			}
+			lines = append(lines, "[resource "+part.URI+"]")
+		case core.ToolContentResourceLink:
+			lines = append(lines, strings.TrimSpace("[resource_link "+part.Name+" "+part.URI+"]"))
+		default:
+			lines = append(lines, "["+string(part.Type)+" "+part.MIMEType+"]")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
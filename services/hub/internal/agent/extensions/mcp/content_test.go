@@ -0,0 +1,97 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"strings"
+	"testing"
+
+	"goyais/services/hub/internal/agent/core"
+)
+
+func TestNormalizeMCPCallResultKeepsTypedContent(t *testing.T) {
+	result := normalizeMCPCallResult("docs", "render", map[string]any{
+		"content": []any{
+			map[string]any{"type": "text", "text": "rendered"},
+			map[string]any{"type": "image", "data": "aGVsbG8=", "mimeType": "image/png"},
+			map[string]any{"type": "resource", "resource": map[string]any{"uri": "file:///a.txt", "mimeType": "text/plain", "text": "alpha"}},
+			map[string]any{"type": "resource_link", "uri": "file:///b.txt", "name": "b.txt"},
+		},
+		"structuredContent": map[string]any{"pages": float64(2)},
+	}, nil)
+
+	if result["ok"] != true || result["is_error"] != false {
+		t.Fatalf("expected a successful result, got %#v", result)
+	}
+	parts, _ := result["content"].([]core.ToolContentPart)
+	if len(parts) != 4 {
+		t.Fatalf("expected four content parts, got %#v", result["content"])
+	}
+	if parts[1].Type != core.ToolContentImage || parts[1].Data != "aGVsbG8=" || parts[1].MIMEType != "image/png" {
+		t.Fatalf("unexpected image part %#v", parts[1])
+	}
+	if parts[2].Type != core.ToolContentResource || parts[2].URI != "file:///a.txt" || parts[2].Text != "alpha" {
+		t.Fatalf("unexpected resource part %#v", parts[2])
+	}
+	if parts[3].Type != core.ToolContentResourceLink || parts[3].Name != "b.txt" {
+		t.Fatalf("unexpected resource link part %#v", parts[3])
+	}
+	want := "rendered\n[image image/png]\nalpha\n[resource_link b.txt file:///b.txt]"
+	if result["output"] != want {
+		t.Fatalf("unexpected output %q", result["output"])
+	}
+	structured, _ := result["structured_content"].(map[string]any)
+	if structured["pages"] != float64(2) {
+		t.Fatalf("unexpected structured content %#v", result["structured_content"])
+	}
+}
+
+func TestNormalizeMCPCallResultReportsIsError(t *testing.T) {
+	result := normalizeMCPCallResult("docs", "render", map[string]any{
+		"content": []any{map[string]any{"type": "text", "text": "page not found"}},
+		"isError": true,
+	}, nil)
+	if result["ok"] != false || result["is_error"] != true || result["output"] != "page not found" {
+		t.Fatalf("expected an error result, got %#v", result)
+	}
+}
+
+func TestNormalizeMCPCallResultValidatesOutputSchema(t *testing.T) {
+	schema := map[string]any{
+		"type":     "object",
+		"required": []any{"pages"},
+		"properties": map[string]any{
+			"pages":  map[string]any{"type": "integer"},
+			"format": map[string]any{"type": "string", "enum": []any{"pdf", "html"}},
+		},
+		"additionalProperties": false,
+	}
+	valid := normalizeMCPCallResult("docs", "render", map[string]any{
+		"structuredContent": map[string]any{"pages": float64(3), "format": "pdf"},
+	}, schema)
+	if valid["ok"] != true {
+		t.Fatalf("expected structured content to validate, got %#v", valid)
+	}
+
+	cases := map[string]map[string]any{
+		"structuredContent.pages is required":     {"format": "pdf"},
+		"structuredContent.pages must be of type": {"pages": 1.5},
+		"structuredContent.format is not one of":  {"pages": float64(1), "format": "doc"},
+		"structuredContent.extra is not allowed":  {"pages": float64(1), "extra": true},
+	}
+	for want, structured := range cases {
+		invalid := normalizeMCPCallResult("docs", "render", map[string]any{"structuredContent": structured}, schema)
+		if invalid["is_error"] != true || !strings.Contains(asStringAny(invalid["output"]), want) {
+			t.Fatalf("expected %q, got %#v", want, invalid)
+		}
+	}
+
+	missing := normalizeMCPCallResult("docs", "render", map[string]any{
+		"content": []any{map[string]any{"type": "text", "text": "3 pages"}},
+	}, schema)
+	if missing["is_error"] != true || !strings.Contains(asStringAny(missing["output"]), "no structuredContent") {
+		t.Fatalf("expected missing structured content to fail, got %#v", missing)
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Server notification methods handled by pooled sessions.
+const (
+	notificationToolsListChanged = "notifications/tools/list_changed"
+	notificationProgress         = "notifications/progress"
+	notificationMessage          = "notifications/message"
+)
+
+// maxServerLogBytes is the size at which a server log is rotated to
+// <name>.log.1.
+const maxServerLogBytes = 1 << 20
+
+// ProgressUpdate is one notifications/progress report for a tool call.
+// Total is zero when the server did not send one.
+type ProgressUpdate struct {
+	Progress float64
+	Total    float64
+	Message  string
+}
+
+type progressHandlerKey struct{}
+
+// WithProgressHandler returns a context whose MCP tool calls request
+// progress reports and pass them to handler.
+func WithProgressHandler(ctx context.Context, handler func(ProgressUpdate)) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, progressHandlerKey{}, handler)
+}
+
+func progressHandlerFrom(ctx context.Context) func(ProgressUpdate) {
+	if ctx == nil {
+		return nil
+	}
+	handler, _ := ctx.Value(progressHandlerKey{}).(func(ProgressUpdate))
+	return handler
+}
+
+var progressTokenSeq atomic.Int64
+
+func nextProgressToken() string {
+	return "goyais-" + strconv.FormatInt(progressTokenSeq.Add(1), 10)
+}
+
+// handleNotification is the notification handler of the session's
+// connection.
+func (s *pooledSession) handleNotification(method string, params map[string]any) {
+	switch method {
+	case notificationToolsListChanged:
+		// Listing tools is a call on the same connection, so it cannot run
+		// on the read loop that delivered this notification.
+		go s.refreshTools()
+	case notificationProgress:
+		token := progressTokenString(params["progressToken"])
+		s.mu.Lock()
+		handler := s.progress[token]
+		s.mu.Unlock()
+		if handler == nil {
+			return
+		}
+		progress, _ := params["progress"].(float64)
+		total, _ := params["total"].(float64)
+		message, _ := params["message"].(string)
+		handler(ProgressUpdate{Progress: progress, Total: total, Message: strings.TrimSpace(message)})
+	case notificationMessage:
+		_ = appendServerLog(s.server.Name, params, time.Now())
+	}
+}
+
+// refreshTools lists tools again after the server reported a change and
+// tells the pool's listeners for this session.
+func (s *pooledSession) refreshTools() {
+	s.mu.Lock()
+	conn, timeout := s.conn, s.timeout
+	s.mu.Unlock()
+	if conn == nil {
+		return
+	}
+	if timeout <= 0 {
+		timeout = defaultClientTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	tools, err := listTools(ctx, conn)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	if s.conn != conn {
+		s.mu.Unlock()
+		return
+	}
+	s.tools = tools
+	s.mu.Unlock()
+	if s.pool != nil {
+		s.pool.notifyToolsChanged(s.sessionID, s.server.Name, toolNames(tools))
+	}
+}
+
+// trackProgress routes progress reports for token to handler until the
+// returned func is called.
+func (s *pooledSession) trackProgress(token string, handler func(ProgressUpdate)) func() {
+	s.mu.Lock()
+	if s.progress == nil {
+		s.progress = map[string]func(ProgressUpdate){}
+	}
+	s.progress[token] = handler
+	s.mu.Unlock()
+	return func() {
+		s.mu.Lock()
+		delete(s.progress, token)
+		s.mu.Unlock()
+	}
+}
+
+func progressTokenString(raw any) string {
+	switch typed := raw.(type) {
+	case string:
+		return typed
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// toolsListener is one OnToolsChanged registration.
+type toolsListener struct {
+	sessionID string
+	fn        func(server string, tools []string)
+}
+
+// OnToolsChanged calls fn with the new tool names whenever a server pooled
+// for sessionID reports notifications/tools/list_changed. The returned func
+// removes the listener.
+func (p *SessionPool) OnToolsChanged(sessionID string, fn func(server string, tools []string)) func() {
+	if fn == nil {
+		return func() {}
+	}
+	p.mu.Lock()
+	p.nextListener++
+	id := p.nextListener
+	if p.listeners == nil {
+		p.listeners = map[int]toolsListener{}
+	}
+	p.listeners[id] = toolsListener{sessionID: sessionID, fn: fn}
+	p.mu.Unlock()
+	return func() {
+		p.mu.Lock()
+		delete(p.listeners, id)
+		p.mu.Unlock()
+	}
+}
+
+func (p *SessionPool) notifyToolsChanged(sessionID string, server string, tools []string) {
+	p.mu.Lock()
+	listeners := make([]func(string, []string), 0, len(p.listeners))
+	for _, listener := range p.listeners {
+		if listener.sessionID == sessionID {
+			listeners = append(listeners, listener.fn)
+		}
+	}
+	p.mu.Unlock()
+	for _, fn := range listeners {
+		fn(server, append([]string(nil), tools...))
+	}
+}
+
+// OnToolsChanged calls fn when one of the manager's servers changes its
+// tools list mid-session. The returned func removes the listener.
+func (m *ClientManager) OnToolsChanged(fn func(server string, tools []string)) func() {
+	if m == nil || fn == nil {
+		return func() {}
+	}
+	return m.pool.OnToolsChanged(m.sessionID, func(server string, tools []string) {
+		if _, configured := m.serversByToken[sanitizePromptToken(server)]; configured {
+			fn(server, tools)
+		}
+	})
+}
+
+// ServerLogDir is where notifications/message logs are written, one file
+// per server. GOYAIS_MCP_LOG_DIR overrides the default ~/.goyais/mcp-logs.
+func ServerLogDir() string {
+	if dir := strings.TrimSpace(os.Getenv("GOYAIS_MCP_LOG_DIR")); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil || strings.TrimSpace(home) == "" {
+		return filepath.Join(".goyais", "mcp-logs")
+	}
+	return filepath.Join(home, ".goyais", "mcp-logs")
+}
+
+// ServerLogPath is the log file of server.
+func ServerLogPath(server string) string {
+	name := sanitizePromptToken(server)
+	if name == "" {
+		name = "unnamed"
+	}
+	return filepath.Join(ServerLogDir(), name+".log")
+}
+
+var serverLogMu sync.Mutex
+
+// appendServerLog writes one notifications/message entry as
+// "<time> [<level>] <logger>: <data>".
+func appendServerLog(server string, params map[string]any, now time.Time) error {
+	level := stringField(params, "level")
+	if level == "" {
+		level = "info"
+	}
+	data, ok := params["data"].(string)
+	if !ok {
+		encoded, _ := json.Marshal(params["data"])
+		data = string(encoded)
+	}
+	line := fmt.Sprintf("%s [%s]", now.UTC().Format(time.RFC3339), level)
+	if logger := stringField(params, "logger"); logger != "" {
+		line += " " + logger + ":"
+	}
+	line += " " + strings.ReplaceAll(strings.TrimSpace(data), "\n", " ") + "\n"
+
+	serverLogMu.Lock()
+	defer serverLogMu.Unlock()
+	path := ServerLogPath(server)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() >= maxServerLogBytes {
+		_ = os.Rename(path, path+".1")
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.WriteString(line)
+	return err
+}
+
+// ReadServerLog returns the last limit lines logged by server, oldest
+// first. A server that never logged has no lines.
+func ReadServerLog(server string, limit int) ([]string, error) {
+	file, err := os.Open(ServerLogPath(server))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	lines := []string{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 4096), maxFrameBytes)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if limit > 0 && len(lines) > limit {
+			lines = lines[1:]
+		}
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func notifyingHelperServer(name string) ServerConfig {
+	server := poolHelperServer(name)
+	server.Env = map[string]string{"MCP_POOL_HELPER_NOTIFY": "1"}
+	return server
+}
+
+func TestSessionPoolRefreshesToolsOnListChanged(t *testing.T) {
+	pool := NewSessionPool(PoolOptions{})
+	defer pool.Close()
+	manager := NewSessionClientManager(pool, "sess_tools", []ServerConfig{notifyingHelperServer("local")}, 2*time.Second)
+	changed := make(chan []string, 1)
+	remove := manager.OnToolsChanged(func(server string, tools []string) {
+		if server == "local" {
+			changed <- tools
+		}
+	})
+	defer remove()
+
+	if _, err := manager.Call(context.Background(), "mcp__local__extra", map[string]any{}); err == nil || !strings.Contains(err.Error(), "does not expose tool") {
+		t.Fatalf("expected extra tool to be unknown before list change, got %v", err)
+	}
+	if _, err := manager.Call(context.Background(), "mcp__local__grow", map[string]any{}); err != nil {
+		t.Fatalf("call grow: %v", err)
+	}
+	select {
+	case tools := <-changed:
+		if !strings.HasSuffix(strings.Join(tools, ","), ",grow,extra") {
+			t.Fatalf("expected refreshed tools to include extra, got %v", tools)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected tools changed listener to fire")
+	}
+	result, err := manager.Call(context.Background(), "mcp__local__extra", map[string]any{})
+	if err != nil || asStringAny(result["output"]) != "extra" {
+		t.Fatalf("expected refreshed tool to be callable, got %+v %v", result, err)
+	}
+}
+
+func TestClientManagerCallForwardsProgressAndLogsMessages(t *testing.T) {
+	t.Setenv("GOYAIS_MCP_LOG_DIR", t.TempDir())
+	pool := NewSessionPool(PoolOptions{})
+	defer pool.Close()
+	manager := NewSessionClientManager(pool, "sess_progress", []ServerConfig{notifyingHelperServer("local")}, 2*time.Second)
+
+	var mu sync.Mutex
+	updates := []ProgressUpdate{}
+	ctx := WithProgressHandler(context.Background(), func(update ProgressUpdate) {
+		mu.Lock()
+		updates = append(updates, update)
+		mu.Unlock()
+	})
+	result, err := manager.Call(ctx, "mcp__local__progress", map[string]any{})
+	if err != nil || asStringAny(result["output"]) != "done" {
+		t.Fatalf("call progress: %+v %v", result, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(updates) != 2 || updates[1] != (ProgressUpdate{Progress: 2, Total: 2, Message: "step 2"}) {
+		t.Fatalf("unexpected progress updates %+v", updates)
+	}
+
+	lines, err := ReadServerLog("local", 10)
+	if err != nil {
+		t.Fatalf("read server log: %v", err)
+	}
+	if len(lines) != 1 || !strings.HasSuffix(lines[0], "[warning] helper: disk almost full") {
+		t.Fatalf("unexpected server log %v", lines)
+	}
+}
+
+func TestClientManagerCallCancelNotifiesServerAndKeepsProcess(t *testing.T) {
+	pool := NewSessionPool(PoolOptions{})
+	defer pool.Close()
+	manager := NewSessionClientManager(pool, "sess_cancel", []ServerConfig{notifyingHelperServer("local")}, 2*time.Second)
+	before, err := manager.Call(context.Background(), "mcp__local__pid", map[string]any{})
+	if err != nil {
+		t.Fatalf("call pid: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if _, err := manager.Call(ctx, "mcp__local__hang", map[string]any{}); err == nil {
+		t.Fatalf("expected cancelled call to fail")
+	}
+
+	cancelled, err := manager.Call(context.Background(), "mcp__local__cancelled", map[string]any{})
+	if err != nil || asStringAny(cancelled["output"]) != "1" {
+		t.Fatalf("expected server to receive one cancellation, got %+v %v", cancelled, err)
+	}
+	after, err := manager.Call(context.Background(), "mcp__local__pid", map[string]any{})
+	if err != nil || after["output"] != before["output"] {
+		t.Fatalf("expected the same server process after cancel, got %v then %v (%v)", before["output"], after["output"], err)
+	}
+}
+
+func TestStreamableHTTPCancelSendsNotification(t *testing.T) {
+	cancelled := make(chan any, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := map[string]any{}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		switch asStringAny(payload["method"]) {
+		case "tools/call":
+			// Hold the stream open until the client gives up.
+			<-r.Context().Done()
+		case "notifications/cancelled":
+			params, _ := payload["params"].(map[string]any)
+			cancelled <- params["requestId"]
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer server.Close()
+
+	conn, err := dialHTTPConn(ServerConfig{Name: "remote", Transport: "http", Endpoint: server.URL}, serverHandlers{})
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := conn.Call(ctx, "tools/call", map[string]any{"name": "slow"}); err == nil {
+		t.Fatalf("expected cancelled call to fail")
+	}
+	select {
+	case id := <-cancelled:
+		if id != float64(1) {
+			t.Fatalf("expected cancellation for request 1, got %v", id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected notifications/cancelled")
+	}
+}
@@ -0,0 +1,715 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// oauthRequestTimeout bounds discovery, registration and token requests.
+	oauthRequestTimeout = 30 * time.Second
+	// oauthRefreshSkew refreshes tokens this long before they expire.
+	oauthRefreshSkew = 30 * time.Second
+	// oauthCallbackPath is where the localhost listener receives the code.
+	oauthCallbackPath = "/callback"
+)
+
+// ErrAuthorizationRequired is returned when a remote server rejects the
+// request and no stored token can be refreshed. Run the login flow for the
+// server to authorize it.
+var ErrAuthorizationRequired = errors.New("mcp server requires authorization")
+
+// TokenSource supplies OAuth bearer tokens for remote servers, keyed by
+// server endpoint. AccessToken returns "" when the endpoint has no token.
+type TokenSource interface {
+	AccessToken(ctx context.Context, endpoint string) (string, error)
+	RefreshAccessToken(ctx context.Context, endpoint string) (string, error)
+}
+
+// TokenSources returns the token source of a credential scope, or nil when
+// servers of that scope have no stored tokens.
+type TokenSources func(scope string) TokenSource
+
+// SetTokenSources makes remote servers in the pool authenticate with the
+// tokens of their CredentialScope unless their config brings its own.
+func (p *SessionPool) SetTokenSources(sources TokenSources) {
+	p.mu.Lock()
+	p.tokens = sources
+	p.mu.Unlock()
+}
+
+func (p *SessionPool) tokenSource(scope string) TokenSource {
+	p.mu.Lock()
+	sources := p.tokens
+	p.mu.Unlock()
+	if sources == nil {
+		return nil
+	}
+	return sources(scope)
+}
+
+// IsAuthorizationRequired reports whether err means the server needs the
+// OAuth login flow to be run.
+func IsAuthorizationRequired(err error) bool {
+	var connectErr *ConnectError
+	if errors.As(err, &connectErr) && connectErr.Code == ConnectErrorUnauthorized {
+		return true
+	}
+	return errors.Is(err, ErrAuthorizationRequired)
+}
+
+// bearerAuth attaches the token of one endpoint to transport requests.
+type bearerAuth struct {
+	source   TokenSource
+	endpoint string
+}
+
+// newBearerAuth returns nil when server has no token source or sends its
+// own Authorization header.
+func newBearerAuth(server ServerConfig) *bearerAuth {
+	if server.Auth == nil {
+		return nil
+	}
+	for key := range server.Env {
+		if strings.EqualFold(strings.TrimSpace(key), "Authorization") {
+			return nil
+		}
+	}
+	return &bearerAuth{source: server.Auth, endpoint: strings.TrimSpace(server.Endpoint)}
+}
+
+func (a *bearerAuth) token(ctx context.Context) (string, error) {
+	return a.source.AccessToken(ctx, a.endpoint)
+}
+
+func (a *bearerAuth) refresh(ctx context.Context) (string, error) {
+	return a.source.RefreshAccessToken(ctx, a.endpoint)
+}
+
+// unauthorizedError reports a 401 that no token could satisfy.
+func unauthorizedError(endpoint string, cause error) *ConnectError {
+	err := fmt.Errorf("%w: %s rejected the request; run the MCP login for this server", ErrAuthorizationRequired, endpoint)
+	if cause != nil && !errors.Is(cause, ErrAuthorizationRequired) {
+		err = fmt.Errorf("%w (%v)", err, cause)
+	}
+	return &ConnectError{Code: ConnectErrorUnauthorized, Err: err}
+}
+
+// ProtectedResourceMetadata is the RFC 9728 document of an MCP server.
+type ProtectedResourceMetadata struct {
+	Resource             string   `json:"resource"`
+	AuthorizationServers []string `json:"authorization_servers"`
+	ScopesSupported      []string `json:"scopes_supported,omitempty"`
+}
+
+// AuthorizationServerMetadata is the RFC 8414 document of an authorization
+// server.
+type AuthorizationServerMetadata struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	RegistrationEndpoint          string   `json:"registration_endpoint,omitempty"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported,omitempty"`
+	ScopesSupported               []string `json:"scopes_supported,omitempty"`
+}
+
+// OAuthToken is one issued token set.
+type OAuthToken struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	TokenType    string    `json:"token_type,omitempty"`
+	Scope        string    `json:"scope,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at,omitempty"`
+}
+
+// OAuthCredentials is everything needed to use and refresh the token of
+// one server without discovering again.
+type OAuthCredentials struct {
+	Resource      string     `json:"resource"`
+	Issuer        string     `json:"issuer"`
+	TokenEndpoint string     `json:"token_endpoint"`
+	ClientID      string     `json:"client_id"`
+	ClientSecret  string     `json:"client_secret,omitempty"`
+	Token         OAuthToken `json:"token"`
+}
+
+// OAuthClientRegistration is a client registered with RFC 7591 dynamic
+// client registration.
+type OAuthClientRegistration struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// resourceMetadataPattern reads resource_metadata from a WWW-Authenticate
+// Bearer challenge.
+var resourceMetadataPattern = regexp.MustCompile(`resource_metadata="([^"]+)"`)
+
+// DiscoverProtectedResource finds the protected-resource metadata of the
+// server at endpoint: first from the 401 challenge of an unauthenticated
+// request, then from the well-known locations.
+func DiscoverProtectedResource(ctx context.Context, client *http.Client, endpoint string) (ProtectedResourceMetadata, error) {
+	httpEndpoint, err := oauthHTTPURL(endpoint)
+	if err != nil {
+		return ProtectedResourceMetadata{}, err
+	}
+	candidates := []string{}
+	if metadataURL := probeResourceMetadataURL(ctx, client, httpEndpoint); metadataURL != "" {
+		candidates = append(candidates, metadataURL)
+	}
+	candidates = append(candidates, wellKnownURLs(httpEndpoint, "oauth-protected-resource")...)
+	for _, candidate := range candidates {
+		metadata := ProtectedResourceMetadata{}
+		if err := getOAuthJSON(ctx, client, candidate, &metadata); err != nil {
+			continue
+		}
+		if len(metadata.AuthorizationServers) == 0 {
+			continue
+		}
+		if strings.TrimSpace(metadata.Resource) == "" {
+			metadata.Resource = httpEndpoint
+		}
+		return metadata, nil
+	}
+	return ProtectedResourceMetadata{}, fmt.Errorf("no protected resource metadata found for %s", httpEndpoint)
+}
+
+// DiscoverAuthorizationServer reads the metadata of issuer. A server that
+// publishes none is assumed to serve the default /authorize, /token and
+// /register endpoints at its origin.
+func DiscoverAuthorizationServer(ctx context.Context, client *http.Client, issuer string) (AuthorizationServerMetadata, error) {
+	parsed, err := url.Parse(strings.TrimSpace(issuer))
+	if err != nil || parsed.Host == "" {
+		return AuthorizationServerMetadata{}, fmt.Errorf("invalid authorization server %q", issuer)
+	}
+	candidates := wellKnownURLs(parsed.String(), "oauth-authorization-server")
+	candidates = append(candidates, wellKnownURLs(parsed.String(), "openid-configuration")...)
+	if path := strings.TrimRight(parsed.Path, "/"); path != "" {
+		candidates = append(candidates, strings.TrimRight(parsed.String(), "/")+"/.well-known/openid-configuration")
+	}
+	for _, candidate := range candidates {
+		metadata := AuthorizationServerMetadata{}
+		if err := getOAuthJSON(ctx, client, candidate, &metadata); err != nil {
+			continue
+		}
+		if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" {
+			continue
+		}
+		return metadata, checkPKCESupport(metadata)
+	}
+	origin := parsed.Scheme + "://" + parsed.Host
+	return AuthorizationServerMetadata{
+		Issuer:                origin,
+		AuthorizationEndpoint: origin + "/authorize",
+		TokenEndpoint:         origin + "/token",
+		RegistrationEndpoint:  origin + "/register",
+	}, nil
+}
+
+func checkPKCESupport(metadata AuthorizationServerMetadata) error {
+	if len(metadata.CodeChallengeMethodsSupported) == 0 {
+		return nil
+	}
+	for _, method := range metadata.CodeChallengeMethodsSupported {
+		if method == "S256" {
+			return nil
+		}
+	}
+	return fmt.Errorf("authorization server %s does not support PKCE S256", metadata.Issuer)
+}
+
+// RegisterClient registers a public client for redirectURI.
+func RegisterClient(ctx context.Context, client *http.Client, metadata AuthorizationServerMetadata, redirectURI string, clientName string) (OAuthClientRegistration, error) {
+	if strings.TrimSpace(metadata.RegistrationEndpoint) == "" {
+		return OAuthClientRegistration{}, fmt.Errorf("authorization server %s does not support dynamic client registration", metadata.Issuer)
+	}
+	body, _ := json.Marshal(map[string]any{
+		"client_name":                firstNonEmptyString(clientName, "goyais"),
+		"redirect_uris":              []string{redirectURI},
+		"grant_types":                []string{"authorization_code", "refresh_token"},
+		"response_types":             []string{"code"},
+		"token_endpoint_auth_method": "none",
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.RegistrationEndpoint, strings.NewReader(string(body)))
+	if err != nil {
+		return OAuthClientRegistration{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	registration := OAuthClientRegistration{}
+	if err := doOAuthJSON(oauthHTTPClient(client), req, &registration); err != nil {
+		return OAuthClientRegistration{}, fmt.Errorf("client registration failed: %w", err)
+	}
+	if strings.TrimSpace(registration.ClientID) == "" {
+		return OAuthClientRegistration{}, errors.New("client registration returned no client_id")
+	}
+	return registration, nil
+}
+
+// OAuthLoginOptions configures StartOAuthLogin.
+type OAuthLoginOptions struct {
+	HTTPClient *http.Client
+	// Store receives the credentials once the code is exchanged.
+	Store TokenStore
+	// ClientID skips dynamic registration for servers that issue clients
+	// out of band.
+	ClientID   string
+	ClientName string
+	// Scopes defaults to the scopes the server advertises.
+	Scopes []string
+	// CallbackAddr is the localhost listener address; the default picks a
+	// free port on 127.0.0.1.
+	CallbackAddr string
+}
+
+// OAuthLogin is one authorization-code flow waiting for the browser to
+// reach its localhost callback.
+type OAuthLogin struct {
+	// AuthorizationURL is the page the user opens to authorize.
+	AuthorizationURL string
+	RedirectURI      string
+
+	server    *http.Server
+	result    chan oauthLoginResult
+	closeOnce sync.Once
+}
+
+type oauthLoginResult struct {
+	credentials OAuthCredentials
+	err         error
+}
+
+// StartOAuthLogin discovers the authorization server of server, registers
+// a client when needed and starts the localhost callback listener. Open
+// AuthorizationURL in a browser, then Wait for the credentials.
+func StartOAuthLogin(ctx context.Context, server ServerConfig, options OAuthLoginOptions) (*OAuthLogin, error) {
+	if options.Store == nil {
+		return nil, errors.New("oauth login requires a token store")
+	}
+	client := oauthHTTPClient(options.HTTPClient)
+	resource, err := DiscoverProtectedResource(ctx, client, server.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := DiscoverAuthorizationServer(ctx, client, resource.AuthorizationServers[0])
+	if err != nil {
+		return nil, err
+	}
+
+	addr := firstNonEmptyString(options.CallbackAddr, "127.0.0.1:0")
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("start oauth callback listener: %w", err)
+	}
+	redirectURI := "http://" + listener.Addr().String() + oauthCallbackPath
+
+	registration := OAuthClientRegistration{ClientID: strings.TrimSpace(options.ClientID)}
+	if registration.ClientID == "" {
+		registration, err = RegisterClient(ctx, client, metadata, redirectURI, options.ClientName)
+		if err != nil {
+			_ = listener.Close()
+			return nil, err
+		}
+	}
+
+	verifier, err := randomURLToken(32)
+	if err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	state, err := randomURLToken(16)
+	if err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	scopes := options.Scopes
+	if len(scopes) == 0 {
+		scopes = resource.ScopesSupported
+	}
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", registration.ClientID)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("code_challenge", pkceChallenge(verifier))
+	query.Set("code_challenge_method", "S256")
+	query.Set("state", state)
+	query.Set("resource", resource.Resource)
+	if len(scopes) > 0 {
+		query.Set("scope", strings.Join(scopes, " "))
+	}
+	authorizationURL := metadata.AuthorizationEndpoint
+	if strings.Contains(authorizationURL, "?") {
+		authorizationURL += "&" + query.Encode()
+	} else {
+		authorizationURL += "?" + query.Encode()
+	}
+
+	login := &OAuthLogin{
+		AuthorizationURL: authorizationURL,
+		RedirectURI:      redirectURI,
+		result:           make(chan oauthLoginResult, 1),
+	}
+	credentials := OAuthCredentials{
+		Resource:      resource.Resource,
+		Issuer:        metadata.Issuer,
+		TokenEndpoint: metadata.TokenEndpoint,
+		ClientID:      registration.ClientID,
+		ClientSecret:  registration.ClientSecret,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(oauthCallbackPath, func(w http.ResponseWriter, r *http.Request) {
+		values := r.URL.Query()
+		if values.Get("state") != state {
+			http.Error(w, "Authorization state does not match this login.", http.StatusBadRequest)
+			return
+		}
+		if code := values.Get("error"); code != "" {
+			login.finish(oauthLoginResult{err: fmt.Errorf("authorization denied: %s", firstNonEmptyString(values.Get("error_description"), code))})
+			http.Error(w, "Authorization failed. You can close this window.", http.StatusBadRequest)
+			return
+		}
+		exchangeCtx, cancel := context.WithTimeout(context.Background(), oauthRequestTimeout)
+		defer cancel()
+		token, err := requestOAuthToken(exchangeCtx, client, credentials, url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {values.Get("code")},
+			"redirect_uri":  {redirectURI},
+			"code_verifier": {verifier},
+		})
+		if err == nil {
+			credentials.Token = token
+			err = options.Store.Save(server.Endpoint, credentials)
+		}
+		if err != nil {
+			login.finish(oauthLoginResult{err: err})
+			http.Error(w, "Authorization failed. You can close this window.", http.StatusBadGateway)
+			return
+		}
+		login.finish(oauthLoginResult{credentials: credentials})
+		_, _ = io.WriteString(w, "Authorization complete. You can close this window.")
+	})
+	login.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = login.server.Serve(listener) }()
+	return login, nil
+}
+
+func (l *OAuthLogin) finish(result oauthLoginResult) {
+	select {
+	case l.result <- result:
+	default:
+	}
+}
+
+// Wait blocks until the callback completes the login or ctx ends, then
+// stops the listener.
+func (l *OAuthLogin) Wait(ctx context.Context) (OAuthCredentials, error) {
+	defer l.Close()
+	select {
+	case result := <-l.result:
+		return result.credentials, result.err
+	case <-ctx.Done():
+		return OAuthCredentials{}, ctx.Err()
+	}
+}
+
+// Close stops the callback listener.
+func (l *OAuthLogin) Close() {
+	l.closeOnce.Do(func() {
+		if l.server != nil {
+			// Let the callback finish writing its page.
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			_ = l.server.Shutdown(ctx)
+		}
+	})
+}
+
+// OAuthTokens is the TokenSource backed by a TokenStore. Expired tokens are
+// refreshed with their refresh token and saved back.
+type OAuthTokens struct {
+	store  TokenStore
+	client *http.Client
+	mu     sync.Mutex
+	now    func() time.Time
+}
+
+// NewOAuthTokens creates a token source over store; a nil client uses a
+// default one.
+func NewOAuthTokens(store TokenStore, client *http.Client) *OAuthTokens {
+	return &OAuthTokens{store: store, client: oauthHTTPClient(client), now: time.Now}
+}
+
+// SharedOAuthTokens uses the tokens of store for every scope. It suits
+// single-user runtimes such as the CLI.
+func SharedOAuthTokens(store TokenStore, client *http.Client) TokenSources {
+	tokens := NewOAuthTokens(store, client)
+	return func(string) TokenSource { return tokens }
+}
+
+// ScopedOAuthTokens keeps the tokens of each credential scope apart in
+// store. Servers without a scope get no tokens, so one user's login is
+// never sent on behalf of another.
+func ScopedOAuthTokens(store *FileTokenStore, client *http.Client) TokenSources {
+	var mu sync.Mutex
+	byScope := map[string]*OAuthTokens{}
+	return func(scope string) TokenSource {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			return nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		tokens := byScope[scope]
+		if tokens == nil {
+			tokens = NewOAuthTokens(store.Scope(scope), client)
+			byScope[scope] = tokens
+		}
+		return tokens
+	}
+}
+
+// AccessToken returns the stored token for endpoint, refreshed if it is
+// about to expire.
+func (t *OAuthTokens) AccessToken(ctx context.Context, endpoint string) (string, error) {
+	if t == nil || t.store == nil {
+		return "", nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	credentials, ok, err := t.store.Load(endpoint)
+	if err != nil || !ok {
+		return "", err
+	}
+	expiresAt := credentials.Token.ExpiresAt
+	if !expiresAt.IsZero() && !t.now().Add(oauthRefreshSkew).Before(expiresAt) {
+		return t.refreshLocked(ctx, endpoint, credentials)
+	}
+	return credentials.Token.AccessToken, nil
+}
+
+// RefreshAccessToken exchanges the refresh token of endpoint after the
+// server rejected its access token.
+func (t *OAuthTokens) RefreshAccessToken(ctx context.Context, endpoint string) (string, error) {
+	if t == nil || t.store == nil {
+		return "", ErrAuthorizationRequired
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	credentials, ok, err := t.store.Load(endpoint)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", ErrAuthorizationRequired
+	}
+	return t.refreshLocked(ctx, endpoint, credentials)
+}
+
+func (t *OAuthTokens) refreshLocked(ctx context.Context, endpoint string, credentials OAuthCredentials) (string, error) {
+	refreshToken := credentials.Token.RefreshToken
+	if refreshToken == "" {
+		return "", ErrAuthorizationRequired
+	}
+	token, err := requestOAuthToken(ctx, t.client, credentials, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: refresh failed: %v", ErrAuthorizationRequired, err)
+	}
+	// Servers that do not rotate refresh tokens omit them from the reply.
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	credentials.Token = token
+	if err := t.store.Save(endpoint, credentials); err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// requestOAuthToken posts form to the token endpoint of credentials with
+// the client and resource parameters added.
+func requestOAuthToken(ctx context.Context, client *http.Client, credentials OAuthCredentials, form url.Values) (OAuthToken, error) {
+	form.Set("client_id", credentials.ClientID)
+	if credentials.ClientSecret != "" {
+		form.Set("client_secret", credentials.ClientSecret)
+	}
+	if credentials.Resource != "" {
+		form.Set("resource", credentials.Resource)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, credentials.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return OAuthToken{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	payload := struct {
+		AccessToken  string  `json:"access_token"`
+		RefreshToken string  `json:"refresh_token"`
+		TokenType    string  `json:"token_type"`
+		Scope        string  `json:"scope"`
+		ExpiresIn    float64 `json:"expires_in"`
+	}{}
+	if err := doOAuthJSON(oauthHTTPClient(client), req, &payload); err != nil {
+		return OAuthToken{}, fmt.Errorf("token request failed: %w", err)
+	}
+	if payload.AccessToken == "" {
+		return OAuthToken{}, errors.New("token response has no access_token")
+	}
+	token := OAuthToken{
+		AccessToken:  payload.AccessToken,
+		RefreshToken: payload.RefreshToken,
+		TokenType:    payload.TokenType,
+		Scope:        payload.Scope,
+	}
+	if payload.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(payload.ExpiresIn) * time.Second).UTC()
+	}
+	return token, nil
+}
+
+// probeResourceMetadataURL sends an unauthenticated initialize and reads
+// the resource_metadata of the 401 challenge, if any.
+func probeResourceMetadataURL(ctx context.Context, client *http.Client, endpoint string) string {
+	body, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 0, "method": "initialize", "params": initializeParams()})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(string(body)))
+	if err != nil {
+		return ""
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	res, err := oauthHTTPClient(client).Do(req)
+	if err != nil {
+		return ""
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxFrameBytes))
+	if res.StatusCode != http.StatusUnauthorized {
+		return ""
+	}
+	return resourceMetadataFromChallenge(res.Header.Values("WWW-Authenticate"))
+}
+
+func resourceMetadataFromChallenge(challenges []string) string {
+	for _, challenge := range challenges {
+		if match := resourceMetadataPattern.FindStringSubmatch(challenge); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// wellKnownURLs lists the RFC 8615 locations of suffix for raw: with the
+// path of raw appended first, then at the origin root.
+func wellKnownURLs(raw string, suffix string) []string {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return nil
+	}
+	origin := parsed.Scheme + "://" + parsed.Host
+	out := []string{}
+	if path := strings.TrimRight(parsed.Path, "/"); path != "" {
+		out = append(out, origin+"/.well-known/"+suffix+path)
+	}
+	return append(out, origin+"/.well-known/"+suffix)
+}
+
+// oauthHTTPURL maps a ws(s) endpoint to the http(s) url its metadata is
+// published under.
+func oauthHTTPURL(endpoint string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(endpoint))
+	if err != nil || parsed.Host == "" {
+		return "", fmt.Errorf("mcp endpoint %q is not a valid url", endpoint)
+	}
+	switch parsed.Scheme {
+	case "ws":
+		parsed.Scheme = "http"
+	case "wss":
+		parsed.Scheme = "https"
+	case "http", "https":
+	default:
+		return "", fmt.Errorf("mcp endpoint %q is not a remote url", endpoint)
+	}
+	parsed.Fragment = ""
+	return parsed.String(), nil
+}
+
+func getOAuthJSON(ctx context.Context, client *http.Client, target string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	return doOAuthJSON(oauthHTTPClient(client), req, out)
+}
+
+// doOAuthJSON sends req and decodes a 2xx JSON reply into out. OAuth error
+// replies are reported by their error and error_description.
+func doOAuthJSON(client *http.Client, req *http.Request, out any) error {
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxFrameBytes))
+	if err != nil {
+		return err
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		failure := struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}{}
+		if json.Unmarshal(body, &failure) == nil && failure.Error != "" {
+			return fmt.Errorf("status %d: %s", res.StatusCode, firstNonEmptyString(failure.Description, failure.Error))
+		}
+		return fmt.Errorf("status %d: %s", res.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
+
+func oauthHTTPClient(client *http.Client) *http.Client {
+	if client != nil {
+		return client
+	}
+	return &http.Client{Timeout: oauthRequestTimeout}
+}
+
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomURLToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func firstNonEmptyString(values ...string) string {
+	for _, value := range values {
+		if trimmed := strings.TrimSpace(value); trimmed != "" {
+			return trimmed
+		}
+	}
+	return ""
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// TokenStore persists OAuth credentials by server endpoint. A store holds
+// the tokens of one credential scope.
+type TokenStore interface {
+	Load(endpoint string) (OAuthCredentials, bool, error)
+	Save(endpoint string, credentials OAuthCredentials) error
+	Delete(endpoint string) error
+}
+
+// SecretCipher encrypts stored credentials. The hub's resource secret
+// cipher is used so tokens share its key.
+type SecretCipher interface {
+	Encrypt(plain string) (string, error)
+	Decrypt(encoded string) (string, error)
+}
+
+// FileTokenStore keeps encrypted credentials in one JSON file shared by the
+// hub and the CLI. Entries are keyed by credential scope and endpoint; the
+// CLI uses the empty scope and the hub one scope per workspace and user.
+type FileTokenStore struct {
+	path   string
+	cipher SecretCipher
+	scope  string
+	mu     *sync.Mutex
+}
+
+type tokenStoreFile struct {
+	Credentials map[string]string `json:"credentials"`
+}
+
+// NewFileTokenStore creates a store at path whose entries are encrypted
+// with cipher.
+func NewFileTokenStore(path string, cipher SecretCipher) *FileTokenStore {
+	return &FileTokenStore{path: path, cipher: cipher, mu: &sync.Mutex{}}
+}
+
+// Scope returns a view of the same file that holds the tokens of scope.
+func (s *FileTokenStore) Scope(scope string) *FileTokenStore {
+	return &FileTokenStore{path: s.path, cipher: s.cipher, scope: strings.TrimSpace(scope), mu: s.mu}
+}
+
+// DefaultTokenStorePath is ~/.goyais/mcp-oauth.json. GOYAIS_MCP_OAUTH_FILE
+// overrides it.
+func DefaultTokenStorePath() string {
+	if path := strings.TrimSpace(os.Getenv("GOYAIS_MCP_OAUTH_FILE")); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil || strings.TrimSpace(home) == "" {
+		return filepath.Join(".goyais", "mcp-oauth.json")
+	}
+	return filepath.Join(home, ".goyais", "mcp-oauth.json")
+}
+
+// Load returns the credentials of endpoint.
+func (s *FileTokenStore) Load(endpoint string) (OAuthCredentials, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := s.read()
+	if err != nil {
+		return OAuthCredentials{}, false, err
+	}
+	encoded, ok := file.Credentials[tokenStoreKey(s.scope, endpoint)]
+	if !ok {
+		return OAuthCredentials{}, false, nil
+	}
+	plain, err := s.cipher.Decrypt(encoded)
+	if err != nil {
+		return OAuthCredentials{}, false, fmt.Errorf("decrypt mcp oauth credentials: %w", err)
+	}
+	credentials := OAuthCredentials{}
+	if err := json.Unmarshal([]byte(plain), &credentials); err != nil {
+		return OAuthCredentials{}, false, fmt.Errorf("decode mcp oauth credentials: %w", err)
+	}
+	return credentials, true, nil
+}
+
+// Save stores credentials for endpoint, replacing earlier ones.
+func (s *FileTokenStore) Save(endpoint string, credentials OAuthCredentials) error {
+	plain, err := json.Marshal(credentials)
+	if err != nil {
+		return err
+	}
+	encoded, err := s.cipher.Encrypt(string(plain))
+	if err != nil {
+		return fmt.Errorf("encrypt mcp oauth credentials: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := s.read()
+	if err != nil {
+		return err
+	}
+	file.Credentials[tokenStoreKey(s.scope, endpoint)] = encoded
+	return s.write(file)
+}
+
+// Delete forgets the credentials of endpoint.
+func (s *FileTokenStore) Delete(endpoint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := s.read()
+	if err != nil {
+		return err
+	}
+	key := tokenStoreKey(s.scope, endpoint)
+	if _, ok := file.Credentials[key]; !ok {
+		return nil
+	}
+	delete(file.Credentials, key)
+	return s.write(file)
+}
+
+func (s *FileTokenStore) read() (tokenStoreFile, error) {
+	file := tokenStoreFile{Credentials: map[string]string{}}
+	raw, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return file, nil
+	}
+	if err != nil {
+		return file, err
+	}
+	if len(raw) == 0 {
+		return file, nil
+	}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return file, fmt.Errorf("decode %s: %w", s.path, err)
+	}
+	if file.Credentials == nil {
+		file.Credentials = map[string]string{}
+	}
+	return file, nil
+}
+
+func (s *FileTokenStore) write(file tokenStoreFile) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	raw = append(raw, '\n')
+	return os.WriteFile(s.path, raw, 0o600)
+}
+
+// tokenStoreKey normalizes endpoint so trivially different spellings of one
+// server share credentials, and prefixes scope so scopes never do.
+func tokenStoreKey(scope string, endpoint string) string {
+	key := normalizeTokenEndpoint(endpoint)
+	if scope == "" {
+		return key
+	}
+	return scope + " " + key
+}
+
+func normalizeTokenEndpoint(endpoint string) string {
+	trimmed := strings.TrimSpace(endpoint)
+	parsed, err := url.Parse(trimmed)
+	if err != nil || parsed.Host == "" {
+		return trimmed
+	}
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	parsed.Fragment = ""
+	parsed.Path = strings.TrimRight(parsed.Path, "/")
+	return parsed.String()
+}
@@ -0,0 +1,305 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type prefixCipher struct{}
+
+func (prefixCipher) Encrypt(plain string) (string, error) {
+	return "enc:" + base64.StdEncoding.EncodeToString([]byte(plain)), nil
+}
+
+func (prefixCipher) Decrypt(encoded string) (string, error) {
+	if !strings.HasPrefix(encoded, "enc:") {
+		return "", errors.New("not encrypted")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encoded, "enc:"))
+	return string(raw), err
+}
+
+// oauthStandIn is an in-process authorization server plus an MCP server
+// that only accepts the token the authorization server issued last.
+type oauthStandIn struct {
+	auth *httptest.Server
+	mcp  *httptest.Server
+
+	mu         sync.Mutex
+	challenge  string
+	issued     int
+	valid      string
+	refreshes  int
+	registered int
+	resources  []string
+}
+
+func newOAuthStandIn(t *testing.T) *oauthStandIn {
+	t.Helper()
+	s := &oauthStandIn{}
+	s.auth = httptest.NewServer(http.HandlerFunc(s.serveAuth))
+	s.mcp = httptest.NewServer(http.HandlerFunc(s.serveMCP))
+	t.Cleanup(s.auth.Close)
+	t.Cleanup(s.mcp.Close)
+	return s
+}
+
+func (s *oauthStandIn) serveAuth(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.URL.Path {
+	case "/.well-known/oauth-authorization-server":
+		writeJSONBody(w, map[string]any{
+			"issuer":                           s.auth.URL,
+			"authorization_endpoint":           s.auth.URL + "/authorize",
+			"token_endpoint":                   s.auth.URL + "/token",
+			"registration_endpoint":            s.auth.URL + "/register",
+			"code_challenge_methods_supported": []string{"S256"},
+		})
+	case "/register":
+		s.registered++
+		writeJSONBody(w, map[string]any{"client_id": "client-1"})
+	case "/authorize":
+		query := r.URL.Query()
+		if query.Get("client_id") != "client-1" || query.Get("code_challenge_method") != "S256" || query.Get("resource") != s.mcp.URL+"/mcp" {
+			http.Error(w, "bad authorization request", http.StatusBadRequest)
+			return
+		}
+		s.challenge = query.Get("code_challenge")
+		http.Redirect(w, r, query.Get("redirect_uri")+"?code=code-1&state="+query.Get("state"), http.StatusFound)
+	case "/token":
+		_ = r.ParseForm()
+		s.resources = append(s.resources, r.PostForm.Get("resource"))
+		switch r.PostForm.Get("grant_type") {
+		case "authorization_code":
+			if r.PostForm.Get("code") != "code-1" || pkceChallenge(r.PostForm.Get("code_verifier")) != s.challenge {
+				w.WriteHeader(http.StatusBadRequest)
+				writeJSONBody(w, map[string]any{"error": "invalid_grant"})
+				return
+			}
+		case "refresh_token":
+			if r.PostForm.Get("refresh_token") != "refresh-1" {
+				w.WriteHeader(http.StatusBadRequest)
+				writeJSONBody(w, map[string]any{"error": "invalid_grant"})
+				return
+			}
+			s.refreshes++
+		}
+		s.issued++
+		s.valid = "access-" + string(rune('0'+s.issued))
+		// Refresh tokens are not rotated, so refreshes omit them.
+		reply := map[string]any{"access_token": s.valid, "token_type": "Bearer", "expires_in": 3600}
+		if r.PostForm.Get("grant_type") == "authorization_code" {
+			reply["refresh_token"] = "refresh-1"
+		}
+		writeJSONBody(w, reply)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *oauthStandIn) serveMCP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/.well-known/oauth-protected-resource/mcp" {
+		writeJSONBody(w, map[string]any{"resource": s.mcp.URL + "/mcp", "authorization_servers": []string{s.auth.URL}})
+		return
+	}
+	s.mu.Lock()
+	valid := s.valid
+	s.mu.Unlock()
+	if valid == "" || r.Header.Get("Authorization") != "Bearer "+valid {
+		w.Header().Set("WWW-Authenticate", `Bearer resource_metadata="`+s.mcp.URL+`/.well-known/oauth-protected-resource/mcp"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodPost {
+		return
+	}
+	payload := map[string]any{}
+	_ = json.NewDecoder(r.Body).Decode(&payload)
+	switch asStringAny(payload["method"]) {
+	case "initialize":
+		writeMCPHTTPResponse(w, map[string]any{"jsonrpc": "2.0", "id": payload["id"], "result": map[string]any{"protocolVersion": "2025-03-26"}})
+	case "tools/list":
+		writeMCPHTTPResponse(w, map[string]any{"jsonrpc": "2.0", "id": payload["id"], "result": map[string]any{"tools": []map[string]any{{"name": "ping"}}}})
+	case "tools/call":
+		writeMCPHTTPResponse(w, map[string]any{"jsonrpc": "2.0", "id": payload["id"], "result": map[string]any{"content": []map[string]any{{"type": "text", "text": "pong"}}}})
+	default:
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// revoke invalidates the current access token, as an expiry on the server
+// side would.
+func (s *oauthStandIn) revoke() {
+	s.mu.Lock()
+	s.valid = "revoked"
+	s.mu.Unlock()
+}
+
+func writeJSONBody(w http.ResponseWriter, payload map[string]any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(payload)
+}
+
+func callOAuthPing(pool *SessionPool, sessionID string, endpoint string, scope string) (map[string]any, error) {
+	manager := NewSessionClientManager(pool, sessionID, []ServerConfig{{Name: "remote", Transport: "http", Endpoint: endpoint, Tools: []string{"ping"}, CredentialScope: scope}}, 2*time.Second)
+	return manager.Call(context.Background(), "mcp__remote__ping", map[string]any{})
+}
+
+func TestOAuthLoginAuthorizesRemoteServer(t *testing.T) {
+	standIn := newOAuthStandIn(t)
+	endpoint := standIn.mcp.URL + "/mcp"
+	store := NewFileTokenStore(filepath.Join(t.TempDir(), "oauth.json"), prefixCipher{})
+	pool := NewSessionPool(PoolOptions{})
+	defer pool.Close()
+	pool.SetTokenSources(ScopedOAuthTokens(store, nil))
+
+	if _, err := callOAuthPing(pool, "sess_oauth_before", endpoint, "ws_1/user_a"); !IsAuthorizationRequired(err) {
+		t.Fatalf("expected authorization to be required before login, got %v", err)
+	}
+
+	login, err := StartOAuthLogin(context.Background(), ServerConfig{Name: "remote", Transport: "http", Endpoint: endpoint}, OAuthLoginOptions{Store: store.Scope("ws_1/user_a")})
+	if err != nil {
+		t.Fatalf("start login: %v", err)
+	}
+	if !strings.HasPrefix(login.RedirectURI, "http://127.0.0.1:") {
+		t.Fatalf("expected a localhost callback, got %q", login.RedirectURI)
+	}
+	// The browser follows the authorization server redirect to the callback.
+	res, err := http.Get(login.AuthorizationURL)
+	if err != nil {
+		t.Fatalf("open authorization url: %v", err)
+	}
+	_ = res.Body.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	credentials, err := login.Wait(ctx)
+	if err != nil {
+		t.Fatalf("wait for login: %v", err)
+	}
+	if credentials.ClientID != "client-1" || credentials.Token.AccessToken != "access-1" || credentials.Token.RefreshToken != "refresh-1" {
+		t.Fatalf("unexpected credentials %+v", credentials)
+	}
+
+	result, err := callOAuthPing(pool, "sess_oauth_after", endpoint, "ws_1/user_a")
+	if err != nil || asStringAny(result["output"]) != "pong" {
+		t.Fatalf("expected authorized call, got %+v %v", result, err)
+	}
+	if _, err := callOAuthPing(pool, "sess_oauth_other_user", endpoint, "ws_1/user_b"); !IsAuthorizationRequired(err) {
+		t.Fatalf("expected another user to need their own login, got %v", err)
+	}
+	if _, err := callOAuthPing(pool, "sess_oauth_unscoped", endpoint, ""); err == nil {
+		t.Fatalf("expected unscoped servers to get no tokens, got %v", err)
+	}
+
+	// A rejected token is refreshed once and the request retried.
+	standIn.revoke()
+	standIn.mu.Lock()
+	standIn.issued = 1
+	standIn.mu.Unlock()
+	fresh := NewSessionPool(PoolOptions{})
+	defer fresh.Close()
+	fresh.SetTokenSources(ScopedOAuthTokens(store, nil))
+	result, err = callOAuthPing(fresh, "sess_oauth_refresh", endpoint, "ws_1/user_a")
+	if err != nil || asStringAny(result["output"]) != "pong" {
+		t.Fatalf("expected call after refresh, got %+v %v", result, err)
+	}
+	stored, _, _ := store.Scope("ws_1/user_a").Load(endpoint)
+	standIn.mu.Lock()
+	defer standIn.mu.Unlock()
+	if standIn.refreshes != 1 || stored.Token.AccessToken != "access-2" || stored.Token.RefreshToken != "refresh-1" {
+		t.Fatalf("expected one refresh keeping the refresh token, got %d %+v", standIn.refreshes, stored.Token)
+	}
+	for _, resource := range standIn.resources {
+		if resource != endpoint {
+			t.Fatalf("expected token requests bound to %s, got %v", endpoint, standIn.resources)
+		}
+	}
+}
+
+func TestOAuthTokensRefreshBeforeExpiry(t *testing.T) {
+	standIn := newOAuthStandIn(t)
+	endpoint := standIn.mcp.URL + "/mcp"
+	store := NewFileTokenStore(filepath.Join(t.TempDir(), "oauth.json"), prefixCipher{})
+	credentials := OAuthCredentials{
+		Resource:      endpoint,
+		TokenEndpoint: standIn.auth.URL + "/token",
+		ClientID:      "client-1",
+		Token:         OAuthToken{AccessToken: "stale", RefreshToken: "refresh-1", ExpiresAt: time.Now().Add(10 * time.Second)},
+	}
+	if err := store.Save(endpoint, credentials); err != nil {
+		t.Fatalf("save credentials: %v", err)
+	}
+	token, err := NewOAuthTokens(store, nil).AccessToken(context.Background(), endpoint)
+	if err != nil || token != "access-1" {
+		t.Fatalf("expected a token about to expire to be refreshed, got %q %v", token, err)
+	}
+
+	if err := store.Save(endpoint, OAuthCredentials{TokenEndpoint: credentials.TokenEndpoint, Token: OAuthToken{AccessToken: "stale"}}); err != nil {
+		t.Fatalf("save credentials: %v", err)
+	}
+	if _, err := NewOAuthTokens(store, nil).RefreshAccessToken(context.Background(), endpoint); !IsAuthorizationRequired(err) {
+		t.Fatalf("expected a login to be required without a refresh token, got %v", err)
+	}
+}
+
+func TestFileTokenStoreEncryptsCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "oauth.json")
+	store := NewFileTokenStore(path, prefixCipher{})
+	if err := store.Save("HTTPS://MCP.example.com/mcp/", OAuthCredentials{ClientID: "client-1", Token: OAuthToken{AccessToken: "secret-token"}}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read store: %v", err)
+	}
+	if strings.Contains(string(raw), "secret-token") {
+		t.Fatalf("expected the token to be encrypted at rest, got %s", raw)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected a private store file, got %v %v", info, err)
+	}
+
+	loaded, ok, err := store.Load("https://mcp.example.com/mcp")
+	if err != nil || !ok || loaded.Token.AccessToken != "secret-token" {
+		t.Fatalf("expected normalized endpoint to load credentials, got %+v %v %v", loaded, ok, err)
+	}
+	if err := store.Delete("https://mcp.example.com/mcp"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, ok, _ := store.Load("https://mcp.example.com/mcp"); ok {
+		t.Fatalf("expected credentials to be deleted")
+	}
+}
+
+func TestFileTokenStoreKeepsScopesApart(t *testing.T) {
+	store := NewFileTokenStore(filepath.Join(t.TempDir(), "oauth.json"), prefixCipher{})
+	endpoint := "https://mcp.example.com/mcp"
+	if err := store.Scope("ws_1/user_a").Save(endpoint, OAuthCredentials{Token: OAuthToken{AccessToken: "token-a"}}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if _, ok, _ := store.Load(endpoint); ok {
+		t.Fatalf("expected the unscoped store not to see scoped tokens")
+	}
+	if _, ok, _ := store.Scope("ws_1/user_b").Load(endpoint); ok {
+		t.Fatalf("expected another scope not to see the tokens")
+	}
+	loaded, ok, err := store.Scope("ws_1/user_a").Load(endpoint)
+	if err != nil || !ok || loaded.Token.AccessToken != "token-a" {
+		t.Fatalf("expected the scope to load its tokens, got %+v %v %v", loaded, ok, err)
+	}
+}
@@ -0,0 +1,577 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPoolIdleTimeout       = 10 * time.Minute
+	defaultPoolRestartBackoff    = 500 * time.Millisecond
+	defaultPoolMaxRestartBackoff = 30 * time.Second
+	defaultPoolMaxRestarts       = 5
+	maxToolListPages             = 32
+)
+
+// Session status values reported by SessionPool.Sessions.
+const (
+	SessionConnecting = "connecting"
+	SessionConnected  = "connected"
+	SessionRestarting = "restarting"
+	SessionFailed     = "failed"
+)
+
+// ConnectError codes.
+const (
+	ConnectErrorMissingCommand       = "missing_command"
+	ConnectErrorInvalidEndpoint      = "invalid_endpoint"
+	ConnectErrorUnsupportedTransport = "unsupported_transport"
+	ConnectErrorStartFailed          = "stdio_start_failed"
+	ConnectErrorHandshake            = "handshake_failed"
+	ConnectErrorToolsList            = "tools_list_failed"
+	ConnectErrorProtocolVersion      = "unsupported_protocol_version"
+	ConnectErrorRestarting           = "restarting"
+	ConnectErrorFailed               = "server_failed"
+	ConnectErrorPoolClosed           = "pool_closed"
+	ConnectErrorUnauthorized         = "authorization_required"
+)
+
+var errPoolClosed = errors.New("mcp session pool is closed")
+
+// ConnectError reports why a pooled session could not be established.
+type ConnectError struct {
+	Code string
+	Err  error
+}
+
+func (e *ConnectError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConnectError) Unwrap() error {
+	return e.Err
+}
+
+// PoolOptions tunes session lifetime and crash recovery. Zero values use the
+// defaults.
+type PoolOptions struct {
+	// IdleTimeout closes sessions that served no request for this long.
+	IdleTimeout time.Duration
+	// RestartBackoff is the delay before the first reconnect after a crash
+	// or failed start; it doubles per consecutive failure.
+	RestartBackoff time.Duration
+	// MaxRestartBackoff caps the reconnect delay.
+	MaxRestartBackoff time.Duration
+	// MaxRestarts is how many consecutive failures are tolerated before the
+	// session is marked failed and stops reconnecting.
+	MaxRestarts int
+}
+
+// SessionStatus is a snapshot of one pooled server session.
+type SessionStatus struct {
+	SessionID       string
+	Server          string
+	Transport       string
+	Status          string
+	ProtocolVersion string
+	Tools           []string
+	RestartCount    int
+	StartedAt       time.Time
+	LastUsedAt      time.Time
+	LastError       string
+}
+
+// SessionPool keeps one initialized connection per MCP server per hub
+// session. Requests on a connection are multiplexed by JSON-RPC id, the
+// tools list is fetched once per connection, idle connections are closed,
+// and a crashed server is restarted on next use after a backoff.
+type SessionPool struct {
+	options PoolOptions
+
+	mu       sync.Mutex
+	sessions map[string]*pooledSession
+	closed   bool
+	reaping  bool
+	stop     chan struct{}
+
+	listeners       map[int]toolsListener
+	requestHandlers map[int]serverRequestRegistration
+	nextListener    int
+	tokens          TokenSources
+}
+
+var (
+	defaultPoolOnce sync.Once
+	defaultPool     *SessionPool
+)
+
+// DefaultSessionPool returns the process-wide pool.
+func DefaultSessionPool() *SessionPool {
+	defaultPoolOnce.Do(func() {
+		defaultPool = NewSessionPool(PoolOptions{})
+	})
+	return defaultPool
+}
+
+// NewSessionPool creates an empty pool.
+func NewSessionPool(options PoolOptions) *SessionPool {
+	if options.IdleTimeout <= 0 {
+		options.IdleTimeout = defaultPoolIdleTimeout
+	}
+	if options.RestartBackoff <= 0 {
+		options.RestartBackoff = defaultPoolRestartBackoff
+	}
+	if options.MaxRestartBackoff < options.RestartBackoff {
+		options.MaxRestartBackoff = defaultPoolMaxRestartBackoff
+		if options.MaxRestartBackoff < options.RestartBackoff {
+			options.MaxRestartBackoff = options.RestartBackoff
+		}
+	}
+	if options.MaxRestarts <= 0 {
+		options.MaxRestarts = defaultPoolMaxRestarts
+	}
+	return &SessionPool{
+		options:  options,
+		sessions: map[string]*pooledSession{},
+		stop:     make(chan struct{}),
+	}
+}
+
+// Connect establishes the session for server if needed and returns its
+// status. A session marked failed is given a fresh set of restarts.
+func (p *SessionPool) Connect(ctx context.Context, sessionID string, server ServerConfig, timeout time.Duration) (SessionStatus, error) {
+	session, err := p.reserve(sessionID, server)
+	if err != nil {
+		return SessionStatus{SessionID: sessionID, Server: server.Name, Transport: server.Transport, Status: SessionFailed, LastError: err.Error()}, err
+	}
+	session.resetFailure()
+	_, err = session.connect(ctx, p.options, timeout)
+	session.release(err)
+	return session.snapshot(), err
+}
+
+// Sessions lists pooled sessions, limited to server when it is non-empty.
+func (p *SessionPool) Sessions(server string) []SessionStatus {
+	p.mu.Lock()
+	sessions := make([]*pooledSession, 0, len(p.sessions))
+	for _, session := range p.sessions {
+		if server == "" || session.server.Name == server {
+			sessions = append(sessions, session)
+		}
+	}
+	p.mu.Unlock()
+	out := make([]SessionStatus, 0, len(sessions))
+	for _, session := range sessions {
+		out = append(out, session.snapshot())
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].SessionID != out[j].SessionID {
+			return out[i].SessionID < out[j].SessionID
+		}
+		return out[i].Server < out[j].Server
+	})
+	return out
+}
+
+// CloseSession shuts down every server connection owned by sessionID.
+func (p *SessionPool) CloseSession(sessionID string) {
+	p.closeWhere(func(session *pooledSession) bool { return session.sessionID == sessionID })
+}
+
+// Close shuts down all connections and stops the idle reaper.
+func (p *SessionPool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.stop)
+	p.mu.Unlock()
+	p.closeWhere(func(*pooledSession) bool { return true })
+}
+
+// acquire returns a connected session for server; callers must release it.
+func (p *SessionPool) acquire(ctx context.Context, sessionID string, server ServerConfig, timeout time.Duration) (*pooledSession, rpcConn, error) {
+	session, err := p.reserve(sessionID, server)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := session.connect(ctx, p.options, timeout)
+	if err != nil {
+		session.release(err)
+		return nil, nil, err
+	}
+	return session, conn, nil
+}
+
+// reserve finds or creates the session entry and marks it in use so the
+// reaper leaves it alone.
+func (p *SessionPool) reserve(sessionID string, server ServerConfig) (*pooledSession, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, &ConnectError{Code: ConnectErrorPoolClosed, Err: errPoolClosed}
+	}
+	key := poolKey(sessionID, server)
+	session := p.sessions[key]
+	if session == nil {
+		session = &pooledSession{pool: p, sessionID: sessionID, server: server, lastUsedAt: time.Now()}
+		p.sessions[key] = session
+	}
+	session.mu.Lock()
+	session.inflight++
+	session.mu.Unlock()
+	if !p.reaping {
+		p.reaping = true
+		go p.reap()
+	}
+	return session, nil
+}
+
+func (p *SessionPool) reap() {
+	interval := p.options.IdleTimeout / 2
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case now := <-ticker.C:
+			p.closeWhere(func(session *pooledSession) bool {
+				return session.inflight == 0 && now.Sub(session.lastUsedAt) >= p.options.IdleTimeout
+			})
+		}
+	}
+}
+
+// closeWhere removes and closes matching sessions. match runs with the
+// session lock held.
+func (p *SessionPool) closeWhere(match func(session *pooledSession) bool) {
+	conns := []rpcConn{}
+	p.mu.Lock()
+	for key, session := range p.sessions {
+		session.mu.Lock()
+		if match(session) {
+			delete(p.sessions, key)
+			session.closed = true
+			if session.conn != nil {
+				conns = append(conns, session.conn)
+				session.conn = nil
+			}
+		}
+		session.mu.Unlock()
+	}
+	p.mu.Unlock()
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+}
+
+func poolKey(sessionID string, server ServerConfig) string {
+	parts := []string{
+		sessionID,
+		strings.TrimSpace(server.Name),
+		strings.ToLower(strings.TrimSpace(server.Transport)),
+		strings.TrimSpace(server.Endpoint),
+		strings.TrimSpace(server.Command),
+		strings.TrimSpace(server.CredentialScope),
+	}
+	keys := make([]string, 0, len(server.Env))
+	for key := range server.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		parts = append(parts, key+"="+server.Env[key])
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// pooledSession is one server connection slot. dialMu serializes connects;
+// mu guards everything else.
+type pooledSession struct {
+	pool      *SessionPool
+	sessionID string
+	server    ServerConfig
+
+	dialMu sync.Mutex
+
+	mu         sync.Mutex
+	conn       rpcConn
+	status     string
+	version    string
+	tools      []any
+	startedAt  time.Time
+	lastUsedAt time.Time
+	restarts   int
+	failures   int
+	retryAt    time.Time
+	lastError  string
+	inflight   int
+	closed     bool
+	timeout    time.Duration
+	// subscriptions holds resource uris to subscribe again after a restart.
+	subscriptions map[string]struct{}
+	// progress routes notifications/progress by progress token.
+	progress map[string]func(ProgressUpdate)
+}
+
+func (s *pooledSession) connect(ctx context.Context, options PoolOptions, timeout time.Duration) (rpcConn, error) {
+	s.dialMu.Lock()
+	defer s.dialMu.Unlock()
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil, &ConnectError{Code: ConnectErrorPoolClosed, Err: errPoolClosed}
+	}
+	if s.conn != nil {
+		conn := s.conn
+		s.mu.Unlock()
+		return conn, nil
+	}
+	if s.status == SessionFailed {
+		err := &ConnectError{Code: ConnectErrorFailed, Err: fmt.Errorf("mcp server %q failed after %d attempts: %s", s.server.Name, s.failures, s.lastError)}
+		s.mu.Unlock()
+		return nil, err
+	}
+	if s.status == "" {
+		s.status = SessionConnecting
+	}
+	wait := time.Until(s.retryAt)
+	s.timeout = timeout
+	subscriptions := make([]string, 0, len(s.subscriptions))
+	for uri := range s.subscriptions {
+		subscriptions = append(subscriptions, uri)
+	}
+	s.mu.Unlock()
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, &ConnectError{Code: ConnectErrorRestarting, Err: fmt.Errorf("mcp server %q is restarting: %w", s.server.Name, ctx.Err())}
+		case <-timer.C:
+		}
+	}
+
+	server := s.server
+	if server.Auth == nil && s.pool != nil {
+		server.Auth = s.pool.tokenSource(server.CredentialScope)
+	}
+	conn, info, err := openSession(ctx, server, timeout, serverHandlers{notify: s.handleNotification, request: s.handleRequest})
+	if err == nil {
+		sort.Strings(subscriptions)
+		for _, uri := range subscriptions {
+			// Best effort: a resource that went away should not keep the
+			// server down.
+			_, _ = conn.Call(ctx, "resources/subscribe", map[string]any{"uri": uri})
+		}
+	}
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil && IsAuthorizationRequired(err) {
+		// Only a login fixes this, so it neither backs off nor uses up
+		// restarts; the next call after the login connects at once.
+		s.lastError = err.Error()
+		return nil, err
+	}
+	if err != nil {
+		s.recordFailureLocked(options, err.Error(), now)
+		return nil, err
+	}
+	if s.closed {
+		go conn.Close()
+		return nil, &ConnectError{Code: ConnectErrorPoolClosed, Err: errPoolClosed}
+	}
+	if !s.startedAt.IsZero() {
+		s.restarts++
+	}
+	s.conn = conn
+	s.version = info.version
+	s.tools = info.tools
+	s.status = SessionConnected
+	s.startedAt = now
+	s.lastUsedAt = now
+	s.lastError = ""
+	go s.watch(conn, options)
+	return conn, nil
+}
+
+// watch marks the session for restart when its connection dies on its own.
+func (s *pooledSession) watch(conn rpcConn, options PoolOptions) {
+	<-conn.Done()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != conn {
+		return
+	}
+	s.conn = nil
+	s.tools = nil
+	reason := "connection closed"
+	if err := conn.Err(); err != nil {
+		reason = err.Error()
+	}
+	s.recordFailureLocked(options, reason, time.Now())
+}
+
+func (s *pooledSession) recordFailureLocked(options PoolOptions, reason string, now time.Time) {
+	s.failures++
+	s.lastError = reason
+	if s.failures > options.MaxRestarts {
+		s.status = SessionFailed
+		return
+	}
+	s.status = SessionRestarting
+	backoff := options.RestartBackoff << (s.failures - 1)
+	if backoff <= 0 || backoff > options.MaxRestartBackoff {
+		backoff = options.MaxRestartBackoff
+	}
+	s.retryAt = now.Add(backoff)
+}
+
+func (s *pooledSession) resetFailure() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status == SessionFailed {
+		s.status = SessionRestarting
+		s.failures = 0
+		s.retryAt = time.Time{}
+	}
+}
+
+// release ends one use of the session. A successful call clears the
+// consecutive failure count.
+func (s *pooledSession) release(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inflight > 0 {
+		s.inflight--
+	}
+	s.lastUsedAt = time.Now()
+	if err == nil && s.conn != nil {
+		s.failures = 0
+	}
+}
+
+func (s *pooledSession) recordSubscription(uri string, subscribed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !subscribed {
+		delete(s.subscriptions, uri)
+		return
+	}
+	if s.subscriptions == nil {
+		s.subscriptions = map[string]struct{}{}
+	}
+	s.subscriptions[uri] = struct{}{}
+}
+
+func (s *pooledSession) cachedTools() []any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tools
+}
+
+func (s *pooledSession) snapshot() SessionStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return SessionStatus{
+		SessionID:       s.sessionID,
+		Server:          s.server.Name,
+		Transport:       s.server.Transport,
+		Status:          s.status,
+		ProtocolVersion: s.version,
+		Tools:           toolNames(s.tools),
+		RestartCount:    s.restarts,
+		StartedAt:       s.startedAt,
+		LastUsedAt:      s.lastUsedAt,
+		LastError:       s.lastError,
+	}
+}
+
+// sessionInfo is what the initialize handshake and tools listing learned
+// about a server.
+type sessionInfo struct {
+	version string
+	tools   []any
+}
+
+// openSession dials server, negotiates the protocol version and fetches the
+// full tools list.
+func openSession(ctx context.Context, server ServerConfig, timeout time.Duration, handlers serverHandlers) (rpcConn, sessionInfo, error) {
+	conn, err := dialConn(ctx, server, timeout, handlers)
+	if err != nil {
+		return nil, sessionInfo{}, err
+	}
+	initialized, err := conn.Call(ctx, "initialize", initializeParams())
+	if err != nil {
+		go conn.Close()
+		if IsAuthorizationRequired(err) {
+			return nil, sessionInfo{}, err
+		}
+		return nil, sessionInfo{}, &ConnectError{Code: ConnectErrorHandshake, Err: fmt.Errorf("initialize failed: %w", err)}
+	}
+	version, err := negotiateProtocolVersion(stringField(initialized, "protocolVersion"))
+	if err != nil {
+		go conn.Close()
+		return nil, sessionInfo{}, &ConnectError{Code: ConnectErrorProtocolVersion, Err: err}
+	}
+	_ = conn.Notify(ctx, "notifications/initialized", map[string]any{})
+
+	tools, err := listTools(ctx, conn)
+	if err != nil {
+		go conn.Close()
+		return nil, sessionInfo{}, &ConnectError{Code: ConnectErrorToolsList, Err: err}
+	}
+	return conn, sessionInfo{version: version, tools: tools}, nil
+}
+
+// listTools fetches every page of tools/list.
+func listTools(ctx context.Context, conn rpcConn) ([]any, error) {
+	tools := []any{}
+	cursor := ""
+	for page := 0; page < maxToolListPages; page++ {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		listed, err := conn.Call(ctx, "tools/list", params)
+		if err != nil {
+			return nil, fmt.Errorf("tools/list failed: %w", err)
+		}
+		payload := asMap(listed)
+		tools = append(tools, asSlice(payload["tools"])...)
+		cursor, _ = payload["nextCursor"].(string)
+		cursor = strings.TrimSpace(cursor)
+		if cursor == "" {
+			break
+		}
+	}
+	return tools, nil
+}
+
+func toolNames(tools []any) []string {
+	names := make([]string, 0, len(tools))
+	for _, item := range tools {
+		if name, _ := asMap(item)["name"].(string); strings.TrimSpace(name) != "" {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+	return names
+}
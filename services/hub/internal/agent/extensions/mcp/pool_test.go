@@ -0,0 +1,311 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func poolHelperServer(name string) ServerConfig {
+	return ServerConfig{
+		Name:      name,
+		Transport: "stdio",
+		Command:   "GO_WANT_MCP_POOL_HELPER=1 exec " + strconv.Quote(os.Args[0]) + " -test.run ^TestMCPPoolHelperProcess$",
+		Tools:     []string{"pid", "slow", "crash"},
+	}
+}
+
+func TestSessionPoolReusesOneProcessPerHubSession(t *testing.T) {
+	pool := NewSessionPool(PoolOptions{})
+	defer pool.Close()
+	servers := []ServerConfig{poolHelperServer("local")}
+	first := NewSessionClientManager(pool, "sess_a", servers, 2*time.Second)
+	second := NewSessionClientManager(pool, "sess_a", servers, 2*time.Second)
+	other := NewSessionClientManager(pool, "sess_b", servers, 2*time.Second)
+
+	pids := []string{}
+	for _, manager := range []*ClientManager{first, second, other} {
+		result, err := manager.Call(context.Background(), "mcp__local__pid", map[string]any{})
+		if err != nil {
+			t.Fatalf("call pid: %v", err)
+		}
+		pids = append(pids, asStringAny(result["output"]))
+	}
+	if pids[0] != pids[1] {
+		t.Fatalf("expected one process per hub session, got %v", pids)
+	}
+	if pids[0] == pids[2] {
+		t.Fatalf("expected separate processes per hub session, got %v", pids)
+	}
+
+	statuses := pool.Sessions("local")
+	if len(statuses) != 2 || statuses[0].SessionID != "sess_a" || statuses[0].Status != SessionConnected {
+		t.Fatalf("unexpected session statuses %+v", statuses)
+	}
+	if strings.Join(statuses[0].Tools, ",") != "pid,slow,crash" {
+		t.Fatalf("expected cached tools list, got %+v", statuses[0].Tools)
+	}
+	if _, err := first.Call(context.Background(), "mcp__local__missing", map[string]any{}); err == nil || !strings.Contains(err.Error(), "does not expose tool") {
+		t.Fatalf("expected cached tools list to reject unknown tool, got %v", err)
+	}
+
+	pool.CloseSession("sess_a")
+	if statuses := pool.Sessions("local"); len(statuses) != 1 || statuses[0].SessionID != "sess_b" {
+		t.Fatalf("expected sess_a connections closed, got %+v", statuses)
+	}
+}
+
+func TestSessionPoolMultiplexesConcurrentCalls(t *testing.T) {
+	pool := NewSessionPool(PoolOptions{})
+	defer pool.Close()
+	manager := NewSessionClientManager(pool, "sess_mux", []ServerConfig{poolHelperServer("local")}, 5*time.Second)
+	if _, err := manager.Call(context.Background(), "mcp__local__pid", map[string]any{}); err != nil {
+		t.Fatalf("warm up: %v", err)
+	}
+
+	var mu sync.Mutex
+	order := []string{}
+	var wg sync.WaitGroup
+	for _, tool := range []string{"slow", "pid"} {
+		wg.Add(1)
+		go func(tool string) {
+			defer wg.Done()
+			if _, err := manager.Call(context.Background(), "mcp__local__"+tool, map[string]any{}); err != nil {
+				t.Errorf("call %s: %v", tool, err)
+				return
+			}
+			mu.Lock()
+			order = append(order, tool)
+			mu.Unlock()
+		}(tool)
+		if tool == "slow" {
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	wg.Wait()
+	if strings.Join(order, ",") != "pid,slow" {
+		t.Fatalf("expected fast call to finish while slow call is pending, got %v", order)
+	}
+}
+
+func TestSessionPoolRestartsCrashedServerAfterBackoff(t *testing.T) {
+	pool := NewSessionPool(PoolOptions{RestartBackoff: 20 * time.Millisecond})
+	defer pool.Close()
+	manager := NewSessionClientManager(pool, "sess_crash", []ServerConfig{poolHelperServer("local")}, 2*time.Second)
+
+	before, err := manager.Call(context.Background(), "mcp__local__pid", map[string]any{})
+	if err != nil {
+		t.Fatalf("call pid: %v", err)
+	}
+	if _, err := manager.Call(context.Background(), "mcp__local__crash", map[string]any{}); err == nil {
+		t.Fatalf("expected crash call to fail")
+	}
+	waitForPoolStatus(t, pool, "local", SessionRestarting)
+
+	after, err := manager.Call(context.Background(), "mcp__local__pid", map[string]any{})
+	if err != nil {
+		t.Fatalf("call after crash: %v", err)
+	}
+	if before["output"] == after["output"] {
+		t.Fatalf("expected a new server process after crash, got pid %v twice", before["output"])
+	}
+	status := pool.Sessions("local")[0]
+	if status.Status != SessionConnected || status.RestartCount != 1 {
+		t.Fatalf("unexpected status after restart %+v", status)
+	}
+}
+
+func TestSessionPoolMarksServerFailedAfterMaxRestarts(t *testing.T) {
+	pool := NewSessionPool(PoolOptions{RestartBackoff: time.Millisecond, MaxRestarts: 1})
+	defer pool.Close()
+	server := ServerConfig{Name: "broken", Transport: "stdio", Command: "echo boom >&2; exit 3", Tools: []string{"ping"}}
+	manager := NewSessionClientManager(pool, "sess_fail", []ServerConfig{server}, time.Second)
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if _, err := manager.Call(context.Background(), "mcp__broken__ping", map[string]any{}); err == nil {
+			t.Fatalf("expected attempt %d to fail", attempt)
+		}
+	}
+	status := pool.Sessions("broken")[0]
+	if status.Status != SessionFailed || !strings.Contains(status.LastError, "boom") {
+		t.Fatalf("expected failed status with stderr, got %+v", status)
+	}
+	_, err := manager.Call(context.Background(), "mcp__broken__ping", map[string]any{})
+	var connectErr *ConnectError
+	if !errors.As(err, &connectErr) || connectErr.Code != ConnectErrorFailed {
+		t.Fatalf("expected server_failed error, got %v", err)
+	}
+
+	status, err = pool.Connect(context.Background(), "sess_fail", server, time.Second)
+	if err == nil || status.Status != SessionRestarting {
+		t.Fatalf("expected explicit connect to retry a failed server, got %+v err=%v", status, err)
+	}
+}
+
+func TestSessionPoolClosesIdleSessions(t *testing.T) {
+	pool := NewSessionPool(PoolOptions{IdleTimeout: 40 * time.Millisecond})
+	defer pool.Close()
+	manager := NewSessionClientManager(pool, "sess_idle", []ServerConfig{poolHelperServer("local")}, 2*time.Second)
+	if _, err := manager.Call(context.Background(), "mcp__local__pid", map[string]any{}); err != nil {
+		t.Fatalf("call pid: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(pool.Sessions("")) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected idle session to be closed, got %+v", pool.Sessions(""))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMCPPoolHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_MCP_POOL_HELPER") != "1" {
+		return
+	}
+	reader := bufio.NewReader(os.Stdin)
+	var writeMu sync.Mutex
+	write := func(payload map[string]any) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		writeTestFrame(os.Stdout, payload)
+	}
+	reply := func(id any, text string) {
+		write(map[string]any{"jsonrpc": "2.0", "id": id, "result": map[string]any{
+			"content": []map[string]any{{"type": "text", "text": text}},
+		}})
+	}
+	subscribed := 0
+	cancelled := 0
+	// Tool calls waiting for the client to answer a server request.
+	awaiting := map[string]any{}
+	tools := []map[string]any{{"name": "pid"}, {"name": "slow"}, {"name": "crash"}}
+	if os.Getenv("MCP_POOL_HELPER_NOTIFY") == "1" {
+		tools = append(tools, map[string]any{"name": "progress"}, map[string]any{"name": "hang"}, map[string]any{"name": "cancelled"}, map[string]any{"name": "sample"}, map[string]any{"name": "elicit"}, map[string]any{"name": "grow"})
+	}
+	for {
+		frame, err := readTestFrame(reader)
+		if err != nil {
+			os.Exit(0)
+		}
+		payload := map[string]any{}
+		if err := json.Unmarshal(frame, &payload); err != nil {
+			continue
+		}
+		id := payload["id"]
+		if callID, ok := awaiting[asStringAny(id)]; ok && payload["method"] == nil {
+			delete(awaiting, asStringAny(id))
+			answer, _ := json.Marshal(map[string]any{"result": payload["result"], "error": payload["error"]})
+			reply(callID, string(answer))
+			continue
+		}
+		switch asStringAny(payload["method"]) {
+		case "initialize":
+			write(map[string]any{"jsonrpc": "2.0", "id": id, "result": map[string]any{}})
+		case "tools/list":
+			write(map[string]any{"jsonrpc": "2.0", "id": id, "result": map[string]any{"tools": tools}})
+		case "notifications/cancelled":
+			cancelled++
+		case "resources/list":
+			params, _ := payload["params"].(map[string]any)
+			if asStringAny(params["cursor"]) == "page2" {
+				write(map[string]any{"jsonrpc": "2.0", "id": id, "result": map[string]any{
+					"resources": []map[string]any{{"uri": "memo://two", "name": "Two"}},
+				}})
+				continue
+			}
+			write(map[string]any{"jsonrpc": "2.0", "id": id, "result": map[string]any{
+				"resources":  []map[string]any{{"uri": "memo://one", "name": "One", "mimeType": "text/plain"}},
+				"nextCursor": "page2",
+			}})
+		case "resources/templates/list":
+			write(map[string]any{"jsonrpc": "2.0", "id": id, "result": map[string]any{
+				"resourceTemplates": []map[string]any{{"uriTemplate": "memo://{id}", "name": "Memo"}},
+			}})
+		case "resources/read":
+			params, _ := payload["params"].(map[string]any)
+			uri := asStringAny(params["uri"])
+			text := "body of " + uri
+			if uri == "memo://subscriptions" {
+				text = strconv.Itoa(subscribed)
+			}
+			write(map[string]any{"jsonrpc": "2.0", "id": id, "result": map[string]any{
+				"contents": []map[string]any{{"uri": uri, "mimeType": "text/plain", "text": text}},
+			}})
+		case "resources/subscribe":
+			subscribed++
+			write(map[string]any{"jsonrpc": "2.0", "id": id, "result": map[string]any{}})
+		case "tools/call":
+			params, _ := payload["params"].(map[string]any)
+			switch asStringAny(params["name"]) {
+			case "pid":
+				reply(id, strconv.Itoa(os.Getpid()))
+			case "slow":
+				go func() {
+					time.Sleep(300 * time.Millisecond)
+					reply(id, "slow")
+				}()
+			case "crash":
+				os.Exit(3)
+			case "progress":
+				meta, _ := params["_meta"].(map[string]any)
+				for step := 1; step <= 2; step++ {
+					write(map[string]any{"jsonrpc": "2.0", "method": "notifications/progress", "params": map[string]any{
+						"progressToken": meta["progressToken"], "progress": step, "total": 2, "message": "step " + strconv.Itoa(step),
+					}})
+				}
+				write(map[string]any{"jsonrpc": "2.0", "method": "notifications/message", "params": map[string]any{
+					"level": "warning", "logger": "helper", "data": "disk almost full",
+				}})
+				reply(id, "done")
+			case "hang":
+			case "cancelled":
+				reply(id, strconv.Itoa(cancelled))
+			case "grow":
+				tools = append(tools, map[string]any{"name": "extra"})
+				write(map[string]any{"jsonrpc": "2.0", "method": "notifications/tools/list_changed"})
+				reply(id, "grown")
+			case "extra":
+				reply(id, "extra")
+			case "sample":
+				awaiting["srv-sample"] = id
+				write(map[string]any{"jsonrpc": "2.0", "id": "srv-sample", "method": "sampling/createMessage", "params": map[string]any{
+					"messages":     []map[string]any{{"role": "user", "content": map[string]any{"type": "text", "text": "summarize"}}},
+					"systemPrompt": "be brief",
+					"maxTokens":    4096,
+				}})
+			case "elicit":
+				awaiting["srv-elicit"] = id
+				write(map[string]any{"jsonrpc": "2.0", "id": "srv-elicit", "method": "elicitation/create", "params": map[string]any{
+					"message":         "pick a region",
+					"requestedSchema": map[string]any{"type": "object", "properties": map[string]any{"region": map[string]any{"type": "string"}}},
+				}})
+			}
+		}
+	}
+}
+
+func waitForPoolStatus(t *testing.T, pool *SessionPool, server string, want string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		statuses := pool.Sessions(server)
+		if len(statuses) > 0 && statuses[0].Status == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %s status %q, got %+v", server, want, statuses)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
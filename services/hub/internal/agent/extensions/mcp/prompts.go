@@ -11,7 +11,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
@@ -38,6 +37,9 @@ type serverRecord struct {
 	Type    string            `json:"type"`
 	Scope   string            `json:"scope"`
 	URL     string            `json:"url,omitempty"`
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
 	Headers map[string]string `json:"headers,omitempty"`
 }
 
@@ -117,6 +119,56 @@ func DiscoverPromptCommands(ctx context.Context, workingDir string) ([]PromptCom
 	return commands, nil
 }
 
+// LoadProjectServerConfigs converts the stdio, SSE and HTTP servers configured in
+// <workingDir>/.goyais/mcp-servers.json into runtime client configs.
+func LoadProjectServerConfigs(workingDir string) ([]ServerConfig, error) {
+	store, err := loadServerStore(strings.TrimSpace(workingDir))
+	if err != nil {
+		return nil, err
+	}
+	records := selectServers(store)
+	out := make([]ServerConfig, 0, len(records))
+	for _, record := range records {
+		switch strings.ToLower(strings.TrimSpace(record.Type)) {
+		case "stdio":
+			command := strings.TrimSpace(record.Command)
+			if command == "" {
+				continue
+			}
+			for _, arg := range record.Args {
+				command += " " + shellQuoteArg(arg)
+			}
+			out = append(out, ServerConfig{Name: record.Name, Transport: "stdio", Command: command, Env: cloneStringMap(record.Env)})
+		case "sse":
+			if strings.TrimSpace(record.URL) == "" {
+				continue
+			}
+			out = append(out, ServerConfig{Name: record.Name, Transport: "http_sse", Endpoint: record.URL, Env: cloneStringMap(record.Headers)})
+		case "http":
+			if strings.TrimSpace(record.URL) == "" {
+				continue
+			}
+			out = append(out, ServerConfig{Name: record.Name, Transport: "http", Endpoint: record.URL, Env: cloneStringMap(record.Headers)})
+		case "ws":
+			if strings.TrimSpace(record.URL) == "" {
+				continue
+			}
+			out = append(out, ServerConfig{Name: record.Name, Transport: "ws", Endpoint: record.URL, Env: cloneStringMap(record.Headers)})
+		}
+	}
+	return out, nil
+}
+
+func shellQuoteArg(value string) string {
+	if value != "" && strings.IndexFunc(value, func(r rune) bool {
+		return !(r == '-' || r == '_' || r == '.' || r == '/' || r == '=' || r == ':' || r == '@' ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'))
+	}) < 0 {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'"'"'`) + "'"
+}
+
 func loadServerStore(workingDir string) (serverStore, error) {
 	path := filepath.Join(workingDirOrDot(workingDir), ".goyais", "mcp-servers.json")
 	raw, err := os.ReadFile(path)
@@ -208,19 +260,7 @@ func scopePriority(scope string) int {
 }
 
 func listPrompts(ctx context.Context, record serverRecord) ([]promptDefinition, error) {
-	if _, err := invokeRPC(ctx, record, 1, "initialize", map[string]any{
-		"protocolVersion": "2024-11-05",
-		"capabilities":    map[string]any{},
-		"clientInfo": map[string]any{
-			"name":    "goyais",
-			"version": "dev",
-		},
-	}); err != nil {
-		return nil, err
-	}
-	_, _ = invokeRPC(ctx, record, 0, "notifications/initialized", map[string]any{})
-
-	result, err := invokeRPC(ctx, record, 2, "prompts/list", map[string]any{})
+	result, err := invokePrompt(ctx, record, "prompts/list", map[string]any{})
 	if err != nil {
 		return nil, err
 	}
@@ -267,7 +307,7 @@ func getPromptMessages(ctx context.Context, record serverRecord, promptName stri
 		arguments[k] = value
 	}
 
-	result, err := invokeRPC(ctx, record, 3, "prompts/get", map[string]any{
+	result, err := invokePrompt(ctx, record, "prompts/get", map[string]any{
 		"name":      name,
 		"arguments": arguments,
 	})
@@ -288,14 +328,15 @@ func getPromptMessages(ctx context.Context, record serverRecord, promptName stri
 	return out, nil
 }
 
-func invokeRPC(ctx context.Context, record serverRecord, id int, method string, params map[string]any) (any, error) {
-	endpoint := strings.TrimSpace(record.URL)
-	if endpoint == "" {
-		return nil, errors.New("mcp server url is required for http transport")
-	}
+// invokePrompt opens a Streamable HTTP session to record, runs the
+// initialize handshake and sends one request.
+func invokePrompt(ctx context.Context, record serverRecord, method string, params map[string]any) (any, error) {
 	if transport := strings.ToLower(strings.TrimSpace(record.Type)); transport != "" && transport != "http" {
 		return nil, fmt.Errorf("unsupported mcp transport %q", transport)
 	}
+	if strings.TrimSpace(record.URL) == "" {
+		return nil, errors.New("mcp server url is required for http transport")
+	}
 	callCtx := ctx
 	if callCtx == nil {
 		callCtx = context.Background()
@@ -306,59 +347,20 @@ func invokeRPC(ctx context.Context, record serverRecord, id int, method string,
 		defer cancel()
 	}
 
-	payload := map[string]any{
-		"jsonrpc": "2.0",
-		"method":  strings.TrimSpace(method),
-	}
-	if params == nil {
-		params = map[string]any{}
-	}
-	payload["params"] = params
-	if id > 0 {
-		payload["id"] = id
-	}
-
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
-	}
-	request, err := http.NewRequestWithContext(callCtx, http.MethodPost, endpoint, strings.NewReader(string(body)))
+	conn, err := dialHTTPConn(ServerConfig{Name: record.Name, Transport: "http", Endpoint: record.URL, Env: record.Headers}, serverHandlers{})
 	if err != nil {
 		return nil, err
 	}
-	request.Header.Set("Content-Type", "application/json")
-	for key, value := range record.Headers {
-		trimmedKey := strings.TrimSpace(key)
-		if trimmedKey == "" {
-			continue
-		}
-		request.Header.Set(trimmedKey, value)
-	}
-
-	response, err := (&http.Client{Timeout: defaultRPCTimeout}).Do(request)
+	defer conn.Close()
+	initialized, err := conn.Call(callCtx, "initialize", initializeParams())
 	if err != nil {
 		return nil, err
 	}
-	defer response.Body.Close()
-	if id == 0 {
-		return map[string]any{}, nil
-	}
-	if response.StatusCode < 200 || response.StatusCode >= 300 {
-		return nil, fmt.Errorf("mcp rpc status %d", response.StatusCode)
-	}
-
-	decoded := map[string]any{}
-	if err := json.NewDecoder(response.Body).Decode(&decoded); err != nil {
+	if _, err := negotiateProtocolVersion(stringField(initialized, "protocolVersion")); err != nil {
 		return nil, err
 	}
-	if errRaw, ok := decoded["error"]; ok && errRaw != nil {
-		message := strings.TrimSpace(asString(asMap(errRaw)["message"]))
-		if message == "" {
-			message = "mcp rpc returned error"
-		}
-		return nil, errors.New(message)
-	}
-	return decoded["result"], nil
+	_ = conn.Notify(callCtx, "notifications/initialized", map[string]any{})
+	return conn.Call(callCtx, method, params)
 }
 
 func mapPromptArgs(argumentSpecs []promptArgument, args []string) map[string]string {
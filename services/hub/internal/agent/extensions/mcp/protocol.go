@@ -0,0 +1,157 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// latestProtocolVersion is offered in initialize; servers answer with the
+// version they will speak.
+const latestProtocolVersion = "2025-06-18"
+
+// legacyProtocolVersion is assumed when a server omits protocolVersion.
+const legacyProtocolVersion = "2024-11-05"
+
+// supportedProtocolVersions lists every version this client speaks, newest
+// first.
+var supportedProtocolVersions = []string{latestProtocolVersion, "2025-03-26", legacyProtocolVersion}
+
+// negotiateProtocolVersion accepts the version a server chose in its
+// initialize result. Since the client offers its newest version, the
+// server's answer is the highest version both sides support, or one the
+// client cannot speak.
+func negotiateProtocolVersion(serverVersion string) (string, error) {
+	version := strings.TrimSpace(serverVersion)
+	if version == "" {
+		return legacyProtocolVersion, nil
+	}
+	for _, supported := range supportedProtocolVersions {
+		if version == supported {
+			return version, nil
+		}
+	}
+	return "", fmt.Errorf("server protocol version %q is not supported (client supports %s)", version, strings.Join(supportedProtocolVersions, ", "))
+}
+
+// initializeParams is the client side of the initialize handshake.
+func initializeParams() map[string]any {
+	return map[string]any{
+		"protocolVersion": latestProtocolVersion,
+		"capabilities": map[string]any{
+			"sampling":    map[string]any{},
+			"elicitation": map[string]any{},
+		},
+		"clientInfo": map[string]any{"name": "goyais", "version": runtimeVersion()},
+	}
+}
+
+// stringField reads a string member of a decoded JSON object.
+func stringField(raw any, key string) string {
+	value, _ := asMap(raw)[key].(string)
+	return strings.TrimSpace(value)
+}
+
+// rpcMessage is any JSON-RPC message received from a server: a response to
+// one of our requests, a server request, or a notification.
+type rpcMessage struct {
+	ID     any    `json:"id"`
+	Method string `json:"method"`
+	Params any    `json:"params"`
+	Result any    `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func decodeRPCMessage(body []byte) (rpcMessage, bool) {
+	message := rpcMessage{}
+	if err := json.Unmarshal(body, &message); err != nil {
+		return rpcMessage{}, false
+	}
+	return message, true
+}
+
+// isServerMessage reports whether the message was initiated by the server.
+func (m rpcMessage) isServerMessage() bool {
+	return m.Method != ""
+}
+
+// deliver passes a notification to handler, if any.
+func (m rpcMessage) deliver(handler notificationHandler) {
+	if handler == nil || m.ID != nil || m.Method == "" {
+		return
+	}
+	handler(m.Method, asMap(m.Params))
+}
+
+func (m rpcMessage) response() (any, error) {
+	if m.Error != nil {
+		return nil, &rpcError{Code: m.Error.Code, Message: m.Error.Message}
+	}
+	return m.Result, nil
+}
+
+// errUnsupportedRequest is returned by request handlers for methods this
+// client does not serve.
+var errUnsupportedRequest = errors.New("method not found")
+
+// serveRequest runs handler for one server request and builds the
+// response. Requests without a handler, or that the handler does not
+// serve, are answered with method not found so the server does not wait
+// forever.
+func serveRequest(ctx context.Context, handler requestHandler, message rpcMessage) map[string]any {
+	if handler == nil {
+		return unsupportedRequestReply(message.ID)
+	}
+	result, err := handler(ctx, message.Method, asMap(message.Params))
+	if errors.Is(err, errUnsupportedRequest) {
+		return unsupportedRequestReply(message.ID)
+	}
+	if err != nil {
+		code := -32603
+		var rpcErr *rpcError
+		if errors.As(err, &rpcErr) {
+			code = rpcErr.Code
+		}
+		return map[string]any{
+			"jsonrpc": "2.0",
+			"id":      message.ID,
+			"error":   map[string]any{"code": code, "message": err.Error()},
+		}
+	}
+	if result == nil {
+		result = map[string]any{}
+	}
+	return map[string]any{"jsonrpc": "2.0", "id": message.ID, "result": result}
+}
+
+// unsupportedRequestReply answers a server request this client cannot
+// serve.
+func unsupportedRequestReply(id any) map[string]any {
+	return map[string]any{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"error":   map[string]any{"code": -32601, "message": "method not found"},
+	}
+}
+
+// doneContext returns a context cancelled once done is closed.
+func doneContext(done <-chan struct{}) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
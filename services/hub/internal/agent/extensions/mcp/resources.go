@@ -0,0 +1,274 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxResourceListPages bounds pagination of resources/list and
+// resources/templates/list.
+const maxResourceListPages = 32
+
+// Resource is one entry of a server's resources/list result.
+type Resource struct {
+	Server      string
+	URI         string
+	Name        string
+	Description string
+	MimeType    string
+}
+
+// ResourceTemplate is one entry of a server's resources/templates/list
+// result.
+type ResourceTemplate struct {
+	Server      string
+	URITemplate string
+	Name        string
+	Description string
+	MimeType    string
+}
+
+// ResourceContent is one item of a resources/read result. Binary contents
+// carry Blob as base64 and leave Text empty.
+type ResourceContent struct {
+	URI      string
+	MimeType string
+	Text     string
+	Blob     string
+}
+
+// ServerNames lists configured server names in sorted order.
+func (m *ClientManager) ServerNames() []string {
+	if m == nil {
+		return nil
+	}
+	names := make([]string, 0, len(m.serversByToken))
+	for _, server := range m.serversByToken {
+		names = append(names, server.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ListResources lists resources and resource templates of server, or of
+// every configured server when server is empty. In the all-servers form,
+// servers that do not implement resources are skipped.
+func (m *ClientManager) ListResources(ctx context.Context, server string) ([]Resource, []ResourceTemplate, error) {
+	servers := []ServerConfig{}
+	if strings.TrimSpace(server) == "" {
+		for _, name := range m.ServerNames() {
+			config, _ := m.lookupServer(name)
+			servers = append(servers, config)
+		}
+	} else {
+		config, err := m.lookupServer(server)
+		if err != nil {
+			return nil, nil, err
+		}
+		servers = append(servers, config)
+	}
+
+	resources := []Resource{}
+	templates := []ResourceTemplate{}
+	for _, config := range servers {
+		listed, err := m.paginate(ctx, config, "resources/list", "resources")
+		if err != nil {
+			if len(servers) > 1 && isMethodNotFound(err) {
+				continue
+			}
+			return nil, nil, fmt.Errorf("mcp server %q resources/list failed: %w", config.Name, err)
+		}
+		for _, item := range listed {
+			entry := asMap(item)
+			uri := stringField(entry, "uri")
+			if uri == "" {
+				continue
+			}
+			resources = append(resources, Resource{
+				Server:      config.Name,
+				URI:         uri,
+				Name:        stringField(entry, "name"),
+				Description: stringField(entry, "description"),
+				MimeType:    stringField(entry, "mimeType"),
+			})
+		}
+		// Templates are optional even for servers that list resources.
+		listed, err = m.paginate(ctx, config, "resources/templates/list", "resourceTemplates")
+		if err != nil && !isMethodNotFound(err) {
+			return nil, nil, fmt.Errorf("mcp server %q resources/templates/list failed: %w", config.Name, err)
+		}
+		for _, item := range listed {
+			entry := asMap(item)
+			uriTemplate := stringField(entry, "uriTemplate")
+			if uriTemplate == "" {
+				continue
+			}
+			templates = append(templates, ResourceTemplate{
+				Server:      config.Name,
+				URITemplate: uriTemplate,
+				Name:        stringField(entry, "name"),
+				Description: stringField(entry, "description"),
+				MimeType:    stringField(entry, "mimeType"),
+			})
+		}
+	}
+	return resources, templates, nil
+}
+
+// ReadResource reads uri from server.
+func (m *ClientManager) ReadResource(ctx context.Context, server string, uri string) ([]ResourceContent, error) {
+	config, err := m.lookupServer(server)
+	if err != nil {
+		return nil, err
+	}
+	uri = strings.TrimSpace(uri)
+	if uri == "" {
+		return nil, errors.New("mcp resource uri is required")
+	}
+	raw, err := m.request(ctx, config, "resources/read", map[string]any{"uri": uri})
+	if err != nil {
+		return nil, fmt.Errorf("mcp server %q resources/read %s failed: %w", config.Name, uri, err)
+	}
+	contents := []ResourceContent{}
+	for _, item := range asSlice(asMap(raw)["contents"]) {
+		entry := asMap(item)
+		text, _ := entry["text"].(string)
+		blob, _ := entry["blob"].(string)
+		contents = append(contents, ResourceContent{
+			URI:      stringField(entry, "uri"),
+			MimeType: stringField(entry, "mimeType"),
+			Text:     text,
+			Blob:     blob,
+		})
+	}
+	return contents, nil
+}
+
+// ReadResourceText reads uri from server and joins its text contents.
+// Binary contents are replaced by a one-line placeholder.
+func (m *ClientManager) ReadResourceText(ctx context.Context, server string, uri string) (string, error) {
+	contents, err := m.ReadResource(ctx, server, uri)
+	if err != nil {
+		return "", err
+	}
+	return ResourceContentsText(contents), nil
+}
+
+// ResourceContentsText renders resource contents as prompt text.
+func ResourceContentsText(contents []ResourceContent) string {
+	parts := make([]string, 0, len(contents))
+	for _, item := range contents {
+		if item.Blob != "" && item.Text == "" {
+			mimeType := item.MimeType
+			if mimeType == "" {
+				mimeType = "application/octet-stream"
+			}
+			parts = append(parts, fmt.Sprintf("[binary resource %s, %s, %d base64 bytes]", item.URI, mimeType, len(item.Blob)))
+			continue
+		}
+		parts = append(parts, item.Text)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// SubscribeResource asks server to send notifications/resources/updated for
+// uri. The subscription is renewed whenever the pooled connection is
+// re-established.
+func (m *ClientManager) SubscribeResource(ctx context.Context, server string, uri string) error {
+	return m.setSubscription(ctx, server, uri, true)
+}
+
+// UnsubscribeResource cancels a SubscribeResource.
+func (m *ClientManager) UnsubscribeResource(ctx context.Context, server string, uri string) error {
+	return m.setSubscription(ctx, server, uri, false)
+}
+
+func (m *ClientManager) setSubscription(ctx context.Context, server string, uri string, subscribe bool) error {
+	config, err := m.lookupServer(server)
+	if err != nil {
+		return err
+	}
+	uri = strings.TrimSpace(uri)
+	if uri == "" {
+		return errors.New("mcp resource uri is required")
+	}
+	method := "resources/unsubscribe"
+	if subscribe {
+		method = "resources/subscribe"
+	}
+	callCtx, cancel := m.callContext(ctx)
+	defer cancel()
+	session, conn, err := m.pool.acquire(callCtx, m.sessionID, config, m.timeout)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Call(callCtx, method, map[string]any{"uri": uri})
+	if err == nil {
+		session.recordSubscription(uri, subscribe)
+	}
+	session.release(err)
+	if err != nil {
+		return fmt.Errorf("mcp server %q %s %s failed: %w", config.Name, method, uri, err)
+	}
+	return nil
+}
+
+func (m *ClientManager) lookupServer(name string) (ServerConfig, error) {
+	if m == nil {
+		return ServerConfig{}, errors.New("mcp client is not configured")
+	}
+	server, exists := m.serversByToken[sanitizePromptToken(name)]
+	if !exists {
+		return ServerConfig{}, fmt.Errorf("mcp server %q is not configured", strings.TrimSpace(name))
+	}
+	return server, nil
+}
+
+// request sends one JSON-RPC request on the pooled connection for server.
+func (m *ClientManager) request(ctx context.Context, server ServerConfig, method string, params map[string]any) (any, error) {
+	callCtx, cancel := m.callContext(ctx)
+	defer cancel()
+	session, conn, err := m.pool.acquire(callCtx, m.sessionID, server, m.timeout)
+	if err != nil {
+		return nil, err
+	}
+	result, err := conn.Call(callCtx, method, params)
+	session.release(err)
+	return result, err
+}
+
+// paginate collects key from every page of a cursor-paginated list method.
+func (m *ClientManager) paginate(ctx context.Context, server ServerConfig, method string, key string) ([]any, error) {
+	items := []any{}
+	cursor := ""
+	for page := 0; page < maxResourceListPages; page++ {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		raw, err := m.request(ctx, server, method, params)
+		if err != nil {
+			return items, err
+		}
+		payload := asMap(raw)
+		items = append(items, asSlice(payload[key])...)
+		cursor, _ = payload["nextCursor"].(string)
+		cursor = strings.TrimSpace(cursor)
+		if cursor == "" {
+			break
+		}
+	}
+	return items, nil
+}
+
+func isMethodNotFound(err error) bool {
+	var rpcErr *rpcError
+	return errors.As(err, &rpcErr) && rpcErr.Code == -32601
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientManagerListsAndReadsResources(t *testing.T) {
+	pool := NewSessionPool(PoolOptions{})
+	defer pool.Close()
+	manager := NewSessionClientManager(pool, "sess_res", []ServerConfig{poolHelperServer("local")}, 2*time.Second)
+
+	resources, templates, err := manager.ListResources(context.Background(), "")
+	if err != nil {
+		t.Fatalf("list resources: %v", err)
+	}
+	uris := []string{}
+	for _, item := range resources {
+		uris = append(uris, item.Server+" "+item.URI)
+	}
+	if strings.Join(uris, ",") != "local memo://one,local memo://two" {
+		t.Fatalf("expected both resource pages, got %v", uris)
+	}
+	if resources[0].MimeType != "text/plain" || resources[0].Name != "One" {
+		t.Fatalf("unexpected resource metadata %+v", resources[0])
+	}
+	if len(templates) != 1 || templates[0].URITemplate != "memo://{id}" {
+		t.Fatalf("unexpected templates %+v", templates)
+	}
+
+	text, err := manager.ReadResourceText(context.Background(), "local", "memo://one")
+	if err != nil {
+		t.Fatalf("read resource: %v", err)
+	}
+	if text != "body of memo://one" {
+		t.Fatalf("unexpected resource text %q", text)
+	}
+	if _, err := manager.ReadResource(context.Background(), "missing", "memo://one"); err == nil || !strings.Contains(err.Error(), "not configured") {
+		t.Fatalf("expected unknown server error, got %v", err)
+	}
+}
+
+func TestClientManagerResubscribesAfterRestart(t *testing.T) {
+	pool := NewSessionPool(PoolOptions{RestartBackoff: 10 * time.Millisecond})
+	defer pool.Close()
+	manager := NewSessionClientManager(pool, "sess_sub", []ServerConfig{poolHelperServer("local")}, 2*time.Second)
+
+	if err := manager.SubscribeResource(context.Background(), "local", "memo://one"); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if _, err := manager.Call(context.Background(), "mcp__local__crash", map[string]any{}); err == nil {
+		t.Fatalf("expected crash call to fail")
+	}
+	waitForPoolStatus(t, pool, "local", SessionRestarting)
+
+	count, err := manager.ReadResourceText(context.Background(), "local", "memo://subscriptions")
+	if err != nil {
+		t.Fatalf("read after restart: %v", err)
+	}
+	if count != "1" {
+		t.Fatalf("expected restarted server to receive the subscription again, got %q", count)
+	}
+}
+
+func TestResourceContentsTextDescribesBinaryContent(t *testing.T) {
+	text := ResourceContentsText([]ResourceContent{
+		{URI: "memo://a", Text: "alpha"},
+		{URI: "memo://logo", MimeType: "image/png", Blob: "aGVsbG8="},
+	})
+	if text != "alpha\n[binary resource memo://logo, image/png, 8 base64 bytes]" {
+		t.Fatalf("unexpected rendered contents %q", text)
+	}
+}
@@ -0,0 +1,343 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"goyais/services/hub/internal/agent/context/settings"
+)
+
+// Server request methods served by pooled sessions.
+const (
+	methodSamplingCreateMessage = "sampling/createMessage"
+	methodElicitationCreate     = "elicitation/create"
+	methodPing                  = "ping"
+)
+
+// Sampling approval policies.
+const (
+	SamplingApprovalAsk   = "ask"
+	SamplingApprovalAllow = "allow"
+	SamplingApprovalDeny  = "deny"
+)
+
+// Elicitation result actions.
+const (
+	ElicitationAccept  = "accept"
+	ElicitationDecline = "decline"
+	ElicitationCancel  = "cancel"
+)
+
+// defaultSamplingMaxTokens caps sampling completions when settings do not.
+const defaultSamplingMaxTokens = 1024
+
+// ErrServerRequestDenied is returned by a ServerRequestHandler that refuses
+// a server request. The server receives it as a user rejection.
+var ErrServerRequestDenied = &rpcError{Code: -1, Message: "request denied"}
+
+// DeniedError wraps ErrServerRequestDenied with the reason sent to the
+// server.
+func DeniedError(reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ErrServerRequestDenied
+	}
+	return &rpcError{Code: ErrServerRequestDenied.Code, Message: reason}
+}
+
+// IsDenied reports whether err refuses a server request.
+func IsDenied(err error) bool {
+	var rpcErr *rpcError
+	return errors.As(err, &rpcErr) && rpcErr.Code == ErrServerRequestDenied.Code
+}
+
+// SamplingMessage is one text message of a sampling request. Non-text
+// content is rendered as a placeholder.
+type SamplingMessage struct {
+	Role string
+	Text string
+}
+
+// SamplingRequest is a decoded sampling/createMessage request.
+type SamplingRequest struct {
+	Messages     []SamplingMessage
+	SystemPrompt string
+	MaxTokens    int
+	ModelHints   []string
+}
+
+// SamplingResult is the completion returned to the server.
+type SamplingResult struct {
+	Text       string
+	Model      string
+	StopReason string
+}
+
+// ElicitationRequest is a decoded elicitation/create request.
+// RequestedSchema is a flat JSON object schema of the fields wanted.
+type ElicitationRequest struct {
+	Message         string
+	RequestedSchema map[string]any
+}
+
+// ElicitationResult is the user's response. Content is set only when
+// Action is ElicitationAccept.
+type ElicitationResult struct {
+	Action  string
+	Content map[string]any
+}
+
+// ServerRequestHandler serves the requests MCP servers send to the client
+// during a run.
+type ServerRequestHandler interface {
+	CreateMessage(ctx context.Context, server string, req SamplingRequest) (SamplingResult, error)
+	Elicit(ctx context.Context, server string, req ElicitationRequest) (ElicitationResult, error)
+}
+
+// SamplingPolicy decides whether a server may sample the model and how
+// many tokens a completion may use.
+type SamplingPolicy struct {
+	Approval  string
+	MaxTokens int
+}
+
+// SamplingPolicies holds the default policy and per-server overrides from
+// the "mcpSampling" settings key:
+//
+//	{"mcpSampling": {"approval": "ask", "maxTokens": 1024, "servers": {"docs": {"approval": "allow"}}}}
+type SamplingPolicies struct {
+	Default SamplingPolicy
+	Servers map[string]SamplingPolicy
+}
+
+// DefaultSamplingPolicies asks before every sampling request.
+func DefaultSamplingPolicies() SamplingPolicies {
+	return SamplingPolicies{Default: SamplingPolicy{Approval: SamplingApprovalAsk, MaxTokens: defaultSamplingMaxTokens}}
+}
+
+// LoadSamplingPolicies reads sampling policies from the merged settings of
+// workingDir; an empty homeDir resolves to the current user's home.
+func LoadSamplingPolicies(workingDir string, homeDir string) (SamplingPolicies, error) {
+	merged, err := settings.LoadAndMerge(settings.LoadOptions{
+		WorkingDir: strings.TrimSpace(workingDir),
+		HomeDir:    strings.TrimSpace(homeDir),
+	})
+	if err != nil {
+		return DefaultSamplingPolicies(), err
+	}
+	return SamplingPoliciesFromSettings(merged.Effective)
+}
+
+// SamplingPoliciesFromSettings decodes the "mcpSampling" key from an
+// effective settings map.
+func SamplingPoliciesFromSettings(effective map[string]any) (SamplingPolicies, error) {
+	policies := DefaultSamplingPolicies()
+	raw, ok := effective["mcpSampling"]
+	if !ok || raw == nil {
+		return policies, nil
+	}
+	section, ok := raw.(map[string]any)
+	if !ok {
+		return policies, fmt.Errorf("mcpSampling settings must be an object")
+	}
+	base, err := decodeSamplingPolicy(section, policies.Default, "mcpSampling")
+	if err != nil {
+		return policies, err
+	}
+	policies.Default = base
+	servers, _ := section["servers"].(map[string]any)
+	for name, value := range servers {
+		entry, ok := value.(map[string]any)
+		if !ok {
+			return policies, fmt.Errorf("mcpSampling.servers.%s must be an object", name)
+		}
+		policy, err := decodeSamplingPolicy(entry, base, "mcpSampling.servers."+name)
+		if err != nil {
+			return policies, err
+		}
+		if policies.Servers == nil {
+			policies.Servers = map[string]SamplingPolicy{}
+		}
+		policies.Servers[sanitizePromptToken(name)] = policy
+	}
+	return policies, nil
+}
+
+func decodeSamplingPolicy(section map[string]any, fallback SamplingPolicy, path string) (SamplingPolicy, error) {
+	policy := fallback
+	if value, exists := section["approval"]; exists {
+		approval := strings.ToLower(strings.TrimSpace(fmt.Sprint(value)))
+		switch approval {
+		case SamplingApprovalAsk, SamplingApprovalAllow, SamplingApprovalDeny:
+			policy.Approval = approval
+		default:
+			return fallback, fmt.Errorf("%s.approval must be ask, allow or deny", path)
+		}
+	}
+	if value, exists := section["maxTokens"]; exists {
+		tokens, ok := value.(float64)
+		if !ok || tokens <= 0 {
+			return fallback, fmt.Errorf("%s.maxTokens must be a positive number", path)
+		}
+		policy.MaxTokens = int(tokens)
+	}
+	return policy, nil
+}
+
+// For returns the policy of server.
+func (p SamplingPolicies) For(server string) SamplingPolicy {
+	if policy, ok := p.Servers[sanitizePromptToken(server)]; ok {
+		return policy
+	}
+	if p.Default.Approval == "" {
+		return DefaultSamplingPolicies().Default
+	}
+	return p.Default
+}
+
+// serverRequestRegistration is one ServeServerRequests registration.
+type serverRequestRegistration struct {
+	sessionID string
+	handler   ServerRequestHandler
+}
+
+// ServeServerRequests makes handler serve sampling and elicitation requests
+// from servers pooled for sessionID until the returned func is called. The
+// most recent registration for a session wins.
+func (p *SessionPool) ServeServerRequests(sessionID string, handler ServerRequestHandler) func() {
+	if handler == nil {
+		return func() {}
+	}
+	p.mu.Lock()
+	p.nextListener++
+	id := p.nextListener
+	if p.requestHandlers == nil {
+		p.requestHandlers = map[int]serverRequestRegistration{}
+	}
+	p.requestHandlers[id] = serverRequestRegistration{sessionID: sessionID, handler: handler}
+	p.mu.Unlock()
+	return func() {
+		p.mu.Lock()
+		delete(p.requestHandlers, id)
+		p.mu.Unlock()
+	}
+}
+
+func (p *SessionPool) serverRequestHandler(sessionID string) ServerRequestHandler {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	latest := 0
+	var handler ServerRequestHandler
+	for id, registration := range p.requestHandlers {
+		if registration.sessionID == sessionID && id > latest {
+			latest, handler = id, registration.handler
+		}
+	}
+	return handler
+}
+
+// ServeServerRequests makes handler serve server requests for the
+// manager's session. The returned func stops serving.
+func (m *ClientManager) ServeServerRequests(handler ServerRequestHandler) func() {
+	if m == nil {
+		return func() {}
+	}
+	return m.pool.ServeServerRequests(m.sessionID, handler)
+}
+
+// handleRequest is the request handler of the session's connection.
+func (s *pooledSession) handleRequest(ctx context.Context, method string, params map[string]any) (any, error) {
+	switch method {
+	case methodPing:
+		return map[string]any{}, nil
+	case methodSamplingCreateMessage, methodElicitationCreate:
+	default:
+		return nil, errUnsupportedRequest
+	}
+	var handler ServerRequestHandler
+	if s.pool != nil {
+		handler = s.pool.serverRequestHandler(s.sessionID)
+	}
+	if handler == nil {
+		return nil, DeniedError("no active run can serve " + method)
+	}
+	if method == methodElicitationCreate {
+		result, err := handler.Elicit(ctx, s.server.Name, decodeElicitationRequest(params))
+		if err != nil {
+			return nil, err
+		}
+		return encodeElicitationResult(result), nil
+	}
+	result, err := handler.CreateMessage(ctx, s.server.Name, decodeSamplingRequest(params))
+	if err != nil {
+		return nil, err
+	}
+	return encodeSamplingResult(result), nil
+}
+
+func decodeSamplingRequest(params map[string]any) SamplingRequest {
+	req := SamplingRequest{SystemPrompt: stringField(params, "systemPrompt")}
+	if tokens, ok := params["maxTokens"].(float64); ok && tokens > 0 {
+		req.MaxTokens = int(tokens)
+	}
+	for _, item := range asSlice(params["messages"]) {
+		entry := asMap(item)
+		content := asMap(entry["content"])
+		text, _ := content["text"].(string)
+		if kind := stringField(content, "type"); kind != "" && kind != "text" {
+			text = fmt.Sprintf("[%s content omitted]", kind)
+		}
+		req.Messages = append(req.Messages, SamplingMessage{Role: stringField(entry, "role"), Text: text})
+	}
+	for _, hint := range asSlice(asMap(params["modelPreferences"])["hints"]) {
+		if name := stringField(hint, "name"); name != "" {
+			req.ModelHints = append(req.ModelHints, name)
+		}
+	}
+	return req
+}
+
+func encodeSamplingResult(result SamplingResult) map[string]any {
+	stopReason := result.StopReason
+	if stopReason == "" {
+		stopReason = "endTurn"
+	}
+	return map[string]any{
+		"role":       "assistant",
+		"content":    map[string]any{"type": "text", "text": result.Text},
+		"model":      result.Model,
+		"stopReason": stopReason,
+	}
+}
+
+func decodeElicitationRequest(params map[string]any) ElicitationRequest {
+	message, _ := params["message"].(string)
+	return ElicitationRequest{
+		Message:         strings.TrimSpace(message),
+		RequestedSchema: asMap(params["requestedSchema"]),
+	}
+}
+
+func encodeElicitationResult(result ElicitationResult) map[string]any {
+	action := result.Action
+	switch action {
+	case ElicitationAccept, ElicitationDecline, ElicitationCancel:
+	default:
+		action = ElicitationCancel
+	}
+	out := map[string]any{"action": action}
+	if action == ElicitationAccept {
+		content := result.Content
+		if content == nil {
+			content = map[string]any{}
+		}
+		out["content"] = content
+	}
+	return out
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeServerRequestHandler struct {
+	sampling    SamplingRequest
+	elicitation ElicitationRequest
+	denySample  bool
+}
+
+func (h *fakeServerRequestHandler) CreateMessage(_ context.Context, server string, req SamplingRequest) (SamplingResult, error) {
+	h.sampling = req
+	if h.denySample {
+		return SamplingResult{}, DeniedError("sampling disabled for " + server)
+	}
+	return SamplingResult{Text: "short summary", Model: "test-model"}, nil
+}
+
+func (h *fakeServerRequestHandler) Elicit(_ context.Context, _ string, req ElicitationRequest) (ElicitationResult, error) {
+	h.elicitation = req
+	return ElicitationResult{Action: ElicitationAccept, Content: map[string]any{"region": "eu-west-1"}}, nil
+}
+
+func callServerRequestTool(t *testing.T, manager *ClientManager, tool string) map[string]any {
+	t.Helper()
+	result, err := manager.Call(context.Background(), "mcp__local__"+tool, map[string]any{})
+	if err != nil {
+		t.Fatalf("call %s: %v", tool, err)
+	}
+	answer := map[string]any{}
+	if err := json.Unmarshal([]byte(asStringAny(result["output"])), &answer); err != nil {
+		t.Fatalf("decode %s answer %v: %v", tool, result["output"], err)
+	}
+	return answer
+}
+
+func TestSessionPoolServesSamplingAndElicitationRequests(t *testing.T) {
+	pool := NewSessionPool(PoolOptions{})
+	defer pool.Close()
+	manager := NewSessionClientManager(pool, "sess_sampling", []ServerConfig{notifyingHelperServer("local")}, 2*time.Second)
+	handler := &fakeServerRequestHandler{}
+	stop := manager.ServeServerRequests(handler)
+	defer stop()
+
+	sampled := callServerRequestTool(t, manager, "sample")
+	result, _ := sampled["result"].(map[string]any)
+	content, _ := result["content"].(map[string]any)
+	if content["text"] != "short summary" || result["model"] != "test-model" || result["stopReason"] != "endTurn" {
+		t.Fatalf("unexpected sampling answer %+v", sampled)
+	}
+	if handler.sampling.SystemPrompt != "be brief" || handler.sampling.MaxTokens != 4096 || len(handler.sampling.Messages) != 1 || handler.sampling.Messages[0].Text != "summarize" {
+		t.Fatalf("unexpected decoded sampling request %+v", handler.sampling)
+	}
+
+	elicited := callServerRequestTool(t, manager, "elicit")
+	result, _ = elicited["result"].(map[string]any)
+	fields, _ := result["content"].(map[string]any)
+	if result["action"] != ElicitationAccept || fields["region"] != "eu-west-1" {
+		t.Fatalf("unexpected elicitation answer %+v", elicited)
+	}
+	if handler.elicitation.Message != "pick a region" {
+		t.Fatalf("unexpected decoded elicitation request %+v", handler.elicitation)
+	}
+}
+
+func TestSessionPoolDeniesServerRequestsWithoutHandler(t *testing.T) {
+	pool := NewSessionPool(PoolOptions{})
+	defer pool.Close()
+	manager := NewSessionClientManager(pool, "sess_sampling_denied", []ServerConfig{notifyingHelperServer("local")}, 2*time.Second)
+
+	answer := callServerRequestTool(t, manager, "sample")
+	rpcErr, _ := answer["error"].(map[string]any)
+	if rpcErr["code"] != float64(-1) || !strings.Contains(asStringAny(rpcErr["message"]), "no active run") {
+		t.Fatalf("expected denial without a handler, got %+v", answer)
+	}
+
+	stop := manager.ServeServerRequests(&fakeServerRequestHandler{denySample: true})
+	defer stop()
+	answer = callServerRequestTool(t, manager, "sample")
+	rpcErr, _ = answer["error"].(map[string]any)
+	if rpcErr["code"] != float64(-1) || rpcErr["message"] != "sampling disabled for local" {
+		t.Fatalf("expected handler denial, got %+v", answer)
+	}
+}
+
+func TestSamplingPoliciesFromSettings(t *testing.T) {
+	policies, err := SamplingPoliciesFromSettings(map[string]any{
+		"mcpSampling": map[string]any{
+			"approval":  "allow",
+			"maxTokens": float64(512),
+			"servers": map[string]any{
+				"Docs": map[string]any{"approval": "deny"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("decode policies: %v", err)
+	}
+	if got := policies.For("other"); got != (SamplingPolicy{Approval: SamplingApprovalAllow, MaxTokens: 512}) {
+		t.Fatalf("unexpected default policy %+v", got)
+	}
+	if got := policies.For("docs"); got != (SamplingPolicy{Approval: SamplingApprovalDeny, MaxTokens: 512}) {
+		t.Fatalf("expected server override to inherit the token cap, got %+v", got)
+	}
+	if got := DefaultSamplingPolicies().For("any"); got.Approval != SamplingApprovalAsk {
+		t.Fatalf("expected sampling to ask by default, got %+v", got)
+	}
+	if _, err := SamplingPoliciesFromSettings(map[string]any{"mcpSampling": map[string]any{"approval": "sometimes"}}); err == nil {
+		t.Fatalf("expected invalid approval to be rejected")
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// validateSchema checks value against the JSON Schema subset MCP tools use
+// for outputSchema: type, required, properties, additionalProperties, items
+// and enum. Other keywords are accepted without being enforced.
+func validateSchema(schema map[string]any, value any, path string) error {
+	if len(schema) == 0 {
+		return nil
+	}
+	if err := checkSchemaType(schema["type"], value, path); err != nil {
+		return err
+	}
+	if options, ok := schema["enum"].([]any); ok && !schemaEnumContains(options, value) {
+		return fmt.Errorf("%s is not one of the allowed values", path)
+	}
+	switch typed := value.(type) {
+	case map[string]any:
+		properties := asMap(schema["properties"])
+		for _, name := range asSlice(schema["required"]) {
+			key, _ := name.(string)
+			if _, exists := typed[key]; key != "" && !exists {
+				return fmt.Errorf("%s.%s is required", path, key)
+			}
+		}
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			propertySchema, declared := properties[key].(map[string]any)
+			if declared {
+				if err := validateSchema(propertySchema, typed[key], path+"."+key); err != nil {
+					return err
+				}
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					return fmt.Errorf("%s.%s is not allowed", path, key)
+				}
+			case map[string]any:
+				if err := validateSchema(extra, typed[key], path+"."+key); err != nil {
+					return err
+				}
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for index, item := range typed {
+				if err := validateSchema(items, item, fmt.Sprintf("%s[%d]", path, index)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func checkSchemaType(declared any, value any, path string) error {
+	names := []string{}
+	switch typed := declared.(type) {
+	case string:
+		names = append(names, typed)
+	case []any:
+		for _, item := range typed {
+			if name, ok := item.(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	for _, name := range names {
+		if schemaTypeMatches(name, value) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must be of type %v", path, declared)
+}
+
+func schemaTypeMatches(name string, value any) bool {
+	switch name {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	case "number":
+		_, ok := schemaNumber(value)
+		return ok
+	case "integer":
+		number, ok := schemaNumber(value)
+		return ok && number == math.Trunc(number)
+	default:
+		return true
+	}
+}
+
+func schemaNumber(value any) (float64, bool) {
+	switch typed := value.(type) {
+	case float64:
+		return typed, true
+	case int:
+		return float64(typed), true
+	case int64:
+		return float64(typed), true
+	case json.Number:
+		number, err := typed.Float64()
+		return number, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func schemaEnumContains(options []any, value any) bool {
+	for _, option := range options {
+		if reflect.DeepEqual(option, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,403 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// maxStreamResumes bounds Last-Event-ID reconnects for one request.
+	maxStreamResumes = 3
+	// sessionDeleteTimeout bounds the DELETE sent when a session is closed,
+	// and the cancellation notice sent when a request is abandoned.
+	sessionDeleteTimeout = 2 * time.Second
+)
+
+// httpConn speaks the Streamable HTTP transport: every message is POSTed to
+// one endpoint and the reply is either a JSON body or an SSE stream. The
+// Mcp-Session-Id assigned at initialize is echoed on every later request,
+// and an interrupted stream is resumed with Last-Event-ID. With an OAuth
+// token source the bearer token is refreshed once when the server answers
+// 401.
+type httpConn struct {
+	client   *http.Client
+	endpoint string
+	headers  map[string]string
+	handlers serverHandlers
+	auth     *bearerAuth
+	nextID   atomic.Int64
+
+	mu          sync.Mutex
+	sessionID   string
+	version     string
+	token       string
+	tokenLoaded bool
+	err         error
+
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+func dialHTTPConn(server ServerConfig, handlers serverHandlers) (*httpConn, error) {
+	endpoint := strings.TrimSpace(server.Endpoint)
+	parsed, err := url.Parse(endpoint)
+	if endpoint == "" || err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, &ConnectError{Code: ConnectErrorInvalidEndpoint, Err: fmt.Errorf("mcp http endpoint %q is not a valid http(s) url", endpoint)}
+	}
+	// Streams may stay open for as long as a tool runs, so requests are
+	// bounded by their context rather than a client timeout.
+	return &httpConn{
+		client:   &http.Client{},
+		endpoint: endpoint,
+		headers:  cloneStringMap(server.Env),
+		handlers: handlers,
+		auth:     newBearerAuth(server),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+func (c *httpConn) Call(ctx context.Context, method string, params map[string]any) (any, error) {
+	if err := c.Err(); err != nil {
+		return nil, err
+	}
+	id := int(c.nextID.Add(1))
+	res, err := c.post(ctx, map[string]any{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			c.cancelRequest(id, ctx.Err())
+		}
+		return nil, err
+	}
+	if method == "initialize" {
+		if sessionID := strings.TrimSpace(res.Header.Get("Mcp-Session-Id")); sessionID != "" {
+			c.mu.Lock()
+			c.sessionID = sessionID
+			c.mu.Unlock()
+		}
+	}
+	result, err := c.readResponse(ctx, res, id)
+	if err != nil && ctx.Err() != nil {
+		c.cancelRequest(id, ctx.Err())
+	}
+	if err == nil && method == "initialize" {
+		c.mu.Lock()
+		c.version = stringField(result, "protocolVersion")
+		c.mu.Unlock()
+	}
+	return result, err
+}
+
+func (c *httpConn) Notify(ctx context.Context, method string, params map[string]any) error {
+	res, err := c.post(ctx, map[string]any{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxFrameBytes))
+	return res.Body.Close()
+}
+
+// cancelRequest tells the server to stop working on an abandoned request.
+// Dropping the response stream alone does not cancel it.
+func (c *httpConn) cancelRequest(id int, reason error) {
+	if c.Err() != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sessionDeleteTimeout)
+	defer cancel()
+	_ = c.Notify(ctx, "notifications/cancelled", map[string]any{
+		"requestId": id,
+		"reason":    reason.Error(),
+	})
+}
+
+func (c *httpConn) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *httpConn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Close ends the server session with a DELETE when one was assigned.
+func (c *httpConn) Close() error {
+	c.mu.Lock()
+	sessionID := c.sessionID
+	alreadyFailed := c.err != nil
+	c.mu.Unlock()
+	if sessionID != "" && !alreadyFailed {
+		ctx, cancel := context.WithTimeout(context.Background(), sessionDeleteTimeout)
+		defer cancel()
+		if req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.endpoint, nil); err == nil {
+			c.applyHeaders(req)
+			if res, err := c.client.Do(req); err == nil {
+				_ = res.Body.Close()
+			}
+		}
+	}
+	c.fail(errConnClosed)
+	return nil
+}
+
+func (c *httpConn) fail(err error) {
+	c.mu.Lock()
+	if c.err == nil {
+		c.err = err
+	}
+	c.mu.Unlock()
+	c.doneOnce.Do(func() { close(c.done) })
+}
+
+func (c *httpConn) applyHeaders(req *http.Request) {
+	for key, value := range c.headers {
+		if trimmed := strings.TrimSpace(key); trimmed != "" {
+			req.Header.Set(trimmed, strings.TrimSpace(value))
+		}
+	}
+	c.mu.Lock()
+	sessionID, version, token := c.sessionID, c.version, c.token
+	c.mu.Unlock()
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", sessionID)
+	}
+	if version != "" {
+		req.Header.Set("MCP-Protocol-Version", version)
+	}
+}
+
+func (c *httpConn) post(ctx context.Context, payload map[string]any) (*http.Response, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if err := c.loadToken(ctx); err != nil {
+		return nil, err
+	}
+	c.applyHeaders(req)
+	return c.do(req)
+}
+
+// loadToken reads the stored token once per connection; later tokens come
+// from refreshes after a 401.
+func (c *httpConn) loadToken(ctx context.Context) error {
+	if c.auth == nil {
+		return nil
+	}
+	c.mu.Lock()
+	loaded := c.tokenLoaded
+	c.mu.Unlock()
+	if loaded {
+		return nil
+	}
+	token, err := c.auth.token(ctx)
+	if err != nil {
+		if IsAuthorizationRequired(err) {
+			return unauthorizedError(c.endpoint, err)
+		}
+		return err
+	}
+	c.mu.Lock()
+	c.token, c.tokenLoaded = token, true
+	c.mu.Unlock()
+	return nil
+}
+
+// reauthorize refreshes the token after req was rejected and returns req
+// again with the new token.
+func (c *httpConn) reauthorize(req *http.Request) (*http.Request, error) {
+	token, err := c.auth.refresh(req.Context())
+	if err != nil || token == "" {
+		return nil, unauthorizedError(c.endpoint, err)
+	}
+	c.mu.Lock()
+	c.token, c.tokenLoaded = token, true
+	c.mu.Unlock()
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	retry.Header.Set("Authorization", "Bearer "+token)
+	return retry, nil
+}
+
+// do sends req and turns non-2xx replies into errors. A 404 on a request
+// that carried a session id means the server dropped the session, so the
+// connection is failed and the pool reinitializes it. A 401 is retried once
+// with a refreshed token.
+func (c *httpConn) do(req *http.Request) (*http.Response, error) {
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusUnauthorized && c.auth != nil {
+		_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxFrameBytes))
+		_ = res.Body.Close()
+		retry, err := c.reauthorize(req)
+		if err != nil {
+			return nil, err
+		}
+		if res, err = c.client.Do(retry); err != nil {
+			return nil, err
+		}
+	}
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return res, nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxFrameBytes))
+	_ = res.Body.Close()
+	if res.StatusCode == http.StatusUnauthorized && c.auth != nil {
+		return nil, unauthorizedError(c.endpoint, nil)
+	}
+	if res.StatusCode == http.StatusNotFound && req.Header.Get("Mcp-Session-Id") != "" {
+		err := fmt.Errorf("%w: mcp session expired", errConnClosed)
+		c.fail(err)
+		return nil, err
+	}
+	return nil, fmt.Errorf("rpc status %d: %s", res.StatusCode, strings.TrimSpace(string(body)))
+}
+
+func (c *httpConn) readResponse(ctx context.Context, res *http.Response, id int) (any, error) {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(res.Header.Get("Content-Type"), ";")[0]))
+	if mediaType == "text/event-stream" {
+		return c.readEventStream(ctx, res.Body, id)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxFrameBytes))
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return map[string]any{}, nil
+	}
+	message, ok := decodeRPCMessage(body)
+	if !ok {
+		return nil, fmt.Errorf("invalid mcp response body: %s", strings.TrimSpace(string(body)))
+	}
+	return message.response()
+}
+
+// readEventStream reads SSE events until the response for id arrives,
+// resuming from the last seen event id if the stream drops first.
+func (c *httpConn) readEventStream(ctx context.Context, body io.ReadCloser, id int) (any, error) {
+	lastEventID := ""
+	for attempt := 0; ; attempt++ {
+		message, found, err := c.scanEvents(ctx, body, id, &lastEventID)
+		_ = body.Close()
+		if found {
+			return message.response()
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if lastEventID == "" || attempt >= maxStreamResumes {
+			if err == nil {
+				err = errors.New("mcp stream closed before response")
+			}
+			return nil, err
+		}
+		req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint, nil)
+		if reqErr != nil {
+			return nil, reqErr
+		}
+		req.Header.Set("Accept", "text/event-stream")
+		c.applyHeaders(req)
+		req.Header.Set("Last-Event-ID", lastEventID)
+		res, resumeErr := c.do(req)
+		if resumeErr != nil {
+			return nil, fmt.Errorf("resume mcp stream: %w", resumeErr)
+		}
+		body = res.Body
+	}
+}
+
+func (c *httpConn) scanEvents(ctx context.Context, reader io.Reader, id int, lastEventID *string) (rpcMessage, bool, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 4096), maxFrameBytes)
+	eventID := ""
+	data := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			if eventID != "" {
+				*lastEventID = eventID
+			}
+			if strings.TrimSpace(data) != "" {
+				if message, ok := decodeRPCMessage([]byte(data)); ok {
+					if message.isServerMessage() {
+						c.handleServerMessage(ctx, message)
+					} else if parseID(message.ID) == id {
+						return message, true, nil
+					}
+				}
+			}
+			eventID = ""
+			data = ""
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "id:"):
+			eventID = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+		case strings.HasPrefix(line, "data:"):
+			if data != "" {
+				data += "\n"
+			}
+			data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+	return rpcMessage{}, false, scanner.Err()
+}
+
+// handleServerMessage delivers notifications that arrive on a response
+// stream and answers server requests with a POST. A request is served for
+// as long as the stream's call is in flight.
+func (c *httpConn) handleServerMessage(ctx context.Context, message rpcMessage) {
+	if message.ID == nil {
+		message.deliver(c.handlers.notify)
+		return
+	}
+	go func() {
+		res, err := c.post(ctx, serveRequest(ctx, c.handlers.request, message))
+		if err != nil {
+			return
+		}
+		_ = res.Body.Close()
+	}()
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClientManagerCallStreamableHTTPResumesStream(t *testing.T) {
+	var mu sync.Mutex
+	seen := []string{}
+	deleted := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodDelete {
+			deleted = r.Header.Get("Mcp-Session-Id") == "sess-42"
+			return
+		}
+		if r.Method == http.MethodGet {
+			if r.Header.Get("Last-Event-ID") != "evt-1" || r.Header.Get("Mcp-Session-Id") != "sess-42" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			seen = append(seen, "resume")
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, "id: evt-2\ndata: "+`{"jsonrpc":"2.0","id":3,"result":{"content":[{"type":"text","text":"pong-streamable"}]}}`+"\n\n")
+			return
+		}
+		payload := map[string]any{}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		method := asStringAny(payload["method"])
+		if method != "initialize" && (r.Header.Get("Mcp-Session-Id") != "sess-42" || r.Header.Get("MCP-Protocol-Version") != "2025-03-26") {
+			t.Errorf("%s sent without session headers: %v", method, r.Header)
+		}
+		if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			t.Errorf("expected streamable accept header, got %q", r.Header.Get("Accept"))
+		}
+		seen = append(seen, method)
+		switch method {
+		case "initialize":
+			w.Header().Set("Mcp-Session-Id", "sess-42")
+			writeMCPHTTPResponse(w, map[string]any{"jsonrpc": "2.0", "id": payload["id"], "result": map[string]any{"protocolVersion": "2025-03-26"}})
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusAccepted)
+		case "tools/list":
+			writeMCPHTTPResponse(w, map[string]any{"jsonrpc": "2.0", "id": payload["id"], "result": map[string]any{"tools": []map[string]any{{"name": "ping"}}}})
+		case "tools/call":
+			// The stream drops after a progress notification; the client must
+			// resume it to get the result.
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, "id: evt-1\ndata: "+`{"jsonrpc":"2.0","method":"notifications/progress","params":{"progress":1}}`+"\n\n")
+		}
+	}))
+	defer server.Close()
+
+	pool := NewSessionPool(PoolOptions{})
+	manager := NewSessionClientManager(pool, "sess_http", []ServerConfig{{Name: "remote", Transport: "http", Endpoint: server.URL + "/mcp", Tools: []string{"ping"}}}, 2*time.Second)
+	result, err := manager.Call(context.Background(), "mcp__remote__ping", map[string]any{})
+	if err != nil {
+		t.Fatalf("streamable http call failed: %v", err)
+	}
+	if asStringAny(result["output"]) != "pong-streamable" {
+		t.Fatalf("unexpected output %#v", result["output"])
+	}
+	if status := pool.Sessions("remote")[0]; status.ProtocolVersion != "2025-03-26" {
+		t.Fatalf("expected negotiated protocol version, got %+v", status)
+	}
+	pool.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(seen, ",") != "initialize,notifications/initialized,tools/list,tools/call,resume" {
+		t.Fatalf("unexpected request sequence %v", seen)
+	}
+	if !deleted {
+		t.Fatalf("expected session to be deleted on close")
+	}
+}
+
+func TestSessionPoolRejectsUnsupportedProtocolVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := map[string]any{}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		if offered := asMap(payload["params"])["protocolVersion"]; offered != latestProtocolVersion {
+			t.Errorf("expected client to offer %s, got %v", latestProtocolVersion, offered)
+		}
+		writeMCPHTTPResponse(w, map[string]any{"jsonrpc": "2.0", "id": payload["id"], "result": map[string]any{"protocolVersion": "2023-01-01"}})
+	}))
+	defer server.Close()
+
+	pool := NewSessionPool(PoolOptions{})
+	defer pool.Close()
+	_, err := pool.Connect(context.Background(), "", ServerConfig{Name: "old", Transport: "http", Endpoint: server.URL}, time.Second)
+	var connectErr *ConnectError
+	if !errors.As(err, &connectErr) || connectErr.Code != ConnectErrorProtocolVersion {
+		t.Fatalf("expected unsupported protocol version error, got %v", err)
+	}
+}
+
+func TestNegotiateProtocolVersion(t *testing.T) {
+	for _, tc := range []struct {
+		server string
+		want   string
+	}{
+		{server: "2025-06-18", want: "2025-06-18"},
+		{server: "2025-03-26", want: "2025-03-26"},
+		{server: "", want: "2024-11-05"},
+	} {
+		got, err := negotiateProtocolVersion(tc.server)
+		if err != nil || got != tc.want {
+			t.Fatalf("negotiate %q: got %q err=%v", tc.server, got, err)
+		}
+	}
+	if _, err := negotiateProtocolVersion("2099-01-01"); err == nil {
+		t.Fatalf("expected unknown version to be rejected")
+	}
+}
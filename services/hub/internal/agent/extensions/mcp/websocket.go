@@ -0,0 +1,469 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket keepalive and reconnect tuning. Variables so tests can shorten
+// them.
+var (
+	// wsPingInterval is how often a ping is sent on an idle socket.
+	wsPingInterval = 20 * time.Second
+	// wsPongWait is how long the socket may stay silent before it is treated
+	// as dead. It must exceed wsPingInterval.
+	wsPongWait = 45 * time.Second
+	// wsWriteWait bounds a single frame write.
+	wsWriteWait = 10 * time.Second
+	// wsReconnectAttempts is how many redials are tried after a dropped
+	// socket before the connection is failed and left to the pool.
+	wsReconnectAttempts = 3
+	// wsReconnectBackoff is the delay before the first redial; it doubles per
+	// attempt.
+	wsReconnectBackoff = 250 * time.Millisecond
+)
+
+var errWSReconnecting = fmt.Errorf("%w: mcp websocket reconnecting", errConnClosed)
+
+// wsConn carries JSON-RPC over a WebSocket, one message per text frame,
+// multiplexing requests by id like stdioConn. A dropped socket is redialed
+// in place and the initialize handshake replayed, so the pooled session
+// survives transient network failures; requests in flight at the drop fail.
+type wsConn struct {
+	dialer   *websocket.Dialer
+	endpoint string
+	headers  http.Header
+	handlers serverHandlers
+	auth     *bearerAuth
+
+	// Timings are copied from the package variables at dial time.
+	pingInterval      time.Duration
+	pongWait          time.Duration
+	reconnectAttempts int
+	reconnectBackoff  time.Duration
+
+	writeMu sync.Mutex
+
+	mu         sync.Mutex
+	socket     *websocket.Conn
+	nextID     int
+	pending    map[int]chan rpcResponse
+	initParams map[string]any
+	closing    bool
+	err        error
+
+	done chan struct{}
+}
+
+func dialWSConn(ctx context.Context, server ServerConfig, timeout time.Duration, handlers serverHandlers) (*wsConn, error) {
+	endpoint := strings.TrimSpace(server.Endpoint)
+	parsed, err := url.Parse(endpoint)
+	if endpoint == "" || err != nil || (parsed.Scheme != "ws" && parsed.Scheme != "wss") || parsed.Host == "" {
+		return nil, &ConnectError{Code: ConnectErrorInvalidEndpoint, Err: fmt.Errorf("mcp ws endpoint %q is not a valid ws(s) url", endpoint)}
+	}
+	headers := http.Header{}
+	for key, value := range server.Env {
+		if trimmed := strings.TrimSpace(key); trimmed != "" {
+			headers.Set(trimmed, strings.TrimSpace(value))
+		}
+	}
+	conn := &wsConn{
+		dialer: &websocket.Dialer{
+			Proxy:            http.ProxyFromEnvironment,
+			HandshakeTimeout: timeout,
+			Subprotocols:     []string{"mcp"},
+		},
+		endpoint:          endpoint,
+		headers:           headers,
+		handlers:          handlers,
+		auth:              newBearerAuth(server),
+		pingInterval:      wsPingInterval,
+		pongWait:          wsPongWait,
+		reconnectAttempts: wsReconnectAttempts,
+		reconnectBackoff:  wsReconnectBackoff,
+		nextID:            1,
+		pending:           map[int]chan rpcResponse{},
+		done:              make(chan struct{}),
+	}
+	socket, err := conn.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn.socket = socket
+	go conn.run(socket)
+	return conn, nil
+}
+
+// dial opens the socket. With a token source the stored bearer token is
+// sent, and a 401 handshake is retried once with a refreshed token.
+func (c *wsConn) dial(ctx context.Context) (*websocket.Conn, error) {
+	headers := c.headers
+	if c.auth != nil {
+		token, err := c.auth.token(ctx)
+		if err != nil {
+			return nil, unauthorizedError(c.endpoint, err)
+		}
+		headers = withBearer(c.headers, token)
+	}
+	socket, res, err := c.dialer.DialContext(ctx, c.endpoint, headers)
+	if err != nil && res != nil && res.StatusCode == http.StatusUnauthorized && c.auth != nil {
+		token, refreshErr := c.auth.refresh(ctx)
+		if refreshErr != nil || token == "" {
+			return nil, unauthorizedError(c.endpoint, refreshErr)
+		}
+		socket, res, err = c.dialer.DialContext(ctx, c.endpoint, withBearer(c.headers, token))
+	}
+	if err != nil {
+		if res != nil && res.StatusCode == http.StatusUnauthorized && c.auth != nil {
+			return nil, unauthorizedError(c.endpoint, nil)
+		}
+		if res != nil {
+			return nil, &ConnectError{
+				Code: fmt.Sprintf("http_%d", res.StatusCode),
+				Err:  fmt.Errorf("ws handshake failed status %d: %w", res.StatusCode, err),
+			}
+		}
+		return nil, &ConnectError{Code: ConnectErrorHandshake, Err: fmt.Errorf("ws handshake failed: %w", err)}
+	}
+	socket.SetReadLimit(maxFrameBytes)
+	return socket, nil
+}
+
+func (c *wsConn) Call(ctx context.Context, method string, params map[string]any) (any, error) {
+	c.mu.Lock()
+	if c.err != nil {
+		err := c.err
+		c.mu.Unlock()
+		return nil, err
+	}
+	if method == "initialize" {
+		c.initParams = params
+	}
+	id := c.nextID
+	c.nextID++
+	ch := make(chan rpcResponse, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	if err := c.write(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  method,
+		"params":  params,
+	}); err != nil {
+		c.forget(id)
+		return nil, err
+	}
+	select {
+	case response := <-ch:
+		return response.result, response.err
+	case <-ctx.Done():
+		c.forget(id)
+		_ = c.Notify(context.Background(), "notifications/cancelled", map[string]any{
+			"requestId": id,
+			"reason":    ctx.Err().Error(),
+		})
+		return nil, ctx.Err()
+	}
+}
+
+func (c *wsConn) Notify(_ context.Context, method string, params map[string]any) error {
+	return c.write(map[string]any{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	})
+}
+
+func (c *wsConn) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *wsConn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Close sends a close frame and waits for the read loop to stop.
+func (c *wsConn) Close() error {
+	c.mu.Lock()
+	if c.closing {
+		c.mu.Unlock()
+		<-c.done
+		return nil
+	}
+	c.closing = true
+	socket := c.socket
+	c.mu.Unlock()
+	if socket != nil {
+		c.writeMu.Lock()
+		_ = socket.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(wsWriteWait))
+		c.writeMu.Unlock()
+		_ = socket.Close()
+	}
+	<-c.done
+	return nil
+}
+
+func (c *wsConn) write(payload map[string]any) error {
+	c.mu.Lock()
+	socket, err := c.socket, c.err
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if socket == nil {
+		return errWSReconnecting
+	}
+	return c.writeTo(socket, payload)
+}
+
+func (c *wsConn) writeTo(socket *websocket.Conn, payload map[string]any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_ = socket.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	return socket.WriteMessage(websocket.TextMessage, body)
+}
+
+func (c *wsConn) forget(id int) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+// run serves socket until it drops, then redials until the connection is
+// closed or reconnectAttempts is exhausted.
+func (c *wsConn) run(socket *websocket.Conn) {
+	for {
+		readErr := c.serve(socket)
+		_ = socket.Close()
+
+		c.mu.Lock()
+		closing := c.closing
+		c.socket = nil
+		pending := c.pending
+		c.pending = map[int]chan rpcResponse{}
+		c.mu.Unlock()
+		if closing {
+			c.finish(errConnClosed, pending)
+			return
+		}
+		dropped := fmt.Errorf("%w: websocket dropped: %v", errConnClosed, readErr)
+		for _, ch := range pending {
+			ch <- rpcResponse{err: dropped}
+		}
+
+		next, err := c.reconnect()
+		if err != nil {
+			c.finish(fmt.Errorf("%w: websocket reconnect failed: %v", errConnClosed, err), nil)
+			return
+		}
+		c.mu.Lock()
+		if c.closing {
+			c.mu.Unlock()
+			_ = next.Close()
+			c.finish(errConnClosed, nil)
+			return
+		}
+		c.socket = next
+		c.mu.Unlock()
+		socket = next
+	}
+}
+
+// serve reads frames until the socket fails. A ping is sent every
+// pingInterval and any frame, including the pong, extends the read
+// deadline, so a peer that stops answering is detected within pongWait.
+func (c *wsConn) serve(socket *websocket.Conn) error {
+	_ = socket.SetReadDeadline(time.Now().Add(c.pongWait))
+	socket.SetPongHandler(func(string) error {
+		return socket.SetReadDeadline(time.Now().Add(c.pongWait))
+	})
+	stop := make(chan struct{})
+	defer close(stop)
+	go c.keepalive(socket, stop)
+	for {
+		kind, body, err := socket.ReadMessage()
+		if err != nil {
+			return err
+		}
+		_ = socket.SetReadDeadline(time.Now().Add(c.pongWait))
+		if kind == websocket.TextMessage || kind == websocket.BinaryMessage {
+			c.dispatch(socket, body)
+		}
+	}
+}
+
+func (c *wsConn) keepalive(socket *websocket.Conn, stop <-chan struct{}) {
+	ticker := time.NewTicker(c.pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			c.writeMu.Lock()
+			err := socket.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait))
+			c.writeMu.Unlock()
+			if err != nil {
+				// Unblock the read loop so the drop is handled there.
+				_ = socket.Close()
+				return
+			}
+		}
+	}
+}
+
+func (c *wsConn) dispatch(socket *websocket.Conn, body []byte) {
+	message, ok := decodeRPCMessage(body)
+	if !ok {
+		return
+	}
+	if message.isServerMessage() {
+		if message.ID != nil {
+			go func() {
+				ctx, cancel := doneContext(c.done)
+				defer cancel()
+				_ = c.writeTo(socket, serveRequest(ctx, c.handlers.request, message))
+			}()
+			return
+		}
+		message.deliver(c.handlers.notify)
+		return
+	}
+	id := parseID(message.ID)
+	c.mu.Lock()
+	ch := c.pending[id]
+	delete(c.pending, id)
+	c.mu.Unlock()
+	if ch == nil {
+		return
+	}
+	result, err := message.response()
+	ch <- rpcResponse{result: result, err: err}
+}
+
+// reconnect redials with doubling backoff and replays the initialize
+// handshake on the new socket before it is handed back to callers.
+func (c *wsConn) reconnect() (*websocket.Conn, error) {
+	c.mu.Lock()
+	initParams := c.initParams
+	c.mu.Unlock()
+	backoff := c.reconnectBackoff
+	var lastErr error
+	for attempt := 0; attempt < c.reconnectAttempts; attempt++ {
+		time.Sleep(backoff)
+		backoff *= 2
+		c.mu.Lock()
+		closing := c.closing
+		c.mu.Unlock()
+		if closing {
+			return nil, errConnClosed
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), c.pongWait)
+		socket, err := c.dial(ctx)
+		cancel()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if initParams != nil {
+			if err := c.reinitialize(socket, initParams); err != nil {
+				_ = socket.Close()
+				lastErr = err
+				continue
+			}
+		}
+		return socket, nil
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no reconnect attempts configured")
+	}
+	return nil, lastErr
+}
+
+// reinitialize runs initialize and notifications/initialized directly on a
+// fresh socket. The read loop is not running yet, so the response is read
+// here and anything else that arrives first is discarded.
+func (c *wsConn) reinitialize(socket *websocket.Conn, params map[string]any) error {
+	c.mu.Lock()
+	id := c.nextID
+	c.nextID++
+	c.mu.Unlock()
+	if err := c.writeTo(socket, map[string]any{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  "initialize",
+		"params":  params,
+	}); err != nil {
+		return err
+	}
+	_ = socket.SetReadDeadline(time.Now().Add(c.pongWait))
+	for {
+		_, body, err := socket.ReadMessage()
+		if err != nil {
+			return fmt.Errorf("reinitialize: %w", err)
+		}
+		message, ok := decodeRPCMessage(body)
+		if !ok || message.isServerMessage() || parseID(message.ID) != id {
+			continue
+		}
+		if _, err := message.response(); err != nil {
+			return fmt.Errorf("reinitialize: %w", err)
+		}
+		break
+	}
+	return c.writeTo(socket, map[string]any{
+		"jsonrpc": "2.0",
+		"method":  "notifications/initialized",
+		"params":  map[string]any{},
+	})
+}
+
+func (c *wsConn) finish(err error, pending map[int]chan rpcResponse) {
+	c.mu.Lock()
+	if c.err == nil {
+		c.err = err
+	}
+	for id, ch := range c.pending {
+		if pending == nil {
+			pending = map[int]chan rpcResponse{}
+		}
+		pending[id] = ch
+	}
+	c.pending = map[int]chan rpcResponse{}
+	c.mu.Unlock()
+	for _, ch := range pending {
+		ch <- rpcResponse{err: err}
+	}
+	close(c.done)
+}
+
+// withBearer copies headers with an Authorization header for token; an
+// empty token leaves them unchanged.
+func withBearer(headers http.Header, token string) http.Header {
+	if token == "" {
+		return headers
+	}
+	out := headers.Clone()
+	out.Set("Authorization", "Bearer "+token)
+	return out
+}
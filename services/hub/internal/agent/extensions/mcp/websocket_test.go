@@ -0,0 +1,133 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestClientManagerCallWebSocketReconnectsAfterDrop(t *testing.T) {
+	restore := shortenWSTimings()
+	defer restore()
+
+	var initializes, pings atomic.Int32
+	var dropped atomic.Bool
+	upgrader := websocket.Upgrader{Subprotocols: []string{"mcp"}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		socket, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer socket.Close()
+		socket.SetPingHandler(func(data string) error {
+			pings.Add(1)
+			return socket.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		})
+		for {
+			payload := map[string]any{}
+			if err := socket.ReadJSON(&payload); err != nil {
+				return
+			}
+			id := payload["id"]
+			switch asStringAny(payload["method"]) {
+			case "initialize":
+				initializes.Add(1)
+				_ = socket.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": id, "result": map[string]any{"protocolVersion": latestProtocolVersion}})
+			case "tools/list":
+				_ = socket.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": id, "result": map[string]any{"tools": []map[string]any{{"name": "echo"}}}})
+			case "tools/call":
+				if dropped.CompareAndSwap(false, true) {
+					// Drop the socket without answering to simulate a network failure.
+					return
+				}
+				_ = socket.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": id, "result": map[string]any{
+					"content": []map[string]any{{"type": "text", "text": "pong"}},
+				}})
+			}
+		}
+	}))
+	defer server.Close()
+
+	pool := NewSessionPool(PoolOptions{})
+	defer pool.Close()
+	config := ServerConfig{
+		Name:      "remote",
+		Transport: "ws",
+		Endpoint:  "ws" + strings.TrimPrefix(server.URL, "http"),
+		Env:       map[string]string{"Authorization": "Bearer secret"},
+	}
+	manager := NewSessionClientManager(pool, "sess_ws", []ServerConfig{config}, 2*time.Second)
+
+	if _, err := manager.Call(context.Background(), "mcp__remote__echo", map[string]any{}); err == nil {
+		t.Fatalf("expected call in flight at drop to fail")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for initializes.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected initialize to be replayed after reconnect, got %d", initializes.Load())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	result, err := manager.Call(context.Background(), "mcp__remote__echo", map[string]any{})
+	if err != nil {
+		t.Fatalf("call after reconnect: %v", err)
+	}
+	if asStringAny(result["output"]) != "pong" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	status := pool.Sessions("remote")[0]
+	if status.Status != SessionConnected || status.RestartCount != 0 || status.ProtocolVersion != latestProtocolVersion {
+		t.Fatalf("expected reconnect to keep the pooled session, got %+v", status)
+	}
+	for pings.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected keepalive pings")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSessionPoolRejectsWebSocketWithoutAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	pool := NewSessionPool(PoolOptions{})
+	defer pool.Close()
+	_, err := pool.Connect(context.Background(), "sess_ws", ServerConfig{
+		Name:      "remote",
+		Transport: "ws",
+		Endpoint:  "ws" + strings.TrimPrefix(server.URL, "http"),
+	}, time.Second)
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected ws handshake to fail with 401, got %v", err)
+	}
+	var connectErr *ConnectError
+	if !errors.As(err, &connectErr) || connectErr.Code != "http_401" {
+		t.Fatalf("expected http_401 connect error, got %v", err)
+	}
+}
+
+func shortenWSTimings() func() {
+	ping, pong, backoff := wsPingInterval, wsPongWait, wsReconnectBackoff
+	wsPingInterval, wsPongWait, wsReconnectBackoff = 20*time.Millisecond, time.Second, 10*time.Millisecond
+	return func() {
+		wsPingInterval, wsPongWait, wsReconnectBackoff = ping, pong, backoff
+	}
+}
@@ -11,7 +11,6 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -20,6 +19,7 @@ import (
 
 	"goyais/services/hub/internal/agent/core"
 	pluginsext "goyais/services/hub/internal/agent/extensions/plugins"
+	"goyais/services/hub/internal/agent/tools/ossandbox"
 )
 
 const defaultSkillBudgetChars = 16000
@@ -736,11 +736,21 @@ func (shellCommandRunner) Run(ctx context.Context, command string, workingDir st
 	if trimmed == "" {
 		return "", fmt.Errorf("!cmd command is empty")
 	}
-	process := exec.CommandContext(ctx, "sh", "-lc", trimmed)
-	if strings.TrimSpace(workingDir) != "" {
-		process.Dir = workingDir
+	// Skill and plugin commands honor the project's OS sandbox settings the
+	// same way Bash does.
+	sandboxConfig, err := ossandbox.LoadProjectConfig(workingDir, "")
+	if err != nil {
+		return "", fmt.Errorf("load sandbox settings for !cmd %q: %w", trimmed, err)
+	}
+	process, _, cleanup, err := ossandbox.Command(ctx, sandboxConfig, ossandbox.CommandRequest{
+		Argv: []string{"sh", "-lc", trimmed},
+		Dir:  workingDir,
+		Env:  mergeProcessEnv(env),
+	})
+	if err != nil {
+		return "", fmt.Errorf("run !cmd %q: %w", trimmed, err)
 	}
-	process.Env = mergeProcessEnv(env)
+	defer cleanup()
 	output, err := process.CombinedOutput()
 	if err != nil {
 		return "", fmt.Errorf("run !cmd %q: %w (%s)", trimmed, err, strings.TrimSpace(string(output)))
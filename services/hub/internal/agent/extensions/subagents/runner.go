@@ -495,13 +495,19 @@ func (r *Runner) createWorktreeDir(agentName string) (string, error) {
 	return dir, nil
 }
 
-func (r *Runner) writeTranscript(request ExecutionRequest, summary string) (string, error) {
-	projectName := normalizeAgentName(filepath.Base(strings.TrimSpace(r.workingDir)))
+// TranscriptRoot returns the directory holding the session transcripts of
+// the project at workingDir. Each session is one subdirectory of it.
+func TranscriptRoot(homeDir string, workingDir string) string {
+	projectName := normalizeAgentName(filepath.Base(strings.TrimSpace(workingDir)))
 	if projectName == "" {
 		projectName = "project"
 	}
+	return filepath.Join(strings.TrimSpace(homeDir), ".claude", "projects", projectName)
+}
+
+func (r *Runner) writeTranscript(request ExecutionRequest, summary string) (string, error) {
 	sessionID := "session-" + strconv.FormatInt(r.now().Unix(), 10)
-	subdir := filepath.Join(strings.TrimSpace(r.homeDir), ".claude", "projects", projectName, sessionID, "subagents")
+	subdir := filepath.Join(TranscriptRoot(r.homeDir, r.workingDir), sessionID, "subagents")
 	if err := os.MkdirAll(subdir, 0o755); err != nil {
 		return "", err
 	}
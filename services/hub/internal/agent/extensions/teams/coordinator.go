@@ -36,11 +36,11 @@ const (
 
 const (
 	// PlanStatusPending means the plan awaits lead review.
-	PlanStatusPending = "pending"
+	PlanStatusPending = core.PlanStatusPending
 	// PlanStatusApproved means the plan was accepted by lead.
-	PlanStatusApproved = "approved"
+	PlanStatusApproved = core.PlanStatusApproved
 	// PlanStatusRejected means the plan was rejected and needs revision.
-	PlanStatusRejected = "rejected"
+	PlanStatusRejected = core.PlanStatusRejected
 )
 
 var (
@@ -98,18 +98,9 @@ type PlanSubmission struct {
 	Content   string
 }
 
-// PlanRecord stores review lifecycle state for one plan submission.
-type PlanRecord struct {
-	ID         string
-	FromAgent  string
-	Title      string
-	Content    string
-	Status     string
-	Feedback   string
-	ReviewedBy string
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
-}
+// PlanRecord stores review lifecycle state for one plan submission. It is the
+// same record plan mode uses for ExitPlanMode approvals.
+type PlanRecord = core.PlanRecord
 
 // CoordinatorOptions configures TeamCoordinator behavior.
 type CoordinatorOptions struct {
@@ -300,10 +291,14 @@ func (c *Coordinator) SubmitPlan(ctx context.Context, submission PlanSubmission)
 		planID = "plan-" + strconv.FormatUint(c.sequence, 10)
 	}
 
+	title := strings.TrimSpace(submission.Title)
+	if title == "" {
+		title = core.PlanTitle(submission.Content)
+	}
 	record := PlanRecord{
 		ID:        planID,
 		FromAgent: strings.TrimSpace(submission.FromAgent),
-		Title:     strings.TrimSpace(submission.Title),
+		Title:     title,
 		Content:   strings.TrimSpace(submission.Content),
 		Status:    PlanStatusPending,
 		CreatedAt: now,
@@ -156,6 +156,20 @@ func TestCoordinatorPlanApprovalFlow(t *testing.T) {
 	}
 }
 
+func TestCoordinatorSubmitPlanDerivesTitleFromMarkdown(t *testing.T) {
+	coordinator := NewCoordinator(CoordinatorOptions{})
+	plan, err := coordinator.SubmitPlan(context.Background(), PlanSubmission{
+		FromAgent: "dev-3",
+		Content:   "# Split storage layer\n\n1. Extract interfaces",
+	})
+	if err != nil {
+		t.Fatalf("submit plan: %v", err)
+	}
+	if plan.Title != "Split storage layer" {
+		t.Fatalf("expected title from plan heading, got %#v", plan)
+	}
+}
+
 func TestCoordinatorGateHooks_BlockTaskCompletedAndTeammateIdle(t *testing.T) {
 	coordinator := NewCoordinator(CoordinatorOptions{
 		Gate: GateEvaluatorFunc(func(_ context.Context, eventName string, _ map[string]any) (GateDecision, error) {
@@ -0,0 +1,243 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package approval
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"goyais/services/hub/internal/agent/context/settings"
+	"goyais/services/hub/internal/agent/core"
+)
+
+// RememberedRule is one "always allow" approval. User and project rules are
+// settings permission entries stored at Path; session rules live in memory
+// and have no path.
+type RememberedRule struct {
+	Scope     core.ApprovalScope `json:"scope"`
+	Rule      string             `json:"rule"`
+	SessionID string             `json:"session_id,omitempty"`
+	Path      string             `json:"path"`
+}
+
+// SessionRules holds session-scoped approvals in memory for one engine.
+// Session IDs are only unique within the engine that issued them, so these
+// rules are never written to the settings files other processes share.
+type SessionRules struct {
+	mu    sync.Mutex
+	rules map[string][]string
+}
+
+// NewSessionRules returns an empty in-memory session rule set.
+func NewSessionRules() *SessionRules {
+	return &SessionRules{rules: map[string][]string{}}
+}
+
+// Forget drops every rule remembered for sessionID.
+func (s *SessionRules) Forget(sessionID string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.rules, strings.TrimSpace(sessionID))
+}
+
+func (s *SessionRules) add(sessionID string, rule string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.rules[sessionID] {
+		if existing == rule {
+			return
+		}
+	}
+	s.rules[sessionID] = append(s.rules[sessionID], rule)
+}
+
+func (s *SessionRules) remove(sessionID string, rule string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := s.rules[sessionID]
+	for index, existing := range items {
+		if existing != rule {
+			continue
+		}
+		items = append(items[:index:index], items[index+1:]...)
+		if len(items) == 0 {
+			delete(s.rules, sessionID)
+		} else {
+			s.rules[sessionID] = items
+		}
+		return true
+	}
+	return false
+}
+
+func (s *SessionRules) list(sessionID string) []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.rules[sessionID]...)
+}
+
+func (s *SessionRules) sessionIDs() []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]string, 0, len(s.rules))
+	for id := range s.rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// RuleStore persists remembered approvals for the scope they were given:
+// user rules go to the user config and project rules to the shared project
+// settings. Session rules are kept in Sessions, in memory.
+type RuleStore struct {
+	WorkingDir string
+	HomeDir    string
+	Sessions   *SessionRules
+}
+
+// NewRuleStore constructs a store for one project; an empty homeDir resolves
+// to the current user's home directory.
+func NewRuleStore(workingDir string, homeDir string) RuleStore {
+	homeDir = strings.TrimSpace(homeDir)
+	if homeDir == "" {
+		if resolved, err := os.UserHomeDir(); err == nil {
+			homeDir = resolved
+		}
+	}
+	return RuleStore{WorkingDir: strings.TrimSpace(workingDir), HomeDir: homeDir}
+}
+
+// WithSessions returns a copy of the store that keeps session rules in
+// sessions.
+func (s RuleStore) WithSessions(sessions *SessionRules) RuleStore {
+	s.Sessions = sessions
+	return s
+}
+
+// Remember persists rules for scope and returns the stored entries.
+func (s RuleStore) Remember(scope core.ApprovalScope, sessionID string, rules []string) ([]RememberedRule, error) {
+	if scope == core.ApprovalScopeSession {
+		return s.rememberSession(sessionID, rules)
+	}
+	path, keyPath, err := s.location(scope)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]RememberedRule, 0, len(rules))
+	for _, rule := range rules {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		if _, err := settings.AppendStringList(path, rule, keyPath...); err != nil {
+			return out, err
+		}
+		out = append(out, RememberedRule{Scope: scope, Rule: rule, Path: path})
+	}
+	return out, nil
+}
+
+func (s RuleStore) rememberSession(sessionID string, rules []string) ([]RememberedRule, error) {
+	sessionID = strings.TrimSpace(sessionID)
+	if s.Sessions == nil || sessionID == "" {
+		return nil, fmt.Errorf("session approvals require a running session")
+	}
+	out := make([]RememberedRule, 0, len(rules))
+	for _, rule := range rules {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		s.Sessions.add(sessionID, rule)
+		out = append(out, RememberedRule{Scope: core.ApprovalScopeSession, Rule: rule, SessionID: sessionID})
+	}
+	return out, nil
+}
+
+// List returns user and project rules plus the session rules of sessionID,
+// or of every session when sessionID is empty.
+func (s RuleStore) List(sessionID string) ([]RememberedRule, error) {
+	out := make([]RememberedRule, 0, 8)
+	for _, scope := range []core.ApprovalScope{core.ApprovalScopeUser, core.ApprovalScopeProject} {
+		path, keyPath, err := s.location(scope)
+		if err != nil {
+			continue
+		}
+		items, err := settings.ReadStringList(path, keyPath...)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			out = append(out, RememberedRule{Scope: scope, Rule: item, Path: path})
+		}
+	}
+	sessionIDs := []string{strings.TrimSpace(sessionID)}
+	if sessionIDs[0] == "" {
+		sessionIDs = s.Sessions.sessionIDs()
+	}
+	for _, id := range sessionIDs {
+		for _, item := range s.Sessions.list(id) {
+			out = append(out, RememberedRule{Scope: core.ApprovalScopeSession, Rule: item, SessionID: id})
+		}
+	}
+	return out, nil
+}
+
+// Revoke removes one remembered rule and reports whether it existed.
+func (s RuleStore) Revoke(scope core.ApprovalScope, sessionID string, rule string) (bool, error) {
+	if scope == core.ApprovalScopeSession {
+		sessionID = strings.TrimSpace(sessionID)
+		if s.Sessions == nil || sessionID == "" {
+			return false, fmt.Errorf("session approvals require a running session")
+		}
+		return s.Sessions.remove(sessionID, strings.TrimSpace(rule)), nil
+	}
+	path, keyPath, err := s.location(scope)
+	if err != nil {
+		return false, err
+	}
+	return settings.RemoveStringList(path, rule, keyPath...)
+}
+
+// SessionRuleLines returns the session's remembered rules as DSL lines.
+// User and project rules reach the gate through the merged settings.
+func (s RuleStore) SessionRuleLines(sessionID string) []string {
+	items := s.Sessions.list(strings.TrimSpace(sessionID))
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, "allow "+item)
+	}
+	return lines
+}
+
+func (s RuleStore) location(scope core.ApprovalScope) (string, []string, error) {
+	switch scope {
+	case core.ApprovalScopeUser:
+		if s.HomeDir == "" {
+			return "", nil, fmt.Errorf("user approvals require a home directory")
+		}
+		return settings.UserSettingsPath(s.HomeDir), []string{"permissions", "allow"}, nil
+	case core.ApprovalScopeProject:
+		if s.WorkingDir == "" {
+			return "", nil, fmt.Errorf("project approvals require a working directory")
+		}
+		return settings.ProjectSettingsPath(s.WorkingDir), []string{"permissions", "allow"}, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported approval scope %q", scope)
+	}
+}
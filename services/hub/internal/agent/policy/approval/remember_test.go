@@ -0,0 +1,94 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package approval
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"goyais/services/hub/internal/agent/core"
+)
+
+func TestRuleStoreRemembersListsAndRevokesPerScope(t *testing.T) {
+	workingDir := t.TempDir()
+	homeDir := t.TempDir()
+	store := NewRuleStore(workingDir, homeDir).WithSessions(NewSessionRules())
+
+	for _, item := range []struct {
+		scope   core.ApprovalScope
+		session string
+		rule    string
+	}{
+		{scope: core.ApprovalScopeUser, rule: "WebFetch(domain:example.com)"},
+		{scope: core.ApprovalScopeProject, rule: "Bash(go test:*)"},
+		{scope: core.ApprovalScopeSession, session: "sess_a", rule: "Bash(git status:*)"},
+		{scope: core.ApprovalScopeSession, session: "sess_b", rule: "Read"},
+	} {
+		if _, err := store.Remember(item.scope, item.session, []string{item.rule}); err != nil {
+			t.Fatalf("remember %s: %v", item.scope, err)
+		}
+	}
+
+	rules, err := store.List("sess_a")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(rules) != 3 {
+		t.Fatalf("expected user, project and sess_a rules, got %#v", rules)
+	}
+	if rules[0].Path != filepath.Join(homeDir, ".goyais", "config.json") || rules[1].Path != filepath.Join(workingDir, ".goyais", "settings.json") {
+		t.Fatalf("unexpected rule locations %#v", rules)
+	}
+	if rules[2].SessionID != "sess_a" || rules[2].Path != "" {
+		t.Fatalf("expected the session rule to stay in memory, got %#v", rules[2])
+	}
+	if _, err := os.Stat(filepath.Join(workingDir, ".goyais", "settings.local.json")); !os.IsNotExist(err) {
+		t.Fatalf("expected session rules to stay out of the shared settings files, got %v", err)
+	}
+	if all, _ := store.List(""); len(all) != 4 {
+		t.Fatalf("expected every session when listing without a session id, got %#v", all)
+	}
+
+	lines := store.SessionRuleLines("sess_a")
+	if len(lines) != 1 || lines[0] != "allow Bash(git status:*)" {
+		t.Fatalf("session rule lines = %#v", lines)
+	}
+	removed, err := store.Revoke(core.ApprovalScopeSession, "sess_a", "Bash(git status:*)")
+	if err != nil || !removed {
+		t.Fatalf("revoke = %v, %v", removed, err)
+	}
+	if lines := store.SessionRuleLines("sess_a"); len(lines) != 0 {
+		t.Fatalf("expected revoked rule to be gone, got %#v", lines)
+	}
+	if _, err := store.Remember(core.ApprovalScopeSession, "", []string{"Read"}); err == nil {
+		t.Fatalf("expected session scope without session id to fail")
+	}
+	if _, err := NewRuleStore(workingDir, homeDir).Remember(core.ApprovalScopeSession, "sess_a", []string{"Read"}); err == nil {
+		t.Fatalf("expected session scope without an in-memory session store to fail")
+	}
+}
+
+func TestSessionRulesForgetKeepsOtherSessionsAndScopes(t *testing.T) {
+	sessions := NewSessionRules()
+	store := NewRuleStore(t.TempDir(), t.TempDir()).WithSessions(sessions)
+	for _, session := range []string{"sess_a", "sess_b"} {
+		if _, err := store.Remember(core.ApprovalScopeSession, session, []string{"Read"}); err != nil {
+			t.Fatalf("remember %s: %v", session, err)
+		}
+	}
+	if _, err := store.Remember(core.ApprovalScopeProject, "", []string{"Bash(go test:*)"}); err != nil {
+		t.Fatalf("remember project: %v", err)
+	}
+
+	sessions.Forget("sess_a")
+	if lines := store.SessionRuleLines("sess_a"); len(lines) != 0 {
+		t.Fatalf("expected sess_a rules to be gone, got %#v", lines)
+	}
+	rules, err := store.List("")
+	if err != nil || len(rules) != 2 || rules[0].Scope != core.ApprovalScopeProject || rules[1].SessionID != "sess_b" {
+		t.Fatalf("expected the project and sess_b rules to remain, got %#v, %v", rules, err)
+	}
+}
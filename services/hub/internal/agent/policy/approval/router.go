@@ -24,8 +24,11 @@ type UserAnswer struct {
 
 // ControlSignal is one external control event routed to one run.
 type ControlSignal struct {
-	Action core.ControlAction
-	Answer *UserAnswer
+	Action   core.ControlAction
+	Answer   *UserAnswer
+	Remember core.ApprovalScope
+	Mode     core.PermissionMode
+	Feedback string
 }
 
 // Router manages run-local control channels for approval and answer waits.
@@ -91,21 +94,31 @@ func (r *Router) Unregister(runID core.RunID) {
 
 // WaitForApproval blocks until one approval action for the run is available.
 func (r *Router) WaitForApproval(ctx context.Context, runID core.RunID) (core.ControlAction, error) {
+	signal, err := r.WaitForApprovalSignal(ctx, runID)
+	if err != nil {
+		return "", err
+	}
+	return signal.Action, nil
+}
+
+// WaitForApprovalSignal is WaitForApproval but returns the whole signal so
+// callers can honor the remember scope attached to an approve.
+func (r *Router) WaitForApprovalSignal(ctx context.Context, runID core.RunID) (ControlSignal, error) {
 	control, exists := r.lookup(runID)
 	if !exists || control == nil {
-		return "", fmt.Errorf("approval control channel is unavailable for run %q", strings.TrimSpace(string(runID)))
+		return ControlSignal{}, fmt.Errorf("approval control channel is unavailable for run %q", strings.TrimSpace(string(runID)))
 	}
 	for {
 		select {
 		case <-ctx.Done():
-			return "", ctx.Err()
+			return ControlSignal{}, ctx.Err()
 		case signal, ok := <-control:
 			if !ok {
-				return "", errors.New("approval control channel is closed")
+				return ControlSignal{}, errors.New("approval control channel is closed")
 			}
 			switch signal.Action {
 			case core.ControlActionApprove, core.ControlActionResume, core.ControlActionDeny, core.ControlActionStop:
-				return signal.Action, nil
+				return signal, nil
 			default:
 				continue
 			}
@@ -7,11 +7,14 @@ package policy
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 
 	"goyais/services/hub/internal/agent/core"
 	"goyais/services/hub/internal/agent/policy/rulesdsl"
+	"goyais/services/hub/internal/agent/policy/shellparse"
 )
 
 type toolRiskLevel string
@@ -26,6 +29,7 @@ const (
 // Gate evaluates permission requests using explicit rules first and falls back
 // to mode matrix decisions.
 type Gate struct {
+	mu    sync.RWMutex
 	rules []rulesdsl.Rule
 }
 
@@ -45,58 +49,123 @@ func NewGateFromLines(lines []string) (*Gate, error) {
 	return NewGateFromRules(rules), nil
 }
 
+// AddRules parses DSL lines and appends them to the gate so later calls in
+// the same run honor them, e.g. an approval the user asked to remember.
+func (g *Gate) AddRules(lines ...string) error {
+	rules, err := rulesdsl.ParseLines(lines)
+	if err != nil {
+		return err
+	}
+	g.mu.Lock()
+	g.rules = append(g.rules, rules...)
+	g.mu.Unlock()
+	return nil
+}
+
 // Evaluate returns one allow/ask/deny permission decision.
 func (g *Gate) Evaluate(_ context.Context, req core.PermissionRequest) (core.PermissionDecision, error) {
+	decision, _, err := g.evaluate(req)
+	return decision, err
+}
+
+// Explain evaluates req exactly like Evaluate and also returns the evidence:
+// the argument rules were matched against, every matched rule, the tool risk
+// and the mode matrix verdict, and which of them decided.
+func (g *Gate) Explain(_ context.Context, req core.PermissionRequest) (core.PermissionDecision, map[string]any, error) {
+	decision, trace, err := g.evaluate(req)
+	if err != nil {
+		return core.PermissionDecision{}, nil, err
+	}
+	return decision, trace, nil
+}
+
+func (g *Gate) evaluate(req core.PermissionRequest) (core.PermissionDecision, map[string]any, error) {
 	toolName := strings.TrimSpace(req.ToolName)
 	if toolName == "" {
-		return core.PermissionDecision{}, fmt.Errorf("tool_name is required")
+		return core.PermissionDecision{}, nil, fmt.Errorf("tool_name is required")
 	}
 
 	mode := req.Mode
 	if strings.TrimSpace(string(mode)) == "" {
 		mode = core.PermissionModeDefault
 	}
+	g.mu.RLock()
+	rules := g.rules
+	g.mu.RUnlock()
+	argument := ruleArgument(toolName, req.Arguments)
+	risk := classifyToolRisk(toolName, argument, req.WorkingDir)
+	matrixKind, matrixReason := evaluateModeMatrix(mode, risk)
+	trace := map[string]any{
+		"mode":     string(mode),
+		"argument": argument,
+		"risk":     string(risk),
+		"mode_matrix": map[string]any{
+			"decision": string(matrixKind),
+			"reason":   matrixReason,
+		},
+		"rule_count": len(rules),
+	}
+	if isShellToolName(toolName) {
+		trace["subcommands"] = shellSubcommands(argument)
+	}
 
-	if len(g.rules) > 0 {
-		effect, matched := rulesdsl.Evaluate(g.rules, rulesdsl.Request{
-			Tool:     toolName,
-			Argument: strings.TrimSpace(req.Arguments),
+	if len(rules) > 0 {
+		effect, matched := rulesdsl.Evaluate(rules, rulesdsl.Request{
+			Tool:       toolName,
+			Argument:   argument,
+			Mode:       string(mode),
+			WorkingDir: req.WorkingDir,
 		})
+		matchedTrace := make([]map[string]any, 0, len(matched))
+		for _, item := range matched {
+			matchedTrace = append(matchedTrace, map[string]any{
+				"rule":   strings.TrimSpace(item.Raw),
+				"effect": string(item.Effect),
+			})
+		}
+		trace["matched_rules"] = matchedTrace
+		trace["rule_effect"] = string(effect)
+
+		var decision core.PermissionDecision
 		switch effect {
 		case rulesdsl.EffectDeny:
-			return core.PermissionDecision{
+			decision = core.PermissionDecision{
 				Kind:        core.PermissionDecisionDeny,
 				Reason:      "denied by policy rule",
 				MatchedRule: firstMatchedRuleRaw(matched, rulesdsl.EffectDeny),
-			}, nil
+			}
 		case rulesdsl.EffectAsk:
 			if mode == core.PermissionModeDontAsk {
-				return core.PermissionDecision{
+				decision = core.PermissionDecision{
 					Kind:        core.PermissionDecisionDeny,
 					Reason:      "dont_ask mode rejects ask-rule operations",
 					MatchedRule: firstMatchedRuleRaw(matched, rulesdsl.EffectAsk),
-				}, nil
+				}
+				break
 			}
-			return core.PermissionDecision{
+			decision = core.PermissionDecision{
 				Kind:        core.PermissionDecisionAsk,
 				Reason:      "requires approval by policy rule",
 				MatchedRule: firstMatchedRuleRaw(matched, rulesdsl.EffectAsk),
-			}, nil
+			}
 		case rulesdsl.EffectAllow:
-			return core.PermissionDecision{
+			decision = core.PermissionDecision{
 				Kind:        core.PermissionDecisionAllow,
 				Reason:      "allowed by policy rule",
 				MatchedRule: firstMatchedRuleRaw(matched, rulesdsl.EffectAllow),
-			}, nil
+			}
+		}
+		if decision.Kind != "" {
+			trace["source"] = "rule"
+			return decision, trace, nil
 		}
 	}
 
-	risk := classifyToolRisk(toolName, req.Arguments)
-	decisionKind, reason := evaluateModeMatrix(mode, risk)
+	trace["source"] = "mode_matrix"
 	return core.PermissionDecision{
-		Kind:   decisionKind,
-		Reason: reason,
-	}, nil
+		Kind:   matrixKind,
+		Reason: matrixReason,
+	}, trace, nil
 }
 
 func evaluateModeMatrix(mode core.PermissionMode, risk toolRiskLevel) (core.PermissionDecisionKind, string) {
@@ -138,15 +207,14 @@ func evaluateModeMatrix(mode core.PermissionMode, risk toolRiskLevel) (core.Perm
 	}
 }
 
-func classifyToolRisk(toolName string, arguments string) toolRiskLevel {
+func classifyToolRisk(toolName string, arguments string, workingDir string) toolRiskLevel {
 	normalizedTool := strings.ToLower(strings.TrimSpace(toolName))
-	normalizedArgs := strings.ToLower(strings.TrimSpace(arguments))
 
 	if strings.Contains(normalizedTool, "delete") || strings.Contains(normalizedTool, "remove") || strings.Contains(normalizedTool, "rm") {
 		return riskCritical
 	}
-	if strings.Contains(normalizedTool, "bash") || strings.Contains(normalizedTool, "shell") || strings.Contains(normalizedTool, "command") {
-		if containsShellOperator(normalizedArgs) {
+	if isShellToolName(normalizedTool) {
+		if isCompoundShellCommand(arguments, workingDir) {
 			return riskCritical
 		}
 		return riskHigh
@@ -172,14 +240,77 @@ func firstMatchedRuleRaw(rules []rulesdsl.Rule, effect rulesdsl.Effect) string {
 	return ""
 }
 
-func containsShellOperator(argument string) bool {
-	operators := []string{"&&", "||", ";", "|", ">", "<", "$("}
-	for _, op := range operators {
-		if strings.Contains(argument, op) {
-			return true
+// isCompoundShellCommand reports whether a shell command is more than one
+// plain simple command, cannot be parsed, or redirects writes outside the
+// workspace.
+func isCompoundShellCommand(command string, workingDir string) bool {
+	script, err := shellparse.Parse(command)
+	if err != nil {
+		return true
+	}
+	if len(shellparse.OutsideWrites(script, workingDir)) > 0 {
+		return true
+	}
+	return script.Compound()
+}
+
+// ruleArgument extracts the argument rules match against from a JSON tool
+// input: the command for shell tools, the URL for fetch tools and the file
+// path for file tools. Other arguments are returned unchanged.
+func ruleArgument(toolName string, arguments string) string {
+	trimmed := strings.TrimSpace(arguments)
+	keys := ruleArgumentKeys(toolName)
+	if len(keys) == 0 || !strings.HasPrefix(trimmed, "{") {
+		return trimmed
+	}
+	input := map[string]any{}
+	if err := json.Unmarshal([]byte(trimmed), &input); err != nil {
+		return trimmed
+	}
+	for _, key := range keys {
+		if value, ok := input[key].(string); ok && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
 		}
 	}
-	return false
+	return trimmed
+}
+
+func ruleArgumentKeys(toolName string) []string {
+	normalized := strings.ToLower(strings.TrimSpace(toolName))
+	switch {
+	case strings.HasPrefix(normalized, "mcp__"):
+		return nil
+	case isShellToolName(normalized):
+		return []string{"command", "cmd"}
+	case normalized == "webfetch":
+		return []string{"url"}
+	case normalized == "read", normalized == "write", normalized == "edit", normalized == "multiedit",
+		normalized == "notebookedit", normalized == "list":
+		return []string{"file_path", "path", "notebook_path"}
+	default:
+		return nil
+	}
+}
+
+// shellSubcommands lists the simple commands shell rules are evaluated
+// against, or nil when the command cannot be parsed.
+func shellSubcommands(command string) []string {
+	script, err := shellparse.Parse(command)
+	if err != nil {
+		return nil
+	}
+	parts := make([]string, 0, len(script.Commands))
+	for _, item := range script.Commands {
+		if text := item.Text(); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return parts
+}
+
+func isShellToolName(toolName string) bool {
+	normalized := strings.ToLower(strings.TrimSpace(toolName))
+	return strings.Contains(normalized, "bash") || strings.Contains(normalized, "shell") || strings.Contains(normalized, "command")
 }
 
 var _ core.PermissionGate = (*Gate)(nil)
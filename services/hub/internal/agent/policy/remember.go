@@ -0,0 +1,118 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package policy
+
+import (
+	"net/url"
+	"strings"
+
+	"goyais/services/hub/internal/agent/policy/shellparse"
+)
+
+// prefixSubcommands lists the programs whose approval may be widened to a
+// "program subcommand:*" prefix, and the subcommands for which that is safe.
+// Everything else, including shells, interpreters and rm, is remembered as
+// the exact command.
+var prefixSubcommands = map[string][]string{
+	"cargo":   {"build", "check", "clippy", "fmt", "test"},
+	"git":     {"blame", "branch", "diff", "fetch", "log", "show", "status"},
+	"go":      {"build", "fmt", "list", "test", "vet"},
+	"npm":     {"ci", "install", "ls", "outdated", "test"},
+	"pnpm":    {"install", "list", "outdated", "test"},
+	"yarn":    {"install", "list", "outdated", "test"},
+	"kubectl": {"describe", "get"},
+}
+
+// RememberPatterns derives the settings permission entries, such as
+// "Bash(git status:*)", that an "always allow" approval of one tool call
+// persists. Shell commands are generalized to a prefix only for the
+// subcommands in prefixSubcommands and are otherwise kept exact; commands
+// with glob characters are not remembered. Fetch calls are generalized to
+// their domain and file tools to the exact path; other tools are allowed by
+// name.
+func RememberPatterns(toolName string, input map[string]any) []string {
+	toolName = strings.TrimSpace(toolName)
+	if toolName == "" {
+		return nil
+	}
+	argument := ""
+	for _, key := range ruleArgumentKeys(toolName) {
+		if value, ok := input[key].(string); ok && strings.TrimSpace(value) != "" {
+			argument = strings.TrimSpace(value)
+			break
+		}
+	}
+	if argument == "" {
+		return []string{toolName}
+	}
+
+	normalized := strings.ToLower(toolName)
+	switch {
+	case isShellToolName(normalized):
+		return rememberShellPatterns(toolName, argument)
+	case normalized == "webfetch":
+		if parsed, err := url.Parse(argument); err == nil && parsed.Hostname() != "" {
+			return []string{toolName + "(domain:" + strings.ToLower(parsed.Hostname()) + ")"}
+		}
+		return []string{toolName}
+	default:
+		return []string{toolName + "(" + argument + ")"}
+	}
+}
+
+func rememberShellPatterns(toolName string, command string) []string {
+	script, err := shellparse.Parse(command)
+	if err != nil || len(script.Commands) == 0 {
+		return exactShellPattern(toolName, command)
+	}
+	patterns := make([]string, 0, len(script.Commands))
+	seen := map[string]struct{}{}
+	for _, item := range script.Commands {
+		if item.Dynamic {
+			return exactShellPattern(toolName, command)
+		}
+		if len(item.Words) == 0 {
+			continue
+		}
+		var pattern string
+		if prefix, ok := shellPrefix(item); ok {
+			pattern = toolName + "(" + prefix + ":*)"
+		} else if text := item.Text(); !strings.ContainsAny(text, "*?[") {
+			pattern = toolName + "(" + text + ")"
+		} else {
+			// An exact pattern with glob characters would match other
+			// commands too.
+			continue
+		}
+		if _, exists := seen[pattern]; exists {
+			continue
+		}
+		seen[pattern] = struct{}{}
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+// shellPrefix returns "program subcommand" when command starts with a
+// vetted subcommand and has no variable assignments in front of it.
+func shellPrefix(command shellparse.Command) (string, bool) {
+	words := command.Words
+	if len(words) < 2 || len(command.Assignments) > 0 {
+		return "", false
+	}
+	for _, subcommand := range prefixSubcommands[words[0]] {
+		if words[1] == subcommand {
+			return words[0] + " " + subcommand, true
+		}
+	}
+	return "", false
+}
+
+func exactShellPattern(toolName string, command string) []string {
+	if strings.ContainsAny(command, "*?[") {
+		return nil
+	}
+	return []string{toolName + "(" + command + ")"}
+}
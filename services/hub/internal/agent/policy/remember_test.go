@@ -0,0 +1,43 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package policy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRememberPatternsGeneralizeApprovedCalls(t *testing.T) {
+	cases := []struct {
+		name  string
+		tool  string
+		input map[string]any
+		want  []string
+	}{
+		{name: "shell subcommand", tool: "Bash", input: map[string]any{"command": "git status --short"}, want: []string{"Bash(git status:*)"}},
+		{name: "shell flag argument", tool: "Bash", input: map[string]any{"command": "ls -la"}, want: []string{"Bash(ls -la)"}},
+		{name: "shell rm stays exact", tool: "Bash", input: map[string]any{"command": "rm -rf build"}, want: []string{"Bash(rm -rf build)"}},
+		{name: "shell interpreter stays exact", tool: "Bash", input: map[string]any{"command": "bash -c 'make all'"}, want: []string{"Bash(bash -c make all)"}},
+		{name: "python stays exact", tool: "Bash", input: map[string]any{"command": "python scripts/gen.py"}, want: []string{"Bash(python scripts/gen.py)"}},
+		{name: "curl stays exact", tool: "Bash", input: map[string]any{"command": "curl https://example.com"}, want: []string{"Bash(curl https://example.com)"}},
+		{name: "sh stays exact", tool: "Bash", input: map[string]any{"command": "sh install.sh"}, want: []string{"Bash(sh install.sh)"}},
+		{name: "flag before subcommand", tool: "Bash", input: map[string]any{"command": "git -C /tmp status"}, want: []string{"Bash(git -C /tmp status)"}},
+		{name: "unvetted subcommand", tool: "Bash", input: map[string]any{"command": "git push origin main"}, want: []string{"Bash(git push origin main)"}},
+		{name: "assignment prefix", tool: "Bash", input: map[string]any{"command": "GOFLAGS=-x go test ./..."}, want: []string{"Bash(GOFLAGS=-x go test ./...)"}},
+		{name: "glob not remembered", tool: "Bash", input: map[string]any{"command": "rm *.o && go vet ./..."}, want: []string{"Bash(go vet:*)"}},
+		{name: "shell compound", tool: "Bash", input: map[string]any{"command": "go test ./... && go vet ./..."}, want: []string{"Bash(go test:*)", "Bash(go vet:*)"}},
+		{name: "shell dynamic", tool: "Bash", input: map[string]any{"command": "$CMD run"}, want: []string{"Bash($CMD run)"}},
+		{name: "fetch domain", tool: "WebFetch", input: map[string]any{"url": "https://Docs.Example.com/a"}, want: []string{"WebFetch(domain:docs.example.com)"}},
+		{name: "file path", tool: "Edit", input: map[string]any{"file_path": "src/main.go"}, want: []string{"Edit(src/main.go)"}},
+		{name: "mcp tool", tool: "mcp__github__create_issue", input: map[string]any{"title": "x"}, want: []string{"mcp__github__create_issue"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := RememberPatterns(tc.tool, tc.input); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("RememberPatterns() = %#v, want %#v", got, tc.want)
+			}
+		})
+	}
+}
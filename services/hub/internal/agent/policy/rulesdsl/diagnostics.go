@@ -0,0 +1,196 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package rulesdsl
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Severity classifies one diagnostic.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic reports one problem found while parsing a rule line. Line and
+// Column are 1-based.
+type Diagnostic struct {
+	Line       int      `json:"line"`
+	Column     int      `json:"column"`
+	Severity   Severity `json:"severity"`
+	Message    string   `json:"message"`
+	Suggestion string   `json:"suggestion,omitempty"`
+	Raw        string   `json:"raw,omitempty"`
+}
+
+// Detail returns the message followed by the suggestion, when present.
+func (d Diagnostic) Detail() string {
+	if strings.TrimSpace(d.Suggestion) == "" {
+		return d.Message
+	}
+	return d.Message + " (" + d.Suggestion + ")"
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s", d.Line, d.Column, d.Severity, d.Detail())
+}
+
+// Lint parses every rule line and collects diagnostics instead of stopping at
+// the first problem. Rules with errors are left out of the result.
+func Lint(lines []string) ([]Rule, []Diagnostic) {
+	rules := make([]Rule, 0, len(lines))
+	diagnostics := make([]Diagnostic, 0)
+	for idx, item := range lines {
+		trimmed := strings.TrimSpace(item)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		rule, lineDiagnostics := parseRule(item)
+		failed := false
+		for _, diagnostic := range lineDiagnostics {
+			diagnostic.Line = idx + 1
+			diagnostic.Raw = trimmed
+			diagnostics = append(diagnostics, diagnostic)
+			failed = failed || diagnostic.Severity == SeverityError
+		}
+		if !failed {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, diagnostics
+}
+
+// knownTools feeds typo suggestions; unknown names are still accepted because
+// MCP servers and plugins add their own tools.
+var knownTools = []string{
+	"Read", "Write", "Edit", "MultiEdit", "Bash", "List", "Glob", "Grep",
+	"ToolSearch", "NotebookEdit", "WebFetch", "WebSearch", "Task",
+}
+
+func lintTool(rule Rule, column int) []Diagnostic {
+	tool := strings.TrimSpace(rule.Tool)
+	if strings.ContainsAny(tool, " \t") {
+		return []Diagnostic{newWarning(column, fmt.Sprintf("tool name %q contains spaces and will never match", tool), "tool names are single words such as `Read` or `mcp__server__tool`")}
+	}
+	if strings.ContainsAny(tool, "*?[") {
+		if _, err := path.Match(strings.ToLower(tool), ""); err != nil {
+			return []Diagnostic{newError(column, fmt.Sprintf("invalid tool glob %q", tool), "check the brackets in the tool name")}
+		}
+		return nil
+	}
+	if strings.HasPrefix(strings.ToLower(tool), "mcp__") {
+		return nil
+	}
+	for _, item := range knownTools {
+		if strings.EqualFold(item, tool) {
+			return nil
+		}
+	}
+	if suggestion := closest(tool, knownTools); suggestion != "" {
+		return []Diagnostic{newWarning(column, fmt.Sprintf("unknown tool %q", tool), fmt.Sprintf("did you mean %q?", suggestion))}
+	}
+	return nil
+}
+
+func lintPattern(rule Rule, column int) []Diagnostic {
+	pattern := strings.TrimSpace(rule.Pattern)
+	if pattern == "" {
+		return nil
+	}
+	if isDomainPattern(pattern) {
+		domain := strings.TrimSpace(pattern[len("domain:"):])
+		if domain == "" {
+			return []Diagnostic{newError(column, "domain rule has no domain", "write domain rules as `WebFetch(domain:*.example.com)`")}
+		}
+		if strings.Contains(domain, "/") || strings.Contains(domain, "://") {
+			return []Diagnostic{newError(column, fmt.Sprintf("domain %q must be a host name", domain), "drop the scheme and path, for example `domain:example.com`")}
+		}
+		if strings.Contains(domain, "*") && domain != "*" && !(strings.HasPrefix(domain, "*.") && !strings.Contains(domain[2:], "*")) {
+			return []Diagnostic{newError(column, fmt.Sprintf("unsupported domain wildcard %q", domain), "only a leading `*.` wildcard is supported")}
+		}
+		if !strings.EqualFold(rule.Tool, "WebFetch") {
+			return []Diagnostic{newWarning(column, fmt.Sprintf("domain patterns only apply to URL tools, not %q", rule.Tool), "use `WebFetch(domain:...)`")}
+		}
+		return nil
+	}
+	if isShellTool(rule.Tool) {
+		return nil
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return []Diagnostic{newError(column, fmt.Sprintf("invalid glob pattern %q", pattern), "check for unbalanced '[' in the pattern")}
+	}
+	return nil
+}
+
+func newError(column int, message string, suggestion string) Diagnostic {
+	return Diagnostic{Column: column, Severity: SeverityError, Message: message, Suggestion: suggestion}
+}
+
+func newWarning(column int, message string, suggestion string) Diagnostic {
+	return Diagnostic{Column: column, Severity: SeverityWarning, Message: message, Suggestion: suggestion}
+}
+
+func suggestEffect(raw string) string {
+	return suggestFrom(raw, []string{string(EffectAllow), string(EffectAsk), string(EffectDeny)}, "rules start with allow, ask or deny")
+}
+
+// suggestFrom returns a "did you mean" hint for near misses and fallback
+// otherwise.
+func suggestFrom(raw string, candidates []string, fallback string) string {
+	if suggestion := closest(raw, candidates); suggestion != "" {
+		return fmt.Sprintf("did you mean %q?", suggestion)
+	}
+	return fallback
+}
+
+func closest(raw string, candidates []string) string {
+	needle := strings.ToLower(strings.TrimSpace(raw))
+	if needle == "" {
+		return ""
+	}
+	best := ""
+	bestDistance := 3
+	if len(needle) <= 4 {
+		bestDistance = 2
+	}
+	for _, item := range candidates {
+		distance := editDistance(needle, strings.ToLower(item))
+		if distance < bestDistance {
+			best = item
+			bestDistance = distance
+		}
+	}
+	return best
+}
+
+// editDistance is the optimal string alignment distance, so a swapped pair
+// of letters ("Raed") counts as one edit.
+func editDistance(a string, b string) int {
+	rows := make([][]int, len(a)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(b)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(a)][len(b)]
+}
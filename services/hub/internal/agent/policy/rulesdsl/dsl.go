@@ -6,11 +6,15 @@
 package rulesdsl
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"unicode/utf8"
+
+	"goyais/services/hub/internal/agent/policy/shellparse"
 )
 
 // Effect is one rule decision kind.
@@ -24,95 +28,250 @@ const (
 
 // Rule is one parsed DSL statement.
 type Rule struct {
-	Effect  Effect
-	Tool    string
-	Pattern string
-	Raw     string
+	Effect     Effect
+	Tool       string
+	Pattern    string
+	Conditions []Condition
+	Raw        string
+}
+
+// Condition restricts a rule to requests whose context matches, for example
+// "when mode=acceptEdits".
+type Condition struct {
+	Key    string
+	Negate bool
+	Values []string
 }
 
-// Request is one permission-evaluation input.
+// Request is one permission-evaluation input. Mode feeds "when mode=..."
+// conditions and WorkingDir anchors project-relative path rules; both are
+// optional.
 type Request struct {
-	Tool     string
-	Argument string
+	Tool       string
+	Argument   string
+	Mode       string
+	WorkingDir string
 }
 
-// ParseLines parses non-empty, non-comment DSL lines.
+// ParseLines parses non-empty, non-comment DSL lines. Warnings are ignored;
+// the first error-level diagnostic fails the whole set.
 func ParseLines(lines []string) ([]Rule, error) {
-	rules := make([]Rule, 0, len(lines))
-	for idx, item := range lines {
-		trimmed := strings.TrimSpace(item)
-		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
-			continue
+	rules, diagnostics := Lint(lines)
+	for _, item := range diagnostics {
+		if item.Severity == SeverityError {
+			return nil, fmt.Errorf("parse rule line %d failed: %s", item.Line, item.Detail())
 		}
-		rule, err := ParseRule(trimmed)
-		if err != nil {
-			return nil, fmt.Errorf("parse rule line %d failed: %w", idx+1, err)
-		}
-		rules = append(rules, rule)
 	}
 	return rules, nil
 }
 
 // ParseRule parses one rule expression:
-// allow|ask|deny ToolName(pattern)
+//
+//	allow|ask|deny ToolName(pattern) [when key=value[|value] ...]
+//	allow|ask|deny ToolName [when ...]
+//
+// A bare tool name applies to every argument; tool names may use globs such
+// as mcp__server__*.
 func ParseRule(raw string) (Rule, error) {
+	rule, diagnostics := parseRule(raw)
+	for _, item := range diagnostics {
+		if item.Severity == SeverityError {
+			return Rule{}, errors.New(item.Detail())
+		}
+	}
+	return rule, nil
+}
+
+func parseRule(raw string) (Rule, []Diagnostic) {
 	trimmed := strings.TrimSpace(raw)
+	column := func(fragment string) int {
+		if index := strings.Index(raw, fragment); index >= 0 && fragment != "" {
+			return index + 1
+		}
+		return 1
+	}
 	parts := strings.Fields(trimmed)
 	if len(parts) < 2 {
-		return Rule{}, fmt.Errorf("invalid rule %q", raw)
+		suggestion := "write rules as `allow|ask|deny Tool(pattern)`"
+		if len(parts) == 1 {
+			if _, err := parseEffect(parts[0]); err != nil {
+				suggestion = fmt.Sprintf("prefix the rule with an effect, for example `ask %s`", parts[0])
+			}
+		}
+		return Rule{}, []Diagnostic{newError(column(trimmed), fmt.Sprintf("invalid rule %q", trimmed), suggestion)}
 	}
 	effect, err := parseEffect(parts[0])
 	if err != nil {
-		return Rule{}, err
+		return Rule{}, []Diagnostic{newError(column(parts[0]), err.Error(), suggestEffect(parts[0]))}
 	}
+
+	rule := Rule{Effect: effect, Raw: trimmed}
+	diagnostics := make([]Diagnostic, 0)
 	body := strings.TrimSpace(strings.TrimPrefix(trimmed, parts[0]))
+	tail := ""
 	open := strings.Index(body, "(")
-	close := strings.LastIndex(body, ")")
-	if open <= 0 || close <= open {
-		return Rule{}, fmt.Errorf("missing rule pattern in %q", raw)
-	}
-	toolName := strings.TrimSpace(body[:open])
-	if toolName == "" {
-		return Rule{}, fmt.Errorf("tool name is required in %q", raw)
-	}
-	pattern := strings.TrimSpace(body[open+1 : close])
-	pattern = strings.Trim(pattern, `"`)
-	pattern = strings.Trim(pattern, `'`)
-	if pattern == "" {
-		return Rule{}, fmt.Errorf("rule pattern is required in %q", raw)
-	}
-	return Rule{
-		Effect:  effect,
-		Tool:    toolName,
-		Pattern: pattern,
-		Raw:     trimmed,
-	}, nil
+	if open < 0 {
+		rule.Tool = strings.Fields(body)[0]
+		tail = strings.TrimPrefix(body, rule.Tool)
+		if strings.Contains(rule.Tool, ")") {
+			return Rule{}, []Diagnostic{newError(column(rule.Tool), fmt.Sprintf("missing '(' in rule %q", trimmed), "write patterns as `Tool(pattern)`")}
+		}
+	} else {
+		close := strings.LastIndex(body, ")")
+		if close <= open {
+			return Rule{}, []Diagnostic{newError(column(body[open:]), fmt.Sprintf("missing closing ')' in rule %q", trimmed), "add ')' after the pattern")}
+		}
+		rule.Tool = strings.TrimSpace(body[:open])
+		if rule.Tool == "" {
+			return Rule{}, []Diagnostic{newError(column(body), fmt.Sprintf("tool name is required in %q", trimmed), "put the tool name before '(', for example `Read(./src/**)`")}
+		}
+		pattern := strings.TrimSpace(body[open+1 : close])
+		pattern = strings.Trim(pattern, `"`)
+		pattern = strings.Trim(pattern, `'`)
+		if pattern == "" {
+			return Rule{}, []Diagnostic{newError(column(body[open:]), fmt.Sprintf("rule pattern is required in %q", trimmed), fmt.Sprintf("drop the parentheses to match every argument: `%s %s`", effect, rule.Tool))}
+		}
+		rule.Pattern = pattern
+		tail = body[close+1:]
+	}
+
+	diagnostics = append(diagnostics, lintTool(rule, column(rule.Tool))...)
+	diagnostics = append(diagnostics, lintPattern(rule, column(rule.Pattern))...)
+	conditions, tailDiagnostics := parseTail(tail, column(strings.TrimSpace(tail)))
+	rule.Conditions = conditions
+	diagnostics = append(diagnostics, tailDiagnostics...)
+	return rule, diagnostics
+}
+
+// parseTail parses the optional text after the rule pattern: a "when" clause
+// or a trailing comment. Anything else is ignored with a warning so rule
+// files written before conditions existed keep loading.
+func parseTail(tail string, column int) ([]Condition, []Diagnostic) {
+	trimmed := strings.TrimSpace(tail)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return nil, nil
+	}
+	keyword := strings.Fields(trimmed)[0]
+	if !strings.EqualFold(keyword, "when") {
+		return nil, []Diagnostic{newWarning(column, fmt.Sprintf("unexpected text %q after rule is ignored", trimmed), "use `when mode=<mode>` for conditions or start a comment with #")}
+	}
+	clause := strings.TrimSpace(trimmed[len(keyword):])
+	if comment := strings.Index(clause, "#"); comment >= 0 {
+		clause = strings.TrimSpace(clause[:comment])
+	}
+	clause = conditionOperatorSpacing.ReplaceAllString(clause, "$1")
+	tokens := strings.Fields(strings.ReplaceAll(clause, ",", " "))
+	conditions := make([]Condition, 0, len(tokens))
+	diagnostics := make([]Diagnostic, 0)
+	for _, token := range tokens {
+		if strings.EqualFold(token, "and") {
+			continue
+		}
+		condition, diagnostic, ok := parseCondition(token, column)
+		if !ok {
+			diagnostics = append(diagnostics, diagnostic)
+			continue
+		}
+		conditions = append(conditions, condition)
+	}
+	if len(conditions) == 0 && len(diagnostics) == 0 {
+		diagnostics = append(diagnostics, newError(column, "when clause has no conditions", "add a condition such as `when mode=acceptEdits`"))
+	}
+	return conditions, diagnostics
+}
+
+var conditionOperatorSpacing = regexp.MustCompile(`\s*(!=|=)\s*`)
+
+func parseCondition(token string, column int) (Condition, Diagnostic, bool) {
+	condition := Condition{}
+	key, value, found := strings.Cut(token, "!=")
+	if found {
+		condition.Negate = true
+	} else {
+		key, value, found = strings.Cut(token, "=")
+	}
+	if !found || strings.TrimSpace(key) == "" || strings.TrimSpace(value) == "" {
+		return Condition{}, newError(column, fmt.Sprintf("invalid condition %q", token), "write conditions as `key=value` or `key!=value`"), false
+	}
+	condition.Key = strings.ToLower(strings.TrimSpace(key))
+	if condition.Key != conditionKeyMode {
+		return Condition{}, newError(column, fmt.Sprintf("unknown condition %q", key), suggestFrom(key, []string{conditionKeyMode}, "supported conditions: mode")), false
+	}
+	for _, item := range strings.Split(value, "|") {
+		mode, ok := canonicalMode(item)
+		if !ok {
+			return Condition{}, newError(column, fmt.Sprintf("unknown permission mode %q", item), suggestFrom(item, knownModes, "supported modes: "+strings.Join(knownModes, ", "))), false
+		}
+		condition.Values = append(condition.Values, mode)
+	}
+	return condition, Diagnostic{}, true
 }
 
 // Match reports whether a rule matches the request.
 // Ref: docs/site/guide/overview.md §8.2
 func Match(rule Rule, req Request) bool {
-	if !strings.EqualFold(strings.TrimSpace(rule.Tool), strings.TrimSpace(req.Tool)) {
+	if !appliesTo(rule, req) {
 		return false
 	}
+	if rule.Pattern == "" {
+		return true
+	}
+	if isDomainPattern(rule.Pattern) {
+		return matchDomain(rule.Pattern, req.Argument)
+	}
 
 	// Handle path prefixes for non-Bash tools
-	ruleTool := strings.TrimSpace(rule.Tool)
-	if !strings.EqualFold(ruleTool, "Bash") {
+	if !isShellTool(req.Tool) {
 		return matchWithPathPrefix(rule, req)
 	}
+	return matchShellCommand(rule.Pattern, req.Argument)
+}
 
-	// Bash-specific matching with word boundary and shell operator semantics
-	pattern := rule.Pattern
-	argument := req.Argument
+// appliesTo reports whether the rule names the request tool and its
+// conditions hold, independent of the argument.
+func appliesTo(rule Rule, req Request) bool {
+	return matchToolName(rule.Tool, req.Tool) && conditionsHold(rule.Conditions, req)
+}
 
-	// Check shell operator containment
-	if !isShellPatternAllowed(pattern, argument) {
+// matchShellCommand reports whether every simple command in argument matches
+// pattern. Patterns that are themselves compound shell syntax keep matching
+// the whole command line.
+func matchShellCommand(pattern string, argument string) bool {
+	if isWholeCommandPattern(pattern) {
+		return matchBashPattern(pattern, strings.TrimSpace(argument))
+	}
+	script, err := shellparse.Parse(argument)
+	if err != nil {
 		return false
 	}
+	parts := 0
+	for _, command := range script.Commands {
+		if command.Text() == "" {
+			continue
+		}
+		parts++
+		if command.Dynamic || !matchBashPattern(pattern, command.Text()) {
+			return false
+		}
+	}
+	return parts > 0
+}
 
-	// Apply word boundary semantics for Bash
-	return matchBashWithWordBoundary(pattern, argument)
+// matchBashPattern matches one simple command. "go test:*" is a prefix rule
+// matching "go test" followed by any arguments.
+func matchBashPattern(pattern string, command string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, ":*"); ok {
+		prefix = strings.TrimSpace(prefix)
+		return command == prefix || strings.HasPrefix(command, prefix+" ")
+	}
+	return matchBashWithWordBoundary(pattern, command)
+}
+
+// isWholeCommandPattern reports whether a Bash rule pattern is itself a
+// compound command (for example "make && make install").
+func isWholeCommandPattern(pattern string) bool {
+	script, err := shellparse.Parse(pattern)
+	return err == nil && script.Compound()
 }
 
 // matchBashWithWordBoundary applies word boundary semantics for Bash patterns.
@@ -217,13 +376,29 @@ func matchWithPathPrefix(rule Rule, req Request) bool {
 	// The argument comes from the actual system and should be compared as-is
 	pattern = resolvePathPrefix(pattern)
 
+	// With a known project root both sides become absolute so "/src/**",
+	// "src/**" and "./src/**" all refer to the same tree.
+	if root := strings.TrimSpace(req.WorkingDir); root != "" {
+		pattern = anchorPath(pattern, root)
+		argument = anchorPath(argument, root)
+	}
+
 	// Normalize for matching
 	patternNorm := normalizePattern(pattern)
 	argumentNorm := normalizePattern(argument)
 
-	// Use glob matching
-	ok, err := filepath.Match(patternNorm, argumentNorm)
-	return err == nil && ok
+	return matchPathGlob(patternNorm, argumentNorm)
+}
+
+func anchorPath(value string, root string) string {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return trimmed
+	}
+	if filepath.IsAbs(trimmed) {
+		return filepath.Clean(trimmed)
+	}
+	return filepath.Join(root, trimmed)
 }
 
 // resolvePathPrefix converts path prefixes to their resolved form.
@@ -265,8 +440,14 @@ func resolvePathPrefix(path string) string {
 	return trimmed
 }
 
-// Evaluate applies rules in fixed precedence deny > ask > allow.
+// Evaluate applies rules in fixed precedence deny > ask > allow. Bash
+// commands are split into simple commands that are evaluated separately; the
+// most restrictive verdict wins and the command is only allowed when every
+// part is allowed.
 func Evaluate(rules []Rule, req Request) (Effect, []Rule) {
+	if isShellTool(req.Tool) {
+		return evaluateShell(rules, req)
+	}
 	matched := make([]Rule, 0, len(rules))
 	for _, item := range rules {
 		if Match(item, req) {
@@ -276,14 +457,99 @@ func Evaluate(rules []Rule, req Request) (Effect, []Rule) {
 	if len(matched) == 0 {
 		return "", nil
 	}
+	return strongestEffect(matched), matched
+}
+
+func evaluateShell(rules []Rule, req Request) (Effect, []Rule) {
+	argument := strings.TrimSpace(req.Argument)
+	shellRules := make([]Rule, 0, len(rules))
+	for _, item := range rules {
+		if appliesTo(item, req) {
+			shellRules = append(shellRules, item)
+		}
+	}
+
+	matched := make([]Rule, 0, len(shellRules))
+	seen := map[int]struct{}{}
+	record := func(index int) {
+		if _, ok := seen[index]; ok {
+			return
+		}
+		seen[index] = struct{}{}
+		matched = append(matched, shellRules[index])
+	}
+
+	wholeMatched := make([]Rule, 0)
+	for index, item := range shellRules {
+		if item.Pattern == "" || (isWholeCommandPattern(item.Pattern) && matchBashPattern(item.Pattern, argument)) {
+			wholeMatched = append(wholeMatched, item)
+			record(index)
+		}
+	}
+	wholeEffect := strongestEffect(wholeMatched)
+
+	script, err := shellparse.Parse(argument)
+	if err != nil {
+		return wholeEffect, matched
+	}
+
+	partEffects := make([]Effect, 0, len(script.Commands))
+	for _, command := range script.Commands {
+		text := command.Text()
+		if text == "" {
+			continue
+		}
+		partMatched := make([]Rule, 0)
+		for index, item := range shellRules {
+			if item.Pattern == "" || isWholeCommandPattern(item.Pattern) || !matchBashPattern(item.Pattern, text) {
+				continue
+			}
+			if item.Effect == EffectAllow && command.Dynamic {
+				continue
+			}
+			partMatched = append(partMatched, item)
+			record(index)
+		}
+		partEffects = append(partEffects, strongestEffect(partMatched))
+	}
+
+	for _, effect := range []Effect{EffectDeny, EffectAsk} {
+		if wholeEffect == effect {
+			return effect, matched
+		}
+		for _, item := range partEffects {
+			if item == effect {
+				return effect, matched
+			}
+		}
+	}
+	if wholeEffect == EffectAllow {
+		return EffectAllow, matched
+	}
+	if len(partEffects) == 0 {
+		return "", matched
+	}
+	for _, item := range partEffects {
+		if item != EffectAllow {
+			return "", matched
+		}
+	}
+	return EffectAllow, matched
+}
+
+func strongestEffect(matched []Rule) Effect {
 	for _, effect := range []Effect{EffectDeny, EffectAsk, EffectAllow} {
 		for _, item := range matched {
 			if item.Effect == effect {
-				return effect, matched
+				return effect
 			}
 		}
 	}
-	return "", matched
+	return ""
+}
+
+func isShellTool(tool string) bool {
+	return strings.EqualFold(strings.TrimSpace(tool), "Bash")
 }
 
 func parseEffect(raw string) (Effect, error) {
@@ -306,20 +572,3 @@ func normalizePattern(input string) string {
 	}
 	return filepath.ToSlash(trimmed)
 }
-
-func isShellPatternAllowed(pattern string, argument string) bool {
-	if hasShellOperator(pattern) {
-		return true
-	}
-	return !hasShellOperator(argument)
-}
-
-func hasShellOperator(value string) bool {
-	operators := []string{"&&", "||", ";", "|", ">", "<", "$("}
-	for _, op := range operators {
-		if strings.Contains(value, op) {
-			return true
-		}
-	}
-	return false
-}
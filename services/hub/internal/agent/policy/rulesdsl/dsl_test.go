@@ -4,7 +4,12 @@
 
 package rulesdsl
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
 
 func TestParseRule(t *testing.T) {
 	rule, err := ParseRule(`deny Bash(npm run *)`)
@@ -53,6 +58,43 @@ func TestBashShellOperatorAwareness(t *testing.T) {
 	}
 }
 
+func TestBashEvaluatesEverySubcommand(t *testing.T) {
+	rules, err := ParseLines([]string{
+		`allow Bash(go test:*)`,
+		`allow Bash(go vet:*)`,
+		`deny Bash(rm:*)`,
+	})
+	if err != nil {
+		t.Fatalf("parse lines failed: %v", err)
+	}
+	cases := map[string]Effect{
+		"go test ./... && go vet ./...":       EffectAllow,
+		"go test ./... | tee out.txt":         "",
+		"go test $(rm -rf /tmp/x)":            EffectDeny,
+		"(go vet ./...; rm -rf build)":        EffectDeny,
+		"go test -run 'A && B' ./...":         EffectAllow,
+		"$GO test ./...":                      "",
+		"go test ./... && echo 'unterminated": "",
+	}
+	for argument, want := range cases {
+		effect, _ := Evaluate(rules, Request{Tool: "Bash", Argument: argument})
+		if effect != want {
+			t.Fatalf("Evaluate(%q) = %q, want %q", argument, effect, want)
+		}
+	}
+}
+
+func TestBashWholeCommandPatternsStillMatch(t *testing.T) {
+	rule, err := ParseRule(`allow Bash(make && make install)`)
+	if err != nil {
+		t.Fatalf("parse rule failed: %v", err)
+	}
+	effect, matched := Evaluate([]Rule{rule}, Request{Tool: "Bash", Argument: "make && make install"})
+	if effect != EffectAllow || len(matched) != 1 {
+		t.Fatalf("expected whole-command allow, got %q (%#v)", effect, matched)
+	}
+}
+
 func TestEvaluatePrecedence(t *testing.T) {
 	rules, err := ParseLines([]string{
 		`allow Read(./*)`,
@@ -73,3 +115,140 @@ func TestEvaluatePrecedence(t *testing.T) {
 		t.Fatalf("expected 3 matched rules, got %#v", matched)
 	}
 }
+
+func TestMatchDoubleStarPathsAgainstProjectRoot(t *testing.T) {
+	root := t.TempDir()
+	rule, err := ParseRule(`deny Read(/config/**/*.pem)`)
+	if err != nil {
+		t.Fatalf("parse rule failed: %v", err)
+	}
+	for _, argument := range []string{"config/a.pem", "./config/x/y/b.pem", filepath.Join(root, "config", "c.pem")} {
+		if !Match(rule, Request{Tool: "Read", Argument: argument, WorkingDir: root}) {
+			t.Fatalf("expected %q to match", argument)
+		}
+	}
+	if Match(rule, Request{Tool: "Read", Argument: "other/config/a.pem", WorkingDir: root}) {
+		t.Fatal("expected path outside the anchored tree not to match")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skipf("home dir unavailable: %v", err)
+	}
+	homeRule, err := ParseRule(`ask Write(~/.ssh/**)`)
+	if err != nil {
+		t.Fatalf("parse home rule failed: %v", err)
+	}
+	if !Match(homeRule, Request{Tool: "Write", Argument: filepath.Join(home, ".ssh", "config"), WorkingDir: root}) {
+		t.Fatal("expected home-relative rule to match")
+	}
+}
+
+func TestMatchWebFetchDomainRules(t *testing.T) {
+	rule, err := ParseRule(`allow WebFetch(domain:*.example.com)`)
+	if err != nil {
+		t.Fatalf("parse rule failed: %v", err)
+	}
+	cases := map[string]bool{
+		"https://example.com/docs":      true,
+		"https://api.example.com/v1":    true,
+		"http://EXAMPLE.com:8080/":      true,
+		"https://example.com.evil.test": false,
+		"https://notexample.com":        false,
+	}
+	for argument, want := range cases {
+		if got := Match(rule, Request{Tool: "WebFetch", Argument: argument}); got != want {
+			t.Fatalf("match %q = %v, want %v", argument, got, want)
+		}
+	}
+}
+
+func TestMatchMCPToolWildcards(t *testing.T) {
+	rules, err := ParseLines([]string{
+		`allow mcp__github__*`,
+		`deny mcp__github__delete_*`,
+		`ask mcp__jira`,
+	})
+	if err != nil {
+		t.Fatalf("parse lines failed: %v", err)
+	}
+	if effect, _ := Evaluate(rules, Request{Tool: "mcp__github__list_issues", Argument: `{"repo":"x"}`}); effect != EffectAllow {
+		t.Fatalf("expected allow, got %q", effect)
+	}
+	if effect, _ := Evaluate(rules, Request{Tool: "mcp__github__delete_repo"}); effect != EffectDeny {
+		t.Fatalf("expected deny, got %q", effect)
+	}
+	if effect, _ := Evaluate(rules, Request{Tool: "mcp__jira__create_issue"}); effect != EffectAsk {
+		t.Fatalf("expected server rule to cover its tools, got %q", effect)
+	}
+	if effect, _ := Evaluate(rules, Request{Tool: "mcp__jiraext__create_issue"}); effect != "" {
+		t.Fatalf("expected other server to stay unmatched, got %q", effect)
+	}
+}
+
+func TestModeConditions(t *testing.T) {
+	rule, err := ParseRule(`allow Edit(src/**) when mode=accept_edits|plan`)
+	if err != nil {
+		t.Fatalf("parse rule failed: %v", err)
+	}
+	if len(rule.Conditions) != 1 || rule.Conditions[0].Values[0] != "acceptEdits" {
+		t.Fatalf("unexpected conditions %#v", rule.Conditions)
+	}
+	if !Match(rule, Request{Tool: "Edit", Argument: "./src/a.go", Mode: "acceptEdits"}) {
+		t.Fatal("expected acceptEdits mode to match")
+	}
+	if Match(rule, Request{Tool: "Edit", Argument: "./src/a.go"}) {
+		t.Fatal("expected empty mode to be treated as default")
+	}
+
+	negated, err := ParseRule(`deny Bash when mode != bypassPermissions`)
+	if err != nil {
+		t.Fatalf("parse negated rule failed: %v", err)
+	}
+	if !Match(negated, Request{Tool: "Bash", Argument: "ls", Mode: "default"}) {
+		t.Fatal("expected negated condition to match default mode")
+	}
+	if Match(negated, Request{Tool: "Bash", Argument: "ls", Mode: "bypassPermissions"}) {
+		t.Fatal("expected negated condition to skip bypass mode")
+	}
+}
+
+func TestLintReportsLineDiagnosticsWithSuggestions(t *testing.T) {
+	rules, diagnostics := Lint([]string{
+		`allow Read(./src/**)`,
+		``,
+		`alow Bash(ls)`,
+		`deny Raed(./.env)`,
+		`ask Edit(src/**) when mode=acceptEdit`,
+		`deny Write(./tmp`,
+		`allow Read(./docs/**) trailing`,
+	})
+	if len(rules) != 3 {
+		t.Fatalf("expected three usable rules, got %#v", rules)
+	}
+	want := []struct {
+		line       int
+		severity   Severity
+		suggestion string
+	}{
+		{3, SeverityError, `did you mean "allow"?`},
+		{4, SeverityWarning, `did you mean "Read"?`},
+		{5, SeverityError, `did you mean "acceptEdits"?`},
+		{6, SeverityError, "add ')' after the pattern"},
+		{7, SeverityWarning, "use `when mode=<mode>` for conditions or start a comment with #"},
+	}
+	if len(diagnostics) != len(want) {
+		t.Fatalf("unexpected diagnostics %#v", diagnostics)
+	}
+	for index, item := range want {
+		got := diagnostics[index]
+		if got.Line != item.line || got.Severity != item.severity || got.Suggestion != item.suggestion {
+			t.Fatalf("diagnostic %d = %s, want line %d %s %q", index, got, item.line, item.severity, item.suggestion)
+		}
+	}
+
+	_, err := ParseLines([]string{`allow Read(./src/**)`, `alow Bash(ls)`})
+	if err == nil || !strings.Contains(err.Error(), "parse rule line 2 failed") {
+		t.Fatalf("expected line-numbered parse error, got %v", err)
+	}
+}
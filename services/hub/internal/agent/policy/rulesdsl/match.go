@@ -0,0 +1,151 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package rulesdsl
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+const conditionKeyMode = "mode"
+
+// knownModes lists the canonical permission modes accepted by mode conditions.
+var knownModes = []string{"default", "acceptEdits", "plan", "dontAsk", "bypassPermissions"}
+
+// matchToolName compares a rule tool with the requested tool. Rule tools may
+// be globs ("mcp__github__*") and a bare "mcp__server" covers every tool the
+// server exposes.
+func matchToolName(ruleTool string, requestTool string) bool {
+	ruleTool = strings.ToLower(strings.TrimSpace(ruleTool))
+	requestTool = strings.ToLower(strings.TrimSpace(requestTool))
+	if ruleTool == "" || requestTool == "" {
+		return false
+	}
+	if strings.ContainsAny(ruleTool, "*?[") {
+		ok, err := path.Match(ruleTool, requestTool)
+		return err == nil && ok
+	}
+	if ruleTool == requestTool {
+		return true
+	}
+	if server, ok := strings.CutPrefix(ruleTool, "mcp__"); ok && server != "" && !strings.Contains(server, "__") {
+		return strings.HasPrefix(requestTool, ruleTool+"__")
+	}
+	return false
+}
+
+func conditionsHold(conditions []Condition, req Request) bool {
+	for _, condition := range conditions {
+		value := ""
+		switch condition.Key {
+		case conditionKeyMode:
+			value = strings.TrimSpace(req.Mode)
+			if value == "" {
+				value = "default"
+			}
+			value, _ = canonicalMode(value)
+		default:
+			return false
+		}
+		matched := false
+		for _, item := range condition.Values {
+			if item == value {
+				matched = true
+				break
+			}
+		}
+		if matched == condition.Negate {
+			return false
+		}
+	}
+	return true
+}
+
+// canonicalMode maps mode spellings such as accept_edits or accept-edits to
+// the canonical camelCase name.
+func canonicalMode(raw string) (string, bool) {
+	key := foldModeName(raw)
+	for _, item := range knownModes {
+		if foldModeName(item) == key {
+			return item, true
+		}
+	}
+	return strings.TrimSpace(raw), false
+}
+
+func foldModeName(raw string) string {
+	replacer := strings.NewReplacer("_", "", "-", "")
+	return strings.ToLower(replacer.Replace(strings.TrimSpace(raw)))
+}
+
+// matchPathGlob matches slash-separated paths. Without "**" it is plain glob
+// matching; "**" spans any number of directories, gitignore style.
+func matchPathGlob(pattern string, target string) bool {
+	if !strings.Contains(pattern, "**") {
+		ok, err := path.Match(pattern, target)
+		return err == nil && ok
+	}
+	return matchPathSegments(strings.Split(pattern, "/"), strings.Split(target, "/"))
+}
+
+func matchPathSegments(pattern []string, target []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for index := 0; index <= len(target); index++ {
+				if matchPathSegments(rest, target[index:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(target) == 0 {
+			return false
+		}
+		ok, err := path.Match(strings.ReplaceAll(pattern[0], "**", "*"), target[0])
+		if err != nil || !ok {
+			return false
+		}
+		pattern, target = pattern[1:], target[1:]
+	}
+	return len(target) == 0
+}
+
+func isDomainPattern(pattern string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(pattern)), "domain:")
+}
+
+// matchDomain matches a "domain:" rule against the host of a URL argument.
+// "*.example.com" covers example.com and every subdomain.
+func matchDomain(pattern string, argument string) bool {
+	domain := strings.ToLower(strings.TrimSpace(strings.TrimSpace(pattern)[len("domain:"):]))
+	host := requestHost(argument)
+	if domain == "" || host == "" {
+		return false
+	}
+	if domain == "*" {
+		return true
+	}
+	if base, ok := strings.CutPrefix(domain, "*."); ok {
+		return host == base || strings.HasSuffix(host, "."+base)
+	}
+	return host == domain
+}
+
+func requestHost(argument string) string {
+	trimmed := strings.TrimSpace(argument)
+	if trimmed == "" {
+		return ""
+	}
+	if !strings.Contains(trimmed, "://") {
+		trimmed = "https://" + trimmed
+	}
+	parsed, err := url.Parse(trimmed)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+}
@@ -15,6 +15,7 @@ import (
 	"strings"
 
 	"goyais/services/hub/internal/agent/core"
+	"goyais/services/hub/internal/agent/policy/shellparse"
 )
 
 // RuleType identifies one sandbox constraint dimension.
@@ -217,17 +218,32 @@ func heuristicDecision(toolName string, workingDir string, pathValue string, com
 		return &decision
 	}
 
-	if containsShellOperator(commandValue) {
-		decision := buildDecision(
-			core.PermissionDecisionAsk,
-			"command contains shell operator",
-			Rule{ID: "heuristic_command_operator", Type: RuleTypeCommand},
-			toolName,
-			pathValue,
-			commandValue,
-			hostValue,
-		)
-		return &decision
+	if strings.TrimSpace(commandValue) != "" {
+		script, err := shellparse.Parse(commandValue)
+		if err != nil {
+			decision := buildDecision(
+				core.PermissionDecisionAsk,
+				"command could not be parsed: "+err.Error(),
+				Rule{ID: "heuristic_command_unparsed", Type: RuleTypeCommand},
+				toolName,
+				pathValue,
+				commandValue,
+				hostValue,
+			)
+			return &decision
+		}
+		if outside := shellparse.OutsideWrites(script, workingDir); len(outside) > 0 {
+			decision := buildDecision(
+				core.PermissionDecisionAsk,
+				"redirection writes outside workspace: "+outside[0].Target,
+				Rule{ID: "heuristic_redirect_outside", Type: RuleTypeCommand},
+				toolName,
+				pathValue,
+				commandValue,
+				hostValue,
+			)
+			return &decision
+		}
 	}
 
 	if externalHost(hostValue) {
@@ -446,20 +462,6 @@ func externalHost(host string) bool {
 	}
 }
 
-func containsShellOperator(command string) bool {
-	normalized := strings.TrimSpace(command)
-	if normalized == "" {
-		return false
-	}
-	operators := []string{"&&", "||", ";", "|", ">", "<", "$("}
-	for _, op := range operators {
-		if strings.Contains(normalized, op) {
-			return true
-		}
-	}
-	return false
-}
-
 func specificityPenalty(pattern string) int {
 	length := len(strings.TrimSpace(pattern))
 	if length <= 0 {
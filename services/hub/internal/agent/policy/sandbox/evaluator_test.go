@@ -71,14 +71,15 @@ func TestEvaluatorEvaluate_HeuristicPathEscapeDeny(t *testing.T) {
 	}
 }
 
-func TestEvaluatorEvaluate_HeuristicCommandAsk(t *testing.T) {
+func TestEvaluatorEvaluate_HeuristicRedirectOutsideAsk(t *testing.T) {
 	evaluator := NewEvaluator(nil)
 
 	decision, err := evaluator.Evaluate(context.Background(), Request{
 		ToolName: "bash",
 		Input: map[string]any{
-			"command": "go test ./... && rm -rf /tmp/x",
+			"command": "go test ./... > /etc/report.txt",
 		},
+		WorkingDir: "/workspace/project",
 	})
 	if err != nil {
 		t.Fatalf("evaluate failed: %v", err)
@@ -86,11 +87,46 @@ func TestEvaluatorEvaluate_HeuristicCommandAsk(t *testing.T) {
 	if decision.Kind != core.PermissionDecisionAsk {
 		t.Fatalf("expected ask, got %q", decision.Kind)
 	}
-	if decision.MatchedRule != "heuristic_command_operator" {
+	if decision.MatchedRule != "heuristic_redirect_outside" {
 		t.Fatalf("unexpected matched rule %q", decision.MatchedRule)
 	}
 }
 
+func TestEvaluatorEvaluate_CompoundCommandInsideWorkspaceAllowed(t *testing.T) {
+	evaluator := NewEvaluator(nil)
+
+	decision, err := evaluator.Evaluate(context.Background(), Request{
+		ToolName: "bash",
+		Input: map[string]any{
+			"command": "go test ./... && go vet ./... > build/vet.log 2>&1",
+		},
+		WorkingDir: "/workspace/project",
+	})
+	if err != nil {
+		t.Fatalf("evaluate failed: %v", err)
+	}
+	if decision.Kind != core.PermissionDecisionAllow {
+		t.Fatalf("expected allow, got %q (%s)", decision.Kind, decision.Reason)
+	}
+}
+
+func TestEvaluatorEvaluate_HeuristicUnparsedCommandAsk(t *testing.T) {
+	evaluator := NewEvaluator(nil)
+
+	decision, err := evaluator.Evaluate(context.Background(), Request{
+		ToolName: "bash",
+		Input: map[string]any{
+			"command": "echo 'unterminated",
+		},
+	})
+	if err != nil {
+		t.Fatalf("evaluate failed: %v", err)
+	}
+	if decision.Kind != core.PermissionDecisionAsk || decision.MatchedRule != "heuristic_command_unparsed" {
+		t.Fatalf("expected unparsed ask, got %q (%s)", decision.Kind, decision.MatchedRule)
+	}
+}
+
 func TestEvaluatorEvaluate_HeuristicNetworkAsk(t *testing.T) {
 	evaluator := NewEvaluator(nil)
 
@@ -0,0 +1,875 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+// Package shellparse splits POSIX shell command lines into simple commands so
+// permission policies can evaluate every subcommand of a pipeline, list,
+// subshell or substitution on its own.
+package shellparse
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Command is one simple command found anywhere in a shell script.
+type Command struct {
+	Assignments []string
+	Words       []string
+	Redirects   []Redirect
+	// Dynamic reports that the command name comes from an expansion and
+	// cannot be matched statically.
+	Dynamic bool
+	// Substitution reports that the command runs inside a command or process
+	// substitution.
+	Substitution bool
+}
+
+// Name returns the command name, or "" for assignment/redirect-only commands.
+func (c Command) Name() string {
+	if len(c.Words) == 0 {
+		return ""
+	}
+	return c.Words[0]
+}
+
+// Text renders the command with quotes removed and words separated by one
+// space; this is the form permission patterns are matched against.
+func (c Command) Text() string {
+	parts := make([]string, 0, len(c.Assignments)+len(c.Words))
+	parts = append(parts, c.Assignments...)
+	parts = append(parts, c.Words...)
+	return strings.Join(parts, " ")
+}
+
+// Redirect is one I/O redirection attached to a command.
+type Redirect struct {
+	// FD is the explicit file descriptor, or -1 when omitted.
+	FD      int
+	Op      string
+	Target  string
+	Dynamic bool
+}
+
+// WritesFile reports whether the redirection opens Target for writing.
+func (r Redirect) WritesFile() bool {
+	switch r.Op {
+	case ">", ">>", ">|", "<>", "&>", "&>>":
+		return true
+	case ">&":
+		if r.Target == "-" {
+			return false
+		}
+		_, err := strconv.Atoi(r.Target)
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// Script is the parsed form of one shell command line.
+type Script struct {
+	Commands      []Command
+	Operators     []string
+	Subshells     int
+	Substitutions int
+}
+
+// Compound reports whether the script is anything more than one plain simple
+// command: lists, pipelines, subshells, substitutions or redirections.
+func (s Script) Compound() bool {
+	if len(s.Commands) != 1 || len(s.Operators) > 0 || s.Subshells > 0 || s.Substitutions > 0 {
+		return true
+	}
+	return len(s.Commands[0].Redirects) > 0
+}
+
+// Redirects returns every redirection in script order.
+func (s Script) Redirects() []Redirect {
+	out := make([]Redirect, 0)
+	for _, command := range s.Commands {
+		out = append(out, command.Redirects...)
+	}
+	return out
+}
+
+// Parse splits src into simple commands. Constructs the parser cannot reason
+// about (case clauses, function definitions) are reported as errors so callers
+// can fall back to the most restrictive decision.
+func Parse(src string) (Script, error) {
+	script := &Script{}
+	p := &parser{src: src, script: script}
+	if err := p.parseList(0); err != nil {
+		return Script{}, err
+	}
+	if !p.eof() {
+		return Script{}, p.errorf("unexpected %q", p.peek())
+	}
+	return *script, nil
+}
+
+type pendingHeredoc struct {
+	delimiter string
+	stripTabs bool
+	expand    bool
+}
+
+type parser struct {
+	src          string
+	pos          int
+	script       *Script
+	substitution int
+	heredocs     []pendingHeredoc
+}
+
+type word struct {
+	text       string
+	dynamic    bool
+	quoted     bool
+	assignment bool
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("shell parse error at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) peekAt(offset int) byte {
+	if p.pos+offset >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos+offset]
+}
+
+func (p *parser) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(p.src[p.pos:], prefix)
+}
+
+// skipBlanks skips spaces, tabs and line continuations.
+func (p *parser) skipBlanks() {
+	for !p.eof() {
+		switch {
+		case p.peek() == ' ' || p.peek() == '\t':
+			p.pos++
+		case p.hasPrefix("\\\n"):
+			p.pos += 2
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) skipComment() {
+	for !p.eof() && p.peek() != '\n' {
+		p.pos++
+	}
+}
+
+func (p *parser) parseList(closer byte) error {
+	needCommand := false
+	for {
+		p.skipBlanks()
+		if p.eof() || (closer != 0 && p.peek() == closer) {
+			if needCommand {
+				return p.errorf("expected command")
+			}
+			if closer != 0 && p.eof() {
+				return p.errorf("missing %q", closer)
+			}
+			return nil
+		}
+		switch c := p.peek(); {
+		case c == '#':
+			p.skipComment()
+			continue
+		case c == '\n':
+			p.pos++
+			if err := p.readHeredocBodies(); err != nil {
+				return err
+			}
+			continue
+		case c == ';':
+			if p.hasPrefix(";;") {
+				return p.errorf("case clauses are not supported")
+			}
+			if needCommand {
+				return p.errorf("expected command")
+			}
+			p.pos++
+			p.script.Operators = append(p.script.Operators, ";")
+			continue
+		case c == ')':
+			return p.errorf("unexpected ')'")
+		}
+
+		if err := p.parsePipeline(); err != nil {
+			return err
+		}
+		needCommand = false
+		p.skipBlanks()
+		switch {
+		case p.hasPrefix("&&"):
+			p.pos += 2
+			p.script.Operators = append(p.script.Operators, "&&")
+			needCommand = true
+		case p.hasPrefix("||"):
+			p.pos += 2
+			p.script.Operators = append(p.script.Operators, "||")
+			needCommand = true
+		case p.peek() == '&':
+			p.pos++
+			p.script.Operators = append(p.script.Operators, "&")
+		}
+	}
+}
+
+func (p *parser) parsePipeline() error {
+	p.skipBlanks()
+	if p.peek() == '!' && isBlankOrBreak(p.peekAt(1)) {
+		p.pos++
+	}
+	for {
+		if err := p.parseCommand(); err != nil {
+			return err
+		}
+		p.skipBlanks()
+		switch {
+		case p.hasPrefix("||"):
+			return nil
+		case p.hasPrefix("|&"):
+			p.pos += 2
+			p.script.Operators = append(p.script.Operators, "|&")
+		case p.peek() == '|':
+			p.pos++
+			p.script.Operators = append(p.script.Operators, "|")
+		default:
+			return nil
+		}
+		for {
+			p.skipBlanks()
+			if p.peek() != '\n' {
+				break
+			}
+			p.pos++
+		}
+	}
+}
+
+func (p *parser) parseCommand() error {
+	p.skipBlanks()
+	if p.eof() {
+		return p.errorf("expected command")
+	}
+	if p.peek() == '(' {
+		if p.hasPrefix("((") {
+			if _, err := p.skipBalanced("((", "))"); err != nil {
+				return err
+			}
+			return p.parseTrailingRedirects()
+		}
+		p.pos++
+		p.script.Subshells++
+		if err := p.parseList(')'); err != nil {
+			return err
+		}
+		p.pos++
+		return p.parseTrailingRedirects()
+	}
+
+	command := Command{Substitution: p.substitution > 0}
+	consumed := false
+	atStart := true
+	for {
+		p.skipBlanks()
+		if p.eof() {
+			break
+		}
+		c := p.peek()
+		if c == '\n' || c == ';' || c == '|' || c == ')' || (c == '&' && !p.hasPrefix("&>")) {
+			break
+		}
+		if c == '#' {
+			p.skipComment()
+			continue
+		}
+		if c == '(' {
+			if len(command.Words) == 1 || p.hasPrefix("()") {
+				return p.errorf("function definitions are not supported")
+			}
+			return p.errorf("unexpected '('")
+		}
+		if (c == '<' || c == '>') && p.peekAt(1) == '(' {
+			item, err := p.readProcessSubstitution()
+			if err != nil {
+				return err
+			}
+			command.Words = append(command.Words, item.text)
+			consumed, atStart = true, false
+			continue
+		}
+		redirect, ok, err := p.tryRedirect()
+		if err != nil {
+			return err
+		}
+		if ok {
+			command.Redirects = append(command.Redirects, redirect)
+			consumed = true
+			continue
+		}
+
+		item, err := p.readWord()
+		if err != nil {
+			return err
+		}
+		consumed = true
+		if atStart && item.assignment {
+			command.Assignments = append(command.Assignments, item.text)
+			continue
+		}
+		if atStart && !item.quoted && !item.dynamic {
+			if handled, err := p.handleReservedWord(item.text); err != nil {
+				return err
+			} else if handled {
+				continue
+			}
+		}
+		atStart = false
+		command.Words = append(command.Words, item.text)
+		if len(command.Words) == 1 {
+			command.Dynamic = item.dynamic
+			if item.text == "[[" && !item.quoted {
+				if err := p.readTestClause(&command); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if !consumed {
+		return p.errorf("expected command")
+	}
+	if len(command.Words) > 0 || len(command.Redirects) > 0 || len(command.Assignments) > 0 {
+		p.script.Commands = append(p.script.Commands, command)
+	}
+	return nil
+}
+
+// handleReservedWord consumes compound-command keywords so the commands they
+// wrap are still extracted.
+func (p *parser) handleReservedWord(text string) (bool, error) {
+	switch text {
+	case "if", "then", "else", "elif", "fi", "do", "done", "while", "until", "{", "}", "!":
+		return true, nil
+	case "for":
+		return true, p.skipForClause()
+	case "case", "select", "function", "coproc":
+		return false, p.errorf("%s is not supported", text)
+	default:
+		return false, nil
+	}
+}
+
+func (p *parser) skipForClause() error {
+	p.skipBlanks()
+	if p.hasPrefix("((") {
+		_, err := p.skipBalanced("((", "))")
+		return err
+	}
+	if _, err := p.readWord(); err != nil {
+		return err
+	}
+	p.skipBlanks()
+	if !p.hasPrefix("in") || !isBlankOrBreak(p.peekAt(2)) {
+		return nil
+	}
+	p.pos += 2
+	for {
+		p.skipBlanks()
+		if p.eof() || isWordBreak(p.peek()) {
+			return nil
+		}
+		if _, err := p.readWord(); err != nil {
+			return err
+		}
+	}
+}
+
+// readTestClause reads a [[ ... ]] expression whose < and > are comparison
+// operators rather than redirections.
+func (p *parser) readTestClause(command *Command) error {
+	for {
+		p.skipBlanks()
+		if p.eof() {
+			return p.errorf("missing ]]")
+		}
+		if p.hasPrefix("]]") && isBlankOrBreak(p.peekAt(2)) {
+			p.pos += 2
+			command.Words = append(command.Words, "]]")
+			return nil
+		}
+		if strings.IndexByte("<>&|()!\n", p.peek()) >= 0 {
+			start := p.pos
+			for !p.eof() && strings.IndexByte("<>&|()!", p.peek()) >= 0 {
+				p.pos++
+			}
+			if p.pos == start {
+				p.pos++
+				continue
+			}
+			command.Words = append(command.Words, p.src[start:p.pos])
+			continue
+		}
+		item, err := p.readWord()
+		if err != nil {
+			return err
+		}
+		command.Words = append(command.Words, item.text)
+	}
+}
+
+func (p *parser) parseTrailingRedirects() error {
+	command := Command{Substitution: p.substitution > 0}
+	for {
+		p.skipBlanks()
+		redirect, ok, err := p.tryRedirect()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		command.Redirects = append(command.Redirects, redirect)
+	}
+	if len(command.Redirects) > 0 {
+		p.script.Commands = append(p.script.Commands, command)
+	}
+	return nil
+}
+
+var redirectOperators = []string{"&>>", "&>", "<<<", "<<-", "<<", "<>", "<&", ">>", ">&", ">|", "<", ">"}
+
+func (p *parser) tryRedirect() (Redirect, bool, error) {
+	index := p.pos
+	for index < len(p.src) && isDigit(p.src[index]) {
+		index++
+	}
+	fd := -1
+	if index > p.pos {
+		if index >= len(p.src) || (p.src[index] != '<' && p.src[index] != '>') {
+			return Redirect{}, false, nil
+		}
+		fd, _ = strconv.Atoi(p.src[p.pos:index])
+	}
+	rest := p.src[index:]
+	op := ""
+	for _, candidate := range redirectOperators {
+		if strings.HasPrefix(rest, candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		return Redirect{}, false, nil
+	}
+	if (op == "<" || op == ">") && fd < 0 && len(rest) > 1 && rest[1] == '(' {
+		return Redirect{}, false, nil
+	}
+	p.pos = index + len(op)
+	p.skipBlanks()
+	if p.eof() || p.peek() == '\n' || p.peek() == ';' || p.peek() == '|' || p.peek() == '&' || p.peek() == ')' {
+		return Redirect{}, false, p.errorf("missing target for %s", op)
+	}
+
+	var target word
+	var err error
+	if (p.peek() == '<' || p.peek() == '>') && p.peekAt(1) == '(' {
+		target, err = p.readProcessSubstitution()
+	} else {
+		target, err = p.readWord()
+	}
+	if err != nil {
+		return Redirect{}, false, err
+	}
+	if op == "<<" || op == "<<-" {
+		p.heredocs = append(p.heredocs, pendingHeredoc{
+			delimiter: target.text,
+			stripTabs: op == "<<-",
+			expand:    !target.quoted,
+		})
+	}
+	return Redirect{FD: fd, Op: op, Target: target.text, Dynamic: target.dynamic}, true, nil
+}
+
+func (p *parser) readProcessSubstitution() (word, error) {
+	start := p.pos
+	p.pos += 2
+	p.script.Substitutions++
+	p.substitution++
+	err := p.parseList(')')
+	p.substitution--
+	if err != nil {
+		return word{}, err
+	}
+	p.pos++
+	return word{text: p.src[start:p.pos], dynamic: true}, nil
+}
+
+func (p *parser) readWord() (word, error) {
+	var builder strings.Builder
+	result := word{assignment: hasAssignmentPrefix(p.src[p.pos:])}
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if isWordBreak(c) {
+			break
+		}
+		switch c {
+		case '\\':
+			if p.peekAt(1) == '\n' {
+				p.pos += 2
+				continue
+			}
+			if p.pos+1 < len(p.src) {
+				builder.WriteByte(p.src[p.pos+1])
+			}
+			p.pos += 2
+			result.quoted = true
+		case '\'':
+			end := strings.IndexByte(p.src[p.pos+1:], '\'')
+			if end < 0 {
+				return word{}, p.errorf("unterminated single quote")
+			}
+			builder.WriteString(p.src[p.pos+1 : p.pos+1+end])
+			p.pos += end + 2
+			result.quoted = true
+		case '"':
+			p.pos++
+			if err := p.readDoubleQuoted(&builder, &result); err != nil {
+				return word{}, err
+			}
+			result.quoted = true
+		case '$':
+			if err := p.readDollar(&builder, &result); err != nil {
+				return word{}, err
+			}
+		case '`':
+			if err := p.readBacktick(&builder, &result); err != nil {
+				return word{}, err
+			}
+		default:
+			builder.WriteByte(c)
+			p.pos++
+		}
+	}
+	if p.pos > len(p.src) {
+		p.pos = len(p.src)
+	}
+	if p.pos == start {
+		return word{}, p.errorf("expected word")
+	}
+	result.text = builder.String()
+	return result, nil
+}
+
+func (p *parser) readDoubleQuoted(builder *strings.Builder, result *word) error {
+	for {
+		if p.eof() {
+			return p.errorf("unterminated double quote")
+		}
+		c := p.peek()
+		switch c {
+		case '"':
+			p.pos++
+			return nil
+		case '\\':
+			next := p.peekAt(1)
+			switch next {
+			case '$', '`', '"', '\\':
+				builder.WriteByte(next)
+				p.pos += 2
+			case '\n':
+				p.pos += 2
+			default:
+				builder.WriteByte('\\')
+				p.pos++
+			}
+		case '$':
+			if err := p.readDollar(builder, result); err != nil {
+				return err
+			}
+		case '`':
+			if err := p.readBacktick(builder, result); err != nil {
+				return err
+			}
+		default:
+			builder.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *parser) readDollar(builder *strings.Builder, result *word) error {
+	start := p.pos
+	next := p.peekAt(1)
+	switch {
+	case next == '(' && p.peekAt(2) == '(':
+		if _, err := p.skipBalanced("$((", "))"); err != nil {
+			return err
+		}
+		result.dynamic = true
+	case next == '(':
+		p.pos += 2
+		p.script.Substitutions++
+		p.substitution++
+		err := p.parseList(')')
+		p.substitution--
+		if err != nil {
+			return err
+		}
+		p.pos++
+		result.dynamic = true
+	case next == '{':
+		p.pos += 2
+		if err := p.skipParameterExpansion(); err != nil {
+			return err
+		}
+		result.dynamic = true
+	case next == '\'':
+		p.pos += 2
+		for {
+			if p.eof() {
+				return p.errorf("unterminated $'...' quote")
+			}
+			c := p.peek()
+			if c == '\'' {
+				p.pos++
+				break
+			}
+			if c == '\\' && p.pos+1 < len(p.src) {
+				builder.WriteByte(p.src[p.pos+1])
+				p.pos += 2
+				continue
+			}
+			builder.WriteByte(c)
+			p.pos++
+		}
+		result.quoted = true
+		return nil
+	case next == '"':
+		p.pos += 2
+		result.quoted = true
+		return p.readDoubleQuoted(builder, result)
+	case isNameChar(next):
+		p.pos++
+		for !p.eof() && isNameChar(p.peek()) {
+			p.pos++
+		}
+		result.dynamic = true
+	case next != 0 && strings.IndexByte("@*#?$!-0123456789", next) >= 0:
+		p.pos += 2
+		result.dynamic = true
+	default:
+		builder.WriteByte('$')
+		p.pos++
+		return nil
+	}
+	builder.WriteString(p.src[start:p.pos])
+	return nil
+}
+
+func (p *parser) skipParameterExpansion() error {
+	var scratch strings.Builder
+	var ignored word
+	for {
+		if p.eof() {
+			return p.errorf("unterminated ${...}")
+		}
+		switch p.peek() {
+		case '}':
+			p.pos++
+			return nil
+		case '\\':
+			p.pos += 2
+		case '\'':
+			end := strings.IndexByte(p.src[p.pos+1:], '\'')
+			if end < 0 {
+				return p.errorf("unterminated single quote")
+			}
+			p.pos += end + 2
+		case '"':
+			p.pos++
+			if err := p.readDoubleQuoted(&scratch, &ignored); err != nil {
+				return err
+			}
+		case '$':
+			if err := p.readDollar(&scratch, &ignored); err != nil {
+				return err
+			}
+		case '`':
+			if err := p.readBacktick(&scratch, &ignored); err != nil {
+				return err
+			}
+		default:
+			p.pos++
+		}
+	}
+}
+
+func (p *parser) readBacktick(builder *strings.Builder, result *word) error {
+	start := p.pos
+	p.pos++
+	var inner strings.Builder
+	for {
+		if p.eof() {
+			return p.errorf("unterminated backquote")
+		}
+		c := p.peek()
+		if c == '`' {
+			p.pos++
+			break
+		}
+		if c == '\\' && strings.IndexByte("`\\$", p.peekAt(1)) >= 0 && p.peekAt(1) != 0 {
+			inner.WriteByte(p.peekAt(1))
+			p.pos += 2
+			continue
+		}
+		inner.WriteByte(c)
+		p.pos++
+	}
+	p.script.Substitutions++
+	nested := &parser{src: inner.String(), script: p.script, substitution: p.substitution + 1}
+	if err := nested.parseList(0); err != nil {
+		return err
+	}
+	if !nested.eof() {
+		return nested.errorf("unexpected %q in backquote", nested.peek())
+	}
+	builder.WriteString(p.src[start:p.pos])
+	result.dynamic = true
+	return nil
+}
+
+// skipBalanced skips an arithmetic expression opened by open and closed by
+// close, tracking nested parentheses.
+func (p *parser) skipBalanced(open string, close string) (string, error) {
+	start := p.pos
+	p.pos += len(open)
+	depth := 0
+	for !p.eof() {
+		if depth == 0 && p.hasPrefix(close) {
+			p.pos += len(close)
+			return p.src[start:p.pos], nil
+		}
+		switch p.peek() {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		p.pos++
+	}
+	return "", p.errorf("missing %q", close)
+}
+
+func (p *parser) readHeredocBodies() error {
+	pending := p.heredocs
+	p.heredocs = nil
+	for _, heredoc := range pending {
+		bodyStart := p.pos
+		bodyEnd := len(p.src)
+		for !p.eof() {
+			lineEnd := strings.IndexByte(p.src[p.pos:], '\n')
+			line := p.src[p.pos:]
+			next := len(p.src)
+			if lineEnd >= 0 {
+				line = p.src[p.pos : p.pos+lineEnd]
+				next = p.pos + lineEnd + 1
+			}
+			candidate := line
+			if heredoc.stripTabs {
+				candidate = strings.TrimLeft(candidate, "\t")
+			}
+			if candidate == heredoc.delimiter {
+				bodyEnd = p.pos
+				p.pos = next
+				break
+			}
+			p.pos = next
+		}
+		if heredoc.expand {
+			if err := p.scanExpansions(p.src[bodyStart:bodyEnd]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// scanExpansions extracts command substitutions from an unquoted here-document
+// body.
+func (p *parser) scanExpansions(body string) error {
+	nested := &parser{src: body, script: p.script, substitution: p.substitution}
+	var scratch strings.Builder
+	var ignored word
+	for !nested.eof() {
+		switch nested.peek() {
+		case '\\':
+			nested.pos += 2
+		case '$':
+			if err := nested.readDollar(&scratch, &ignored); err != nil {
+				return err
+			}
+		case '`':
+			if err := nested.readBacktick(&scratch, &ignored); err != nil {
+				return err
+			}
+		default:
+			nested.pos++
+		}
+	}
+	return nil
+}
+
+func isWordBreak(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', ';', '&', '|', '(', ')', '<', '>':
+		return true
+	default:
+		return false
+	}
+}
+
+func isBlankOrBreak(c byte) bool {
+	return c == 0 || isWordBreak(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// hasAssignmentPrefix reports whether raw source starts with NAME= or NAME+=.
+func hasAssignmentPrefix(raw string) bool {
+	index := 0
+	for index < len(raw) && isNameChar(raw[index]) {
+		index++
+	}
+	if index == 0 || isDigit(raw[0]) {
+		return false
+	}
+	rest := raw[index:]
+	return strings.HasPrefix(rest, "=") || strings.HasPrefix(rest, "+=")
+}
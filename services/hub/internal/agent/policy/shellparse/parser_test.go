@@ -0,0 +1,160 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package shellparse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func commandTexts(t *testing.T, src string) []string {
+	t.Helper()
+	script, err := Parse(src)
+	if err != nil {
+		t.Fatalf("parse %q failed: %v", src, err)
+	}
+	out := make([]string, 0, len(script.Commands))
+	for _, command := range script.Commands {
+		out = append(out, command.Text())
+	}
+	return out
+}
+
+func TestParseSplitsListsAndPipelines(t *testing.T) {
+	got := commandTexts(t, "go test ./... && go vet ./... || echo failed; git status | grep -v '^??' |& tee log &")
+	want := []string{"go test ./...", "go vet ./...", "echo failed", "git status", "grep -v ^??", "tee log"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected commands %#v", got)
+	}
+}
+
+func TestParseKeepsQuotedOperatorsInsideWords(t *testing.T) {
+	script, err := Parse(`git commit -m "fix: a && b; c | d" && echo 'x > y'`)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(script.Commands) != 2 || script.Commands[0].Text() != "git commit -m fix: a && b; c | d" {
+		t.Fatalf("unexpected commands %#v", script.Commands)
+	}
+	if len(script.Redirects()) != 0 {
+		t.Fatalf("quoted > must not be a redirection, got %#v", script.Redirects())
+	}
+}
+
+func TestParseExtractsSubstitutionsAndSubshells(t *testing.T) {
+	script, err := Parse("echo $(rm -rf /tmp/x) `whoami` \"$(id -u)\" && (cd sub; make) && diff <(ls a) <(ls b)")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	texts := make([]string, 0, len(script.Commands))
+	nested := 0
+	for _, command := range script.Commands {
+		texts = append(texts, command.Name())
+		if command.Substitution {
+			nested++
+		}
+	}
+	want := []string{"rm", "whoami", "id", "echo", "cd", "make", "ls", "ls", "diff"}
+	if !reflect.DeepEqual(texts, want) {
+		t.Fatalf("unexpected command names %#v", texts)
+	}
+	if nested != 5 || script.Subshells != 1 || script.Substitutions != 5 {
+		t.Fatalf("unexpected nesting nested=%d subshells=%d substitutions=%d", nested, script.Subshells, script.Substitutions)
+	}
+}
+
+func TestParseMarksDynamicCommandNames(t *testing.T) {
+	script, err := Parse("FOO=bar $CMD --flag; X=\"a b\" go build")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if !script.Commands[0].Dynamic || script.Commands[0].Assignments[0] != "FOO=bar" {
+		t.Fatalf("expected dynamic command with assignment, got %#v", script.Commands[0])
+	}
+	if script.Commands[1].Dynamic || script.Commands[1].Name() != "go" || script.Commands[1].Assignments[0] != "X=a b" {
+		t.Fatalf("unexpected second command %#v", script.Commands[1])
+	}
+}
+
+func TestParseRedirections(t *testing.T) {
+	script, err := Parse("make 2>&1 >build.log; cat <<EOF > /etc/motd\nhello $(hostname)\nEOF\necho done &>> out.txt")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	names := []string{}
+	for _, command := range script.Commands {
+		names = append(names, command.Name())
+	}
+	if !reflect.DeepEqual(names, []string{"make", "cat", "hostname", "echo"}) {
+		t.Fatalf("unexpected commands %#v", names)
+	}
+	redirects := script.Redirects()
+	writes := []string{}
+	for _, redirect := range redirects {
+		if redirect.WritesFile() {
+			writes = append(writes, redirect.Op+redirect.Target)
+		}
+	}
+	if !reflect.DeepEqual(writes, []string{">build.log", ">/etc/motd", "&>>out.txt"}) {
+		t.Fatalf("unexpected write redirections %#v", writes)
+	}
+}
+
+func TestParseCompoundKeywords(t *testing.T) {
+	got := commandTexts(t, "if test -f go.mod; then go build; else echo missing; fi\nfor f in *.go; do gofmt -l $f; done\nwhile read line; do echo $line; done < input.txt")
+	want := []string{"test -f go.mod", "go build", "echo missing", "gofmt -l $f", "read line", "echo $line", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected commands %#v", got)
+	}
+}
+
+func TestParseRejectsUnsupportedOrInvalidInput(t *testing.T) {
+	for _, src := range []string{
+		"echo 'unterminated",
+		"go test &&",
+		"(echo hi",
+		"case $x in a) echo a;; esac",
+		"f() { rm -rf /; }",
+		"echo >",
+	} {
+		if _, err := Parse(src); err == nil {
+			t.Fatalf("expected parse error for %q", src)
+		}
+	}
+}
+
+func TestCompound(t *testing.T) {
+	cases := map[string]bool{
+		"go test ./...":          false,
+		"go test ./... | head":   true,
+		"echo hi > out.txt":      true,
+		"echo $(date)":           true,
+		"git commit -m 'a && b'": false,
+	}
+	for src, want := range cases {
+		script, err := Parse(src)
+		if err != nil {
+			t.Fatalf("parse %q failed: %v", src, err)
+		}
+		if script.Compound() != want {
+			t.Fatalf("Compound(%q) = %t, want %t", src, script.Compound(), want)
+		}
+	}
+}
+
+func TestOutsideWrites(t *testing.T) {
+	script, err := Parse("echo a > out.txt; echo b > ../escape.txt; echo c >> /tmp/extra/log; echo d > /dev/null; echo e > $TARGET; echo f 2>&1; echo g > /etc/passwd")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	outside := OutsideWrites(script, "/work/project", "/tmp/extra")
+	targets := []string{}
+	for _, redirect := range outside {
+		targets = append(targets, redirect.Target)
+	}
+	if !reflect.DeepEqual(targets, []string{"../escape.txt", "$TARGET", "/etc/passwd"}) {
+		t.Fatalf("unexpected outside targets %#v", targets)
+	}
+}
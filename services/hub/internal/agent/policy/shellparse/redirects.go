@@ -0,0 +1,81 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package shellparse
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var harmlessWriteTargets = map[string]struct{}{
+	"/dev/null":   {},
+	"/dev/stdout": {},
+	"/dev/stderr": {},
+	"/dev/tty":    {},
+}
+
+// OutsideWrites returns write redirections whose targets resolve outside
+// workingDir and allowedDirs. Targets produced by expansions are included
+// because they cannot be resolved statically. Relative targets are treated as
+// inside when workingDir is empty.
+func OutsideWrites(script Script, workingDir string, allowedDirs ...string) []Redirect {
+	roots := make([]string, 0, 1+len(allowedDirs))
+	for _, item := range append([]string{workingDir}, allowedDirs...) {
+		trimmed := strings.TrimSpace(item)
+		if trimmed == "" {
+			continue
+		}
+		if absolute, err := filepath.Abs(filepath.Clean(trimmed)); err == nil {
+			roots = append(roots, absolute)
+		}
+	}
+
+	out := make([]Redirect, 0)
+	for _, redirect := range script.Redirects() {
+		if !redirect.WritesFile() {
+			continue
+		}
+		if redirect.Dynamic {
+			out = append(out, redirect)
+			continue
+		}
+		target := strings.TrimSpace(redirect.Target)
+		if _, ok := harmlessWriteTargets[target]; ok || strings.HasPrefix(target, "/dev/fd/") {
+			continue
+		}
+		if target == "~" || strings.HasPrefix(target, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				out = append(out, redirect)
+				continue
+			}
+			target = filepath.Join(home, strings.TrimPrefix(target, "~"))
+		}
+		if !filepath.IsAbs(target) {
+			if len(roots) == 0 {
+				continue
+			}
+			target = filepath.Join(roots[0], target)
+		}
+		if !withinAny(filepath.Clean(target), roots) {
+			out = append(out, redirect)
+		}
+	}
+	return out
+}
+
+func withinAny(target string, roots []string) bool {
+	for _, root := range roots {
+		rel, err := filepath.Rel(root, target)
+		if err != nil {
+			continue
+		}
+		if rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))) {
+			return true
+		}
+	}
+	return false
+}
@@ -59,6 +59,14 @@ type Dependencies struct {
 type Request struct {
 	SessionID core.SessionID
 	Trigger   Trigger
+	// Instructions are passed to a RequestSummarizer, for example the text
+	// after /compact.
+	Instructions string
+	// Model is the session's model configuration for a RequestSummarizer.
+	Model *core.RuntimeModelConfig
+	// WindowTokens overrides Config.WindowTokens for automatic compaction,
+	// for example with the context window of the session's model.
+	WindowTokens int
 }
 
 // Result reports one compaction outcome.
@@ -68,6 +76,12 @@ type Result struct {
 	KeptCount      int
 	Summary        string
 	SummaryCursor  int64
+	// UsageTokens is what the summarizer spent; it is also added to the
+	// session's SummaryUsageTokens.
+	UsageTokens int
+	// FallbackReason is set when the RequestSummarizer failed and the
+	// transcript summary was used instead.
+	FallbackReason string
 }
 
 // Message is one in-memory conversational unit tracked for compaction.
@@ -83,6 +97,8 @@ type SessionSnapshot struct {
 	Messages    []Message
 	TotalTokens int
 	Summary     string
+	// SummaryUsageTokens is the total the session spent on summaries.
+	SummaryUsageTokens int
 }
 
 // Summarizer reduces old conversational messages into one summary fragment.
@@ -107,6 +123,9 @@ type sessionState struct {
 	total      int
 	summary    string
 	cursorMap  map[int64]int64
+	// compacting is set while a summarizer runs without the manager lock.
+	compacting   bool
+	summaryUsage int
 }
 
 // NewManager constructs a compaction manager with deterministic defaults.
@@ -164,16 +183,19 @@ func (m *Manager) AppendMessage(sessionID core.SessionID, role string, content s
 
 // MaybeCompact runs TriggerAuto compaction when usage crosses threshold.
 func (m *Manager) MaybeCompact(ctx context.Context, sessionID core.SessionID) (Result, bool, error) {
+	return m.MaybeCompactWith(ctx, Request{SessionID: sessionID})
+}
+
+// MaybeCompactWith is MaybeCompact with the model and instructions of req.
+func (m *Manager) MaybeCompactWith(ctx context.Context, req Request) (Result, bool, error) {
 	if m == nil {
 		return Result{}, false, nil
 	}
-	if !m.shouldAutoCompact(sessionID) {
+	if !m.shouldAutoCompact(req.SessionID, req.WindowTokens) {
 		return Result{}, false, nil
 	}
-	result, err := m.Compact(ctx, Request{
-		SessionID: sessionID,
-		Trigger:   TriggerAuto,
-	})
+	req.Trigger = TriggerAuto
+	result, err := m.Compact(ctx, req)
 	if err != nil {
 		return Result{}, false, err
 	}
@@ -195,19 +217,22 @@ func (m *Manager) Compact(ctx context.Context, req Request) (Result, error) {
 	trigger := normalizeTrigger(req.Trigger)
 
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	state := m.ensureSessionLocked(normalizedSession)
 	keepRecent := m.cfg.KeepRecentMessages
-	if len(state.messages) <= keepRecent {
+	if len(state.messages) <= keepRecent || state.compacting {
+		m.mu.Unlock()
 		return Result{Trigger: trigger}, nil
 	}
 	if err := m.dispatchPreCompactLocked(ctx, normalizedSession, trigger); err != nil {
+		m.mu.Unlock()
 		return Result{}, err
 	}
 
 	compactedMessages := cloneMessages(state.messages[:len(state.messages)-keepRecent])
-	keptMessages := cloneMessages(state.messages[len(state.messages)-keepRecent:])
+	compactedCursors := make(map[int64]struct{}, len(compactedMessages))
+	for _, item := range compactedMessages {
+		compactedCursors[item.Cursor] = struct{}{}
+	}
 	summarizeInput := make([]Message, 0, len(compactedMessages)+1)
 	if strings.TrimSpace(state.summary) != "" {
 		summarizeInput = append(summarizeInput, Message{
@@ -217,11 +242,33 @@ func (m *Manager) Compact(ctx context.Context, req Request) (Result, error) {
 		})
 	}
 	summarizeInput = append(summarizeInput, compactedMessages...)
-	summary, err := m.summarizer.Summarize(ctx, summarizeInput)
+	// The summarizer may call a model, so it runs without the lock; messages
+	// appended meanwhile are kept after the summary.
+	state.compacting = true
+	m.mu.Unlock()
+
+	generated, fallbackReason, err := m.summarize(ctx, SummaryRequest{
+		SessionID:    normalizedSession,
+		Trigger:      trigger,
+		Messages:     summarizeInput,
+		Instructions: strings.TrimSpace(req.Instructions),
+		Model:        req.Model,
+	})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state.compacting = false
 	if err != nil {
-		return Result{}, fmt.Errorf("summarize compacted messages: %w", err)
+		return Result{}, err
+	}
+	state.summaryUsage += generated.UsageTokens
+	keptMessages := make([]Message, 0, keepRecent)
+	for _, item := range state.messages {
+		if _, compacted := compactedCursors[item.Cursor]; !compacted {
+			keptMessages = append(keptMessages, item)
+		}
 	}
-	summary = strings.TrimSpace(summary)
+	summary := strings.TrimSpace(generated.Text)
 	if summary == "" {
 		summary = "Conversation context compacted."
 	}
@@ -257,9 +304,36 @@ func (m *Manager) Compact(ctx context.Context, req Request) (Result, error) {
 		KeptCount:      len(keptMessages),
 		Summary:        summary,
 		SummaryCursor:  summaryCursor,
+		UsageTokens:    generated.UsageTokens,
+		FallbackReason: fallbackReason,
 	}, nil
 }
 
+// summarize prefers a RequestSummarizer and falls back to the transcript
+// summary when it fails for any reason other than cancellation.
+func (m *Manager) summarize(ctx context.Context, req SummaryRequest) (Summary, string, error) {
+	requestSummarizer, ok := m.summarizer.(RequestSummarizer)
+	if !ok {
+		text, err := m.summarizer.Summarize(ctx, req.Messages)
+		if err != nil {
+			return Summary{}, "", fmt.Errorf("summarize compacted messages: %w", err)
+		}
+		return Summary{Text: text}, "", nil
+	}
+	generated, err := requestSummarizer.SummarizeRequest(ctx, req)
+	if err == nil && strings.TrimSpace(generated.Text) != "" {
+		return generated, "", nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return Summary{}, "", ctxErr
+	}
+	if err == nil {
+		err = errors.New("summarizer returned an empty summary")
+	}
+	text, _ := defaultSummarizer{}.Summarize(ctx, req.Messages)
+	return Summary{Text: text, UsageTokens: generated.UsageTokens}, err.Error(), nil
+}
+
 // ResolveCursor returns the post-compaction cursor for one original cursor.
 func (m *Manager) ResolveCursor(sessionID core.SessionID, original int64) (int64, bool) {
 	if m == nil {
@@ -297,9 +371,10 @@ func (m *Manager) Snapshot(sessionID core.SessionID) SessionSnapshot {
 		return SessionSnapshot{}
 	}
 	return SessionSnapshot{
-		Messages:    cloneMessages(state.messages),
-		TotalTokens: state.total,
-		Summary:     strings.TrimSpace(state.summary),
+		Messages:           cloneMessages(state.messages),
+		TotalTokens:        state.total,
+		Summary:            strings.TrimSpace(state.summary),
+		SummaryUsageTokens: state.summaryUsage,
 	}
 }
 
@@ -326,12 +401,14 @@ func (m *Manager) SummarySnippet(sessionID core.SessionID) string {
 	return "[Compacted Context Summary]\n" + summary
 }
 
-func (m *Manager) shouldAutoCompact(sessionID core.SessionID) bool {
+func (m *Manager) shouldAutoCompact(sessionID core.SessionID, window int) bool {
 	normalizedSession := normalizeSessionID(sessionID)
 	if normalizedSession == "" {
 		return false
 	}
-	window := m.cfg.WindowTokens
+	if window <= 0 {
+		window = m.cfg.WindowTokens
+	}
 	if window <= 0 {
 		return false
 	}
@@ -6,7 +6,9 @@ package compaction
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -95,6 +97,29 @@ func TestManagerMaybeCompactAutoByTokenThreshold(t *testing.T) {
 	}
 }
 
+func TestManagerMaybeCompactUsesRequestWindow(t *testing.T) {
+	sessionID := core.SessionID("sess_window")
+	manager := NewManager(Config{
+		WindowTokens:       1000,
+		AutoCompactPercent: 80,
+		KeepRecentMessages: 1,
+	}, Dependencies{
+		Summarizer: summarizerFunc(func(_ context.Context, _ []Message) (string, error) {
+			return "summary(window)", nil
+		}),
+	})
+	manager.AppendMessage(sessionID, "user", "m1", 50)
+	manager.AppendMessage(sessionID, "assistant", "m2", 50)
+
+	if _, compacted, err := manager.MaybeCompactWith(context.Background(), Request{SessionID: sessionID}); err != nil || compacted {
+		t.Fatalf("expected the configured window to hold, compacted=%v err=%v", compacted, err)
+	}
+	_, compacted, err := manager.MaybeCompactWith(context.Background(), Request{SessionID: sessionID, WindowTokens: 100})
+	if err != nil || !compacted {
+		t.Fatalf("expected the model window to trigger compaction, compacted=%v err=%v", compacted, err)
+	}
+}
+
 func TestManagerCompactManualDispatchesPreCompactHook(t *testing.T) {
 	sessionID := core.SessionID("sess_manual")
 	hookStub := &hookDispatcherStub{}
@@ -264,3 +289,81 @@ func TestManagerCompactionStabilityOver120Rounds(t *testing.T) {
 		t.Fatalf("manual compact after stress failed: %v", err)
 	}
 }
+
+type requestSummarizerFunc func(ctx context.Context, req SummaryRequest) (Summary, error)
+
+func (f requestSummarizerFunc) Summarize(ctx context.Context, messages []Message) (string, error) {
+	summary, err := f(ctx, SummaryRequest{Messages: messages})
+	return summary.Text, err
+}
+
+func (f requestSummarizerFunc) SummarizeRequest(ctx context.Context, req SummaryRequest) (Summary, error) {
+	return f(ctx, req)
+}
+
+func TestManagerRequestSummarizerReceivesInstructionsAndAccountsUsage(t *testing.T) {
+	sessionID := core.SessionID("sess_request")
+	var received SummaryRequest
+	manager := NewManager(Config{KeepRecentMessages: 1}, Dependencies{
+		Summarizer: requestSummarizerFunc(func(_ context.Context, req SummaryRequest) (Summary, error) {
+			received = req
+			return Summary{Text: "## Goals\nship it", UsageTokens: 42}, nil
+		}),
+	})
+	manager.AppendMessage(sessionID, "user", "plan the release", 5)
+	manager.AppendMessage(sessionID, "assistant", "release planned", 5)
+
+	model := &core.RuntimeModelConfig{ProviderName: "openai", ModelName: "gpt-test"}
+	result, err := manager.Compact(context.Background(), Request{
+		SessionID:    sessionID,
+		Trigger:      TriggerManual,
+		Instructions: "focus on open tasks",
+		Model:        model,
+	})
+	if err != nil {
+		t.Fatalf("compact failed: %v", err)
+	}
+	if received.Instructions != "focus on open tasks" || received.Model != model || len(received.Messages) != 1 {
+		t.Fatalf("unexpected summary request %#v", received)
+	}
+	if result.UsageTokens != 42 || result.FallbackReason != "" || result.Summary != "## Goals\nship it" {
+		t.Fatalf("unexpected result %#v", result)
+	}
+	if snapshot := manager.Snapshot(sessionID); snapshot.SummaryUsageTokens != 42 {
+		t.Fatalf("summary usage = %d, want 42", snapshot.SummaryUsageTokens)
+	}
+}
+
+func TestManagerFallsBackToTranscriptSummaryWhenRequestSummarizerFails(t *testing.T) {
+	sessionID := core.SessionID("sess_fallback")
+	manager := NewManager(Config{KeepRecentMessages: 1}, Dependencies{
+		Summarizer: requestSummarizerFunc(func(_ context.Context, _ SummaryRequest) (Summary, error) {
+			return Summary{UsageTokens: 3}, errors.New("model unavailable")
+		}),
+	})
+	manager.AppendMessage(sessionID, "user", "first question", 5)
+	manager.AppendMessage(sessionID, "assistant", "latest answer", 5)
+
+	result, err := manager.Compact(context.Background(), Request{SessionID: sessionID, Trigger: TriggerManual})
+	if err != nil {
+		t.Fatalf("compact failed: %v", err)
+	}
+	if result.FallbackReason != "model unavailable" || result.Summary != "user: first question" || result.UsageTokens != 3 {
+		t.Fatalf("unexpected fallback result %#v", result)
+	}
+}
+
+func TestBuildSummaryPromptUsesTemplateAndInstructions(t *testing.T) {
+	system, user := BuildSummaryPrompt(SummaryRequest{
+		Messages:     []Message{{Role: "user", Content: "edit main.go"}, {Role: "assistant", Content: "done"}},
+		Instructions: "keep file paths",
+	})
+	for _, want := range []string{"## Goals", "## Decisions", "## Files touched", "## Open tasks", "## Errors", "keep file paths"} {
+		if !strings.Contains(system, want) {
+			t.Fatalf("system prompt misses %q:\n%s", want, system)
+		}
+	}
+	if !strings.Contains(user, "[user]\nedit main.go") || !strings.Contains(user, "[assistant]\ndone") {
+		t.Fatalf("unexpected transcript %q", user)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package compaction
+
+import (
+	"context"
+	"strings"
+
+	"goyais/services/hub/internal/agent/core"
+)
+
+// SummaryTemplate is the structure model-backed summaries follow.
+const SummaryTemplate = `## Goals
+What the user is trying to achieve, including constraints they stated.
+
+## Decisions
+Choices made so far and the reasons for them.
+
+## Files touched
+Paths read, created or changed, with one line on what changed.
+
+## Open tasks
+Work that was started or promised but is not finished.
+
+## Errors
+Failures seen so far and whether they were resolved.`
+
+// SummaryRequest is everything a RequestSummarizer gets for one compaction.
+type SummaryRequest struct {
+	SessionID core.SessionID
+	Trigger   Trigger
+	// Messages starts with the previous summary, when there is one.
+	Messages []Message
+	// Instructions are extra directions, such as the text after /compact.
+	Instructions string
+	// Model is the session's model, used unless the summarizer has its own.
+	Model *core.RuntimeModelConfig
+}
+
+// Summary is the output of a RequestSummarizer.
+type Summary struct {
+	Text        string
+	UsageTokens int
+}
+
+// RequestSummarizer is a summarizer that sees the whole compaction request
+// and reports the tokens it spent. The manager prefers it over Summarize
+// and falls back to the transcript summary when it fails.
+type RequestSummarizer interface {
+	SummarizeRequest(ctx context.Context, req SummaryRequest) (Summary, error)
+}
+
+// BuildSummaryPrompt renders the system and user prompts that ask a model to
+// summarize req.Messages with SummaryTemplate.
+func BuildSummaryPrompt(req SummaryRequest) (string, string) {
+	system := "You compact the history of a coding agent session. " +
+		"Write a summary another agent can continue from without the original messages. " +
+		"Use exactly these sections, keep each one short, and write \"None.\" for an empty section:\n\n" +
+		SummaryTemplate
+	if instructions := strings.TrimSpace(req.Instructions); instructions != "" {
+		system += "\n\nAdditional instructions from the user:\n" + instructions
+	}
+
+	var transcript strings.Builder
+	transcript.WriteString("Summarize this conversation:\n")
+	for _, message := range req.Messages {
+		content := strings.TrimSpace(message.Content)
+		if content == "" {
+			continue
+		}
+		transcript.WriteString("\n[")
+		transcript.WriteString(normalizeRole(message.Role))
+		transcript.WriteString("]\n")
+		transcript.WriteString(content)
+		transcript.WriteString("\n")
+	}
+	return system, strings.TrimSpace(transcript.String())
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package loop
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"goyais/services/hub/internal/agent/core"
+	"goyais/services/hub/internal/agent/tools/catalog"
+	"goyais/services/hub/internal/agent/tools/checkpoint"
+	"goyais/services/hub/internal/agent/tools/executor"
+)
+
+// sessionCheckpoints returns the on-disk checkpoint store of one session,
+// opening it under the engine's checkpoint dir on first use. Manifests a
+// previous process left there are loaded, so a recovered session keeps its
+// timeline. It returns nil when the engine has no checkpoint dir.
+func (e *Engine) sessionCheckpoints(sessionID core.SessionID, workingDir string) (*checkpoint.DiskStore, error) {
+	if strings.TrimSpace(e.checkpointDir) == "" {
+		return nil, nil
+	}
+	e.checkpointMu.Lock()
+	defer e.checkpointMu.Unlock()
+	if store, ok := e.checkpointStores[sessionID]; ok {
+		return store, nil
+	}
+	store, err := checkpoint.NewDiskStore(workingDir, e.sessionCheckpointDir(sessionID), e.checkpointOptions)
+	if err != nil {
+		return nil, fmt.Errorf("open checkpoints of session %s: %w", sessionID, err)
+	}
+	e.checkpointStores[sessionID] = store
+	return store, nil
+}
+
+func (e *Engine) sessionCheckpointDir(sessionID core.SessionID) string {
+	return filepath.Join(e.checkpointDir, string(sessionID))
+}
+
+// forgetSessionCheckpoints drops a closed session's checkpoints from memory
+// and disk. Session IDs are only unique among the sessions an engine
+// knows, so the data must not outlive the session.
+func (e *Engine) forgetSessionCheckpoints(sessionID core.SessionID) {
+	if strings.TrimSpace(e.checkpointDir) == "" || sessionID == "" {
+		return
+	}
+	e.checkpointMu.Lock()
+	defer e.checkpointMu.Unlock()
+	delete(e.checkpointStores, sessionID)
+	_ = os.RemoveAll(e.sessionCheckpointDir(sessionID))
+}
+
+// runCheckpoints returns the checkpoint store a run snapshots edits into,
+// or nil when the engine keeps no checkpoints. A store that cannot be
+// opened fails the run's Write and Edit calls rather than letting them
+// change files without a checkpoint.
+func (e *Engine) runCheckpoints(run *runRuntime) core.CheckpointStore {
+	store, err := e.sessionCheckpoints(run.sessionID, run.workingDir)
+	switch {
+	case err != nil:
+		return unavailableCheckpoints{err: err}
+	case store == nil:
+		return nil
+	default:
+		return store
+	}
+}
+
+// unavailableCheckpoints is the store of a session whose checkpoint dir
+// could not be opened.
+type unavailableCheckpoints struct {
+	err error
+}
+
+func (s unavailableCheckpoints) Snapshot(context.Context, core.SnapshotRequest) (core.CheckpointID, error) {
+	return "", s.err
+}
+
+func (s unavailableCheckpoints) Restore(context.Context, core.CheckpointID) error {
+	return s.err
+}
+
+// Checkpoints returns the checkpoint timeline of one session, oldest first.
+// It is empty when the engine keeps no checkpoints.
+func (e *Engine) Checkpoints(ctx context.Context, sessionID string) ([]core.CheckpointInfo, error) {
+	normalizedSessionID := core.SessionID(strings.TrimSpace(sessionID))
+	e.mu.Lock()
+	session, exists := e.sessions[normalizedSessionID]
+	workingDir := ""
+	if exists {
+		workingDir = session.workingDir
+	}
+	e.mu.Unlock()
+	if !exists {
+		return nil, core.ErrSessionNotFound
+	}
+	store, err := e.sessionCheckpoints(normalizedSessionID, workingDir)
+	if err != nil {
+		return nil, err
+	}
+	if store == nil {
+		return []core.CheckpointInfo{}, nil
+	}
+	return store.ListCheckpoints(ctx, normalizedSessionID)
+}
+
+// checkpointingRunner snapshots the file a Write or Edit call changes before
+// running it, so the session can be rewound to the state before the edit.
+type checkpointingRunner struct {
+	executor.Runner
+	Store     core.CheckpointStore
+	SessionID core.SessionID
+}
+
+func (r checkpointingRunner) Execute(ctx context.Context, req executor.RunRequest) (map[string]any, error) {
+	name := strings.TrimSpace(req.Call.Name)
+	if r.Store != nil && (name == catalog.ToolWrite || name == catalog.ToolEdit) {
+		path, _ := req.Call.Input["path"].(string)
+		if path = strings.TrimSpace(path); path != "" {
+			if _, err := r.Store.Snapshot(ctx, core.SnapshotRequest{
+				SessionID: r.SessionID,
+				Paths:     []string{path},
+				Reason:    name + " " + path,
+			}); err != nil {
+				return nil, fmt.Errorf("checkpoint %s before %s: %w", path, name, err)
+			}
+		}
+	}
+	return r.Runner.Execute(ctx, req)
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package loop
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"goyais/services/hub/internal/agent/core"
+	"goyais/services/hub/internal/agent/tools/catalog"
+	"goyais/services/hub/internal/agent/tools/executor"
+)
+
+type writeRunnerFunc func(ctx context.Context, req executor.RunRequest) (map[string]any, error)
+
+func (f writeRunnerFunc) Execute(ctx context.Context, req executor.RunRequest) (map[string]any, error) {
+	return f(ctx, req)
+}
+
+func TestEngineCheckpointsWritesAndKeepsThemAcrossEngines(t *testing.T) {
+	workingDir := t.TempDir()
+	checkpointDir := t.TempDir()
+	target := filepath.Join(workingDir, "notes.txt")
+	if err := os.WriteFile(target, []byte("before"), 0o644); err != nil {
+		t.Fatalf("seed file: %v", err)
+	}
+
+	newEngine := func() *Engine {
+		return NewEngineWithDeps(Dependencies{
+			Executor: executorFunc(func(ctx context.Context, req ExecuteRequest) (ExecuteResult, error) {
+				runner := checkpointingRunner{
+					Runner: writeRunnerFunc(func(_ context.Context, _ executor.RunRequest) (map[string]any, error) {
+						return map[string]any{}, os.WriteFile(target, []byte("after"), 0o644)
+					}),
+					Store:     req.Checkpoints,
+					SessionID: req.SessionID,
+				}
+				_, err := runner.Execute(ctx, executor.RunRequest{Call: executor.ToolCall{
+					Name:  catalog.ToolWrite,
+					Input: map[string]any{"path": "notes.txt"},
+				}})
+				return ExecuteResult{Output: "ok"}, err
+			}),
+			CheckpointDir: checkpointDir,
+		})
+	}
+
+	engine := newEngine()
+	session, err := engine.StartSession(context.Background(), core.StartSessionRequest{WorkingDir: workingDir})
+	if err != nil {
+		t.Fatalf("start session: %v", err)
+	}
+	sub, err := engine.Subscribe(context.Background(), string(session.SessionID), "")
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer sub.Close()
+	runID, err := engine.Submit(context.Background(), string(session.SessionID), core.UserInput{Text: "write"})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	events := waitRunEventsUntilTerminal(t, sub.Events(), runID, 2*time.Second)
+	if last := events[len(events)-1].Type; last != core.RunEventTypeRunCompleted {
+		t.Fatalf("expected run to complete, got %s", last)
+	}
+
+	items, err := engine.Checkpoints(context.Background(), string(session.SessionID))
+	if err != nil {
+		t.Fatalf("list checkpoints: %v", err)
+	}
+	if len(items) != 1 || items[0].Reason != catalog.ToolWrite+" notes.txt" {
+		t.Fatalf("expected one Write checkpoint, got %#v", items)
+	}
+
+	restarted := newEngine()
+	again, err := restarted.StartSession(context.Background(), core.StartSessionRequest{WorkingDir: workingDir})
+	if err != nil {
+		t.Fatalf("start session after restart: %v", err)
+	}
+	if again.SessionID != session.SessionID {
+		t.Fatalf("expected the recovered session id %s, got %s", session.SessionID, again.SessionID)
+	}
+	items, err = restarted.Checkpoints(context.Background(), string(again.SessionID))
+	if err != nil {
+		t.Fatalf("list checkpoints after restart: %v", err)
+	}
+	if len(items) != 1 || items[0].ID == "" {
+		t.Fatalf("expected the checkpoint to survive a restart, got %#v", items)
+	}
+
+	restarted.CloseSession(context.Background(), string(again.SessionID))
+	if _, err := os.Stat(filepath.Join(checkpointDir, string(again.SessionID))); !os.IsNotExist(err) {
+		t.Fatalf("expected closing the session to delete its checkpoints, stat err=%v", err)
+	}
+}
+
+func TestEngineCheckpointsUnknownSession(t *testing.T) {
+	engine := NewEngineWithDeps(Dependencies{CheckpointDir: t.TempDir()})
+	if _, err := engine.Checkpoints(context.Background(), "sess_missing"); err != core.ErrSessionNotFound {
+		t.Fatalf("expected ErrSessionNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package loop
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"goyais/services/hub/internal/agent/core"
+	"goyais/services/hub/internal/agent/runtime/compaction"
+	"goyais/services/hub/internal/agent/runtime/model"
+)
+
+const defaultSummaryMaxOutputTokens = 2048
+
+// ModelSummarizer writes compaction summaries with a model, following
+// compaction.SummaryTemplate. It uses Model when set, otherwise the
+// session's model from the request, otherwise the environment model.
+// GOYAIS_AGENT_COMPACT_MODEL_NAME replaces the model name of the session
+// or environment model, so a cheaper model on the same provider can be used.
+type ModelSummarizer struct {
+	Model           *core.RuntimeModelConfig
+	MaxOutputTokens int
+}
+
+var _ compaction.RequestSummarizer = (*ModelSummarizer)(nil)
+
+// NewModelSummarizer returns a summarizer that uses override, when not nil,
+// instead of the session's model.
+func NewModelSummarizer(override *core.RuntimeModelConfig) *ModelSummarizer {
+	return &ModelSummarizer{Model: override, MaxOutputTokens: defaultSummaryMaxOutputTokens}
+}
+
+// Summarize implements compaction.Summarizer without instructions or a
+// session model.
+func (s *ModelSummarizer) Summarize(ctx context.Context, messages []compaction.Message) (string, error) {
+	summary, err := s.SummarizeRequest(ctx, compaction.SummaryRequest{Messages: messages})
+	return summary.Text, err
+}
+
+// SummarizeRequest asks the resolved model for one structured summary.
+func (s *ModelSummarizer) SummarizeRequest(ctx context.Context, req compaction.SummaryRequest) (compaction.Summary, error) {
+	config, configured := s.resolveConfig(req.Model)
+	if !configured {
+		return compaction.Summary{}, model.ErrProviderMissing
+	}
+	maxTokens := s.MaxOutputTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultSummaryMaxOutputTokens
+	}
+	provider, err := newModelProvider(samplingModelConfig(config, maxTokens), defaultModelHTTPClient(config.TimeoutMS), nil)
+	if err != nil {
+		return compaction.Summary{}, err
+	}
+	systemPrompt, userInput := compaction.BuildSummaryPrompt(req)
+	turn, err := provider.Turn(ctx, model.TurnRequest{
+		SystemPrompt: systemPrompt,
+		UserInput:    userInput,
+	})
+	usage := sumUsageTokens(turn.Usage)
+	if err != nil {
+		return compaction.Summary{UsageTokens: usage}, err
+	}
+	return compaction.Summary{
+		Text:        strings.TrimSpace(turn.AssistantText),
+		UsageTokens: usage,
+	}, nil
+}
+
+func (s *ModelSummarizer) resolveConfig(sessionModel *core.RuntimeModelConfig) (resolvedModelConfig, bool) {
+	if s != nil && s.Model != nil {
+		return resolveModelConfigFromRuntimeConfig(core.RuntimeConfig{Model: *s.Model})
+	}
+	var (
+		config     resolvedModelConfig
+		configured bool
+	)
+	if sessionModel != nil {
+		config, configured = resolveModelConfigFromRuntimeConfig(core.RuntimeConfig{Model: *sessionModel})
+	} else {
+		config, configured = resolveModelConfigFromEnv()
+	}
+	if name := strings.TrimSpace(os.Getenv("GOYAIS_AGENT_COMPACT_MODEL_NAME")); configured && name != "" {
+		config.ModelName = name
+	}
+	return config, configured
+}
+
+// parseCompactCommand reports whether text is /compact and returns the
+// instructions that follow it.
+func parseCompactCommand(text string) (string, bool) {
+	trimmed := strings.TrimSpace(text)
+	const command = "/compact"
+	if len(trimmed) < len(command) || !strings.EqualFold(trimmed[:len(command)], command) {
+		return "", false
+	}
+	rest := trimmed[len(command):]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' && rest[0] != '\n' {
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// runModelConfig returns the model the run was submitted with, if any.
+func runModelConfig(run *runRuntime) *core.RuntimeModelConfig {
+	if run == nil || run.input.RuntimeConfig == nil {
+		return nil
+	}
+	config := run.input.RuntimeConfig.Model
+	return &config
+}
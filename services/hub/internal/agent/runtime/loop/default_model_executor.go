@@ -15,10 +15,13 @@ import (
 	"time"
 
 	capabilitygraph "goyais/services/hub/internal/agent/capability"
+	"goyais/services/hub/internal/agent/context/settings"
 	"goyais/services/hub/internal/agent/core"
 	mcpext "goyais/services/hub/internal/agent/extensions/mcp"
 	"goyais/services/hub/internal/agent/policy"
 	"goyais/services/hub/internal/agent/policy/approval"
+	"goyais/services/hub/internal/agent/policy/redaction"
+	"goyais/services/hub/internal/agent/policy/rulesdsl"
 	sandboxpolicy "goyais/services/hub/internal/agent/policy/sandbox"
 	"goyais/services/hub/internal/agent/runtime/model"
 	"goyais/services/hub/internal/agent/runtime/model/codec"
@@ -26,6 +29,7 @@ import (
 	"goyais/services/hub/internal/agent/tools/catalog"
 	"goyais/services/hub/internal/agent/tools/executor"
 	"goyais/services/hub/internal/agent/tools/interaction"
+	"goyais/services/hub/internal/agent/tools/ossandbox"
 	"goyais/services/hub/internal/agent/tools/registry"
 	runnertools "goyais/services/hub/internal/agent/tools/runner"
 	"goyais/services/hub/internal/agent/tools/spec"
@@ -44,14 +48,17 @@ type resolvedModelConfig struct {
 	Params        map[string]any
 	TimeoutMS     int
 	MaxModelTurns int
+	Vision        bool
 }
 
 type resolvedToolingConfig struct {
-	PermissionMode           string
-	RulesDSL                 string
-	MCPServers               []core.MCPServerConfig
-	AlwaysLoadedCapabilities []core.CapabilityDescriptor
-	SearchableCapabilities   []core.CapabilityDescriptor
+	PermissionMode            string
+	RulesDSL                  string
+	MCPServers                []core.MCPServerConfig
+	AlwaysLoadedCapabilities  []core.CapabilityDescriptor
+	SearchableCapabilities    []core.CapabilityDescriptor
+	PermissionPromptTool      string
+	PermissionPromptTimeoutMS int
 }
 
 func executeWithConfiguredModel(ctx context.Context, req ExecuteRequest) (ExecuteResult, bool, error) {
@@ -64,7 +71,7 @@ func executeWithConfiguredModel(ctx context.Context, req ExecuteRequest) (Execut
 
 	var mcpManager *mcpext.ClientManager
 	if len(tooling.MCPServers) > 0 {
-		mcpManager = mcpext.NewClientManager(convertToMCPExtServers(tooling.MCPServers), time.Duration(config.TimeoutMS)*time.Millisecond)
+		mcpManager = mcpext.NewSessionClientManager(mcpext.DefaultSessionPool(), string(req.SessionID), convertToMCPExtServers(tooling.MCPServers), time.Duration(config.TimeoutMS)*time.Millisecond)
 	}
 
 	toolSpecs := capabilitygraph.ToToolSpecs(tooling.AlwaysLoadedCapabilities)
@@ -72,71 +79,125 @@ func executeWithConfiguredModel(ctx context.Context, req ExecuteRequest) (Execut
 	if err != nil {
 		return ExecuteResult{}, true, err
 	}
-	ruleLines := splitDSLLines(tooling.RulesDSL)
+	ruleStore := approval.NewRuleStore(req.WorkingDir, "").WithSessions(req.SessionApprovals)
+	ruleLines, ignoredRules, err := resolvePermissionRuleLines(tooling.RulesDSL, req.WorkingDir, ruleStore, req.SessionID)
+	if err != nil {
+		return ExecuteResult{}, true, err
+	}
+	emitIgnoredPermissionRules(req.EmitOutputDelta, ignoredRules)
 	permissionGate, err := policy.NewGateFromLines(ruleLines)
 	if err != nil {
 		return ExecuteResult{}, true, err
 	}
 
+	modeState := newRunModeState(tooling.PermissionMode)
 	waiters := runtimeApprovalWaiters{
+		SessionID:          req.SessionID,
 		RunID:              req.RunID,
 		Router:             req.ApprovalRouter,
+		Gate:               permissionGate,
+		RuleStore:          ruleStore,
 		Specs:              toolRegistry,
 		Capabilities:       indexCapabilities(append(tooling.AlwaysLoadedCapabilities, tooling.SearchableCapabilities...)),
 		EmitOutputDelta:    req.EmitOutputDelta,
 		EmitApprovalNeeded: req.EmitApprovalNeeded,
 		SetRunState:        req.SetRunState,
+		PermissionPrompt:   newPermissionPromptDelegate(req.SessionID, tooling.PermissionPromptTool, tooling.PermissionPromptTimeoutMS, req.WorkingDir, tooling.MCPServers),
+		Mode:               modeState,
+		SetPermissionMode:  req.SetPermissionMode,
+	}
+	var provider model.Provider
+	toolRunner := runnertools.NewWithSearchIndex(mcpManager, capabilitygraph.NewSearchIndex(tooling.SearchableCapabilities))
+	sandboxConfig, err := ossandbox.LoadProjectConfig(req.WorkingDir, "")
+	if err != nil {
+		return ExecuteResult{}, true, err
+	}
+	toolRunner.SetOSSandbox(sandboxConfig)
+	redactionConfig, err := redaction.LoadProjectConfig(req.WorkingDir, "")
+	if err != nil {
+		return ExecuteResult{}, true, err
+	}
+	redactor := redaction.New(redactionConfig)
+	loadCapabilities := func(items []core.CapabilityDescriptor) {
+		loaded := registerLoadedCapabilities(toolRegistry, items)
+		if loader, ok := provider.(model.ToolLoader); ok && len(loaded) > 0 {
+			loader.LoadTools(convertToCodecToolSpecs(loaded))
+		}
+	}
+	toolRunner.SetCapabilityLoader(loadCapabilities)
+	toolRunner.SetProgressReporter(func(callID string, toolName string, update mcpext.ProgressUpdate) {
+		emitToolProgress(req.EmitOutputDelta, callID, toolName, update)
+	})
+	var toolHooks core.HookDispatcher
+	if req.HookDispatcher != nil {
+		toolHooks = runScopedHookDispatcher{Dispatcher: req.HookDispatcher, SessionID: req.SessionID, RunID: req.RunID, WorkingDir: req.WorkingDir}
 	}
 	pipeline := executor.NewPipeline(executor.Dependencies{
-		Runner:           runnertools.NewWithSearchable(mcpManager, tooling.SearchableCapabilities),
+		Runner:           checkpointingRunner{Runner: toolRunner, Store: req.Checkpoints, SessionID: req.SessionID},
 		Specs:            toolRegistry,
 		SandboxGate:      runtimeSandboxGate{Evaluator: sandboxpolicy.NewEvaluator(nil)},
 		PermissionGate:   permissionGate,
 		ApprovalWaiter:   waiters,
 		UserAnswerWaiter: waiters,
+		PlanWaiter:       waiters,
+		OutputRedactor:   runtimeOutputRedactor{Redactor: redactor, EmitOutputDelta: req.EmitOutputDelta},
+		HookDispatcher:   toolHooks,
 	})
 	orderedToolSpecs := toolRegistry.ListOrdered()
 	codecToolSpecs := convertToCodecToolSpecs(orderedToolSpecs)
 	toolInvoker := runtimePipelineToolInvoker{
-		Pipeline:        pipeline,
-		Specs:           toolRegistry,
-		Capabilities:    indexCapabilities(append(tooling.AlwaysLoadedCapabilities, tooling.SearchableCapabilities...)),
-		SessionMode:     tooling.PermissionMode,
-		SafeMode:        false,
-		ToolContext:     executor.ToolContext{WorkingDir: strings.TrimSpace(req.WorkingDir)},
+		Pipeline:     pipeline,
+		Specs:        toolRegistry,
+		Capabilities: indexCapabilities(append(tooling.AlwaysLoadedCapabilities, tooling.SearchableCapabilities...)),
+		SessionMode:  tooling.PermissionMode,
+		Mode:         modeState,
+		SafeMode:     false,
+		ToolContext: executor.ToolContext{
+			WorkingDir:            strings.TrimSpace(req.WorkingDir),
+			AdditionalDirectories: append([]string(nil), req.AdditionalDirectories...),
+		},
 		EmitOutputDelta: req.EmitOutputDelta,
 	}
 
-	var provider model.Provider
 	client := defaultModelHTTPClient(config.TimeoutMS)
-	switch config.ProviderName {
-	case "openai", "openai-compatible", "openai_compatible":
-		provider = providers.NewOpenAI(providers.OpenAIConfig{
-			Endpoint:    config.Endpoint,
-			APIKey:      config.APIKey,
-			Model:       config.ModelName,
-			Params:      cloneMapAny(config.Params),
-			ToolSchemas: codec.BuildOpenAIToolSchemas(codecToolSpecs),
-			HTTPClient:  client,
+	provider, err = newModelProvider(config, client, codecToolSpecs)
+	if err != nil {
+		return ExecuteResult{}, true, err
+	}
+
+	if mcpManager != nil {
+		// Tools a server adds mid-run become callable on the next model turn.
+		stopWatching := mcpManager.OnToolsChanged(func(server string, tools []string) {
+			loadCapabilities(changedMCPToolDescriptors(tooling.MCPServers, server, tools))
 		})
-	case "google", "gemini":
-		provider = providers.NewGoogle(providers.GoogleConfig{
-			Endpoint:   config.Endpoint,
-			APIKey:     config.APIKey,
-			Model:      config.ModelName,
-			Params:     cloneMapAny(config.Params),
-			Tools:      codec.BuildGoogleToolDeclarations(codecToolSpecs),
-			HTTPClient: client,
+		defer stopWatching()
+
+		samplingPolicies, err := mcpext.LoadSamplingPolicies(req.WorkingDir, "")
+		if err != nil {
+			return ExecuteResult{}, true, err
+		}
+		stopServing := mcpManager.ServeServerRequests(mcpServerRequests{
+			SessionID: req.SessionID,
+			RunID:     req.RunID,
+			Policies:  samplingPolicies,
+			Hooks:     toolHooks,
+			Waiters:   waiters,
+			Model:     config.ModelName,
+			NewProvider: func(maxTokens int) (model.Provider, error) {
+				return newModelProvider(samplingModelConfig(config, maxTokens), client, nil)
+			},
+			EmitOutputDelta: req.EmitOutputDelta,
 		})
-	default:
-		return ExecuteResult{}, true, fmt.Errorf("unsupported model provider %q", config.ProviderName)
+		defer stopServing()
 	}
 
+	userInput, detections := redactor.Redact(req.Input.Text, "user_input")
+	emitSecretDetections(req.EmitOutputDelta, "", "", detections)
 	loopResult, err := model.RunLoop(ctx, model.LoopRequest{
 		Provider:      provider,
 		ToolInvoker:   toolInvoker,
 		SystemPrompt:  req.PromptContext.SystemPrompt,
-		UserInput:     req.Input.Text,
+		UserInput:     userInput,
 		MaxModelTurns: config.MaxModelTurns,
 	})
 	if err != nil {
@@ -149,6 +210,35 @@ func executeWithConfiguredModel(ctx context.Context, req ExecuteRequest) (Execut
 	}, true, nil
 }
 
+// newModelProvider builds a provider for config. MCP sampling passes nil
+// tools so server-requested completions cannot call back into tools.
+func newModelProvider(config resolvedModelConfig, client *http.Client, tools []codec.ToolSpec) (model.Provider, error) {
+	switch config.ProviderName {
+	case "openai", "openai-compatible", "openai_compatible":
+		return providers.NewOpenAI(providers.OpenAIConfig{
+			Endpoint:    config.Endpoint,
+			APIKey:      config.APIKey,
+			Model:       config.ModelName,
+			Params:      cloneMapAny(config.Params),
+			ToolSchemas: codec.BuildOpenAIToolSchemas(tools),
+			HTTPClient:  client,
+			Vision:      config.Vision,
+		}), nil
+	case "google", "gemini":
+		return providers.NewGoogle(providers.GoogleConfig{
+			Endpoint:   config.Endpoint,
+			APIKey:     config.APIKey,
+			Model:      config.ModelName,
+			Params:     cloneMapAny(config.Params),
+			Tools:      codec.BuildGoogleToolDeclarations(tools),
+			HTTPClient: client,
+			Vision:     config.Vision,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unsupported model provider %q", config.ProviderName)
+	}
+}
+
 func resolveModelConfig(input core.UserInput) (resolvedModelConfig, bool) {
 	if input.RuntimeConfig != nil {
 		return resolveModelConfigFromRuntimeConfig(*input.RuntimeConfig)
@@ -180,6 +270,7 @@ func resolveModelConfigFromRuntimeConfig(config core.RuntimeConfig) (resolvedMod
 		Params:        cloneMapAny(config.Model.Params),
 		TimeoutMS:     timeoutMS,
 		MaxModelTurns: maxModelTurns,
+		Vision:        config.Model.Vision,
 	}, true
 }
 
@@ -197,6 +288,7 @@ func resolveModelConfigFromEnv() (resolvedModelConfig, bool) {
 		Params:        map[string]any{},
 		TimeoutMS:     readEnvInt("GOYAIS_AGENT_MODEL_TIMEOUT_MS", defaultModelTimeoutMS),
 		MaxModelTurns: readEnvInt("GOYAIS_AGENT_MAX_MODEL_TURNS", defaultModelMaxTurns),
+		Vision:        readEnvBool("GOYAIS_AGENT_MODEL_VISION"),
 	}, true
 }
 
@@ -212,6 +304,11 @@ func readEnvInt(key string, fallback int) int {
 	return parsed
 }
 
+func readEnvBool(key string) bool {
+	parsed, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(key)))
+	return err == nil && parsed
+}
+
 func defaultModelHTTPClient(timeoutMS int) *http.Client {
 	effectiveTimeoutMS := timeoutMS
 	if effectiveTimeoutMS <= 0 {
@@ -267,25 +364,33 @@ func parseInt(value any) (int, bool) {
 }
 
 func resolveToolingConfig(input core.UserInput) resolvedToolingConfig {
+	var tooling resolvedToolingConfig
 	if input.RuntimeConfig != nil {
-		return resolveToolingConfigFromRuntimeConfig(*input.RuntimeConfig)
+		tooling = resolveToolingConfigFromRuntimeConfig(*input.RuntimeConfig)
+	} else {
+		tooling = resolvedToolingConfig{
+			PermissionMode:           string(core.PermissionModeDefault),
+			RulesDSL:                 "",
+			MCPServers:               nil,
+			AlwaysLoadedCapabilities: capabilitygraph.BuildBuiltinToolDescriptors(catalog.BuiltinToolSpecs()),
+			SearchableCapabilities:   nil,
+		}
 	}
-	return resolvedToolingConfig{
-		PermissionMode:           string(core.PermissionModeDefault),
-		RulesDSL:                 "",
-		MCPServers:               nil,
-		AlwaysLoadedCapabilities: capabilitygraph.BuildBuiltinToolDescriptors(catalog.BuiltinToolSpecs()),
-		SearchableCapabilities:   nil,
+	if tooling.PermissionPromptTool == "" {
+		tooling.PermissionPromptTool = strings.TrimSpace(input.Metadata[metadataPermissionPromptTool])
 	}
+	return tooling
 }
 
 func resolveToolingConfigFromRuntimeConfig(config core.RuntimeConfig) resolvedToolingConfig {
 	return resolvedToolingConfig{
-		PermissionMode:           string(config.Tooling.PermissionMode),
-		RulesDSL:                 strings.TrimSpace(config.Tooling.RulesDSL),
-		MCPServers:               cloneCoreMCPServers(config.Tooling.MCPServers),
-		AlwaysLoadedCapabilities: cloneCapabilityDescriptors(config.Tooling.AlwaysLoadedCapabilities),
-		SearchableCapabilities:   cloneCapabilityDescriptors(config.Tooling.SearchableCapabilities),
+		PermissionMode:            string(config.Tooling.PermissionMode),
+		RulesDSL:                  strings.TrimSpace(config.Tooling.RulesDSL),
+		MCPServers:                cloneCoreMCPServers(config.Tooling.MCPServers),
+		AlwaysLoadedCapabilities:  cloneCapabilityDescriptors(config.Tooling.AlwaysLoadedCapabilities),
+		SearchableCapabilities:    cloneCapabilityDescriptors(config.Tooling.SearchableCapabilities),
+		PermissionPromptTool:      strings.TrimSpace(config.Tooling.PermissionPromptTool),
+		PermissionPromptTimeoutMS: config.Tooling.PermissionPromptTimeoutMS,
 	}
 }
 
@@ -299,6 +404,57 @@ func buildToolRegistry(items []spec.ToolSpec) (*registry.Registry, error) {
 	return result, nil
 }
 
+// registerLoadedCapabilities adds ToolSearch-selected capabilities to the run
+// registry and returns the specs that were not registered before.
+func registerLoadedCapabilities(target *registry.Registry, items []core.CapabilityDescriptor) []spec.ToolSpec {
+	loaded := []spec.ToolSpec{}
+	for _, item := range capabilitygraph.ToToolSpecs(items) {
+		if _, exists := target.Lookup(item.Name); exists {
+			continue
+		}
+		if err := target.Register(item); err != nil {
+			continue
+		}
+		loaded = append(loaded, item)
+	}
+	return loaded
+}
+
+// changedMCPToolDescriptors builds descriptors for the tools server now
+// lists. Tools it stopped listing stay registered; calling them fails with
+// the server's own error.
+func changedMCPToolDescriptors(servers []core.MCPServerConfig, server string, tools []string) []core.CapabilityDescriptor {
+	for _, item := range servers {
+		if strings.TrimSpace(item.Name) != server {
+			continue
+		}
+		item.Tools = append([]string(nil), tools...)
+		return capabilitygraph.BuildMCPToolDescriptors([]core.MCPServerConfig{item})
+	}
+	return nil
+}
+
+// emitToolProgress reports one MCP progress notification as a tool_progress
+// output delta.
+func emitToolProgress(emit func(payload core.OutputDeltaPayload), callID string, toolName string, update mcpext.ProgressUpdate) {
+	if emit == nil {
+		return
+	}
+	output := map[string]any{"progress": update.Progress}
+	if update.Total > 0 {
+		output["total"] = update.Total
+	}
+	if update.Message != "" {
+		output["message"] = update.Message
+	}
+	emit(core.OutputDeltaPayload{
+		Stage:  "tool_progress",
+		CallID: strings.TrimSpace(callID),
+		Name:   strings.TrimSpace(toolName),
+		Output: output,
+	})
+}
+
 func convertToCodecToolSpecs(items []spec.ToolSpec) []codec.ToolSpec {
 	if len(items) == 0 {
 		return nil
@@ -336,10 +492,12 @@ func splitDSLLines(content string) []string {
 }
 
 type runtimePipelineToolInvoker struct {
-	Pipeline        *executor.Pipeline
-	Specs           spec.Resolver
-	Capabilities    map[string]core.CapabilityDescriptor
-	SessionMode     string
+	Pipeline     *executor.Pipeline
+	Specs        spec.Resolver
+	Capabilities map[string]core.CapabilityDescriptor
+	SessionMode  string
+	// Mode, when set, overrides SessionMode with the live run mode.
+	Mode            *runModeState
 	SafeMode        bool
 	ToolContext     executor.ToolContext
 	EmitOutputDelta func(payload core.OutputDeltaPayload)
@@ -360,9 +518,13 @@ func (i runtimePipelineToolInvoker) Execute(ctx context.Context, calls []codec.T
 		execCalls = append(execCalls, normalizedCall)
 		i.emitToolCallDelta(normalizedCall)
 	}
+	sessionMode := i.SessionMode
+	if i.Mode != nil {
+		sessionMode = i.Mode.Get()
+	}
 	results, err := i.Pipeline.ExecuteBatch(ctx, executor.ExecuteBatchRequest{
 		Calls:       execCalls,
-		SessionMode: strings.TrimSpace(i.SessionMode),
+		SessionMode: strings.TrimSpace(sessionMode),
 		SafeMode:    i.SafeMode,
 		ToolContext: i.ToolContext,
 	})
@@ -375,6 +537,7 @@ func (i runtimePipelineToolInvoker) Execute(ctx context.Context, calls []codec.T
 		nextTurn = append(nextTurn, codec.ToolResultForNextTurn{
 			CallID: strings.TrimSpace(item.CallID),
 			Text:   encodeToolResultForNextTurn(item),
+			Images: toolResultImages(item.Content),
 		})
 	}
 	return nextTurn, nil
@@ -472,6 +635,22 @@ func (i runtimePipelineToolInvoker) lookupCapability(toolName string) (core.Capa
 	return item, exists
 }
 
+// toolResultImages picks the image parts a vision-capable provider can
+// receive next to the tool result text.
+func toolResultImages(parts []core.ToolContentPart) []codec.ImagePart {
+	images := []codec.ImagePart{}
+	for _, part := range parts {
+		if part.Type != core.ToolContentImage || strings.TrimSpace(part.Data) == "" {
+			continue
+		}
+		images = append(images, codec.ImagePart{MIMEType: part.MIMEType, Data: part.Data})
+	}
+	if len(images) == 0 {
+		return nil
+	}
+	return images
+}
+
 func encodeToolResultForNextTurn(result executor.ExecuteSingleResult) string {
 	payload := map[string]any{
 		"call_id": strings.TrimSpace(result.CallID),
@@ -492,18 +671,82 @@ func encodeToolResultForNextTurn(result executor.ExecuteSingleResult) string {
 }
 
 type runtimeApprovalWaiters struct {
+	SessionID          core.SessionID
 	RunID              core.RunID
 	Router             *approval.Router
+	Gate               *policy.Gate
+	RuleStore          approval.RuleStore
 	Specs              spec.Resolver
 	Capabilities       map[string]core.CapabilityDescriptor
 	EmitOutputDelta    func(payload core.OutputDeltaPayload)
 	EmitApprovalNeeded func(payload core.ApprovalNeededPayload)
 	SetRunState        func(state core.RunState)
+	PermissionPrompt   *permissionPromptDelegate
+	Mode               *runModeState
+	SetPermissionMode  func(from core.PermissionMode, to core.PermissionMode, planID string)
+}
+
+// WaitForApprovalDecision implements executor.ApprovalDecisionWaiter. With a
+// permission prompt tool configured the decision is delegated to it;
+// otherwise it waits for a human control action.
+func (w runtimeApprovalWaiters) WaitForApprovalDecision(ctx context.Context, req executor.ApprovalRequest) (executor.ApprovalDecision, error) {
+	if w.PermissionPrompt == nil {
+		action, feedback, err := w.waitForHumanApproval(ctx, req)
+		return executor.ApprovalDecision{Action: action, Reason: feedback}, err
+	}
+	riskLevel := w.lookupRiskLevel(req.ToolName)
+	resolvedName, kind, source, scope := w.lookupCapabilityMetadata(req.ToolName)
+	if w.EmitApprovalNeeded != nil {
+		w.EmitApprovalNeeded(core.ApprovalNeededPayload{
+			ToolName:         strings.TrimSpace(req.ToolName),
+			ResolvedName:     resolvedName,
+			CapabilityKind:   kind,
+			CapabilitySource: source,
+			CapabilityScope:  scope,
+			Input:            approvalInput(req.Input),
+			RiskLevel:        riskLevel,
+		})
+	}
+	verdict, err := w.PermissionPrompt.decide(ctx, req, riskLevel)
+	if err != nil {
+		return executor.ApprovalDecision{}, err
+	}
+	if w.EmitOutputDelta != nil {
+		w.EmitOutputDelta(core.OutputDeltaPayload{
+			Stage:            "approval_resolved",
+			CallID:           strings.TrimSpace(req.CallID),
+			Name:             strings.TrimSpace(req.ToolName),
+			ResolvedName:     resolvedName,
+			CapabilityKind:   kind,
+			CapabilitySource: source,
+			CapabilityScope:  scope,
+			RiskLevel:        riskLevel,
+			Delta:            string(verdict.Action),
+			Text:             verdict.Message,
+			Input:            verdict.UpdatedInput,
+			Output: map[string]any{
+				"decided_by": w.PermissionPrompt.Tool,
+				"timed_out":  verdict.TimedOut,
+			},
+		})
+	}
+	return executor.ApprovalDecision{
+		Action:       verdict.Action,
+		UpdatedInput: verdict.UpdatedInput,
+		Reason:       verdict.Message,
+	}, nil
 }
 
 func (w runtimeApprovalWaiters) WaitForApproval(ctx context.Context, req executor.ApprovalRequest) (executor.ApprovalAction, error) {
+	action, _, err := w.waitForHumanApproval(ctx, req)
+	return action, err
+}
+
+// waitForHumanApproval waits for a control action on req. A deny may carry
+// feedback, which is returned so the model sees why the call was denied.
+func (w runtimeApprovalWaiters) waitForHumanApproval(ctx context.Context, req executor.ApprovalRequest) (executor.ApprovalAction, string, error) {
 	if w.Router == nil {
-		return "", fmt.Errorf("approval router is nil")
+		return "", "", fmt.Errorf("approval router is nil")
 	}
 	riskLevel := w.lookupRiskLevel(req.ToolName)
 	if w.SetRunState != nil {
@@ -517,15 +760,16 @@ func (w runtimeApprovalWaiters) WaitForApproval(ctx context.Context, req executo
 			CapabilityKind:   kind,
 			CapabilitySource: source,
 			CapabilityScope:  scope,
-			Input:            map[string]any{},
+			Input:            approvalInput(req.Input),
 			RiskLevel:        riskLevel,
 		})
 	}
 
-	action, err := w.Router.WaitForApproval(ctx, w.RunID)
+	signal, err := w.Router.WaitForApprovalSignal(ctx, w.RunID)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
+	action := signal.Action
 	if w.EmitOutputDelta != nil {
 		resolvedName, kind, source, scope := w.lookupCapabilityMetadata(req.ToolName)
 		w.EmitOutputDelta(core.OutputDeltaPayload{
@@ -537,20 +781,54 @@ func (w runtimeApprovalWaiters) WaitForApproval(ctx context.Context, req executo
 			CapabilitySource: source,
 			CapabilityScope:  scope,
 			Delta:            string(action),
+			Text:             signal.Feedback,
 		})
 	}
 	switch action {
 	case core.ControlActionApprove:
-		return executor.ApprovalActionApprove, nil
+		if signal.Remember != "" {
+			w.rememberApproval(req, signal.Remember)
+		}
+		return executor.ApprovalActionApprove, "", nil
 	case core.ControlActionResume:
-		return executor.ApprovalActionResume, nil
+		return executor.ApprovalActionResume, "", nil
 	case core.ControlActionDeny:
-		return executor.ApprovalActionDeny, nil
+		return executor.ApprovalActionDeny, signal.Feedback, nil
 	case core.ControlActionStop:
-		return executor.ApprovalActionStop, nil
+		return executor.ApprovalActionStop, "", nil
 	default:
-		return "", fmt.Errorf("unsupported approval action %q", action)
+		return "", "", fmt.Errorf("unsupported approval action %q", action)
+	}
+}
+
+// rememberApproval persists the approved call as allow rules for scope and
+// applies them to the live gate. Failures are reported as output deltas; the
+// current call stays approved either way.
+func (w runtimeApprovalWaiters) rememberApproval(req executor.ApprovalRequest, scope core.ApprovalScope) {
+	patterns := policy.RememberPatterns(req.ToolName, req.Input)
+	stored, err := w.RuleStore.Remember(scope, string(w.SessionID), patterns)
+	if err == nil && w.Gate != nil {
+		lines := make([]string, 0, len(stored))
+		for _, item := range stored {
+			lines = append(lines, "allow "+item.Rule)
+		}
+		err = w.Gate.AddRules(lines...)
+	}
+	if w.EmitOutputDelta == nil {
+		return
 	}
+	payload := core.OutputDeltaPayload{
+		Stage:  "approval_remembered",
+		CallID: strings.TrimSpace(req.CallID),
+		Name:   strings.TrimSpace(req.ToolName),
+		Delta:  string(scope),
+		Text:   strings.Join(patterns, "\n"),
+	}
+	if err != nil {
+		payload.Stage = "approval_remember_failed"
+		payload.Text = strings.TrimSpace(err.Error())
+	}
+	w.EmitOutputDelta(payload)
 }
 
 func (w runtimeApprovalWaiters) WaitForAnswer(ctx context.Context, question interaction.PendingUserQuestion) (executor.UserAnswer, error) {
@@ -628,6 +906,78 @@ func (w runtimeApprovalWaiters) lookupCapability(toolName string) (core.Capabili
 	return item, exists
 }
 
+// stagePermissionRuleIgnored marks output deltas that report a settings or
+// session permission entry the run left out because it does not parse.
+const stagePermissionRuleIgnored = "permission_rule_ignored"
+
+// ignoredPermissionRule is one entry resolvePermissionRuleLines dropped.
+type ignoredPermissionRule struct {
+	Source     string
+	Diagnostic rulesdsl.Diagnostic
+}
+
+// resolvePermissionRuleLines combines the runtime rules DSL with settings
+// permissions (including remembered user and project approvals) and the
+// session's remembered approvals.
+func resolvePermissionRuleLines(rulesDSL string, workingDir string, store approval.RuleStore, sessionID core.SessionID) ([]string, []ignoredPermissionRule, error) {
+	lines := splitDSLLines(rulesDSL)
+	if strings.TrimSpace(workingDir) == "" {
+		return lines, nil, nil
+	}
+	merged, err := settings.LoadAndMerge(settings.LoadOptions{WorkingDir: workingDir, HomeDir: store.HomeDir})
+	if err != nil {
+		return nil, nil, err
+	}
+	candidates, sources := settings.PermissionRuleLines(merged)
+	for _, line := range store.SessionRuleLines(string(sessionID)) {
+		candidates = append(candidates, line)
+		sources = append(sources, "session")
+	}
+	// One bad entry in a settings file must not fail every run, so entries
+	// that do not parse are dropped and reported instead.
+	_, diagnostics := rulesdsl.Lint(candidates)
+	invalid := map[int]bool{}
+	ignored := []ignoredPermissionRule{}
+	for _, diagnostic := range diagnostics {
+		index := diagnostic.Line - 1
+		if diagnostic.Severity != rulesdsl.SeverityError || invalid[index] {
+			continue
+		}
+		invalid[index] = true
+		ignored = append(ignored, ignoredPermissionRule{Source: sources[index], Diagnostic: diagnostic})
+	}
+	for index, line := range candidates {
+		if !invalid[index] {
+			lines = append(lines, line)
+		}
+	}
+	return lines, ignored, nil
+}
+
+func emitIgnoredPermissionRules(emit func(payload core.OutputDeltaPayload), ignored []ignoredPermissionRule) {
+	if emit == nil {
+		return
+	}
+	for _, item := range ignored {
+		emit(core.OutputDeltaPayload{
+			Stage: stagePermissionRuleIgnored,
+			Text:  item.Diagnostic.Detail(),
+			Output: map[string]any{
+				"rule":   item.Diagnostic.Raw,
+				"source": item.Source,
+				"column": item.Diagnostic.Column,
+			},
+		})
+	}
+}
+
+func approvalInput(input map[string]any) map[string]any {
+	if len(input) == 0 {
+		return map[string]any{}
+	}
+	return cloneMapAny(input)
+}
+
 func resolvedCapabilityName(item core.CapabilityDescriptor) string {
 	name := strings.TrimSpace(item.Name)
 	if item.Kind == core.CapabilityKindMCPTool && strings.HasPrefix(strings.ToLower(name), "mcp__") {
@@ -663,12 +1013,13 @@ func convertToMCPExtServers(items []core.MCPServerConfig) []mcpext.ServerConfig
 	out := make([]mcpext.ServerConfig, 0, len(items))
 	for _, item := range items {
 		out = append(out, mcpext.ServerConfig{
-			Name:      strings.TrimSpace(item.Name),
-			Transport: strings.TrimSpace(item.Transport),
-			Endpoint:  strings.TrimSpace(item.Endpoint),
-			Command:   strings.TrimSpace(item.Command),
-			Env:       cloneStringMap(item.Env),
-			Tools:     dedupeNonEmpty(item.Tools),
+			Name:            strings.TrimSpace(item.Name),
+			Transport:       strings.TrimSpace(item.Transport),
+			Endpoint:        strings.TrimSpace(item.Endpoint),
+			Command:         strings.TrimSpace(item.Command),
+			Env:             cloneStringMap(item.Env),
+			Tools:           dedupeNonEmpty(item.Tools),
+			CredentialScope: strings.TrimSpace(item.CredentialScope),
 		})
 	}
 	return out
@@ -681,12 +1032,13 @@ func convertToCoreMCPServers(items []mcpext.ServerConfig) []core.MCPServerConfig
 	out := make([]core.MCPServerConfig, 0, len(items))
 	for _, item := range items {
 		out = append(out, core.MCPServerConfig{
-			Name:      strings.TrimSpace(item.Name),
-			Transport: strings.TrimSpace(item.Transport),
-			Endpoint:  strings.TrimSpace(item.Endpoint),
-			Command:   strings.TrimSpace(item.Command),
-			Env:       cloneStringMap(item.Env),
-			Tools:     dedupeNonEmpty(item.Tools),
+			Name:            strings.TrimSpace(item.Name),
+			Transport:       strings.TrimSpace(item.Transport),
+			Endpoint:        strings.TrimSpace(item.Endpoint),
+			Command:         strings.TrimSpace(item.Command),
+			Env:             cloneStringMap(item.Env),
+			Tools:           dedupeNonEmpty(item.Tools),
+			CredentialScope: strings.TrimSpace(item.CredentialScope),
 		})
 	}
 	return out
@@ -699,12 +1051,13 @@ func cloneCoreMCPServers(items []core.MCPServerConfig) []core.MCPServerConfig {
 	out := make([]core.MCPServerConfig, 0, len(items))
 	for _, item := range items {
 		out = append(out, core.MCPServerConfig{
-			Name:      strings.TrimSpace(item.Name),
-			Transport: strings.TrimSpace(item.Transport),
-			Endpoint:  strings.TrimSpace(item.Endpoint),
-			Command:   strings.TrimSpace(item.Command),
-			Env:       cloneStringMap(item.Env),
-			Tools:     dedupeNonEmpty(item.Tools),
+			Name:            strings.TrimSpace(item.Name),
+			Transport:       strings.TrimSpace(item.Transport),
+			Endpoint:        strings.TrimSpace(item.Endpoint),
+			Command:         strings.TrimSpace(item.Command),
+			Env:             cloneStringMap(item.Env),
+			Tools:           dedupeNonEmpty(item.Tools),
+			CredentialScope: strings.TrimSpace(item.CredentialScope),
 		})
 	}
 	return out
@@ -723,6 +1076,33 @@ func cloneCapabilityDescriptors(items []core.CapabilityDescriptor) []core.Capabi
 	return out
 }
 
+// runScopedHookDispatcher stamps hook events with the run they belong to and
+// its working directory; the pipeline itself knows neither.
+type runScopedHookDispatcher struct {
+	Dispatcher core.HookDispatcher
+	SessionID  core.SessionID
+	RunID      core.RunID
+	WorkingDir string
+}
+
+func (d runScopedHookDispatcher) Dispatch(ctx context.Context, event core.HookEvent) (core.HookDecision, error) {
+	if event.SessionID == "" {
+		event.SessionID = d.SessionID
+	}
+	if event.RunID == "" {
+		event.RunID = d.RunID
+	}
+	if _, ok := event.Payload["cwd"]; !ok && strings.TrimSpace(d.WorkingDir) != "" {
+		payload := make(map[string]any, len(event.Payload)+1)
+		for key, value := range event.Payload {
+			payload[key] = value
+		}
+		payload["cwd"] = strings.TrimSpace(d.WorkingDir)
+		event.Payload = payload
+	}
+	return d.Dispatcher.Dispatch(ctx, event)
+}
+
 type runtimeSandboxGate struct {
 	Evaluator *sandboxpolicy.Evaluator
 }
@@ -17,8 +17,11 @@ import (
 	"time"
 
 	"goyais/services/hub/internal/agent/core"
+	mcpext "goyais/services/hub/internal/agent/extensions/mcp"
+	"goyais/services/hub/internal/agent/policy"
 	"goyais/services/hub/internal/agent/policy/approval"
 	"goyais/services/hub/internal/agent/runtime/model"
+	"goyais/services/hub/internal/agent/tools/executor"
 	"goyais/services/hub/internal/agent/tools/interaction"
 )
 
@@ -128,6 +131,53 @@ func TestDefaultExecutorOpenAIHandlesToolCallsWithoutHardFailure(t *testing.T) {
 	}
 }
 
+func TestDefaultExecutorDispatchesToolHooksForRun(t *testing.T) {
+	callCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callCount++
+		w.Header().Set("Content-Type", "application/json")
+		if callCount == 1 {
+			_, _ = w.Write([]byte(`{
+				"choices":[{"message":{"content":"","tool_calls":[{"id":"call_1","type":"function","function":{"name":"Read","arguments":"{\"path\":\"missing.txt\"}"}}]}}],
+				"usage":{"prompt_tokens":1,"completion_tokens":1}
+			}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"done"}}],"usage":{"prompt_tokens":1,"completion_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("GOYAIS_AGENT_MODEL_PROVIDER", "openai")
+	t.Setenv("GOYAIS_AGENT_MODEL_ENDPOINT", server.URL)
+	t.Setenv("GOYAIS_AGENT_MODEL_NAME", "gpt-test")
+
+	events := []core.HookEvent{}
+	executor := defaultExecutor{}
+	_, err := executor.Execute(context.Background(), ExecuteRequest{
+		SessionID:  "sess_hooks",
+		RunID:      "run_hooks",
+		WorkingDir: t.TempDir(),
+		Input:      core.UserInput{Text: "read it"},
+		HookDispatcher: hookDispatcherFunc(func(_ context.Context, event core.HookEvent) (core.HookDecision, error) {
+			events = append(events, event)
+			return core.HookDecision{}, nil
+		}),
+	})
+	if err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+	types := []string{}
+	for _, event := range events {
+		if event.SessionID != "sess_hooks" || event.RunID != "run_hooks" {
+			t.Fatalf("expected run-scoped hook event, got %#v", event)
+		}
+		types = append(types, event.Type)
+	}
+	if strings.Join(types, ",") != "PreToolUse,PostToolUseFailure" {
+		t.Fatalf("unexpected hook events %v", types)
+	}
+}
+
 func TestDefaultExecutorUsesRuntimeConfigBeforeEnv(t *testing.T) {
 	runtimeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -424,6 +474,64 @@ func TestRuntimeApprovalWaitersWaitForAnswer(t *testing.T) {
 	}
 }
 
+func TestRuntimeApprovalWaitersRememberApprovalAsScopedRule(t *testing.T) {
+	router := approval.NewRouter(8)
+	runID := core.RunID("run_remember")
+	router.Register(runID)
+	defer router.Unregister(runID)
+
+	workingDir := t.TempDir()
+	store := approval.NewRuleStore(workingDir, t.TempDir()).WithSessions(approval.NewSessionRules())
+	gate, err := policy.NewGateFromLines(nil)
+	if err != nil {
+		t.Fatalf("new gate: %v", err)
+	}
+	stages := make([]string, 0, 4)
+	waiters := runtimeApprovalWaiters{
+		SessionID: "sess_remember",
+		RunID:     runID,
+		Router:    router,
+		Gate:      gate,
+		RuleStore: store,
+		EmitOutputDelta: func(payload core.OutputDeltaPayload) {
+			stages = append(stages, payload.Stage)
+		},
+	}
+	_ = router.Send(runID, approval.ControlSignal{Action: core.ControlActionApprove, Remember: core.ApprovalScopeSession})
+
+	action, err := waiters.WaitForApproval(context.Background(), executor.ApprovalRequest{
+		ToolName: "Bash",
+		CallID:   "call_remember",
+		Input:    map[string]any{"command": "git status --short"},
+	})
+	if err != nil || action != executor.ApprovalActionApprove {
+		t.Fatalf("wait for approval = %q, %v", action, err)
+	}
+	if !containsString(stages, "approval_remembered") {
+		t.Fatalf("expected approval_remembered stage, got %#v", stages)
+	}
+
+	decision, err := gate.Evaluate(context.Background(), core.PermissionRequest{
+		Mode:      core.PermissionModeDefault,
+		ToolName:  "Bash",
+		Arguments: `{"command":"git status"}`,
+	})
+	if err != nil || decision.Kind != core.PermissionDecisionAllow || decision.MatchedRule != "allow Bash(git status:*)" {
+		t.Fatalf("expected live gate to honor remembered rule, got %#v, %v", decision, err)
+	}
+
+	lines, _, err := resolvePermissionRuleLines("", workingDir, store, "sess_remember")
+	if err != nil {
+		t.Fatalf("resolve rule lines: %v", err)
+	}
+	if !containsString(lines, "allow Bash(git status:*)") {
+		t.Fatalf("expected later runs in the session to load the rule, got %#v", lines)
+	}
+	if lines, _, _ := resolvePermissionRuleLines("", workingDir, store, "sess_other"); containsString(lines, "allow Bash(git status:*)") {
+		t.Fatalf("expected session rule to stay scoped to its session, got %#v", lines)
+	}
+}
+
 func containsString(items []string, target string) bool {
 	for _, item := range items {
 		if strings.TrimSpace(item) == strings.TrimSpace(target) {
@@ -432,3 +540,64 @@ func containsString(items []string, target string) bool {
 	}
 	return false
 }
+
+func TestResolvePermissionRuleLinesDropsInvalidSettingsEntries(t *testing.T) {
+	workingDir := t.TempDir()
+	settingsPath := filepath.Join(workingDir, ".goyais", "settings.json")
+	if err := os.MkdirAll(filepath.Dir(settingsPath), 0o755); err != nil {
+		t.Fatalf("create settings dir: %v", err)
+	}
+	if err := os.WriteFile(settingsPath, []byte(`{"permissions":{"allow":["Bash(git status)","Bash(git log"]}}`), 0o644); err != nil {
+		t.Fatalf("write settings: %v", err)
+	}
+	store := approval.NewRuleStore(workingDir, t.TempDir())
+
+	lines, ignored, err := resolvePermissionRuleLines("deny Bash(rm:*)", workingDir, store, "sess_lint")
+	if err != nil {
+		t.Fatalf("expected an invalid settings entry not to fail the run, got %v", err)
+	}
+	if !containsString(lines, "deny Bash(rm:*)") || !containsString(lines, "allow Bash(git status)") || containsString(lines, "allow Bash(git log") {
+		t.Fatalf("expected the valid lines only, got %#v", lines)
+	}
+	if _, err := policy.NewGateFromLines(lines); err != nil {
+		t.Fatalf("expected the remaining lines to build a gate: %v", err)
+	}
+	if len(ignored) != 1 || ignored[0].Diagnostic.Raw != "allow Bash(git log" || ignored[0].Source != "settings:project" {
+		t.Fatalf("expected the invalid entry to be reported, got %#v", ignored)
+	}
+
+	var payloads []core.OutputDeltaPayload
+	emitIgnoredPermissionRules(func(payload core.OutputDeltaPayload) { payloads = append(payloads, payload) }, ignored)
+	if len(payloads) != 1 || payloads[0].Stage != stagePermissionRuleIgnored || payloads[0].Output["rule"] != "allow Bash(git log" {
+		t.Fatalf("unexpected ignored rule deltas %#v", payloads)
+	}
+}
+
+func TestChangedMCPToolDescriptorsUsesRefreshedTools(t *testing.T) {
+	servers := []core.MCPServerConfig{{Name: "docs", Transport: "stdio", Tools: []string{"search"}}, {Name: "other", Tools: []string{"x"}}}
+	items := changedMCPToolDescriptors(servers, "docs", []string{"search", "index"})
+	names := []string{}
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	if strings.Join(names, ",") != "mcp__docs__index,mcp__docs__search" {
+		t.Fatalf("unexpected refreshed descriptors %v", names)
+	}
+	if servers[0].Tools[0] != "search" || len(servers[0].Tools) != 1 {
+		t.Fatalf("expected configured servers to be left untouched, got %+v", servers[0])
+	}
+	if items := changedMCPToolDescriptors(servers, "missing", []string{"a"}); items != nil {
+		t.Fatalf("expected unknown server to yield nothing, got %+v", items)
+	}
+}
+
+func TestEmitToolProgressBuildsToolProgressDelta(t *testing.T) {
+	var got core.OutputDeltaPayload
+	emitToolProgress(func(payload core.OutputDeltaPayload) { got = payload }, "call_1", "mcp__docs__index", mcpext.ProgressUpdate{Progress: 3, Total: 10, Message: "indexing"})
+	if got.Stage != "tool_progress" || got.CallID != "call_1" || got.Name != "mcp__docs__index" {
+		t.Fatalf("unexpected progress delta %+v", got)
+	}
+	if got.Output["progress"] != 3.0 || got.Output["total"] != 10.0 || got.Output["message"] != "indexing" {
+		t.Fatalf("unexpected progress output %+v", got.Output)
+	}
+}
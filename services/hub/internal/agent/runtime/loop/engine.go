@@ -20,8 +20,12 @@ import (
 	"goyais/services/hub/internal/agent/core"
 	eventscore "goyais/services/hub/internal/agent/core/events"
 	"goyais/services/hub/internal/agent/core/statemachine"
+	mcpext "goyais/services/hub/internal/agent/extensions/mcp"
 	"goyais/services/hub/internal/agent/policy/approval"
+	"goyais/services/hub/internal/agent/policy/redaction"
 	"goyais/services/hub/internal/agent/runtime/compaction"
+	"goyais/services/hub/internal/agent/runtime/session"
+	"goyais/services/hub/internal/agent/tools/checkpoint"
 	transportevents "goyais/services/hub/internal/agent/transport/events"
 	"goyais/services/hub/internal/agent/transport/subscribers"
 )
@@ -36,9 +40,18 @@ type ExecuteRequest struct {
 	WorkingDir            string
 	AdditionalDirectories []string
 	ApprovalRouter        *approval.Router
+	SessionApprovals      *approval.SessionRules
 	EmitOutputDelta       func(payload core.OutputDeltaPayload)
 	EmitApprovalNeeded    func(payload core.ApprovalNeededPayload)
 	SetRunState           func(state core.RunState)
+	// SetPermissionMode records a mode switch made during the run, such as
+	// leaving plan mode after the plan was approved.
+	SetPermissionMode func(from core.PermissionMode, to core.PermissionMode, planID string)
+	// HookDispatcher, when set, runs tool hooks and may deny requests MCP
+	// servers send during the run.
+	HookDispatcher core.HookDispatcher
+	// Checkpoints, when set, snapshots files before Write and Edit calls.
+	Checkpoints core.CheckpointStore
 }
 
 // ExecuteResult is the normalized output returned from one run execution.
@@ -58,10 +71,21 @@ type Engine struct {
 
 	executor       Executor
 	contextBuilder core.ContextBuilder
-	eventStore     *transportevents.Store
+	eventStore     transportevents.EventLog
 	approvalRouter *approval.Router
 	subscriberCfg  subscribers.Config
 	compactor      *compaction.Manager
+	sessionManager *session.Manager
+	hookDispatcher core.HookDispatcher
+	journal        Journal
+	journalWriter  *journalWriter
+
+	sessionApprovals *approval.SessionRules
+
+	checkpointDir     string
+	checkpointOptions checkpoint.DiskOptions
+	checkpointMu      sync.Mutex
+	checkpointStores  map[core.SessionID]*checkpoint.DiskStore
 
 	nextSessionID uint64
 	nextRunID     uint64
@@ -82,6 +106,9 @@ type sessionRuntime struct {
 
 	queue  []core.RunID
 	active core.RunID
+
+	// hooksStarted is set once SessionStart hooks ran for the session.
+	hooksStarted bool
 }
 
 type runRuntime struct {
@@ -94,6 +121,12 @@ type runRuntime struct {
 	machine       *statemachine.Machine
 	cancel        context.CancelFunc
 	promptContext core.PromptContext
+	createdAt     time.Time
+	// interrupted marks a run failed by Recover; resume queues it again.
+	interrupted bool
+	// pendingPlan marks a run waiting on a plan review rather than a tool
+	// approval.
+	pendingPlan bool
 }
 
 type eventSubscription struct {
@@ -121,10 +154,27 @@ type defaultExecutor struct{}
 type Dependencies struct {
 	Executor       Executor
 	ContextBuilder core.ContextBuilder
-	EventStore     *transportevents.Store
+	EventStore     transportevents.EventLog
+	// EventRetention configures the in-memory event store used when
+	// EventStore is nil. The zero value keeps every event.
+	EventRetention transportevents.Options
 	ApprovalRouter *approval.Router
 	SubscriberCfg  subscribers.Config
 	Compactor      *compaction.Manager
+	// SessionManager, when set, records permission mode transitions made
+	// during runs.
+	SessionManager *session.Manager
+	// HookDispatcher, when set, runs SessionStart and UserPromptSubmit hooks
+	// before prompt assembly and is passed to the executor for tool hooks.
+	HookDispatcher core.HookDispatcher
+	// Journal, when set, receives sessions, runs and events as they change
+	// so that Recover can rebuild them after a restart.
+	Journal Journal
+	// CheckpointDir, when set, keeps an on-disk checkpoint store for each
+	// session in a subdirectory named after the session. Write and Edit
+	// calls snapshot the file they change first.
+	CheckpointDir     string
+	CheckpointOptions checkpoint.DiskOptions
 }
 
 func (defaultExecutor) Execute(ctx context.Context, req ExecuteRequest) (ExecuteResult, error) {
@@ -156,7 +206,7 @@ func NewEngineWithDeps(deps Dependencies) *Engine {
 		deps.ContextBuilder = promptctx.NewBuilder(promptctx.BuilderOptions{})
 	}
 	if deps.EventStore == nil {
-		deps.EventStore = transportevents.NewStore()
+		deps.EventStore = transportevents.NewStoreWithOptions(deps.EventRetention)
 	}
 	if deps.ApprovalRouter == nil {
 		deps.ApprovalRouter = approval.NewRouter(16)
@@ -169,14 +219,22 @@ func NewEngineWithDeps(deps Dependencies) *Engine {
 		subscriberCfg.BackpressurePolicy = subscribers.BackpressureDropNewest
 	}
 	return &Engine{
-		executor:       deps.Executor,
-		contextBuilder: deps.ContextBuilder,
-		eventStore:     deps.EventStore,
-		approvalRouter: deps.ApprovalRouter,
-		subscriberCfg:  subscriberCfg,
-		compactor:      deps.Compactor,
-		sessions:       map[core.SessionID]*sessionRuntime{},
-		runs:           map[core.RunID]*runRuntime{},
+		executor:          deps.Executor,
+		contextBuilder:    deps.ContextBuilder,
+		eventStore:        deps.EventStore,
+		approvalRouter:    deps.ApprovalRouter,
+		subscriberCfg:     subscriberCfg,
+		compactor:         deps.Compactor,
+		sessionManager:    deps.SessionManager,
+		hookDispatcher:    deps.HookDispatcher,
+		journal:           deps.Journal,
+		journalWriter:     newJournalWriter(deps.Journal),
+		sessionApprovals:  approval.NewSessionRules(),
+		checkpointDir:     strings.TrimSpace(deps.CheckpointDir),
+		checkpointOptions: deps.CheckpointOptions,
+		checkpointStores:  map[core.SessionID]*checkpoint.DiskStore{},
+		sessions:          map[core.SessionID]*sessionRuntime{},
+		runs:              map[core.RunID]*runRuntime{},
 	}
 }
 
@@ -200,6 +258,7 @@ func (e *Engine) StartSession(_ context.Context, req core.StartSessionRequest) (
 		subscriberManager:     subscribers.NewManager(e.subscriberCfg),
 		queue:                 make([]core.RunID, 0, 8),
 	}
+	e.saveSessionLocked(e.sessions[sessionID])
 
 	return core.SessionHandle{
 		SessionID: sessionID,
@@ -207,6 +266,32 @@ func (e *Engine) StartSession(_ context.Context, req core.StartSessionRequest) (
 	}, nil
 }
 
+// CloseSession releases what a finished session holds outside the engine.
+// Its pooled MCP servers are shut down now rather than at the pool's idle
+// timeout, so stdio server processes end with the session, and its
+// session-scoped approvals and checkpoints are forgotten, and the journal
+// marks it closed so later restarts do not rebuild it. Callers cancel the
+// session's runs first.
+func (e *Engine) CloseSession(_ context.Context, sessionID string) {
+	sessionID = strings.TrimSpace(sessionID)
+	if sessionID == "" {
+		return
+	}
+	mcpext.DefaultSessionPool().CloseSession(sessionID)
+	e.sessionApprovals.Forget(sessionID)
+	e.forgetSessionCheckpoints(core.SessionID(sessionID))
+	e.closeSessionJournal(core.SessionID(sessionID))
+}
+
+// SessionApprovals returns the session-scoped approvals remembered during
+// this engine's runs. They are kept in memory only.
+func (e *Engine) SessionApprovals() *approval.SessionRules {
+	if e == nil {
+		return nil
+	}
+	return e.sessionApprovals
+}
+
 // Submit queues one run in the session and starts it immediately when idle.
 func (e *Engine) Submit(_ context.Context, sessionID string, input core.UserInput) (runID string, err error) {
 	if err := input.Validate(); err != nil {
@@ -240,12 +325,10 @@ func (e *Engine) Submit(_ context.Context, sessionID string, input core.UserInpu
 		workingDir:            session.workingDir,
 		additionalDirectories: append([]string(nil), session.additionalDirectories...),
 		machine:               machine,
+		createdAt:             time.Now().UTC(),
 	}
 
 	e.runs[newRunID] = run
-	if e.compactor != nil {
-		e.compactor.AppendMessage(normalizedSessionID, "user", input.Text, 0)
-	}
 	if e.approvalRouter != nil {
 		e.approvalRouter.Register(newRunID)
 	}
@@ -280,6 +363,8 @@ func (e *Engine) Control(_ context.Context, req core.ControlRequest) error {
 	if !sessionExists {
 		return core.ErrSessionNotFound
 	}
+	pendingPlan := run.pendingPlan
+	run.pendingPlan = false
 	if e.approvalRouter != nil {
 		var answer *approval.UserAnswer
 		if req.Answer != nil {
@@ -290,7 +375,14 @@ func (e *Engine) Control(_ context.Context, req core.ControlRequest) error {
 				Text:             normalizedAnswer.Text,
 			}
 		}
-		_ = e.approvalRouter.Send(normalizedRunID, approval.ControlSignal{Action: action, Answer: answer})
+		remember, _ := core.ParseApprovalScope(string(req.Remember))
+		_ = e.approvalRouter.Send(normalizedRunID, approval.ControlSignal{
+			Action:   action,
+			Answer:   answer,
+			Remember: remember,
+			Mode:     core.PermissionMode(strings.TrimSpace(string(req.Mode))),
+			Feedback: strings.TrimSpace(req.Feedback),
+		})
 	}
 
 	switch action {
@@ -298,6 +390,12 @@ func (e *Engine) Control(_ context.Context, req core.ControlRequest) error {
 		if run.machine.IsTerminal() {
 			return nil
 		}
+		// A deny with feedback rejects only the pending plan; the run
+		// continues so the model can revise it.
+		if action == core.ControlActionDeny && strings.TrimSpace(req.Feedback) != "" && pendingPlan &&
+			session.active == run.id && run.machine.State() == statemachine.RunStateWaitingApproval {
+			return run.machine.ApplyControl(statemachine.ControlActionResume)
+		}
 		if session.active == run.id {
 			if run.cancel != nil {
 				run.cancel()
@@ -317,7 +415,14 @@ func (e *Engine) Control(_ context.Context, req core.ControlRequest) error {
 		}
 		return nil
 	case core.ControlActionApprove, core.ControlActionResume, core.ControlActionAnswer:
-		return run.machine.ApplyControl(statemachine.ControlAction(action))
+		if action == core.ControlActionResume && run.interrupted && run.machine.IsTerminal() {
+			return e.resumeInterruptedLocked(session, run)
+		}
+		if err := run.machine.ApplyControl(statemachine.ControlAction(action)); err != nil {
+			return err
+		}
+		e.saveRunLocked(run)
+		return nil
 	default:
 		return fmt.Errorf("unsupported control action %q", action)
 	}
@@ -425,10 +530,37 @@ func (e *Engine) startNextIfIdleLocked(session *sessionRuntime) {
 }
 
 func (e *Engine) executeRun(ctx context.Context, run *runRuntime) {
-	if strings.EqualFold(strings.TrimSpace(run.input.Text), "/compact") {
-		e.executeManualCompact(ctx, run)
+	if instructions, ok := parseCompactCommand(run.input.Text); ok {
+		e.executeManualCompact(ctx, run, instructions)
+		return
+	}
+
+	hookResult, hookErr := e.runPromptHooks(ctx, run)
+	if hookErr != nil {
+		var blocked *PromptBlockedError
+		switch {
+		case errors.As(hookErr, &blocked):
+			e.finishRunAsFailed(run, "prompt_blocked", hookErr)
+		case errors.Is(hookErr, context.Canceled) || ctx.Err() != nil:
+			e.finishRunAsCancelled(run, "control_stop")
+		default:
+			e.finishRunAsFailed(run, "prompt_hook_failed", hookErr)
+		}
 		return
 	}
+	if hookResult.Prompt != run.input.Text {
+		e.mu.Lock()
+		run.input.Text = hookResult.Prompt
+		e.mu.Unlock()
+	}
+	// The transcript feeds compaction summaries, so it only ever holds
+	// redacted text.
+	var transcript *redaction.Redactor
+	if e.compactor != nil {
+		transcript = transcriptRedactor(run.workingDir)
+		userText, _ := transcript.Redact(run.input.Text, "user_input")
+		e.compactor.AppendMessage(run.sessionID, "user", userText, 0)
+	}
 
 	if e.contextBuilder != nil {
 		builtContext, buildErr := e.contextBuilder.Build(ctx, core.BuildContextRequest{
@@ -456,6 +588,25 @@ func (e *Engine) executeRun(ctx context.Context, run *runRuntime) {
 			}
 		}
 		run.promptContext = builtContext
+		emitSecretDetections(func(payload core.OutputDeltaPayload) {
+			e.emitRunOutputDelta(run.id, payload)
+		}, "", "", builtContext.Redactions)
+	}
+	// Hook context and MCP resources are added after the context builder, so
+	// they are redacted here before they reach the model.
+	extraSections := append(hookResult.Sections, resolveResourceMentions(ctx, run)...)
+	if len(extraSections) > 0 {
+		if transcript == nil {
+			transcript = transcriptRedactor(run.workingDir)
+		}
+		redactedSections, detections := redactPromptSections(transcript, extraSections)
+		emitSecretDetections(func(payload core.OutputDeltaPayload) {
+			e.emitRunOutputDelta(run.id, payload)
+		}, "", "", detections)
+		for _, section := range redactedSections {
+			run.promptContext.SystemPrompt = strings.TrimSpace(run.promptContext.SystemPrompt + "\n\n" + section.Content)
+			run.promptContext.Sections = append(run.promptContext.Sections, section)
+		}
 	}
 
 	result, runErr := e.executor.Execute(ctx, ExecuteRequest{
@@ -466,6 +617,8 @@ func (e *Engine) executeRun(ctx context.Context, run *runRuntime) {
 		WorkingDir:            run.workingDir,
 		AdditionalDirectories: append([]string(nil), run.additionalDirectories...),
 		ApprovalRouter:        e.approvalRouter,
+		SessionApprovals:      e.sessionApprovals,
+		Checkpoints:           e.runCheckpoints(run),
 		EmitOutputDelta: func(payload core.OutputDeltaPayload) {
 			e.emitRunOutputDelta(run.id, payload)
 		},
@@ -475,10 +628,23 @@ func (e *Engine) executeRun(ctx context.Context, run *runRuntime) {
 		SetRunState: func(state core.RunState) {
 			e.setRunMachineState(run.id, state)
 		},
+		SetPermissionMode: func(from core.PermissionMode, to core.PermissionMode, planID string) {
+			e.recordPermissionMode(ctx, run.sessionID, from, to, planID)
+		},
+		HookDispatcher: e.hookDispatcher,
 	})
 	if runErr == nil && e.compactor != nil {
-		e.compactor.AppendMessage(run.sessionID, "assistant", result.Output, result.UsageTokens)
-		_, _, _ = e.compactor.MaybeCompact(ctx, run.sessionID)
+		assistantText, _ := transcript.Redact(result.Output, "assistant_output")
+		e.compactor.AppendMessage(run.sessionID, "assistant", assistantText, result.UsageTokens)
+		compactRequest := compaction.Request{
+			SessionID: run.sessionID,
+			Model:     runModelConfig(run),
+		}
+		if compactRequest.Model != nil {
+			compactRequest.WindowTokens = compactRequest.Model.ContextWindowTokens
+		}
+		compacted, _, _ := e.compactor.MaybeCompactWith(ctx, compactRequest)
+		result.UsageTokens += compacted.UsageTokens
 	}
 
 	e.mu.Lock()
@@ -522,15 +688,17 @@ func (e *Engine) executeRun(ctx context.Context, run *runRuntime) {
 	e.startNextIfIdleLocked(session)
 }
 
-func (e *Engine) executeManualCompact(ctx context.Context, run *runRuntime) {
+func (e *Engine) executeManualCompact(ctx context.Context, run *runRuntime, instructions string) {
 	result := compaction.Result{}
 	var compactErr error
 	if e.compactor == nil {
 		compactErr = errors.New("compaction is not configured")
 	} else {
 		result, compactErr = e.compactor.Compact(ctx, compaction.Request{
-			SessionID: run.sessionID,
-			Trigger:   compaction.TriggerManual,
+			SessionID:    run.sessionID,
+			Trigger:      compaction.TriggerManual,
+			Instructions: instructions,
+			Model:        runModelConfig(run),
 		})
 	}
 
@@ -558,12 +726,16 @@ func (e *Engine) executeManualCompact(ctx context.Context, run *runRuntime) {
 		output := "Context compacted."
 		if result.CompactedCount == 0 {
 			output = "Context compacted: no eligible history segments."
+		} else if result.FallbackReason != "" {
+			output = "Context compacted with the transcript summary; the model summary failed: " + result.FallbackReason
 		}
 		e.emitEventLocked(session, newSequencedEvent(session, run.id, eventscore.RunOutputDeltaEventSpec, core.OutputDeltaPayload{
 			Delta: output,
 		}))
 		_ = run.machine.Transition(statemachine.RunStateCompleted)
-		e.emitEventLocked(session, newSequencedEvent(session, run.id, eventscore.RunCompletedEventSpec, core.RunCompletedPayload{}))
+		e.emitEventLocked(session, newSequencedEvent(session, run.id, eventscore.RunCompletedEventSpec, core.RunCompletedPayload{
+			UsageTokens: result.UsageTokens,
+		}))
 	}
 
 	if session.active == run.id {
@@ -622,6 +794,7 @@ func (e *Engine) emitEventLocked(session *sessionRuntime, event core.EventEnvelo
 	if e.eventStore != nil {
 		_ = e.eventStore.Append(event)
 	}
+	e.journalEventLocked(event)
 
 	if session.subscriberManager != nil {
 		_ = session.subscriberManager.Publish(context.Background(), event)
@@ -661,6 +834,7 @@ func (e *Engine) emitRunApprovalNeeded(runID core.RunID, payload core.ApprovalNe
 	if session == nil {
 		return
 	}
+	run.pendingPlan = payload.Plan != nil
 	e.emitEventLocked(session, newSequencedEvent(session, run.id, eventscore.RunApprovalNeededEventSpec, payload))
 }
 
@@ -680,6 +854,7 @@ func (e *Engine) setRunMachineState(runID core.RunID, next core.RunState) {
 		return
 	}
 	_ = run.machine.Transition(statemachine.RunState(next))
+	e.saveRunLocked(run)
 }
 
 func newSequencedEvent[P eventscore.EventPayload](
@@ -786,3 +961,42 @@ func cloneCapabilityInputSchema(input map[string]any) map[string]any {
 }
 
 var _ core.Engine = (*Engine)(nil)
+
+// recordPermissionMode mirrors a run's mode switch into the session manager.
+// Sessions are registered lazily with the mode the run started in.
+func (e *Engine) recordPermissionMode(ctx context.Context, sessionID core.SessionID, from core.PermissionMode, to core.PermissionMode, planID string) {
+	if e.sessionManager == nil {
+		return
+	}
+	state, err := e.sessionManager.Get(sessionID)
+	if errors.Is(err, core.ErrSessionNotFound) {
+		e.mu.Lock()
+		runtime := e.sessions[sessionID]
+		createdAt := time.Now().UTC()
+		workingDir := ""
+		if runtime != nil {
+			createdAt = runtime.createdAt
+			workingDir = runtime.workingDir
+		}
+		e.mu.Unlock()
+		state, err = e.sessionManager.Register(session.RegisterRequest{
+			Handle:         core.SessionHandle{SessionID: sessionID, CreatedAt: createdAt},
+			WorkingDir:     workingDir,
+			PermissionMode: from,
+		})
+	}
+	if err != nil {
+		return
+	}
+	if state.PermissionMode != from {
+		if _, err := e.sessionManager.SetPermissionMode(ctx, session.SetPermissionModeRequest{SessionID: sessionID, Mode: from, Reason: "run_started"}); err != nil {
+			return
+		}
+	}
+	_, _ = e.sessionManager.SetPermissionMode(ctx, session.SetPermissionModeRequest{
+		SessionID: sessionID,
+		Mode:      to,
+		Reason:    "plan_approved",
+		PlanID:    planID,
+	})
+}
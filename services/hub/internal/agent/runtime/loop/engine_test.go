@@ -67,6 +67,32 @@ func TestEngineSubmitRunLifecycle(t *testing.T) {
 	)
 }
 
+func TestEngineAppliesEventRetentionToDefaultStore(t *testing.T) {
+	engine := NewEngineWithDeps(Dependencies{
+		Executor: executorFunc(func(_ context.Context, _ ExecuteRequest) (ExecuteResult, error) {
+			return ExecuteResult{Output: "ok"}, nil
+		}),
+		EventRetention: transportevents.Options{MaxEventsPerSession: 2},
+	})
+	session, err := engine.StartSession(context.Background(), core.StartSessionRequest{WorkingDir: "/tmp/project"})
+	if err != nil {
+		t.Fatalf("start session: %v", err)
+	}
+	sub, err := engine.Subscribe(context.Background(), string(session.SessionID), "")
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer sub.Close()
+	runID, err := engine.Submit(context.Background(), string(session.SessionID), core.UserInput{Text: "hello"})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	waitRunEventsUntilTerminal(t, sub.Events(), runID, 2*time.Second)
+	if count := engine.eventStore.Count(session.SessionID); count != 2 {
+		t.Fatalf("expected retention to keep 2 events, got %d", count)
+	}
+}
+
 func TestEngineSubmitFailureEmitsRunFailed(t *testing.T) {
 	engine := NewEngine(executorFunc(func(_ context.Context, _ ExecuteRequest) (ExecuteResult, error) {
 		return ExecuteResult{}, errors.New("model failed")
@@ -783,3 +809,34 @@ func assertEventTypes(t *testing.T, events []core.EventEnvelope, expected ...cor
 		}
 	}
 }
+
+func TestEngineSessionApprovalsStayInMemoryAndEndWithTheSession(t *testing.T) {
+	workingDir := t.TempDir()
+	first := NewEngineWithDeps(Dependencies{})
+	second := NewEngineWithDeps(Dependencies{})
+	sessions := make([]core.SessionHandle, 0, 2)
+	for _, engine := range []*Engine{first, second} {
+		session, err := engine.StartSession(context.Background(), core.StartSessionRequest{WorkingDir: workingDir})
+		if err != nil {
+			t.Fatalf("start session: %v", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if sessions[0].SessionID != sessions[1].SessionID {
+		t.Fatalf("expected both engines to issue the same session id, got %s and %s", sessions[0].SessionID, sessions[1].SessionID)
+	}
+
+	store := approval.NewRuleStore(workingDir, t.TempDir()).WithSessions(first.SessionApprovals())
+	if _, err := store.Remember(core.ApprovalScopeSession, string(sessions[0].SessionID), []string{"Bash(rm:*)"}); err != nil {
+		t.Fatalf("remember rule: %v", err)
+	}
+	other := approval.NewRuleStore(workingDir, t.TempDir()).WithSessions(second.SessionApprovals())
+	if lines := other.SessionRuleLines(string(sessions[1].SessionID)); len(lines) != 0 {
+		t.Fatalf("expected another engine's session with the same id not to inherit approvals, got %#v", lines)
+	}
+
+	first.CloseSession(context.Background(), string(sessions[0].SessionID))
+	if lines := store.SessionRuleLines(string(sessions[0].SessionID)); len(lines) != 0 {
+		t.Fatalf("expected session approvals to end with the session, got %#v", lines)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package loop
+
+import (
+	"context"
+	"strings"
+
+	"goyais/services/hub/internal/agent/core"
+	"goyais/services/hub/internal/agent/extensions/hooks"
+	"goyais/services/hub/internal/agent/extensions/subagents"
+	"goyais/services/hub/internal/agent/runtime/model"
+)
+
+const (
+	defaultHookEvaluatorMaxOutputTokens = 1024
+	hookEvaluatorSystemPrompt           = "You review agent hook events for a policy. Reply with only the requested JSON object."
+)
+
+// HookRuntimeResolver returns the runtime config of the run a hook event
+// belongs to. Nil falls back to the environment model.
+type HookRuntimeResolver func(ctx context.Context, event core.HookEvent) *core.RuntimeConfig
+
+// NewHookPromptEvaluator returns the executor for prompt hook handlers. It
+// asks the run's model for one reply without tools.
+func NewHookPromptEvaluator(resolve HookRuntimeResolver) hooks.PromptEvaluator {
+	return func(ctx context.Context, event core.HookEvent, prompt string) (string, error) {
+		config, configured := resolveModelConfig(core.UserInput{RuntimeConfig: resolveHookRuntime(ctx, resolve, event)})
+		if !configured {
+			return "", model.ErrProviderMissing
+		}
+		provider, err := newModelProvider(samplingModelConfig(config, defaultHookEvaluatorMaxOutputTokens), defaultModelHTTPClient(config.TimeoutMS), nil)
+		if err != nil {
+			return "", err
+		}
+		turn, err := provider.Turn(ctx, model.TurnRequest{
+			SystemPrompt: hookEvaluatorSystemPrompt,
+			UserInput:    prompt,
+		})
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(turn.AssistantText), nil
+	}
+}
+
+// NewHookAgentHandler returns the executor for agent hook handlers. The
+// named subagent runs a multi-turn loop with the run's model and tools in
+// plan mode, so it can inspect the workspace but not change it. Calls that
+// would need approval are refused because no one is asked.
+func NewHookAgentHandler(resolve HookRuntimeResolver) hooks.AgentHandler {
+	return func(ctx context.Context, event core.HookEvent, req core.SubagentRequest) (core.SubagentResult, error) {
+		runtimeConfig := resolveHookRuntime(ctx, resolve, event)
+		workingDir, _ := event.Payload["cwd"].(string)
+		runner := subagents.NewRunner(subagents.RunnerOptions{
+			WorkingDir: strings.TrimSpace(workingDir),
+			Execute: subagents.ExecutorFunc(func(ctx context.Context, request subagents.ExecutionRequest) (string, error) {
+				return runHookSubagent(ctx, event, runtimeConfig, request)
+			}),
+		})
+		return runner.Run(ctx, req)
+	}
+}
+
+func runHookSubagent(ctx context.Context, event core.HookEvent, runtimeConfig *core.RuntimeConfig, request subagents.ExecutionRequest) (string, error) {
+	if runtimeConfig != nil {
+		config := *runtimeConfig
+		config.Model.MaxModelTurns = request.MaxTurns
+		config.Tooling.PermissionMode = core.PermissionModePlan
+		config.Tooling.PermissionPromptTool = ""
+		config.Tooling.AlwaysLoadedCapabilities = allowedCapabilities(config.Tooling.AlwaysLoadedCapabilities, request.AllowedTools)
+		config.Tooling.SearchableCapabilities = allowedCapabilities(config.Tooling.SearchableCapabilities, request.AllowedTools)
+		runtimeConfig = &config
+	}
+	result, _, err := executeWithConfiguredModel(ctx, ExecuteRequest{
+		SessionID:     event.SessionID,
+		RunID:         event.RunID,
+		Input:         core.UserInput{Text: request.Prompt, RuntimeConfig: runtimeConfig},
+		PromptContext: core.PromptContext{SystemPrompt: request.Definition.PromptTemplate},
+		WorkingDir:    request.WorkingDir,
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.Output, nil
+}
+
+// allowedCapabilities keeps the capabilities named in allowed; an empty list
+// keeps all of them.
+func allowedCapabilities(items []core.CapabilityDescriptor, allowed []string) []core.CapabilityDescriptor {
+	if len(allowed) == 0 {
+		return append([]core.CapabilityDescriptor(nil), items...)
+	}
+	out := make([]core.CapabilityDescriptor, 0, len(items))
+	for _, item := range items {
+		for _, name := range allowed {
+			if strings.EqualFold(strings.TrimSpace(name), item.Name) {
+				out = append(out, item)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func resolveHookRuntime(ctx context.Context, resolve HookRuntimeResolver, event core.HookEvent) *core.RuntimeConfig {
+	if resolve == nil {
+		return nil
+	}
+	return resolve(ctx, event)
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package loop
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"goyais/services/hub/internal/agent/core"
+)
+
+func TestHookHandlersUseTheRunModel(t *testing.T) {
+	var bodies []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		body := map[string]any{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"{\"decision\":\"deny\",\"reason\":\"unsafe\"}"}}]}`))
+	}))
+	defer server.Close()
+	resolve := func(context.Context, core.HookEvent) *core.RuntimeConfig {
+		return &core.RuntimeConfig{Model: core.RuntimeModelConfig{ProviderName: "openai", Endpoint: server.URL, ModelName: "gpt-run"}}
+	}
+	event := core.HookEvent{Type: "PreToolUse", SessionID: "sess_1", RunID: "run_1", Payload: map[string]any{"cwd": t.TempDir()}}
+
+	reply, err := NewHookPromptEvaluator(resolve)(context.Background(), event, "Is this safe?")
+	if err != nil {
+		t.Fatalf("evaluate prompt: %v", err)
+	}
+	if !strings.Contains(reply, `"deny"`) || bodies[0]["model"] != "gpt-run" {
+		t.Fatalf("unexpected prompt evaluation %q with %#v", reply, bodies[0])
+	}
+
+	result, err := NewHookAgentHandler(resolve)(context.Background(), event, core.SubagentRequest{AgentName: "explore", Prompt: "Review the change"})
+	if err != nil {
+		t.Fatalf("run agent handler: %v", err)
+	}
+	if !strings.Contains(result.Summary, `"deny"`) || result.TranscriptPath == "" {
+		t.Fatalf("unexpected subagent result %#v", result)
+	}
+	encoded, _ := json.Marshal(bodies[len(bodies)-1]["messages"])
+	if !strings.Contains(string(encoded), "Review the change") {
+		t.Fatalf("expected the subagent prompt in the model request, got %s", encoded)
+	}
+}
+
+func TestAllowedCapabilitiesFiltersByName(t *testing.T) {
+	items := []core.CapabilityDescriptor{{Name: "Read"}, {Name: "Write"}}
+	if got := allowedCapabilities(items, []string{"read"}); len(got) != 1 || got[0].Name != "Read" {
+		t.Fatalf("expected only Read, got %#v", got)
+	}
+	if got := allowedCapabilities(items, nil); len(got) != 2 {
+		t.Fatalf("expected every capability without a filter, got %#v", got)
+	}
+}
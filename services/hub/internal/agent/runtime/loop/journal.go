@@ -0,0 +1,307 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package loop
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"goyais/services/hub/internal/agent/core"
+	eventscore "goyais/services/hub/internal/agent/core/events"
+	"goyais/services/hub/internal/agent/core/statemachine"
+	"goyais/services/hub/internal/agent/transport/subscribers"
+)
+
+// runInterruptedCode marks a run that was executing when the previous
+// process stopped. Such runs can be resumed with a resume control.
+const runInterruptedCode = "run_interrupted"
+
+// JournalReplayLimit is the number of most recent events per session that
+// Recover replays into the event log. Journals need not load older events.
+const JournalReplayLimit = 512
+
+// Journal persists engine sessions, runs and events so that a restarted
+// engine can rebuild its state with Recover. Writes are queued by the engine
+// and applied in order on a background goroutine; they are best effort, and
+// most output deltas are never written. Load returns only what Recover
+// needs: open sessions, their unfinished or interrupted runs and their
+// recent events.
+type Journal interface {
+	SaveSession(ctx context.Context, record SessionRecord) error
+	SaveRun(ctx context.Context, record RunRecord) error
+	AppendEvent(ctx context.Context, event core.EventEnvelope) error
+	// CloseSession marks a session closed so that Load skips it and its
+	// runs. Its events may be dropped.
+	CloseSession(ctx context.Context, sessionID core.SessionID) error
+	Load(ctx context.Context) (JournalSnapshot, error)
+}
+
+// SessionRecord is the persisted form of one engine session.
+type SessionRecord struct {
+	ID                    core.SessionID
+	WorkingDir            string
+	AdditionalDirectories []string
+	CreatedAt             time.Time
+}
+
+// RunRecord is the persisted form of one engine run. Input is kept so that
+// queued and interrupted runs can execute again after a restart.
+type RunRecord struct {
+	ID                    core.RunID
+	SessionID             core.SessionID
+	Input                 core.UserInput
+	WorkingDir            string
+	AdditionalDirectories []string
+	State                 core.RunState
+	Interrupted           bool
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+}
+
+// JournalSnapshot is what a Journal loads for Recover.
+type JournalSnapshot struct {
+	Sessions []SessionRecord
+	Runs     []RunRecord
+	Events   []core.EventEnvelope
+	// LastSessionID and LastRunID are the newest IDs ever journaled,
+	// including those of closed sessions and finished runs Load skips, so
+	// a recovered engine does not hand them out again.
+	LastSessionID core.SessionID
+	LastRunID     core.RunID
+}
+
+// RecoveredRun describes one run Recover did not find finished.
+type RecoveredRun struct {
+	RunID     core.RunID
+	SessionID core.SessionID
+	Metadata  map[string]string
+	// Interrupted is set for runs that were executing; otherwise the run was
+	// queued and has been scheduled again.
+	Interrupted bool
+	// LastSequence is the last session event written before the restart.
+	LastSequence int64
+}
+
+// RecoveryReport lists the unfinished runs found by Recover.
+type RecoveryReport struct {
+	Sessions int
+	Runs     []RecoveredRun
+}
+
+// Recover rebuilds sessions, runs and the event log from the journal, keeping
+// the last JournalReplayLimit events of each session. Runs that were
+// executing fail as interrupted and keep their input as a checkpoint for a
+// later resume; queued runs are scheduled again. Call it once, before the
+// engine accepts new sessions.
+func (e *Engine) Recover(ctx context.Context) (RecoveryReport, error) {
+	if e == nil || e.journal == nil {
+		return RecoveryReport{}, nil
+	}
+	snapshot, err := e.journal.Load(ctx)
+	if err != nil {
+		return RecoveryReport{}, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	report := RecoveryReport{}
+	e.nextSessionID = max(e.nextSessionID, idNumber(string(snapshot.LastSessionID), "sess_"))
+	e.nextRunID = max(e.nextRunID, idNumber(string(snapshot.LastRunID), "run_"))
+	for _, record := range snapshot.Sessions {
+		if _, exists := e.sessions[record.ID]; exists {
+			continue
+		}
+		e.sessions[record.ID] = &sessionRuntime{
+			id:                    record.ID,
+			createdAt:             record.CreatedAt,
+			workingDir:            record.WorkingDir,
+			additionalDirectories: sanitizeDirectories(record.AdditionalDirectories),
+			subscriberManager:     subscribers.NewManager(e.subscriberCfg),
+			queue:                 make([]core.RunID, 0, 8),
+		}
+		e.nextSessionID = max(e.nextSessionID, idNumber(string(record.ID), "sess_"))
+		report.Sessions++
+	}
+	skip := map[core.SessionID]int{}
+	for _, event := range snapshot.Events {
+		skip[event.SessionID]++
+	}
+	for sessionID, count := range skip {
+		skip[sessionID] = count - JournalReplayLimit
+	}
+	for _, event := range snapshot.Events {
+		session := e.sessions[event.SessionID]
+		if session == nil {
+			continue
+		}
+		if skip[event.SessionID] > 0 {
+			skip[event.SessionID]--
+		} else if e.eventStore != nil {
+			_ = e.eventStore.Append(event)
+		}
+		if event.Sequence >= session.nextSequence {
+			session.nextSequence = event.Sequence + 1
+		}
+	}
+
+	runs := append([]RunRecord(nil), snapshot.Runs...)
+	sort.SliceStable(runs, func(i, j int) bool {
+		return idNumber(string(runs[i].ID), "run_") < idNumber(string(runs[j].ID), "run_")
+	})
+	touched := map[core.SessionID]*sessionRuntime{}
+	for _, record := range runs {
+		session := e.sessions[record.SessionID]
+		if session == nil {
+			continue
+		}
+		e.nextRunID = max(e.nextRunID, idNumber(string(record.ID), "run_"))
+		state := statemachine.RunState(record.State)
+		machine, machineErr := statemachine.NewMachine(state)
+		if machineErr != nil {
+			continue
+		}
+		run := &runRuntime{
+			id:                    record.ID,
+			sessionID:             record.SessionID,
+			input:                 record.Input,
+			workingDir:            record.WorkingDir,
+			additionalDirectories: append([]string(nil), record.AdditionalDirectories...),
+			machine:               machine,
+			createdAt:             record.CreatedAt,
+			interrupted:           record.Interrupted,
+		}
+		e.runs[run.id] = run
+		if machine.IsTerminal() {
+			continue
+		}
+
+		recovered := RecoveredRun{
+			RunID:        run.id,
+			SessionID:    run.sessionID,
+			Metadata:     cloneStringMap(run.input.Metadata),
+			LastSequence: session.nextSequence - 1,
+		}
+		if state == statemachine.RunStateQueued {
+			if e.approvalRouter != nil {
+				e.approvalRouter.Register(run.id)
+			}
+			session.queue = append(session.queue, run.id)
+			touched[session.id] = session
+		} else {
+			run.interrupted = true
+			_ = run.machine.Transition(statemachine.RunStateFailed)
+			e.emitEventLocked(session, newSequencedEvent(session, run.id, eventscore.RunFailedEventSpec, core.RunFailedPayload{
+				Code:    runInterruptedCode,
+				Message: "the run was interrupted by a restart; resume it to run it again",
+				Metadata: map[string]any{
+					"resumable":           true,
+					"checkpoint_sequence": recovered.LastSequence,
+				},
+			}))
+			recovered.Interrupted = true
+		}
+		report.Runs = append(report.Runs, recovered)
+	}
+	for _, session := range touched {
+		e.startNextIfIdleLocked(session)
+	}
+	return report, nil
+}
+
+// FlushJournal waits until every queued journal write has been applied.
+// Call it before shutting down so the journal holds the latest state.
+func (e *Engine) FlushJournal() {
+	if e == nil {
+		return
+	}
+	e.journalWriter.flush()
+}
+
+// resumeInterruptedLocked queues an interrupted run again under the same
+// run ID, starting from its recorded input.
+func (e *Engine) resumeInterruptedLocked(session *sessionRuntime, run *runRuntime) error {
+	machine, err := statemachine.NewMachine(statemachine.RunStateQueued)
+	if err != nil {
+		return err
+	}
+	run.machine = machine
+	run.interrupted = false
+	run.cancel = nil
+	if e.approvalRouter != nil {
+		e.approvalRouter.Register(run.id)
+	}
+	session.queue = append(session.queue, run.id)
+	e.emitEventLocked(session, newSequencedEvent(session, run.id, eventscore.RunQueuedEventSpec, core.RunQueuedPayload{
+		QueuePosition: len(session.queue),
+	}))
+	e.startNextIfIdleLocked(session)
+	return nil
+}
+
+func (e *Engine) saveSessionLocked(session *sessionRuntime) {
+	if e.journal == nil || session == nil {
+		return
+	}
+	e.journalWriter.enqueue(journalWrite{session: &SessionRecord{
+		ID:                    session.id,
+		WorkingDir:            session.workingDir,
+		AdditionalDirectories: append([]string(nil), session.additionalDirectories...),
+		CreatedAt:             session.createdAt,
+	}})
+}
+
+// closeSessionJournal queues the mark that keeps a closed session out of
+// later recoveries.
+func (e *Engine) closeSessionJournal(sessionID core.SessionID) {
+	if e.journal == nil || sessionID == "" {
+		return
+	}
+	e.journalWriter.enqueue(journalWrite{closed: sessionID})
+}
+
+func (e *Engine) saveRunLocked(run *runRuntime) {
+	if e.journal == nil || run == nil || run.machine == nil {
+		return
+	}
+	e.journalWriter.enqueue(journalWrite{run: &RunRecord{
+		ID:                    run.id,
+		SessionID:             run.sessionID,
+		Input:                 run.input,
+		WorkingDir:            run.workingDir,
+		AdditionalDirectories: append([]string(nil), run.additionalDirectories...),
+		State:                 core.RunState(run.machine.State()),
+		Interrupted:           run.interrupted,
+		CreatedAt:             run.createdAt,
+		UpdatedAt:             time.Now().UTC(),
+	}})
+}
+
+// journalEventLocked queues event and, for lifecycle events, the state of
+// the run it belongs to.
+func (e *Engine) journalEventLocked(event core.EventEnvelope) {
+	if e.journal == nil {
+		return
+	}
+	e.journalWriter.enqueue(journalWrite{event: &event})
+	switch event.Type {
+	case eventscore.RunEventTypeRunQueued, eventscore.RunEventTypeRunStarted,
+		eventscore.RunEventTypeRunCompleted, eventscore.RunEventTypeRunFailed,
+		eventscore.RunEventTypeRunCancelled:
+		e.saveRunLocked(e.runs[event.RunID])
+	}
+}
+
+// idNumber reads the counter behind an engine ID such as "run_12".
+func idNumber(id string, prefix string) uint64 {
+	parsed, err := strconv.ParseUint(strings.TrimPrefix(id, prefix), 10, 64)
+	if err != nil || !strings.HasPrefix(id, prefix) {
+		return 0
+	}
+	return parsed
+}
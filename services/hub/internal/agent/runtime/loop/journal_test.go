@@ -0,0 +1,320 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package loop
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"goyais/services/hub/internal/agent/core"
+)
+
+type memoryJournal struct {
+	mu       sync.Mutex
+	sessions map[core.SessionID]SessionRecord
+	runs     map[core.RunID]RunRecord
+	events   []core.EventEnvelope
+	closed   map[core.SessionID]bool
+}
+
+func newMemoryJournal() *memoryJournal {
+	return &memoryJournal{
+		sessions: map[core.SessionID]SessionRecord{},
+		runs:     map[core.RunID]RunRecord{},
+		closed:   map[core.SessionID]bool{},
+	}
+}
+
+func (j *memoryJournal) SaveSession(_ context.Context, record SessionRecord) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.sessions[record.ID] = record
+	return nil
+}
+
+func (j *memoryJournal) SaveRun(_ context.Context, record RunRecord) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.runs[record.ID] = record
+	return nil
+}
+
+func (j *memoryJournal) AppendEvent(_ context.Context, event core.EventEnvelope) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.events = append(j.events, event)
+	return nil
+}
+
+func (j *memoryJournal) CloseSession(_ context.Context, sessionID core.SessionID) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.closed[sessionID] = true
+	kept := j.events[:0]
+	for _, event := range j.events {
+		if event.SessionID != sessionID {
+			kept = append(kept, event)
+		}
+	}
+	j.events = kept
+	return nil
+}
+
+func (j *memoryJournal) Load(_ context.Context) (JournalSnapshot, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	snapshot := JournalSnapshot{Events: append([]core.EventEnvelope(nil), j.events...)}
+	for _, record := range j.sessions {
+		if idNumber(string(record.ID), "sess_") > idNumber(string(snapshot.LastSessionID), "sess_") {
+			snapshot.LastSessionID = record.ID
+		}
+		if !j.closed[record.ID] {
+			snapshot.Sessions = append(snapshot.Sessions, record)
+		}
+	}
+	for _, record := range j.runs {
+		if idNumber(string(record.ID), "run_") > idNumber(string(snapshot.LastRunID), "run_") {
+			snapshot.LastRunID = record.ID
+		}
+		finished := record.State == core.RunStateCompleted || record.State == core.RunStateFailed || record.State == core.RunStateCancelled
+		if !j.closed[record.SessionID] && (!finished || record.Interrupted) {
+			snapshot.Runs = append(snapshot.Runs, record)
+		}
+	}
+	return snapshot, nil
+}
+
+// clone copies the journal as it stands, as a crash would leave it.
+func (j *memoryJournal) clone() *memoryJournal {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	out := newMemoryJournal()
+	for id, record := range j.sessions {
+		out.sessions[id] = record
+	}
+	for id, record := range j.runs {
+		out.runs[id] = record
+	}
+	for id := range j.closed {
+		out.closed[id] = true
+	}
+	out.events = append(out.events, j.events...)
+	return out
+}
+
+func TestEngineRecoverInterruptsRunningAndRestartsQueuedRuns(t *testing.T) {
+	journal := newMemoryJournal()
+	started := make(chan struct{}, 1)
+	first := NewEngineWithDeps(Dependencies{
+		Journal: journal,
+		Executor: executorFunc(func(ctx context.Context, _ ExecuteRequest) (ExecuteResult, error) {
+			started <- struct{}{}
+			<-ctx.Done()
+			return ExecuteResult{}, ctx.Err()
+		}),
+	})
+	session, err := first.StartSession(context.Background(), core.StartSessionRequest{WorkingDir: "/tmp/project"})
+	if err != nil {
+		t.Fatalf("start session: %v", err)
+	}
+	runningID, err := first.Submit(context.Background(), string(session.SessionID), core.UserInput{
+		Text:     "long task",
+		Metadata: map[string]string{"run_id": "exec_1"},
+	})
+	if err != nil {
+		t.Fatalf("submit running: %v", err)
+	}
+	<-started
+	queuedID, err := first.Submit(context.Background(), string(session.SessionID), core.UserInput{Text: "next task"})
+	if err != nil {
+		t.Fatalf("submit queued: %v", err)
+	}
+	first.FlushJournal()
+	crashed := journal.clone()
+	defer func() {
+		_ = first.Control(context.Background(), core.ControlRequest{RunID: runningID, Action: core.ControlActionStop})
+	}()
+
+	second := NewEngineWithDeps(Dependencies{
+		Journal: crashed,
+		Executor: executorFunc(func(_ context.Context, req ExecuteRequest) (ExecuteResult, error) {
+			return ExecuteResult{Output: "done " + req.Input.Text}, nil
+		}),
+	})
+	report, err := second.Recover(context.Background())
+	if err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+	if report.Sessions != 1 || len(report.Runs) != 2 {
+		t.Fatalf("unexpected report %#v", report)
+	}
+	interrupted := report.Runs[0]
+	if string(interrupted.RunID) != runningID || !interrupted.Interrupted || interrupted.Metadata["run_id"] != "exec_1" {
+		t.Fatalf("unexpected interrupted run %#v", interrupted)
+	}
+	if string(report.Runs[1].RunID) != queuedID || report.Runs[1].Interrupted {
+		t.Fatalf("unexpected queued run %#v", report.Runs[1])
+	}
+
+	sub, err := second.Subscribe(context.Background(), string(session.SessionID), "")
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer sub.Close()
+	failed := waitForRunEvent(t, sub.Events(), runningID, core.RunEventTypeRunFailed, 2*time.Second)
+	payload, ok := failed.Payload.(core.RunFailedPayload)
+	if !ok || payload.Code != runInterruptedCode || payload.Metadata["resumable"] != true {
+		t.Fatalf("unexpected interrupted payload %#v", failed.Payload)
+	}
+	waitForRunEvent(t, sub.Events(), queuedID, core.RunEventTypeRunCompleted, 2*time.Second)
+
+	if err := second.Control(context.Background(), core.ControlRequest{RunID: runningID, Action: core.ControlActionResume}); err != nil {
+		t.Fatalf("resume interrupted run: %v", err)
+	}
+	waitForRunEvent(t, sub.Events(), runningID, core.RunEventTypeRunCompleted, 2*time.Second)
+
+	next, err := second.StartSession(context.Background(), core.StartSessionRequest{WorkingDir: "/tmp/project"})
+	if err != nil {
+		t.Fatalf("start session after recover: %v", err)
+	}
+	if next.SessionID == session.SessionID {
+		t.Fatalf("expected a fresh session id, got %s", next.SessionID)
+	}
+	second.FlushJournal()
+	crashed.mu.Lock()
+	record := crashed.runs[core.RunID(runningID)]
+	crashed.mu.Unlock()
+	if record.State != core.RunStateCompleted {
+		t.Fatalf("expected the resumed run to be journaled as completed, got %#v", record)
+	}
+}
+
+func TestEngineRecoverSkipsClosedSessionsAndFinishedRuns(t *testing.T) {
+	journal := newMemoryJournal()
+	first := NewEngineWithDeps(Dependencies{
+		Journal: journal,
+		Executor: executorFunc(func(_ context.Context, _ ExecuteRequest) (ExecuteResult, error) {
+			return ExecuteResult{Output: "ok"}, nil
+		}),
+	})
+	kept, err := first.StartSession(context.Background(), core.StartSessionRequest{WorkingDir: "/tmp/project"})
+	if err != nil {
+		t.Fatalf("start kept session: %v", err)
+	}
+	closed, err := first.StartSession(context.Background(), core.StartSessionRequest{WorkingDir: "/tmp/project"})
+	if err != nil {
+		t.Fatalf("start closed session: %v", err)
+	}
+	var lastRunID string
+	for _, session := range []core.SessionHandle{kept, closed} {
+		sub, err := first.Subscribe(context.Background(), string(session.SessionID), "")
+		if err != nil {
+			t.Fatalf("subscribe: %v", err)
+		}
+		lastRunID, err = first.Submit(context.Background(), string(session.SessionID), core.UserInput{Text: "task"})
+		if err != nil {
+			t.Fatalf("submit: %v", err)
+		}
+		waitForRunEvent(t, sub.Events(), lastRunID, core.RunEventTypeRunCompleted, 2*time.Second)
+		sub.Close()
+	}
+	first.CloseSession(context.Background(), string(closed.SessionID))
+	first.FlushJournal()
+
+	snapshot, err := journal.Load(context.Background())
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(snapshot.Sessions) != 1 || snapshot.Sessions[0].ID != kept.SessionID || len(snapshot.Runs) != 0 {
+		t.Fatalf("expected only the open session and no finished runs, got %#v", snapshot)
+	}
+	for _, event := range snapshot.Events {
+		if event.SessionID == closed.SessionID {
+			t.Fatalf("expected the closed session's events to be dropped, got %#v", event)
+		}
+	}
+
+	second := NewEngineWithDeps(Dependencies{Journal: journal.clone()})
+	report, err := second.Recover(context.Background())
+	if err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+	if report.Sessions != 1 || len(report.Runs) != 0 {
+		t.Fatalf("unexpected report %#v", report)
+	}
+	next, err := second.StartSession(context.Background(), core.StartSessionRequest{WorkingDir: "/tmp/project"})
+	if err != nil {
+		t.Fatalf("start session after recover: %v", err)
+	}
+	if next.SessionID == closed.SessionID || next.SessionID == kept.SessionID {
+		t.Fatalf("expected a session id the journal never saw, got %s", next.SessionID)
+	}
+	runID, err := second.Submit(context.Background(), string(next.SessionID), core.UserInput{Text: "task"})
+	if err != nil {
+		t.Fatalf("submit after recover: %v", err)
+	}
+	if idNumber(runID, "run_") <= idNumber(lastRunID, "run_") {
+		t.Fatalf("expected run id after %s, got %s", lastRunID, runID)
+	}
+}
+
+// gatedJournal blocks session writes until release is closed.
+type gatedJournal struct {
+	*memoryJournal
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (j *gatedJournal) SaveSession(ctx context.Context, record SessionRecord) error {
+	j.entered <- struct{}{}
+	<-j.release
+	return j.memoryJournal.SaveSession(ctx, record)
+}
+
+func TestEngineJournalWritesDoNotBlockRunsAndCoalesceOutputDeltas(t *testing.T) {
+	journal := &gatedJournal{memoryJournal: newMemoryJournal(), entered: make(chan struct{}, 1), release: make(chan struct{})}
+	engine := NewEngineWithDeps(Dependencies{
+		Journal: journal,
+		Executor: executorFunc(func(_ context.Context, req ExecuteRequest) (ExecuteResult, error) {
+			for _, chunk := range []string{"a", "b", "c"} {
+				req.EmitOutputDelta(core.OutputDeltaPayload{Delta: chunk})
+			}
+			return ExecuteResult{Output: "abc"}, nil
+		}),
+	})
+	session, err := engine.StartSession(context.Background(), core.StartSessionRequest{WorkingDir: "/tmp/project"})
+	if err != nil {
+		t.Fatalf("start session: %v", err)
+	}
+	<-journal.entered
+	sub, err := engine.Subscribe(context.Background(), string(session.SessionID), "")
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer sub.Close()
+	runID, err := engine.Submit(context.Background(), string(session.SessionID), core.UserInput{Text: "go"})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	completed := waitForRunEvent(t, sub.Events(), runID, core.RunEventTypeRunCompleted, 2*time.Second)
+
+	close(journal.release)
+	engine.FlushJournal()
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+	if len(journal.events) == 0 || journal.events[len(journal.events)-1].Sequence != completed.Sequence {
+		t.Fatalf("expected the journal to end at sequence %d, got %#v", completed.Sequence, journal.events)
+	}
+	for _, event := range journal.events {
+		if event.Type == core.RunEventTypeRunOutputDelta {
+			t.Fatalf("expected output deltas to be coalesced away, got %#v", event)
+		}
+	}
+	if journal.runs[core.RunID(runID)].State != core.RunStateCompleted {
+		t.Fatalf("expected the run to be journaled as completed, got %#v", journal.runs[core.RunID(runID)])
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package loop
+
+import (
+	"context"
+	"sync"
+
+	"goyais/services/hub/internal/agent/core"
+	eventscore "goyais/services/hub/internal/agent/core/events"
+)
+
+// EventBatchJournal is implemented by journals that can append several
+// events in one write. The journal writer prefers it over AppendEvent.
+type EventBatchJournal interface {
+	AppendEvents(ctx context.Context, events []core.EventEnvelope) error
+}
+
+// journalWriter applies journal writes on its own goroutine, so the engine
+// never waits on storage while it holds its lock. Writes keep their order.
+// Within one batch, an output delta is only written when it is the last
+// event of its session, which keeps the session's last sequence without
+// storing every chunk.
+type journalWriter struct {
+	journal Journal
+
+	mu      sync.Mutex
+	idle    *sync.Cond
+	pending []journalWrite
+	running bool
+}
+
+type journalWrite struct {
+	session *SessionRecord
+	run     *RunRecord
+	event   *core.EventEnvelope
+	closed  core.SessionID
+}
+
+func newJournalWriter(journal Journal) *journalWriter {
+	if journal == nil {
+		return nil
+	}
+	writer := &journalWriter{journal: journal}
+	writer.idle = sync.NewCond(&writer.mu)
+	return writer
+}
+
+func (w *journalWriter) enqueue(write journalWrite) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = append(w.pending, write)
+	if !w.running {
+		w.running = true
+		go w.drain()
+	}
+}
+
+// flush waits until every queued write has been applied.
+func (w *journalWriter) flush() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for w.running {
+		w.idle.Wait()
+	}
+}
+
+func (w *journalWriter) drain() {
+	for {
+		w.mu.Lock()
+		batch := w.pending
+		w.pending = nil
+		if len(batch) == 0 {
+			w.running = false
+			w.idle.Broadcast()
+			w.mu.Unlock()
+			return
+		}
+		w.mu.Unlock()
+		w.apply(batch)
+	}
+}
+
+func (w *journalWriter) apply(batch []journalWrite) {
+	ctx := context.Background()
+	last := map[core.SessionID]int{}
+	for index, write := range batch {
+		if write.event != nil {
+			last[write.event.SessionID] = index
+		}
+	}
+	var events []core.EventEnvelope
+	for index, write := range batch {
+		switch {
+		case write.event != nil:
+			if write.event.Type == eventscore.RunEventTypeRunOutputDelta && last[write.event.SessionID] != index {
+				continue
+			}
+			events = append(events, *write.event)
+		case write.session != nil:
+			events = w.appendEvents(ctx, events)
+			_ = w.journal.SaveSession(ctx, *write.session)
+		case write.run != nil:
+			events = w.appendEvents(ctx, events)
+			_ = w.journal.SaveRun(ctx, *write.run)
+		case write.closed != "":
+			events = w.appendEvents(ctx, events)
+			_ = w.journal.CloseSession(ctx, write.closed)
+		}
+	}
+	w.appendEvents(ctx, events)
+}
+
+// appendEvents writes events and returns an empty slice for the next run
+// of events.
+func (w *journalWriter) appendEvents(ctx context.Context, events []core.EventEnvelope) []core.EventEnvelope {
+	if len(events) == 0 {
+		return events
+	}
+	if batch, ok := w.journal.(EventBatchJournal); ok {
+		_ = batch.AppendEvents(ctx, events)
+		return nil
+	}
+	for _, event := range events {
+		_ = w.journal.AppendEvent(ctx, event)
+	}
+	return nil
+}
@@ -0,0 +1,430 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package loop
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"goyais/services/hub/internal/agent/core"
+	mcpext "goyais/services/hub/internal/agent/extensions/mcp"
+	"goyais/services/hub/internal/agent/runtime/model"
+	"goyais/services/hub/internal/agent/tools/executor"
+	"goyais/services/hub/internal/agent/tools/interaction"
+)
+
+const (
+	// eventMCPServerRequest is dispatched before a sampling or elicitation
+	// request from an MCP server is served, so hook policy can deny it.
+	eventMCPServerRequest = "MCPServerRequest"
+
+	stageMCPServerRequest = "mcp_server_request"
+
+	samplingOptionAllow   = "allow"
+	samplingOptionDeny    = "deny"
+	elicitationOptionSkip = "decline"
+)
+
+var mcpServerRequestSeq atomic.Int64
+
+// mcpServerRequests serves sampling/createMessage and elicitation/create
+// requests that MCP servers send during one run. Every outcome is emitted
+// as an mcp_server_request output delta so it reaches the audit log.
+type mcpServerRequests struct {
+	SessionID core.SessionID
+	RunID     core.RunID
+	Policies  mcpext.SamplingPolicies
+	Hooks     core.HookDispatcher
+	Waiters   runtimeApprovalWaiters
+	Model     string
+	// NewProvider builds a tool-less provider whose completions are capped
+	// at maxTokens. Providers keep history, so each request gets its own.
+	NewProvider     func(maxTokens int) (model.Provider, error)
+	EmitOutputDelta func(payload core.OutputDeltaPayload)
+}
+
+// mcpServerRequestOutcome is what one served request reports.
+type mcpServerRequestOutcome struct {
+	Outcome     string
+	DecidedBy   string
+	Reason      string
+	MaxTokens   int
+	UsageTokens int
+}
+
+// CreateMessage runs one completion for server after hook policy and the
+// server's sampling policy allow it.
+func (r mcpServerRequests) CreateMessage(ctx context.Context, server string, req mcpext.SamplingRequest) (mcpext.SamplingResult, error) {
+	toolName := mcpServerRequestToolName(server, "sampling")
+	policy := r.Policies.For(server)
+	maxTokens := policy.MaxTokens
+	if req.MaxTokens > 0 && (maxTokens <= 0 || req.MaxTokens < maxTokens) {
+		maxTokens = req.MaxTokens
+	}
+	outcome := mcpServerRequestOutcome{MaxTokens: maxTokens}
+	report := func() { r.emit(server, "sampling/createMessage", toolName, outcome) }
+
+	approval := policy.Approval
+	decision, err := r.dispatchHook(ctx, server, "sampling/createMessage", toolName, map[string]any{
+		"system_prompt": req.SystemPrompt,
+		"messages":      samplingMessagesToMaps(req.Messages),
+		"max_tokens":    maxTokens,
+	})
+	if err != nil {
+		return mcpext.SamplingResult{}, err
+	}
+	switch strings.ToLower(strings.TrimSpace(decision.Decision)) {
+	case string(core.PermissionDecisionDeny):
+		outcome.Outcome, outcome.DecidedBy = "denied", "hook"
+		outcome.Reason = firstNonEmpty(firstNonEmptyMetadata(decision.Metadata, "reason", "stop_reason"), "sampling denied by hook policy")
+		report()
+		return mcpext.SamplingResult{}, mcpext.DeniedError(outcome.Reason)
+	case string(core.PermissionDecisionAsk):
+		approval = mcpext.SamplingApprovalAsk
+	}
+
+	outcome.DecidedBy = "policy"
+	switch approval {
+	case mcpext.SamplingApprovalDeny:
+		outcome.Outcome, outcome.Reason = "denied", "sampling is denied for MCP server "+server
+		report()
+		return mcpext.SamplingResult{}, mcpext.DeniedError(outcome.Reason)
+	case mcpext.SamplingApprovalAsk:
+		answer, err := r.Waiters.WaitForAnswer(ctx, samplingQuestion(server, toolName, req, maxTokens))
+		if err != nil {
+			outcome.Outcome, outcome.DecidedBy, outcome.Reason = "denied", "user", err.Error()
+			report()
+			return mcpext.SamplingResult{}, mcpext.DeniedError("sampling request was not answered")
+		}
+		outcome.DecidedBy = "user"
+		if answer.SelectedOptionID != samplingOptionAllow {
+			outcome.Outcome, outcome.Reason = "denied", firstNonEmpty(answer.Text, "sampling denied by user")
+			report()
+			return mcpext.SamplingResult{}, mcpext.DeniedError(outcome.Reason)
+		}
+	}
+
+	if r.NewProvider == nil {
+		outcome.Outcome, outcome.Reason = "failed", "model provider is not configured"
+		report()
+		return mcpext.SamplingResult{}, model.ErrProviderMissing
+	}
+	provider, err := r.NewProvider(maxTokens)
+	if err != nil {
+		outcome.Outcome, outcome.Reason = "failed", err.Error()
+		report()
+		return mcpext.SamplingResult{}, err
+	}
+	turn, err := provider.Turn(ctx, model.TurnRequest{
+		SystemPrompt: strings.TrimSpace(req.SystemPrompt),
+		UserInput:    renderSamplingMessages(req.Messages),
+	})
+	if err != nil {
+		outcome.Outcome, outcome.Reason = "failed", err.Error()
+		report()
+		return mcpext.SamplingResult{}, err
+	}
+	outcome.Outcome = "allowed"
+	outcome.UsageTokens = sumUsageTokens(turn.Usage)
+	report()
+	return mcpext.SamplingResult{Text: strings.TrimSpace(turn.AssistantText), Model: r.Model}, nil
+}
+
+// Elicit asks the user for the fields server requested through the
+// pending-question flow.
+func (r mcpServerRequests) Elicit(ctx context.Context, server string, req mcpext.ElicitationRequest) (mcpext.ElicitationResult, error) {
+	toolName := mcpServerRequestToolName(server, "elicitation")
+	outcome := mcpServerRequestOutcome{}
+	report := func() { r.emit(server, "elicitation/create", toolName, outcome) }
+
+	decision, err := r.dispatchHook(ctx, server, "elicitation/create", toolName, map[string]any{
+		"message":          req.Message,
+		"requested_schema": req.RequestedSchema,
+	})
+	if err != nil {
+		return mcpext.ElicitationResult{}, err
+	}
+	if strings.EqualFold(strings.TrimSpace(decision.Decision), string(core.PermissionDecisionDeny)) {
+		outcome.Outcome, outcome.DecidedBy = mcpext.ElicitationDecline, "hook"
+		outcome.Reason = firstNonEmpty(firstNonEmptyMetadata(decision.Metadata, "reason", "stop_reason"), "elicitation denied by hook policy")
+		report()
+		return mcpext.ElicitationResult{Action: mcpext.ElicitationDecline}, nil
+	}
+
+	fields := elicitationFields(req.RequestedSchema)
+	answer, err := r.Waiters.WaitForAnswer(ctx, elicitationQuestion(server, toolName, req.Message, fields))
+	outcome.DecidedBy = "user"
+	if err != nil {
+		outcome.Outcome, outcome.Reason = mcpext.ElicitationCancel, err.Error()
+		report()
+		return mcpext.ElicitationResult{Action: mcpext.ElicitationCancel}, nil
+	}
+	if answer.SelectedOptionID == elicitationOptionSkip {
+		outcome.Outcome = mcpext.ElicitationDecline
+		report()
+		return mcpext.ElicitationResult{Action: mcpext.ElicitationDecline}, nil
+	}
+	content, err := elicitationContent(fields, answer)
+	if err != nil {
+		outcome.Outcome, outcome.Reason = mcpext.ElicitationCancel, err.Error()
+		report()
+		return mcpext.ElicitationResult{Action: mcpext.ElicitationCancel}, nil
+	}
+	outcome.Outcome = mcpext.ElicitationAccept
+	report()
+	return mcpext.ElicitationResult{Action: mcpext.ElicitationAccept, Content: content}, nil
+}
+
+func (r mcpServerRequests) dispatchHook(ctx context.Context, server string, method string, toolName string, input map[string]any) (core.HookDecision, error) {
+	if r.Hooks == nil {
+		return core.HookDecision{}, nil
+	}
+	decision, err := r.Hooks.Dispatch(ctx, core.HookEvent{
+		Type:      eventMCPServerRequest,
+		SessionID: r.SessionID,
+		RunID:     r.RunID,
+		Payload: map[string]any{
+			"tool_name":  toolName,
+			"server":     server,
+			"method":     method,
+			"tool_input": input,
+		},
+	})
+	if err != nil {
+		return core.HookDecision{}, fmt.Errorf("dispatch %s hook failed: %w", eventMCPServerRequest, err)
+	}
+	return decision, nil
+}
+
+func (r mcpServerRequests) emit(server string, method string, toolName string, outcome mcpServerRequestOutcome) {
+	if r.EmitOutputDelta == nil {
+		return
+	}
+	output := map[string]any{
+		"server":     server,
+		"method":     method,
+		"outcome":    outcome.Outcome,
+		"decided_by": outcome.DecidedBy,
+	}
+	if outcome.Reason != "" {
+		output["reason"] = outcome.Reason
+	}
+	if outcome.MaxTokens > 0 {
+		output["max_tokens"] = outcome.MaxTokens
+	}
+	if outcome.UsageTokens > 0 {
+		output["usage_tokens"] = outcome.UsageTokens
+	}
+	r.EmitOutputDelta(core.OutputDeltaPayload{
+		Stage:  stageMCPServerRequest,
+		Name:   toolName,
+		Output: output,
+	})
+}
+
+func mcpServerRequestToolName(server string, kind string) string {
+	return "mcp__" + strings.TrimSpace(server) + "__" + kind
+}
+
+func samplingQuestion(server string, toolName string, req mcpext.SamplingRequest, maxTokens int) interaction.PendingUserQuestion {
+	prompt := renderSamplingMessages(req.Messages)
+	if len(prompt) > 500 {
+		prompt = strings.ToValidUTF8(prompt[:500], "") + "..."
+	}
+	return interaction.PendingUserQuestion{
+		QuestionID: "mcp_sampling_" + strconv.FormatInt(mcpServerRequestSeq.Add(1), 10),
+		Question:   fmt.Sprintf("MCP server %q asks to run a model completion (up to %d tokens):\n%s", server, maxTokens, prompt),
+		Options: []interaction.QuestionOption{
+			{ID: samplingOptionAllow, Label: "Allow"},
+			{ID: samplingOptionDeny, Label: "Deny"},
+		},
+		RecommendedOptionID: samplingOptionDeny,
+		AllowText:           true,
+		Required:            true,
+		ToolName:            toolName,
+	}
+}
+
+// elicitationField is one property of a requested schema.
+type elicitationField struct {
+	Name     string
+	Type     string
+	Enum     []string
+	Required bool
+}
+
+func elicitationFields(schema map[string]any) []elicitationField {
+	properties, _ := schema["properties"].(map[string]any)
+	required := map[string]bool{}
+	if items, ok := schema["required"].([]any); ok {
+		for _, item := range items {
+			if name, ok := item.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+	fields := make([]elicitationField, 0, len(properties))
+	for name, raw := range properties {
+		property, _ := raw.(map[string]any)
+		field := elicitationField{Name: name, Required: required[name]}
+		field.Type, _ = property["type"].(string)
+		if values, ok := property["enum"].([]any); ok {
+			for _, value := range values {
+				field.Enum = append(field.Enum, fmt.Sprint(value))
+			}
+		}
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields
+}
+
+// elicitationQuestion offers a single enum or boolean field as options and
+// asks for anything else as text: the value itself for one field, a JSON
+// object for several.
+func elicitationQuestion(server string, toolName string, message string, fields []elicitationField) interaction.PendingUserQuestion {
+	question := interaction.PendingUserQuestion{
+		QuestionID: "mcp_elicitation_" + strconv.FormatInt(mcpServerRequestSeq.Add(1), 10),
+		Question:   fmt.Sprintf("MCP server %q asks: %s", server, firstNonEmpty(message, "please provide input")),
+		AllowText:  true,
+		Required:   true,
+		ToolName:   toolName,
+	}
+	if len(fields) == 1 {
+		field := fields[0]
+		switch {
+		case len(field.Enum) > 0:
+			for _, value := range field.Enum {
+				question.Options = append(question.Options, interaction.QuestionOption{ID: "value:" + value, Label: value})
+			}
+			question.AllowText = false
+		case field.Type == "boolean":
+			question.Options = append(question.Options,
+				interaction.QuestionOption{ID: "value:true", Label: "Yes"},
+				interaction.QuestionOption{ID: "value:false", Label: "No"},
+			)
+			question.AllowText = false
+		default:
+			question.Question += fmt.Sprintf("\nAnswer with the %s (%s).", field.Name, firstNonEmpty(field.Type, "string"))
+		}
+	} else if len(fields) > 1 {
+		names := make([]string, 0, len(fields))
+		for _, field := range fields {
+			label := field.Name + " (" + firstNonEmpty(field.Type, "string")
+			if field.Required {
+				label += ", required"
+			}
+			names = append(names, label+")")
+		}
+		question.Question += "\nAnswer with a JSON object of: " + strings.Join(names, ", ") + "."
+	}
+	question.Options = append(question.Options, interaction.QuestionOption{ID: elicitationOptionSkip, Label: "Decline"})
+	return question
+}
+
+func elicitationContent(fields []elicitationField, answer executor.UserAnswer) (map[string]any, error) {
+	value := strings.TrimSpace(answer.Text)
+	if selected, ok := strings.CutPrefix(answer.SelectedOptionID, "value:"); ok {
+		value = selected
+	}
+	switch len(fields) {
+	case 0:
+		if value == "" {
+			return map[string]any{}, nil
+		}
+		return map[string]any{"value": value}, nil
+	case 1:
+		coerced, err := coerceElicitationValue(fields[0], value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{fields[0].Name: coerced}, nil
+	}
+	content := map[string]any{}
+	if err := json.Unmarshal([]byte(value), &content); err != nil {
+		return nil, fmt.Errorf("answer must be a JSON object: %w", err)
+	}
+	for _, field := range fields {
+		if _, ok := content[field.Name]; !ok && field.Required {
+			return nil, fmt.Errorf("answer is missing required field %q", field.Name)
+		}
+	}
+	return content, nil
+}
+
+func coerceElicitationValue(field elicitationField, value string) (any, error) {
+	if value == "" && field.Required {
+		return nil, fmt.Errorf("answer is missing required field %q", field.Name)
+	}
+	switch field.Type {
+	case "boolean":
+		return strconv.ParseBool(value)
+	case "integer":
+		return strconv.Atoi(value)
+	case "number":
+		return strconv.ParseFloat(value, 64)
+	default:
+		return value, nil
+	}
+}
+
+func samplingMessagesToMaps(messages []mcpext.SamplingMessage) []map[string]any {
+	out := make([]map[string]any, 0, len(messages))
+	for _, message := range messages {
+		out = append(out, map[string]any{"role": message.Role, "text": message.Text})
+	}
+	return out
+}
+
+// renderSamplingMessages flattens the conversation into one user turn,
+// since providers take a single prompt per turn.
+func renderSamplingMessages(messages []mcpext.SamplingMessage) string {
+	if len(messages) == 1 {
+		return strings.TrimSpace(messages[0].Text)
+	}
+	lines := make([]string, 0, len(messages))
+	for _, message := range messages {
+		lines = append(lines, firstNonEmpty(message.Role, "user")+": "+strings.TrimSpace(message.Text))
+	}
+	return strings.Join(lines, "\n\n")
+}
+
+// samplingModelConfig caps completions of config at maxTokens.
+func samplingModelConfig(config resolvedModelConfig, maxTokens int) resolvedModelConfig {
+	params := cloneMapAny(config.Params)
+	if params == nil {
+		params = map[string]any{}
+	}
+	if maxTokens > 0 {
+		switch config.ProviderName {
+		case "google", "gemini":
+			generation, _ := params["generationConfig"].(map[string]any)
+			generation = cloneMapAny(generation)
+			if generation == nil {
+				generation = map[string]any{}
+			}
+			generation["maxOutputTokens"] = maxTokens
+			params["generationConfig"] = generation
+		default:
+			params["max_tokens"] = maxTokens
+		}
+	}
+	config.Params = params
+	return config
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if trimmed := strings.TrimSpace(value); trimmed != "" {
+			return trimmed
+		}
+	}
+	return ""
+}
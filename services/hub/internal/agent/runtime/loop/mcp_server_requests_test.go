@@ -0,0 +1,384 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package loop
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"goyais/services/hub/internal/agent/core"
+	mcpext "goyais/services/hub/internal/agent/extensions/mcp"
+	"goyais/services/hub/internal/agent/policy/approval"
+	"goyais/services/hub/internal/agent/runtime/model"
+	"goyais/services/hub/internal/agent/runtime/model/codec"
+)
+
+type providerFunc func(ctx context.Context, req model.TurnRequest) (codec.TurnResult, error)
+
+func (f providerFunc) Turn(ctx context.Context, req model.TurnRequest) (codec.TurnResult, error) {
+	return f(ctx, req)
+}
+
+// answeringServerRequests wires mcpServerRequests to a router that answers
+// every question with selected and text.
+func answeringServerRequests(t *testing.T, selected string, text string) (*mcpServerRequests, *[]core.OutputDeltaPayload) {
+	t.Helper()
+	router := approval.NewRouter(8)
+	runID := core.RunID("run_mcp_server_requests")
+	router.Register(runID)
+	t.Cleanup(func() { router.Unregister(runID) })
+
+	var mu sync.Mutex
+	emitted := []core.OutputDeltaPayload{}
+	emit := func(payload core.OutputDeltaPayload) {
+		mu.Lock()
+		emitted = append(emitted, payload)
+		mu.Unlock()
+		if payload.Stage == "run_user_question_needed" {
+			_ = router.Send(runID, approval.ControlSignal{
+				Action: core.ControlActionAnswer,
+				Answer: &approval.UserAnswer{QuestionID: payload.QuestionID, SelectedOptionID: selected, Text: text},
+			})
+		}
+	}
+	return &mcpServerRequests{
+		RunID:           runID,
+		Policies:        mcpext.DefaultSamplingPolicies(),
+		Waiters:         runtimeApprovalWaiters{RunID: runID, Router: router, EmitOutputDelta: emit},
+		Model:           "gpt-test",
+		EmitOutputDelta: emit,
+	}, &emitted
+}
+
+func lastServerRequestOutput(t *testing.T, emitted []core.OutputDeltaPayload) map[string]any {
+	t.Helper()
+	for index := len(emitted) - 1; index >= 0; index-- {
+		if emitted[index].Stage == stageMCPServerRequest {
+			return emitted[index].Output
+		}
+	}
+	t.Fatalf("expected an %s output delta, got %+v", stageMCPServerRequest, emitted)
+	return nil
+}
+
+func TestMCPServerRequestsSamplingAsksUserAndCapsTokens(t *testing.T) {
+	requests, emitted := answeringServerRequests(t, samplingOptionAllow, "")
+	requests.Policies.Default.MaxTokens = 256
+	builtWith := 0
+	var turn model.TurnRequest
+	requests.NewProvider = func(maxTokens int) (model.Provider, error) {
+		builtWith = maxTokens
+		return providerFunc(func(_ context.Context, req model.TurnRequest) (codec.TurnResult, error) {
+			turn = req
+			return codec.TurnResult{AssistantText: " summary ", Usage: map[string]any{"input_tokens": 10, "output_tokens": 5}}, nil
+		}), nil
+	}
+
+	result, err := requests.CreateMessage(context.Background(), "docs", mcpext.SamplingRequest{
+		SystemPrompt: "be brief",
+		MaxTokens:    4096,
+		Messages:     []mcpext.SamplingMessage{{Role: "user", Text: "summarize"}},
+	})
+	if err != nil {
+		t.Fatalf("create message: %v", err)
+	}
+	if result.Text != "summary" || result.Model != "gpt-test" {
+		t.Fatalf("unexpected sampling result %+v", result)
+	}
+	if builtWith != 256 || turn.SystemPrompt != "be brief" || turn.UserInput != "summarize" {
+		t.Fatalf("expected a 256-token completion of the request, got %d %+v", builtWith, turn)
+	}
+	output := lastServerRequestOutput(t, *emitted)
+	if output["outcome"] != "allowed" || output["decided_by"] != "user" || output["usage_tokens"] != 15 || output["max_tokens"] != 256 {
+		t.Fatalf("unexpected audit output %+v", output)
+	}
+}
+
+func TestMCPServerRequestsHookDenialSkipsModelAndUser(t *testing.T) {
+	requests, emitted := answeringServerRequests(t, samplingOptionAllow, "")
+	requests.Policies.Default.Approval = mcpext.SamplingApprovalAllow
+	requests.NewProvider = func(int) (model.Provider, error) {
+		t.Fatalf("denied sampling must not build a provider")
+		return nil, nil
+	}
+	var seen core.HookEvent
+	requests.Hooks = hookDispatcherFunc(func(_ context.Context, event core.HookEvent) (core.HookDecision, error) {
+		seen = event
+		return core.HookDecision{Decision: "deny", Metadata: map[string]any{"reason": "no sampling here"}}, nil
+	})
+
+	_, err := requests.CreateMessage(context.Background(), "docs", mcpext.SamplingRequest{})
+	if !mcpext.IsDenied(err) || err.Error() != "no sampling here" {
+		t.Fatalf("expected hook denial, got %v", err)
+	}
+	if seen.Type != eventMCPServerRequest || seen.Payload["tool_name"] != "mcp__docs__sampling" {
+		t.Fatalf("unexpected hook event %+v", seen)
+	}
+	if output := lastServerRequestOutput(t, *emitted); output["outcome"] != "denied" || output["decided_by"] != "hook" {
+		t.Fatalf("unexpected audit output %+v", output)
+	}
+
+	elicited, err := requests.Elicit(context.Background(), "docs", mcpext.ElicitationRequest{Message: "token?"})
+	if err != nil || elicited.Action != mcpext.ElicitationDecline {
+		t.Fatalf("expected hook to decline elicitation, got %+v %v", elicited, err)
+	}
+}
+
+func TestMCPServerRequestsSamplingPolicyDeny(t *testing.T) {
+	requests, emitted := answeringServerRequests(t, samplingOptionAllow, "")
+	requests.Policies.Servers = map[string]mcpext.SamplingPolicy{"docs": {Approval: mcpext.SamplingApprovalDeny}}
+
+	if _, err := requests.CreateMessage(context.Background(), "docs", mcpext.SamplingRequest{}); !mcpext.IsDenied(err) {
+		t.Fatalf("expected policy denial, got %v", err)
+	}
+	if output := lastServerRequestOutput(t, *emitted); output["outcome"] != "denied" || output["decided_by"] != "policy" {
+		t.Fatalf("unexpected audit output %+v", output)
+	}
+}
+
+func TestMCPServerRequestsElicitMapsSchemaToQuestion(t *testing.T) {
+	requests, emitted := answeringServerRequests(t, "value:eu", "")
+	result, err := requests.Elicit(context.Background(), "cloud", mcpext.ElicitationRequest{
+		Message: "pick a region",
+		RequestedSchema: map[string]any{"properties": map[string]any{
+			"region": map[string]any{"type": "string", "enum": []any{"us", "eu"}},
+		}},
+	})
+	if err != nil || result.Action != mcpext.ElicitationAccept || result.Content["region"] != "eu" {
+		t.Fatalf("unexpected elicitation result %+v %v", result, err)
+	}
+	var question core.OutputDeltaPayload
+	for _, payload := range *emitted {
+		if payload.Stage == "run_user_question_needed" {
+			question = payload
+		}
+	}
+	if len(question.Options) != 3 || question.Options[2]["id"] != elicitationOptionSkip {
+		t.Fatalf("expected enum options plus decline, got %+v", question.Options)
+	}
+
+	requests, _ = answeringServerRequests(t, "", `{"name":"api","replicas":3}`)
+	result, err = requests.Elicit(context.Background(), "cloud", mcpext.ElicitationRequest{
+		RequestedSchema: map[string]any{
+			"properties": map[string]any{
+				"name":     map[string]any{"type": "string"},
+				"replicas": map[string]any{"type": "integer"},
+			},
+			"required": []any{"name"},
+		},
+	})
+	if err != nil || result.Action != mcpext.ElicitationAccept || result.Content["name"] != "api" || result.Content["replicas"] != float64(3) {
+		t.Fatalf("unexpected multi-field elicitation result %+v %v", result, err)
+	}
+}
+
+func TestSamplingModelConfigCapsProviderTokens(t *testing.T) {
+	openai := samplingModelConfig(resolvedModelConfig{ProviderName: "openai", Params: map[string]any{"temperature": 0.2}}, 128)
+	if openai.Params["max_tokens"] != 128 || openai.Params["temperature"] != 0.2 {
+		t.Fatalf("unexpected openai params %+v", openai.Params)
+	}
+	base := map[string]any{"generationConfig": map[string]any{"temperature": 0.5}}
+	google := samplingModelConfig(resolvedModelConfig{ProviderName: "google", Params: base}, 64)
+	generation, _ := google.Params["generationConfig"].(map[string]any)
+	if generation["maxOutputTokens"] != 64 || generation["temperature"] != 0.5 {
+		t.Fatalf("unexpected google params %+v", google.Params)
+	}
+	if _, mutated := base["generationConfig"].(map[string]any)["maxOutputTokens"]; mutated {
+		t.Fatalf("expected the session config to be left unchanged")
+	}
+}
+
+func TestEngineMCPServerRequestsUseTheHookDispatcher(t *testing.T) {
+	var toolReply string
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		if calls == 1 {
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"","tool_calls":[{"id":"call_sample","type":"function","function":{"name":"mcp__helper__sample","arguments":"{}"}}]}}]}`))
+			return
+		}
+		var body struct {
+			Messages []map[string]any `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		for _, message := range body.Messages {
+			if message["role"] == "tool" {
+				toolReply, _ = message["content"].(string)
+			}
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"done"}}]}`))
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var seen []core.HookEvent
+	engine := NewEngineWithDeps(Dependencies{
+		HookDispatcher: hookDispatcherFunc(func(_ context.Context, event core.HookEvent) (core.HookDecision, error) {
+			mu.Lock()
+			seen = append(seen, event)
+			mu.Unlock()
+			if event.Type == eventMCPServerRequest {
+				return core.HookDecision{Decision: "deny", Metadata: map[string]any{"reason": "no sampling from hooks"}}, nil
+			}
+			return core.HookDecision{Decision: "allow"}, nil
+		}),
+	})
+	session, err := engine.StartSession(context.Background(), core.StartSessionRequest{WorkingDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("start session: %v", err)
+	}
+	t.Cleanup(func() { mcpext.DefaultSessionPool().CloseSession(string(session.SessionID)) })
+	sub, err := engine.Subscribe(context.Background(), string(session.SessionID), "")
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer sub.Close()
+
+	runID, err := engine.Submit(context.Background(), string(session.SessionID), core.UserInput{
+		Text: "sample something",
+		RuntimeConfig: &core.RuntimeConfig{
+			Model: core.RuntimeModelConfig{ProviderName: "openai", Endpoint: server.URL, ModelName: "gpt-test"},
+			Tooling: core.RuntimeToolingConfig{
+				PermissionMode: core.PermissionModeBypassPermissions,
+				MCPServers: []core.MCPServerConfig{{
+					Name:      "helper",
+					Transport: "stdio",
+					Command:   "GO_WANT_LOOP_MCP_HELPER=1 exec " + strconv.Quote(os.Args[0]) + " -test.run ^TestLoopMCPHelperProcess$",
+					Tools:     []string{"sample"},
+				}},
+				AlwaysLoadedCapabilities: []core.CapabilityDescriptor{{
+					ID:          "mcp:helper:sample",
+					Kind:        core.CapabilityKindMCPTool,
+					Name:        "mcp__helper__sample",
+					Source:      "helper",
+					InputSchema: map[string]any{"type": "object"},
+					RiskLevel:   "low",
+				}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	waitForRunEvent(t, sub.Events(), runID, core.RunEventTypeRunCompleted, 10*time.Second)
+
+	mu.Lock()
+	defer mu.Unlock()
+	var request *core.HookEvent
+	for index := range seen {
+		if seen[index].Type == eventMCPServerRequest {
+			request = &seen[index]
+		}
+	}
+	if request == nil || request.SessionID != session.SessionID || request.RunID != core.RunID(runID) || request.Payload["tool_name"] != "mcp__helper__sampling" {
+		t.Fatalf("expected a run-scoped MCPServerRequest hook event, got %+v", seen)
+	}
+	if !strings.Contains(toolReply, "no sampling from hooks") {
+		t.Fatalf("expected the server to receive the hook denial, got %q", toolReply)
+	}
+}
+
+func TestEngineCloseSessionShutsDownPooledMCPServers(t *testing.T) {
+	engine := NewEngineWithDeps(Dependencies{})
+	session, err := engine.StartSession(context.Background(), core.StartSessionRequest{WorkingDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("start session: %v", err)
+	}
+	sessionID := string(session.SessionID) + "_close"
+	server := mcpext.ServerConfig{
+		Name:      "close-helper",
+		Transport: "stdio",
+		Command:   "GO_WANT_LOOP_MCP_HELPER=1 exec " + strconv.Quote(os.Args[0]) + " -test.run ^TestLoopMCPHelperProcess$",
+	}
+	pool := mcpext.DefaultSessionPool()
+	if _, err := pool.Connect(context.Background(), sessionID, server, 5*time.Second); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if sessions := pool.Sessions(server.Name); len(sessions) != 1 {
+		t.Fatalf("expected one pooled session, got %+v", sessions)
+	}
+
+	engine.CloseSession(context.Background(), sessionID)
+	if sessions := pool.Sessions(server.Name); len(sessions) != 0 {
+		t.Fatalf("expected the session's servers to be closed, got %+v", sessions)
+	}
+}
+
+// TestLoopMCPHelperProcess is a stdio MCP server whose "sample" tool asks
+// the client for a completion and returns the client's answer as text.
+func TestLoopMCPHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_LOOP_MCP_HELPER") != "1" {
+		return
+	}
+	reader := bufio.NewReader(os.Stdin)
+	write := func(payload map[string]any) {
+		body, _ := json.Marshal(payload)
+		fmt.Fprintf(os.Stdout, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+	var pendingCall any
+	for {
+		frame, err := readHelperFrame(reader)
+		if err != nil {
+			os.Exit(0)
+		}
+		payload := map[string]any{}
+		if json.Unmarshal(frame, &payload) != nil {
+			continue
+		}
+		id := payload["id"]
+		if id == "srv-sample" && payload["method"] == nil {
+			answer, _ := json.Marshal(map[string]any{"result": payload["result"], "error": payload["error"]})
+			write(map[string]any{"jsonrpc": "2.0", "id": pendingCall, "result": map[string]any{
+				"content": []map[string]any{{"type": "text", "text": string(answer)}},
+			}})
+			continue
+		}
+		switch payload["method"] {
+		case "initialize":
+			write(map[string]any{"jsonrpc": "2.0", "id": id, "result": map[string]any{}})
+		case "tools/list":
+			write(map[string]any{"jsonrpc": "2.0", "id": id, "result": map[string]any{"tools": []map[string]any{{"name": "sample"}}}})
+		case "tools/call":
+			pendingCall = id
+			write(map[string]any{"jsonrpc": "2.0", "id": "srv-sample", "method": "sampling/createMessage", "params": map[string]any{
+				"messages":  []map[string]any{{"role": "user", "content": map[string]any{"type": "text", "text": "summarize"}}},
+				"maxTokens": 64,
+			}})
+		}
+	}
+}
+
+func readHelperFrame(reader *bufio.Reader) ([]byte, error) {
+	length := 0
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if value, ok := strings.CutPrefix(strings.ToLower(line), "content-length:"); ok {
+			length, _ = strconv.Atoi(strings.TrimSpace(value))
+		}
+	}
+	body := make([]byte, length)
+	_, err := io.ReadFull(reader, body)
+	return body, err
+}
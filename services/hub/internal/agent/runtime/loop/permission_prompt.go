@@ -0,0 +1,138 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package loop
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"goyais/services/hub/internal/agent/core"
+	mcpext "goyais/services/hub/internal/agent/extensions/mcp"
+	"goyais/services/hub/internal/agent/tools/executor"
+)
+
+const (
+	// metadataPermissionPromptTool carries --permission-prompt-tool on runs
+	// submitted without a RuntimeConfig snapshot.
+	metadataPermissionPromptTool   = "permission_prompt_tool"
+	defaultPermissionPromptTimeout = 60 * time.Second
+)
+
+// permissionPromptDelegate answers approval checkpoints by calling an MCP tool
+// instead of waiting for a human control action. Any failure, including a
+// timeout, resolves to deny.
+type permissionPromptDelegate struct {
+	Tool    string
+	Timeout time.Duration
+	Call    func(ctx context.Context, qualifiedToolName string, input map[string]any) (map[string]any, error)
+}
+
+// permissionPromptVerdict is the normalized answer of the prompt tool.
+type permissionPromptVerdict struct {
+	Action       executor.ApprovalAction
+	UpdatedInput map[string]any
+	Message      string
+	TimedOut     bool
+}
+
+// newPermissionPromptDelegate calls tool through the session's pooled MCP
+// connections, so the prompt server is started once per session.
+func newPermissionPromptDelegate(sessionID core.SessionID, tool string, timeoutMS int, workingDir string, servers []core.MCPServerConfig) *permissionPromptDelegate {
+	tool = strings.TrimSpace(tool)
+	if !strings.HasPrefix(strings.ToLower(tool), "mcp__") {
+		return nil
+	}
+	timeout := defaultPermissionPromptTimeout
+	if timeoutMS > 0 {
+		timeout = time.Duration(timeoutMS) * time.Millisecond
+	}
+	configs := []mcpext.ServerConfig{}
+	if strings.TrimSpace(workingDir) != "" {
+		if projectServers, err := mcpext.LoadProjectServerConfigs(workingDir); err == nil {
+			configs = append(configs, projectServers...)
+		}
+	}
+	// Runtime snapshot servers are appended last so they win on name clashes.
+	configs = append(configs, convertToMCPExtServers(servers)...)
+	manager := mcpext.NewSessionClientManager(mcpext.DefaultSessionPool(), string(sessionID), configs, timeout)
+	return &permissionPromptDelegate{Tool: tool, Timeout: timeout, Call: manager.Call}
+}
+
+func (d permissionPromptDelegate) decide(ctx context.Context, req executor.ApprovalRequest, riskLevel string) (permissionPromptVerdict, error) {
+	callCtx, cancel := context.WithTimeout(ctx, d.Timeout)
+	defer cancel()
+	result, err := d.Call(callCtx, d.Tool, map[string]any{
+		"tool_name":   strings.TrimSpace(req.ToolName),
+		"tool_use_id": strings.TrimSpace(req.CallID),
+		"input":       approvalInput(req.Input),
+		"risk_level":  strings.TrimSpace(riskLevel),
+		"reason":      strings.TrimSpace(req.Reason),
+	})
+	if ctx.Err() != nil {
+		return permissionPromptVerdict{}, ctx.Err()
+	}
+	if errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+		return permissionPromptVerdict{
+			Action:   executor.ApprovalActionDeny,
+			Message:  "permission prompt tool " + d.Tool + " timed out after " + d.Timeout.String(),
+			TimedOut: true,
+		}, nil
+	}
+	if err != nil {
+		return permissionPromptVerdict{
+			Action:  executor.ApprovalActionDeny,
+			Message: "permission prompt tool " + d.Tool + " failed: " + strings.TrimSpace(err.Error()),
+		}, nil
+	}
+	return parsePermissionPromptResult(d.Tool, result), nil
+}
+
+// parsePermissionPromptResult reads {"behavior":"allow"|"deny",
+// "updatedInput":{...},"message":"..."} from structuredContent or the text
+// content of the tool result.
+func parsePermissionPromptResult(tool string, result map[string]any) permissionPromptVerdict {
+	if ok, _ := result["ok"].(bool); !ok {
+		message := strings.TrimSpace(asString(result["output"]))
+		if message == "" {
+			message = "permission prompt tool " + tool + " returned an error"
+		}
+		return permissionPromptVerdict{Action: executor.ApprovalActionDeny, Message: message}
+	}
+	decision := map[string]any{}
+	if structured, _ := result["structured_content"].(map[string]any); structured != nil {
+		decision = structured
+	}
+	if len(decision) == 0 {
+		_ = json.Unmarshal([]byte(strings.TrimSpace(asString(result["output"]))), &decision)
+	}
+	behavior := strings.ToLower(strings.TrimSpace(asString(decision["behavior"])))
+	message := strings.TrimSpace(asString(decision["message"]))
+	switch behavior {
+	case "allow":
+		verdict := permissionPromptVerdict{Action: executor.ApprovalActionApprove, Message: message}
+		if updated, ok := decision["updatedInput"].(map[string]any); ok {
+			verdict.UpdatedInput = cloneMapAny(updated)
+		}
+		return verdict
+	case "deny":
+		if message == "" {
+			message = "tool call denied by permission prompt tool " + tool
+		}
+		return permissionPromptVerdict{Action: executor.ApprovalActionDeny, Message: message}
+	default:
+		return permissionPromptVerdict{
+			Action:  executor.ApprovalActionDeny,
+			Message: "permission prompt tool " + tool + ` returned no "allow" or "deny" behavior`,
+		}
+	}
+}
+
+func asString(value any) string {
+	text, _ := value.(string)
+	return text
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package loop
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"goyais/services/hub/internal/agent/core"
+	"goyais/services/hub/internal/agent/tools/executor"
+)
+
+func TestRuntimeApprovalWaitersDelegateToPermissionPromptTool(t *testing.T) {
+	var received map[string]any
+	responses := map[string]map[string]any{
+		"call_allow": {"ok": true, "output": `{"behavior":"allow","updatedInput":{"command":"git status --short"}}`},
+		"call_deny":  {"ok": true, "output": `{"behavior":"deny","message":"pushes are blocked in CI"}`},
+		"call_bad":   {"ok": true, "output": "sure"},
+	}
+	delegate := &permissionPromptDelegate{
+		Tool:    "mcp__approver__approve",
+		Timeout: 20 * time.Millisecond,
+		Call: func(ctx context.Context, qualifiedToolName string, input map[string]any) (map[string]any, error) {
+			received = input
+			callID, _ := input["tool_use_id"].(string)
+			if callID == "call_slow" {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return responses[callID], nil
+		},
+	}
+	deltas := make([]core.OutputDeltaPayload, 0, 4)
+	needed := 0
+	waiters := runtimeApprovalWaiters{
+		RunID:              "run_delegate",
+		PermissionPrompt:   delegate,
+		Capabilities:       map[string]core.CapabilityDescriptor{"Bash": {Name: "Bash", RiskLevel: "high"}},
+		EmitOutputDelta:    func(payload core.OutputDeltaPayload) { deltas = append(deltas, payload) },
+		EmitApprovalNeeded: func(core.ApprovalNeededPayload) { needed++ },
+	}
+
+	decision, err := waiters.WaitForApprovalDecision(context.Background(), executor.ApprovalRequest{
+		ToolName: "Bash",
+		CallID:   "call_allow",
+		Input:    map[string]any{"command": "git status"},
+	})
+	if err != nil || decision.Action != executor.ApprovalActionApprove || decision.UpdatedInput["command"] != "git status --short" {
+		t.Fatalf("expected allow with updated input, got %#v, %v", decision, err)
+	}
+	if received["tool_name"] != "Bash" || received["risk_level"] != "high" {
+		t.Fatalf("expected tool name and risk level in prompt input, got %#v", received)
+	}
+
+	decision, _ = waiters.WaitForApprovalDecision(context.Background(), executor.ApprovalRequest{ToolName: "Bash", CallID: "call_deny"})
+	if decision.Action != executor.ApprovalActionDeny || decision.Reason != "pushes are blocked in CI" {
+		t.Fatalf("expected deny with message, got %#v", decision)
+	}
+	decision, _ = waiters.WaitForApprovalDecision(context.Background(), executor.ApprovalRequest{ToolName: "Bash", CallID: "call_bad"})
+	if decision.Action != executor.ApprovalActionDeny {
+		t.Fatalf("expected malformed answer to deny, got %#v", decision)
+	}
+	decision, err = waiters.WaitForApprovalDecision(context.Background(), executor.ApprovalRequest{ToolName: "Bash", CallID: "call_slow"})
+	if err != nil || decision.Action != executor.ApprovalActionDeny {
+		t.Fatalf("expected timeout to fall back to deny, got %#v, %v", decision, err)
+	}
+
+	if needed != 4 || len(deltas) != 4 {
+		t.Fatalf("expected every delegated decision to be recorded, got needed=%d deltas=%d", needed, len(deltas))
+	}
+	last := deltas[3]
+	if last.Stage != "approval_resolved" || last.Delta != "deny" || last.Output["decided_by"] != "mcp__approver__approve" || last.Output["timed_out"] != true {
+		t.Fatalf("unexpected timeout event %#v", last)
+	}
+}
+
+func TestNewPermissionPromptDelegateRequiresQualifiedMCPTool(t *testing.T) {
+	if delegate := newPermissionPromptDelegate("sess_prompt", "stdio", 0, "", nil); delegate != nil {
+		t.Fatalf("expected stdio to stay on the control protocol, got %#v", delegate)
+	}
+	delegate := newPermissionPromptDelegate("sess_prompt", "mcp__approver__approve", 0, t.TempDir(), nil)
+	if delegate == nil || delegate.Timeout != defaultPermissionPromptTimeout {
+		t.Fatalf("expected delegate with default timeout, got %#v", delegate)
+	}
+}
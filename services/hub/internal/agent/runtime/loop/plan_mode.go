@@ -0,0 +1,131 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package loop
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"goyais/services/hub/internal/agent/core"
+	"goyais/services/hub/internal/agent/tools/executor"
+)
+
+// stagePlanResolved marks the output delta that records a plan review outcome.
+const stagePlanResolved = "plan_resolved"
+
+// runModeState holds the permission mode of one run. Approving a plan switches
+// it, so tool calls later in the same run are gated by the new mode.
+type runModeState struct {
+	mu   sync.RWMutex
+	mode string
+}
+
+func newRunModeState(mode string) *runModeState {
+	return &runModeState{mode: strings.TrimSpace(mode)}
+}
+
+func (s *runModeState) Get() string {
+	if s == nil {
+		return ""
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.mode
+}
+
+func (s *runModeState) Set(mode string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.mode = strings.TrimSpace(mode)
+	s.mu.Unlock()
+}
+
+var _ executor.PlanApprovalWaiter = runtimeApprovalWaiters{}
+
+// WaitForPlanApproval implements executor.PlanApprovalWaiter. Outside plan
+// mode the plan is accepted as is; in plan mode the run waits for the user to
+// approve it, optionally choosing the next mode, or to reject it with feedback.
+func (w runtimeApprovalWaiters) WaitForPlanApproval(ctx context.Context, req executor.PlanApprovalRequest) (core.PlanRecord, error) {
+	now := time.Now().UTC()
+	record := core.PlanRecord{
+		ID:        "plan_" + strings.TrimSpace(req.CallID),
+		FromAgent: "main",
+		Title:     core.PlanTitle(req.Plan),
+		Content:   req.Plan,
+		Status:    core.PlanStatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	currentMode := core.PermissionMode(w.Mode.Get())
+	if currentMode != core.PermissionModePlan {
+		record.Status = core.PlanStatusApproved
+		record.NextMode = string(currentMode)
+		return record, nil
+	}
+	if w.Router == nil {
+		return core.PlanRecord{}, fmt.Errorf("approval router is nil")
+	}
+	if w.SetRunState != nil {
+		w.SetRunState(core.RunStateWaitingApproval)
+	}
+	if w.EmitApprovalNeeded != nil {
+		pending := record
+		w.EmitApprovalNeeded(core.ApprovalNeededPayload{
+			ToolName:  strings.TrimSpace(req.ToolName),
+			Input:     map[string]any{"plan": req.Plan},
+			RiskLevel: "low",
+			Plan:      &pending,
+		})
+	}
+
+	signal, err := w.Router.WaitForApprovalSignal(ctx, w.RunID)
+	if err != nil {
+		return core.PlanRecord{}, err
+	}
+	switch signal.Action {
+	case core.ControlActionApprove, core.ControlActionResume:
+		nextMode := signal.Mode
+		if nextMode == "" {
+			nextMode = core.PermissionModeDefault
+		}
+		record.Status = core.PlanStatusApproved
+		record.NextMode = string(nextMode)
+		w.Mode.Set(string(nextMode))
+		if w.SetPermissionMode != nil {
+			w.SetPermissionMode(currentMode, nextMode, record.ID)
+		}
+	case core.ControlActionDeny:
+		record.Status = core.PlanStatusRejected
+		record.Feedback = strings.TrimSpace(signal.Feedback)
+	case core.ControlActionStop:
+		return core.PlanRecord{}, context.Canceled
+	default:
+		return core.PlanRecord{}, fmt.Errorf("unsupported approval action %q", signal.Action)
+	}
+	record.ReviewedBy = "user"
+	record.UpdatedAt = time.Now().UTC()
+
+	if w.EmitOutputDelta != nil {
+		w.EmitOutputDelta(core.OutputDeltaPayload{
+			Stage:  stagePlanResolved,
+			CallID: strings.TrimSpace(req.CallID),
+			Name:   strings.TrimSpace(req.ToolName),
+			Delta:  record.Status,
+			Text:   record.Feedback,
+			Output: map[string]any{
+				"plan_id": record.ID,
+				"title":   record.Title,
+				"status":  record.Status,
+				"mode":    record.NextMode,
+			},
+		})
+	}
+	return record, nil
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package loop
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"goyais/services/hub/internal/agent/core"
+	"goyais/services/hub/internal/agent/policy/approval"
+	"goyais/services/hub/internal/agent/runtime/session"
+	"goyais/services/hub/internal/agent/tools/executor"
+)
+
+func TestRuntimeApprovalWaitersWaitForPlanApproval(t *testing.T) {
+	router := approval.NewRouter(8)
+	runID := core.RunID("run_plan_review")
+	router.Register(runID)
+	defer router.Unregister(runID)
+
+	mode := newRunModeState(string(core.PermissionModePlan))
+	needed := []core.ApprovalNeededPayload{}
+	deltas := []core.OutputDeltaPayload{}
+	transitions := []string{}
+	waiters := runtimeApprovalWaiters{
+		RunID:              runID,
+		Router:             router,
+		Mode:               mode,
+		EmitApprovalNeeded: func(payload core.ApprovalNeededPayload) { needed = append(needed, payload) },
+		EmitOutputDelta:    func(payload core.OutputDeltaPayload) { deltas = append(deltas, payload) },
+		SetPermissionMode: func(from core.PermissionMode, to core.PermissionMode, planID string) {
+			transitions = append(transitions, string(from)+"->"+string(to)+"@"+planID)
+		},
+	}
+	req := executor.PlanApprovalRequest{CallID: "call_plan", ToolName: "ExitPlanMode", Plan: "# Add caching\n\n1. Wrap the client."}
+
+	if err := router.Send(runID, approval.ControlSignal{Action: core.ControlActionDeny, Feedback: "cover invalidation"}); err != nil {
+		t.Fatalf("send deny failed: %v", err)
+	}
+	record, err := waiters.WaitForPlanApproval(context.Background(), req)
+	if err != nil {
+		t.Fatalf("wait for rejected plan failed: %v", err)
+	}
+	if record.Status != core.PlanStatusRejected || record.Feedback != "cover invalidation" || mode.Get() != string(core.PermissionModePlan) {
+		t.Fatalf("expected rejected plan to keep plan mode, got %#v mode=%s", record, mode.Get())
+	}
+	if len(needed) != 1 || needed[0].Plan == nil || needed[0].Plan.Title != "Add caching" || needed[0].Plan.Status != core.PlanStatusPending {
+		t.Fatalf("expected approval checkpoint carrying the pending plan, got %#v", needed)
+	}
+
+	if err := router.Send(runID, approval.ControlSignal{Action: core.ControlActionApprove, Mode: core.PermissionModeAcceptEdits}); err != nil {
+		t.Fatalf("send approve failed: %v", err)
+	}
+	record, err = waiters.WaitForPlanApproval(context.Background(), req)
+	if err != nil {
+		t.Fatalf("wait for approved plan failed: %v", err)
+	}
+	if record.Status != core.PlanStatusApproved || record.NextMode != string(core.PermissionModeAcceptEdits) || mode.Get() != string(core.PermissionModeAcceptEdits) {
+		t.Fatalf("expected approved plan to switch mode, got %#v mode=%s", record, mode.Get())
+	}
+	if len(transitions) != 1 || transitions[0] != "plan->acceptEdits@plan_call_plan" {
+		t.Fatalf("unexpected mode transitions %#v", transitions)
+	}
+	if len(deltas) != 2 || deltas[1].Stage != stagePlanResolved || deltas[1].Output["mode"] != string(core.PermissionModeAcceptEdits) {
+		t.Fatalf("unexpected plan resolution deltas %#v", deltas)
+	}
+
+	record, err = waiters.WaitForPlanApproval(context.Background(), req)
+	if err != nil || record.Status != core.PlanStatusApproved || len(needed) != 2 {
+		t.Fatalf("expected plans outside plan mode to pass without review, got %#v %v", record, err)
+	}
+}
+
+func TestEngineRecordsPlanModeTransitionOnSession(t *testing.T) {
+	manager := session.NewManager(session.Dependencies{})
+	engine := NewEngineWithDeps(Dependencies{
+		Executor: executorFunc(func(_ context.Context, req ExecuteRequest) (ExecuteResult, error) {
+			req.SetPermissionMode(core.PermissionModePlan, core.PermissionModeAcceptEdits, "plan_call_1")
+			return ExecuteResult{Output: "ok"}, nil
+		}),
+		SessionManager: manager,
+	})
+	handle, err := engine.StartSession(context.Background(), core.StartSessionRequest{WorkingDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("start session failed: %v", err)
+	}
+	if _, err := engine.Submit(context.Background(), string(handle.SessionID), core.UserInput{Text: "plan it"}); err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+
+	var state session.State
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		state, err = manager.Get(handle.SessionID)
+		if err == nil && len(state.ModeTransitions) > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if state.PermissionMode != core.PermissionModeAcceptEdits || len(state.ModeTransitions) != 1 {
+		t.Fatalf("expected one recorded plan transition, got %#v (%v)", state, err)
+	}
+	transition := state.ModeTransitions[0]
+	if transition.From != core.PermissionModePlan || transition.Reason != "plan_approved" || transition.PlanID != "plan_call_1" {
+		t.Fatalf("unexpected transition %#v", transition)
+	}
+}
+
+func TestEngineDenyWithFeedbackResumesOnlyPlanReviews(t *testing.T) {
+	for name, plan := range map[string]*core.PlanRecord{"plan": {ID: "plan_call_1"}, "tool": nil} {
+		t.Run(name, func(t *testing.T) {
+			waiting := make(chan context.Context, 1)
+			release := make(chan struct{})
+			defer close(release)
+			engine := NewEngineWithDeps(Dependencies{
+				Executor: executorFunc(func(ctx context.Context, req ExecuteRequest) (ExecuteResult, error) {
+					req.SetRunState(core.RunStateWaitingApproval)
+					req.EmitApprovalNeeded(core.ApprovalNeededPayload{ToolName: "ExitPlanMode", Plan: plan})
+					waiting <- ctx
+					<-release
+					return ExecuteResult{}, nil
+				}),
+			})
+			session, err := engine.StartSession(context.Background(), core.StartSessionRequest{WorkingDir: t.TempDir()})
+			if err != nil {
+				t.Fatalf("start session: %v", err)
+			}
+			runID, err := engine.Submit(context.Background(), string(session.SessionID), core.UserInput{Text: "plan it"})
+			if err != nil {
+				t.Fatalf("submit: %v", err)
+			}
+			runCtx := <-waiting
+			if err := engine.Control(context.Background(), core.ControlRequest{RunID: runID, Action: core.ControlActionDeny, Feedback: "smaller steps"}); err != nil {
+				t.Fatalf("control: %v", err)
+			}
+			if cancelled := runCtx.Err() != nil; cancelled != (plan == nil) {
+				t.Fatalf("run cancelled = %v for %s approval", cancelled, name)
+			}
+		})
+	}
+}
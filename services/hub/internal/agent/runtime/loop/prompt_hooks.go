@@ -0,0 +1,120 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package loop
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"goyais/services/hub/internal/agent/core"
+)
+
+const (
+	eventSessionStart     = "SessionStart"
+	eventUserPromptSubmit = "UserPromptSubmit"
+
+	promptSourceSessionStartHook = "hook_session_start"
+	promptSourcePromptSubmitHook = "hook_user_prompt_submit"
+)
+
+// PromptBlockedError reports a prompt rejected by a UserPromptSubmit hook.
+type PromptBlockedError struct {
+	PolicyID string
+	Reason   string
+}
+
+func (e *PromptBlockedError) Error() string {
+	reason := strings.TrimSpace(e.Reason)
+	if reason == "" {
+		reason = "prompt blocked by hook policy"
+	}
+	return reason
+}
+
+// promptHookResult is what prompt-level hooks contribute to one run.
+type promptHookResult struct {
+	Prompt   string
+	Sections []core.PromptSection
+}
+
+// runPromptHooks dispatches SessionStart on the first run of a session and
+// UserPromptSubmit on every run. Hooks may add context sections, rewrite the
+// prompt, or block it with a *PromptBlockedError.
+func (e *Engine) runPromptHooks(ctx context.Context, run *runRuntime) (promptHookResult, error) {
+	result := promptHookResult{Prompt: run.input.Text}
+	if e.hookDispatcher == nil {
+		return result, nil
+	}
+
+	if e.markSessionStarted(run.sessionID) {
+		decision, err := e.hookDispatcher.Dispatch(ctx, core.HookEvent{
+			Type:      eventSessionStart,
+			SessionID: run.sessionID,
+			RunID:     run.id,
+			Payload: map[string]any{
+				"source": "startup",
+				"cwd":    run.workingDir,
+			},
+		})
+		if err != nil {
+			return promptHookResult{}, fmt.Errorf("dispatch SessionStart hook failed: %w", err)
+		}
+		result.Sections = appendHookContextSection(result.Sections, promptSourceSessionStartHook, decision.Metadata)
+	}
+
+	decision, err := e.hookDispatcher.Dispatch(ctx, core.HookEvent{
+		Type:      eventUserPromptSubmit,
+		SessionID: run.sessionID,
+		RunID:     run.id,
+		Payload: map[string]any{
+			"prompt": run.input.Text,
+			"cwd":    run.workingDir,
+		},
+	})
+	if err != nil {
+		return promptHookResult{}, fmt.Errorf("dispatch UserPromptSubmit hook failed: %w", err)
+	}
+	if strings.EqualFold(strings.TrimSpace(decision.Decision), string(core.PermissionDecisionDeny)) {
+		return promptHookResult{}, &PromptBlockedError{
+			PolicyID: strings.TrimSpace(decision.MatchedPolicyID),
+			Reason:   firstNonEmptyMetadata(decision.Metadata, "reason", "stop_reason"),
+		}
+	}
+	if updated := firstNonEmptyMetadata(decision.Metadata, "updated_prompt"); updated != "" {
+		result.Prompt = updated
+	}
+	result.Sections = appendHookContextSection(result.Sections, promptSourcePromptSubmitHook, decision.Metadata)
+	return result, nil
+}
+
+// markSessionStarted reports whether this call is the first for sessionID.
+func (e *Engine) markSessionStarted(sessionID core.SessionID) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	session := e.sessions[sessionID]
+	if session == nil || session.hooksStarted {
+		return false
+	}
+	session.hooksStarted = true
+	return true
+}
+
+func appendHookContextSection(sections []core.PromptSection, source string, metadata map[string]any) []core.PromptSection {
+	content := firstNonEmptyMetadata(metadata, "additional_context")
+	if content == "" {
+		return sections
+	}
+	return append(sections, core.PromptSection{Source: source, Content: content})
+}
+
+func firstNonEmptyMetadata(metadata map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := metadata[key].(string); ok && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package loop
+
+import (
+	"context"
+	"time"
+
+	"goyais/services/hub/internal/agent/context/mentions"
+	"goyais/services/hub/internal/agent/core"
+	mcpext "goyais/services/hub/internal/agent/extensions/mcp"
+)
+
+// resourceMentionTimeout bounds reading all resources mentioned in one
+// prompt.
+const resourceMentionTimeout = 15 * time.Second
+
+// resolveResourceMentions inlines every @server:uri mention in the run input
+// as a prompt section, reading through the session's pooled MCP connections.
+func resolveResourceMentions(ctx context.Context, run *runRuntime) []core.PromptSection {
+	if run.input.RuntimeConfig == nil || len(run.input.RuntimeConfig.Tooling.MCPServers) == 0 {
+		return nil
+	}
+	servers := convertToMCPExtServers(run.input.RuntimeConfig.Tooling.MCPServers)
+	names := make([]string, 0, len(servers))
+	for _, server := range servers {
+		names = append(names, server.Name)
+	}
+	if len(mentions.ExtractResourceMentions(run.input.Text, names)) == 0 {
+		return nil
+	}
+	readCtx, cancel := context.WithTimeout(ctx, resourceMentionTimeout)
+	defer cancel()
+	manager := mcpext.NewSessionClientManager(mcpext.DefaultSessionPool(), string(run.sessionID), servers, resourceMentionTimeout)
+	return mentions.ResolveResourceMentions(readCtx, run.input.Text, names, manager)
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package loop
+
+import (
+	"context"
+	"strings"
+
+	"goyais/services/hub/internal/agent/core"
+	"goyais/services/hub/internal/agent/policy/redaction"
+	"goyais/services/hub/internal/agent/tools/executor"
+)
+
+// stageSecretRedacted marks output deltas that record one redacted secret.
+// The hub appends each of them to the audit log.
+const stageSecretRedacted = "secret_redacted"
+
+// runtimeOutputRedactor implements executor.OutputRedactor and reports every
+// detection as a staged output delta.
+type runtimeOutputRedactor struct {
+	Redactor        *redaction.Redactor
+	EmitOutputDelta func(payload core.OutputDeltaPayload)
+}
+
+var _ executor.OutputRedactor = runtimeOutputRedactor{}
+
+func (r runtimeOutputRedactor) RedactToolOutput(_ context.Context, call executor.ToolCall, output map[string]any) map[string]any {
+	if r.Redactor == nil || len(output) == 0 {
+		return output
+	}
+	redacted, detections := r.Redactor.RedactFileValue(toolInputPath(call.Input), output, "tool:"+strings.TrimSpace(call.Name))
+	if len(detections) == 0 {
+		return output
+	}
+	emitSecretDetections(r.EmitOutputDelta, call.CallID, call.Name, detections)
+	redactedOutput, _ := redacted.(map[string]any)
+	return redactedOutput
+}
+
+// transcriptRedactor returns the project redactor for workingDir. Invalid
+// redaction settings fall back to the defaults rather than disabling it.
+func transcriptRedactor(workingDir string) *redaction.Redactor {
+	config, err := redaction.LoadProjectConfig(workingDir, "")
+	if err != nil {
+		config = redaction.DefaultConfig()
+	}
+	return redaction.New(config)
+}
+
+// redactPromptSections redacts each section the way the context builder
+// does, labelling detections with the section source.
+func redactPromptSections(redactor *redaction.Redactor, sections []core.PromptSection) ([]core.PromptSection, []core.SecretDetection) {
+	out := make([]core.PromptSection, 0, len(sections))
+	detections := []core.SecretDetection{}
+	for _, section := range sections {
+		content, found := redactor.Redact(section.Content, "prompt:"+section.Source)
+		section.Content = content
+		out = append(out, section)
+		detections = append(detections, found...)
+	}
+	return out, detections
+}
+
+// emitSecretDetections records detections without the secret values.
+func emitSecretDetections(emit func(payload core.OutputDeltaPayload), callID string, name string, detections []core.SecretDetection) {
+	if emit == nil {
+		return
+	}
+	for _, detection := range detections {
+		emit(core.OutputDeltaPayload{
+			Stage:  stageSecretRedacted,
+			CallID: strings.TrimSpace(callID),
+			Name:   strings.TrimSpace(name),
+			Output: map[string]any{
+				"kind":        detection.Kind,
+				"placeholder": detection.Placeholder,
+				"fingerprint": detection.Fingerprint,
+				"source":      detection.Source,
+			},
+		})
+	}
+}
+
+func toolInputPath(input map[string]any) string {
+	for _, key := range []string{"file_path", "path", "notebook_path"} {
+		if path, ok := input[key].(string); ok && strings.TrimSpace(path) != "" {
+			return path
+		}
+	}
+	return ""
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package loop
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"goyais/services/hub/internal/agent/core"
+	"goyais/services/hub/internal/agent/policy/redaction"
+	"goyais/services/hub/internal/agent/tools/executor"
+)
+
+func TestRuntimeOutputRedactorRecordsDetections(t *testing.T) {
+	deltas := []core.OutputDeltaPayload{}
+	redactor := runtimeOutputRedactor{
+		Redactor:        redaction.New(redaction.DefaultConfig()),
+		EmitOutputDelta: func(payload core.OutputDeltaPayload) { deltas = append(deltas, payload) },
+	}
+
+	output := redactor.RedactToolOutput(context.Background(), executor.ToolCall{
+		CallID: "call_env",
+		Name:   "Read",
+		Input:  map[string]any{"file_path": "/repo/.env"},
+	}, map[string]any{"ok": true, "output": "PORT=8080\nREGION=eu-west-1\n"})
+	text, _ := output["output"].(string)
+	if strings.Contains(text, "8080") || strings.Contains(text, "eu-west-1") {
+		t.Fatalf("expected dotenv values to be redacted, got %q", text)
+	}
+	if len(deltas) != 2 {
+		t.Fatalf("expected one audit delta per detection, got %#v", deltas)
+	}
+	first := deltas[0]
+	if first.Stage != stageSecretRedacted || first.CallID != "call_env" || first.Output["kind"] != redaction.KindDotenv || first.Output["source"] != "tool:Read" {
+		t.Fatalf("unexpected detection delta %#v", first)
+	}
+	if strings.Contains(first.Output["fingerprint"].(string), "8080") {
+		t.Fatalf("expected fingerprint without the secret, got %#v", first.Output)
+	}
+
+	deltas = deltas[:0]
+	clean := map[string]any{"ok": true, "output": "PORT=8080"}
+	if got := redactor.RedactToolOutput(context.Background(), executor.ToolCall{Name: "Read", Input: map[string]any{"file_path": "main.go"}}, clean); got["output"] != "PORT=8080" || len(deltas) != 0 {
+		t.Fatalf("expected clean output to pass through, got %#v %#v", got, deltas)
+	}
+}
@@ -37,6 +37,14 @@ type ToolCall struct {
 type ToolResultForNextTurn struct {
 	CallID string
 	Text   string
+	// Images are forwarded only to providers configured for vision.
+	Images []ImagePart
+}
+
+// ImagePart is one base64-encoded image returned by a tool.
+type ImagePart struct {
+	MIMEType string
+	Data     string
 }
 
 // ToolSpec is the minimal tool capability schema used for provider requests.
@@ -255,6 +263,46 @@ func BuildGoogleFunctionResponseContent(calls []ToolCall, results []ToolResultFo
 	}
 }
 
+// BuildGoogleInlineImageParts converts tool result images into Google
+// inlineData parts.
+func BuildGoogleInlineImageParts(results []ToolResultForNextTurn) []map[string]any {
+	parts := []map[string]any{}
+	for _, item := range results {
+		for _, image := range item.Images {
+			parts = append(parts, map[string]any{
+				"inlineData": map[string]any{
+					"mimeType": image.MIMEType,
+					"data":     image.Data,
+				},
+			})
+		}
+	}
+	return parts
+}
+
+// BuildOpenAIToolImageMessage converts tool result images into one user
+// message of image_url parts, since OpenAI tool messages carry text only. It
+// returns nil when no result has images.
+func BuildOpenAIToolImageMessage(results []ToolResultForNextTurn) map[string]any {
+	parts := []map[string]any{}
+	for _, item := range results {
+		for _, image := range item.Images {
+			parts = append(parts, map[string]any{
+				"type":      "image_url",
+				"image_url": map[string]any{"url": "data:" + image.MIMEType + ";base64," + image.Data},
+			})
+		}
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	content := append([]map[string]any{{"type": "text", "text": "Images returned by the tool calls above."}}, parts...)
+	return map[string]any{
+		"role":    "user",
+		"content": content,
+	}
+}
+
 // BuildOpenAIToolCallsForRequest converts normalized tool calls to OpenAI wire
 // request items.
 func BuildOpenAIToolCallsForRequest(calls []ToolCall) []map[string]any {
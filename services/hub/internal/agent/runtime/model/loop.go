@@ -27,6 +27,12 @@ type Provider interface {
 	Turn(ctx context.Context, req TurnRequest) (codec.TurnResult, error)
 }
 
+// ToolLoader is implemented by providers that accept tool declarations loaded
+// mid-run, such as deferred capabilities selected through ToolSearch.
+type ToolLoader interface {
+	LoadTools(tools []codec.ToolSpec)
+}
+
 // ToolInvoker executes one model turn tool-call batch.
 type ToolInvoker interface {
 	Execute(ctx context.Context, calls []codec.ToolCall) ([]codec.ToolResultForNextTurn, error)
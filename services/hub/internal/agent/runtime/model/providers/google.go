@@ -27,6 +27,8 @@ type GoogleConfig struct {
 	Tools           []map[string]any
 	InitialContents []map[string]any
 	HTTPClient      *http.Client
+	// Vision forwards images returned by tools to the model.
+	Vision bool
 }
 
 // Google is a stateful turn provider for Google generateContent APIs.
@@ -63,7 +65,12 @@ func (p *Google) Turn(ctx context.Context, req model.TurnRequest) (codec.TurnRes
 	p.mu.Lock()
 	p.bootstrapLocked(req)
 	if len(req.PriorToolCalls) > 0 {
-		p.contents = append(p.contents, codec.BuildGoogleFunctionResponseContent(req.PriorToolCalls, req.PriorToolResults))
+		response := codec.BuildGoogleFunctionResponseContent(req.PriorToolCalls, req.PriorToolResults)
+		if p.cfg.Vision {
+			parts, _ := response["parts"].([]map[string]any)
+			response["parts"] = append(parts, codec.BuildGoogleInlineImageParts(req.PriorToolResults)...)
+		}
+		p.contents = append(p.contents, response)
 	}
 	contents := cloneObjectSlice(p.contents)
 	params := cloneMapAny(p.cfg.Params)
@@ -134,6 +141,50 @@ func (p *Google) Turn(ctx context.Context, req model.TurnRequest) (codec.TurnRes
 	return turn, nil
 }
 
+// LoadTools appends function declarations for tools loaded mid-run. Tools
+// already declared are skipped.
+func (p *Google) LoadTools(tools []codec.ToolSpec) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	declared := map[string]struct{}{}
+	hasDeclarations := false
+	for _, item := range p.cfg.Tools {
+		list, ok := item["functionDeclarations"].([]map[string]any)
+		if !ok {
+			continue
+		}
+		hasDeclarations = true
+		for _, declaration := range list {
+			declared[strings.TrimSpace(fmt.Sprint(declaration["name"]))] = struct{}{}
+		}
+	}
+	pending := make([]codec.ToolSpec, 0, len(tools))
+	for _, item := range tools {
+		if _, exists := declared[strings.TrimSpace(item.Name)]; exists {
+			continue
+		}
+		pending = append(pending, item)
+	}
+	added := codec.BuildGoogleToolDeclarations(pending)
+	if len(added) == 0 {
+		return
+	}
+	if !hasDeclarations {
+		p.cfg.Tools = append(p.cfg.Tools, added...)
+		return
+	}
+	for idx, item := range p.cfg.Tools {
+		list, ok := item["functionDeclarations"].([]map[string]any)
+		if !ok {
+			continue
+		}
+		merged := cloneMapAny(item)
+		merged["functionDeclarations"] = append(append([]map[string]any{}, list...), added[0]["functionDeclarations"].([]map[string]any)...)
+		p.cfg.Tools[idx] = merged
+		return
+	}
+}
+
 func (p *Google) bootstrapLocked(req model.TurnRequest) {
 	if p.bootstrapped {
 		return
@@ -154,3 +205,4 @@ func (p *Google) bootstrapLocked(req model.TurnRequest) {
 }
 
 var _ model.Provider = (*Google)(nil)
+var _ model.ToolLoader = (*Google)(nil)
@@ -28,6 +28,8 @@ type OpenAIConfig struct {
 	ToolSchemas     []map[string]any
 	InitialMessages []map[string]any
 	HTTPClient      *http.Client
+	// Vision forwards images returned by tools to the model.
+	Vision bool
 }
 
 // OpenAI is a stateful turn provider for OpenAI-compatible APIs.
@@ -130,6 +132,27 @@ func (p *OpenAI) Turn(ctx context.Context, req model.TurnRequest) (codec.TurnRes
 	return turn, nil
 }
 
+// LoadTools appends function schemas for tools loaded mid-run. Tools already
+// declared are skipped.
+func (p *OpenAI) LoadTools(tools []codec.ToolSpec) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	declared := map[string]struct{}{}
+	for _, item := range p.cfg.ToolSchemas {
+		if function, ok := item["function"].(map[string]any); ok {
+			declared[strings.TrimSpace(fmt.Sprint(function["name"]))] = struct{}{}
+		}
+	}
+	pending := make([]codec.ToolSpec, 0, len(tools))
+	for _, item := range tools {
+		if _, exists := declared[strings.TrimSpace(item.Name)]; exists {
+			continue
+		}
+		pending = append(pending, item)
+	}
+	p.cfg.ToolSchemas = append(p.cfg.ToolSchemas, codec.BuildOpenAIToolSchemas(pending)...)
+}
+
 func (p *OpenAI) bootstrapLocked(req model.TurnRequest) {
 	if p.bootstrapped {
 		return
@@ -173,6 +196,12 @@ func (p *OpenAI) appendToolResultsLocked(calls []codec.ToolCall, results []codec
 			"content":      firstNonEmpty(resultByCallID[callID], ""),
 		})
 	}
+	if !p.cfg.Vision {
+		return
+	}
+	if message := codec.BuildOpenAIToolImageMessage(results); message != nil {
+		p.messages = append(p.messages, message)
+	}
 }
 
 func cloneObjectSlice(input []map[string]any) []map[string]any {
@@ -220,3 +249,4 @@ func firstNonEmpty(values ...string) string {
 }
 
 var _ model.Provider = (*OpenAI)(nil)
+var _ model.ToolLoader = (*OpenAI)(nil)
@@ -127,6 +127,51 @@ func TestOpenAITurnAppendsToolResultsOnNextTurn(t *testing.T) {
 	}
 }
 
+func TestOpenAITurnForwardsToolImagesWithVision(t *testing.T) {
+	var messages []any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		messages, _ = body["messages"].([]any)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"seen"}}]}`))
+	}))
+	defer server.Close()
+
+	calls := []codec.ToolCall{{CallID: "call_1", Name: "mcp__browser__screenshot"}}
+	results := []codec.ToolResultForNextTurn{{
+		CallID: "call_1",
+		Text:   `{"ok":true}`,
+		Images: []codec.ImagePart{{MIMEType: "image/png", Data: "aGVsbG8="}},
+	}}
+	for _, vision := range []bool{false, true} {
+		provider := NewOpenAI(OpenAIConfig{Endpoint: server.URL, Model: "gpt-test", Vision: vision})
+		if _, err := provider.Turn(context.Background(), model.TurnRequest{
+			UserInput:        "look",
+			PriorToolCalls:   calls,
+			PriorToolResults: results,
+		}); err != nil {
+			t.Fatalf("turn failed: %v", err)
+		}
+		last, _ := messages[len(messages)-1].(map[string]any)
+		if !vision {
+			if last["role"] != "tool" {
+				t.Fatalf("expected no image message without vision, got %#v", messages)
+			}
+			continue
+		}
+		content, _ := last["content"].([]any)
+		if last["role"] != "user" || len(content) != 2 {
+			t.Fatalf("expected an image message after the tool result, got %#v", last)
+		}
+		image, _ := content[1].(map[string]any)["image_url"].(map[string]any)
+		if image["url"] != "data:image/png;base64,aGVsbG8=" {
+			t.Fatalf("unexpected image part %#v", content[1])
+		}
+	}
+}
+
 func TestOpenAITurnParsesMiniMaxTextToolCall(t *testing.T) {
 	callCount := 0
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
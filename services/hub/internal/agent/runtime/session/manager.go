@@ -72,6 +72,25 @@ type ClearRequest struct {
 	Reason    string
 }
 
+// SetPermissionModeRequest switches the permission mode of one session.
+type SetPermissionModeRequest struct {
+	SessionID core.SessionID
+	Mode      core.PermissionMode
+	// Reason names the trigger, e.g. "plan_approved".
+	Reason string
+	// PlanID links the transition to the approved plan, if any.
+	PlanID string
+}
+
+// ModeTransition records one permission mode change on a session.
+type ModeTransition struct {
+	From   core.PermissionMode
+	To     core.PermissionMode
+	Reason string
+	PlanID string
+	At     time.Time
+}
+
 // HandoffTarget identifies the destination client surface for session transfer.
 type HandoffTarget string
 
@@ -125,6 +144,7 @@ type State struct {
 	LastClearedReason     string
 	LastHandoffTarget     HandoffTarget
 	LastHandoffAt         time.Time
+	ModeTransitions       []ModeTransition
 }
 
 // Manager owns runtime-agnostic session lifecycle state.
@@ -187,6 +207,20 @@ func (m *Manager) Register(req RegisterRequest) (State, error) {
 	return cloneState(state), nil
 }
 
+// Get returns the tracked state of one session.
+func (m *Manager) Get(sessionID core.SessionID) (State, error) {
+	if m == nil {
+		return State{}, errors.New("session manager is nil")
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	state := m.sessions[normalizeSessionID(sessionID)]
+	if state == nil {
+		return State{}, core.ErrSessionNotFound
+	}
+	return cloneState(state), nil
+}
+
 // Resume returns existing session state while dropping temporary permissions.
 func (m *Manager) Resume(_ context.Context, req ResumeRequest) (State, error) {
 	if m == nil {
@@ -289,6 +323,15 @@ func (m *Manager) Rewind(ctx context.Context, req RewindRequest) (State, error)
 	if req.TargetCursor < 0 {
 		return State{}, errors.New("target_cursor must be >= 0")
 	}
+	if lister, ok := m.checkpointStore.(core.CheckpointLister); ok {
+		timeline, err := lister.ListCheckpoints(ctx, sessionID)
+		if err != nil {
+			return State{}, fmt.Errorf("list checkpoints failed: %w", err)
+		}
+		if !containsCheckpoint(timeline, checkpointID) {
+			return State{}, fmt.Errorf("checkpoint %q does not belong to session %q", checkpointID, sessionID)
+		}
+	}
 
 	if err := m.checkpointStore.Restore(ctx, checkpointID); err != nil {
 		return State{}, fmt.Errorf("restore checkpoint failed: %w", err)
@@ -309,6 +352,27 @@ func (m *Manager) Rewind(ctx context.Context, req RewindRequest) (State, error)
 	return cloneState(state), nil
 }
 
+// Checkpoints returns the checkpoint timeline of one session, oldest first,
+// for rewind pickers. The checkpoint store must implement
+// core.CheckpointLister.
+func (m *Manager) Checkpoints(ctx context.Context, sessionID core.SessionID) ([]core.CheckpointInfo, error) {
+	if m == nil {
+		return nil, errors.New("session manager is nil")
+	}
+	lister, ok := m.checkpointStore.(core.CheckpointLister)
+	if !ok {
+		return nil, errors.New("checkpoint store does not support listing")
+	}
+	sessionID = normalizeSessionID(sessionID)
+	m.mu.RLock()
+	_, exists := m.sessions[sessionID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, core.ErrSessionNotFound
+	}
+	return lister.ListCheckpoints(ctx, sessionID)
+}
+
 // Clear removes accumulated context while preserving stable session identity.
 func (m *Manager) Clear(_ context.Context, req ClearRequest) (State, error) {
 	if m == nil {
@@ -335,6 +399,40 @@ func (m *Manager) Clear(_ context.Context, req ClearRequest) (State, error) {
 	return cloneState(state), nil
 }
 
+// SetPermissionMode switches the session permission mode and appends the
+// change to the session's mode transition history.
+func (m *Manager) SetPermissionMode(_ context.Context, req SetPermissionModeRequest) (State, error) {
+	if m == nil {
+		return State{}, errors.New("session manager is nil")
+	}
+	sessionID := normalizeSessionID(req.SessionID)
+	if sessionID == "" {
+		return State{}, core.ErrSessionNotFound
+	}
+	mode := normalizePermissionMode(req.Mode)
+	if mode != req.Mode {
+		return State{}, fmt.Errorf("unsupported permission mode %q", req.Mode)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state := m.sessions[sessionID]
+	if state == nil {
+		return State{}, core.ErrSessionNotFound
+	}
+	now := time.Now().UTC()
+	state.ModeTransitions = append(state.ModeTransitions, ModeTransition{
+		From:   state.PermissionMode,
+		To:     mode,
+		Reason: strings.TrimSpace(req.Reason),
+		PlanID: strings.TrimSpace(req.PlanID),
+		At:     now,
+	})
+	state.PermissionMode = mode
+	state.UpdatedAt = now
+	return cloneState(state), nil
+}
+
 // Handoff builds a cross-surface snapshot for desktop/mobile transfer.
 func (m *Manager) Handoff(_ context.Context, req HandoffRequest) (HandoffSnapshot, error) {
 	if m == nil {
@@ -388,6 +486,15 @@ func normalizeCheckpointID(input core.CheckpointID) core.CheckpointID {
 	return core.CheckpointID(strings.TrimSpace(string(input)))
 }
 
+func containsCheckpoint(timeline []core.CheckpointInfo, id core.CheckpointID) bool {
+	for _, item := range timeline {
+		if item.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
 func normalizePermissionMode(mode core.PermissionMode) core.PermissionMode {
 	switch mode {
 	case core.PermissionModeDefault,
@@ -478,5 +585,6 @@ func cloneState(input *State) State {
 		LastClearedReason:     input.LastClearedReason,
 		LastHandoffTarget:     input.LastHandoffTarget,
 		LastHandoffAt:         input.LastHandoffAt,
+		ModeTransitions:       append([]ModeTransition(nil), input.ModeTransitions...),
 	}
 }
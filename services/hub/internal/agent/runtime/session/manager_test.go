@@ -7,10 +7,12 @@ package session
 import (
 	"context"
 	"errors"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"goyais/services/hub/internal/agent/core"
+	"goyais/services/hub/internal/agent/tools/checkpoint"
 )
 
 type starterStub struct {
@@ -167,6 +169,48 @@ func TestManagerRewindRestoresCheckpointAndCursor(t *testing.T) {
 	}
 }
 
+func TestManagerCheckpointsListsTimelineAndGuardsRewind(t *testing.T) {
+	root := t.TempDir()
+	store, err := checkpoint.NewDiskStore(root, filepath.Join(t.TempDir(), "checkpoints"), checkpoint.DiskOptions{})
+	if err != nil {
+		t.Fatalf("open checkpoint store: %v", err)
+	}
+	manager := NewManager(Dependencies{CheckpointStore: store})
+	for _, id := range []core.SessionID{"sess_a", "sess_b"} {
+		if _, err := manager.Register(RegisterRequest{
+			Handle:     core.SessionHandle{SessionID: id, CreatedAt: time.Now().UTC()},
+			WorkingDir: root,
+		}); err != nil {
+			t.Fatalf("register %s failed: %v", id, err)
+		}
+	}
+	own, err := store.Snapshot(context.Background(), core.SnapshotRequest{SessionID: "sess_a", Paths: []string{"a.txt"}, Reason: "before edit"})
+	if err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+	foreign, err := store.Snapshot(context.Background(), core.SnapshotRequest{SessionID: "sess_b", Paths: []string{"b.txt"}})
+	if err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+
+	timeline, err := manager.Checkpoints(context.Background(), "sess_a")
+	if err != nil {
+		t.Fatalf("list checkpoints failed: %v", err)
+	}
+	if len(timeline) != 1 || timeline[0].ID != own || timeline[0].Reason != "before edit" {
+		t.Fatalf("unexpected timeline %#v", timeline)
+	}
+	if _, err := manager.Rewind(context.Background(), RewindRequest{SessionID: "sess_a", CheckpointID: foreign}); err == nil {
+		t.Fatal("expected rewind to another session's checkpoint to fail")
+	}
+	if _, err := manager.Rewind(context.Background(), RewindRequest{SessionID: "sess_a", CheckpointID: own}); err != nil {
+		t.Fatalf("rewind failed: %v", err)
+	}
+	if _, err := manager.Checkpoints(context.Background(), "sess_missing"); !errors.Is(err, core.ErrSessionNotFound) {
+		t.Fatalf("expected session not found, got %v", err)
+	}
+}
+
 func TestManagerClearResetsHistoryAndCursor(t *testing.T) {
 	manager := NewManager(Dependencies{})
 	_, err := manager.Register(RegisterRequest{
@@ -312,3 +356,37 @@ func TestManagerHandoffRejectsInvalidTarget(t *testing.T) {
 		t.Fatalf("expected invalid target to fail")
 	}
 }
+
+func TestManagerSetPermissionModeRecordsTransition(t *testing.T) {
+	manager := NewManager(Dependencies{})
+	if _, err := manager.Register(RegisterRequest{
+		Handle:         core.SessionHandle{SessionID: core.SessionID("sess_plan"), CreatedAt: time.Now().UTC()},
+		PermissionMode: core.PermissionModePlan,
+	}); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	state, err := manager.SetPermissionMode(context.Background(), SetPermissionModeRequest{
+		SessionID: "sess_plan",
+		Mode:      core.PermissionModeAcceptEdits,
+		Reason:    "plan_approved",
+		PlanID:    "plan_call_1",
+	})
+	if err != nil {
+		t.Fatalf("set permission mode failed: %v", err)
+	}
+	if state.PermissionMode != core.PermissionModeAcceptEdits || len(state.ModeTransitions) != 1 {
+		t.Fatalf("expected one recorded transition, got %#v", state)
+	}
+	transition := state.ModeTransitions[0]
+	if transition.From != core.PermissionModePlan || transition.To != core.PermissionModeAcceptEdits || transition.PlanID != "plan_call_1" || transition.At.IsZero() {
+		t.Fatalf("unexpected transition %#v", transition)
+	}
+
+	if _, err := manager.SetPermissionMode(context.Background(), SetPermissionModeRequest{SessionID: "sess_plan", Mode: "yolo"}); err == nil {
+		t.Fatalf("expected unknown mode to be rejected")
+	}
+	if _, err := manager.SetPermissionMode(context.Background(), SetPermissionModeRequest{SessionID: "sess_missing", Mode: core.PermissionModeDefault}); !errors.Is(err, core.ErrSessionNotFound) {
+		t.Fatalf("expected session not found, got %v", err)
+	}
+}
@@ -14,11 +14,18 @@ const (
 	ToolBash       = "Bash"
 	ToolList       = "List"
 	ToolToolSearch = "ToolSearch"
+	// ToolExitPlanMode submits a markdown plan for review and leaves plan
+	// mode once the plan is approved.
+	ToolExitPlanMode = "ExitPlanMode"
+	// ToolListMcpResources and ToolReadMcpResource expose resources of the
+	// session's MCP servers.
+	ToolListMcpResources = "ListMcpResources"
+	ToolReadMcpResource  = "ReadMcpResource"
 )
 
 // BuiltinToolNames returns the stable built-in tool list for runtime sessions.
 func BuiltinToolNames() []string {
-	return []string{ToolRead, ToolWrite, ToolEdit, ToolBash, ToolList, ToolToolSearch}
+	return []string{ToolRead, ToolWrite, ToolEdit, ToolBash, ToolList, ToolToolSearch, ToolExitPlanMode, ToolListMcpResources, ToolReadMcpResource}
 }
 
 // BuiltinToolSpecs returns normalized specs for all built-in tools.
@@ -71,12 +78,39 @@ func BuiltinToolSpecs() []spec.ToolSpec {
 		},
 		{
 			Name:             ToolToolSearch,
-			Description:      "Search deferred capability descriptors exposed by the runtime",
+			Description:      "Search deferred capability descriptors exposed by the runtime; use query \"select:Name1,Name2\" to load named tools exactly",
 			InputSchema:      map[string]any{"type": "object", "properties": map[string]any{"query": map[string]any{"type": "string"}, "limit": map[string]any{"type": "integer"}}},
 			RiskLevel:        "low",
 			ReadOnly:         true,
 			ConcurrencySafe:  true,
 			NeedsPermissions: false,
 		},
+		{
+			Name:             ToolExitPlanMode,
+			Description:      "In plan mode, present a markdown implementation plan to the user and wait for approval before making changes",
+			InputSchema:      map[string]any{"type": "object", "properties": map[string]any{"plan": map[string]any{"type": "string"}}, "required": []string{"plan"}},
+			RiskLevel:        "low",
+			ReadOnly:         true,
+			ConcurrencySafe:  false,
+			NeedsPermissions: false,
+		},
+		{
+			Name:             ToolListMcpResources,
+			Description:      "List resources and resource templates exposed by connected MCP servers; pass server to list one server only",
+			InputSchema:      map[string]any{"type": "object", "properties": map[string]any{"server": map[string]any{"type": "string"}}},
+			RiskLevel:        "low",
+			ReadOnly:         true,
+			ConcurrencySafe:  true,
+			NeedsPermissions: false,
+		},
+		{
+			Name:             ToolReadMcpResource,
+			Description:      "Read one MCP resource by server name and uri",
+			InputSchema:      map[string]any{"type": "object", "properties": map[string]any{"server": map[string]any{"type": "string"}, "uri": map[string]any{"type": "string"}}, "required": []string{"server", "uri"}},
+			RiskLevel:        "low",
+			ReadOnly:         true,
+			ConcurrencySafe:  true,
+			NeedsPermissions: false,
+		},
 	}
 }
@@ -0,0 +1,553 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package checkpoint
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"goyais/services/hub/internal/agent/core"
+)
+
+const (
+	blobsDirName     = "blobs"
+	manifestsDirName = "manifests"
+	compressedSuffix = ".gz"
+)
+
+// DiskOptions configures compression and retention of a DiskStore. Zero
+// values store blobs uncompressed and keep every checkpoint.
+type DiskOptions struct {
+	// Compress gzips new blobs. Existing blobs are read either way.
+	Compress bool
+	// MaxAge drops checkpoints created before now minus MaxAge.
+	MaxAge time.Duration
+	// MaxCheckpointsPerSession keeps only the newest checkpoints of a session.
+	MaxCheckpointsPerSession int
+	// MaxBytes bounds the on-disk size of the blobs that checkpoints
+	// reference; the oldest checkpoints are dropped first. The newest
+	// checkpoint is always kept.
+	MaxBytes int64
+	// Now overrides the clock used for creation times and MaxAge.
+	Now func() time.Time
+}
+
+func (o DiskOptions) normalized() DiskOptions {
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+	return o
+}
+
+// manifest is the JSON document written for one checkpoint.
+type manifest struct {
+	ID        core.CheckpointID `json:"id"`
+	SessionID core.SessionID    `json:"session_id"`
+	Reason    string            `json:"reason,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	Entries   []manifestEntry   `json:"entries"`
+}
+
+type manifestEntry struct {
+	Path       string      `json:"path"`
+	Exists     bool        `json:"exists"`
+	Mode       os.FileMode `json:"mode,omitempty"`
+	SHA256     string      `json:"sha256,omitempty"`
+	Size       int64       `json:"size,omitempty"`
+	Compressed bool        `json:"compressed,omitempty"`
+}
+
+func (e manifestEntry) blobName() string {
+	if e.Compressed {
+		return e.SHA256 + compressedSuffix
+	}
+	return e.SHA256
+}
+
+// DiskStore is a checkpoint store kept under one data directory, such as
+// the session data dir, so checkpoints survive restarts. File contents are
+// written once as blobs named by their SHA-256 and shared by every
+// checkpoint that captured the same bytes; each checkpoint is a JSON
+// manifest pointing at its blobs. Retention runs after every snapshot and
+// deletes blobs no manifest references.
+type DiskStore struct {
+	rootDir string
+	dataDir string
+	options DiskOptions
+
+	mu        sync.RWMutex
+	manifests map[core.CheckpointID]manifest
+	blobSizes map[string]int64
+}
+
+var (
+	_ core.CheckpointStore  = (*DiskStore)(nil)
+	_ core.CheckpointLister = (*DiskStore)(nil)
+)
+
+// NewDiskStore opens the store in dataDir, creating it when missing, for
+// files under the workspace rootDir. Manifests left by a previous process
+// are loaded so their checkpoints can be listed and restored.
+func NewDiskStore(rootDir string, dataDir string, options DiskOptions) (*DiskStore, error) {
+	dataDir = strings.TrimSpace(dataDir)
+	if dataDir == "" {
+		return nil, errors.New("checkpoint data dir is required")
+	}
+	for _, dir := range []string{filepath.Join(dataDir, blobsDirName), filepath.Join(dataDir, manifestsDirName)} {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("create checkpoint dir %q failed: %w", dir, err)
+		}
+	}
+	store := &DiskStore{
+		rootDir:   strings.TrimSpace(rootDir),
+		dataDir:   dataDir,
+		options:   options.normalized(),
+		manifests: map[core.CheckpointID]manifest{},
+		blobSizes: map[string]int64{},
+	}
+	if err := store.load(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Snapshot captures current file states for requested paths, then applies
+// retention.
+func (s *DiskStore) Snapshot(ctx context.Context, req core.SnapshotRequest) (core.CheckpointID, error) {
+	if s == nil {
+		return "", errors.New("checkpoint store is nil")
+	}
+	if strings.TrimSpace(string(req.SessionID)) == "" {
+		return "", errors.New("session_id is required")
+	}
+	if len(req.Paths) == 0 {
+		return "", errors.New("snapshot paths are required")
+	}
+	entries, err := captureEntries(ctx, s.rootDir, req.Paths)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item := manifest{
+		ID:        core.CheckpointID("cp_" + randomHex(8)),
+		SessionID: req.SessionID,
+		Reason:    strings.TrimSpace(req.Reason),
+		CreatedAt: s.options.Now().UTC(),
+		Entries:   make([]manifestEntry, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		written, writeErr := s.writeBlobLocked(entry)
+		if writeErr != nil {
+			return "", writeErr
+		}
+		item.Entries = append(item.Entries, written)
+	}
+	raw, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(s.manifestPath(item.ID), raw, 0o600); err != nil {
+		return "", fmt.Errorf("write checkpoint manifest failed: %w", err)
+	}
+	s.manifests[item.ID] = item
+	if err := s.pruneLocked(item.ID); err != nil {
+		return "", err
+	}
+	return item.ID, nil
+}
+
+// Restore rolls files back to one checkpoint. Every blob is read and
+// verified before the first file is written.
+func (s *DiskStore) Restore(ctx context.Context, id core.CheckpointID) error {
+	if s == nil {
+		return errors.New("checkpoint store is nil")
+	}
+	normalizedID := core.CheckpointID(strings.TrimSpace(string(id)))
+	if normalizedID == "" {
+		return errors.New("checkpoint id is required")
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, exists := s.manifests[normalizedID]
+	if !exists {
+		return fmt.Errorf("checkpoint %q not found", normalizedID)
+	}
+	entries := make([]snapshotEntry, 0, len(item.Entries))
+	for _, entry := range item.Entries {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		// Manifests live on disk, so their paths are checked again.
+		absPath, err := resolvePath(s.rootDir, entry.Path)
+		if err != nil {
+			return err
+		}
+		restored := snapshotEntry{absPath: absPath, exists: entry.Exists, mode: entry.Mode}
+		if entry.Exists {
+			content, err := s.readBlob(entry)
+			if err != nil {
+				return err
+			}
+			restored.content = content
+		}
+		entries = append(entries, restored)
+	}
+	for _, entry := range entries {
+		if err := restoreEntry(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListCheckpoints returns the checkpoints of one session, oldest first.
+func (s *DiskStore) ListCheckpoints(_ context.Context, sessionID core.SessionID) ([]core.CheckpointInfo, error) {
+	if s == nil {
+		return nil, errors.New("checkpoint store is nil")
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := []core.CheckpointInfo{}
+	for _, item := range s.manifests {
+		if item.SessionID != sessionID {
+			continue
+		}
+		info := core.CheckpointInfo{
+			ID:        item.ID,
+			SessionID: item.SessionID,
+			Reason:    item.Reason,
+			CreatedAt: item.CreatedAt,
+			Paths:     make([]string, 0, len(item.Entries)),
+		}
+		for _, entry := range item.Entries {
+			info.Paths = append(info.Paths, entry.Path)
+			info.SizeBytes += entry.Size
+		}
+		out = append(out, info)
+	}
+	sortCheckpointInfos(out)
+	return out, nil
+}
+
+// Delete removes one checkpoint and the blobs only it referenced.
+func (s *DiskStore) Delete(_ context.Context, id core.CheckpointID) error {
+	if s == nil {
+		return errors.New("checkpoint store is nil")
+	}
+	normalizedID := core.CheckpointID(strings.TrimSpace(string(id)))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.manifests[normalizedID]; !exists {
+		return fmt.Errorf("checkpoint %q not found", normalizedID)
+	}
+	if err := s.removeManifestLocked(normalizedID); err != nil {
+		return err
+	}
+	return s.collectGarbageLocked()
+}
+
+// Prune applies retention and deletes unreferenced blobs.
+func (s *DiskStore) Prune(_ context.Context) error {
+	if s == nil {
+		return errors.New("checkpoint store is nil")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pruneLocked("")
+}
+
+// DiskUsage returns the bytes held by blobs on disk.
+func (s *DiskStore) DiskUsage() int64 {
+	if s == nil {
+		return 0
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var total int64
+	for _, size := range s.blobSizes {
+		total += size
+	}
+	return total
+}
+
+func (s *DiskStore) load() error {
+	manifestDir := filepath.Join(s.dataDir, manifestsDirName)
+	items, err := os.ReadDir(manifestDir)
+	if err != nil {
+		return fmt.Errorf("read checkpoint manifests failed: %w", err)
+	}
+	for _, item := range items {
+		if item.IsDir() || filepath.Ext(item.Name()) != ".json" {
+			continue
+		}
+		raw, readErr := os.ReadFile(filepath.Join(manifestDir, item.Name()))
+		if readErr != nil {
+			return fmt.Errorf("read checkpoint manifest %q failed: %w", item.Name(), readErr)
+		}
+		var decoded manifest
+		if decodeErr := json.Unmarshal(raw, &decoded); decodeErr != nil || decoded.ID == "" {
+			// A manifest cut short by a crash is skipped; its blobs are
+			// collected below like any other unreferenced blob.
+			continue
+		}
+		s.manifests[decoded.ID] = decoded
+	}
+	walkErr := filepath.WalkDir(filepath.Join(s.dataDir, blobsDirName), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		if strings.HasPrefix(entry.Name(), ".tmp-") {
+			_ = os.Remove(path)
+			return nil
+		}
+		info, infoErr := entry.Info()
+		if infoErr != nil {
+			return infoErr
+		}
+		s.blobSizes[entry.Name()] = info.Size()
+		return nil
+	})
+	if walkErr != nil {
+		return fmt.Errorf("scan checkpoint blobs failed: %w", walkErr)
+	}
+	return s.collectGarbageLocked()
+}
+
+// writeBlobLocked stores the content of entry unless a blob with the same
+// hash exists already, in either form.
+func (s *DiskStore) writeBlobLocked(entry snapshotEntry) (manifestEntry, error) {
+	out := manifestEntry{Path: entry.absPath, Exists: entry.exists, Mode: entry.mode}
+	if !entry.exists {
+		return out, nil
+	}
+	sum := sha256.Sum256(entry.content)
+	out.SHA256 = hex.EncodeToString(sum[:])
+	out.Size = int64(len(entry.content))
+	for _, compressed := range []bool{s.options.Compress, !s.options.Compress} {
+		out.Compressed = compressed
+		if _, exists := s.blobSizes[out.blobName()]; exists {
+			return out, nil
+		}
+	}
+
+	out.Compressed = s.options.Compress
+	data := entry.content
+	if out.Compressed {
+		var buffer bytes.Buffer
+		writer := gzip.NewWriter(&buffer)
+		if _, err := writer.Write(entry.content); err != nil {
+			return manifestEntry{}, fmt.Errorf("compress checkpoint blob failed: %w", err)
+		}
+		if err := writer.Close(); err != nil {
+			return manifestEntry{}, fmt.Errorf("compress checkpoint blob failed: %w", err)
+		}
+		data = buffer.Bytes()
+	}
+	path := s.blobPath(out)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return manifestEntry{}, fmt.Errorf("create checkpoint blob dir failed: %w", err)
+	}
+	if err := writeFileAtomic(path, data, 0o600); err != nil {
+		return manifestEntry{}, fmt.Errorf("write checkpoint blob failed: %w", err)
+	}
+	s.blobSizes[out.blobName()] = int64(len(data))
+	return out, nil
+}
+
+func (s *DiskStore) readBlob(entry manifestEntry) ([]byte, error) {
+	raw, err := os.ReadFile(s.blobPath(entry))
+	if err != nil {
+		return nil, fmt.Errorf("read checkpoint blob for %q failed: %w", entry.Path, err)
+	}
+	if entry.Compressed {
+		reader, gzipErr := gzip.NewReader(bytes.NewReader(raw))
+		if gzipErr != nil {
+			return nil, fmt.Errorf("decompress checkpoint blob for %q failed: %w", entry.Path, gzipErr)
+		}
+		raw, err = io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("decompress checkpoint blob for %q failed: %w", entry.Path, err)
+		}
+	}
+	sum := sha256.Sum256(raw)
+	if hex.EncodeToString(sum[:]) != entry.SHA256 {
+		return nil, fmt.Errorf("checkpoint blob for %q is corrupt", entry.Path)
+	}
+	return raw, nil
+}
+
+// pruneLocked drops checkpoints that fall outside the retention options,
+// never dropping keep, and then collects unreferenced blobs.
+func (s *DiskStore) pruneLocked(keep core.CheckpointID) error {
+	ordered := make([]manifest, 0, len(s.manifests))
+	for _, item := range s.manifests {
+		ordered = append(ordered, item)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if !ordered[i].CreatedAt.Equal(ordered[j].CreatedAt) {
+			return ordered[i].CreatedAt.After(ordered[j].CreatedAt)
+		}
+		return ordered[i].ID > ordered[j].ID
+	})
+	if keep == "" && len(ordered) > 0 {
+		keep = ordered[0].ID
+	}
+
+	// ordered is newest first; walk it keeping what fits.
+	cutoff := time.Time{}
+	if s.options.MaxAge > 0 {
+		cutoff = s.options.Now().Add(-s.options.MaxAge)
+	}
+	perSession := map[core.SessionID]int{}
+	referenced := map[string]struct{}{}
+	var usedBytes int64
+	drop := []core.CheckpointID{}
+	for _, item := range ordered {
+		if item.ID != keep {
+			expired := !cutoff.IsZero() && item.CreatedAt.Before(cutoff)
+			overCount := s.options.MaxCheckpointsPerSession > 0 &&
+				perSession[item.SessionID] >= s.options.MaxCheckpointsPerSession
+			overSize := s.options.MaxBytes > 0 && usedBytes+s.newBlobBytes(item, referenced) > s.options.MaxBytes
+			if expired || overCount || overSize {
+				drop = append(drop, item.ID)
+				continue
+			}
+		}
+		perSession[item.SessionID]++
+		usedBytes += s.newBlobBytes(item, referenced)
+		for _, entry := range item.Entries {
+			if entry.Exists {
+				referenced[entry.blobName()] = struct{}{}
+			}
+		}
+	}
+	for _, id := range drop {
+		if err := s.removeManifestLocked(id); err != nil {
+			return err
+		}
+	}
+	if len(drop) == 0 {
+		return nil
+	}
+	return s.collectGarbageLocked()
+}
+
+// newBlobBytes is the size of the blobs item references that are not in
+// referenced yet.
+func (s *DiskStore) newBlobBytes(item manifest, referenced map[string]struct{}) int64 {
+	var total int64
+	seen := map[string]struct{}{}
+	for _, entry := range item.Entries {
+		if !entry.Exists {
+			continue
+		}
+		name := entry.blobName()
+		if _, exists := referenced[name]; exists {
+			continue
+		}
+		if _, exists := seen[name]; exists {
+			continue
+		}
+		seen[name] = struct{}{}
+		total += s.blobSizes[name]
+	}
+	return total
+}
+
+func (s *DiskStore) removeManifestLocked(id core.CheckpointID) error {
+	if err := os.Remove(s.manifestPath(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove checkpoint manifest %q failed: %w", id, err)
+	}
+	delete(s.manifests, id)
+	return nil
+}
+
+// collectGarbageLocked deletes blobs that no manifest references.
+func (s *DiskStore) collectGarbageLocked() error {
+	referenced := map[string]struct{}{}
+	for _, item := range s.manifests {
+		for _, entry := range item.Entries {
+			if entry.Exists {
+				referenced[entry.blobName()] = struct{}{}
+			}
+		}
+	}
+	for name := range s.blobSizes {
+		if _, exists := referenced[name]; exists {
+			continue
+		}
+		path := filepath.Join(s.dataDir, blobsDirName, blobFanout(name), name)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove checkpoint blob %q failed: %w", name, err)
+		}
+		delete(s.blobSizes, name)
+	}
+	return nil
+}
+
+func (s *DiskStore) manifestPath(id core.CheckpointID) string {
+	return filepath.Join(s.dataDir, manifestsDirName, string(id)+".json")
+}
+
+func (s *DiskStore) blobPath(entry manifestEntry) string {
+	name := entry.blobName()
+	return filepath.Join(s.dataDir, blobsDirName, blobFanout(name), name)
+}
+
+// blobFanout spreads blobs over subdirectories named by their first two
+// hex digits.
+func blobFanout(name string) string {
+	if len(name) < 2 {
+		return "00"
+	}
+	return name[:2]
+}
+
+// writeFileAtomic writes to a temporary file next to path and renames it,
+// so readers never see a partial file.
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	tempPath := temp.Name()
+	if _, err := temp.Write(data); err != nil {
+		_ = temp.Close()
+		_ = os.Remove(tempPath)
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		_ = os.Remove(tempPath)
+		return err
+	}
+	if err := os.Chmod(tempPath, mode); err != nil {
+		_ = os.Remove(tempPath)
+		return err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		_ = os.Remove(tempPath)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package checkpoint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"goyais/services/hub/internal/agent/core"
+)
+
+func TestDiskStoreRestoreSurvivesReopen(t *testing.T) {
+	root := t.TempDir()
+	dataDir := filepath.Join(t.TempDir(), "checkpoints")
+	target := filepath.Join(root, "notes.txt")
+	writeTestFile(t, target, "v1")
+
+	store, err := NewDiskStore(root, dataDir, DiskOptions{Compress: true})
+	if err != nil {
+		t.Fatalf("open store failed: %v", err)
+	}
+	checkpointID, err := store.Snapshot(context.Background(), core.SnapshotRequest{
+		SessionID: core.SessionID("sess_1"),
+		Paths:     []string{"notes.txt", "created.txt"},
+		Reason:    "before edit",
+	})
+	if err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+	writeTestFile(t, target, "v2")
+	writeTestFile(t, filepath.Join(root, "created.txt"), "new")
+
+	reopened, err := NewDiskStore(root, dataDir, DiskOptions{})
+	if err != nil {
+		t.Fatalf("reopen store failed: %v", err)
+	}
+	timeline, err := reopened.ListCheckpoints(context.Background(), core.SessionID("sess_1"))
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(timeline) != 1 || timeline[0].ID != checkpointID || timeline[0].Reason != "before edit" || timeline[0].SizeBytes != 2 {
+		t.Fatalf("unexpected timeline %#v", timeline)
+	}
+	if err := reopened.Restore(context.Background(), checkpointID); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if content := readTestFile(t, target); content != "v1" {
+		t.Fatalf("unexpected restored content %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(root, "created.txt")); !os.IsNotExist(err) {
+		t.Fatalf("file created after snapshot should be removed, err=%v", err)
+	}
+}
+
+func TestDiskStoreDeduplicatesBlobsAndCollectsGarbage(t *testing.T) {
+	root := t.TempDir()
+	dataDir := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "a.txt"), strings.Repeat("same", 64))
+	writeTestFile(t, filepath.Join(root, "b.txt"), strings.Repeat("same", 64))
+
+	store, err := NewDiskStore(root, dataDir, DiskOptions{})
+	if err != nil {
+		t.Fatalf("open store failed: %v", err)
+	}
+	first, err := store.Snapshot(context.Background(), core.SnapshotRequest{SessionID: "sess_1", Paths: []string{"a.txt", "b.txt"}})
+	if err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+	second, err := store.Snapshot(context.Background(), core.SnapshotRequest{SessionID: "sess_1", Paths: []string{"a.txt"}})
+	if err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+	if got := countBlobs(t, dataDir); got != 1 {
+		t.Fatalf("expected one shared blob, got %d", got)
+	}
+
+	writeTestFile(t, filepath.Join(root, "a.txt"), "changed")
+	third, err := store.Snapshot(context.Background(), core.SnapshotRequest{SessionID: "sess_1", Paths: []string{"a.txt"}})
+	if err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+	if got := countBlobs(t, dataDir); got != 2 {
+		t.Fatalf("expected two blobs, got %d", got)
+	}
+	for _, id := range []core.CheckpointID{first, second} {
+		if err := store.Delete(context.Background(), id); err != nil {
+			t.Fatalf("delete %s failed: %v", id, err)
+		}
+	}
+	if got := countBlobs(t, dataDir); got != 1 {
+		t.Fatalf("expected unreferenced blob to be collected, got %d blobs", got)
+	}
+	if err := store.Restore(context.Background(), third); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+}
+
+func TestDiskStoreRetentionByAgeCountAndSize(t *testing.T) {
+	root := t.TempDir()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	snapshot := func(store *DiskStore, sessionID core.SessionID, content string) core.CheckpointID {
+		t.Helper()
+		writeTestFile(t, filepath.Join(root, "file.txt"), content)
+		id, err := store.Snapshot(context.Background(), core.SnapshotRequest{SessionID: sessionID, Paths: []string{"file.txt"}})
+		if err != nil {
+			t.Fatalf("snapshot failed: %v", err)
+		}
+		now = now.Add(time.Minute)
+		return id
+	}
+	ids := func(store *DiskStore, sessionID core.SessionID) []core.CheckpointID {
+		t.Helper()
+		timeline, err := store.ListCheckpoints(context.Background(), sessionID)
+		if err != nil {
+			t.Fatalf("list failed: %v", err)
+		}
+		out := make([]core.CheckpointID, 0, len(timeline))
+		for _, item := range timeline {
+			out = append(out, item.ID)
+		}
+		return out
+	}
+
+	byCount, err := NewDiskStore(root, t.TempDir(), DiskOptions{MaxCheckpointsPerSession: 2, Now: clock})
+	if err != nil {
+		t.Fatalf("open store failed: %v", err)
+	}
+	snapshot(byCount, "sess_1", "one")
+	second := snapshot(byCount, "sess_1", "two")
+	third := snapshot(byCount, "sess_1", "three")
+	other := snapshot(byCount, "sess_2", "other")
+	if got := ids(byCount, "sess_1"); len(got) != 2 || got[0] != second || got[1] != third {
+		t.Fatalf("count retention kept %v", got)
+	}
+	if got := ids(byCount, "sess_2"); len(got) != 1 || got[0] != other {
+		t.Fatalf("count retention should be per session, got %v", got)
+	}
+
+	byAge, err := NewDiskStore(root, t.TempDir(), DiskOptions{MaxAge: 90 * time.Second, Now: clock})
+	if err != nil {
+		t.Fatalf("open store failed: %v", err)
+	}
+	snapshot(byAge, "sess_1", "one")
+	kept := snapshot(byAge, "sess_1", "two")
+	latest := snapshot(byAge, "sess_1", "three")
+	if got := ids(byAge, "sess_1"); len(got) != 2 || got[0] != kept || got[1] != latest {
+		t.Fatalf("age retention kept %v", got)
+	}
+
+	bySize, err := NewDiskStore(root, t.TempDir(), DiskOptions{MaxBytes: 10, Now: clock})
+	if err != nil {
+		t.Fatalf("open store failed: %v", err)
+	}
+	snapshot(bySize, "sess_1", "12345")
+	previous := snapshot(bySize, "sess_1", "67890")
+	newest := snapshot(bySize, "sess_1", "abcde")
+	if got := ids(bySize, "sess_1"); len(got) != 2 || got[0] != previous || got[1] != newest {
+		t.Fatalf("size retention kept %v", got)
+	}
+	if usage := bySize.DiskUsage(); usage != 10 {
+		t.Fatalf("expected 10 bytes of blobs, got %d", usage)
+	}
+	oversized := snapshot(bySize, "sess_1", strings.Repeat("x", 32))
+	if got := ids(bySize, "sess_1"); len(got) != 1 || got[0] != oversized {
+		t.Fatalf("size retention should keep the newest checkpoint, got %v", got)
+	}
+}
+
+func TestDiskStoreRestoreRejectsCorruptBlob(t *testing.T) {
+	root := t.TempDir()
+	dataDir := t.TempDir()
+	target := filepath.Join(root, "notes.txt")
+	writeTestFile(t, target, "v1")
+	store, err := NewDiskStore(root, dataDir, DiskOptions{})
+	if err != nil {
+		t.Fatalf("open store failed: %v", err)
+	}
+	checkpointID, err := store.Snapshot(context.Background(), core.SnapshotRequest{SessionID: "sess_1", Paths: []string{"notes.txt"}})
+	if err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+	blobs := listBlobs(t, dataDir)
+	if len(blobs) != 1 {
+		t.Fatalf("expected one blob, got %v", blobs)
+	}
+	writeTestFile(t, blobs[0], "tampered")
+	writeTestFile(t, target, "v2")
+
+	if err := store.Restore(context.Background(), checkpointID); err == nil {
+		t.Fatal("expected corrupt blob error")
+	}
+	if content := readTestFile(t, target); content != "v2" {
+		t.Fatalf("failed restore should not touch files, got %q", content)
+	}
+}
+
+func TestDiskStoreRestoreRejectsManifestPathOutsideRoot(t *testing.T) {
+	root := t.TempDir()
+	dataDir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "outside.txt")
+	writeTestFile(t, filepath.Join(root, "notes.txt"), "v1")
+	writeTestFile(t, outside, "keep")
+	store, err := NewDiskStore(root, dataDir, DiskOptions{})
+	if err != nil {
+		t.Fatalf("open store failed: %v", err)
+	}
+	checkpointID, err := store.Snapshot(context.Background(), core.SnapshotRequest{SessionID: "sess_1", Paths: []string{"notes.txt"}})
+	if err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+	manifestPath := store.manifestPath(checkpointID)
+	raw := readTestFile(t, manifestPath)
+	writeTestFile(t, manifestPath, strings.Replace(raw, filepath.Join(root, "notes.txt"), outside, 1))
+
+	reopened, err := NewDiskStore(root, dataDir, DiskOptions{})
+	if err != nil {
+		t.Fatalf("reopen store failed: %v", err)
+	}
+	if err := reopened.Restore(context.Background(), checkpointID); err == nil {
+		t.Fatal("expected restore outside the workspace root to fail")
+	}
+	if content := readTestFile(t, outside); content != "keep" {
+		t.Fatalf("restore wrote outside the workspace root: %q", content)
+	}
+}
+
+func TestDiskStoreFilesArePrivate(t *testing.T) {
+	root := t.TempDir()
+	dataDir := filepath.Join(t.TempDir(), "checkpoints")
+	writeTestFile(t, filepath.Join(root, "notes.txt"), "v1")
+	store, err := NewDiskStore(root, dataDir, DiskOptions{})
+	if err != nil {
+		t.Fatalf("open store failed: %v", err)
+	}
+	checkpointID, err := store.Snapshot(context.Background(), core.SnapshotRequest{SessionID: "sess_1", Paths: []string{"notes.txt"}})
+	if err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+	blobs := listBlobs(t, dataDir)
+	if len(blobs) != 1 {
+		t.Fatalf("expected one blob, got %v", blobs)
+	}
+	for path, want := range map[string]os.FileMode{
+		filepath.Join(dataDir, blobsDirName):     0o700,
+		filepath.Dir(blobs[0]):                   0o700,
+		blobs[0]:                                 0o600,
+		store.manifestPath(checkpointID):         0o600,
+		filepath.Join(dataDir, manifestsDirName): 0o700,
+	} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("stat %s failed: %v", path, err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Fatalf("%s mode = %o, want %o", path, got, want)
+		}
+	}
+}
+
+func writeTestFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s failed: %v", path, err)
+	}
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s failed: %v", path, err)
+	}
+	return string(content)
+}
+
+func listBlobs(t *testing.T, dataDir string) []string {
+	t.Helper()
+	out := []string{}
+	err := filepath.WalkDir(filepath.Join(dataDir, blobsDirName), func(path string, entry os.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			out = append(out, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("walk blobs failed: %v", err)
+	}
+	return out
+}
+
+func countBlobs(t *testing.T, dataDir string) int {
+	t.Helper()
+	return len(listBlobs(t, dataDir))
+}
@@ -13,8 +13,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"goyais/services/hub/internal/agent/core"
 )
@@ -29,6 +31,7 @@ type snapshotEntry struct {
 type snapshot struct {
 	sessionID core.SessionID
 	reason    string
+	createdAt time.Time
 	entries   []snapshotEntry
 }
 
@@ -40,7 +43,10 @@ type Store struct {
 	snapshots map[core.CheckpointID]snapshot
 }
 
-var _ core.CheckpointStore = (*Store)(nil)
+var (
+	_ core.CheckpointStore  = (*Store)(nil)
+	_ core.CheckpointLister = (*Store)(nil)
+)
 
 // NewStore creates a checkpoint store rooted at one workspace path.
 func NewStore(rootDir string) *Store {
@@ -62,48 +68,9 @@ func (s *Store) Snapshot(ctx context.Context, req core.SnapshotRequest) (core.Ch
 		return "", errors.New("snapshot paths are required")
 	}
 
-	entries := make([]snapshotEntry, 0, len(req.Paths))
-	seen := map[string]struct{}{}
-	for _, item := range req.Paths {
-		select {
-		case <-ctx.Done():
-			return "", ctx.Err()
-		default:
-		}
-
-		absPath, err := s.resolvePath(item)
-		if err != nil {
-			return "", err
-		}
-		if _, exists := seen[absPath]; exists {
-			continue
-		}
-		seen[absPath] = struct{}{}
-
-		info, statErr := os.Stat(absPath)
-		if statErr != nil {
-			if os.IsNotExist(statErr) {
-				entries = append(entries, snapshotEntry{
-					absPath: absPath,
-					exists:  false,
-				})
-				continue
-			}
-			return "", fmt.Errorf("stat checkpoint path %q failed: %w", absPath, statErr)
-		}
-		if info.IsDir() {
-			return "", fmt.Errorf("checkpoint path %q is a directory", absPath)
-		}
-		content, readErr := os.ReadFile(absPath)
-		if readErr != nil {
-			return "", fmt.Errorf("read checkpoint path %q failed: %w", absPath, readErr)
-		}
-		entries = append(entries, snapshotEntry{
-			absPath: absPath,
-			exists:  true,
-			mode:    info.Mode().Perm(),
-			content: append([]byte(nil), content...),
-		})
+	entries, err := captureEntries(ctx, s.rootDir, req.Paths)
+	if err != nil {
+		return "", err
 	}
 
 	checkpointID := core.CheckpointID("cp_" + randomHex(8))
@@ -111,6 +78,7 @@ func (s *Store) Snapshot(ctx context.Context, req core.SnapshotRequest) (core.Ch
 	s.snapshots[checkpointID] = snapshot{
 		sessionID: req.SessionID,
 		reason:    strings.TrimSpace(req.Reason),
+		createdAt: time.Now().UTC(),
 		entries:   entries,
 	}
 	s.mu.Unlock()
@@ -140,33 +108,128 @@ func (s *Store) Restore(ctx context.Context, id core.CheckpointID) error {
 			return ctx.Err()
 		default:
 		}
-		if !entry.exists {
-			if err := os.Remove(entry.absPath); err != nil && !os.IsNotExist(err) {
-				return fmt.Errorf("remove %q failed: %w", entry.absPath, err)
-			}
+		if err := restoreEntry(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListCheckpoints returns the checkpoints of one session, oldest first.
+func (s *Store) ListCheckpoints(_ context.Context, sessionID core.SessionID) ([]core.CheckpointInfo, error) {
+	if s == nil {
+		return nil, errors.New("checkpoint store is nil")
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := []core.CheckpointInfo{}
+	for id, data := range s.snapshots {
+		if data.sessionID != sessionID {
 			continue
 		}
-		if err := os.MkdirAll(filepath.Dir(entry.absPath), 0o755); err != nil {
-			return fmt.Errorf("create restore parent for %q failed: %w", entry.absPath, err)
+		info := core.CheckpointInfo{
+			ID:        id,
+			SessionID: data.sessionID,
+			Reason:    data.reason,
+			CreatedAt: data.createdAt,
+			Paths:     make([]string, 0, len(data.entries)),
 		}
-		mode := entry.mode
-		if mode == 0 {
-			mode = 0o644
+		for _, entry := range data.entries {
+			info.Paths = append(info.Paths, entry.absPath)
+			info.SizeBytes += int64(len(entry.content))
 		}
-		if err := os.WriteFile(entry.absPath, entry.content, mode); err != nil {
-			return fmt.Errorf("restore %q failed: %w", entry.absPath, err)
+		out = append(out, info)
+	}
+	sortCheckpointInfos(out)
+	return out, nil
+}
+
+// captureEntries reads the current state of paths, resolved against rootDir.
+func captureEntries(ctx context.Context, rootDir string, paths []string) ([]snapshotEntry, error) {
+	entries := make([]snapshotEntry, 0, len(paths))
+	seen := map[string]struct{}{}
+	for _, item := range paths {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		absPath, err := resolvePath(rootDir, item)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := seen[absPath]; exists {
+			continue
+		}
+		seen[absPath] = struct{}{}
+
+		info, statErr := os.Stat(absPath)
+		if statErr != nil {
+			if os.IsNotExist(statErr) {
+				entries = append(entries, snapshotEntry{
+					absPath: absPath,
+					exists:  false,
+				})
+				continue
+			}
+			return nil, fmt.Errorf("stat checkpoint path %q failed: %w", absPath, statErr)
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("checkpoint path %q is a directory", absPath)
+		}
+		content, readErr := os.ReadFile(absPath)
+		if readErr != nil {
+			return nil, fmt.Errorf("read checkpoint path %q failed: %w", absPath, readErr)
+		}
+		entries = append(entries, snapshotEntry{
+			absPath: absPath,
+			exists:  true,
+			mode:    info.Mode().Perm(),
+			content: content,
+		})
+	}
+	return entries, nil
+}
+
+// restoreEntry writes one captured file back, or removes it when it did not
+// exist at snapshot time.
+func restoreEntry(entry snapshotEntry) error {
+	if !entry.exists {
+		if err := os.Remove(entry.absPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove %q failed: %w", entry.absPath, err)
 		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(entry.absPath), 0o755); err != nil {
+		return fmt.Errorf("create restore parent for %q failed: %w", entry.absPath, err)
+	}
+	mode := entry.mode
+	if mode == 0 {
+		mode = 0o644
+	}
+	if err := os.WriteFile(entry.absPath, entry.content, mode); err != nil {
+		return fmt.Errorf("restore %q failed: %w", entry.absPath, err)
 	}
 	return nil
 }
 
-func (s *Store) resolvePath(rawPath string) (string, error) {
+func sortCheckpointInfos(items []core.CheckpointInfo) {
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].CreatedAt.Equal(items[j].CreatedAt) {
+			return items[i].CreatedAt.Before(items[j].CreatedAt)
+		}
+		return items[i].ID < items[j].ID
+	})
+}
+
+func resolvePath(rootDir string, rawPath string) (string, error) {
 	trimmed := strings.TrimSpace(rawPath)
 	if trimmed == "" {
 		return "", errors.New("checkpoint path is empty")
 	}
 
-	root := strings.TrimSpace(s.rootDir)
+	root := strings.TrimSpace(rootDir)
 	if root == "" {
 		abs, err := filepath.Abs(trimmed)
 		if err != nil {
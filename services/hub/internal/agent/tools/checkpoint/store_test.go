@@ -87,3 +87,21 @@ func TestStoreSnapshotRejectsEmptyPaths(t *testing.T) {
 		t.Fatal("expected error for empty snapshot paths")
 	}
 }
+
+func TestStoreListCheckpointsFiltersBySession(t *testing.T) {
+	store := NewStore(t.TempDir())
+	own, err := store.Snapshot(context.Background(), core.SnapshotRequest{SessionID: "sess_1", Paths: []string{"a.txt"}, Reason: "before edit"})
+	if err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+	if _, err := store.Snapshot(context.Background(), core.SnapshotRequest{SessionID: "sess_2", Paths: []string{"a.txt"}}); err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+	timeline, err := store.ListCheckpoints(context.Background(), "sess_1")
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(timeline) != 1 || timeline[0].ID != own || timeline[0].Reason != "before edit" || len(timeline[0].Paths) != 1 {
+		t.Fatalf("unexpected timeline %#v", timeline)
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"goyais/services/hub/internal/agent/core"
+)
+
+// Permission layers in the order ExecuteSingle consults them.
+const (
+	LayerHooks      = "hooks"
+	LayerSandbox    = "sandbox"
+	LayerPermission = "permission"
+)
+
+// PermissionExplainer is implemented by permission gates that can report the
+// evidence behind a decision, such as matched rules and mode fallbacks.
+type PermissionExplainer interface {
+	Explain(ctx context.Context, req core.PermissionRequest) (core.PermissionDecision, map[string]any, error)
+}
+
+// ExplainLayer is the verdict of one permission layer for a dry-run call.
+type ExplainLayer struct {
+	Layer       string                      `json:"layer"`
+	Configured  bool                        `json:"configured"`
+	Decision    core.PermissionDecisionKind `json:"decision,omitempty"`
+	Reason      string                      `json:"reason,omitempty"`
+	MatchedRule string                      `json:"matched_rule,omitempty"`
+	Trace       map[string]any              `json:"trace,omitempty"`
+}
+
+// Explanation is the full permission trace of one hypothetical tool call.
+type Explanation struct {
+	ToolName   string                      `json:"tool_name"`
+	Input      map[string]any              `json:"input"`
+	Mode       core.PermissionMode         `json:"mode"`
+	WorkingDir string                      `json:"working_dir,omitempty"`
+	Layers     []ExplainLayer              `json:"layers"`
+	Decision   core.PermissionDecisionKind `json:"decision"`
+	DecidedBy  string                      `json:"decided_by,omitempty"`
+	Reason     string                      `json:"reason,omitempty"`
+	Precedence string                      `json:"precedence"`
+}
+
+const explainPrecedence = "layers run in order hooks, sandbox, permission; the first deny stops the call, otherwise any ask requires approval, otherwise the call is allowed"
+
+// Explain runs one tool call through every permission layer without invoking
+// the runner or waiting for approvals. Unlike ExecuteSingle it keeps going
+// after a deny so the trace shows every layer's verdict.
+func (p *Pipeline) Explain(ctx context.Context, req ExecuteSingleRequest) (Explanation, error) {
+	if p == nil {
+		return Explanation{}, fmt.Errorf("tool pipeline is not configured")
+	}
+	call := normalizeCall(req.Call)
+	if call.Name == "" {
+		return Explanation{}, fmt.Errorf("tool_name is required")
+	}
+	if call.CallID == "" {
+		call.CallID = "explain_" + randomHex(6)
+	}
+	workingDir := strings.TrimSpace(req.ToolContext.WorkingDir)
+	explanation := Explanation{
+		ToolName:   call.Name,
+		Mode:       normalizePermissionMode(req.SessionMode),
+		WorkingDir: workingDir,
+		Layers:     make([]ExplainLayer, 0, 3),
+		Precedence: explainPrecedence,
+	}
+
+	hookLayer := ExplainLayer{Layer: LayerHooks, Configured: p.hookDispatcher != nil}
+	if p.hookDispatcher != nil {
+		hookDecision, err := p.hookDispatcher.Dispatch(ctx, core.HookEvent{
+			Type: "PreToolUse",
+			Payload: map[string]any{
+				"tool_name": call.Name,
+				"call_id":   call.CallID,
+				"input":     cloneMapAny(call.Input),
+				"dry_run":   true,
+			},
+		})
+		if err != nil {
+			return Explanation{}, err
+		}
+		hookLayer.Decision = normalizeHookDecision(hookDecision.Decision)
+		hookLayer.Reason = extractHookReason(hookDecision.Metadata, "")
+		hookLayer.MatchedRule = strings.TrimSpace(hookDecision.MatchedPolicyID)
+		hookLayer.Trace = cloneMapAny(hookDecision.Metadata)
+		if updatedInput, ok := extractHookUpdatedInput(hookDecision.Metadata); ok {
+			call.Input = updatedInput
+		}
+	}
+	explanation.Layers = append(explanation.Layers, hookLayer)
+
+	sandboxLayer := ExplainLayer{Layer: LayerSandbox, Configured: p.sandboxGate != nil}
+	if p.sandboxGate != nil {
+		decision, err := p.sandboxGate.Evaluate(ctx, SandboxRequest{
+			ToolName:   call.Name,
+			Input:      cloneMapAny(call.Input),
+			WorkingDir: workingDir,
+		})
+		if err != nil {
+			return Explanation{}, err
+		}
+		sandboxLayer.Decision = normalizeSandboxDecision(decision.Kind)
+		sandboxLayer.Reason = strings.TrimSpace(decision.Reason)
+		sandboxLayer.MatchedRule = strings.TrimSpace(decision.MatchedRule)
+		sandboxLayer.Trace = cloneMapAny(decision.Metadata)
+	}
+	explanation.Layers = append(explanation.Layers, sandboxLayer)
+
+	permissionLayer := ExplainLayer{Layer: LayerPermission, Configured: p.permissionGate != nil}
+	if p.permissionGate != nil {
+		permissionReq := core.PermissionRequest{
+			Mode:       explanation.Mode,
+			ToolName:   call.Name,
+			Arguments:  renderOutput(call.Input),
+			WorkingDir: workingDir,
+		}
+		var (
+			decision core.PermissionDecision
+			trace    map[string]any
+			err      error
+		)
+		if explainer, ok := p.permissionGate.(PermissionExplainer); ok {
+			decision, trace, err = explainer.Explain(ctx, permissionReq)
+		} else {
+			decision, err = p.permissionGate.Evaluate(ctx, permissionReq)
+		}
+		if err != nil {
+			return Explanation{}, err
+		}
+		permissionLayer.Decision = decision.Kind
+		permissionLayer.Reason = strings.TrimSpace(decision.Reason)
+		permissionLayer.MatchedRule = strings.TrimSpace(decision.MatchedRule)
+		permissionLayer.Trace = trace
+	}
+	explanation.Layers = append(explanation.Layers, permissionLayer)
+
+	explanation.Input = cloneMapAny(call.Input)
+	explanation.Decision, explanation.DecidedBy, explanation.Reason = resolveExplainDecision(explanation.Layers)
+	return explanation, nil
+}
+
+// resolveExplainDecision applies ExecuteSingle precedence to layer verdicts.
+func resolveExplainDecision(layers []ExplainLayer) (core.PermissionDecisionKind, string, string) {
+	for _, kind := range []core.PermissionDecisionKind{core.PermissionDecisionDeny, core.PermissionDecisionAsk} {
+		for _, item := range layers {
+			if item.Configured && item.Decision == kind {
+				return kind, item.Layer, item.Reason
+			}
+		}
+	}
+	return core.PermissionDecisionAllow, "", "no layer denied or asked"
+}
+
+// ExplainInput converts a command-line style argument into tool input. JSON
+// objects are used as-is; otherwise the text becomes the tool's primary
+// argument (command, url or path).
+func ExplainInput(toolName string, raw string) map[string]any {
+	trimmed := strings.TrimSpace(raw)
+	if strings.HasPrefix(trimmed, "{") {
+		input := map[string]any{}
+		if err := json.Unmarshal([]byte(trimmed), &input); err == nil {
+			return input
+		}
+	}
+	if trimmed == "" {
+		return map[string]any{}
+	}
+	normalized := strings.ToLower(strings.TrimSpace(toolName))
+	switch {
+	case strings.Contains(normalized, "bash") || strings.Contains(normalized, "shell") || strings.Contains(normalized, "command"):
+		return map[string]any{"command": trimmed}
+	case strings.Contains(normalized, "fetch"):
+		return map[string]any{"url": trimmed}
+	case strings.Contains(normalized, "search"):
+		return map[string]any{"query": trimmed}
+	default:
+		return map[string]any{"path": trimmed}
+	}
+}
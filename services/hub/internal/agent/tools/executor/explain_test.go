@@ -0,0 +1,95 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package executor
+
+import (
+	"context"
+	"testing"
+
+	"goyais/services/hub/internal/agent/core"
+	"goyais/services/hub/internal/agent/policy"
+)
+
+func TestExplainReportsEveryLayerWithoutRunning(t *testing.T) {
+	runner := &stubRunner{}
+	hooks := &stubHookDispatcher{
+		decision: core.HookDecision{
+			Decision:        "allow",
+			MatchedPolicyID: "hook_rewrite",
+			Metadata: map[string]any{
+				"updated_input": map[string]any{"command": "npm run lint && rm -rf build"},
+				"scope":         "project",
+			},
+		},
+	}
+	sandbox := &stubSandboxGate{decision: SandboxDecision{Kind: core.PermissionDecisionAsk, Reason: "outside workspace"}}
+	gate, err := policy.NewGateFromLines([]string{`allow Bash(npm run *)`, `deny Bash(rm:*)`})
+	if err != nil {
+		t.Fatalf("new gate failed: %v", err)
+	}
+	pipeline := NewPipeline(Dependencies{
+		Runner:         runner,
+		HookDispatcher: hooks,
+		SandboxGate:    sandbox,
+		PermissionGate: gate,
+	})
+
+	explanation, err := pipeline.Explain(context.Background(), ExecuteSingleRequest{
+		Call:        ToolCall{Name: "Bash", Input: ExplainInput("Bash", "npm run lint")},
+		SessionMode: "acceptEdits",
+	})
+	if err != nil {
+		t.Fatalf("explain failed: %v", err)
+	}
+	if len(runner.calls) != 0 {
+		t.Fatalf("explain must not run the tool, got %d calls", len(runner.calls))
+	}
+	if hooks.events[0].Payload["dry_run"] != true {
+		t.Fatalf("expected dry_run marker in hook payload, got %#v", hooks.events[0].Payload)
+	}
+	if sandbox.requests[0].Input["command"] != "npm run lint && rm -rf build" {
+		t.Fatalf("expected hook-updated input to reach sandbox, got %#v", sandbox.requests[0].Input)
+	}
+	if len(explanation.Layers) != 3 {
+		t.Fatalf("expected three layers, got %#v", explanation.Layers)
+	}
+	if explanation.Layers[0].MatchedRule != "hook_rewrite" || explanation.Layers[1].Decision != core.PermissionDecisionAsk {
+		t.Fatalf("unexpected hook/sandbox layers %#v", explanation.Layers[:2])
+	}
+	permission := explanation.Layers[2]
+	if permission.Decision != core.PermissionDecisionDeny || permission.MatchedRule != "deny Bash(rm:*)" {
+		t.Fatalf("unexpected permission layer %#v", permission)
+	}
+	if permission.Trace["source"] != "rule" || len(permission.Trace["matched_rules"].([]map[string]any)) != 2 {
+		t.Fatalf("expected rule trace with both matches, got %#v", permission.Trace)
+	}
+	if explanation.Decision != core.PermissionDecisionDeny || explanation.DecidedBy != LayerPermission {
+		t.Fatalf("expected deny decided by permission layer, got %q by %q", explanation.Decision, explanation.DecidedBy)
+	}
+	if explanation.Mode != core.PermissionModeAcceptEdits {
+		t.Fatalf("unexpected mode %q", explanation.Mode)
+	}
+}
+
+func TestExplainFallsBackToAllowWhenNoLayerObjects(t *testing.T) {
+	pipeline := NewPipeline(Dependencies{Runner: &stubRunner{}})
+	explanation, err := pipeline.Explain(context.Background(), ExecuteSingleRequest{
+		Call: ToolCall{Name: "Read", Input: ExplainInput("Read", "./README.md")},
+	})
+	if err != nil {
+		t.Fatalf("explain failed: %v", err)
+	}
+	if explanation.Decision != core.PermissionDecisionAllow || explanation.DecidedBy != "" {
+		t.Fatalf("unexpected decision %#v", explanation)
+	}
+	for _, layer := range explanation.Layers {
+		if layer.Configured {
+			t.Fatalf("expected unconfigured layer, got %#v", layer)
+		}
+	}
+	if explanation.Input["path"] != "./README.md" {
+		t.Fatalf("unexpected input %#v", explanation.Input)
+	}
+}
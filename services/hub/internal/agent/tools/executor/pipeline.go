@@ -30,8 +30,9 @@ type ToolCall struct {
 
 // ToolContext carries execution environment metadata for tools.
 type ToolContext struct {
-	WorkingDir string
-	Env        map[string]string
+	WorkingDir            string
+	AdditionalDirectories []string
+	Env                   map[string]string
 }
 
 // RunRequest is the executor backend input for one tool attempt.
@@ -54,6 +55,7 @@ type ApprovalRequest struct {
 	ToolName string
 	CallID   string
 	Reason   string
+	Input    map[string]any
 }
 
 // ApprovalAction is the user decision for one approval checkpoint.
@@ -71,6 +73,21 @@ type ApprovalWaiter interface {
 	WaitForApproval(ctx context.Context, req ApprovalRequest) (ApprovalAction, error)
 }
 
+// ApprovalDecision is an approval action with optional call rewrites.
+type ApprovalDecision struct {
+	Action ApprovalAction
+	// UpdatedInput replaces the call input when non-nil.
+	UpdatedInput map[string]any
+	// Reason overrides the denial text reported to the model.
+	Reason string
+}
+
+// ApprovalDecisionWaiter is an optional ApprovalWaiter extension for deciders
+// that may rewrite the call, such as a delegated permission prompt tool.
+type ApprovalDecisionWaiter interface {
+	WaitForApprovalDecision(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error)
+}
+
 // UserAnswer is the normalized user answer for a tool-generated question.
 type UserAnswer struct {
 	QuestionID       string
@@ -83,6 +100,19 @@ type UserAnswerWaiter interface {
 	WaitForAnswer(ctx context.Context, question interaction.PendingUserQuestion) (UserAnswer, error)
 }
 
+// PlanApprovalRequest is one markdown plan submitted with ExitPlanMode.
+type PlanApprovalRequest struct {
+	CallID   string
+	ToolName string
+	Plan     string
+}
+
+// PlanApprovalWaiter blocks until a submitted plan is approved or rejected and
+// returns the reviewed plan record.
+type PlanApprovalWaiter interface {
+	WaitForPlanApproval(ctx context.Context, req PlanApprovalRequest) (core.PlanRecord, error)
+}
+
 // SandboxRequest contains sandbox-evaluation inputs for one tool call.
 type SandboxRequest struct {
 	ToolName   string
@@ -103,6 +133,12 @@ type SandboxGate interface {
 	Evaluate(ctx context.Context, req SandboxRequest) (SandboxDecision, error)
 }
 
+// OutputRedactor scrubs secrets from raw tool output before it is rendered
+// for the model. Implementations record their own detections.
+type OutputRedactor interface {
+	RedactToolOutput(ctx context.Context, call ToolCall, output map[string]any) map[string]any
+}
+
 // Dependencies declares explicit collaborators for the pipeline.
 type Dependencies struct {
 	Runner           Runner
@@ -112,6 +148,8 @@ type Dependencies struct {
 	PermissionGate   core.PermissionGate
 	ApprovalWaiter   ApprovalWaiter
 	UserAnswerWaiter UserAnswerWaiter
+	PlanWaiter       PlanApprovalWaiter
+	OutputRedactor   OutputRedactor
 }
 
 // Pipeline executes tool calls with concurrency grouping and approval retries.
@@ -123,6 +161,8 @@ type Pipeline struct {
 	permissionGate   core.PermissionGate
 	approvalWaiter   ApprovalWaiter
 	userAnswerWaiter UserAnswerWaiter
+	planWaiter       PlanApprovalWaiter
+	outputRedactor   OutputRedactor
 }
 
 var _ core.ToolExecutor = (*Pipeline)(nil)
@@ -137,6 +177,8 @@ func NewPipeline(deps Dependencies) *Pipeline {
 		permissionGate:   deps.PermissionGate,
 		approvalWaiter:   deps.ApprovalWaiter,
 		userAnswerWaiter: deps.UserAnswerWaiter,
+		planWaiter:       deps.PlanWaiter,
+		outputRedactor:   deps.OutputRedactor,
 	}
 }
 
@@ -161,8 +203,10 @@ func (p *Pipeline) Execute(ctx context.Context, call core.ToolCall) (core.ToolRe
 		return core.ToolResult{}, err
 	}
 	out := core.ToolResult{
-		ToolName: item.ToolName,
-		Output:   cloneMapAny(item.Output),
+		ToolName:          item.ToolName,
+		Output:            cloneMapAny(item.Output),
+		Content:           item.Content,
+		StructuredContent: item.StructuredContent,
 	}
 	if item.ErrorText != "" {
 		out.Error = &core.RunError{
@@ -197,6 +241,10 @@ type ExecuteSingleResult struct {
 	OutputText      string
 	ErrorText       string
 	PendingQuestion *interaction.PendingUserQuestion
+	// Content and StructuredContent carry typed result parts, such as the
+	// images and resources an MCP tool returns.
+	Content           []core.ToolContentPart
+	StructuredContent map[string]any
 }
 
 // OK reports whether the result is successful.
@@ -339,7 +387,7 @@ func (p *Pipeline) ExecuteSingle(ctx context.Context, req ExecuteSingleRequest)
 			}, nil
 		case core.PermissionDecisionAsk:
 			reason := extractHookReason(hookDecision.Metadata, "hook policy requires approval")
-			userApproved, denied, waitErr := p.waitForApproval(ctx, call, reason)
+			userApproved, denied, waitErr := p.waitForApproval(ctx, &call, reason)
 			if waitErr != nil {
 				return ExecuteSingleResult{}, waitErr
 			}
@@ -372,7 +420,7 @@ func (p *Pipeline) ExecuteSingle(ctx context.Context, req ExecuteSingleRequest)
 				ErrorText: errText,
 			}, nil
 		case core.PermissionDecisionAsk:
-			userApproved, denied, waitErr := p.waitForApproval(ctx, call, strings.TrimSpace(decision.Reason))
+			userApproved, denied, waitErr := p.waitForApproval(ctx, &call, strings.TrimSpace(decision.Reason))
 			if waitErr != nil {
 				return ExecuteSingleResult{}, waitErr
 			}
@@ -405,7 +453,7 @@ func (p *Pipeline) ExecuteSingle(ctx context.Context, req ExecuteSingleRequest)
 				ErrorText: errText,
 			}, nil
 		case core.PermissionDecisionAsk:
-			userApproved, denied, waitErr := p.waitForApproval(ctx, call, strings.TrimSpace(decision.Reason))
+			userApproved, denied, waitErr := p.waitForApproval(ctx, &call, strings.TrimSpace(decision.Reason))
 			if waitErr != nil {
 				return ExecuteSingleResult{}, waitErr
 			}
@@ -426,12 +474,16 @@ func (p *Pipeline) ExecuteSingle(ctx context.Context, req ExecuteSingleRequest)
 			Call:        call,
 		})
 		if err == nil {
-			return p.resolveOutput(ctx, call, output)
+			result, resolveErr := p.resolveOutput(ctx, call, output)
+			if resolveErr != nil {
+				return ExecuteSingleResult{}, resolveErr
+			}
+			return p.dispatchPostToolUse(ctx, call, result)
 		}
 
 		var approvalErr *ApprovalRequiredError
 		if errors.As(err, &approvalErr) {
-			userApproved, denied, waitErr := p.waitForApproval(ctx, call, strings.TrimSpace(approvalErr.Reason))
+			userApproved, denied, waitErr := p.waitForApproval(ctx, &call, strings.TrimSpace(approvalErr.Reason))
 			if waitErr != nil {
 				return ExecuteSingleResult{}, waitErr
 			}
@@ -446,15 +498,39 @@ func (p *Pipeline) ExecuteSingle(ctx context.Context, req ExecuteSingleRequest)
 		if errText == "" {
 			errText = "tool execution failed"
 		}
-		return ExecuteSingleResult{
+		return p.dispatchPostToolUse(ctx, call, ExecuteSingleResult{
 			CallID:    call.CallID,
 			ToolName:  call.Name,
 			ErrorText: errText,
-		}, nil
+		})
+	}
+}
+
+// dispatchPostToolUse reports a tool call that ran to the PostToolUse or,
+// when it failed, the PostToolUseFailure hooks.
+func (p *Pipeline) dispatchPostToolUse(ctx context.Context, call ToolCall, result ExecuteSingleResult) (ExecuteSingleResult, error) {
+	if p.hookDispatcher == nil {
+		return result, nil
 	}
+	payload := map[string]any{
+		"tool_name": call.Name,
+		"call_id":   call.CallID,
+		"input":     cloneMapAny(call.Input),
+	}
+	eventType := "PostToolUse"
+	if result.OK() {
+		payload["output"] = cloneMapAny(result.Output)
+	} else {
+		eventType = "PostToolUseFailure"
+		payload["error"] = result.ErrorText
+	}
+	if _, err := p.hookDispatcher.Dispatch(ctx, core.HookEvent{Type: eventType, Payload: payload}); err != nil {
+		return ExecuteSingleResult{}, err
+	}
+	return result, nil
 }
 
-func (p *Pipeline) waitForApproval(ctx context.Context, call ToolCall, reason string) (bool, *ExecuteSingleResult, error) {
+func (p *Pipeline) waitForApproval(ctx context.Context, call *ToolCall, reason string) (bool, *ExecuteSingleResult, error) {
 	if p.approvalWaiter == nil {
 		return false, nil, &ApprovalRequiredError{
 			ToolName: call.Name,
@@ -462,19 +538,23 @@ func (p *Pipeline) waitForApproval(ctx context.Context, call ToolCall, reason st
 		}
 	}
 	for {
-		action, waitErr := p.approvalWaiter.WaitForApproval(ctx, ApprovalRequest{
+		decision, waitErr := p.requestApproval(ctx, ApprovalRequest{
 			ToolName: call.Name,
 			CallID:   call.CallID,
 			Reason:   reason,
+			Input:    cloneMapAny(call.Input),
 		})
 		if waitErr != nil {
 			return false, nil, waitErr
 		}
-		switch action {
+		switch decision.Action {
 		case ApprovalActionStop:
 			return false, nil, context.Canceled
 		case ApprovalActionDeny:
-			errText := strings.TrimSpace(reason)
+			errText := strings.TrimSpace(decision.Reason)
+			if errText == "" {
+				errText = strings.TrimSpace(reason)
+			}
 			if errText == "" {
 				errText = "tool call denied by user"
 			}
@@ -485,6 +565,9 @@ func (p *Pipeline) waitForApproval(ctx context.Context, call ToolCall, reason st
 			}
 			return false, &denied, nil
 		case ApprovalActionApprove, ApprovalActionResume:
+			if decision.UpdatedInput != nil {
+				call.Input = cloneMapAny(decision.UpdatedInput)
+			}
 			return true, nil, nil
 		default:
 			continue
@@ -492,6 +575,14 @@ func (p *Pipeline) waitForApproval(ctx context.Context, call ToolCall, reason st
 	}
 }
 
+func (p *Pipeline) requestApproval(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+	if decider, ok := p.approvalWaiter.(ApprovalDecisionWaiter); ok {
+		return decider.WaitForApprovalDecision(ctx, req)
+	}
+	action, err := p.approvalWaiter.WaitForApproval(ctx, req)
+	return ApprovalDecision{Action: action}, err
+}
+
 func (p *Pipeline) canRunInParallel(name string) bool {
 	if p.specs == nil {
 		return false
@@ -504,11 +595,29 @@ func (p *Pipeline) canRunInParallel(name string) bool {
 }
 
 func (p *Pipeline) resolveOutput(ctx context.Context, call ToolCall, output map[string]any) (ExecuteSingleResult, error) {
+	if p.outputRedactor != nil {
+		output = p.outputRedactor.RedactToolOutput(ctx, call, output)
+	}
+	content, structured := extractToolContent(output)
+	output = withoutContentData(output)
 	result := ExecuteSingleResult{
-		CallID:     call.CallID,
-		ToolName:   call.Name,
-		Output:     cloneMapAny(output),
-		OutputText: renderOutput(output),
+		CallID:            call.CallID,
+		ToolName:          call.Name,
+		Output:            cloneMapAny(output),
+		OutputText:        renderOutput(output),
+		Content:           content,
+		StructuredContent: structured,
+	}
+	if failed, _ := output["is_error"].(bool); failed {
+		text, _ := output["output"].(string)
+		result.ErrorText = strings.TrimSpace(text)
+		if result.ErrorText == "" {
+			result.ErrorText = call.Name + " reported an error"
+		}
+		return result, nil
+	}
+	if interaction.RequiresPlanApprovalFromToolResult(output) {
+		return p.resolvePlanApproval(ctx, call, result)
 	}
 	if !interaction.RequiresUserInputFromToolResult(output) {
 		return result, nil
@@ -535,6 +644,68 @@ func (p *Pipeline) resolveOutput(ctx context.Context, call ToolCall, output map[
 	return result, nil
 }
 
+// resolvePlanApproval waits for the review of a plan submitted with
+// ExitPlanMode and tells the model how to continue.
+func (p *Pipeline) resolvePlanApproval(ctx context.Context, call ToolCall, result ExecuteSingleResult) (ExecuteSingleResult, error) {
+	if p.planWaiter == nil {
+		return result, nil
+	}
+	record, err := p.planWaiter.WaitForPlanApproval(ctx, PlanApprovalRequest{
+		CallID:   call.CallID,
+		ToolName: call.Name,
+		Plan:     interaction.PlanContentFromToolResult(result.Output),
+	})
+	if err != nil {
+		return ExecuteSingleResult{}, err
+	}
+	merged := cloneMapAny(result.Output)
+	merged["requires_plan_approval"] = false
+	merged["plan_id"] = record.ID
+	merged["status"] = record.Status
+	if record.NextMode != "" {
+		merged["mode"] = record.NextMode
+	}
+	if record.Feedback != "" {
+		merged["feedback"] = record.Feedback
+	}
+	if record.Status == core.PlanStatusApproved {
+		merged["message"] = "The user approved the plan. You can now start implementing it."
+	} else {
+		merged["message"] = "The user rejected the plan. Stay in plan mode, revise the plan using the feedback and submit it again."
+	}
+	result.Output = merged
+	result.OutputText = renderOutput(merged)
+	return result, nil
+}
+
+// extractToolContent reads the typed parts a runner attached to its output
+// under "content" and "structured_content".
+func extractToolContent(output map[string]any) ([]core.ToolContentPart, map[string]any) {
+	content, _ := output["content"].([]core.ToolContentPart)
+	structured, _ := output["structured_content"].(map[string]any)
+	if len(content) == 0 {
+		content = nil
+	}
+	return content, structured
+}
+
+// withoutContentData drops binary payloads from typed parts so the rendered
+// output stays text; ExecuteSingleResult.Content keeps them.
+func withoutContentData(output map[string]any) map[string]any {
+	content, _ := output["content"].([]core.ToolContentPart)
+	if len(content) == 0 {
+		return output
+	}
+	stripped := make([]core.ToolContentPart, 0, len(content))
+	for _, part := range content {
+		part.Data = ""
+		stripped = append(stripped, part)
+	}
+	out := cloneMapAny(output)
+	out["content"] = stripped
+	return out
+}
+
 func normalizeCall(call ToolCall) ToolCall {
 	call.CallID = strings.TrimSpace(call.CallID)
 	call.Name = strings.TrimSpace(call.Name)
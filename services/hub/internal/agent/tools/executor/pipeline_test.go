@@ -7,6 +7,7 @@ package executor
 import (
 	"context"
 	"errors"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -761,3 +762,184 @@ func TestExecuteSingle_HookUpdatedInputOverridesCallInput(t *testing.T) {
 		t.Fatalf("expected hook-updated input content, got %#v", runner.calls[0].Call.Input)
 	}
 }
+
+type stubDecisionWaiter struct {
+	decision ApprovalDecision
+}
+
+func (s *stubDecisionWaiter) WaitForApproval(_ context.Context, _ ApprovalRequest) (ApprovalAction, error) {
+	return "", errors.New("WaitForApprovalDecision should be preferred")
+}
+
+func (s *stubDecisionWaiter) WaitForApprovalDecision(_ context.Context, _ ApprovalRequest) (ApprovalDecision, error) {
+	return s.decision, nil
+}
+
+func TestExecuteSingle_ApprovalDecisionUpdatesInputOrDeniesWithReason(t *testing.T) {
+	gate := &stubPermissionGate{decision: core.PermissionDecision{Kind: core.PermissionDecisionAsk, Reason: "needs approval"}}
+	runner := &stubRunner{}
+	pipeline := NewPipeline(Dependencies{
+		Runner:         runner,
+		PermissionGate: gate,
+		ApprovalWaiter: &stubDecisionWaiter{decision: ApprovalDecision{
+			Action:       ApprovalActionApprove,
+			UpdatedInput: map[string]any{"command": "git status --short"},
+		}},
+	})
+	result, err := pipeline.ExecuteSingle(context.Background(), ExecuteSingleRequest{
+		Call: ToolCall{CallID: "call_update", Name: "Bash", Input: map[string]any{"command": "git status"}},
+	})
+	if err != nil || result.ErrorText != "" {
+		t.Fatalf("expected approved call, got %#v, %v", result, err)
+	}
+	if len(runner.calls) != 1 || runner.calls[0].Call.Input["command"] != "git status --short" {
+		t.Fatalf("expected runner to receive updated input, got %#v", runner.calls)
+	}
+
+	pipeline = NewPipeline(Dependencies{
+		Runner:         runner,
+		PermissionGate: gate,
+		ApprovalWaiter: &stubDecisionWaiter{decision: ApprovalDecision{Action: ApprovalActionDeny, Reason: "blocked by CI policy"}},
+	})
+	result, err = pipeline.ExecuteSingle(context.Background(), ExecuteSingleRequest{
+		Call: ToolCall{CallID: "call_deny", Name: "Bash", Input: map[string]any{"command": "rm -rf dist"}},
+	})
+	if err != nil || result.ErrorText != "blocked by CI policy" {
+		t.Fatalf("expected denial reason from decision, got %#v, %v", result, err)
+	}
+}
+
+type stubOutputRedactor struct {
+	calls []string
+}
+
+func (s *stubOutputRedactor) RedactToolOutput(_ context.Context, call ToolCall, output map[string]any) map[string]any {
+	s.calls = append(s.calls, call.Name)
+	redacted := cloneMapAny(output)
+	redacted["output"] = "token [REDACTED:github_token:0f3e2a1b]"
+	return redacted
+}
+
+func TestExecuteSingle_RedactsOutputBeforeRendering(t *testing.T) {
+	redactor := &stubOutputRedactor{}
+	pipeline := NewPipeline(Dependencies{
+		Runner: &stubRunner{run: func(RunRequest) (map[string]any, error) {
+			return map[string]any{"ok": true, "output": "token ghp_secret"}, nil
+		}},
+		OutputRedactor: redactor,
+	})
+	result, err := pipeline.ExecuteSingle(context.Background(), ExecuteSingleRequest{
+		Call: ToolCall{CallID: "call_read", Name: "Read", Input: map[string]any{"file_path": "notes.txt"}},
+	})
+	if err != nil || len(redactor.calls) != 1 {
+		t.Fatalf("expected redactor to run once, got %#v, %v", redactor.calls, err)
+	}
+	if strings.Contains(result.OutputText, "ghp_secret") || result.Output["output"] != "token [REDACTED:github_token:0f3e2a1b]" {
+		t.Fatalf("expected redacted output, got %#v", result)
+	}
+}
+
+type stubPlanWaiter struct {
+	requests []PlanApprovalRequest
+	record   core.PlanRecord
+}
+
+func (s *stubPlanWaiter) WaitForPlanApproval(_ context.Context, req PlanApprovalRequest) (core.PlanRecord, error) {
+	s.requests = append(s.requests, req)
+	return s.record, nil
+}
+
+func TestExecuteSingle_PlanApprovalReportsReviewOutcome(t *testing.T) {
+	runner := &stubRunner{run: func(RunRequest) (map[string]any, error) {
+		return map[string]any{"ok": true, "requires_plan_approval": true, "plan": "# Plan\n1. step"}, nil
+	}}
+	waiter := &stubPlanWaiter{record: core.PlanRecord{ID: "plan_call_plan", Status: core.PlanStatusRejected, Feedback: "cover the rollback path"}}
+	pipeline := NewPipeline(Dependencies{Runner: runner, PlanWaiter: waiter})
+	result, err := pipeline.ExecuteSingle(context.Background(), ExecuteSingleRequest{
+		Call: ToolCall{CallID: "call_plan", Name: "ExitPlanMode", Input: map[string]any{"plan": "# Plan\n1. step"}},
+	})
+	if err != nil {
+		t.Fatalf("execute plan call: %v", err)
+	}
+	if len(waiter.requests) != 1 || waiter.requests[0].Plan != "# Plan\n1. step" || waiter.requests[0].CallID != "call_plan" {
+		t.Fatalf("expected plan to reach the waiter, got %#v", waiter.requests)
+	}
+	if result.Output["status"] != core.PlanStatusRejected || result.Output["feedback"] != "cover the rollback path" || result.Output["requires_plan_approval"] != false {
+		t.Fatalf("expected rejection with feedback, got %#v", result.Output)
+	}
+	if !strings.Contains(result.OutputText, "cover the rollback path") {
+		t.Fatalf("expected feedback in rendered output, got %q", result.OutputText)
+	}
+
+	waiter.record = core.PlanRecord{ID: "plan_call_plan", Status: core.PlanStatusApproved, NextMode: "acceptEdits"}
+	result, _ = pipeline.ExecuteSingle(context.Background(), ExecuteSingleRequest{
+		Call: ToolCall{CallID: "call_plan", Name: "ExitPlanMode", Input: map[string]any{"plan": "# Plan\n1. step"}},
+	})
+	if result.Output["status"] != core.PlanStatusApproved || result.Output["mode"] != "acceptEdits" {
+		t.Fatalf("expected approval with mode switch, got %#v", result.Output)
+	}
+}
+
+func TestExecuteSingle_ToolErrorResultFailsAndDispatchesPostHooks(t *testing.T) {
+	runner := &stubRunner{run: func(req RunRequest) (map[string]any, error) {
+		if req.Call.Name == "mcp__docs__render" {
+			return map[string]any{
+				"ok":       false,
+				"is_error": true,
+				"output":   "page not found",
+			}, nil
+		}
+		return map[string]any{
+			"ok":     true,
+			"output": "rendered",
+			"content": []core.ToolContentPart{
+				{Type: core.ToolContentText, Text: "rendered"},
+				{Type: core.ToolContentImage, MIMEType: "image/png", Data: "aGVsbG8="},
+			},
+			"structured_content": map[string]any{"pages": float64(2)},
+		}, nil
+	}}
+	hooks := &stubHookDispatcher{}
+	pipeline := NewPipeline(Dependencies{Runner: runner, HookDispatcher: hooks})
+
+	failed, err := pipeline.ExecuteSingle(context.Background(), ExecuteSingleRequest{
+		Call: ToolCall{CallID: "call_failed", Name: "mcp__docs__render"},
+	})
+	if err != nil {
+		t.Fatalf("execute single failed: %v", err)
+	}
+	if failed.OK() || failed.ErrorText != "page not found" {
+		t.Fatalf("expected isError to fail the call, got %#v", failed)
+	}
+
+	rendered, err := pipeline.ExecuteSingle(context.Background(), ExecuteSingleRequest{
+		Call: ToolCall{CallID: "call_rendered", Name: "mcp__docs__snapshot"},
+	})
+	if err != nil {
+		t.Fatalf("execute single failed: %v", err)
+	}
+	if !rendered.OK() || len(rendered.Content) != 2 || rendered.Content[1].Data != "aGVsbG8=" {
+		t.Fatalf("expected typed content to be preserved, got %#v", rendered.Content)
+	}
+	if rendered.StructuredContent["pages"] != float64(2) {
+		t.Fatalf("unexpected structured content %#v", rendered.StructuredContent)
+	}
+	if strings.Contains(rendered.OutputText, "aGVsbG8=") {
+		t.Fatalf("expected image data to stay out of the output text, got %s", rendered.OutputText)
+	}
+
+	types := []string{}
+	for _, event := range hooks.events {
+		types = append(types, event.Type)
+	}
+	want := "PreToolUse,PostToolUseFailure,PreToolUse,PostToolUse"
+	if strings.Join(types, ",") != want {
+		t.Fatalf("expected hook events %s, got %v", want, types)
+	}
+	if hooks.events[1].Payload["error"] != "page not found" {
+		t.Fatalf("unexpected failure hook payload %#v", hooks.events[1].Payload)
+	}
+	if output, _ := hooks.events[3].Payload["output"].(map[string]any); output["output"] != "rendered" {
+		t.Fatalf("unexpected post hook payload %#v", hooks.events[3].Payload)
+	}
+}
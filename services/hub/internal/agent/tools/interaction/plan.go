@@ -0,0 +1,20 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package interaction
+
+// RequiresPlanApprovalFromToolResult reports whether tool output submits a
+// plan for review, as ExitPlanMode does.
+func RequiresPlanApprovalFromToolResult(output map[string]any) bool {
+	if len(output) == 0 {
+		return false
+	}
+	required, _ := output["requires_plan_approval"].(bool)
+	return required
+}
+
+// PlanContentFromToolResult returns the markdown plan carried by tool output.
+func PlanContentFromToolResult(output map[string]any) string {
+	return lookupString(output, "plan")
+}
@@ -0,0 +1,315 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+// Package ossandbox confines Bash and plugin executables with an opt-in
+// operating-system sandbox. On Linux it combines user, mount, pid and network
+// namespaces with Landlock, a read-only root and rlimits; other platforms and
+// kernels without support fall back to unsandboxed execution with an explicit
+// status.
+package ossandbox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"goyais/services/hub/internal/agent/context/settings"
+)
+
+// NetworkMode controls outbound network access inside the sandbox.
+type NetworkMode string
+
+const (
+	// NetworkOff isolates the command in an empty network namespace.
+	NetworkOff NetworkMode = "off"
+	// NetworkAllowlist isolates the command and routes HTTP(S) traffic through
+	// a local proxy that only admits allowlisted domains.
+	NetworkAllowlist NetworkMode = "allowlist"
+	// NetworkOn shares the host network namespace.
+	NetworkOn NetworkMode = "on"
+)
+
+// Limits are per-command resource limits applied as rlimits.
+type Limits struct {
+	CPUSeconds   int
+	MemoryMB     int
+	MaxProcesses int
+}
+
+// Config is the per-project sandbox configuration read from the "sandbox"
+// settings key, e.g. in .goyais/settings.json:
+//
+//	{"sandbox": {"enabled": true, "network": {"mode": "allowlist",
+//	  "allowedDomains": ["*.github.com"]}, "writableDirs": ["../shared"],
+//	  "limits": {"cpuSeconds": 120, "memoryMB": 2048, "maxProcesses": 256}}}
+type Config struct {
+	Enabled           bool
+	FailIfUnavailable bool
+	WritableDirs      []string
+	PrivateTmp        bool
+	Network           NetworkMode
+	AllowedDomains    []string
+	Limits            Limits
+}
+
+// DefaultConfig returns the disabled configuration with safe defaults for
+// every other field.
+func DefaultConfig() Config {
+	return Config{
+		Enabled:    false,
+		PrivateTmp: true,
+		Network:    NetworkOff,
+	}
+}
+
+// LoadProjectConfig resolves the effective sandbox configuration for one
+// project by merging user, project, local and managed settings layers. The
+// project and local layers come with the repository, so once the user or
+// managed layer enables the sandbox they can only narrow it; see
+// ConfigFromLayers.
+func LoadProjectConfig(workingDir string, homeDir string) (Config, error) {
+	layers, err := settings.LoadLayers(settings.LoadOptions{
+		WorkingDir: strings.TrimSpace(workingDir),
+		HomeDir:    strings.TrimSpace(homeDir),
+	})
+	if err != nil {
+		return DefaultConfig(), err
+	}
+	merged, err := settings.Merge(layers)
+	if err != nil {
+		return DefaultConfig(), err
+	}
+	trusted, err := settings.Merge(settings.LayeredSettings{
+		User:    layers.User,
+		CLI:     layers.CLI,
+		Managed: layers.Managed,
+	})
+	if err != nil {
+		return DefaultConfig(), err
+	}
+	return ConfigFromLayers(trusted.Effective, merged.Effective)
+}
+
+// ConfigFromLayers decodes the sandbox of the trusted (user, CLI and
+// managed) settings and of all merged settings. When the trusted settings
+// leave the sandbox disabled, the merged configuration applies as is: any
+// sandbox is narrower than none. When they enable it, the merged settings
+// may only narrow it: the sandbox stays enabled, writableDirs and allowed
+// domains come from the trusted settings, and network and limits take the
+// stricter of the two.
+func ConfigFromLayers(trusted map[string]any, effective map[string]any) (Config, error) {
+	base, err := ConfigFromSettings(trusted)
+	if err != nil {
+		return DefaultConfig(), err
+	}
+	config, err := ConfigFromSettings(effective)
+	if err != nil || !base.Enabled {
+		return config, err
+	}
+	return base.narrowedBy(config), nil
+}
+
+// narrowedBy applies the restrictions of other to c without letting other
+// widen it.
+func (c Config) narrowedBy(other Config) Config {
+	out := c
+	out.FailIfUnavailable = c.FailIfUnavailable || other.FailIfUnavailable
+	out.PrivateTmp = c.PrivateTmp || other.PrivateTmp
+	if networkOpenness(other.Network) < networkOpenness(c.Network) {
+		out.Network = other.Network
+		out.AllowedDomains = other.AllowedDomains
+		if other.Network != NetworkAllowlist {
+			out.AllowedDomains = nil
+		}
+	}
+	out.Limits = Limits{
+		CPUSeconds:   stricterLimit(c.Limits.CPUSeconds, other.Limits.CPUSeconds),
+		MemoryMB:     stricterLimit(c.Limits.MemoryMB, other.Limits.MemoryMB),
+		MaxProcesses: stricterLimit(c.Limits.MaxProcesses, other.Limits.MaxProcesses),
+	}
+	return out
+}
+
+func networkOpenness(mode NetworkMode) int {
+	switch mode {
+	case NetworkOn:
+		return 2
+	case NetworkAllowlist:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// stricterLimit returns the lower of two rlimits, where zero means none.
+func stricterLimit(a int, b int) int {
+	switch {
+	case a <= 0:
+		return b
+	case b <= 0:
+		return a
+	default:
+		return min(a, b)
+	}
+}
+
+// ConfigFromSettings decodes the "sandbox" key from an effective settings map.
+func ConfigFromSettings(effective map[string]any) (Config, error) {
+	config := DefaultConfig()
+	raw, ok := effective["sandbox"]
+	if !ok || raw == nil {
+		return config, nil
+	}
+	section, ok := raw.(map[string]any)
+	if !ok {
+		return config, fmt.Errorf("sandbox settings must be an object")
+	}
+
+	config.Enabled = asBool(section["enabled"], config.Enabled)
+	config.FailIfUnavailable = asBool(section["failIfUnavailable"], config.FailIfUnavailable)
+	config.PrivateTmp = asBool(section["privateTmp"], config.PrivateTmp)
+	config.WritableDirs = asStringSlice(section["writableDirs"])
+
+	switch network := section["network"].(type) {
+	case nil:
+	case string:
+		mode, err := parseNetworkMode(network)
+		if err != nil {
+			return config, err
+		}
+		config.Network = mode
+	case map[string]any:
+		if rawMode, exists := network["mode"]; exists {
+			mode, err := parseNetworkMode(fmt.Sprint(rawMode))
+			if err != nil {
+				return config, err
+			}
+			config.Network = mode
+		}
+		config.AllowedDomains = asStringSlice(network["allowedDomains"])
+	default:
+		return config, fmt.Errorf("sandbox.network must be a string or object")
+	}
+	if config.Network == NetworkAllowlist && len(config.AllowedDomains) == 0 {
+		return config, fmt.Errorf("sandbox.network.allowedDomains is required for allowlist mode")
+	}
+
+	if limits, ok := section["limits"].(map[string]any); ok {
+		config.Limits = Limits{
+			CPUSeconds:   asInt(limits["cpuSeconds"]),
+			MemoryMB:     asInt(limits["memoryMB"]),
+			MaxProcesses: asInt(limits["maxProcesses"]),
+		}
+	}
+	return config, nil
+}
+
+// ResolveWritableDirs returns the absolute, de-duplicated writable directory
+// set: the workspace, additional session directories and configured
+// writableDirs (relative entries resolve against the workspace).
+func (c Config) ResolveWritableDirs(workingDir string, additional []string) []string {
+	root := strings.TrimSpace(workingDir)
+	candidates := make([]string, 0, 1+len(additional)+len(c.WritableDirs))
+	if root != "" {
+		candidates = append(candidates, root)
+	}
+	candidates = append(candidates, additional...)
+	candidates = append(candidates, c.WritableDirs...)
+
+	seen := map[string]struct{}{}
+	out := make([]string, 0, len(candidates))
+	for _, item := range candidates {
+		trimmed := strings.TrimSpace(item)
+		if trimmed == "" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				trimmed = filepath.Join(home, trimmed[2:])
+			}
+		}
+		if !filepath.IsAbs(trimmed) && root != "" {
+			trimmed = filepath.Join(root, trimmed)
+		}
+		absPath, err := filepath.Abs(filepath.Clean(trimmed))
+		if err != nil {
+			continue
+		}
+		if _, exists := seen[absPath]; exists {
+			continue
+		}
+		seen[absPath] = struct{}{}
+		out = append(out, absPath)
+	}
+	return out
+}
+
+func parseNetworkMode(raw string) (NetworkMode, error) {
+	switch NetworkMode(strings.ToLower(strings.TrimSpace(raw))) {
+	case "", NetworkOff:
+		return NetworkOff, nil
+	case NetworkAllowlist:
+		return NetworkAllowlist, nil
+	case NetworkOn:
+		return NetworkOn, nil
+	default:
+		return NetworkOff, fmt.Errorf("unsupported sandbox network mode %q", raw)
+	}
+}
+
+func asBool(value any, fallback bool) bool {
+	switch typed := value.(type) {
+	case bool:
+		return typed
+	case string:
+		parsed, err := strconv.ParseBool(strings.TrimSpace(typed))
+		if err != nil {
+			return fallback
+		}
+		return parsed
+	default:
+		return fallback
+	}
+}
+
+func asInt(value any) int {
+	switch typed := value.(type) {
+	case int:
+		return typed
+	case int64:
+		return int(typed)
+	case float64:
+		return int(typed)
+	case string:
+		parsed, err := strconv.Atoi(strings.TrimSpace(typed))
+		if err != nil {
+			return 0
+		}
+		return parsed
+	default:
+		return 0
+	}
+}
+
+func asStringSlice(value any) []string {
+	items, ok := value.([]any)
+	if !ok {
+		if typed, ok := value.([]string); ok {
+			items = make([]any, 0, len(typed))
+			for _, item := range typed {
+				items = append(items, item)
+			}
+		}
+	}
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		text := strings.TrimSpace(fmt.Sprint(item))
+		if text != "" {
+			out = append(out, text)
+		}
+	}
+	return out
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package ossandbox
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestConfigFromSettingsDefaultsToDisabled(t *testing.T) {
+	config, err := ConfigFromSettings(map[string]any{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.Enabled || config.Network != NetworkOff || !config.PrivateTmp {
+		t.Fatalf("unexpected default config %#v", config)
+	}
+}
+
+func TestConfigFromSettingsParsesNetworkAndLimits(t *testing.T) {
+	config, err := ConfigFromSettings(map[string]any{
+		"sandbox": map[string]any{
+			"enabled":           true,
+			"failIfUnavailable": true,
+			"privateTmp":        false,
+			"writableDirs":      []any{"../shared", "~/cache"},
+			"network": map[string]any{
+				"mode":           "allowlist",
+				"allowedDomains": []any{"*.github.com", "proxy.golang.org"},
+			},
+			"limits": map[string]any{"cpuSeconds": float64(60), "memoryMB": float64(1024), "maxProcesses": "64"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !config.Enabled || !config.FailIfUnavailable || config.PrivateTmp {
+		t.Fatalf("unexpected flags %#v", config)
+	}
+	if config.Network != NetworkAllowlist || !reflect.DeepEqual(config.AllowedDomains, []string{"*.github.com", "proxy.golang.org"}) {
+		t.Fatalf("unexpected network settings %#v", config)
+	}
+	if config.Limits != (Limits{CPUSeconds: 60, MemoryMB: 1024, MaxProcesses: 64}) {
+		t.Fatalf("unexpected limits %#v", config.Limits)
+	}
+}
+
+func TestConfigFromSettingsRejectsInvalidNetwork(t *testing.T) {
+	if _, err := ConfigFromSettings(map[string]any{"sandbox": map[string]any{"network": "lan"}}); err == nil {
+		t.Fatal("expected unsupported network mode error")
+	}
+	if _, err := ConfigFromSettings(map[string]any{"sandbox": map[string]any{"network": "allowlist"}}); err == nil {
+		t.Fatal("expected missing allowedDomains error")
+	}
+}
+
+func TestResolveWritableDirsDeduplicatesRelativeEntries(t *testing.T) {
+	root := t.TempDir()
+	config := Config{WritableDirs: []string{"build", filepath.Join(root, "build"), "  "}}
+	got := config.ResolveWritableDirs(root, []string{root, "/opt/extra"})
+	want := []string{root, "/opt/extra", filepath.Join(root, "build")}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected writable dirs %#v", got)
+	}
+}
+
+func TestLoadProjectConfigReadsProjectSettings(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".goyais"), 0o755); err != nil {
+		t.Fatalf("mkdir settings dir: %v", err)
+	}
+	raw := []byte(`{"sandbox":{"enabled":true,"network":"on"}}`)
+	if err := os.WriteFile(filepath.Join(root, ".goyais", "settings.json"), raw, 0o644); err != nil {
+		t.Fatalf("write settings: %v", err)
+	}
+	config, err := LoadProjectConfig(root, t.TempDir())
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if !config.Enabled || config.Network != NetworkOn {
+		t.Fatalf("unexpected project config %#v", config)
+	}
+}
+
+func TestConfigFromLayersLetsProjectOnlyNarrowTrustedSandbox(t *testing.T) {
+	trusted := map[string]any{"sandbox": map[string]any{
+		"enabled":      true,
+		"writableDirs": []any{"/opt/cache"},
+		"network":      "on",
+		"limits":       map[string]any{"cpuSeconds": 100},
+	}}
+	effective := map[string]any{"sandbox": map[string]any{
+		"enabled":      false,
+		"writableDirs": []any{"/etc"},
+		"privateTmp":   false,
+		"network":      map[string]any{"mode": "allowlist", "allowedDomains": []any{"*.github.com"}},
+		"limits":       map[string]any{"cpuSeconds": 600, "memoryMB": 512},
+	}}
+	config, err := ConfigFromLayers(trusted, effective)
+	if err != nil {
+		t.Fatalf("config from layers: %v", err)
+	}
+	if !config.Enabled || !config.PrivateTmp {
+		t.Fatalf("expected the project to be unable to disable the sandbox, got %#v", config)
+	}
+	if !reflect.DeepEqual(config.WritableDirs, []string{"/opt/cache"}) {
+		t.Fatalf("expected only trusted writable dirs, got %#v", config.WritableDirs)
+	}
+	if config.Network != NetworkAllowlist || !reflect.DeepEqual(config.AllowedDomains, []string{"*.github.com"}) {
+		t.Fatalf("expected the project to narrow the network, got %s %#v", config.Network, config.AllowedDomains)
+	}
+	if config.Limits.CPUSeconds != 100 || config.Limits.MemoryMB != 512 {
+		t.Fatalf("expected the stricter limits, got %#v", config.Limits)
+	}
+
+	widened, err := ConfigFromLayers(
+		map[string]any{"sandbox": map[string]any{"enabled": true}},
+		map[string]any{"sandbox": map[string]any{"enabled": true, "network": "on"}},
+	)
+	if err != nil {
+		t.Fatalf("config from layers: %v", err)
+	}
+	if widened.Network != NetworkOff {
+		t.Fatalf("expected the project to be unable to open the network, got %s", widened.Network)
+	}
+}
+
+func TestLoadProjectConfigKeepsUserEnabledSandbox(t *testing.T) {
+	root := t.TempDir()
+	home := t.TempDir()
+	for path, raw := range map[string]string{
+		filepath.Join(home, ".goyais", "config.json"):   `{"sandbox":{"enabled":true}}`,
+		filepath.Join(root, ".goyais", "settings.json"): `{"sandbox":{"enabled":false,"writableDirs":["/"]}}`,
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir settings dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(raw), 0o644); err != nil {
+			t.Fatalf("write settings: %v", err)
+		}
+	}
+	config, err := LoadProjectConfig(root, home)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if !config.Enabled || len(config.WritableDirs) != 0 {
+		t.Fatalf("expected the user-enabled sandbox to hold, got %#v", config)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package ossandbox
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const proxySocketName = "proxy.sock"
+
+// allowlistProxy is an HTTP forward proxy listening on a unix socket outside
+// the sandbox. It admits CONNECT tunnels and absolute-form HTTP requests only
+// for allowlisted domains.
+type allowlistProxy struct {
+	dir      string
+	listener net.Listener
+	server   *http.Server
+	domains  []string
+	once     sync.Once
+}
+
+func startAllowlistProxy(domains []string) (*allowlistProxy, error) {
+	dir, err := os.MkdirTemp("", "goyais-sandbox-")
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", filepath.Join(dir, proxySocketName))
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	proxy := &allowlistProxy{
+		dir:      dir,
+		listener: listener,
+		domains:  append([]string{}, domains...),
+	}
+	proxy.server = &http.Server{
+		Handler:           proxy,
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	go func() {
+		_ = proxy.server.Serve(listener)
+	}()
+	return proxy, nil
+}
+
+func (p *allowlistProxy) Dir() string {
+	return p.dir
+}
+
+func (p *allowlistProxy) Close() {
+	p.once.Do(func() {
+		_ = p.server.Close()
+		_ = os.RemoveAll(p.dir)
+	})
+}
+
+func (p *allowlistProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.serveConnect(w, r)
+		return
+	}
+	if r.URL == nil || !r.URL.IsAbs() {
+		http.Error(w, "sandbox proxy requires absolute-form requests", http.StatusBadRequest)
+		return
+	}
+	if !MatchDomain(p.domains, r.URL.Hostname()) {
+		http.Error(w, "sandbox network policy denies "+r.URL.Hostname(), http.StatusForbidden)
+		return
+	}
+	outbound := r.Clone(r.Context())
+	outbound.RequestURI = ""
+	for _, header := range hopByHopHeaders {
+		outbound.Header.Del(header)
+	}
+	res, err := http.DefaultTransport.RoundTrip(outbound)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer res.Body.Close()
+	for key, values := range res.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.WriteHeader(res.StatusCode)
+	_, _ = io.Copy(w, res.Body)
+}
+
+func (p *allowlistProxy) serveConnect(w http.ResponseWriter, r *http.Request) {
+	host := r.URL.Hostname()
+	if host == "" {
+		host, _, _ = net.SplitHostPort(r.Host)
+	}
+	if !MatchDomain(p.domains, host) {
+		http.Error(w, "sandbox network policy denies "+host, http.StatusForbidden)
+		return
+	}
+	upstream, err := net.DialTimeout("tcp", r.Host, 30*time.Second)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		_ = upstream.Close()
+		http.Error(w, "sandbox proxy cannot hijack connection", http.StatusInternalServerError)
+		return
+	}
+	client, buffered, err := hijacker.Hijack()
+	if err != nil {
+		_ = upstream.Close()
+		return
+	}
+	_, _ = client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"))
+	if buffered != nil && buffered.Reader.Buffered() > 0 {
+		pending := make([]byte, buffered.Reader.Buffered())
+		_, _ = buffered.Read(pending)
+		_, _ = upstream.Write(pending)
+	}
+	pipeConnections(client, upstream)
+}
+
+// MatchDomain reports whether host matches one allowlist pattern. Patterns
+// are exact hosts or "*.example.com", which matches example.com and every
+// subdomain.
+func MatchDomain(patterns []string, host string) bool {
+	normalized := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(host), "."))
+	if normalized == "" {
+		return false
+	}
+	for _, raw := range patterns {
+		pattern := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(raw), "."))
+		switch {
+		case pattern == "":
+			continue
+		case pattern == "*":
+			return true
+		case strings.HasPrefix(pattern, "*."):
+			base := strings.TrimPrefix(pattern, "*.")
+			if normalized == base || strings.HasSuffix(normalized, "."+base) {
+				return true
+			}
+		case normalized == pattern:
+			return true
+		}
+	}
+	return false
+}
+
+func pipeConnections(left net.Conn, right net.Conn) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	copyHalf := func(dst net.Conn, src net.Conn) {
+		defer wg.Done()
+		_, _ = io.Copy(dst, src)
+		if closer, ok := dst.(interface{ CloseWrite() error }); ok {
+			_ = closer.CloseWrite()
+		} else {
+			_ = dst.Close()
+		}
+	}
+	go copyHalf(left, right)
+	go copyHalf(right, left)
+	wg.Wait()
+	_ = left.Close()
+	_ = right.Close()
+}
+
+var hopByHopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
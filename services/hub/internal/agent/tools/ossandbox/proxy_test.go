@@ -0,0 +1,60 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package ossandbox
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchDomain(t *testing.T) {
+	patterns := []string{"*.github.com", "proxy.golang.org."}
+	cases := map[string]bool{
+		"github.com":          true,
+		"api.github.com":      true,
+		"GITHUB.com.":         true,
+		"notgithub.com":       false,
+		"proxy.golang.org":    true,
+		"sum.golang.org":      false,
+		"":                    false,
+		"evil.com/github.com": false,
+	}
+	for host, want := range cases {
+		if got := MatchDomain(patterns, host); got != want {
+			t.Fatalf("MatchDomain(%q) = %t, want %t", host, got, want)
+		}
+	}
+	if !MatchDomain([]string{"*"}, "anything.example") {
+		t.Fatal("expected wildcard pattern to match every host")
+	}
+}
+
+func TestAllowlistProxyDeniesUnlistedConnect(t *testing.T) {
+	proxy, err := startAllowlistProxy([]string{"allowed.example"})
+	if err != nil {
+		t.Fatalf("start proxy: %v", err)
+	}
+	defer proxy.Close()
+
+	conn, err := net.Dial("unix", filepath.Join(proxy.Dir(), proxySocketName))
+	if err != nil {
+		t.Fatalf("dial proxy: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("CONNECT denied.example:443 HTTP/1.1\r\nHost: denied.example:443\r\n\r\n")); err != nil {
+		t.Fatalf("write connect: %v", err)
+	}
+	res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for denied domain, got %d", res.StatusCode)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package ossandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// ErrUnavailable reports that the sandbox was required but the platform or
+// kernel cannot provide it.
+var ErrUnavailable = errors.New("os sandbox is unavailable")
+
+// Support describes the sandbox features provided by the running kernel.
+type Support struct {
+	Platform       string
+	Available      bool
+	UserNamespaces bool
+	LandlockABI    int
+	MountSetattr   bool
+	Reason         string
+}
+
+// Summary renders a one-line human readable support description.
+func (s Support) Summary() string {
+	if !s.Available {
+		return "unavailable (" + strings.TrimSpace(s.Reason) + ")"
+	}
+	parts := []string{s.Platform + " namespaces"}
+	if s.LandlockABI > 0 {
+		parts = append(parts, fmt.Sprintf("landlock abi %d", s.LandlockABI))
+	} else {
+		parts = append(parts, "landlock unavailable")
+	}
+	if s.MountSetattr {
+		parts = append(parts, "recursive read-only root")
+	} else {
+		parts = append(parts, "top-level read-only root")
+	}
+	return "available (" + strings.Join(parts, ", ") + ")"
+}
+
+// Status records how one command was, or was not, sandboxed.
+type Status struct {
+	Requested bool
+	Active    bool
+	Backend   string
+	Network   NetworkMode
+	Landlock  bool
+	Reason    string
+}
+
+// AuditMap renders the status for tool output and audit metadata.
+func (s Status) AuditMap() map[string]any {
+	return map[string]any{
+		"requested": s.Requested,
+		"active":    s.Active,
+		"backend":   strings.TrimSpace(s.Backend),
+		"network":   string(s.Network),
+		"landlock":  s.Landlock,
+		"reason":    strings.TrimSpace(s.Reason),
+	}
+}
+
+// CommandRequest describes one executable launch.
+type CommandRequest struct {
+	Argv           []string
+	Dir            string
+	Env            []string
+	AdditionalDirs []string
+}
+
+var (
+	probeOnce   sync.Once
+	probeResult Support
+)
+
+// Probe reports kernel sandbox support. The result is computed once per
+// process.
+func Probe() Support {
+	probeOnce.Do(func() {
+		probeResult = probePlatform()
+	})
+	return probeResult
+}
+
+// Command prepares argv for execution under config. When the sandbox is
+// disabled, or unavailable and not required, the returned command runs
+// unsandboxed and Status explains why. The cleanup function must be called
+// once the command has exited.
+func Command(ctx context.Context, config Config, req CommandRequest) (*exec.Cmd, Status, func(), error) {
+	if len(req.Argv) == 0 {
+		return nil, Status{}, noopCleanup, fmt.Errorf("sandbox command argv is empty")
+	}
+	if !config.Enabled {
+		return plainCommand(ctx, req), Status{Reason: "sandbox disabled"}, noopCleanup, nil
+	}
+	support := Probe()
+	if !support.Available {
+		status := Status{Requested: true, Network: config.Network, Reason: "sandbox unavailable: " + strings.TrimSpace(support.Reason)}
+		if config.FailIfUnavailable {
+			return nil, status, noopCleanup, fmt.Errorf("%w: %s", ErrUnavailable, strings.TrimSpace(support.Reason))
+		}
+		status.Reason += "; running unsandboxed"
+		return plainCommand(ctx, req), status, noopCleanup, nil
+	}
+	return platformCommand(ctx, config, support, req)
+}
+
+func plainCommand(ctx context.Context, req CommandRequest) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, req.Argv[0], req.Argv[1:]...)
+	cmd.Dir = strings.TrimSpace(req.Dir)
+	if len(req.Env) > 0 {
+		cmd.Env = append([]string{}, req.Env...)
+	}
+	return cmd
+}
+
+func noopCleanup() {}
+
+func environOrDefault(env []string) []string {
+	if len(env) > 0 {
+		return append([]string{}, env...)
+	}
+	return os.Environ()
+}
@@ -0,0 +1,544 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+//go:build linux
+
+package ossandbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	stage1Arg  = "__goyais_sandbox_init"
+	stage2Arg  = "__goyais_sandbox_exec"
+	specEnvKey = "GOYAIS_SANDBOX_SPEC"
+
+	backendName    = "linux-namespaces"
+	helperExitCode = 126
+)
+
+// initSpec is handed from the host process to the in-namespace helper stages
+// through the environment.
+type initSpec struct {
+	Argv         []string    `json:"argv"`
+	Dir          string      `json:"dir"`
+	WritableDirs []string    `json:"writable_dirs"`
+	PrivateTmp   bool        `json:"private_tmp"`
+	Network      NetworkMode `json:"network"`
+	ProxyDir     string      `json:"proxy_dir,omitempty"`
+	Limits       Limits      `json:"limits"`
+	LandlockABI  int         `json:"landlock_abi"`
+	MountSetattr bool        `json:"mount_setattr"`
+	Executable   string      `json:"executable"`
+}
+
+// RunInitIfRequested runs the sandbox helper stages when the current process
+// was re-executed as one. Binaries that execute sandboxed commands must call
+// it first thing in main (and in TestMain for tests); it returns immediately
+// for normal invocations.
+//
+// Stage one runs as pid 1 inside the new user, mount, pid and network
+// namespaces: it makes the root read-only, binds writable directories, brings
+// up loopback and starts the proxy forwarder. Stage two applies rlimits,
+// no_new_privs, an empty capability bounding set and Landlock before it execs
+// the target command.
+func RunInitIfRequested() {
+	if len(os.Args) < 2 {
+		return
+	}
+	switch os.Args[1] {
+	case stage1Arg:
+		os.Exit(runStage1())
+	case stage2Arg:
+		runtime.LockOSThread()
+		os.Exit(runStage2())
+	}
+}
+
+func platformCommand(ctx context.Context, config Config, support Support, req CommandRequest) (*exec.Cmd, Status, func(), error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, Status{}, noopCleanup, fmt.Errorf("resolve sandbox helper executable: %w", err)
+	}
+	spec := initSpec{
+		Argv:         append([]string{}, req.Argv...),
+		Dir:          strings.TrimSpace(req.Dir),
+		WritableDirs: config.ResolveWritableDirs(req.Dir, req.AdditionalDirs),
+		PrivateTmp:   config.PrivateTmp,
+		Network:      config.Network,
+		Limits:       config.Limits,
+		LandlockABI:  support.LandlockABI,
+		MountSetattr: support.MountSetattr,
+		Executable:   executable,
+	}
+	cleanup := noopCleanup
+	if config.Network == NetworkAllowlist {
+		proxy, proxyErr := startAllowlistProxy(config.AllowedDomains)
+		if proxyErr != nil {
+			return nil, Status{}, noopCleanup, fmt.Errorf("start sandbox network proxy: %w", proxyErr)
+		}
+		spec.ProxyDir = proxy.Dir()
+		cleanup = proxy.Close
+	}
+	encoded, err := json.Marshal(spec)
+	if err != nil {
+		cleanup()
+		return nil, Status{}, noopCleanup, fmt.Errorf("encode sandbox spec: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, executable, stage1Arg)
+	cmd.Dir = spec.Dir
+	cmd.Env = append(environOrDefault(req.Env), specEnvKey+"="+string(encoded))
+	cmd.SysProcAttr = namespaceAttr(config.Network != NetworkOn)
+	return cmd, Status{
+		Requested: true,
+		Active:    true,
+		Backend:   backendName,
+		Network:   config.Network,
+		Landlock:  support.LandlockABI > 0,
+		Reason:    "sandboxed",
+	}, cleanup, nil
+}
+
+func namespaceAttr(isolateNetwork bool) *syscall.SysProcAttr {
+	flags := syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID
+	if isolateNetwork {
+		flags |= syscall.CLONE_NEWNET
+	}
+	return &syscall.SysProcAttr{
+		Cloneflags:                 uintptr(flags),
+		UidMappings:                []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}},
+		GidMappings:                []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}},
+		GidMappingsEnableSetgroups: false,
+		Pdeathsig:                  syscall.SIGKILL,
+	}
+}
+
+func probePlatform() Support {
+	support := Support{
+		Platform:     "linux",
+		LandlockABI:  landlockABI(),
+		MountSetattr: mountSetattrSupported(),
+	}
+	if err := probeUserNamespaces(); err != nil {
+		support.Reason = err.Error()
+		return support
+	}
+	support.UserNamespaces = true
+	support.Available = true
+	return support
+}
+
+func probeUserNamespaces() error {
+	if raw, err := os.ReadFile("/proc/sys/user/max_user_namespaces"); err == nil && strings.TrimSpace(string(raw)) == "0" {
+		return errors.New("user namespaces are disabled (user.max_user_namespaces=0)")
+	}
+	if raw, err := os.ReadFile("/proc/sys/kernel/unprivileged_userns_clone"); err == nil && strings.TrimSpace(string(raw)) == "0" {
+		return errors.New("unprivileged user namespaces are disabled (kernel.unprivileged_userns_clone=0)")
+	}
+	for _, candidate := range []string{"/bin/true", "/usr/bin/true"} {
+		if _, err := os.Stat(candidate); err != nil {
+			continue
+		}
+		cmd := exec.Command(candidate)
+		cmd.SysProcAttr = namespaceAttr(true)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("cannot create user namespaces: %v", err)
+		}
+		return nil
+	}
+	return nil
+}
+
+func landlockABI() int {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return 0
+	}
+	return int(abi)
+}
+
+func mountSetattrSupported() bool {
+	err := unix.MountSetattr(-1, "", 0, &unix.MountAttr{})
+	return !errors.Is(err, unix.ENOSYS)
+}
+
+func decodeSpec() (initSpec, error) {
+	var spec initSpec
+	raw := os.Getenv(specEnvKey)
+	if strings.TrimSpace(raw) == "" {
+		return spec, errors.New("sandbox spec is missing")
+	}
+	if err := json.Unmarshal([]byte(raw), &spec); err != nil {
+		return spec, fmt.Errorf("decode sandbox spec: %w", err)
+	}
+	if len(spec.Argv) == 0 {
+		return spec, errors.New("sandbox spec argv is empty")
+	}
+	return spec, nil
+}
+
+func helperFailure(err error) int {
+	_, _ = fmt.Fprintf(os.Stderr, "goyais sandbox: %v\n", err)
+	return helperExitCode
+}
+
+type pathFD struct {
+	path string
+	fd   int
+}
+
+func runStage1() int {
+	spec, err := decodeSpec()
+	if err != nil {
+		return helperFailure(err)
+	}
+
+	// Hold O_PATH descriptors so binds, the helper binary and the proxy socket
+	// stay reachable after a private /tmp hides their original paths.
+	exeFD, err := unix.Open(spec.Executable, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return helperFailure(fmt.Errorf("open helper executable: %w", err))
+	}
+	writable := make([]pathFD, 0, len(spec.WritableDirs))
+	for _, dir := range spec.WritableDirs {
+		fd, openErr := unix.Open(dir, unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+		if openErr != nil {
+			continue
+		}
+		writable = append(writable, pathFD{path: dir, fd: fd})
+	}
+	proxyFD := -1
+	if spec.ProxyDir != "" {
+		proxyFD, err = unix.Open(spec.ProxyDir, unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+		if err != nil {
+			return helperFailure(fmt.Errorf("open proxy directory: %w", err))
+		}
+	}
+
+	if err := setupMounts(spec, writable); err != nil {
+		return helperFailure(err)
+	}
+
+	env := os.Environ()
+	if spec.Network != NetworkOn {
+		if err := bringLoopbackUp(); err != nil && spec.Network == NetworkAllowlist {
+			return helperFailure(fmt.Errorf("bring up loopback: %w", err))
+		}
+	}
+	if spec.Network == NetworkAllowlist {
+		address, forwardErr := startProxyForwarder(fmt.Sprintf("/proc/self/fd/%d/%s", proxyFD, proxySocketName))
+		if forwardErr != nil {
+			return helperFailure(fmt.Errorf("start proxy forwarder: %w", forwardErr))
+		}
+		proxyURL := "http://" + address
+		env = append(env,
+			"HTTP_PROXY="+proxyURL, "HTTPS_PROXY="+proxyURL, "ALL_PROXY="+proxyURL,
+			"http_proxy="+proxyURL, "https_proxy="+proxyURL, "all_proxy="+proxyURL,
+			"NO_PROXY=localhost,127.0.0.1", "no_proxy=localhost,127.0.0.1",
+		)
+	}
+
+	child := exec.Command(fmt.Sprintf("/proc/self/fd/%d", exeFD), stage2Arg)
+	child.Dir = spec.Dir
+	child.Env = env
+	child.Stdin = os.Stdin
+	child.Stdout = os.NewFile(uintptr(syscall.Stdout), "/dev/stdout")
+	child.Stderr = os.Stderr
+	if err := child.Start(); err != nil {
+		return helperFailure(fmt.Errorf("start sandboxed command: %w", err))
+	}
+
+	signals := make(chan os.Signal, 4)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+	go func() {
+		for sig := range signals {
+			_ = child.Process.Signal(sig)
+		}
+	}()
+
+	waitErr := child.Wait()
+	signal.Stop(signals)
+	return exitCodeOf(child.ProcessState, waitErr)
+}
+
+func runStage2() int {
+	spec, err := decodeSpec()
+	if err != nil {
+		return helperFailure(err)
+	}
+	if err := applyRlimits(spec.Limits); err != nil {
+		return helperFailure(err)
+	}
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return helperFailure(fmt.Errorf("set no_new_privs: %w", err))
+	}
+	for capability := 0; capability <= 63; capability++ {
+		if err := unix.Prctl(unix.PR_CAPBSET_DROP, uintptr(capability), 0, 0, 0); err != nil && !errors.Is(err, unix.EINVAL) {
+			return helperFailure(fmt.Errorf("drop capability %d: %w", capability, err))
+		}
+	}
+	if err := applyLandlock(spec); err != nil {
+		return helperFailure(err)
+	}
+
+	env := make([]string, 0, len(os.Environ()))
+	for _, entry := range os.Environ() {
+		if strings.HasPrefix(entry, specEnvKey+"=") {
+			continue
+		}
+		env = append(env, entry)
+	}
+	path, err := exec.LookPath(spec.Argv[0])
+	if err != nil {
+		return helperFailure(err)
+	}
+	err = syscall.Exec(path, spec.Argv, env)
+	return helperFailure(fmt.Errorf("exec %s: %w", spec.Argv[0], err))
+}
+
+func setupMounts(spec initSpec, writable []pathFD) error {
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+	if err := setReadOnly("/", true, spec.MountSetattr); err != nil {
+		return fmt.Errorf("remount root read-only: %w", err)
+	}
+	// A fresh procfs reflects the new pid namespace; it is best-effort because
+	// container runtimes may mask /proc.
+	_ = unix.Mount("proc", "/proc", "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, "")
+	if spec.PrivateTmp {
+		if err := unix.Mount("tmpfs", "/tmp", "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=1777"); err != nil {
+			return fmt.Errorf("mount private /tmp: %w", err)
+		}
+	}
+	for _, item := range writable {
+		_ = os.MkdirAll(item.path, 0o755)
+		source := fmt.Sprintf("/proc/self/fd/%d", item.fd)
+		if err := unix.Mount(source, item.path, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+			return fmt.Errorf("bind writable directory %s: %w", item.path, err)
+		}
+		if err := setReadOnly(item.path, false, spec.MountSetattr); err != nil {
+			return fmt.Errorf("remount %s writable: %w", item.path, err)
+		}
+	}
+	return nil
+}
+
+// setReadOnly toggles the read-only attribute recursively when mount_setattr
+// is available, otherwise on the top mount only while preserving the locked
+// nosuid/nodev/noexec/atime flags.
+func setReadOnly(path string, readOnly bool, recursive bool) error {
+	if recursive {
+		attr := &unix.MountAttr{}
+		if readOnly {
+			attr.Attr_set = unix.MOUNT_ATTR_RDONLY
+		} else {
+			attr.Attr_clr = unix.MOUNT_ATTR_RDONLY
+		}
+		if err := unix.MountSetattr(-1, path, unix.AT_RECURSIVE, attr); err == nil {
+			return nil
+		}
+	}
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return err
+	}
+	preserved := uintptr(stat.Flags) & (unix.MS_NOSUID | unix.MS_NODEV | unix.MS_NOEXEC | unix.MS_NOATIME | unix.MS_NODIRATIME | unix.MS_RELATIME)
+	flags := preserved | unix.MS_BIND | unix.MS_REMOUNT
+	if readOnly {
+		flags |= unix.MS_RDONLY
+	}
+	return unix.Mount("", path, "", flags, "")
+}
+
+func bringLoopbackUp() error {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+	ifr, err := unix.NewIfreq("lo")
+	if err != nil {
+		return err
+	}
+	if err := unix.IoctlIfreq(fd, unix.SIOCGIFFLAGS, ifr); err != nil {
+		return err
+	}
+	ifr.SetUint16(ifr.Uint16() | unix.IFF_UP)
+	return unix.IoctlIfreq(fd, unix.SIOCSIFFLAGS, ifr)
+}
+
+func startProxyForwarder(socketPath string) (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	go func() {
+		for {
+			conn, acceptErr := listener.Accept()
+			if acceptErr != nil {
+				return
+			}
+			go func() {
+				upstream, dialErr := net.Dial("unix", socketPath)
+				if dialErr != nil {
+					_ = conn.Close()
+					return
+				}
+				pipeConnections(conn, upstream)
+			}()
+		}
+	}()
+	return listener.Addr().String(), nil
+}
+
+func applyRlimits(limits Limits) error {
+	set := func(resource int, value uint64, name string) error {
+		if value == 0 {
+			return nil
+		}
+		if err := unix.Setrlimit(resource, &unix.Rlimit{Cur: value, Max: value}); err != nil {
+			return fmt.Errorf("set %s limit: %w", name, err)
+		}
+		return nil
+	}
+	if limits.CPUSeconds > 0 {
+		if err := set(unix.RLIMIT_CPU, uint64(limits.CPUSeconds), "cpu"); err != nil {
+			return err
+		}
+	}
+	if limits.MemoryMB > 0 {
+		if err := set(unix.RLIMIT_AS, uint64(limits.MemoryMB)<<20, "memory"); err != nil {
+			return err
+		}
+	}
+	if limits.MaxProcesses > 0 {
+		if err := set(unix.RLIMIT_NPROC, uint64(limits.MaxProcesses), "process"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const landlockReadAccess = unix.LANDLOCK_ACCESS_FS_EXECUTE | unix.LANDLOCK_ACCESS_FS_READ_FILE | unix.LANDLOCK_ACCESS_FS_READ_DIR
+
+func landlockHandledAccess(abi int) uint64 {
+	handled := uint64(unix.LANDLOCK_ACCESS_FS_EXECUTE | unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_FILE | unix.LANDLOCK_ACCESS_FS_READ_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_DIR | unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+		unix.LANDLOCK_ACCESS_FS_MAKE_CHAR | unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_REG | unix.LANDLOCK_ACCESS_FS_MAKE_SOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_FIFO | unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SYM)
+	if abi >= 2 {
+		handled |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+	if abi >= 3 {
+		handled |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+	if abi >= 5 {
+		handled |= unix.LANDLOCK_ACCESS_FS_IOCTL_DEV
+	}
+	return handled
+}
+
+// applyLandlock restricts the current thread to read-only access everywhere
+// plus full access beneath the writable directories. The domain is inherited
+// by the exec'd command.
+func applyLandlock(spec initSpec) error {
+	if spec.LandlockABI <= 0 {
+		return nil
+	}
+	handled := landlockHandledAccess(spec.LandlockABI)
+	attr := unix.LandlockRulesetAttr{Access_fs: handled}
+	size := unsafe.Sizeof(attr.Access_fs)
+	if spec.LandlockABI >= 4 {
+		size += unsafe.Sizeof(attr.Access_net)
+	}
+	if spec.LandlockABI >= 6 {
+		size += unsafe.Sizeof(attr.Scoped)
+	}
+	rulesetFD, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), size, 0)
+	if errno != 0 {
+		return fmt.Errorf("create landlock ruleset: %w", errno)
+	}
+	defer unix.Close(int(rulesetFD))
+
+	devAccess := uint64(landlockReadAccess | unix.LANDLOCK_ACCESS_FS_WRITE_FILE)
+	if spec.LandlockABI >= 5 {
+		devAccess |= unix.LANDLOCK_ACCESS_FS_IOCTL_DEV
+	}
+	rules := []struct {
+		path   string
+		access uint64
+	}{
+		{path: "/", access: landlockReadAccess},
+		{path: "/dev", access: devAccess},
+	}
+	if spec.PrivateTmp {
+		rules = append(rules, struct {
+			path   string
+			access uint64
+		}{path: "/tmp", access: handled})
+	}
+	for _, dir := range spec.WritableDirs {
+		rules = append(rules, struct {
+			path   string
+			access uint64
+		}{path: dir, access: handled})
+	}
+	for _, rule := range rules {
+		if err := addLandlockPathRule(int(rulesetFD), rule.path, rule.access); err != nil {
+			return err
+		}
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, rulesetFD, 0, 0); errno != 0 {
+		return fmt.Errorf("restrict landlock domain: %w", errno)
+	}
+	return nil
+}
+
+func addLandlockPathRule(rulesetFD int, path string, access uint64) error {
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		if errors.Is(err, unix.ENOENT) {
+			return nil
+		}
+		return fmt.Errorf("open landlock path %s: %w", path, err)
+	}
+	defer unix.Close(fd)
+	rule := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(fd)}
+	if _, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(rulesetFD), unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&rule)), 0, 0, 0); errno != 0 {
+		return fmt.Errorf("add landlock rule for %s: %w", path, errno)
+	}
+	return nil
+}
+
+func exitCodeOf(state *os.ProcessState, err error) int {
+	if state == nil {
+		if err != nil {
+			return helperFailure(err)
+		}
+		return 0
+	}
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return state.ExitCode()
+}
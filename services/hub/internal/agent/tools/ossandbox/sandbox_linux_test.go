@@ -0,0 +1,136 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+//go:build linux
+
+package ossandbox
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	RunInitIfRequested()
+	os.Exit(m.Run())
+}
+
+func requireSandbox(t *testing.T) {
+	t.Helper()
+	if support := Probe(); !support.Available {
+		t.Skipf("os sandbox unavailable: %s", support.Reason)
+	}
+}
+
+func runSandboxed(t *testing.T, config Config, dir string, script string) (string, int) {
+	t.Helper()
+	cmd, status, cleanup, err := Command(context.Background(), config, CommandRequest{
+		Argv: []string{"sh", "-c", script},
+		Dir:  dir,
+	})
+	if err != nil {
+		t.Fatalf("prepare sandbox command: %v", err)
+	}
+	defer cleanup()
+	if !status.Active || status.Backend != backendName {
+		t.Fatalf("expected active sandbox status, got %#v", status)
+	}
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	runErr := cmd.Run()
+	if runErr != nil && cmd.ProcessState == nil {
+		t.Fatalf("run sandbox command: %v", runErr)
+	}
+	return strings.TrimSpace(output.String()), cmd.ProcessState.ExitCode()
+}
+
+func TestCommandDisabledRunsUnsandboxed(t *testing.T) {
+	cmd, status, cleanup, err := Command(context.Background(), DefaultConfig(), CommandRequest{Argv: []string{"true"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer cleanup()
+	if status.Requested || status.Active || cmd.SysProcAttr != nil {
+		t.Fatalf("expected plain command, got status %#v", status)
+	}
+}
+
+func TestSandboxAllowsWorkspaceWritesOnly(t *testing.T) {
+	requireSandbox(t)
+	workspace := t.TempDir()
+	outside := t.TempDir()
+	config := DefaultConfig()
+	config.Enabled = true
+
+	script := "echo inside > inside.txt && cat inside.txt; " +
+		"if (echo outside > " + filepath.Join(outside, "outside.txt") + ") 2>/dev/null; then echo escaped; fi; " +
+		"if (touch /usr/goyais-sandbox-probe) 2>/dev/null; then echo escaped-root; fi"
+	output, code := runSandboxed(t, config, workspace, script)
+	if code != 0 || output != "inside" {
+		t.Fatalf("unexpected sandbox result code=%d output=%q", code, output)
+	}
+	if _, err := os.Stat(filepath.Join(workspace, "inside.txt")); err != nil {
+		t.Fatalf("expected workspace write to persist: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "outside.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected write outside workspace to fail, stat err=%v", err)
+	}
+}
+
+func TestSandboxIsolatesPidsAndNetwork(t *testing.T) {
+	requireSandbox(t)
+	config := DefaultConfig()
+	config.Enabled = true
+
+	output, code := runSandboxed(t, config, t.TempDir(), "echo $$; tail -n +3 /proc/net/dev | cut -d: -f1")
+	if code != 0 {
+		t.Fatalf("unexpected exit code %d: %s", code, output)
+	}
+	fields := strings.Fields(output)
+	if len(fields) != 2 || len(fields[0]) > 2 || fields[1] != "lo" {
+		t.Fatalf("expected a fresh pid namespace with only loopback, got %q", output)
+	}
+}
+
+func TestSandboxPropagatesExitCode(t *testing.T) {
+	requireSandbox(t)
+	config := DefaultConfig()
+	config.Enabled = true
+
+	_, code := runSandboxed(t, config, t.TempDir(), "exit 7")
+	if code != 7 {
+		t.Fatalf("expected exit code 7, got %d", code)
+	}
+}
+
+func TestSandboxAllowlistRoutesThroughProxy(t *testing.T) {
+	requireSandbox(t)
+	if _, err := exec.LookPath("curl"); err != nil {
+		t.Skip("curl is not installed")
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("allowed"))
+	}))
+	defer server.Close()
+	config := DefaultConfig()
+	config.Enabled = true
+	config.Network = NetworkAllowlist
+	config.AllowedDomains = []string{"127.0.0.1"}
+
+	// NO_PROXY keeps sandbox-local loopback direct, so force the host test
+	// server through the proxy explicitly.
+	script := "curl -s --noproxy '' " + server.URL + "; echo; curl -s -o /dev/null -w '%{http_code}' http://denied.invalid/"
+	output, code := runSandboxed(t, config, t.TempDir(), script)
+	if code != 0 || output != "allowed\n403" {
+		t.Fatalf("unexpected allowlist result code=%d output=%q", code, output)
+	}
+}
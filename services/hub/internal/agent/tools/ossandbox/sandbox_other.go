@@ -0,0 +1,27 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+//go:build !linux
+
+package ossandbox
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+)
+
+// RunInitIfRequested is a no-op on platforms without the namespace sandbox.
+func RunInitIfRequested() {}
+
+func probePlatform() Support {
+	return Support{
+		Platform: runtime.GOOS,
+		Reason:   "os sandbox is only implemented on linux",
+	}
+}
+
+func platformCommand(_ context.Context, _ Config, support Support, _ CommandRequest) (*exec.Cmd, Status, func(), error) {
+	return nil, Status{Requested: true, Reason: support.Reason}, noopCleanup, ErrUnavailable
+}
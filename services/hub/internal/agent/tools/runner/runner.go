@@ -22,8 +22,10 @@ import (
 	"goyais/services/hub/internal/agent/capability"
 	"goyais/services/hub/internal/agent/core"
 	mcpext "goyais/services/hub/internal/agent/extensions/mcp"
+	"goyais/services/hub/internal/agent/policy/redaction"
 	"goyais/services/hub/internal/agent/tools/catalog"
 	"goyais/services/hub/internal/agent/tools/executor"
+	"goyais/services/hub/internal/agent/tools/ossandbox"
 )
 
 const (
@@ -33,13 +35,24 @@ const (
 	defaultListLimit      = 200
 )
 
+// CapabilityLoader receives searchable capabilities loaded by name through a
+// "select:" ToolSearch query so callers can expose them to the model.
+type CapabilityLoader func(items []core.CapabilityDescriptor)
+
+// ProgressReporter receives notifications/progress reports for one MCP
+// tool call.
+type ProgressReporter func(callID string, toolName string, update mcpext.ProgressUpdate)
+
 // Runner executes built-in tools and MCP proxied tools.
 type Runner struct {
-	mcpCaller             *mcpext.ClientManager
-	readMaxBytes          int
-	bashMaxBytes          int
-	bashTimeoutSec        int
-	searchableCapabilities []core.CapabilityDescriptor
+	mcpCaller        *mcpext.ClientManager
+	readMaxBytes     int
+	bashMaxBytes     int
+	bashTimeoutSec   int
+	searchIndex      *capability.SearchIndex
+	capabilityLoader CapabilityLoader
+	progressReporter ProgressReporter
+	osSandbox        ossandbox.Config
 }
 
 // New constructs a tool runner with optional MCP caller.
@@ -50,15 +63,42 @@ func New(mcpCaller *mcpext.ClientManager) *Runner {
 // NewWithSearchable constructs a tool runner with deferred searchable
 // capability descriptors available to ToolSearch.
 func NewWithSearchable(mcpCaller *mcpext.ClientManager, searchable []core.CapabilityDescriptor) *Runner {
+	return NewWithSearchIndex(mcpCaller, capability.NewSearchIndex(searchable))
+}
+
+// NewWithSearchIndex constructs a tool runner backed by a prebuilt ToolSearch
+// index so the index is built once per run config.
+func NewWithSearchIndex(mcpCaller *mcpext.ClientManager, index *capability.SearchIndex) *Runner {
+	if index == nil {
+		index = capability.NewSearchIndex(nil)
+	}
 	return &Runner{
-		mcpCaller:             mcpCaller,
-		readMaxBytes:          defaultReadMaxBytes,
-		bashMaxBytes:          defaultBashMaxBytes,
-		bashTimeoutSec:        defaultBashTimeoutSec,
-		searchableCapabilities: cloneCapabilities(searchable),
+		mcpCaller:      mcpCaller,
+		readMaxBytes:   defaultReadMaxBytes,
+		bashMaxBytes:   defaultBashMaxBytes,
+		bashTimeoutSec: defaultBashTimeoutSec,
+		searchIndex:    index,
+		osSandbox:      ossandbox.DefaultConfig(),
 	}
 }
 
+// SetCapabilityLoader registers the callback invoked when ToolSearch loads
+// capabilities by exact name.
+func (r *Runner) SetCapabilityLoader(loader CapabilityLoader) {
+	r.capabilityLoader = loader
+}
+
+// SetProgressReporter registers the callback invoked when an MCP server
+// reports progress on a tool call.
+func (r *Runner) SetProgressReporter(reporter ProgressReporter) {
+	r.progressReporter = reporter
+}
+
+// SetOSSandbox configures the operating-system sandbox applied to Bash.
+func (r *Runner) SetOSSandbox(config ossandbox.Config) {
+	r.osSandbox = config
+}
+
 var _ executor.Runner = (*Runner)(nil)
 
 // Execute runs one tool call.
@@ -80,16 +120,28 @@ func (r *Runner) Execute(ctx context.Context, req executor.RunRequest) (map[stri
 	case catalog.ToolEdit:
 		return r.runEdit(root, call.Input)
 	case catalog.ToolBash:
-		return r.runBash(ctx, root, call.Input)
+		return r.runBash(ctx, root, req.ToolContext.AdditionalDirectories, call.Input)
 	case catalog.ToolList:
 		return r.runList(root, call.Input)
 	case catalog.ToolToolSearch:
 		return r.runToolSearch(call.Input), nil
+	case catalog.ToolExitPlanMode:
+		return runExitPlanMode(call.Input)
+	case catalog.ToolListMcpResources:
+		return r.runListMcpResources(ctx, call.Input)
+	case catalog.ToolReadMcpResource:
+		return r.runReadMcpResource(ctx, call.Input)
 	default:
 		if strings.HasPrefix(strings.ToLower(toolName), "mcp__") {
 			if r.mcpCaller == nil {
 				return nil, fmt.Errorf("mcp caller is not configured")
 			}
+			if reporter := r.progressReporter; reporter != nil {
+				callID := call.CallID
+				ctx = mcpext.WithProgressHandler(ctx, func(update mcpext.ProgressUpdate) {
+					reporter(callID, toolName, update)
+				})
+			}
 			return r.mcpCaller.Call(ctx, toolName, call.Input)
 		}
 		return nil, fmt.Errorf("unsupported tool %q", toolName)
@@ -98,16 +150,120 @@ func (r *Runner) Execute(ctx context.Context, req executor.RunRequest) (map[stri
 
 func (r *Runner) runToolSearch(input map[string]any) map[string]any {
 	query := asString(input["query"])
+	if names, ok := capability.ParseSelectQuery(query); ok {
+		selected, missing := r.searchIndex.Select(names)
+		if len(selected) > 0 && r.capabilityLoader != nil {
+			r.capabilityLoader(cloneCapabilities(selected))
+		}
+		loaded := make([]string, 0, len(selected))
+		for _, item := range selected {
+			loaded = append(loaded, item.Name)
+		}
+		matches := capability.RenderSelectedCapabilities(selected)
+		return map[string]any{
+			"ok":      len(missing) == 0,
+			"mode":    "select",
+			"query":   strings.TrimSpace(query),
+			"matches": matches,
+			"count":   len(matches),
+			"loaded":  loaded,
+			"missing": missing,
+		}
+	}
 	limit := asInt(input["limit"], 20)
-	matches := capability.BuildSearchResultItems(r.searchableCapabilities, query, limit)
+	matches := capability.RenderSearchHits(r.searchIndex.Search(query, limit))
 	return map[string]any{
 		"ok":      true,
+		"mode":    "search",
 		"query":   strings.TrimSpace(query),
 		"matches": matches,
 		"count":   len(matches),
 	}
 }
 
+func (r *Runner) runListMcpResources(ctx context.Context, input map[string]any) (map[string]any, error) {
+	if r.mcpCaller == nil {
+		return nil, fmt.Errorf("mcp caller is not configured")
+	}
+	resources, templates, err := r.mcpCaller.ListResources(ctx, strings.TrimSpace(asString(input["server"])))
+	if err != nil {
+		return nil, err
+	}
+	resourceItems := make([]map[string]any, 0, len(resources))
+	for _, item := range resources {
+		resourceItems = append(resourceItems, map[string]any{
+			"server":      item.Server,
+			"uri":         item.URI,
+			"name":        item.Name,
+			"description": item.Description,
+			"mime_type":   item.MimeType,
+		})
+	}
+	templateItems := make([]map[string]any, 0, len(templates))
+	for _, item := range templates {
+		templateItems = append(templateItems, map[string]any{
+			"server":       item.Server,
+			"uri_template": item.URITemplate,
+			"name":         item.Name,
+			"description":  item.Description,
+			"mime_type":    item.MimeType,
+		})
+	}
+	return map[string]any{
+		"ok":        true,
+		"resources": resourceItems,
+		"templates": templateItems,
+		"count":     len(resourceItems),
+	}, nil
+}
+
+func (r *Runner) runReadMcpResource(ctx context.Context, input map[string]any) (map[string]any, error) {
+	if r.mcpCaller == nil {
+		return nil, fmt.Errorf("mcp caller is not configured")
+	}
+	server := strings.TrimSpace(asString(input["server"]))
+	uri := strings.TrimSpace(asString(input["uri"]))
+	if server == "" || uri == "" {
+		return nil, errors.New("server and uri are required")
+	}
+	contents, err := r.mcpCaller.ReadResource(ctx, server, uri)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]map[string]any, 0, len(contents))
+	for _, item := range contents {
+		entry := map[string]any{"uri": item.URI, "mime_type": item.MimeType}
+		if item.Text != "" {
+			entry["text"] = item.Text
+		}
+		if item.Blob != "" {
+			entry["blob"] = item.Blob
+		}
+		items = append(items, entry)
+	}
+	return map[string]any{
+		"ok":       true,
+		"server":   server,
+		"uri":      uri,
+		"contents": items,
+		"output":   mcpext.ResourceContentsText(contents),
+	}, nil
+}
+
+// runExitPlanMode only validates the plan; the executor pipeline runs the
+// review handshake when it sees requires_plan_approval.
+func runExitPlanMode(input map[string]any) (map[string]any, error) {
+	plan := strings.TrimSpace(asString(input["plan"]))
+	if plan == "" {
+		return nil, errors.New("plan is required")
+	}
+	return map[string]any{
+		"ok":                     true,
+		"requires_plan_approval": true,
+		"plan":                   plan,
+	}, nil
+}
+
 func (r *Runner) runRead(root string, input map[string]any) (map[string]any, error) {
 	absPath, relPath, err := resolvePath(root, asString(input["path"]), false)
 	if err != nil {
@@ -151,6 +307,9 @@ func (r *Runner) runWrite(root string, input map[string]any) (map[string]any, er
 	} else if !errors.Is(readErr, os.ErrNotExist) {
 		return nil, readErr
 	}
+	if err := rejectRedactionPlaceholders(relPath, before, content); err != nil {
+		return nil, err
+	}
 
 	if err := os.MkdirAll(filepath.Dir(absPath), 0o755); err != nil {
 		return nil, err
@@ -201,6 +360,9 @@ func (r *Runner) runEdit(root string, input map[string]any) (map[string]any, err
 		return nil, err
 	}
 	before := string(raw)
+	if err := rejectRedactionPlaceholders(relPath, before, newString); err != nil {
+		return nil, err
+	}
 	replaceAll := asBool(input["replace_all"])
 	after := before
 	replacements := 0
@@ -233,7 +395,20 @@ func (r *Runner) runEdit(root string, input map[string]any) (map[string]any, err
 	}, nil
 }
 
-func (r *Runner) runBash(ctx context.Context, root string, input map[string]any) (map[string]any, error) {
+// rejectRedactionPlaceholders refuses writes that would put a redaction
+// placeholder into a file. The model only ever saw the placeholder, so
+// writing it back would overwrite the secret it replaced. Placeholders the
+// file already contains literally are left alone.
+func rejectRedactionPlaceholders(relPath string, before string, written string) error {
+	for _, placeholder := range redaction.Placeholders(written) {
+		if !strings.Contains(before, placeholder) {
+			return fmt.Errorf("refusing to write redaction placeholder %s to %s: it stands for a secret that was hidden from the model; leave that value out of the change", placeholder, relPath)
+		}
+	}
+	return nil
+}
+
+func (r *Runner) runBash(ctx context.Context, root string, additionalDirs []string, input map[string]any) (map[string]any, error) {
 	command := strings.TrimSpace(asString(input["command"]))
 	if command == "" {
 		return nil, fmt.Errorf("command is required")
@@ -244,8 +419,15 @@ func (r *Runner) runBash(ctx context.Context, root string, input map[string]any)
 	}
 	runCtx, cancel := context.WithTimeout(ctx, time.Duration(timeoutSec)*time.Second)
 	defer cancel()
-	cmd := exec.CommandContext(runCtx, "sh", "-lc", command)
-	cmd.Dir = root
+	cmd, sandboxStatus, cleanup, err := ossandbox.Command(runCtx, r.osSandbox, ossandbox.CommandRequest{
+		Argv:           []string{"sh", "-lc", command},
+		Dir:            root,
+		AdditionalDirs: additionalDirs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
 
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
@@ -275,6 +457,9 @@ func (r *Runner) runBash(ctx context.Context, root string, input map[string]any)
 		"output":    outputText,
 		"exit_code": exitCode,
 	}
+	if sandboxStatus.Requested {
+		response["sandbox"] = sandboxStatus.AuditMap()
+	}
 	if runErr != nil {
 		response["error"] = strings.TrimSpace(runErr.Error())
 	}
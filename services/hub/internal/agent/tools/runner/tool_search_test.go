@@ -50,3 +50,47 @@ func TestRunnerExecuteToolSearchReturnsSearchableCapabilities(t *testing.T) {
 		t.Fatalf("unexpected tool search match %#v", matches[0])
 	}
 }
+
+func TestRunnerExecuteToolSearchSelectLoadsNamedCapabilities(t *testing.T) {
+	tempDir := t.TempDir()
+	runner := NewWithSearchable(nil, []core.CapabilityDescriptor{
+		{
+			ID:               "mcp:local:search_docs",
+			Kind:             core.CapabilityKindMCPTool,
+			Name:             "mcp__local__search_docs",
+			Description:      "Search documentation",
+			VisibilityPolicy: core.CapabilityVisibilitySearchable,
+		},
+		{
+			ID:               "mcp:local:read_doc",
+			Kind:             core.CapabilityKindMCPTool,
+			Name:             "mcp__local__read_doc",
+			Description:      "Read one document",
+			VisibilityPolicy: core.CapabilityVisibilitySearchable,
+		},
+	})
+	loaded := []string{}
+	runner.SetCapabilityLoader(func(items []core.CapabilityDescriptor) {
+		for _, item := range items {
+			loaded = append(loaded, item.Name)
+		}
+	})
+
+	output, err := runner.Execute(context.Background(), executor.RunRequest{
+		ToolContext: executor.ToolContext{WorkingDir: tempDir},
+		Call: executor.ToolCall{
+			CallID: "call_tool_select",
+			Name:   "ToolSearch",
+			Input:  map[string]any{"query": "select:mcp__local__read_doc"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("tool search select failed: %v", err)
+	}
+	if output["mode"] != "select" || output["count"] != 1 {
+		t.Fatalf("unexpected select output %#v", output)
+	}
+	if len(loaded) != 1 || loaded[0] != "mcp__local__read_doc" {
+		t.Fatalf("expected loader to receive selected capability, got %#v", loaded)
+	}
+}
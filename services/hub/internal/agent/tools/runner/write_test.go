@@ -0,0 +1,56 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"goyais/services/hub/internal/agent/policy/redaction"
+	"goyais/services/hub/internal/agent/tools/executor"
+)
+
+func TestRunnerRefusesToWriteRedactionPlaceholdersOverSecrets(t *testing.T) {
+	tempDir := t.TempDir()
+	envPath := filepath.Join(tempDir, ".env")
+	original := "API_KEY=sk-live-0123456789abcdef\nDEBUG=false\n"
+	if err := os.WriteFile(envPath, []byte(original), 0o644); err != nil {
+		t.Fatalf("write .env: %v", err)
+	}
+	placeholder := redaction.Placeholder(redaction.KindDotenv, "sk-live-0123456789abcdef")
+	runner := New(nil)
+	execute := func(name string, input map[string]any) error {
+		_, err := runner.Execute(context.Background(), executor.RunRequest{
+			ToolContext: executor.ToolContext{WorkingDir: tempDir},
+			Call:        executor.ToolCall{CallID: "call_" + name, Name: name, Input: input},
+		})
+		return err
+	}
+
+	if err := execute("Write", map[string]any{"path": ".env", "content": "API_KEY=" + placeholder + "\nDEBUG=true\n"}); err == nil || !strings.Contains(err.Error(), placeholder) {
+		t.Fatalf("expected Write with a placeholder to be refused, got %v", err)
+	}
+	if err := execute("Edit", map[string]any{"path": ".env", "old_string": "DEBUG=false", "new_string": "API_KEY=" + placeholder + "\nDEBUG=true"}); err == nil {
+		t.Fatal("expected Edit introducing a placeholder to be refused")
+	}
+	if raw, _ := os.ReadFile(envPath); string(raw) != original {
+		t.Fatalf("expected the secret to survive, got %q", raw)
+	}
+
+	if err := execute("Edit", map[string]any{"path": ".env", "old_string": "DEBUG=false", "new_string": "DEBUG=true"}); err != nil {
+		t.Fatalf("expected an edit around the secret to succeed: %v", err)
+	}
+	docPath := filepath.Join(tempDir, "REDACTION.md")
+	doc := "Secrets show up as " + placeholder + ".\n"
+	if err := os.WriteFile(docPath, []byte(doc), 0o644); err != nil {
+		t.Fatalf("write doc: %v", err)
+	}
+	if err := execute("Write", map[string]any{"path": "REDACTION.md", "content": doc + "More notes.\n"}); err != nil {
+		t.Fatalf("expected placeholders the file already holds to be kept: %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package events
+
+import (
+	"database/sql"
+	"errors"
+
+	"goyais/services/hub/internal/agent/core"
+	eventscore "goyais/services/hub/internal/agent/core/events"
+)
+
+// SQLiteStore is an EventLog kept in a sqlite table, for sessions that
+// should not live in memory. The caller opens the database and registers
+// the driver. Reads that fail return no events.
+type SQLiteStore struct {
+	db      *sql.DB
+	options Options
+}
+
+var _ EventLog = (*SQLiteStore)(nil)
+
+// NewSQLiteStore creates the agent_events table when missing and returns a
+// store over it. Options.SegmentSize is not used.
+func NewSQLiteStore(db *sql.DB, options Options) (*SQLiteStore, error) {
+	if db == nil {
+		return nil, errors.New("event store database is nil")
+	}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS agent_events (
+		session_id TEXT NOT NULL,
+		sequence INTEGER NOT NULL,
+		run_id TEXT NOT NULL,
+		type TEXT NOT NULL,
+		occurred_at_unix_nano INTEGER NOT NULL,
+		envelope_json TEXT NOT NULL,
+		PRIMARY KEY(session_id, sequence)
+	) WITHOUT ROWID`); err != nil {
+		return nil, err
+	}
+	return &SQLiteStore{db: db, options: options.normalized()}, nil
+}
+
+// Append validates and persists one event envelope.
+func (s *SQLiteStore) Append(event core.EventEnvelope) error {
+	return s.AppendMany([]core.EventEnvelope{event})
+}
+
+// AppendMany appends multiple events in one transaction.
+func (s *SQLiteStore) AppendMany(events []core.EventEnvelope) (err error) {
+	if s == nil || s.db == nil {
+		return errors.New("event store is nil")
+	}
+	encoded := make([][]byte, 0, len(events))
+	for _, event := range events {
+		raw, encodeErr := eventscore.EncodeJSON(event)
+		if encodeErr != nil {
+			return encodeErr
+		}
+		encoded = append(encoded, raw)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	statement, err := tx.Prepare(`INSERT INTO agent_events(
+		session_id, sequence, run_id, type, occurred_at_unix_nano, envelope_json
+	) VALUES(?,?,?,?,?,?)
+	ON CONFLICT(session_id, sequence) DO UPDATE SET
+		run_id = excluded.run_id,
+		type = excluded.type,
+		occurred_at_unix_nano = excluded.occurred_at_unix_nano,
+		envelope_json = excluded.envelope_json`)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+	sessions := map[core.SessionID]struct{}{}
+	for index, event := range events {
+		if _, err = statement.Exec(
+			string(event.SessionID),
+			event.Sequence,
+			string(event.RunID),
+			string(event.Type),
+			event.Timestamp.UTC().UnixNano(),
+			string(encoded[index]),
+		); err != nil {
+			return err
+		}
+		sessions[event.SessionID] = struct{}{}
+	}
+	for sessionID := range sessions {
+		if err = s.applyRetention(tx, sessionID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Replay returns events with sequence greater than afterSequence.
+// If limit <= 0, all matching events are returned.
+func (s *SQLiteStore) Replay(sessionID core.SessionID, afterSequence int64, limit int) []core.EventEnvelope {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := s.db.Query(
+		`SELECT envelope_json FROM agent_events
+		 WHERE session_id = ? AND sequence > ?
+		 ORDER BY sequence ASC
+		 LIMIT ?`,
+		string(sessionID), afterSequence, limit,
+	)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	out := []core.EventEnvelope{}
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil
+		}
+		event, err := eventscore.DecodeJSON([]byte(raw))
+		if err != nil {
+			return nil
+		}
+		out = append(out, event)
+	}
+	if rows.Err() != nil || len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// LatestSequence returns the highest persisted sequence in one session.
+func (s *SQLiteStore) LatestSequence(sessionID core.SessionID) (int64, bool) {
+	if s == nil || s.db == nil {
+		return 0, false
+	}
+	var latest sql.NullInt64
+	if err := s.db.QueryRow(
+		`SELECT MAX(sequence) FROM agent_events WHERE session_id = ?`,
+		string(sessionID),
+	).Scan(&latest); err != nil || !latest.Valid {
+		return 0, false
+	}
+	return latest.Int64, true
+}
+
+// Count returns persisted event count for one session.
+func (s *SQLiteStore) Count(sessionID core.SessionID) int {
+	if s == nil || s.db == nil {
+		return 0
+	}
+	count := 0
+	if err := s.db.QueryRow(
+		`SELECT COUNT(*) FROM agent_events WHERE session_id = ?`,
+		string(sessionID),
+	).Scan(&count); err != nil {
+		return 0
+	}
+	return count
+}
+
+// Compact applies retention to one session. Space is reclaimed by sqlite.
+func (s *SQLiteStore) Compact(sessionID core.SessionID) {
+	if s == nil || s.db == nil {
+		return
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return
+	}
+	if err := s.applyRetention(tx, sessionID); err != nil {
+		_ = tx.Rollback()
+		return
+	}
+	_ = tx.Commit()
+}
+
+func (s *SQLiteStore) applyRetention(tx *sql.Tx, sessionID core.SessionID) error {
+	if s.options.MaxEventsPerSession > 0 {
+		if _, err := tx.Exec(
+			`DELETE FROM agent_events
+			 WHERE session_id = ? AND sequence <= (
+				SELECT sequence FROM agent_events
+				WHERE session_id = ?
+				ORDER BY sequence DESC
+				LIMIT 1 OFFSET ?
+			 )`,
+			string(sessionID), string(sessionID), s.options.MaxEventsPerSession,
+		); err != nil {
+			return err
+		}
+	}
+	if s.options.MaxAge > 0 {
+		cutoff := s.options.Now().Add(-s.options.MaxAge).UTC().UnixNano()
+		if _, err := tx.Exec(
+			`DELETE FROM agent_events WHERE session_id = ? AND occurred_at_unix_nano < ?`,
+			string(sessionID), cutoff,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
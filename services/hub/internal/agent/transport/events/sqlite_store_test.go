@@ -0,0 +1,76 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package events
+
+import (
+	"database/sql"
+	"testing"
+
+	"goyais/services/hub/internal/agent/core"
+
+	_ "modernc.org/sqlite"
+)
+
+func openSQLiteStore(t testing.TB, options Options) *SQLiteStore {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite failed: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	store, err := NewSQLiteStore(db, options)
+	if err != nil {
+		t.Fatalf("create sqlite store failed: %v", err)
+	}
+	return store
+}
+
+func TestSQLiteStoreMatchesMemoryStoreSemantics(t *testing.T) {
+	store := openSQLiteStore(t, Options{MaxEventsPerSession: 4})
+	sessionID := core.SessionID("sess_sql")
+	err := store.AppendMany([]core.EventEnvelope{
+		makeEvent(sessionID, core.RunID("run_1"), 3),
+		makeEvent(sessionID, core.RunID("run_1"), 1),
+		makeEvent(sessionID, core.RunID("run_1"), 2),
+	})
+	if err != nil {
+		t.Fatalf("append many failed: %v", err)
+	}
+	if err := store.Append(makeEvent(sessionID, core.RunID("run_replaced"), 2)); err != nil {
+		t.Fatalf("append duplicate failed: %v", err)
+	}
+
+	items := store.Replay(sessionID, 0, 0)
+	if len(items) != 3 || items[0].Sequence != 1 || items[2].Sequence != 3 {
+		t.Fatalf("unexpected replay %#v", items)
+	}
+	if items[1].RunID != "run_replaced" {
+		t.Fatalf("expected duplicate sequence to replace the event, got %#v", items[1])
+	}
+	if page := store.Replay(sessionID, 1, 1); len(page) != 1 || page[0].Sequence != 2 {
+		t.Fatalf("unexpected cursor page %#v", page)
+	}
+
+	for sequence := int64(4); sequence <= 6; sequence++ {
+		if err := store.Append(makeEvent(sessionID, core.RunID("run_1"), sequence)); err != nil {
+			t.Fatalf("append %d failed: %v", sequence, err)
+		}
+	}
+	if count := store.Count(sessionID); count != 4 {
+		t.Fatalf("expected retention to keep 4 events, got %d", count)
+	}
+	if latest, ok := store.LatestSequence(sessionID); !ok || latest != 6 {
+		t.Fatalf("unexpected latest sequence %d ok=%v", latest, ok)
+	}
+	if items := store.Replay(sessionID, -1, 0); items[0].Sequence != 3 {
+		t.Fatalf("expected the oldest events to be dropped, got %#v", items)
+	}
+	if err := store.Append(core.EventEnvelope{}); err == nil {
+		t.Fatal("expected validation error")
+	}
+}
@@ -9,21 +9,75 @@ import (
 	"errors"
 	"sort"
 	"sync"
+	"time"
 
 	"goyais/services/hub/internal/agent/core"
 )
 
-// Store is an in-memory ordered event log keyed by session.
+// DefaultSegmentSize is the number of events one in-memory segment holds.
+const DefaultSegmentSize = 1024
+
+// EventLog is an ordered event log keyed by session. Events are ordered by
+// sequence; appending a sequence that is already stored replaces the event.
+type EventLog interface {
+	Append(event core.EventEnvelope) error
+	AppendMany(events []core.EventEnvelope) error
+	Replay(sessionID core.SessionID, afterSequence int64, limit int) []core.EventEnvelope
+	LatestSequence(sessionID core.SessionID) (int64, bool)
+	Count(sessionID core.SessionID) int
+	Compact(sessionID core.SessionID)
+}
+
+// Options configures retention and segment layout. Zero values keep every
+// event and use DefaultSegmentSize.
+type Options struct {
+	SegmentSize int
+	// MaxEventsPerSession drops the oldest events once a session holds more.
+	MaxEventsPerSession int
+	// MaxAge drops events whose timestamp is older than now minus MaxAge.
+	MaxAge time.Duration
+	// Now overrides the clock used for MaxAge.
+	Now func() time.Time
+}
+
+func (o Options) normalized() Options {
+	if o.SegmentSize <= 0 {
+		o.SegmentSize = DefaultSegmentSize
+	}
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+	return o
+}
+
+// Store is an in-memory ordered event log keyed by session. Each session is
+// a list of sorted segments: in-order appends go to the tail segment, late
+// events are inserted into the segment that covers their sequence, and
+// reads binary-search the segments for the cursor.
 type Store struct {
 	mu sync.RWMutex
 
-	items map[core.SessionID][]core.EventEnvelope
+	options Options
+	items   map[core.SessionID]*sessionLog
 }
 
-// NewStore creates an empty event store.
+type sessionLog struct {
+	segments [][]core.EventEnvelope
+	count    int
+}
+
+var _ EventLog = (*Store)(nil)
+
+// NewStore creates an empty event store that keeps every event.
 func NewStore() *Store {
+	return NewStoreWithOptions(Options{})
+}
+
+// NewStoreWithOptions creates an empty event store with retention options.
+func NewStoreWithOptions(options Options) *Store {
 	return &Store{
-		items: map[core.SessionID][]core.EventEnvelope{},
+		options: options.normalized(),
+		items:   map[core.SessionID]*sessionLog{},
 	}
 }
 
@@ -38,27 +92,41 @@ func (s *Store) Append(event core.EventEnvelope) error {
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
-
-	sessionID := event.SessionID
-	items := append([]core.EventEnvelope(nil), s.items[sessionID]...)
-	items = append(items, event)
-	sort.SliceStable(items, func(i, j int) bool {
-		return items[i].Sequence < items[j].Sequence
-	})
-	s.items[sessionID] = items
+	s.appendLocked(event)
 	return nil
 }
 
 // AppendMany appends multiple events in one lock window.
 func (s *Store) AppendMany(events []core.EventEnvelope) error {
+	if s == nil {
+		return errors.New("event store is nil")
+	}
 	for _, event := range events {
-		if err := s.Append(event); err != nil {
+		if err := event.Validate(); err != nil {
 			return err
 		}
 	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, event := range events {
+		s.appendLocked(event)
+	}
 	return nil
 }
 
+func (s *Store) appendLocked(event core.EventEnvelope) {
+	log := s.items[event.SessionID]
+	if log == nil {
+		log = &sessionLog{}
+		s.items[event.SessionID] = log
+	}
+	if log.insert(event, s.options.SegmentSize) && log.fragmented(s.options.SegmentSize) {
+		log.repack(s.options.SegmentSize)
+	}
+	log.applyRetention(s.options)
+}
+
 // Replay returns events with sequence greater than afterSequence.
 // If limit <= 0, all matching events are returned.
 func (s *Store) Replay(sessionID core.SessionID, afterSequence int64, limit int) []core.EventEnvelope {
@@ -68,25 +136,40 @@ func (s *Store) Replay(sessionID core.SessionID, afterSequence int64, limit int)
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	items := s.items[sessionID]
-	if len(items) == 0 {
+	log := s.items[sessionID]
+	if log == nil || log.count == 0 {
+		return nil
+	}
+	segments := log.segments
+	first := sort.Search(len(segments), func(i int) bool {
+		segment := segments[i]
+		return segment[len(segment)-1].Sequence > afterSequence
+	})
+	if first == len(segments) {
 		return nil
 	}
 
-	out := make([]core.EventEnvelope, 0, len(items))
-	for _, item := range items {
-		if item.Sequence <= afterSequence {
-			continue
+	capacity := log.count
+	if limit > 0 && limit < capacity {
+		capacity = limit
+	}
+	out := make([]core.EventEnvelope, 0, capacity)
+	for index := first; index < len(segments); index++ {
+		segment := segments[index]
+		start := 0
+		if index == first {
+			start = sort.Search(len(segment), func(i int) bool {
+				return segment[i].Sequence > afterSequence
+			})
 		}
-		out = append(out, item)
-		if limit > 0 && len(out) >= limit {
-			break
+		for _, item := range segment[start:] {
+			out = append(out, item)
+			if limit > 0 && len(out) >= limit {
+				return out
+			}
 		}
 	}
-	if len(out) == 0 {
-		return nil
-	}
-	return append([]core.EventEnvelope(nil), out...)
+	return out
 }
 
 // LatestSequence returns the highest persisted sequence in one session.
@@ -97,11 +180,12 @@ func (s *Store) LatestSequence(sessionID core.SessionID) (int64, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	items := s.items[sessionID]
-	if len(items) == 0 {
+	log := s.items[sessionID]
+	if log == nil || log.count == 0 {
 		return 0, false
 	}
-	return items[len(items)-1].Sequence, true
+	tail := log.segments[len(log.segments)-1]
+	return tail[len(tail)-1].Sequence, true
 }
 
 // Count returns persisted event count for one session.
@@ -111,5 +195,148 @@ func (s *Store) Count(sessionID core.SessionID) int {
 	}
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return len(s.items[sessionID])
+	if log := s.items[sessionID]; log != nil {
+		return log.count
+	}
+	return 0
+}
+
+// Compact applies retention to one session and packs its events into full
+// segments, releasing the space left behind by trimmed and split segments.
+func (s *Store) Compact(sessionID core.SessionID) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	log := s.items[sessionID]
+	if log == nil {
+		return
+	}
+	log.applyRetention(s.options)
+	if log.count == 0 {
+		delete(s.items, sessionID)
+		return
+	}
+	log.repack(s.options.SegmentSize)
+}
+
+// insert places event by sequence and reports whether it sealed the tail
+// segment.
+func (l *sessionLog) insert(event core.EventEnvelope, segmentSize int) bool {
+	if len(l.segments) == 0 {
+		l.segments = append(l.segments, newSegment(segmentSize, event))
+		l.count++
+		return false
+	}
+	last := len(l.segments) - 1
+	tail := l.segments[last]
+	if event.Sequence > tail[len(tail)-1].Sequence {
+		if len(tail) >= segmentSize {
+			l.segments = append(l.segments, newSegment(segmentSize, event))
+			l.count++
+			return true
+		}
+		l.segments[last] = append(tail, event)
+		l.count++
+		return false
+	}
+
+	index := sort.Search(len(l.segments), func(i int) bool {
+		segment := l.segments[i]
+		return segment[len(segment)-1].Sequence >= event.Sequence
+	})
+	segment := l.segments[index]
+	position := sort.Search(len(segment), func(i int) bool {
+		return segment[i].Sequence >= event.Sequence
+	})
+	if segment[position].Sequence == event.Sequence {
+		segment[position] = event
+		return false
+	}
+	segment = append(segment, core.EventEnvelope{})
+	copy(segment[position+1:], segment[position:])
+	segment[position] = event
+	l.count++
+	if len(segment) <= 2*segmentSize {
+		l.segments[index] = segment
+		return false
+	}
+	half := len(segment) / 2
+	left := append(make([]core.EventEnvelope, 0, segmentSize), segment[:half]...)
+	right := append(make([]core.EventEnvelope, 0, segmentSize), segment[half:]...)
+	l.segments = append(l.segments, nil)
+	copy(l.segments[index+2:], l.segments[index+1:])
+	l.segments[index] = left
+	l.segments[index+1] = right
+	return false
+}
+
+// fragmented reports whether the log holds clearly more segments than its
+// event count needs.
+func (l *sessionLog) fragmented(segmentSize int) bool {
+	needed := l.count/segmentSize + 1
+	return len(l.segments) > 2*needed
+}
+
+func (l *sessionLog) repack(segmentSize int) {
+	packed := make([][]core.EventEnvelope, 0, l.count/segmentSize+1)
+	current := make([]core.EventEnvelope, 0, segmentSize)
+	for _, segment := range l.segments {
+		for _, item := range segment {
+			if len(current) == segmentSize {
+				packed = append(packed, current)
+				current = make([]core.EventEnvelope, 0, segmentSize)
+			}
+			current = append(current, item)
+		}
+	}
+	if len(current) > 0 {
+		packed = append(packed, current)
+	}
+	l.segments = packed
+}
+
+// applyRetention drops events from the head of the log. Whole segments are
+// released; a partly expired head segment is resliced.
+func (l *sessionLog) applyRetention(options Options) {
+	if options.MaxEventsPerSession > 0 {
+		l.dropHead(l.count - options.MaxEventsPerSession)
+	}
+	if options.MaxAge > 0 {
+		cutoff := options.Now().Add(-options.MaxAge)
+		for len(l.segments) > 0 {
+			head := l.segments[0]
+			if !head[len(head)-1].Timestamp.Before(cutoff) {
+				expired := sort.Search(len(head), func(i int) bool {
+					return !head[i].Timestamp.Before(cutoff)
+				})
+				l.dropHead(expired)
+				break
+			}
+			l.dropHead(len(head))
+		}
+	}
+}
+
+func (l *sessionLog) dropHead(n int) {
+	for n > 0 && len(l.segments) > 0 {
+		head := l.segments[0]
+		if n < len(head) {
+			clear(head[:n])
+			l.segments[0] = head[n:]
+			l.count -= n
+			return
+		}
+		n -= len(head)
+		l.count -= len(head)
+		l.segments[0] = nil
+		l.segments = l.segments[1:]
+	}
+}
+
+func newSegment(segmentSize int, first core.EventEnvelope) []core.EventEnvelope {
+	segment := make([]core.EventEnvelope, 0, segmentSize)
+	return append(segment, first)
 }
@@ -0,0 +1,94 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goya
+// SPDX-License-Identifier: MIT
+
+package events
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"goyais/services/hub/internal/agent/core"
+)
+
+const benchmarkSessionEvents = 100_000
+
+func fillStore(b *testing.B, store EventLog, sessionID core.SessionID, count int) {
+	b.Helper()
+	for sequence := int64(1); sequence <= int64(count); sequence++ {
+		if err := store.Append(makeEvent(sessionID, core.RunID("run_bench"), sequence)); err != nil {
+			b.Fatalf("append failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkStoreAppend100kSession(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		fillStore(b, NewStore(), core.SessionID("sess_bench"), benchmarkSessionEvents)
+	}
+}
+
+func BenchmarkStoreAppendOutOfOrder100kSession(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		store := NewStore()
+		sessionID := core.SessionID("sess_bench")
+		for sequence := int64(0); sequence < benchmarkSessionEvents; sequence += 2 {
+			_ = store.Append(makeEvent(sessionID, core.RunID("run_bench"), sequence+1))
+			_ = store.Append(makeEvent(sessionID, core.RunID("run_bench"), sequence))
+		}
+	}
+}
+
+func BenchmarkStoreReplayCursor100kSession(b *testing.B) {
+	store := NewStore()
+	sessionID := core.SessionID("sess_bench")
+	fillStore(b, store, sessionID, benchmarkSessionEvents)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cursor := int64(i*7919) % benchmarkSessionEvents
+		if items := store.Replay(sessionID, cursor, 64); len(items) == 0 && cursor < benchmarkSessionEvents-1 {
+			b.Fatalf("expected events after cursor %d", cursor)
+		}
+	}
+}
+
+func BenchmarkStoreManySubscribers(b *testing.B) {
+	for _, subscribers := range []int{16, 256} {
+		b.Run(fmt.Sprintf("subscribers=%d", subscribers), func(b *testing.B) {
+			store := NewStore()
+			sessionID := core.SessionID("sess_bench")
+			fillStore(b, store, sessionID, benchmarkSessionEvents)
+			var next atomic.Int64
+			next.Store(benchmarkSessionEvents)
+			b.SetParallelism(subscribers)
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				cursor := int64(benchmarkSessionEvents - 256)
+				for pb.Next() {
+					// Each subscriber tails the log while new events arrive.
+					sequence := next.Add(1)
+					_ = store.Append(makeEvent(sessionID, core.RunID("run_bench"), sequence))
+					items := store.Replay(sessionID, cursor, 64)
+					if len(items) > 0 {
+						cursor = items[len(items)-1].Sequence
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkSQLiteStoreAppend(b *testing.B) {
+	store := openSQLiteStore(b, Options{})
+	sessionID := core.SessionID("sess_bench")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = store.Append(makeEvent(sessionID, core.RunID("run_bench"), int64(i+1)))
+	}
+}
@@ -96,3 +96,82 @@ func TestStoreCount(t *testing.T) {
 		t.Fatalf("unexpected count %d", count)
 	}
 }
+
+func TestStoreSegmentsKeepOrderAcrossLateInsertsAndSplits(t *testing.T) {
+	store := NewStoreWithOptions(Options{SegmentSize: 4})
+	sessionID := core.SessionID("sess_4")
+	for sequence := int64(2); sequence <= 40; sequence += 2 {
+		if err := store.Append(makeEvent(sessionID, core.RunID("run_4"), sequence)); err != nil {
+			t.Fatalf("append %d failed: %v", sequence, err)
+		}
+	}
+	for sequence := int64(1); sequence < 40; sequence += 2 {
+		if err := store.Append(makeEvent(sessionID, core.RunID("run_4"), sequence)); err != nil {
+			t.Fatalf("append late %d failed: %v", sequence, err)
+		}
+	}
+	replaced := makeEvent(sessionID, core.RunID("run_replaced"), 7)
+	if err := store.Append(replaced); err != nil {
+		t.Fatalf("append duplicate failed: %v", err)
+	}
+
+	if count := store.Count(sessionID); count != 40 {
+		t.Fatalf("expected 40 events, got %d", count)
+	}
+	items := store.Replay(sessionID, -1, 0)
+	for idx, item := range items {
+		if item.Sequence != int64(idx+1) {
+			t.Fatalf("unexpected sequence %d at %d", item.Sequence, idx)
+		}
+	}
+	if items[6].RunID != "run_replaced" {
+		t.Fatalf("expected duplicate sequence to replace the event, got %#v", items[6])
+	}
+	page := store.Replay(sessionID, 17, 5)
+	if len(page) != 5 || page[0].Sequence != 18 || page[4].Sequence != 22 {
+		t.Fatalf("unexpected cursor page %#v", page)
+	}
+	if items := store.Replay(sessionID, 40, 0); items != nil {
+		t.Fatalf("expected no events past the tail, got %#v", items)
+	}
+
+	store.Compact(sessionID)
+	log := store.items[sessionID]
+	if len(log.segments) != 10 {
+		t.Fatalf("expected compaction to pack 40 events into 10 segments, got %d", len(log.segments))
+	}
+	if latest, ok := store.LatestSequence(sessionID); !ok || latest != 40 {
+		t.Fatalf("unexpected latest sequence %d ok=%v", latest, ok)
+	}
+}
+
+func TestStoreRetentionDropsOldestEvents(t *testing.T) {
+	store := NewStoreWithOptions(Options{SegmentSize: 3, MaxEventsPerSession: 5})
+	sessionID := core.SessionID("sess_5")
+	for sequence := int64(1); sequence <= 12; sequence++ {
+		if err := store.Append(makeEvent(sessionID, core.RunID("run_5"), sequence)); err != nil {
+			t.Fatalf("append %d failed: %v", sequence, err)
+		}
+	}
+	items := store.Replay(sessionID, -1, 0)
+	if len(items) != 5 || items[0].Sequence != 8 || items[4].Sequence != 12 {
+		t.Fatalf("unexpected retained events %#v", items)
+	}
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	aged := NewStoreWithOptions(Options{SegmentSize: 2, MaxAge: time.Hour, Now: func() time.Time { return now }})
+	agedSession := core.SessionID("sess_6")
+	for sequence := int64(1); sequence <= 6; sequence++ {
+		event := makeEvent(agedSession, core.RunID("run_6"), sequence)
+		event.Timestamp = now.Add(time.Duration(sequence-5) * 30 * time.Minute)
+		if err := aged.Append(event); err != nil {
+			t.Fatalf("append aged %d failed: %v", sequence, err)
+		}
+	}
+	now = now.Add(30 * time.Minute)
+	aged.Compact(agedSession)
+	items = aged.Replay(agedSession, -1, 0)
+	if len(items) != 3 || items[0].Sequence != 4 {
+		t.Fatalf("unexpected events after age retention %#v", items)
+	}
+}
@@ -26,6 +26,7 @@ type Handlers struct {
 	AdminAudit                  http.HandlerFunc
 	HooksPolicies               http.HandlerFunc
 	HookExecutions              http.HandlerFunc
+	HooksPolicySimulate         http.HandlerFunc
 }
 
 func Register(mux *http.ServeMux, handlers Handlers) {
@@ -51,6 +52,7 @@ func Register(mux *http.ServeMux, handlers Handlers) {
 	mustHandle(mux, "/v1/admin/abac-policies/{policy_id}", handlers.AdminABACPolicyByID)
 	mustHandle(mux, "/v1/admin/audit", handlers.AdminAudit)
 	mustHandle(mux, "/v1/hooks/policies", handlers.HooksPolicies)
+	mustHandle(mux, "/v1/hooks/policies:simulate", handlers.HooksPolicySimulate)
 	mustHandle(mux, "/v1/hooks/runs/{run_id}", handlers.HookExecutions)
 }
 
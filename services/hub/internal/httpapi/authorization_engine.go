@@ -265,6 +265,15 @@ func matchOperator(operator string, actual any, expected any) bool {
 	case "eq":
 		return compareString(actual) == compareString(expected)
 	case "neq":
+		if actualSlice := toAnySlice(actual); len(actualSlice) > 0 {
+			expectedValue := compareString(expected)
+			for _, item := range actualSlice {
+				if compareString(item) == expectedValue {
+					return false
+				}
+			}
+			return true
+		}
 		return compareString(actual) != compareString(expected)
 	case "in":
 		values := toAnySlice(expected)
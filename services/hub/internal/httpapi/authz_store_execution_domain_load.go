@@ -269,6 +269,7 @@ func toHTTPAPIExecutionResourceProfile(input *runtimeapplication.ExecutionResour
 		ProjectFilePaths:         append([]string{}, input.ProjectFilePaths...),
 		RulesDSL:                 input.RulesDSL,
 		MCPServers:               toHTTPAPIExecutionMCPServerSnapshots(input.MCPServers),
+		MCPCredentialScope:       input.MCPCredentialScope,
 		AlwaysLoadedCapabilities: toHTTPAPIExecutionCapabilityDescriptorSnapshots(input.AlwaysLoadedCapabilities),
 		SearchableCapabilities:   toHTTPAPIExecutionCapabilityDescriptorSnapshots(input.SearchableCapabilities),
 	}
@@ -404,6 +405,10 @@ func toHTTPAPIHookPolicy(row runtimeinfra.HookPolicyRow) (HookPolicy, error) {
 	if err != nil {
 		return HookPolicy{}, fmt.Errorf("decode hook policy decision: %w", err)
 	}
+	handler, err := decodeHookHandlerConfigJSON(row.HandlerJSON)
+	if err != nil {
+		return HookPolicy{}, fmt.Errorf("decode hook policy handler: %w", err)
+	}
 	return HookPolicy{
 		ID:          row.ID,
 		Scope:       scope,
@@ -414,6 +419,7 @@ func toHTTPAPIHookPolicy(row runtimeinfra.HookPolicyRow) (HookPolicy, error) {
 		ProjectID:   derefString(row.ProjectID),
 		SessionID:   derefString(row.ConversationID),
 		Enabled:     row.Enabled,
+		Handler:     handler,
 		Decision:    decision,
 		UpdatedAt:   row.UpdatedAt,
 	}, nil
@@ -448,6 +454,18 @@ func toHTTPAPIHookExecutionRecord(row runtimeinfra.HookExecutionRecordRow) (Hook
 	return record, nil
 }
 
+func decodeHookHandlerConfigJSON(input string) (*HookHandlerConfig, error) {
+	trimmed := strings.TrimSpace(input)
+	if trimmed == "" || trimmed == "{}" {
+		return nil, nil
+	}
+	handler := HookHandlerConfig{}
+	if err := json.Unmarshal([]byte(trimmed), &handler); err != nil {
+		return nil, err
+	}
+	return &handler, nil
+}
+
 func decodeHookDecisionJSON(input string) (HookDecision, error) {
 	if input == "" {
 		return HookDecision{Action: HookDecisionActionAllow}, nil
@@ -257,6 +257,10 @@ func (s *authzStore) replaceExecutionDomainSnapshot(snapshot executionDomainSnap
 		if marshalErr != nil {
 			return marshalErr
 		}
+		handlerJSON, marshalErr := encodeHookHandlerConfigJSON(normalizedPolicy.Handler)
+		if marshalErr != nil {
+			return marshalErr
+		}
 		hookPolicyRows = append(hookPolicyRows, runtimeinfra.HookPolicyRow{
 			ID:             normalizedPolicy.ID,
 			Scope:          string(normalizedPolicy.Scope),
@@ -267,6 +271,7 @@ func (s *authzStore) replaceExecutionDomainSnapshot(snapshot executionDomainSnap
 			ProjectID:      normalizeOptionalString(stringPtrOrNil(normalizedPolicy.ProjectID)),
 			ConversationID: normalizeOptionalString(stringPtrOrNil(normalizedPolicy.SessionID)),
 			Enabled:        normalizedPolicy.Enabled,
+			HandlerJSON:    handlerJSON,
 			DecisionJSON:   decisionJSON,
 			UpdatedAt:      normalizedPolicy.UpdatedAt,
 		})
@@ -373,6 +378,7 @@ func toRuntimeApplicationExecutionResourceProfileSnapshot(input *ExecutionResour
 		ProjectFilePaths:         append([]string{}, input.ProjectFilePaths...),
 		RulesDSL:                 input.RulesDSL,
 		MCPServers:               toRuntimeApplicationExecutionMCPServerSnapshots(input.MCPServers),
+		MCPCredentialScope:       input.MCPCredentialScope,
 		AlwaysLoadedCapabilities: toRuntimeApplicationExecutionCapabilityDescriptorSnapshots(input.AlwaysLoadedCapabilities),
 		SearchableCapabilities:   toRuntimeApplicationExecutionCapabilityDescriptorSnapshots(input.SearchableCapabilities),
 	}
@@ -478,6 +484,10 @@ func normalizeHookPolicyForPersistence(input HookPolicy) (HookPolicy, error) {
 	if err := validateHookScopeBindings(scope, projectID, sessionID); err != nil {
 		return HookPolicy{}, fmt.Errorf("invalid hook policy scope bindings: %w", err)
 	}
+	handler, err := normalizeHookHandlerConfig(handlerType, input.Handler)
+	if err != nil {
+		return HookPolicy{}, fmt.Errorf("invalid hook policy handler: %w", err)
+	}
 	return HookPolicy{
 		ID:          policyID,
 		Scope:       scope,
@@ -488,6 +498,7 @@ func normalizeHookPolicyForPersistence(input HookPolicy) (HookPolicy, error) {
 		ProjectID:   projectID,
 		SessionID:   sessionID,
 		Enabled:     input.Enabled,
+		Handler:     handler,
 		Decision: HookDecision{
 			Action:            action,
 			Reason:            strings.TrimSpace(input.Decision.Reason),
@@ -532,6 +543,7 @@ func normalizeHookExecutionRecordForPersistence(input HookExecutionRecord) (Hook
 			Reason:            strings.TrimSpace(input.Decision.Reason),
 			UpdatedInput:      cloneMapAny(input.Decision.UpdatedInput),
 			AdditionalContext: cloneMapAny(input.Decision.AdditionalContext),
+			Handlers:          append([]HookHandlerExecution(nil), input.Decision.Handlers...),
 		},
 		Timestamp: strings.TrimSpace(input.Timestamp),
 	}, nil
@@ -543,6 +555,7 @@ func encodeHookDecisionJSON(input HookDecision) (string, error) {
 		Reason:            strings.TrimSpace(input.Reason),
 		UpdatedInput:      cloneMapAny(input.UpdatedInput),
 		AdditionalContext: cloneMapAny(input.AdditionalContext),
+		Handlers:          append([]HookHandlerExecution(nil), input.Handlers...),
 	}
 	raw, err := json.Marshal(payload)
 	if err != nil {
@@ -551,6 +564,17 @@ func encodeHookDecisionJSON(input HookDecision) (string, error) {
 	return string(raw), nil
 }
 
+func encodeHookHandlerConfigJSON(input *HookHandlerConfig) (string, error) {
+	if input == nil {
+		return "{}", nil
+	}
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
 func stringPtrOrNil(value string) *string {
 	trimmed := strings.TrimSpace(value)
 	if trimmed == "" {
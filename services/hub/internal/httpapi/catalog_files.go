@@ -283,6 +283,21 @@ func (s *AppState) resolveCatalogVendorBaseURL(workspaceID string, vendor ModelV
 	return strings.TrimSpace(item.BaseURL)
 }
 
+// resolveCatalogContextWindow returns the catalog context window of one
+// model, or zero when the catalog does not declare it.
+func (s *AppState) resolveCatalogContextWindow(workspaceID string, vendor ModelVendorName, modelID string) int {
+	item, ok := s.resolveCatalogVendor(workspaceID, vendor)
+	if !ok {
+		return 0
+	}
+	for _, model := range item.Models {
+		if strings.TrimSpace(model.ID) == strings.TrimSpace(modelID) {
+			return model.ContextWindow
+		}
+	}
+	return 0
+}
+
 func (s *AppState) resolveWorkspaceDefaultModelID(workspaceID string) string {
 	response, err := s.LoadModelCatalog(workspaceID, false)
 	if err != nil {
@@ -437,7 +452,10 @@ func parseModelCatalogPayload(raw []byte, source string, allowAutoFill bool) (mo
 					meta.AutoFilled = true
 				}
 			}
-			models = append(models, ModelCatalogModel{ID: modelID, Label: label, Enabled: model.Enabled})
+			if model.ContextWindow < 0 {
+				return modelCatalogFile{}, meta, fmt.Errorf("vendor %s model %s has negative context_window", vendorName, modelID)
+			}
+			models = append(models, ModelCatalogModel{ID: modelID, Label: label, Enabled: model.Enabled, ContextWindow: model.ContextWindow})
 		}
 		if len(models) == 0 {
 			return modelCatalogFile{}, meta, fmt.Errorf("vendor %s models cannot be empty", vendorName)
@@ -60,6 +60,35 @@ func TestParseModelCatalogPayload_SupportsDeepSeekVendor(t *testing.T) {
 	}
 }
 
+func TestParseModelCatalogPayload_KeepsContextWindow(t *testing.T) {
+	payload := []byte(`{
+  "version": "1",
+  "vendors": [
+    {
+      "name": "DeepSeek",
+      "base_url": "https://api.deepseek.com/v1",
+      "auth": { "type": "http_bearer", "header": "Authorization", "scheme": "Bearer", "api_key_env": "DEEPSEEK_API_KEY" },
+      "models": [
+        { "id": "deepseek-chat", "label": "DeepSeek Chat", "enabled": true, "context_window": 64000 }
+      ]
+    }
+  ]
+}`)
+
+	parsed, _, err := parseModelCatalogPayload(payload, "deepseek.json", false)
+	if err != nil {
+		t.Fatalf("parse catalog failed: %v", err)
+	}
+	if got := parsed.Vendors[0].Models[0].ContextWindow; got != 64000 {
+		t.Fatalf("expected context_window 64000, got %d", got)
+	}
+
+	negative := []byte(strings.Replace(string(payload), "64000", "-1", 1))
+	if _, _, err := parseModelCatalogPayload(negative, "deepseek.json", false); err == nil {
+		t.Fatalf("expected negative context_window to be rejected")
+	}
+}
+
 func TestLoadModelCatalogDetailed_LegacyAutoFillWriteback(t *testing.T) {
 	state := NewAppState(nil)
 	workspaceID := "ws_catalog_autofill"
@@ -0,0 +1,74 @@
+package httpapi
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	composerctx "goyais/services/hub/internal/agent/context/composer"
+	mcpsext "goyais/services/hub/internal/agent/extensions/mcp"
+)
+
+const composerMCPResourceTimeout = 3 * time.Second
+
+// composerMCPResources offers the project's MCP servers as @server:
+// prefixes and, once the token at the cursor names one of them, lists that
+// server's resources through the conversation's pooled connection, signed
+// in as the requesting user.
+func composerMCPResources(state *AppState, workspaceID string, sessionID string, credentialScope string, mcpIDs []string, draft string, cursor int) ([]string, []composerctx.MCPResourceItem) {
+	if cursor < 0 || cursor > len(draft) {
+		cursor = len(draft)
+	}
+	token := draft[:cursor]
+	if index := strings.LastIndexAny(token, " \t\r\n"); index >= 0 {
+		token = token[index+1:]
+	}
+	if !strings.HasPrefix(token, "@") {
+		return nil, nil
+	}
+	configs, err := resolveMCPServersForRuntime(state, workspaceID, mcpIDs)
+	if err != nil || len(configs) == 0 {
+		return nil, nil
+	}
+	names := make([]string, 0, len(configs))
+	servers := make([]mcpsext.ServerConfig, 0, len(configs))
+	for _, item := range configs {
+		names = append(names, item.Name)
+		servers = append(servers, mcpsext.ServerConfig{
+			Name:            item.Name,
+			Transport:       item.Transport,
+			Endpoint:        item.Endpoint,
+			Command:         item.Command,
+			Env:             cloneStringMapForRuntime(item.Env),
+			Tools:           append([]string{}, item.Tools...),
+			CredentialScope: credentialScope,
+		})
+	}
+
+	serverPart, _, hasColon := strings.Cut(strings.TrimPrefix(token, "@"), ":")
+	if !hasColon {
+		return names, nil
+	}
+	selected := ""
+	for _, name := range names {
+		if strings.EqualFold(name, strings.TrimSpace(serverPart)) {
+			selected = name
+			break
+		}
+	}
+	if selected == "" {
+		return names, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), composerMCPResourceTimeout)
+	defer cancel()
+	manager := mcpsext.NewSessionClientManager(mcpsext.DefaultSessionPool(), sessionID, servers, composerMCPResourceTimeout)
+	resources, _, err := manager.ListResources(ctx, selected)
+	if err != nil {
+		return names, nil
+	}
+	items := make([]composerctx.MCPResourceItem, 0, len(resources))
+	for _, item := range resources {
+		items = append(items, composerctx.MCPResourceItem{Server: item.Server, URI: item.URI, Name: item.Name})
+	}
+	return names, items
+}
@@ -303,6 +303,7 @@ func (s *authzStore) migrate() error {
 			project_id TEXT,
 			conversation_id TEXT,
 			enabled INTEGER NOT NULL DEFAULT 1,
+			handler_json TEXT NOT NULL DEFAULT '{}',
 			decision_json TEXT NOT NULL,
 			updated_at TEXT NOT NULL
 		)`,
@@ -358,7 +359,9 @@ func (s *authzStore) migrate() error {
 			mcp_ids_json TEXT NOT NULL,
 			active_run_id TEXT,
 			created_at TEXT NOT NULL,
-			updated_at TEXT NOT NULL
+			updated_at TEXT NOT NULL,
+			origin TEXT NOT NULL DEFAULT 'hub',
+			engine_state_json TEXT NOT NULL DEFAULT '{}'
 		)`,
 		`CREATE INDEX IF NOT EXISTS idx_runtime_sessions_workspace_created ON runtime_sessions(workspace_id, created_at, id)`,
 		`CREATE INDEX IF NOT EXISTS idx_runtime_sessions_project_created ON runtime_sessions(project_id, created_at, id)`,
@@ -375,7 +378,9 @@ func (s *authzStore) migrate() error {
 			tokens_out INTEGER NOT NULL DEFAULT 0,
 			trace_id TEXT NOT NULL,
 			created_at TEXT NOT NULL,
-			updated_at TEXT NOT NULL
+			updated_at TEXT NOT NULL,
+			origin TEXT NOT NULL DEFAULT 'hub',
+			engine_state_json TEXT NOT NULL DEFAULT '{}'
 		)`,
 		`CREATE INDEX IF NOT EXISTS idx_runtime_runs_session_created ON runtime_runs(session_id, created_at, id)`,
 		`CREATE INDEX IF NOT EXISTS idx_runtime_runs_workspace_created ON runtime_runs(workspace_id, created_at, id)`,
@@ -387,7 +392,8 @@ func (s *authzStore) migrate() error {
 			type TEXT NOT NULL,
 			timestamp TEXT NOT NULL,
 			payload_json TEXT NOT NULL,
-			occurred_at TEXT NOT NULL
+			occurred_at TEXT NOT NULL,
+			origin TEXT NOT NULL DEFAULT 'hub'
 		)`,
 		`CREATE UNIQUE INDEX IF NOT EXISTS idx_runtime_run_events_session_sequence ON runtime_run_events(session_id, sequence)`,
 		`CREATE INDEX IF NOT EXISTS idx_runtime_run_events_run_sequence ON runtime_run_events(run_id, sequence)`,
@@ -447,6 +453,12 @@ func (s *authzStore) migrate() error {
 	if migrateErr := s.ensureRuntimeRunModelConfigIDColumn(); migrateErr != nil {
 		return fmt.Errorf("ensure runtime run model_config_id column: %w", migrateErr)
 	}
+	if migrateErr := s.ensureHookPolicyHandlerColumn(); migrateErr != nil {
+		return fmt.Errorf("ensure hook policy handler_json column: %w", migrateErr)
+	}
+	if migrateErr := s.ensureRuntimeEngineColumns(); migrateErr != nil {
+		return fmt.Errorf("ensure runtime engine columns: %w", migrateErr)
+	}
 	if validationErr := s.validateStrictSchema(); validationErr != nil {
 		backupPath := ""
 		if shouldBackupPreviousSchema(s.dbPath, validationErr) {
@@ -579,6 +591,53 @@ func (s *authzStore) ensureRuntimeRunModelConfigIDColumn() error {
 	return err
 }
 
+func (s *authzStore) ensureHookPolicyHandlerColumn() error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	hasHandlerJSON, err := tableHasColumn(s.db, "hook_policies", "handler_json")
+	if err != nil {
+		return err
+	}
+	if hasHandlerJSON {
+		return nil
+	}
+	_, err = s.db.Exec(`ALTER TABLE hook_policies ADD COLUMN handler_json TEXT NOT NULL DEFAULT '{}'`)
+	return err
+}
+
+// ensureRuntimeEngineColumns adds the columns that keep the agent engine's
+// own rows apart from the hub snapshot in the runtime tables.
+func (s *authzStore) ensureRuntimeEngineColumns() error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	columns := []struct {
+		table      string
+		column     string
+		definition string
+	}{
+		{table: "runtime_sessions", column: "origin", definition: "TEXT NOT NULL DEFAULT 'hub'"},
+		{table: "runtime_sessions", column: "engine_state_json", definition: "TEXT NOT NULL DEFAULT '{}'"},
+		{table: "runtime_runs", column: "origin", definition: "TEXT NOT NULL DEFAULT 'hub'"},
+		{table: "runtime_runs", column: "engine_state_json", definition: "TEXT NOT NULL DEFAULT '{}'"},
+		{table: "runtime_run_events", column: "origin", definition: "TEXT NOT NULL DEFAULT 'hub'"},
+	}
+	for _, item := range columns {
+		exists, err := tableHasColumn(s.db, item.table, item.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := s.db.Exec(`ALTER TABLE ` + item.table + ` ADD COLUMN ` + item.column + ` ` + item.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func shouldBackupPreviousSchema(dbPath string, validationErr error) bool {
 	if validationErr == nil {
 		return false
@@ -689,6 +748,7 @@ func (s *authzStore) validateStrictSchema() error {
 		{table: "hook_policies", column: "workspace_id"},
 		{table: "hook_policies", column: "project_id"},
 		{table: "hook_policies", column: "conversation_id"},
+		{table: "hook_policies", column: "handler_json"},
 		{table: "hook_execution_records", column: "decision_json"},
 	}
 	for _, field := range requiredColumns {
@@ -980,6 +1040,18 @@ func defaultHubDBPath() string {
 	return filepath.Join(configDir, defaultHubDBAppName, defaultHubDBFileName)
 }
 
+// hubCheckpointDir places runtime session checkpoints next to the hub
+// database, so they survive restarts along with the journaled sessions.
+// An in-memory database keeps no checkpoints.
+func hubCheckpointDir(dbPath string) string {
+	dbPath = strings.TrimSpace(dbPath)
+	if dbPath == "" || dbPath == ":memory:" {
+		return ""
+	}
+	name := strings.TrimSuffix(filepath.Base(dbPath), filepath.Ext(dbPath))
+	return filepath.Join(filepath.Dir(dbPath), name+"-checkpoints")
+}
+
 func boolToInt(value bool) int {
 	if value {
 		return 1
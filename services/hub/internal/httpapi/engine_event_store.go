@@ -0,0 +1,70 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goyais Team
+// SPDX-License-Identifier: MIT
+
+package httpapi
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"goyais/services/hub/internal/agent/runtime/loop"
+	transportevents "goyais/services/hub/internal/agent/transport/events"
+)
+
+// Hub settings for the agent engine's event log, read from the environment
+// like HUB_DB_PATH.
+const (
+	// hubEventStoreEnv selects the event log backend: "memory" (the
+	// default) or "sqlite", which keeps events in the hub database.
+	hubEventStoreEnv = "HUB_EVENT_STORE"
+	// hubEventRetentionMaxEventsEnv caps the events kept per session.
+	hubEventRetentionMaxEventsEnv = "HUB_EVENT_RETENTION_MAX_EVENTS"
+	// hubEventRetentionMaxAgeEnv drops events older than a Go duration
+	// such as 168h.
+	hubEventRetentionMaxAgeEnv = "HUB_EVENT_RETENTION_MAX_AGE"
+)
+
+// configureEngineEventStore applies the hub's event log settings to the
+// engine dependencies. Invalid settings are logged and left at their
+// defaults, so a typo never keeps the hub from starting.
+func configureEngineEventStore(deps *loop.Dependencies, store *authzStore) {
+	retention := transportevents.Options{}
+	if raw := strings.TrimSpace(os.Getenv(hubEventRetentionMaxEventsEnv)); raw != "" {
+		maxEvents, err := strconv.Atoi(raw)
+		if err != nil || maxEvents < 0 {
+			log.Printf("ignoring %s=%q: expected a non-negative integer", hubEventRetentionMaxEventsEnv, raw)
+		} else {
+			retention.MaxEventsPerSession = maxEvents
+		}
+	}
+	if raw := strings.TrimSpace(os.Getenv(hubEventRetentionMaxAgeEnv)); raw != "" {
+		maxAge, err := time.ParseDuration(raw)
+		if err != nil || maxAge < 0 {
+			log.Printf("ignoring %s=%q: expected a duration such as 168h", hubEventRetentionMaxAgeEnv, raw)
+		} else {
+			retention.MaxAge = maxAge
+		}
+	}
+	deps.EventRetention = retention
+
+	switch backend := strings.ToLower(strings.TrimSpace(os.Getenv(hubEventStoreEnv))); backend {
+	case "", "memory":
+	case "sqlite":
+		if store == nil || store.db == nil {
+			log.Printf("%s=sqlite needs the hub database; keeping events in memory", hubEventStoreEnv)
+			return
+		}
+		sqliteStore, err := transportevents.NewSQLiteStore(store.db, retention)
+		if err != nil {
+			log.Printf("failed to open the sqlite event store, keeping events in memory: %v", err)
+			return
+		}
+		deps.EventStore = sqliteStore
+	default:
+		log.Printf("ignoring %s=%q: expected memory or sqlite", hubEventStoreEnv, backend)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goyais Team
+// SPDX-License-Identifier: MIT
+
+package httpapi
+
+import (
+	"testing"
+	"time"
+
+	"goyais/services/hub/internal/agent/runtime/loop"
+	transportevents "goyais/services/hub/internal/agent/transport/events"
+)
+
+func TestConfigureEngineEventStoreReadsRetentionAndBackend(t *testing.T) {
+	store, err := openAuthzStore(":memory:")
+	if err != nil {
+		t.Fatalf("open authz store failed: %v", err)
+	}
+	defer func() {
+		if closeErr := store.close(); closeErr != nil {
+			t.Fatalf("close authz store failed: %v", closeErr)
+		}
+	}()
+
+	t.Setenv(hubEventRetentionMaxEventsEnv, "500")
+	t.Setenv(hubEventRetentionMaxAgeEnv, "168h")
+	t.Setenv(hubEventStoreEnv, "")
+	deps := loop.Dependencies{}
+	configureEngineEventStore(&deps, store)
+	if deps.EventStore != nil {
+		t.Fatalf("expected the in-memory default, got %T", deps.EventStore)
+	}
+	if deps.EventRetention.MaxEventsPerSession != 500 || deps.EventRetention.MaxAge != 168*time.Hour {
+		t.Fatalf("unexpected retention %#v", deps.EventRetention)
+	}
+
+	t.Setenv(hubEventStoreEnv, "sqlite")
+	deps = loop.Dependencies{}
+	configureEngineEventStore(&deps, store)
+	if _, ok := deps.EventStore.(*transportevents.SQLiteStore); !ok {
+		t.Fatalf("expected the sqlite event store, got %T", deps.EventStore)
+	}
+
+	t.Setenv(hubEventRetentionMaxEventsEnv, "many")
+	t.Setenv(hubEventRetentionMaxAgeEnv, "a week")
+	t.Setenv(hubEventStoreEnv, "postgres")
+	deps = loop.Dependencies{}
+	configureEngineEventStore(&deps, nil)
+	if deps.EventStore != nil || deps.EventRetention.MaxEventsPerSession != 0 || deps.EventRetention.MaxAge != 0 {
+		t.Fatalf("expected invalid settings to keep the defaults, got %#v", deps)
+	}
+}
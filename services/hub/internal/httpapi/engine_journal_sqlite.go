@@ -0,0 +1,378 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goyais Team
+// SPDX-License-Identifier: MIT
+
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	agentcore "goyais/services/hub/internal/agent/core"
+	eventscore "goyais/services/hub/internal/agent/core/events"
+	"goyais/services/hub/internal/agent/runtime/loop"
+)
+
+// sqliteEngineJournal persists the agent engine into the runtime tables as
+// rows with origin 'engine', next to the hub projection rows that
+// ReplaceAll rewrites.
+type sqliteEngineJournal struct {
+	db *sql.DB
+}
+
+// engineSessionState is the engine_state_json of an engine session row.
+// Closed sessions keep their row so their ID is never handed out again.
+type engineSessionState struct {
+	WorkingDir            string   `json:"working_dir"`
+	AdditionalDirectories []string `json:"additional_directories,omitempty"`
+	Closed                bool     `json:"closed,omitempty"`
+}
+
+// engineRunState is the engine_state_json of an engine run row. The input
+// carries the run's model credentials, so it is stored encrypted.
+type engineRunState struct {
+	WorkingDir            string   `json:"working_dir"`
+	AdditionalDirectories []string `json:"additional_directories,omitempty"`
+	Interrupted           bool     `json:"interrupted,omitempty"`
+	InputEncrypted        string   `json:"input_encrypted"`
+}
+
+func newSQLiteEngineJournal(db *sql.DB) *sqliteEngineJournal {
+	return &sqliteEngineJournal{db: db}
+}
+
+func (j *sqliteEngineJournal) SaveSession(ctx context.Context, record loop.SessionRecord) error {
+	if j == nil || j.db == nil {
+		return nil
+	}
+	stateJSON, err := json.Marshal(engineSessionState{
+		WorkingDir:            record.WorkingDir,
+		AdditionalDirectories: record.AdditionalDirectories,
+	})
+	if err != nil {
+		return err
+	}
+	createdAt := record.CreatedAt.UTC().Format(time.RFC3339Nano)
+	_, err = j.db.ExecContext(ctx,
+		`INSERT INTO runtime_sessions(
+			id, workspace_id, project_id, name, default_mode, model_config_id,
+			rule_ids_json, skill_ids_json, mcp_ids_json, active_run_id, created_at, updated_at,
+			origin, engine_state_json
+		) VALUES(?,'','','','','','[]','[]','[]',NULL,?,?,'engine',?)
+		ON CONFLICT(id) DO UPDATE SET
+			updated_at = excluded.updated_at,
+			engine_state_json = excluded.engine_state_json
+		WHERE runtime_sessions.origin = 'engine'`,
+		string(record.ID),
+		createdAt,
+		time.Now().UTC().Format(time.RFC3339Nano),
+		string(stateJSON),
+	)
+	return err
+}
+
+func (j *sqliteEngineJournal) SaveRun(ctx context.Context, record loop.RunRecord) error {
+	if j == nil || j.db == nil {
+		return nil
+	}
+	inputJSON, err := json.Marshal(record.Input)
+	if err != nil {
+		return err
+	}
+	encryptedInput, err := encryptSecret(string(inputJSON))
+	if err != nil {
+		return err
+	}
+	stateJSON, err := json.Marshal(engineRunState{
+		WorkingDir:            record.WorkingDir,
+		AdditionalDirectories: record.AdditionalDirectories,
+		Interrupted:           record.Interrupted,
+		InputEncrypted:        encryptedInput,
+	})
+	if err != nil {
+		return err
+	}
+	mode, modelID := "", ""
+	if record.Input.RuntimeConfig != nil {
+		mode = string(record.Input.RuntimeConfig.Tooling.PermissionMode)
+		modelID = record.Input.RuntimeConfig.Model.ModelName
+	}
+	_, err = j.db.ExecContext(ctx,
+		`INSERT INTO runtime_runs(
+			id, session_id, workspace_id, message_id, state, mode, model_id, model_config_id,
+			tokens_in, tokens_out, trace_id, created_at, updated_at, origin, engine_state_json
+		) VALUES(?,?,?,'',?,?,?,'',0,0,'',?,?,'engine',?)
+		ON CONFLICT(id) DO UPDATE SET
+			state = excluded.state,
+			updated_at = excluded.updated_at,
+			engine_state_json = excluded.engine_state_json
+		WHERE runtime_runs.origin = 'engine'`,
+		string(record.ID),
+		string(record.SessionID),
+		strings.TrimSpace(record.Input.Metadata["workspace_id"]),
+		string(record.State),
+		mode,
+		modelID,
+		record.CreatedAt.UTC().Format(time.RFC3339Nano),
+		record.UpdatedAt.UTC().Format(time.RFC3339Nano),
+		string(stateJSON),
+	)
+	return err
+}
+
+// CloseSession marks an engine session closed and drops its events, which
+// only a recovery of the session would read.
+func (j *sqliteEngineJournal) CloseSession(ctx context.Context, sessionID agentcore.SessionID) error {
+	if j == nil || j.db == nil {
+		return nil
+	}
+	tx, err := j.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE runtime_sessions SET
+			engine_state_json = json_set(engine_state_json, '$.closed', json('true')),
+			updated_at = ?
+		WHERE id = ? AND origin = 'engine'`,
+		time.Now().UTC().Format(time.RFC3339Nano),
+		string(sessionID),
+	); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM runtime_run_events WHERE session_id = ? AND origin = 'engine'`,
+		string(sessionID),
+	); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (j *sqliteEngineJournal) AppendEvent(ctx context.Context, event agentcore.EventEnvelope) error {
+	return j.AppendEvents(ctx, []agentcore.EventEnvelope{event})
+}
+
+// AppendEvents writes a batch of engine events in one transaction.
+func (j *sqliteEngineJournal) AppendEvents(ctx context.Context, events []agentcore.EventEnvelope) error {
+	if j == nil || j.db == nil || len(events) == 0 {
+		return nil
+	}
+	tx, err := j.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if err := appendEngineEvent(ctx, tx, event); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func appendEngineEvent(ctx context.Context, tx *sql.Tx, event agentcore.EventEnvelope) error {
+	payloadJSON, err := eventscore.EncodeJSON(event)
+	if err != nil {
+		return err
+	}
+	timestamp := event.Timestamp.UTC().Format(time.RFC3339Nano)
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO runtime_run_events(
+			event_id, run_id, session_id, sequence, type, timestamp, payload_json, occurred_at, origin
+		) VALUES(?,?,?,?,?,?,?,?,'engine')
+		ON CONFLICT DO NOTHING`,
+		fmt.Sprintf("engine:%s:%d", event.SessionID, event.Sequence),
+		string(event.RunID),
+		string(event.SessionID),
+		event.Sequence,
+		string(event.Type),
+		timestamp,
+		string(payloadJSON),
+		timestamp,
+	)
+	return err
+}
+
+func (j *sqliteEngineJournal) Load(ctx context.Context) (loop.JournalSnapshot, error) {
+	snapshot := loop.JournalSnapshot{}
+	if j == nil || j.db == nil {
+		return snapshot, nil
+	}
+
+	// Engine IDs end in a counter, so the longest and then greatest ID is
+	// the newest.
+	for _, last := range []struct {
+		table string
+		id    *string
+	}{
+		{table: "runtime_sessions", id: (*string)(&snapshot.LastSessionID)},
+		{table: "runtime_runs", id: (*string)(&snapshot.LastRunID)},
+	} {
+		err := j.db.QueryRowContext(ctx,
+			`SELECT id FROM `+last.table+` WHERE origin = 'engine' ORDER BY LENGTH(id) DESC, id DESC LIMIT 1`,
+		).Scan(last.id)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return snapshot, err
+		}
+	}
+
+	sessionRows, err := j.db.QueryContext(ctx,
+		`SELECT id, created_at, engine_state_json FROM runtime_sessions
+		WHERE origin = 'engine' AND COALESCE(json_extract(engine_state_json, '$.closed'), 0) = 0
+		ORDER BY created_at, id`)
+	if err != nil {
+		return snapshot, err
+	}
+	defer sessionRows.Close()
+	for sessionRows.Next() {
+		var id, createdAt, stateJSON string
+		if err := sessionRows.Scan(&id, &createdAt, &stateJSON); err != nil {
+			return snapshot, err
+		}
+		state := engineSessionState{}
+		if err := json.Unmarshal([]byte(stateJSON), &state); err != nil {
+			return snapshot, fmt.Errorf("decode engine session %s: %w", id, err)
+		}
+		snapshot.Sessions = append(snapshot.Sessions, loop.SessionRecord{
+			ID:                    agentcore.SessionID(id),
+			WorkingDir:            state.WorkingDir,
+			AdditionalDirectories: state.AdditionalDirectories,
+			CreatedAt:             parseJournalTime(createdAt),
+		})
+	}
+	if err := sessionRows.Err(); err != nil {
+		return snapshot, err
+	}
+
+	// Finished runs are only loaded when they were interrupted, since those
+	// can still be resumed.
+	runRows, err := j.db.QueryContext(ctx,
+		`SELECT r.id, r.session_id, r.state, r.created_at, r.updated_at, r.engine_state_json
+		FROM runtime_runs r
+		JOIN runtime_sessions s ON s.id = r.session_id AND s.origin = 'engine'
+		WHERE r.origin = 'engine'
+			AND COALESCE(json_extract(s.engine_state_json, '$.closed'), 0) = 0
+			AND (r.state NOT IN (?, ?, ?) OR COALESCE(json_extract(r.engine_state_json, '$.interrupted'), 0) = 1)
+		ORDER BY r.created_at, r.id`,
+		string(agentcore.RunStateCompleted),
+		string(agentcore.RunStateFailed),
+		string(agentcore.RunStateCancelled),
+	)
+	if err != nil {
+		return snapshot, err
+	}
+	defer runRows.Close()
+	for runRows.Next() {
+		var id, sessionID, runState, createdAt, updatedAt, stateJSON string
+		if err := runRows.Scan(&id, &sessionID, &runState, &createdAt, &updatedAt, &stateJSON); err != nil {
+			return snapshot, err
+		}
+		state := engineRunState{}
+		if err := json.Unmarshal([]byte(stateJSON), &state); err != nil {
+			return snapshot, fmt.Errorf("decode engine run %s: %w", id, err)
+		}
+		input := agentcore.UserInput{}
+		plainInput, err := decryptSecret(state.InputEncrypted)
+		if err != nil {
+			return snapshot, fmt.Errorf("decrypt engine run %s input: %w", id, err)
+		}
+		if plainInput != "" {
+			if err := json.Unmarshal([]byte(plainInput), &input); err != nil {
+				return snapshot, fmt.Errorf("decode engine run %s input: %w", id, err)
+			}
+		}
+		snapshot.Runs = append(snapshot.Runs, loop.RunRecord{
+			ID:                    agentcore.RunID(id),
+			SessionID:             agentcore.SessionID(sessionID),
+			Input:                 input,
+			WorkingDir:            state.WorkingDir,
+			AdditionalDirectories: state.AdditionalDirectories,
+			State:                 agentcore.RunState(runState),
+			Interrupted:           state.Interrupted,
+			CreatedAt:             parseJournalTime(createdAt),
+			UpdatedAt:             parseJournalTime(updatedAt),
+		})
+	}
+	if err := runRows.Err(); err != nil {
+		return snapshot, err
+	}
+
+	// Recover only replays the most recent events of each session.
+	eventRows, err := j.db.QueryContext(ctx,
+		`SELECT payload_json FROM (
+			SELECT payload_json, session_id, sequence,
+				ROW_NUMBER() OVER (PARTITION BY session_id ORDER BY sequence DESC) AS recent
+			FROM runtime_run_events WHERE origin = 'engine'
+		) WHERE recent <= ? ORDER BY session_id, sequence`,
+		loop.JournalReplayLimit,
+	)
+	if err != nil {
+		return snapshot, err
+	}
+	defer eventRows.Close()
+	for eventRows.Next() {
+		var payloadJSON string
+		if err := eventRows.Scan(&payloadJSON); err != nil {
+			return snapshot, err
+		}
+		event, err := eventscore.DecodeJSON([]byte(payloadJSON))
+		if err != nil {
+			return snapshot, err
+		}
+		snapshot.Events = append(snapshot.Events, event)
+	}
+	return snapshot, eventRows.Err()
+}
+
+// recoverRuntimeEngine rebuilds the engine from its journal and rebinds the
+// unfinished runs to their executions, so the projection reports interrupted
+// runs as failed and follows queued runs as they restart.
+func (s *AppState) recoverRuntimeEngine(engine *loop.Engine) {
+	if s == nil || engine == nil {
+		return
+	}
+	report, err := engine.Recover(context.Background())
+	if err != nil {
+		log.Printf("failed to recover agent engine: %v", err)
+		return
+	}
+	conversations := map[string]string{}
+	for _, run := range report.Runs {
+		executionID := strings.TrimSpace(run.Metadata["run_id"])
+		conversationID := strings.TrimSpace(run.Metadata["session_id"])
+		if executionID == "" || conversationID == "" {
+			continue
+		}
+		s.bindExecutionRunID(executionID, string(run.RunID))
+		s.bindConversationSessionID(conversationID, string(run.SessionID))
+		s.mu.Lock()
+		current, seen := s.conversationProjectionLastSeq[conversationID]
+		if !seen || run.LastSequence < current {
+			s.conversationProjectionLastSeq[conversationID] = run.LastSequence
+		}
+		s.mu.Unlock()
+		conversations[conversationID] = string(run.SessionID)
+	}
+	for conversationID, sessionID := range conversations {
+		if err := s.ensureConversationProjection(conversationID, sessionID); err != nil {
+			log.Printf("failed to resume projection for conversation %s: %v", conversationID, err)
+		}
+	}
+}
+
+func parseJournalTime(raw string) time.Time {
+	parsed, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(raw))
+	if err != nil {
+		return time.Time{}
+	}
+	return parsed
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2026 Ysmjjsy
+// Author: Goyais Team
+// SPDX-License-Identifier: MIT
+
+package httpapi
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	agentcore "goyais/services/hub/internal/agent/core"
+	eventscore "goyais/services/hub/internal/agent/core/events"
+	"goyais/services/hub/internal/agent/runtime/loop"
+)
+
+func TestSQLiteEngineJournalRoundTripSurvivesProjectionRewrite(t *testing.T) {
+	store, err := openAuthzStore(":memory:")
+	if err != nil {
+		t.Fatalf("open authz store failed: %v", err)
+	}
+	defer func() {
+		if closeErr := store.close(); closeErr != nil {
+			t.Fatalf("close authz store failed: %v", closeErr)
+		}
+	}()
+	ctx := context.Background()
+	journal := newSQLiteEngineJournal(store.db)
+	createdAt := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	if err := journal.SaveSession(ctx, loop.SessionRecord{
+		ID:         "sess_1",
+		WorkingDir: "/tmp/project",
+		CreatedAt:  createdAt,
+	}); err != nil {
+		t.Fatalf("save session failed: %v", err)
+	}
+	run := loop.RunRecord{
+		ID:        "run_1",
+		SessionID: "sess_1",
+		Input: agentcore.UserInput{
+			Text:     "hello",
+			Metadata: map[string]string{"run_id": "exec_1", "session_id": "conv_1", "workspace_id": localWorkspaceID},
+			RuntimeConfig: &agentcore.RuntimeConfig{
+				Model: agentcore.RuntimeModelConfig{ProviderName: "openai", ModelName: "gpt-5", APIKey: "sk-secret"},
+			},
+		},
+		WorkingDir: "/tmp/project",
+		State:      agentcore.RunStateRunning,
+		CreatedAt:  createdAt,
+		UpdatedAt:  createdAt,
+	}
+	if err := journal.SaveRun(ctx, run); err != nil {
+		t.Fatalf("save run failed: %v", err)
+	}
+	event := eventscore.NewEvent(eventscore.RunQueuedEventSpec, "sess_1", "run_1", 0, createdAt, agentcore.RunQueuedPayload{QueuePosition: 1})
+	if err := journal.AppendEvent(ctx, event); err != nil {
+		t.Fatalf("append event failed: %v", err)
+	}
+
+	repositories := NewSQLiteRuntimeRepositorySet(store.db)
+	if err := repositories.Sessions.ReplaceAll(ctx, nil); err != nil {
+		t.Fatalf("replace sessions failed: %v", err)
+	}
+	if err := repositories.Runs.ReplaceAll(ctx, nil); err != nil {
+		t.Fatalf("replace runs failed: %v", err)
+	}
+	if err := repositories.RunEvents.ReplaceAll(ctx, nil); err != nil {
+		t.Fatalf("replace run events failed: %v", err)
+	}
+
+	var rawState string
+	if err := store.db.QueryRow(`SELECT engine_state_json FROM runtime_runs WHERE id = 'run_1'`).Scan(&rawState); err != nil {
+		t.Fatalf("read engine run row failed: %v", err)
+	}
+	if strings.Contains(rawState, "sk-secret") {
+		t.Fatalf("expected run input to be encrypted, got %s", rawState)
+	}
+
+	snapshot, err := journal.Load(ctx)
+	if err != nil {
+		t.Fatalf("load journal failed: %v", err)
+	}
+	if len(snapshot.Sessions) != 1 || snapshot.Sessions[0].WorkingDir != "/tmp/project" {
+		t.Fatalf("unexpected sessions %#v", snapshot.Sessions)
+	}
+	if len(snapshot.Runs) != 1 {
+		t.Fatalf("unexpected runs %#v", snapshot.Runs)
+	}
+	loaded := snapshot.Runs[0]
+	if loaded.State != agentcore.RunStateRunning || loaded.Input.Metadata["run_id"] != "exec_1" ||
+		loaded.Input.RuntimeConfig == nil || loaded.Input.RuntimeConfig.Model.APIKey != "sk-secret" {
+		t.Fatalf("unexpected run %#v", loaded)
+	}
+	if len(snapshot.Events) != 1 || snapshot.Events[0].Type != agentcore.RunEventTypeRunQueued {
+		t.Fatalf("unexpected events %#v", snapshot.Events)
+	}
+
+	engine := loop.NewEngineWithDeps(loop.Dependencies{Journal: journal})
+	report, err := engine.Recover(ctx)
+	if err != nil {
+		t.Fatalf("recover engine failed: %v", err)
+	}
+	if len(report.Runs) != 1 || !report.Runs[0].Interrupted || report.Runs[0].Metadata["session_id"] != "conv_1" {
+		t.Fatalf("unexpected recovery report %#v", report)
+	}
+	engine.FlushJournal()
+	var state string
+	if err := store.db.QueryRow(`SELECT state FROM runtime_runs WHERE id = 'run_1'`).Scan(&state); err != nil {
+		t.Fatalf("read recovered run row failed: %v", err)
+	}
+	if state != string(agentcore.RunStateFailed) {
+		t.Fatalf("expected the interrupted run to be stored as failed, got %q", state)
+	}
+}
+
+func TestSQLiteEngineJournalLoadsOnlyRecentEventsPerSession(t *testing.T) {
+	store, err := openAuthzStore(":memory:")
+	if err != nil {
+		t.Fatalf("open authz store failed: %v", err)
+	}
+	defer func() {
+		if closeErr := store.close(); closeErr != nil {
+			t.Fatalf("close authz store failed: %v", closeErr)
+		}
+	}()
+	ctx := context.Background()
+	journal := newSQLiteEngineJournal(store.db)
+	createdAt := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	total := loop.JournalReplayLimit + 5
+	events := make([]agentcore.EventEnvelope, 0, total+1)
+	for sequence := 0; sequence < total; sequence++ {
+		events = append(events, eventscore.NewEvent(eventscore.RunOutputDeltaEventSpec, "sess_1", "run_1", int64(sequence), createdAt, agentcore.OutputDeltaPayload{Delta: "chunk"}))
+	}
+	events = append(events, eventscore.NewEvent(eventscore.RunQueuedEventSpec, "sess_2", "run_2", 0, createdAt, agentcore.RunQueuedPayload{QueuePosition: 1}))
+	if err := journal.AppendEvents(ctx, events); err != nil {
+		t.Fatalf("append events failed: %v", err)
+	}
+
+	snapshot, err := journal.Load(ctx)
+	if err != nil {
+		t.Fatalf("load journal failed: %v", err)
+	}
+	counts := map[agentcore.SessionID]int{}
+	var last int64
+	for _, event := range snapshot.Events {
+		counts[event.SessionID]++
+		if event.SessionID == "sess_1" {
+			last = event.Sequence
+		}
+	}
+	if counts["sess_1"] != loop.JournalReplayLimit || counts["sess_2"] != 1 {
+		t.Fatalf("unexpected event counts %#v", counts)
+	}
+	if last != int64(total-1) {
+		t.Fatalf("expected the latest sess_1 event to be kept, got sequence %d", last)
+	}
+}
+
+func TestSQLiteEngineJournalLoadsOnlyOpenSessionsAndUnfinishedRuns(t *testing.T) {
+	store, err := openAuthzStore(":memory:")
+	if err != nil {
+		t.Fatalf("open authz store failed: %v", err)
+	}
+	defer func() {
+		if closeErr := store.close(); closeErr != nil {
+			t.Fatalf("close authz store failed: %v", closeErr)
+		}
+	}()
+	ctx := context.Background()
+	journal := newSQLiteEngineJournal(store.db)
+	createdAt := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, id := range []agentcore.SessionID{"sess_1", "sess_2", "sess_10"} {
+		if err := journal.SaveSession(ctx, loop.SessionRecord{ID: id, WorkingDir: "/tmp/project", CreatedAt: createdAt}); err != nil {
+			t.Fatalf("save session %s failed: %v", id, err)
+		}
+	}
+	for _, run := range []loop.RunRecord{
+		{ID: "run_1", SessionID: "sess_1", State: agentcore.RunStateCompleted},
+		{ID: "run_2", SessionID: "sess_2", State: agentcore.RunStateRunning},
+		{ID: "run_3", SessionID: "sess_2", State: agentcore.RunStateFailed, Interrupted: true},
+		{ID: "run_12", SessionID: "sess_10", State: agentcore.RunStateQueued},
+	} {
+		run.Input = agentcore.UserInput{Text: "task"}
+		run.CreatedAt, run.UpdatedAt = createdAt, createdAt
+		if err := journal.SaveRun(ctx, run); err != nil {
+			t.Fatalf("save run %s failed: %v", run.ID, err)
+		}
+	}
+	if err := journal.AppendEvent(ctx, eventscore.NewEvent(eventscore.RunQueuedEventSpec, "sess_10", "run_12", 0, createdAt, agentcore.RunQueuedPayload{QueuePosition: 1})); err != nil {
+		t.Fatalf("append event failed: %v", err)
+	}
+	if err := journal.CloseSession(ctx, "sess_10"); err != nil {
+		t.Fatalf("close session failed: %v", err)
+	}
+
+	snapshot, err := journal.Load(ctx)
+	if err != nil {
+		t.Fatalf("load journal failed: %v", err)
+	}
+	if len(snapshot.Sessions) != 2 || snapshot.Sessions[0].ID != "sess_1" || snapshot.Sessions[1].ID != "sess_2" {
+		t.Fatalf("expected the open sessions only, got %#v", snapshot.Sessions)
+	}
+	if len(snapshot.Runs) != 2 || snapshot.Runs[0].ID != "run_2" || snapshot.Runs[1].ID != "run_3" {
+		t.Fatalf("expected the running and interrupted runs only, got %#v", snapshot.Runs)
+	}
+	if len(snapshot.Events) != 0 {
+		t.Fatalf("expected the closed session's events to be dropped, got %#v", snapshot.Events)
+	}
+	if snapshot.LastSessionID != "sess_10" || snapshot.LastRunID != "run_12" {
+		t.Fatalf("expected the newest ids to include closed sessions, got %s and %s", snapshot.LastSessionID, snapshot.LastRunID)
+	}
+}
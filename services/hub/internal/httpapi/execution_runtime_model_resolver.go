@@ -23,6 +23,7 @@ type runtimeModelConfig struct {
 	ParamsJSON    string
 	TimeoutMS     int
 	MaxModelTurns int
+	ContextWindow int
 }
 
 type runtimeMCPServerConfig struct {
@@ -91,6 +92,7 @@ func resolveRuntimeModelConfigForExecution(state *AppState, execution Execution)
 		ParamsJSON:    paramsJSON,
 		TimeoutMS:     resolveModelRequestTimeoutMS(modelSpec.Runtime),
 		MaxModelTurns: normalizeWorkspaceAgentConfig(workspaceID, workspaceAgentConfig, workspaceAgentConfig.UpdatedAt).Execution.MaxModelTurns,
+		ContextWindow: state.resolveCatalogContextWindow(workspaceID, modelSpec.Vendor, modelName),
 	}, nil
 }
 
@@ -142,7 +144,7 @@ func resolveRuntimeToolingConfigForExecution(state *AppState, execution Executio
 	if workspaceAgentConfigErr != nil {
 		return runtimeToolingConfig{}, fmt.Errorf("load workspace agent config failed: %w", workspaceAgentConfigErr)
 	}
-	return resolveRuntimeToolingConfig(
+	tooling, err := resolveRuntimeToolingConfig(
 		state,
 		workspaceID,
 		resolveExecutionPermissionModeForRuntime(state, execution),
@@ -152,6 +154,17 @@ func resolveRuntimeToolingConfigForExecution(state *AppState, execution Executio
 		projectRepoPath,
 		workspaceAgentConfig,
 	)
+	if err != nil {
+		return runtimeToolingConfig{}, err
+	}
+	// Remote MCP servers sign in with the tokens of the user who submitted
+	// the run; executions without a recorded user get none.
+	if execution.ResourceProfileSnapshot != nil {
+		for index := range tooling.MCPServers {
+			tooling.MCPServers[index].CredentialScope = execution.ResourceProfileSnapshot.MCPCredentialScope
+		}
+	}
+	return tooling, nil
 }
 
 func resolveRuntimeToolingConfig(
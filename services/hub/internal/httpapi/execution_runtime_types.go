@@ -15,8 +15,11 @@ type ExecutionUserAnswer struct {
 
 // executionControlSignal is the internal run-control command envelope.
 type executionControlSignal struct {
-	Action agentcore.ControlAction
-	Answer *ExecutionUserAnswer
+	Action   agentcore.ControlAction
+	Answer   *ExecutionUserAnswer
+	Remember agentcore.ApprovalScope
+	Mode     agentcore.PermissionMode
+	Feedback string
 }
 
 // pendingUserQuestion stores one pending user-input request for run control.
@@ -13,6 +13,7 @@ func buildExecutionResourceProfileSnapshot(
 	skillIDs []string,
 	mcpIDs []string,
 	projectFilePaths []string,
+	mcpCredentialScope string,
 	tooling runtimeToolingConfig,
 ) *ExecutionResourceProfile {
 	return &ExecutionResourceProfile{
@@ -24,6 +25,7 @@ func buildExecutionResourceProfileSnapshot(
 		ProjectFilePaths:         append([]string{}, projectFilePaths...),
 		RulesDSL:                 tooling.RulesDSL,
 		MCPServers:               toExecutionMCPServerSnapshots(tooling.MCPServers),
+		MCPCredentialScope:       mcpCredentialScope,
 		AlwaysLoadedCapabilities: toExecutionCapabilityDescriptorSnapshots(tooling.AlwaysLoadedCapabilities),
 		SearchableCapabilities:   toExecutionCapabilityDescriptorSnapshots(tooling.SearchableCapabilities),
 	}
@@ -61,7 +61,7 @@ func ConversationInputSuggestHandler(state *AppState) http.HandlerFunc {
 		}
 
 		conversationID := runtimeSessionIDFromPath(r)
-		conversation, project, projectConfig, _, ok := loadConversationInputContext(state, w, r, conversationID, "session.read")
+		conversation, project, projectConfig, session, ok := loadConversationInputContext(state, w, r, conversationID, "session.read")
 		if !ok {
 			return
 		}
@@ -106,12 +106,15 @@ func ConversationInputSuggestHandler(state *AppState) http.HandlerFunc {
 				Kind:        kind,
 			})
 		}
+		mcpServers, mcpResources := composerMCPResources(state, conversation.WorkspaceID, conversation.ID, mcpCredentialScope(conversation.WorkspaceID, session.UserID), projectConfig.MCPIDs, input.Draft, input.Cursor)
 		suggestions := composerctx.Suggest(composerctx.SuggestRequest{
-			Draft:     input.Draft,
-			Cursor:    input.Cursor,
-			Limit:     input.Limit,
-			Commands:  commands,
-			Resources: resources,
+			Draft:        input.Draft,
+			Cursor:       input.Cursor,
+			Limit:        input.Limit,
+			Commands:     commands,
+			Resources:    resources,
+			MCPServers:   mcpServers,
+			MCPResources: mcpResources,
 		})
 		response := ComposerSuggestResponse{
 			Revision:    catalog.Revision,
@@ -350,6 +353,54 @@ func ConversationInputSubmitHandler(state *AppState) http.HandlerFunc {
 		}
 
 		now := time.Now().UTC().Format(time.RFC3339)
+		msgID := "msg_" + randomHex(6)
+		execution := Execution{
+			ID:             "exec_" + randomHex(6),
+			WorkspaceID:    conversationSeed.WorkspaceID,
+			ConversationID: conversationID,
+			MessageID:      msgID,
+			Mode:           resolvedMode,
+			ModelID:        resolvedModelID,
+			ModeSnapshot:   resolvedMode,
+			ModelSnapshot:  resolvedModelSnapshot,
+			ResourceProfileSnapshot: buildExecutionResourceProfileSnapshot(
+				resolvedModelConfigID,
+				resolvedModelID,
+				resolvedRuleIDs,
+				resolvedSkillIDs,
+				resolvedMCPIDs,
+				projectFilePaths,
+				mcpCredentialScope(conversationSeed.WorkspaceID, session.UserID),
+				runtimeToolingSnapshot,
+			),
+			AgentConfigSnapshot:     toExecutionAgentConfigSnapshot(workspaceAgentConfig),
+			TokensIn:                0,
+			TokensOut:               0,
+			ProjectRevisionSnapshot: project.CurrentRevision,
+			TraceID:                 TraceIDFromContext(r.Context()),
+			CreatedAt:               now,
+			UpdatedAt:               now,
+		}
+
+		// UserPromptSubmit runs before the prompt is stored or queued, so a
+		// deny keeps it out of the conversation and an updated prompt is
+		// what the run receives.
+		promptDecision, promptPolicyID := runHookDecisionWithState(withHookExecution(r.Context(), execution), state, execution, HookEventTypeUserPromptSubmit, "", map[string]any{
+			"message_id": msgID,
+			"prompt":     promptText,
+		})
+		if promptDecision.Action == HookDecisionActionDeny {
+			appendHookExecutionRecordWithState(state, execution, HookEventTypeUserPromptSubmit, "", promptPolicyID, promptDecision)
+			syncExecutionDomainBestEffort(state)
+			WriteStandardError(w, r, http.StatusForbidden, "PROMPT_BLOCKED", firstNonEmpty(promptDecision.Reason, "prompt blocked by hook policy"), map[string]any{
+				"policy_id": promptPolicyID,
+			})
+			return
+		}
+		if updatedPrompt, _ := promptDecision.UpdatedInput["prompt"].(string); strings.TrimSpace(updatedPrompt) != "" {
+			promptText = strings.TrimSpace(updatedPrompt)
+		}
+
 		var createdExecution Execution
 		var queueState QueueState
 		nextExecutionToSubmit := ""
@@ -373,7 +424,6 @@ func ConversationInputSubmitHandler(state *AppState) http.HandlerFunc {
 		}
 
 		queueIndex := deriveNextQueueIndexLocked(state, conversationID)
-		msgID := "msg_" + randomHex(6)
 		userRole := MessageRoleUser
 		canRollback := true
 		message := ConversationMessage{
@@ -391,34 +441,8 @@ func ConversationInputSubmitHandler(state *AppState) http.HandlerFunc {
 		if conversation.ActiveExecutionID == nil {
 			executionState = RunStatePending
 		}
-		execution := Execution{
-			ID:             "exec_" + randomHex(6),
-			WorkspaceID:    conversation.WorkspaceID,
-			ConversationID: conversationID,
-			MessageID:      msgID,
-			State:          executionState,
-			Mode:           resolvedMode,
-			ModelID:        resolvedModelID,
-			ModeSnapshot:   resolvedMode,
-			ModelSnapshot:  resolvedModelSnapshot,
-			ResourceProfileSnapshot: buildExecutionResourceProfileSnapshot(
-				resolvedModelConfigID,
-				resolvedModelID,
-				resolvedRuleIDs,
-				resolvedSkillIDs,
-				resolvedMCPIDs,
-				projectFilePaths,
-				runtimeToolingSnapshot,
-			),
-			AgentConfigSnapshot:     toExecutionAgentConfigSnapshot(workspaceAgentConfig),
-			TokensIn:                0,
-			TokensOut:               0,
-			ProjectRevisionSnapshot: project.CurrentRevision,
-			QueueIndex:              queueIndex,
-			TraceID:                 TraceIDFromContext(r.Context()),
-			CreatedAt:               now,
-			UpdatedAt:               now,
-		}
+		execution.State = executionState
+		execution.QueueIndex = queueIndex
 		state.executions[execution.ID] = execution
 		state.conversationExecutionOrder[conversationID] = append(state.conversationExecutionOrder[conversationID], execution.ID)
 
@@ -510,15 +534,14 @@ func ConversationInputSubmitHandler(state *AppState) http.HandlerFunc {
 		})
 		state.mu.Unlock()
 
-		decision, matchedPolicyID := evaluateHookDecisionWithState(state, createdExecution, HookEventTypeUserPromptSubmit, "")
 		appendHookExecutionRecordAndEventWithState(
 			state,
 			createdExecution,
 			createdExecution.ID,
 			HookEventTypeUserPromptSubmit,
 			"",
-			matchedPolicyID,
-			decision,
+			promptPolicyID,
+			promptDecision,
 			map[string]any{
 				"message_id": createdExecution.MessageID,
 				"source":     "composer_input",
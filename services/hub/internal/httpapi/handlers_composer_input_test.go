@@ -1,7 +1,9 @@
 package httpapi
 
 import (
+	"encoding/json"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -209,15 +211,16 @@ func TestConversationInputSubmit_EmitsStructuredTaskEvents(t *testing.T) {
 func TestConversationInputSubmit_EmitsUserPromptSubmitHookRecord(t *testing.T) {
 	state, conversationID := seedConversationMessageValidationState(t)
 	state.mu.Lock()
-	state.hookPolicies["policy_user_prompt_submit_deny"] = HookPolicy{
-		ID:          "policy_user_prompt_submit_deny",
+	state.hookPolicies["policy_user_prompt_submit_context"] = HookPolicy{
+		ID:          "policy_user_prompt_submit_context",
 		Scope:       HookScopeGlobal,
 		Event:       HookEventTypeUserPromptSubmit,
 		HandlerType: HookHandlerTypeAgent,
 		Enabled:     true,
 		Decision: HookDecision{
-			Action: HookDecisionActionDeny,
-			Reason: "test submit hook deny",
+			Action:            HookDecisionActionAllow,
+			Reason:            "test submit hook context",
+			AdditionalContext: map[string]any{"context": "release freeze starts friday"},
 		},
 		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
 	}
@@ -245,6 +248,7 @@ func TestConversationInputSubmit_EmitsUserPromptSubmitHookRecord(t *testing.T) {
 	state.mu.RLock()
 	records := append([]HookExecutionRecord{}, state.hookExecutionRecords[conversationID]...)
 	events := append([]ExecutionEvent{}, state.executionEvents[conversationID]...)
+	storedExecution := state.executions[executionID]
 	state.mu.RUnlock()
 
 	foundHookRecord := false
@@ -252,7 +256,7 @@ func TestConversationInputSubmit_EmitsUserPromptSubmitHookRecord(t *testing.T) {
 		if record.RunID != executionID || record.Event != HookEventTypeUserPromptSubmit {
 			continue
 		}
-		if record.PolicyID != "policy_user_prompt_submit_deny" || record.Decision.Action != HookDecisionActionDeny {
+		if record.PolicyID != "policy_user_prompt_submit_context" || record.Decision.Action != HookDecisionActionAllow {
 			t.Fatalf("unexpected submit hook record: %#v", record)
 		}
 		foundHookRecord = true
@@ -266,14 +270,93 @@ func TestConversationInputSubmit_EmitsUserPromptSubmitHookRecord(t *testing.T) {
 		if event.ExecutionID != executionID || event.Type != RunEventTypeUserPromptSubmit {
 			continue
 		}
-		if strings.TrimSpace(asString(event.Payload["policy_id"])) != "policy_user_prompt_submit_deny" {
-			t.Fatalf("expected hook execution event payload policy_id=policy_user_prompt_submit_deny, got %#v", event.Payload)
+		if strings.TrimSpace(asString(event.Payload["policy_id"])) != "policy_user_prompt_submit_context" {
+			t.Fatalf("expected hook execution event payload policy_id=policy_user_prompt_submit_context, got %#v", event.Payload)
 		}
 		foundHookExecutionEvent = true
 	}
 	if !foundHookExecutionEvent {
 		t.Fatalf("expected user_prompt_submit execution event for run %s, got %#v", executionID, events)
 	}
+
+	replayed := hubHookDispatcher{state: state}.submittedPromptDecision(storedExecution)
+	if replayed.Decision != "allow" || replayed.Metadata["additional_context"] != "release freeze starts friday" {
+		t.Fatalf("expected the run to receive the submitted context, got %#v", replayed)
+	}
+}
+
+func TestConversationInputSubmit_UserPromptSubmitDenyBlocksPrompt(t *testing.T) {
+	state, conversationID := seedConversationMessageValidationState(t)
+	state.mu.Lock()
+	state.hookPolicies["policy_user_prompt_submit_deny"] = HookPolicy{
+		ID:          "policy_user_prompt_submit_deny",
+		Scope:       HookScopeGlobal,
+		Event:       HookEventTypeUserPromptSubmit,
+		HandlerType: HookHandlerTypeAgent,
+		Enabled:     true,
+		Decision: HookDecision{
+			Action: HookDecisionActionDeny,
+			Reason: "test submit hook deny",
+		},
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	messageCount := len(state.conversationMessages[conversationID])
+	executionCount := len(state.conversationExecutionOrder[conversationID])
+	state.mu.Unlock()
+
+	router := composerInputTestMux(state)
+	res := performJSONRequest(t, router, http.MethodPost, "/v1/sessions/"+conversationID+"/runs", map[string]any{
+		"raw_input": "run submit hook deny check",
+	}, nil)
+	if res.Code != http.StatusForbidden || !strings.Contains(res.Body.String(), "PROMPT_BLOCKED") || !strings.Contains(res.Body.String(), "test submit hook deny") {
+		t.Fatalf("expected submit 403 PROMPT_BLOCKED, got %d (%s)", res.Code, res.Body.String())
+	}
+
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+	if len(state.conversationMessages[conversationID]) != messageCount || len(state.conversationExecutionOrder[conversationID]) != executionCount {
+		t.Fatalf("expected the blocked prompt to stay out of the conversation")
+	}
+	records := state.hookExecutionRecords[conversationID]
+	if len(records) != 1 || records[0].PolicyID != "policy_user_prompt_submit_deny" || records[0].Decision.Action != HookDecisionActionDeny {
+		t.Fatalf("expected the blocked prompt recorded, got %#v", records)
+	}
+}
+
+func TestConversationInputSubmit_UserPromptSubmitHandlerUpdatesPrompt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"decision":"allow","updatedPrompt":"run the rewritten prompt"}`))
+	}))
+	defer server.Close()
+	state, conversationID := seedConversationMessageValidationState(t)
+	state.mu.Lock()
+	state.hookPolicies["policy_user_prompt_submit_rewrite"] = HookPolicy{
+		ID:          "policy_user_prompt_submit_rewrite",
+		Scope:       HookScopeGlobal,
+		Event:       HookEventTypeUserPromptSubmit,
+		HandlerType: HookHandlerTypeHTTP,
+		Enabled:     true,
+		Handler:     &HookHandlerConfig{URL: server.URL, TimeoutMS: 5000},
+		Decision:    HookDecision{Action: HookDecisionActionAllow},
+		UpdatedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+	state.mu.Unlock()
+
+	router := composerInputTestMux(state)
+	res := performJSONRequest(t, router, http.MethodPost, "/v1/sessions/"+conversationID+"/runs", map[string]any{
+		"raw_input": "run the original prompt",
+	}, nil)
+	if res.Code != http.StatusCreated {
+		t.Fatalf("expected submit 201, got %d (%s)", res.Code, res.Body.String())
+	}
+
+	state.mu.RLock()
+	messages := state.conversationMessages[conversationID]
+	lastMessage := messages[len(messages)-1]
+	state.mu.RUnlock()
+	if lastMessage.Content != "run the rewritten prompt" {
+		t.Fatalf("expected the updated prompt stored, got %q", lastMessage.Content)
+	}
 }
 
 func TestConversationInputSubmit_RejectsUnknownCommand(t *testing.T) {
@@ -633,6 +716,74 @@ func TestConversationInputSuggest_UsesCommandAndResourceDetails(t *testing.T) {
 	}
 }
 
+func TestConversationInputSuggest_CompletesMCPResourceURIs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := map[string]any{}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		id, hasID := payload["id"]
+		if !hasID {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		result := map[string]any{}
+		switch asString(payload["method"]) {
+		case "initialize":
+			result["protocolVersion"] = "2025-06-18"
+		case "resources/list":
+			result["resources"] = []map[string]any{{"uri": "file:///guide.md", "name": "Guide"}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": id, "result": result})
+	}))
+	defer server.Close()
+
+	state, conversationID := seedConversationMessageValidationState(t)
+	conversation := state.conversations[conversationID]
+	mcpID := "rc_mcp_docs"
+	projectConfig := state.projectConfigs[conversation.ProjectID]
+	projectConfig.MCPIDs = append(projectConfig.MCPIDs, mcpID)
+	state.projectConfigs[conversation.ProjectID] = projectConfig
+	mustSaveTestResourceConfig(t, state, ResourceConfig{
+		ID:          mcpID,
+		WorkspaceID: conversation.WorkspaceID,
+		Type:        ResourceTypeMCP,
+		Name:        "docs",
+		Enabled:     true,
+		MCP:         &McpSpec{Transport: "http", Endpoint: server.URL},
+		CreatedAt:   conversation.UpdatedAt,
+		UpdatedAt:   conversation.UpdatedAt,
+	})
+
+	router := composerInputTestMux(state)
+	for _, tc := range []struct {
+		draft string
+		want  string
+	}{
+		{draft: "read @do", want: "@docs:"},
+		{draft: "read @docs:gu", want: "@docs:file:///guide.md"},
+	} {
+		res := performJSONRequest(t, router, http.MethodPost, "/v1/sessions/"+conversationID+"/input/suggest", map[string]any{
+			"draft":  tc.draft,
+			"cursor": len(tc.draft),
+		}, nil)
+		if res.Code != http.StatusOK {
+			t.Fatalf("expected suggest 200, got %d (%s)", res.Code, res.Body.String())
+		}
+		payload := map[string]any{}
+		mustDecodeJSON(t, res.Body.Bytes(), &payload)
+		found := false
+		for _, item := range payload["suggestions"].([]any) {
+			suggestion, _ := item.(map[string]any)
+			if asString(suggestion["kind"]) == "mcp_resource" && asString(suggestion["insert_text"]) == tc.want {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("expected %q suggestion for draft %q, got %#v", tc.want, tc.draft, payload["suggestions"])
+		}
+	}
+}
+
 func setComposerProjectRepoForTest(t *testing.T, state *AppState, conversationID string) string {
 	t.Helper()
 	projectRoot := t.TempDir()
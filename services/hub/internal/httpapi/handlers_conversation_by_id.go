@@ -228,7 +228,12 @@ func ConversationByIDHandler(state *AppState) http.HandlerFunc {
 			}
 			state.mu.Unlock()
 			if configChanged && hasConfigChangeExecution {
-				decision, matchedPolicyID := evaluateHookDecisionWithState(state, configChangeExecution, HookEventTypeConfigChange, "")
+				hookPayload := map[string]any{
+					"session_id":     conversationID,
+					"changed_fields": append([]string{}, configChangedFields...),
+					"source":         "conversation_patch",
+				}
+				decision, matchedPolicyID := runHookDecisionWithState(r.Context(), state, configChangeExecution, HookEventTypeConfigChange, "", hookPayload)
 				appendHookExecutionRecordAndEventWithState(
 					state,
 					configChangeExecution,
@@ -237,11 +242,7 @@ func ConversationByIDHandler(state *AppState) http.HandlerFunc {
 					"",
 					matchedPolicyID,
 					decision,
-					map[string]any{
-						"session_id":     conversationID,
-						"changed_fields": append([]string{}, configChangedFields...),
-						"source":         "conversation_patch",
-					},
+					hookPayload,
 				)
 			}
 			syncExecutionDomainBestEffort(state)
@@ -288,6 +289,7 @@ func ConversationByIDHandler(state *AppState) http.HandlerFunc {
 			delete(state.conversationExecutionOrder, conversationID)
 			delete(state.executionEvents, conversationID)
 			delete(state.conversationEventSeq, conversationID)
+			runtimeSessionID := state.conversationSessionIDs[conversationID]
 			delete(state.conversationSessionIDs, conversationID)
 			if subscribers, ok := state.conversationEventSubs[conversationID]; ok {
 				for id := range subscribers {
@@ -299,6 +301,7 @@ func ConversationByIDHandler(state *AppState) http.HandlerFunc {
 				state.cancelExecutionBestEffort(r.Context(), executionID)
 				state.clearExecutionRuntimeMapping(executionID)
 			}
+			state.closeConversationRuntime(r.Context(), conversationID, runtimeSessionID)
 			syncExecutionDomainBestEffort(state)
 			writeJSON(w, http.StatusNoContent, map[string]any{})
 		default:
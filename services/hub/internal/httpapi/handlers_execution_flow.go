@@ -269,7 +269,11 @@ func ConversationStopHandler(state *AppState) http.HandlerFunc {
 		state.conversations[conversationID] = conversation
 		state.mu.Unlock()
 		if hasCanceledExecution {
-			decision, matchedPolicyID := evaluateHookDecisionWithState(state, canceledExecution, HookEventTypeStop, "")
+			hookPayload := map[string]any{
+				"reason": "user_stop",
+				"source": "conversation_stop",
+			}
+			decision, matchedPolicyID := runHookDecisionWithState(r.Context(), state, canceledExecution, HookEventTypeStop, "", hookPayload)
 			appendHookExecutionRecordAndEventWithState(
 				state,
 				canceledExecution,
@@ -278,10 +282,7 @@ func ConversationStopHandler(state *AppState) http.HandlerFunc {
 				"",
 				matchedPolicyID,
 				decision,
-				map[string]any{
-					"reason": "user_stop",
-					"source": "conversation_stop",
-				},
+				hookPayload,
 			)
 		}
 		syncExecutionDomainBestEffort(state)
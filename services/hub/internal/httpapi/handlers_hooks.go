@@ -3,6 +3,9 @@ package httpapi
 import (
 	"net/http"
 	"strings"
+
+	agentcore "goyais/services/hub/internal/agent/core"
+	controlplanepolicy "goyais/services/hub/internal/controlplane/policy"
 )
 
 func HooksPoliciesHandler(state *AppState) http.HandlerFunc {
@@ -24,6 +27,15 @@ func HooksPoliciesHandler(state *AppState) http.HandlerFunc {
 				err.write(w, r)
 				return
 			}
+			workspaceID := strings.TrimSpace(input.WorkspaceID)
+			if _, authErr := authorizeAction(
+				state, r, workspaceID, "admin.policies.manage",
+				authorizationResource{WorkspaceID: workspaceID, ResourceType: "hook_policy"},
+				authorizationContext{OperationType: "write", ABACRequired: true}, RoleAdmin,
+			); authErr != nil {
+				authErr.write(w, r)
+				return
+			}
 			state.mu.Lock()
 			item, upsertErr := upsertHookPolicyLocked(state, input)
 			state.mu.Unlock()
@@ -53,6 +65,90 @@ func HooksPoliciesHandler(state *AppState) http.HandlerFunc {
 	}
 }
 
+// HooksPolicySimulateHandler evaluates the stored policies against a
+// hypothetical event without recording anything. Handlers only run when the
+// request asks for it; that is live execution, marked only by "dry_run": true
+// in the payload, so simulating requires the same role as editing policies.
+// The policies are ranked by evaluateHookPolicies, the evaluator live runs
+// use, so a simulation reports the decision a real run would take.
+func HooksPolicySimulateHandler(state *AppState) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if state == nil {
+			WriteStandardError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Runtime state is unavailable", map[string]any{})
+			return
+		}
+		if r.Method != http.MethodPost {
+			WriteStandardError(w, r, http.StatusNotImplemented, "INTERNAL_NOT_IMPLEMENTED", "Route is not implemented yet", map[string]any{
+				"method": r.Method,
+				"path":   r.URL.Path,
+			})
+			return
+		}
+		input := HookPolicySimulateRequest{}
+		if err := decodeJSONBody(r, &input); err != nil {
+			err.write(w, r)
+			return
+		}
+		eventType, ok := normalizeHookEventType(input.Event)
+		if !ok {
+			WriteStandardError(w, r, http.StatusBadRequest, "VALIDATION_ERROR", "event is invalid", map[string]any{"event": strings.TrimSpace(string(input.Event))})
+			return
+		}
+		workspaceID := strings.TrimSpace(input.WorkspaceID)
+		if _, authErr := authorizeAction(
+			state, r, workspaceID, "admin.policies.manage",
+			authorizationResource{WorkspaceID: workspaceID, ResourceType: "hook_policy"},
+			authorizationContext{OperationType: "write", ABACRequired: true}, RoleAdmin,
+		); authErr != nil {
+			authErr.write(w, r)
+			return
+		}
+
+		state.mu.RLock()
+		policies := listHookPoliciesLocked(state)
+		handlers := state.hookHandlers
+		state.mu.RUnlock()
+
+		sessionID := strings.TrimSpace(input.SessionID)
+		toolName := strings.TrimSpace(input.ToolName)
+		scopeContext := controlplanepolicy.HookScopeContext{
+			WorkspaceID:      workspaceID,
+			ProjectID:        strings.TrimSpace(input.ProjectID),
+			ConversationID:   sessionID,
+			ToolName:         toolName,
+			IsLocalWorkspace: workspaceID == localWorkspaceID,
+		}
+		payload := hookHandlerPayload(Execution{WorkspaceID: workspaceID, ConversationID: sessionID}, toolName, "", input.Payload)
+		payload["project_id"] = scopeContext.ProjectID
+		payload["is_local_workspace"] = scopeContext.IsLocalWorkspace
+		if input.RunHandlers {
+			payload["dry_run"] = true
+		}
+		evaluation := evaluateHookPolicies(r.Context(), policies, handlers, scopeContext, eventType, agentcore.HookEvent{
+			Type:      agentHookEventName(eventType),
+			SessionID: agentcore.SessionID(sessionID),
+			Payload:   payload,
+		}, input.RunHandlers)
+		writeJSON(w, http.StatusOK, toHookPolicySimulateResponse(evaluation))
+	}
+}
+
+func toHookPolicySimulateResponse(evaluation hookEvaluation) HookPolicySimulateResponse {
+	metadata := toAgentHookDecision(evaluation.decision, evaluation.policyID).Metadata
+	metadata["precedence"] = hookPolicyPrecedence
+	if len(evaluation.ranking) > 0 {
+		metadata["scope_trace"] = evaluation.ranking[0].ScopeTrace
+	}
+	return HookPolicySimulateResponse{
+		Decision:        evaluation.decision.Action,
+		MatchedPolicyID: evaluation.policyID,
+		Reason:          evaluation.decision.Reason,
+		Metadata:        metadata,
+		Ranking:         evaluation.ranking,
+		Unmatched:       evaluation.unmatched,
+	}
+}
+
 func HookExecutionsHandler(state *AppState) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
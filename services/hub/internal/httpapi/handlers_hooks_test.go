@@ -2,11 +2,16 @@ package httpapi
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
+
+	agentcore "goyais/services/hub/internal/agent/core"
 )
 
 func TestHookRoutesAreRegistered(t *testing.T) {
@@ -391,3 +396,400 @@ func TestHooksPoliciesHandlerAcceptsConfigChangeEvent(t *testing.T) {
 		t.Fatalf("expected event config_change, got %#v", payload)
 	}
 }
+
+func TestHookHTTPHandlerDecisionIsPersisted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"decision":"deny","reason":"tests still failing"}`))
+	}))
+	defer server.Close()
+	store, err := openAuthzStore(":memory:")
+	if err != nil {
+		t.Fatalf("open authz store failed: %v", err)
+	}
+	defer func() {
+		if closeErr := store.close(); closeErr != nil {
+			t.Fatalf("close authz store failed: %v", closeErr)
+		}
+	}()
+
+	state := NewAppState(store)
+	handler := HooksPoliciesHandler(state)
+	body, err := json.Marshal(map[string]any{
+		"id":           "policy_stop_http",
+		"scope":        "global",
+		"event":        "stop",
+		"handler_type": "http",
+		"enabled":      true,
+		"handler": map[string]any{
+			"url":        server.URL,
+			"timeout_ms": 5000,
+		},
+		"decision": map[string]any{"action": "allow"},
+	})
+	if err != nil {
+		t.Fatalf("marshal request body failed: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/v1/hooks/policies", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d (%s)", res.Code, res.Body.String())
+	}
+
+	loaded, err := store.loadExecutionDomainSnapshot()
+	if err != nil {
+		t.Fatalf("load execution domain snapshot failed: %v", err)
+	}
+	if len(loaded.HookPolicies) != 1 || loaded.HookPolicies[0].Handler == nil || loaded.HookPolicies[0].Handler.TimeoutMS != 5000 {
+		t.Fatalf("expected handler config persisted, got %#v", loaded.HookPolicies)
+	}
+
+	execution := Execution{ID: "exec_hook_http", WorkspaceID: localWorkspaceID, ConversationID: "conv_hook_http"}
+	decision, policyID := runHookDecisionWithState(context.Background(), state, execution, HookEventTypeStop, "", nil)
+	if decision.Action != HookDecisionActionDeny || decision.Reason != "tests still failing" || policyID != "policy_stop_http" {
+		t.Fatalf("expected http handler to deny, got %#v (%s)", decision, policyID)
+	}
+	if len(decision.Handlers) != 1 || decision.Handlers[0].HandlerType != HookHandlerTypeHTTP || decision.Handlers[0].Action != HookDecisionActionDeny {
+		t.Fatalf("expected handler execution recorded, got %#v", decision.Handlers)
+	}
+
+	static, _ := evaluateHookDecisionWithState(state, execution, HookEventTypeStop, "")
+	if static.Action != HookDecisionActionAllow || len(static.Handlers) != 0 {
+		t.Fatalf("expected static evaluation to skip handlers, got %#v", static)
+	}
+
+	appendHookExecutionRecordAndEventWithState(state, execution, execution.ID, HookEventTypeStop, "", policyID, decision, nil)
+	syncExecutionDomainBestEffort(state)
+	reloaded, err := store.loadExecutionDomainSnapshot()
+	if err != nil {
+		t.Fatalf("reload execution domain snapshot failed: %v", err)
+	}
+	if len(reloaded.HookExecutionRecords) != 1 || len(reloaded.HookExecutionRecords[0].Decision.Handlers) != 1 {
+		t.Fatalf("expected handler results persisted with the record, got %#v", reloaded.HookExecutionRecords)
+	}
+}
+
+func TestHooksPoliciesHandlerRejectsCommandHandlers(t *testing.T) {
+	router := NewRouter()
+	for _, policy := range []map[string]any{
+		{"handler_type": "command", "handler": map[string]any{"command": "touch /tmp/pwned"}},
+		{"handler_type": "command", "handler": map[string]any{"timeout_ms": 1000}},
+		{"handler_type": "http", "handler": map[string]any{"url": "http://127.0.0.1:1", "command": "touch /tmp/pwned"}},
+	} {
+		policy["id"] = "policy_command"
+		policy["scope"] = "global"
+		policy["event"] = "stop"
+		policy["enabled"] = true
+		policy["decision"] = map[string]any{"action": "allow"}
+		res := performJSONRequest(t, router, http.MethodPost, "/v1/hooks/policies", policy, nil)
+		if res.Code != http.StatusBadRequest || !strings.Contains(res.Body.String(), "local settings files") {
+			t.Fatalf("expected 400 for command handler %#v, got %d (%s)", policy["handler"], res.Code, res.Body.String())
+		}
+	}
+}
+
+func TestHooksPoliciesHandlerRequiresAdmin(t *testing.T) {
+	router := NewRouter()
+	workspaceID := createRemoteWorkspace(t, router, "Remote Hook Policies", "http://127.0.0.1:9141", false)
+	policy := map[string]any{
+		"id":           "policy_remote",
+		"scope":        "global",
+		"event":        "stop",
+		"handler_type": "agent",
+		"workspace_id": workspaceID,
+		"enabled":      true,
+		"decision":     map[string]any{"action": "deny"},
+	}
+
+	res := performJSONRequest(t, router, http.MethodPost, "/v1/hooks/policies", policy, nil)
+	if res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a token, got %d (%s)", res.Code, res.Body.String())
+	}
+	developer := loginRemoteWorkspace(t, router, workspaceID, "hook_developer", "pw", RoleDeveloper, true)
+	res = performJSONRequest(t, router, http.MethodPost, "/v1/hooks/policies", policy, map[string]string{"Authorization": "Bearer " + developer})
+	if res.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a developer, got %d (%s)", res.Code, res.Body.String())
+	}
+	admin := loginRemoteWorkspace(t, router, workspaceID, "hook_admin", "pw", RoleAdmin, true)
+	res = performJSONRequest(t, router, http.MethodPost, "/v1/hooks/policies", policy, map[string]string{"Authorization": "Bearer " + admin})
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200 for an admin, got %d (%s)", res.Code, res.Body.String())
+	}
+
+	simulate := map[string]any{"event": "stop", "workspace_id": workspaceID, "run_handlers": true}
+	if res := performJSONRequest(t, router, http.MethodPost, "/v1/hooks/policies:simulate", simulate, nil); res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected simulate 401 without a token, got %d (%s)", res.Code, res.Body.String())
+	}
+	if res := performJSONRequest(t, router, http.MethodPost, "/v1/hooks/policies:simulate", simulate, map[string]string{"Authorization": "Bearer " + developer}); res.Code != http.StatusForbidden {
+		t.Fatalf("expected simulate 403 for a developer, got %d (%s)", res.Code, res.Body.String())
+	}
+	if res := performJSONRequest(t, router, http.MethodPost, "/v1/hooks/policies:simulate", simulate, map[string]string{"Authorization": "Bearer " + admin}); res.Code != http.StatusOK {
+		t.Fatalf("expected simulate 200 for an admin, got %d (%s)", res.Code, res.Body.String())
+	}
+}
+
+func TestHooksPolicySimulateRanksStoredPolicies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), `"dry_run":true`) {
+			_, _ = w.Write([]byte(`{"decision":"deny","reason":"blocked in dry run"}`))
+		}
+	}))
+	defer server.Close()
+	router := NewRouter()
+	for _, policy := range []map[string]any{
+		{
+			"id": "policy_sim_global_allow", "scope": "global", "event": "pre_tool_use", "handler_type": "agent", "enabled": true,
+			"decision": map[string]any{"action": "allow"},
+		},
+		{
+			"id": "policy_sim_project_ask", "scope": "project", "event": "pre_tool_use", "handler_type": "agent", "tool_name": "Bash",
+			"project_id": "proj_sim", "enabled": true, "decision": map[string]any{"action": "ask", "reason": "review shell"},
+		},
+		{
+			"id": "policy_sim_write_deny", "scope": "global", "event": "pre_tool_use", "handler_type": "agent", "tool_name": "Write",
+			"enabled": true, "decision": map[string]any{"action": "deny"},
+		},
+		{
+			"id": "policy_sim_http", "scope": "global", "event": "pre_tool_use", "handler_type": "http", "enabled": true,
+			"handler":  map[string]any{"url": server.URL},
+			"decision": map[string]any{"action": "allow"},
+		},
+	} {
+		res := performJSONRequest(t, router, http.MethodPost, "/v1/hooks/policies", policy, nil)
+		if res.Code != http.StatusOK {
+			t.Fatalf("expected policy upsert 200, got %d (%s)", res.Code, res.Body.String())
+		}
+	}
+
+	res := performJSONRequest(t, router, http.MethodPost, "/v1/hooks/policies:simulate", map[string]any{
+		"event":      "pre_tool_use",
+		"tool_name":  "Bash",
+		"project_id": "proj_sim",
+		"payload":    map[string]any{"input": map[string]any{"command": "rm -rf build"}},
+	}, nil)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected simulate 200, got %d (%s)", res.Code, res.Body.String())
+	}
+	payload := HookPolicySimulateResponse{}
+	mustDecodeJSON(t, res.Body.Bytes(), &payload)
+	if payload.Decision != HookDecisionActionAllow || payload.MatchedPolicyID != "policy_sim_global_allow" {
+		t.Fatalf("unexpected simulated decision %#v", payload)
+	}
+	if len(payload.Ranking) != 3 || payload.Ranking[2].PolicyID != "policy_sim_project_ask" || payload.Ranking[2].Scope != "project" || len(payload.Ranking[2].ScopeTrace) == 0 {
+		t.Fatalf("unexpected ranking %#v", payload.Ranking)
+	}
+	if len(payload.Unmatched) != 1 || payload.Unmatched[0].PolicyID != "policy_sim_write_deny" || payload.Unmatched[0].Reason != "tool" {
+		t.Fatalf("unexpected unmatched policies %#v", payload.Unmatched)
+	}
+
+	res = performJSONRequest(t, router, http.MethodPost, "/v1/hooks/policies:simulate", map[string]any{
+		"event":        "pre_tool_use",
+		"tool_name":    "Bash",
+		"run_handlers": true,
+	}, nil)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected simulate 200, got %d (%s)", res.Code, res.Body.String())
+	}
+	payload = HookPolicySimulateResponse{}
+	mustDecodeJSON(t, res.Body.Bytes(), &payload)
+	if payload.Decision != HookDecisionActionDeny || payload.Reason != "blocked in dry run" || payload.Ranking[0].Handler["decision"] != "deny" {
+		t.Fatalf("expected dry-run handler deny, got %#v", payload)
+	}
+
+	res = performJSONRequest(t, router, http.MethodPost, "/v1/hooks/policies:simulate", map[string]any{"event": "pre_tool"}, nil)
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid event 400, got %d (%s)", res.Code, res.Body.String())
+	}
+}
+
+func TestHooksPolicySimulateMatchesLiveEvaluation(t *testing.T) {
+	state := NewAppState(nil)
+	router := http.NewServeMux()
+	router.HandleFunc("/v1/hooks/policies", HooksPoliciesHandler(state))
+	router.HandleFunc("/v1/hooks/policies:simulate", HooksPolicySimulateHandler(state))
+	for _, policy := range []map[string]any{
+		{
+			"id": "policy_live_global_allow", "scope": "global", "event": "pre_tool_use", "handler_type": "agent",
+			"tool_name": "Bash", "enabled": true, "decision": map[string]any{"action": "allow"},
+		},
+		{
+			"id": "policy_live_project_deny", "scope": "project", "event": "pre_tool_use", "handler_type": "agent",
+			"tool_name": "Bash", "project_id": "proj_live", "enabled": true,
+			"decision": map[string]any{"action": "deny", "reason": "no shell here"},
+		},
+	} {
+		res := performJSONRequest(t, router, http.MethodPost, "/v1/hooks/policies", policy, nil)
+		if res.Code != http.StatusOK {
+			t.Fatalf("expected policy upsert 200, got %d (%s)", res.Code, res.Body.String())
+		}
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	state.mu.Lock()
+	state.conversations["conv_live"] = Conversation{ID: "conv_live", WorkspaceID: localWorkspaceID, ProjectID: "proj_live", CreatedAt: now, UpdatedAt: now}
+	state.mu.Unlock()
+	live, livePolicyID := evaluateHookDecisionWithState(state, Execution{ID: "exec_live", WorkspaceID: localWorkspaceID, ConversationID: "conv_live"}, HookEventTypePreToolUse, "Bash")
+
+	res := performJSONRequest(t, router, http.MethodPost, "/v1/hooks/policies:simulate", map[string]any{
+		"event":        "pre_tool_use",
+		"tool_name":    "Bash",
+		"workspace_id": localWorkspaceID,
+		"project_id":   "proj_live",
+	}, nil)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected simulate 200, got %d (%s)", res.Code, res.Body.String())
+	}
+	payload := HookPolicySimulateResponse{}
+	mustDecodeJSON(t, res.Body.Bytes(), &payload)
+	if live.Action != HookDecisionActionAllow || livePolicyID != "policy_live_global_allow" {
+		t.Fatalf("expected the global allow to win a live evaluation, got %#v from %q", live, livePolicyID)
+	}
+	if payload.Decision != live.Action || payload.MatchedPolicyID != livePolicyID {
+		t.Fatalf("expected simulation to match live evaluation %q/%q, got %#v", live.Action, livePolicyID, payload)
+	}
+	if len(payload.Ranking) != 2 || payload.Ranking[1].PolicyID != "policy_live_project_deny" || payload.Ranking[1].ScopeRank <= payload.Ranking[0].ScopeRank {
+		t.Fatalf("unexpected ranking %#v", payload.Ranking)
+	}
+}
+
+func TestHubHookDispatcherAppliesPoliciesToRuntimeEvents(t *testing.T) {
+	model := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"{\"decision\":\"deny\",\"reason\":\"no network from hooks\"}"}}]}`))
+	}))
+	defer model.Close()
+	t.Setenv("GOYAIS_AGENT_MODEL_PROVIDER", "openai")
+	t.Setenv("GOYAIS_AGENT_MODEL_ENDPOINT", model.URL)
+
+	state := NewAppState(nil)
+	now := time.Now().UTC().Format(time.RFC3339)
+	executionID := "exec_runtime_hooks"
+	state.mu.Lock()
+	state.conversations["conv_runtime_hooks"] = Conversation{
+		ID:                "conv_runtime_hooks",
+		WorkspaceID:       localWorkspaceID,
+		ActiveExecutionID: &executionID,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	state.executions[executionID] = Execution{ID: executionID, WorkspaceID: localWorkspaceID, ConversationID: "conv_runtime_hooks"}
+	state.conversationSessionIDs["conv_runtime_hooks"] = "sess_runtime_hooks"
+	state.hookPolicies["policy_write_context"] = HookPolicy{
+		ID:          "policy_write_context",
+		Scope:       HookScopeGlobal,
+		Event:       HookEventTypePreToolUse,
+		HandlerType: HookHandlerTypeAgent,
+		ToolName:    "Write",
+		Enabled:     true,
+		Decision: HookDecision{
+			Action:            HookDecisionActionAllow,
+			UpdatedInput:      map[string]any{"path": "safe.txt"},
+			AdditionalContext: map[string]any{"context": "writes are reviewed"},
+		},
+	}
+	state.hookPolicies["policy_bash_prompt"] = HookPolicy{
+		ID:          "policy_bash_prompt",
+		Scope:       HookScopeGlobal,
+		Event:       HookEventTypePreToolUse,
+		HandlerType: HookHandlerTypePrompt,
+		ToolName:    "Bash",
+		Enabled:     true,
+		Handler:     &HookHandlerConfig{Prompt: "Deny network commands."},
+		Decision:    HookDecision{Action: HookDecisionActionAllow},
+	}
+	state.mu.Unlock()
+
+	dispatcher := hubHookDispatcher{state: state}
+	write, err := dispatcher.Dispatch(context.Background(), agentcore.HookEvent{
+		Type:      "PreToolUse",
+		SessionID: "sess_runtime_hooks",
+		RunID:     "run_1",
+		Payload:   map[string]any{"tool_name": "Write", "call_id": "call_write"},
+	})
+	if err != nil {
+		t.Fatalf("dispatch write event: %v", err)
+	}
+	if write.Decision != "allow" || write.MatchedPolicyID != "policy_write_context" {
+		t.Fatalf("unexpected write decision %#v", write)
+	}
+	if updated, _ := write.Metadata["updated_input"].(map[string]any); updated["path"] != "safe.txt" || write.Metadata["additional_context"] != "writes are reviewed" {
+		t.Fatalf("expected policy input and context in metadata, got %#v", write.Metadata)
+	}
+
+	bash, err := dispatcher.Dispatch(context.Background(), agentcore.HookEvent{
+		Type:      "PreToolUse",
+		SessionID: "sess_runtime_hooks",
+		RunID:     "run_1",
+		Payload:   map[string]any{"tool_name": "Bash", "call_id": "call_bash"},
+	})
+	if err != nil {
+		t.Fatalf("dispatch bash event: %v", err)
+	}
+	if bash.Decision != "deny" || bash.Metadata["reason"] != "no network from hooks" {
+		t.Fatalf("expected the prompt handler to deny, got %#v", bash)
+	}
+
+	other, err := dispatcher.Dispatch(context.Background(), agentcore.HookEvent{
+		Type:      "PreToolUse",
+		SessionID: "sess_unknown",
+		Payload:   map[string]any{"tool_name": "Bash"},
+	})
+	if err != nil || other.Decision != "allow" {
+		t.Fatalf("expected events of unknown sessions to pass, got %#v (%v)", other, err)
+	}
+
+	state.mu.RLock()
+	records := append([]HookExecutionRecord(nil), state.hookExecutionRecords["conv_runtime_hooks"]...)
+	state.mu.RUnlock()
+	if len(records) != 2 || records[0].RunID != executionID || records[1].PolicyID != "policy_bash_prompt" {
+		t.Fatalf("expected both evaluations recorded for the execution, got %#v", records)
+	}
+}
+
+func TestHubHookDispatcherAppliesMCPServerRequestPolicies(t *testing.T) {
+	if eventType, ok := hubHookEventType("MCPServerRequest"); !ok || eventType != HookEventTypeMCPServerRequest {
+		t.Fatalf("expected MCPServerRequest to map to %q, got %q", HookEventTypeMCPServerRequest, eventType)
+	}
+	if name := agentHookEventName(HookEventTypeMCPServerRequest); name != "MCPServerRequest" {
+		t.Fatalf("expected mcp_server_request to map back to MCPServerRequest, got %q", name)
+	}
+
+	state := NewAppState(nil)
+	now := time.Now().UTC().Format(time.RFC3339)
+	executionID := "exec_mcp_hooks"
+	state.mu.Lock()
+	state.conversations["conv_mcp_hooks"] = Conversation{
+		ID:                "conv_mcp_hooks",
+		WorkspaceID:       localWorkspaceID,
+		ActiveExecutionID: &executionID,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	state.executions[executionID] = Execution{ID: executionID, WorkspaceID: localWorkspaceID, ConversationID: "conv_mcp_hooks"}
+	state.conversationSessionIDs["conv_mcp_hooks"] = "sess_mcp_hooks"
+	state.hookPolicies["policy_no_sampling"] = HookPolicy{
+		ID:          "policy_no_sampling",
+		Scope:       HookScopeGlobal,
+		Event:       HookEventTypeMCPServerRequest,
+		HandlerType: HookHandlerTypeCommand,
+		ToolName:    "mcp__docs__sampling",
+		Enabled:     true,
+		Decision:    HookDecision{Action: HookDecisionActionDeny, Reason: "servers may not sample"},
+	}
+	state.mu.Unlock()
+
+	decision, err := hubHookDispatcher{state: state}.Dispatch(context.Background(), agentcore.HookEvent{
+		Type:      "MCPServerRequest",
+		SessionID: "sess_mcp_hooks",
+		RunID:     "run_1",
+		Payload:   map[string]any{"tool_name": "mcp__docs__sampling", "server": "docs", "method": "sampling/createMessage"},
+	})
+	if err != nil {
+		t.Fatalf("dispatch mcp server request: %v", err)
+	}
+	if decision.Decision != "deny" || decision.MatchedPolicyID != "policy_no_sampling" || decision.Metadata["reason"] != "servers may not sample" {
+		t.Fatalf("expected the policy to deny the server request, got %#v", decision)
+	}
+}
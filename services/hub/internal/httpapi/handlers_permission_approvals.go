@@ -0,0 +1,197 @@
+package httpapi
+
+import (
+	"net/http"
+	"strings"
+
+	agentcore "goyais/services/hub/internal/agent/core"
+	"goyais/services/hub/internal/agent/policy/approval"
+)
+
+// PermissionApprovalListResponse lists remembered "always allow" approvals
+// that apply to one session.
+type PermissionApprovalListResponse struct {
+	SessionID        string                    `json:"session_id"`
+	RuntimeSessionID string                    `json:"runtime_session_id,omitempty"`
+	Items            []approval.RememberedRule `json:"items"`
+}
+
+// ConversationPermissionApprovalsHandler lists the user, project and session
+// approvals remembered for a session.
+func ConversationPermissionApprovalsHandler(state *AppState) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			WriteStandardError(w, r, http.StatusNotImplemented, "INTERNAL_NOT_IMPLEMENTED", "Route is not implemented yet", map[string]any{
+				"method": r.Method, "path": r.URL.Path,
+			})
+			return
+		}
+		approvalCtx, ok := loadPermissionApprovalContext(state, w, r, "session.read", "read")
+		if !ok {
+			return
+		}
+		conversation, runtimeSessionID := approvalCtx.conversation, approvalCtx.runtimeSessionID
+		items, err := approvalCtx.store.List(runtimeSessionID)
+		if err != nil {
+			WriteStandardError(w, r, http.StatusInternalServerError, "PERMISSION_APPROVALS_READ_FAILED", "Failed to read remembered approvals", map[string]any{
+				"session_id": conversation.ID,
+				"error":      err.Error(),
+			})
+			return
+		}
+		if runtimeSessionID == "" {
+			items = withoutSessionApprovals(items)
+		}
+		writeJSON(w, http.StatusOK, PermissionApprovalListResponse{
+			SessionID:        conversation.ID,
+			RuntimeSessionID: runtimeSessionID,
+			Items:            items,
+		})
+	}
+}
+
+// ConversationPermissionApprovalRevokeHandler removes one remembered approval.
+func ConversationPermissionApprovalRevokeHandler(state *AppState) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			WriteStandardError(w, r, http.StatusNotImplemented, "INTERNAL_NOT_IMPLEMENTED", "Route is not implemented yet", map[string]any{
+				"method": r.Method, "path": r.URL.Path,
+			})
+			return
+		}
+		input := PermissionApprovalRevokeRequest{}
+		if err := decodeJSONBody(r, &input); err != nil {
+			err.write(w, r)
+			return
+		}
+		scope, scopeErr := agentcore.ParseApprovalScope(input.Scope)
+		rule := strings.TrimSpace(input.Rule)
+		if scopeErr != nil || scope == "" || rule == "" {
+			WriteStandardError(w, r, http.StatusBadRequest, "VALIDATION_ERROR", "scope must be one of session/project/user and rule is required", map[string]any{
+				"scope": input.Scope,
+				"rule":  input.Rule,
+			})
+			return
+		}
+		approvalCtx, ok := loadPermissionApprovalContext(state, w, r, "session.write", "write")
+		if !ok {
+			return
+		}
+		conversation, runtimeSessionID := approvalCtx.conversation, approvalCtx.runtimeSessionID
+		if scope == agentcore.ApprovalScopeUser {
+			if authErr := authorizeUserApprovalScope(state, r, conversation.WorkspaceID); authErr != nil {
+				authErr.write(w, r)
+				return
+			}
+		}
+		removed, err := approvalCtx.store.Revoke(scope, runtimeSessionID, rule)
+		if err != nil && !(scope == agentcore.ApprovalScopeSession && runtimeSessionID == "") {
+			WriteStandardError(w, r, http.StatusInternalServerError, "PERMISSION_APPROVALS_WRITE_FAILED", "Failed to revoke remembered approval", map[string]any{
+				"session_id": conversation.ID,
+				"error":      err.Error(),
+			})
+			return
+		}
+		if !removed {
+			WriteStandardError(w, r, http.StatusNotFound, "PERMISSION_APPROVAL_NOT_FOUND", "Remembered approval does not exist", map[string]any{
+				"session_id": conversation.ID,
+				"scope":      string(scope),
+				"rule":       rule,
+			})
+			return
+		}
+		if state.authz != nil {
+			_ = state.authz.appendAudit(conversation.WorkspaceID, approvalCtx.session.UserID, "session.write", "conversation", conversation.ID, "success", map[string]any{
+				"operation": "revoke_permission_approval",
+				"scope":     string(scope),
+				"rule":      rule,
+			}, TraceIDFromContext(r.Context()))
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"ok":    true,
+			"scope": string(scope),
+			"rule":  rule,
+		})
+	}
+}
+
+type permissionApprovalContext struct {
+	conversation     Conversation
+	session          Session
+	store            approval.RuleStore
+	runtimeSessionID string
+}
+
+func loadPermissionApprovalContext(
+	state *AppState,
+	w http.ResponseWriter,
+	r *http.Request,
+	permission string,
+	operationType string,
+) (permissionApprovalContext, bool) {
+	conversationID := runtimeSessionIDFromPath(r)
+	conversation, exists := loadExecutionFlowConversationSeed(r.Context(), state, conversationID)
+	if !exists {
+		WriteStandardError(w, r, http.StatusNotFound, "CONVERSATION_NOT_FOUND", "Conversation does not exist", map[string]any{"session_id": conversationID})
+		return permissionApprovalContext{}, false
+	}
+	session, authErr := authorizeAction(
+		state,
+		r,
+		conversation.WorkspaceID,
+		permission,
+		authorizationResource{WorkspaceID: conversation.WorkspaceID},
+		authorizationContext{OperationType: operationType},
+	)
+	if authErr != nil {
+		authErr.write(w, r)
+		return permissionApprovalContext{}, false
+	}
+	state.mu.RLock()
+	runtimeSessionID := strings.TrimSpace(state.conversationSessionIDs[conversation.ID])
+	state.mu.RUnlock()
+	return permissionApprovalContext{
+		conversation:     conversation,
+		session:          session,
+		store:            approval.NewRuleStore(resolveProjectRepoPathFromConversation(state, conversation), "").WithSessions(state.runtimeSessionApprovals()),
+		runtimeSessionID: runtimeSessionID,
+	}, true
+}
+
+// runtimeSessionApprovals returns the session approvals the engine keeps in
+// memory, or nil when the engine does not remember any.
+func (s *AppState) runtimeSessionApprovals() *approval.SessionRules {
+	provider, ok := s.runtimeEngine.(interface {
+		SessionApprovals() *approval.SessionRules
+	})
+	if !ok {
+		return nil
+	}
+	return provider.SessionApprovals()
+}
+
+// authorizeUserApprovalScope requires admin rights to change approvals in
+// the user scope: they live in the hub host's user settings and apply to
+// every project served from it.
+func authorizeUserApprovalScope(state *AppState, r *http.Request, workspaceID string) *apiError {
+	_, authErr := authorizeAction(
+		state,
+		r,
+		workspaceID,
+		"admin.permissions.manage",
+		authorizationResource{WorkspaceID: workspaceID},
+		authorizationContext{OperationType: "write", ABACRequired: true},
+		RoleAdmin,
+	)
+	return authErr
+}
+
+func withoutSessionApprovals(items []approval.RememberedRule) []approval.RememberedRule {
+	out := make([]approval.RememberedRule, 0, len(items))
+	for _, item := range items {
+		if item.Scope != agentcore.ApprovalScopeSession {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,97 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	agentcore "goyais/services/hub/internal/agent/core"
+	"goyais/services/hub/internal/agent/policy/approval"
+)
+
+func TestConversationPermissionApprovalsListAndRevoke(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	state := NewAppState(nil)
+	now := "2026-03-01T00:00:00Z"
+	projectID := "proj_permission_approvals"
+	conversationID := "conv_permission_approvals"
+	repoPath := t.TempDir()
+	state.mu.Lock()
+	state.projects[projectID] = Project{
+		ID:          projectID,
+		WorkspaceID: localWorkspaceID,
+		Name:        "Permission Approvals Project",
+		RepoPath:    repoPath,
+		DefaultMode: PermissionModeDefault,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	state.conversations[conversationID] = Conversation{
+		ID:          conversationID,
+		WorkspaceID: localWorkspaceID,
+		ProjectID:   projectID,
+		Name:        "Permission Approvals Conversation",
+		QueueState:  QueueStateIdle,
+		DefaultMode: PermissionModeDefault,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	state.conversationSessionIDs[conversationID] = "sess_runtime_1"
+	state.mu.Unlock()
+
+	store := approval.NewRuleStore(repoPath, "").WithSessions(state.runtimeSessionApprovals())
+	if _, err := store.Remember(agentcore.ApprovalScopeProject, "", []string{"Bash(go test:*)"}); err != nil {
+		t.Fatalf("remember project rule failed: %v", err)
+	}
+	if _, err := store.Remember(agentcore.ApprovalScopeSession, "sess_runtime_1", []string{"Bash(npm run:*)"}); err != nil {
+		t.Fatalf("remember session rule failed: %v", err)
+	}
+	if _, err := store.Remember(agentcore.ApprovalScopeSession, "sess_other", []string{"Bash(make:*)"}); err != nil {
+		t.Fatalf("remember other session rule failed: %v", err)
+	}
+
+	listReq := httptest.NewRequest(http.MethodGet, "/v1/sessions/"+conversationID+"/permissions/approvals", nil)
+	listReq.SetPathValue("session_id", conversationID)
+	listRecorder := httptest.NewRecorder()
+	ConversationPermissionApprovalsHandler(state).ServeHTTP(listRecorder, listReq)
+	if listRecorder.Code != http.StatusOK {
+		t.Fatalf("expected list 200, got %d (%s)", listRecorder.Code, listRecorder.Body.String())
+	}
+	listed := PermissionApprovalListResponse{}
+	if err := json.Unmarshal(listRecorder.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("decode list response failed: %v", err)
+	}
+	if listed.RuntimeSessionID != "sess_runtime_1" || len(listed.Items) != 2 {
+		t.Fatalf("expected project and own session rules, got %#v", listed)
+	}
+	if listed.Items[0].Rule != "Bash(go test:*)" || listed.Items[1].Rule != "Bash(npm run:*)" || listed.Items[1].SessionID != "sess_runtime_1" {
+		t.Fatalf("unexpected listed rules %#v", listed.Items)
+	}
+
+	revoke := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/sessions/"+conversationID+"/permissions/approvals:revoke", strings.NewReader(body))
+		req.SetPathValue("session_id", conversationID)
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		ConversationPermissionApprovalRevokeHandler(state).ServeHTTP(recorder, req)
+		return recorder
+	}
+	if recorder := revoke(`{"scope":"session","rule":"Bash(npm run:*)"}`); recorder.Code != http.StatusOK {
+		t.Fatalf("expected revoke 200, got %d (%s)", recorder.Code, recorder.Body.String())
+	}
+	if recorder := revoke(`{"scope":"session","rule":"Bash(npm run:*)"}`); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected second revoke 404, got %d (%s)", recorder.Code, recorder.Body.String())
+	}
+	if recorder := revoke(`{"scope":"global","rule":"Bash(ls)"}`); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid scope 400, got %d (%s)", recorder.Code, recorder.Body.String())
+	}
+	remaining, err := store.List("")
+	if err != nil {
+		t.Fatalf("list remaining rules failed: %v", err)
+	}
+	if len(remaining) != 2 || remaining[1].SessionID != "sess_other" {
+		t.Fatalf("expected other session rule to survive, got %#v", remaining)
+	}
+}
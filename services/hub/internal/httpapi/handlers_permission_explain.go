@@ -0,0 +1,181 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	agentcore "goyais/services/hub/internal/agent/core"
+	"goyais/services/hub/internal/agent/policy"
+	"goyais/services/hub/internal/agent/policy/rulesdsl"
+	sandboxpolicy "goyais/services/hub/internal/agent/policy/sandbox"
+	"goyais/services/hub/internal/agent/tools/executor"
+	controlplanepolicy "goyais/services/hub/internal/controlplane/policy"
+)
+
+// PermissionExplainResponse is the dry-run permission trace for one session.
+type PermissionExplainResponse struct {
+	SessionID string `json:"session_id"`
+	executor.Explanation
+	RuleConfigIDs   []string              `json:"rule_config_ids"`
+	RuleDiagnostics []rulesdsl.Diagnostic `json:"rule_diagnostics"`
+}
+
+// ConversationPermissionExplainHandler runs a hypothetical tool call through
+// the session's hook policies, sandbox heuristics, rules DSL and permission
+// mode without executing it.
+func ConversationPermissionExplainHandler(state *AppState) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			WriteStandardError(w, r, http.StatusNotImplemented, "INTERNAL_NOT_IMPLEMENTED", "Route is not implemented yet", map[string]any{
+				"method": r.Method, "path": r.URL.Path,
+			})
+			return
+		}
+
+		conversationID := runtimeSessionIDFromPath(r)
+		conversation, exists := loadExecutionFlowConversationSeed(r.Context(), state, conversationID)
+		if !exists {
+			WriteStandardError(w, r, http.StatusNotFound, "CONVERSATION_NOT_FOUND", "Conversation does not exist", map[string]any{"session_id": conversationID})
+			return
+		}
+		_, authErr := authorizeAction(
+			state,
+			r,
+			conversation.WorkspaceID,
+			"session.read",
+			authorizationResource{WorkspaceID: conversation.WorkspaceID},
+			authorizationContext{OperationType: "read"},
+		)
+		if authErr != nil {
+			authErr.write(w, r)
+			return
+		}
+
+		input := PermissionExplainRequest{}
+		if err := decodeJSONBody(r, &input); err != nil {
+			err.write(w, r)
+			return
+		}
+		toolName := strings.TrimSpace(input.ToolName)
+		if toolName == "" {
+			WriteStandardError(w, r, http.StatusBadRequest, "VALIDATION_ERROR", "tool_name is required", map[string]any{})
+			return
+		}
+		mode := NormalizePermissionMode(string(conversation.DefaultMode))
+		if strings.TrimSpace(input.Mode) != "" {
+			parsed, ok := ParsePermissionMode(input.Mode)
+			if !ok {
+				WriteStandardError(w, r, http.StatusBadRequest, "VALIDATION_ERROR", "mode is invalid", map[string]any{"mode": input.Mode})
+				return
+			}
+			mode = parsed
+		}
+		toolInput := cloneMapAny(input.Input)
+		if len(input.Input) == 0 {
+			toolInput = executor.ExplainInput(toolName, input.Arguments)
+		}
+
+		ruleIDs := sanitizeIDList(conversation.RuleIDs)
+		rulesDSL, err := resolveMergedRuleDSLForRuntime(state, conversation.WorkspaceID, ruleIDs)
+		if err != nil {
+			WriteStandardError(w, r, http.StatusInternalServerError, "RESOURCE_CONFIG_READ_FAILED", "Failed to resolve session rules", map[string]any{"session_id": conversationID})
+			return
+		}
+		rules, diagnostics := rulesdsl.Lint(strings.Split(rulesDSL, "\n"))
+
+		pipeline := executor.NewPipeline(executor.Dependencies{
+			HookDispatcher: sessionHookPolicyDispatcher{
+				state:     state,
+				execution: Execution{WorkspaceID: conversation.WorkspaceID, ConversationID: conversation.ID},
+			},
+			SandboxGate:    executor.NewSandboxGateFromEvaluator(sandboxpolicy.NewEvaluator(nil)),
+			PermissionGate: policy.NewGateFromRules(rules),
+		})
+		explanation, err := pipeline.Explain(r.Context(), executor.ExecuteSingleRequest{
+			Call:        executor.ToolCall{Name: toolName, Input: toolInput},
+			SessionMode: string(mode),
+			ToolContext: executor.ToolContext{WorkingDir: resolveProjectRepoPathFromConversation(state, conversation)},
+		})
+		if err != nil {
+			WriteStandardError(w, r, http.StatusBadRequest, "PERMISSION_EXPLAIN_FAILED", err.Error(), map[string]any{"session_id": conversationID})
+			return
+		}
+		writeJSON(w, http.StatusOK, PermissionExplainResponse{
+			SessionID:       conversation.ID,
+			Explanation:     explanation,
+			RuleConfigIDs:   ruleIDs,
+			RuleDiagnostics: diagnostics,
+		})
+	}
+}
+
+// sessionHookPolicyDispatcher evaluates workspace hook policies for one
+// session and reports how scope resolution filtered them.
+type sessionHookPolicyDispatcher struct {
+	state     *AppState
+	execution Execution
+}
+
+func (d sessionHookPolicyDispatcher) Dispatch(_ context.Context, event agentcore.HookEvent) (agentcore.HookDecision, error) {
+	toolName, _ := event.Payload["tool_name"].(string)
+	toolName = strings.TrimSpace(toolName)
+	decision, policyID := evaluateHookDecisionWithState(d.state, d.execution, HookEventTypePreToolUse, toolName)
+	metadata := map[string]any{
+		"scope_trace": d.scopeTrace(toolName),
+		"precedence":  hookPolicyPrecedence,
+	}
+	if reason := strings.TrimSpace(decision.Reason); reason != "" {
+		metadata["reason"] = reason
+	}
+	if len(decision.UpdatedInput) > 0 {
+		metadata["updated_input"] = cloneMapAny(decision.UpdatedInput)
+	}
+	action := string(decision.Action)
+	if action == "" {
+		action = string(HookDecisionActionAllow)
+	}
+	return agentcore.HookDecision{
+		Decision:        action,
+		MatchedPolicyID: policyID,
+		Metadata:        metadata,
+	}, nil
+}
+
+// scopeTrace lists every enabled pre-tool policy for the tool and whether it
+// applies in this session's workspace, project and session scope.
+func (d sessionHookPolicyDispatcher) scopeTrace(toolName string) []map[string]any {
+	if d.state == nil {
+		return []map[string]any{}
+	}
+	scopeContext := controlplanepolicy.HookScopeContext{
+		WorkspaceID:      d.execution.WorkspaceID,
+		ConversationID:   d.execution.ConversationID,
+		ToolName:         toolName,
+		IsLocalWorkspace: strings.TrimSpace(d.execution.WorkspaceID) == localWorkspaceID,
+	}
+	d.state.mu.RLock()
+	policies := listHookPoliciesLocked(d.state)
+	if conversation, ok := d.state.conversations[d.execution.ConversationID]; ok {
+		scopeContext.ProjectID = conversation.ProjectID
+	}
+	d.state.mu.RUnlock()
+
+	trace := make([]map[string]any, 0, len(policies))
+	for _, item := range policies {
+		if !item.Enabled || item.Event != HookEventTypePreToolUse {
+			continue
+		}
+		if tool := strings.TrimSpace(item.ToolName); tool != "" && !strings.EqualFold(tool, toolName) {
+			continue
+		}
+		resolved := controlplanepolicy.ResolveHookPolicies(toControlPlaneHookPolicies([]HookPolicy{item}), scopeContext)
+		trace = append(trace, map[string]any{
+			"policy_id": item.ID,
+			"scope":     string(item.Scope),
+			"action":    string(item.Decision.Action),
+			"in_scope":  len(resolved) > 0,
+		})
+	}
+	return trace
+}
@@ -0,0 +1,105 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConversationPermissionExplainHandlerTracesEveryLayer(t *testing.T) {
+	state := NewAppState(nil)
+	now := "2026-03-01T00:00:00Z"
+	projectID := "proj_permission_explain"
+	conversationID := "conv_permission_explain"
+	if _, err := saveWorkspaceResourceConfig(state, ResourceConfig{
+		ID:          "rc_rule_explain",
+		WorkspaceID: localWorkspaceID,
+		Type:        ResourceTypeRule,
+		Name:        "Shell rules",
+		Enabled:     true,
+		Rule:        &RuleSpec{Content: "allow Bash(go test:*)\ndeny Bash(rm:*)\nalow Bash(ls)"},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}); err != nil {
+		t.Fatalf("save rule config failed: %v", err)
+	}
+	state.mu.Lock()
+	state.projects[projectID] = Project{
+		ID:          projectID,
+		WorkspaceID: localWorkspaceID,
+		Name:        "Permission Explain Project",
+		RepoPath:    t.TempDir(),
+		DefaultMode: PermissionModeDefault,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	state.conversations[conversationID] = Conversation{
+		ID:          conversationID,
+		WorkspaceID: localWorkspaceID,
+		ProjectID:   projectID,
+		Name:        "Permission Explain Conversation",
+		QueueState:  QueueStateIdle,
+		DefaultMode: PermissionModeAcceptEdits,
+		RuleIDs:     []string{"rc_rule_explain"},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	state.hookPolicies["hook_bash_ask"] = HookPolicy{
+		ID:       "hook_bash_ask",
+		Scope:    HookScopeGlobal,
+		Event:    HookEventTypePreToolUse,
+		ToolName: "Bash",
+		Enabled:  true,
+		Decision: HookDecision{Action: HookDecisionActionAsk, Reason: "shell needs review"},
+	}
+	state.mu.Unlock()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/sessions/"+conversationID+"/permissions:explain", strings.NewReader(`{"tool_name":"Bash","arguments":"go test ./... && rm -rf dist"}`))
+	req.SetPathValue("session_id", conversationID)
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	ConversationPermissionExplainHandler(state).ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected explain 200, got %d (%s)", recorder.Code, recorder.Body.String())
+	}
+	payload := struct {
+		Decision  string `json:"decision"`
+		DecidedBy string `json:"decided_by"`
+		Mode      string `json:"mode"`
+		Layers    []struct {
+			Layer       string         `json:"layer"`
+			Decision    string         `json:"decision"`
+			MatchedRule string         `json:"matched_rule"`
+			Trace       map[string]any `json:"trace"`
+		} `json:"layers"`
+		RuleDiagnostics []struct {
+			Line       int    `json:"line"`
+			Suggestion string `json:"suggestion"`
+		} `json:"rule_diagnostics"`
+	}{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("decode explain response failed: %v", err)
+	}
+	if payload.Decision != "deny" || payload.DecidedBy != "permission" || payload.Mode != "acceptEdits" {
+		t.Fatalf("unexpected final decision %#v", payload)
+	}
+	if len(payload.Layers) != 3 {
+		t.Fatalf("expected three layers, got %#v", payload.Layers)
+	}
+	hooks := payload.Layers[0]
+	if hooks.Decision != "ask" || hooks.MatchedRule != "hook_bash_ask" {
+		t.Fatalf("unexpected hook layer %#v", hooks)
+	}
+	if trace, _ := hooks.Trace["scope_trace"].([]any); len(trace) != 1 {
+		t.Fatalf("expected hook scope trace, got %#v", hooks.Trace)
+	}
+	if permission := payload.Layers[2]; permission.Decision != "deny" || permission.MatchedRule != "deny Bash(rm:*)" {
+		t.Fatalf("unexpected permission layer %#v", permission)
+	}
+	if len(payload.RuleDiagnostics) != 1 || payload.RuleDiagnostics[0].Line != 3 || payload.RuleDiagnostics[0].Suggestion != `did you mean "allow"?` {
+		t.Fatalf("unexpected rule diagnostics %#v", payload.RuleDiagnostics)
+	}
+}
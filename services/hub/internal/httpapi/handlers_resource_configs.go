@@ -342,6 +342,17 @@ func isValidURLString(raw string) bool {
 	return strings.TrimSpace(parsed.Host) != ""
 }
 
+func isValidWebSocketURLString(raw string) bool {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	if parsed.Scheme != "ws" && parsed.Scheme != "wss" {
+		return false
+	}
+	return strings.TrimSpace(parsed.Host) != ""
+}
+
 func matchesResourceConfigQuery(item ResourceConfig, query string) bool {
 	lowerQuery := strings.ToLower(strings.TrimSpace(query))
 	if lowerQuery == "" {
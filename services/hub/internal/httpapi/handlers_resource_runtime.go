@@ -46,7 +46,7 @@ func ResourceConfigConnectHandler(state *AppState) http.HandlerFunc {
 		}
 		workspaceID := strings.TrimSpace(r.PathValue("workspace_id"))
 		configID := strings.TrimSpace(r.PathValue("config_id"))
-		_, authErr := authorizeAction(state, r, workspaceID, "mcp.connect", authorizationResource{WorkspaceID: workspaceID, ResourceType: "mcp"}, authorizationContext{OperationType: "write", ABACRequired: true}, RoleAdmin, RoleApprover, RoleDeveloper)
+		session, authErr := authorizeAction(state, r, workspaceID, "mcp.connect", authorizationResource{WorkspaceID: workspaceID, ResourceType: "mcp"}, authorizationContext{OperationType: "write", ABACRequired: true}, RoleAdmin, RoleApprover, RoleDeveloper)
 		if authErr != nil {
 			authErr.write(w, r)
 			return
@@ -62,18 +62,8 @@ func ResourceConfigConnectHandler(state *AppState) http.HandlerFunc {
 			return
 		}
 
-		result := connectMCPConfig(config)
-		next := config
-		next.MCP.Status = result.Status
-		next.MCP.Tools = append([]string{}, result.Tools...)
-		next.MCP.LastConnectedAt = result.ConnectedAt
-		if result.ErrorCode != nil {
-			next.MCP.LastError = result.Message
-		} else {
-			next.MCP.LastError = ""
-		}
-		next.UpdatedAt = nowUTC()
-		if _, err := saveWorkspaceResourceConfig(state, next); err != nil {
+		result := connectMCPConfig(config, mcpCredentialScope(workspaceID, session.UserID))
+		if err := saveMCPConnectResult(state, config, result); err != nil {
 			WriteStandardError(w, r, http.StatusInternalServerError, "MCP_CONNECT_PERSIST_FAILED", "Failed to persist mcp status", map[string]any{})
 			return
 		}
@@ -82,6 +72,60 @@ func ResourceConfigConnectHandler(state *AppState) http.HandlerFunc {
 	}
 }
 
+// ResourceConfigAuthorizeHandler starts the OAuth login of a remote MCP
+// config and reconnects it in the background once the user has approved.
+func ResourceConfigAuthorizeHandler(state *AppState) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			WriteStandardError(w, r, http.StatusNotImplemented, "INTERNAL_NOT_IMPLEMENTED", "Route is not implemented yet", map[string]any{"method": r.Method, "path": r.URL.Path})
+			return
+		}
+		workspaceID := strings.TrimSpace(r.PathValue("workspace_id"))
+		configID := strings.TrimSpace(r.PathValue("config_id"))
+		session, authErr := authorizeAction(state, r, workspaceID, "mcp.connect", authorizationResource{WorkspaceID: workspaceID, ResourceType: "mcp"}, authorizationContext{OperationType: "write", ABACRequired: true}, RoleAdmin, RoleApprover, RoleDeveloper)
+		if authErr != nil {
+			authErr.write(w, r)
+			return
+		}
+
+		config, exists, err := loadWorkspaceResourceConfigRaw(state, workspaceID, configID)
+		if err != nil || !exists {
+			WriteStandardError(w, r, http.StatusNotFound, "RESOURCE_CONFIG_NOT_FOUND", "Resource config does not exist", map[string]any{"config_id": configID})
+			return
+		}
+		if config.Type != ResourceTypeMCP || config.MCP == nil {
+			WriteStandardError(w, r, http.StatusBadRequest, "MCP_CONFIG_REQUIRED", "resource config is not mcp type", map[string]any{"config_id": configID})
+			return
+		}
+		if !isRemoteMCPTransport(config.MCP.Transport) {
+			WriteStandardError(w, r, http.StatusBadRequest, "MCP_REMOTE_TRANSPORT_REQUIRED", "only http, http_sse and ws mcp configs can be authorized", map[string]any{"config_id": configID})
+			return
+		}
+
+		result := authorizeMCPConfig(state, config, mcpCredentialScope(workspaceID, session.UserID))
+		appendResourceTestLog(state, workspaceID, configID, "mcp_authorize", result.Status, 0, result.ErrorCode, result.Message)
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// saveMCPConnectResult records the outcome of a connect on config.
+func saveMCPConnectResult(state *AppState, config ResourceConfig, result McpConnectResult) error {
+	next := config
+	mcp := *config.MCP
+	next.MCP = &mcp
+	next.MCP.Status = result.Status
+	next.MCP.Tools = append([]string{}, result.Tools...)
+	next.MCP.LastConnectedAt = result.ConnectedAt
+	if result.ErrorCode != nil {
+		next.MCP.LastError = result.Message
+	} else {
+		next.MCP.LastError = ""
+	}
+	next.UpdatedAt = nowUTC()
+	_, err := saveWorkspaceResourceConfig(state, next)
+	return err
+}
+
 func MCPExportHandler(state *AppState) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
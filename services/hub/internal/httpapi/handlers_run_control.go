@@ -12,8 +12,11 @@ import (
 )
 
 type runControlRequest struct {
-	Action string                 `json:"action"`
-	Answer *runControlAnswerInput `json:"answer,omitempty"`
+	Action   string                 `json:"action"`
+	Answer   *runControlAnswerInput `json:"answer,omitempty"`
+	Remember string                 `json:"remember,omitempty"`
+	Mode     string                 `json:"mode,omitempty"`
+	Feedback string                 `json:"feedback,omitempty"`
 }
 
 type runControlAnswerInput struct {
@@ -49,6 +52,29 @@ func RunControlHandler(state *AppState) http.HandlerFunc {
 			})
 			return
 		}
+		remember, rememberErr := agentcore.ParseApprovalScope(input.Remember)
+		if rememberErr != nil || (remember != "" && action != agentcore.ControlActionApprove) {
+			WriteStandardError(w, r, http.StatusBadRequest, "VALIDATION_ERROR", "remember must be one of session/project/user and requires action=approve", map[string]any{
+				"action":   input.Action,
+				"remember": input.Remember,
+			})
+			return
+		}
+		mode := PermissionMode(strings.TrimSpace(input.Mode))
+		if mode != "" && (action != agentcore.ControlActionApprove || (mode != PermissionModeDefault && mode != PermissionModeAcceptEdits)) {
+			WriteStandardError(w, r, http.StatusBadRequest, "VALIDATION_ERROR", "mode must be one of default/acceptEdits and requires action=approve", map[string]any{
+				"action": input.Action,
+				"mode":   input.Mode,
+			})
+			return
+		}
+		feedback := strings.TrimSpace(input.Feedback)
+		if feedback != "" && action != agentcore.ControlActionDeny {
+			WriteStandardError(w, r, http.StatusBadRequest, "VALIDATION_ERROR", "feedback requires action=deny", map[string]any{
+				"action": input.Action,
+			})
+			return
+		}
 		var answerPayload *ExecutionUserAnswer
 		if action == agentcore.ControlActionAnswer {
 			if input.Answer == nil {
@@ -91,6 +117,12 @@ func RunControlHandler(state *AppState) http.HandlerFunc {
 			authErr.write(w, r)
 			return
 		}
+		if remember == agentcore.ApprovalScopeUser {
+			if authErr := authorizeUserApprovalScope(state, r, executionSeed.WorkspaceID); authErr != nil {
+				authErr.write(w, r)
+				return
+			}
+		}
 		conversationSeed, hasConversationSeed := loadRunControlConversationSeed(r.Context(), state, executionSeed.ConversationID)
 
 		now := time.Now().UTC().Format(time.RFC3339)
@@ -148,6 +180,18 @@ func RunControlHandler(state *AppState) http.HandlerFunc {
 			return
 		}
 
+		// A plan review is the only approval that can switch modes or take
+		// feedback; tool approvals are a plain yes or no.
+		if (mode != "" || feedback != "") && !pendingApprovalIsPlanLocked(state, execution) {
+			state.mu.Unlock()
+			WriteStandardError(w, r, http.StatusConflict, "RUN_CONTROL_STATE_CONFLICT", "mode and feedback require a pending plan approval", map[string]any{
+				"run_id": runID,
+				"state":  execution.State,
+				"action": action,
+			})
+			return
+		}
+
 		previousState := execution.State
 		desiredState := mapCoreStateToRunState(machine.State(), execution.State)
 
@@ -171,6 +215,9 @@ func RunControlHandler(state *AppState) http.HandlerFunc {
 				desiredState = RunStateExecuting
 				actionCopy := action
 				controlSignalAction = &actionCopy
+				if mode != "" {
+					conversation.DefaultMode = mode
+				}
 				appendExecutionEventLocked(state, ExecutionEvent{
 					ExecutionID:    execution.ID,
 					ConversationID: execution.ConversationID,
@@ -179,9 +226,11 @@ func RunControlHandler(state *AppState) http.HandlerFunc {
 					Type:           RunEventTypeThinkingDelta,
 					Timestamp:      now,
 					Payload: map[string]any{
-						"stage":  "approval_resolved",
-						"action": string(action),
-						"source": "run_control",
+						"stage":    "approval_resolved",
+						"action":   string(action),
+						"remember": string(remember),
+						"mode":     string(mode),
+						"source":   "run_control",
 					},
 				})
 			}
@@ -210,9 +259,10 @@ func RunControlHandler(state *AppState) http.HandlerFunc {
 					Type:           RunEventTypeThinkingDelta,
 					Timestamp:      now,
 					Payload: map[string]any{
-						"stage":  "approval_denied",
-						"action": string(action),
-						"source": "run_control",
+						"stage":    "approval_denied",
+						"action":   string(action),
+						"feedback": feedback,
+						"source":   "run_control",
 					},
 				})
 				activeID := execution.ID
@@ -379,7 +429,11 @@ func RunControlHandler(state *AppState) http.HandlerFunc {
 		state.conversations[conversation.ID] = conversation
 		state.mu.Unlock()
 		if action == agentcore.ControlActionStop || (action == agentcore.ControlActionDeny && previousState != RunStateConfirming) {
-			decision, matchedPolicyID := evaluateHookDecisionWithState(state, execution, HookEventTypeStop, "")
+			hookPayload := map[string]any{
+				"action": string(action),
+				"source": "run_control",
+			}
+			decision, matchedPolicyID := runHookDecisionWithState(r.Context(), state, execution, HookEventTypeStop, "", hookPayload)
 			appendHookExecutionRecordAndEventWithState(
 				state,
 				execution,
@@ -388,17 +442,17 @@ func RunControlHandler(state *AppState) http.HandlerFunc {
 				"",
 				matchedPolicyID,
 				decision,
-				map[string]any{
-					"action": string(action),
-					"source": "run_control",
-				},
+				hookPayload,
 			)
 		}
 		syncExecutionDomainBestEffort(state)
 		if controlSignalAction != nil {
 			state.controlExecutionBestEffort(r.Context(), execution.ID, executionControlSignal{
-				Action: *controlSignalAction,
-				Answer: controlSignalAnswer,
+				Action:   *controlSignalAction,
+				Answer:   controlSignalAnswer,
+				Remember: remember,
+				Mode:     agentcore.PermissionMode(mode),
+				Feedback: feedback,
 			})
 		}
 		if cancelExecutionID != "" {
@@ -417,8 +471,11 @@ func RunControlHandler(state *AppState) http.HandlerFunc {
 				execution.ID,
 				"success",
 				map[string]any{
-					"action": string(action),
-					"run_id": execution.ID,
+					"action":   string(action),
+					"run_id":   execution.ID,
+					"remember": string(remember),
+					"mode":     string(mode),
+					"feedback": feedback,
 				},
 				TraceIDFromContext(r.Context()),
 			)
@@ -433,6 +490,23 @@ func RunControlHandler(state *AppState) http.HandlerFunc {
 	}
 }
 
+// pendingApprovalIsPlanLocked reports whether the run is waiting on a plan
+// review, that is whether its latest approval request carries a plan.
+func pendingApprovalIsPlanLocked(state *AppState, execution Execution) bool {
+	if execution.State != RunStateConfirming {
+		return false
+	}
+	events := state.executionEvents[execution.ConversationID]
+	for index := len(events) - 1; index >= 0; index-- {
+		item := events[index]
+		if item.ExecutionID != execution.ID || strings.TrimSpace(asStringValue(item.Payload["stage"])) != "run_approval_needed" {
+			continue
+		}
+		return item.Payload["plan"] != nil
+	}
+	return false
+}
+
 func loadRunControlExecutionSeed(ctx context.Context, state *AppState, runID string) (Execution, bool) {
 	normalizedRunID := strings.TrimSpace(runID)
 	if state == nil || normalizedRunID == "" {
@@ -193,6 +193,157 @@ func TestRunControlEndpoint_DenyConfirmingRunResumesExecution(t *testing.T) {
 	}
 }
 
+func TestRunControlEndpoint_ApprovePlanSwitchesConversationMode(t *testing.T) {
+	state := NewAppState(nil)
+	handler := RunControlHandler(state)
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	conversationID := "conv_plan_" + randomHex(4)
+	executionID := "exec_plan_" + randomHex(4)
+	activeExecutionID := executionID
+
+	state.mu.Lock()
+	state.conversations[conversationID] = Conversation{
+		ID:                conversationID,
+		WorkspaceID:       localWorkspaceID,
+		ProjectID:         "proj_" + randomHex(4),
+		Name:              "Plan Review",
+		QueueState:        QueueStateRunning,
+		DefaultMode:       PermissionModePlan,
+		ModelConfigID:     "rc_model_test",
+		ActiveExecutionID: &activeExecutionID,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	state.executions[executionID] = Execution{
+		ID:             executionID,
+		WorkspaceID:    localWorkspaceID,
+		ConversationID: conversationID,
+		MessageID:      "msg_" + randomHex(4),
+		State:          RunStateConfirming,
+		Mode:           PermissionModePlan,
+		ModelID:        "gpt-5.3",
+		ModeSnapshot:   PermissionModePlan,
+		ModelSnapshot:  ModelSnapshot{ModelID: "gpt-5.3"},
+		QueueIndex:     0,
+		TraceID:        "tr_" + randomHex(4),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	state.conversationExecutionOrder[conversationID] = []string{executionID}
+	state.executionEvents[conversationID] = []ExecutionEvent{{
+		ExecutionID:    executionID,
+		ConversationID: conversationID,
+		Type:           RunEventTypeThinkingDelta,
+		Timestamp:      now,
+		Payload:        map[string]any{"stage": "run_approval_needed", "name": "ExitPlanMode", "plan": map[string]any{"id": "plan_call_1"}},
+	}}
+	state.mu.Unlock()
+
+	for _, body := range []string{`{"action":"approve","mode":"plan"}`, `{"action":"approve","mode":"bypassPermissions"}`, `{"action":"deny","mode":"acceptEdits"}`, `{"action":"approve","feedback":"why"}`} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/runs/"+executionID+"/control", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.SetPathValue("run_id", executionID)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		if res.Code != http.StatusBadRequest {
+			t.Fatalf("expected %s to be rejected, got %d (%s)", body, res.Code, res.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/runs/"+executionID+"/control", strings.NewReader(`{"action":"approve","mode":"acceptEdits"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.SetPathValue("run_id", executionID)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected plan approval 200, got %d (%s)", res.Code, res.Body.String())
+	}
+
+	state.mu.RLock()
+	conversation := state.conversations[conversationID]
+	events := append([]ExecutionEvent{}, state.executionEvents[conversationID]...)
+	state.mu.RUnlock()
+	if conversation.DefaultMode != PermissionModeAcceptEdits {
+		t.Fatalf("expected conversation mode acceptEdits after plan approval, got %q", conversation.DefaultMode)
+	}
+	found := false
+	for _, event := range events {
+		if event.Payload["stage"] == "approval_resolved" && event.Payload["mode"] == string(PermissionModeAcceptEdits) {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected approval_resolved event carrying the next mode, got %#v", events)
+	}
+}
+
+func TestRunControlEndpoint_ToolApprovalIgnoresModeAndFeedback(t *testing.T) {
+	state := NewAppState(nil)
+	handler := RunControlHandler(state)
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	conversationID := "conv_tool_" + randomHex(4)
+	executionID := "exec_tool_" + randomHex(4)
+	activeExecutionID := executionID
+
+	state.mu.Lock()
+	state.conversations[conversationID] = Conversation{
+		ID:                conversationID,
+		WorkspaceID:       localWorkspaceID,
+		ProjectID:         "proj_" + randomHex(4),
+		Name:              "Tool Approval",
+		QueueState:        QueueStateRunning,
+		DefaultMode:       PermissionModeDefault,
+		ModelConfigID:     "rc_model_test",
+		ActiveExecutionID: &activeExecutionID,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	state.executions[executionID] = Execution{
+		ID:             executionID,
+		WorkspaceID:    localWorkspaceID,
+		ConversationID: conversationID,
+		MessageID:      "msg_" + randomHex(4),
+		State:          RunStateConfirming,
+		Mode:           PermissionModeDefault,
+		ModelID:        "gpt-5.3",
+		ModeSnapshot:   PermissionModeDefault,
+		ModelSnapshot:  ModelSnapshot{ModelID: "gpt-5.3"},
+		QueueIndex:     0,
+		TraceID:        "tr_" + randomHex(4),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	state.conversationExecutionOrder[conversationID] = []string{executionID}
+	state.executionEvents[conversationID] = []ExecutionEvent{{
+		ExecutionID:    executionID,
+		ConversationID: conversationID,
+		Type:           RunEventTypeThinkingDelta,
+		Timestamp:      now,
+		Payload:        map[string]any{"stage": "run_approval_needed", "name": "Bash", "input": map[string]any{"command": "make"}},
+	}}
+	state.mu.Unlock()
+
+	for _, body := range []string{`{"action":"approve","mode":"acceptEdits"}`, `{"action":"deny","feedback":"use go test"}`} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/runs/"+executionID+"/control", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.SetPathValue("run_id", executionID)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		if res.Code != http.StatusConflict {
+			t.Fatalf("expected %s to be rejected for a tool approval, got %d (%s)", body, res.Code, res.Body.String())
+		}
+	}
+
+	state.mu.RLock()
+	conversation := state.conversations[conversationID]
+	state.mu.RUnlock()
+	if conversation.DefaultMode != PermissionModeDefault {
+		t.Fatalf("tool approval must not change the conversation mode, got %q", conversation.DefaultMode)
+	}
+}
+
 func TestRunControlEndpoint_AnswerAwaitingInputTransitionsToExecuting(t *testing.T) {
 	state := NewAppState(nil)
 	handler := RunControlHandler(state)
@@ -745,3 +896,62 @@ func TestRunControlEndpoint_UsesRepositoryWhenExecutionMapMissing(t *testing.T)
 		t.Fatalf("expected run control events to be emitted")
 	}
 }
+
+func TestRunControlEndpoint_RememberUserRequiresAdmin(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	router := NewRouter()
+	workspaceID := createRemoteWorkspace(t, router, "Remote Run Control Remember", "http://127.0.0.1:9124", false)
+	token := loginRemoteWorkspace(t, router, workspaceID, "run_control_remember_user", "pw", RoleDeveloper, true)
+	authHeaders := map[string]string{"Authorization": "Bearer " + token}
+
+	projectRes := performJSONRequest(t, router, http.MethodPost, "/v1/projects/import", map[string]any{
+		"workspace_id":   workspaceID,
+		"directory_path": t.TempDir(),
+	}, authHeaders)
+	if projectRes.Code != http.StatusCreated {
+		t.Fatalf("expected import project 201, got %d (%s)", projectRes.Code, projectRes.Body.String())
+	}
+	projectPayload := map[string]any{}
+	mustDecodeJSON(t, projectRes.Body.Bytes(), &projectPayload)
+	projectID := projectPayload["id"].(string)
+	modelConfigID := createModelResourceConfigForTest(t, router, workspaceID, authHeaders, "OpenAI", "gpt-5.3")
+	bindProjectConfigWithModelForTest(t, router, projectID, modelConfigID, authHeaders)
+
+	conversationRes := performJSONRequest(t, router, http.MethodPost, "/v1/projects/"+projectID+"/sessions", map[string]any{
+		"workspace_id": workspaceID,
+		"name":         "RunControlRememberConv",
+	}, authHeaders)
+	if conversationRes.Code != http.StatusCreated {
+		t.Fatalf("expected create conversation 201, got %d (%s)", conversationRes.Code, conversationRes.Body.String())
+	}
+	conversationPayload := map[string]any{}
+	mustDecodeJSON(t, conversationRes.Body.Bytes(), &conversationPayload)
+	conversationID := conversationPayload["id"].(string)
+
+	runRes := performJSONRequest(t, router, http.MethodPost, "/v1/sessions/"+conversationID+"/runs", map[string]any{
+		"raw_input":       "first",
+		"model_config_id": modelConfigID,
+	}, authHeaders)
+	if runRes.Code != http.StatusCreated {
+		t.Fatalf("expected run 201, got %d (%s)", runRes.Code, runRes.Body.String())
+	}
+	runPayload := map[string]any{}
+	mustDecodeJSON(t, runRes.Body.Bytes(), &runPayload)
+	runID := runPayload["run"].(map[string]any)["id"].(string)
+
+	controlRes := performJSONRequest(t, router, http.MethodPost, "/v1/runs/"+runID+"/control", map[string]any{
+		"action":   "approve",
+		"remember": "user",
+	}, authHeaders)
+	if controlRes.Code != http.StatusForbidden {
+		t.Fatalf("expected remember=user to require admin, got %d (%s)", controlRes.Code, controlRes.Body.String())
+	}
+
+	revokeRes := performJSONRequest(t, router, http.MethodPost, "/v1/sessions/"+conversationID+"/permissions/approvals:revoke", map[string]any{
+		"scope": "user",
+		"rule":  "Read",
+	}, authHeaders)
+	if revokeRes.Code != http.StatusForbidden {
+		t.Fatalf("expected revoking a user approval to require admin, got %d (%s)", revokeRes.Code, revokeRes.Body.String())
+	}
+}
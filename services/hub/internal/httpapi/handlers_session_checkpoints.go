@@ -0,0 +1,102 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	agentcore "goyais/services/hub/internal/agent/core"
+)
+
+// SessionCheckpoint is one file checkpoint the runtime took before a Write
+// or Edit call of the session.
+type SessionCheckpoint struct {
+	CheckpointID string   `json:"checkpoint_id"`
+	Reason       string   `json:"reason,omitempty"`
+	CreatedAt    string   `json:"created_at"`
+	Paths        []string `json:"paths"`
+	SizeBytes    int64    `json:"size_bytes"`
+}
+
+// SessionCheckpointListResponse is the checkpoint timeline of one session,
+// oldest first.
+type SessionCheckpointListResponse struct {
+	SessionID        string              `json:"session_id"`
+	RuntimeSessionID string              `json:"runtime_session_id,omitempty"`
+	Items            []SessionCheckpoint `json:"items"`
+}
+
+// ConversationCheckpointsHandler lists the file checkpoints of a session's
+// runtime session for the desktop timeline.
+func ConversationCheckpointsHandler(state *AppState) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			WriteStandardError(w, r, http.StatusNotImplemented, "INTERNAL_NOT_IMPLEMENTED", "Route is not implemented yet", map[string]any{
+				"method": r.Method, "path": r.URL.Path,
+			})
+			return
+		}
+		conversationID := runtimeSessionIDFromPath(r)
+		conversation, exists := loadExecutionFlowConversationSeed(r.Context(), state, conversationID)
+		if !exists {
+			WriteStandardError(w, r, http.StatusNotFound, "CONVERSATION_NOT_FOUND", "Conversation does not exist", map[string]any{"session_id": conversationID})
+			return
+		}
+		if _, authErr := authorizeAction(
+			state,
+			r,
+			conversation.WorkspaceID,
+			"session.read",
+			authorizationResource{WorkspaceID: conversation.WorkspaceID},
+			authorizationContext{OperationType: "read"},
+		); authErr != nil {
+			authErr.write(w, r)
+			return
+		}
+		state.mu.RLock()
+		runtimeSessionID := strings.TrimSpace(state.conversationSessionIDs[conversation.ID])
+		state.mu.RUnlock()
+
+		response := SessionCheckpointListResponse{
+			SessionID:        conversation.ID,
+			RuntimeSessionID: runtimeSessionID,
+			Items:            []SessionCheckpoint{},
+		}
+		if runtimeSessionID == "" {
+			writeJSON(w, http.StatusOK, response)
+			return
+		}
+		infos, err := state.runtimeCheckpoints(r.Context(), runtimeSessionID)
+		if err != nil && !errors.Is(err, agentcore.ErrSessionNotFound) {
+			WriteStandardError(w, r, http.StatusInternalServerError, "CHECKPOINTS_READ_FAILED", "Failed to read session checkpoints", map[string]any{
+				"session_id": conversation.ID,
+				"error":      err.Error(),
+			})
+			return
+		}
+		for _, info := range infos {
+			response.Items = append(response.Items, SessionCheckpoint{
+				CheckpointID: string(info.ID),
+				Reason:       info.Reason,
+				CreatedAt:    info.CreatedAt.UTC().Format(time.RFC3339Nano),
+				Paths:        append([]string{}, info.Paths...),
+				SizeBytes:    info.SizeBytes,
+			})
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// runtimeCheckpoints returns the checkpoint timeline the engine keeps for a
+// runtime session, or none when the engine keeps no checkpoints.
+func (s *AppState) runtimeCheckpoints(ctx context.Context, runtimeSessionID string) ([]agentcore.CheckpointInfo, error) {
+	lister, ok := s.runtimeEngine.(interface {
+		Checkpoints(ctx context.Context, sessionID string) ([]agentcore.CheckpointInfo, error)
+	})
+	if !ok {
+		return nil, nil
+	}
+	return lister.Checkpoints(ctx, runtimeSessionID)
+}
@@ -0,0 +1,109 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	agentcore "goyais/services/hub/internal/agent/core"
+)
+
+type checkpointListingEngine struct {
+	agentcore.Engine
+	infos map[string][]agentcore.CheckpointInfo
+}
+
+func (e checkpointListingEngine) Checkpoints(_ context.Context, sessionID string) ([]agentcore.CheckpointInfo, error) {
+	infos, ok := e.infos[sessionID]
+	if !ok {
+		return nil, agentcore.ErrSessionNotFound
+	}
+	return infos, nil
+}
+
+func TestConversationCheckpointsListsRuntimeTimeline(t *testing.T) {
+	state := NewAppState(nil)
+	now := "2026-03-01T00:00:00Z"
+	conversationID := "conv_checkpoints"
+	state.mu.Lock()
+	state.projects["proj_checkpoints"] = Project{
+		ID:          "proj_checkpoints",
+		WorkspaceID: localWorkspaceID,
+		Name:        "Checkpoints Project",
+		RepoPath:    t.TempDir(),
+		DefaultMode: PermissionModeDefault,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	state.conversations[conversationID] = Conversation{
+		ID:          conversationID,
+		WorkspaceID: localWorkspaceID,
+		ProjectID:   "proj_checkpoints",
+		Name:        "Checkpoints Conversation",
+		QueueState:  QueueStateIdle,
+		DefaultMode: PermissionModeDefault,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	state.mu.Unlock()
+	createdAt := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	state.runtimeEngine = checkpointListingEngine{infos: map[string][]agentcore.CheckpointInfo{
+		"sess_runtime_1": {{
+			ID:        "cp_1",
+			SessionID: "sess_runtime_1",
+			Reason:    "Write notes.txt",
+			CreatedAt: createdAt,
+			Paths:     []string{"/repo/notes.txt"},
+			SizeBytes: 6,
+		}},
+	}}
+
+	list := func() SessionCheckpointListResponse {
+		req := httptest.NewRequest(http.MethodGet, "/v1/sessions/"+conversationID+"/checkpoints", nil)
+		req.SetPathValue("session_id", conversationID)
+		recorder := httptest.NewRecorder()
+		ConversationCheckpointsHandler(state).ServeHTTP(recorder, req)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected list 200, got %d (%s)", recorder.Code, recorder.Body.String())
+		}
+		response := SessionCheckpointListResponse{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("decode list response failed: %v", err)
+		}
+		return response
+	}
+
+	if listed := list(); listed.RuntimeSessionID != "" || len(listed.Items) != 0 {
+		t.Fatalf("expected an empty timeline before the first run, got %#v", listed)
+	}
+
+	state.mu.Lock()
+	state.conversationSessionIDs[conversationID] = "sess_runtime_1"
+	state.mu.Unlock()
+	listed := list()
+	if listed.RuntimeSessionID != "sess_runtime_1" || len(listed.Items) != 1 {
+		t.Fatalf("expected one checkpoint, got %#v", listed)
+	}
+	item := listed.Items[0]
+	if item.CheckpointID != "cp_1" || item.Reason != "Write notes.txt" || item.CreatedAt != createdAt.Format(time.RFC3339Nano) || item.SizeBytes != 6 {
+		t.Fatalf("unexpected checkpoint %#v", item)
+	}
+
+	state.mu.Lock()
+	state.conversationSessionIDs[conversationID] = "sess_closed"
+	state.mu.Unlock()
+	if listed := list(); len(listed.Items) != 0 {
+		t.Fatalf("expected a closed runtime session to list nothing, got %#v", listed)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/sessions/conv_missing/checkpoints", nil)
+	req.SetPathValue("session_id", "conv_missing")
+	recorder := httptest.NewRecorder()
+	ConversationCheckpointsHandler(state).ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected missing conversation 404, got %d (%s)", recorder.Code, recorder.Body.String())
+	}
+}
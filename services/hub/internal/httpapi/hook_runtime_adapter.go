@@ -5,14 +5,247 @@
 package httpapi
 
 import (
+	"context"
+	"fmt"
+	"sort"
 	"strings"
 	"time"
+	"unicode"
 
+	agentcore "goyais/services/hub/internal/agent/core"
+	agenthooks "goyais/services/hub/internal/agent/extensions/hooks"
+	"goyais/services/hub/internal/agent/runtime/loop"
 	controlplanepolicy "goyais/services/hub/internal/controlplane/policy"
 	runtimehooks "goyais/services/hub/internal/runtime/hooks"
 )
 
+// hubHookHandlers returns the handler executors for hub hook policies. Hub
+// policies come from the HTTP API, so command handlers are left out; they
+// only run from local settings files. Prompt and agent handlers use the
+// model of the execution the event belongs to.
+func hubHookHandlers(state *AppState) agenthooks.Handlers {
+	handlers := agenthooks.DefaultHandlers()
+	handlers.Command = nil
+	handlers.Prompt = loop.NewHookPromptEvaluator(state.hookRuntimeConfig)
+	handlers.Agent = loop.NewHookAgentHandler(state.hookRuntimeConfig)
+	return handlers
+}
+
+type hookExecutionContextKey struct{}
+
+// withHookExecution makes execution resolvable by hook handlers before it is
+// stored, as when a prompt is checked ahead of being queued.
+func withHookExecution(ctx context.Context, execution Execution) context.Context {
+	return context.WithValue(ctx, hookExecutionContextKey{}, execution)
+}
+
+// hookRuntimeConfig resolves the runtime config of the execution a hub hook
+// event was raised for. Events without a known execution, such as
+// simulations, fall back to the environment model.
+func (s *AppState) hookRuntimeConfig(ctx context.Context, event agentcore.HookEvent) *agentcore.RuntimeConfig {
+	executionID := strings.TrimSpace(string(event.RunID))
+	execution, exists := ctx.Value(hookExecutionContextKey{}).(Execution)
+	if !exists || execution.ID != executionID {
+		s.mu.RLock()
+		execution, exists = s.executions[executionID]
+		s.mu.RUnlock()
+	}
+	if !exists {
+		return nil
+	}
+	model, err := resolveRuntimeModelConfigForExecution(s, execution)
+	if err != nil {
+		return nil
+	}
+	tooling, err := resolveRuntimeToolingConfigForExecution(s, execution)
+	if err != nil {
+		return nil
+	}
+	config := buildExecutionRuntimeConfig(model, tooling)
+	return &config
+}
+
+// hubHookDispatcher routes the hook events the runtime engine raises during
+// runs to the hub hook policies of the session's active execution.
+// Evaluations that matched a policy are recorded like hub-side events.
+type hubHookDispatcher struct {
+	state *AppState
+}
+
+var _ agentcore.HookDispatcher = hubHookDispatcher{}
+
+func (d hubHookDispatcher) Dispatch(ctx context.Context, event agentcore.HookEvent) (agentcore.HookDecision, error) {
+	allow := agentcore.HookDecision{Decision: string(HookDecisionActionAllow)}
+	eventType, ok := hubHookEventType(event.Type)
+	if !ok {
+		return allow, nil
+	}
+	execution, ok := d.state.executionForRuntimeSession(string(event.SessionID), string(event.RunID))
+	if !ok {
+		return allow, nil
+	}
+	if eventType == HookEventTypeUserPromptSubmit {
+		return d.submittedPromptDecision(execution), nil
+	}
+	toolName, _ := event.Payload["tool_name"].(string)
+	toolName = strings.TrimSpace(toolName)
+	decision, policyID := runHookDecisionWithState(ctx, d.state, execution, eventType, toolName, event.Payload)
+	if policyID != "" || len(decision.Handlers) > 0 {
+		callID, _ := event.Payload["call_id"].(string)
+		if strings.TrimSpace(callID) == "" {
+			callID = execution.ID
+		}
+		appendHookExecutionRecordAndEventWithState(d.state, execution, callID, eventType, toolName, policyID, decision, map[string]any{
+			"source": "runtime",
+		})
+	}
+	return toAgentHookDecision(decision, policyID), nil
+}
+
+// submittedPromptDecision replays the UserPromptSubmit decision the composer
+// recorded when the prompt was submitted. The prompt was already updated and
+// denied prompts never reach the runtime, so only additional context is left
+// for the run to apply.
+func (d hubHookDispatcher) submittedPromptDecision(execution Execution) agentcore.HookDecision {
+	d.state.mu.RLock()
+	records := d.state.hookExecutionRecords[execution.ConversationID]
+	d.state.mu.RUnlock()
+	for index := len(records) - 1; index >= 0; index-- {
+		record := records[index]
+		if record.RunID != execution.ID || record.Event != HookEventTypeUserPromptSubmit {
+			continue
+		}
+		return toAgentHookDecision(HookDecision{
+			Action:            HookDecisionActionAllow,
+			AdditionalContext: record.Decision.AdditionalContext,
+		}, record.PolicyID)
+	}
+	return agentcore.HookDecision{Decision: string(HookDecisionActionAllow)}
+}
+
+// executionForRuntimeSession finds the hub execution a runtime event belongs
+// to. The hub submits one execution per conversation at a time, so the
+// conversation's active execution is preferred; the run binding covers
+// events raised after the execution left the active slot.
+func (s *AppState) executionForRuntimeSession(sessionID string, runID string) (Execution, bool) {
+	sessionID = strings.TrimSpace(sessionID)
+	runID = strings.TrimSpace(runID)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if sessionID != "" {
+		for conversationID, boundSessionID := range s.conversationSessionIDs {
+			if strings.TrimSpace(boundSessionID) != sessionID {
+				continue
+			}
+			conversation, exists := s.conversations[conversationID]
+			if !exists || conversation.ActiveExecutionID == nil {
+				break
+			}
+			if execution, exists := s.executions[*conversation.ActiveExecutionID]; exists {
+				return execution, true
+			}
+			break
+		}
+	}
+	if runID != "" {
+		for executionID, boundRunID := range s.executionRunIDs {
+			if strings.TrimSpace(boundRunID) != runID {
+				continue
+			}
+			if execution, exists := s.executions[executionID]; exists {
+				return execution, true
+			}
+		}
+	}
+	return Execution{}, false
+}
+
+// toAgentHookDecision converts a hub decision into the metadata keys the
+// runtime reads: reason, updated_input and additional_context.
+func toAgentHookDecision(decision HookDecision, policyID string) agentcore.HookDecision {
+	action := string(decision.Action)
+	if action == "" {
+		action = string(HookDecisionActionAllow)
+	}
+	metadata := map[string]any{}
+	if reason := strings.TrimSpace(decision.Reason); reason != "" {
+		metadata["reason"] = reason
+	}
+	if len(decision.UpdatedInput) > 0 {
+		metadata["updated_input"] = cloneMapAny(decision.UpdatedInput)
+	}
+	if text := hookAdditionalContextText(decision.AdditionalContext); text != "" {
+		metadata["additional_context"] = text
+	}
+	return agentcore.HookDecision{
+		Decision:        action,
+		MatchedPolicyID: policyID,
+		Metadata:        metadata,
+	}
+}
+
+// hookAdditionalContextText flattens a policy's additional context into the
+// text added to the prompt. Handler context is stored under "context"; other
+// keys are rendered as "key: value" lines in key order.
+func hookAdditionalContextText(additional map[string]any) string {
+	if len(additional) == 0 {
+		return ""
+	}
+	if text, ok := additional["context"].(string); ok && len(additional) == 1 {
+		return strings.TrimSpace(text)
+	}
+	keys := make([]string, 0, len(additional))
+	for key := range additional {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("%s: %v", key, additional[key]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// hubHookEventType converts a runtime event name such as PreToolUse to the
+// hub event type.
+func hubHookEventType(name string) (HookEventType, bool) {
+	var builder strings.Builder
+	chars := []rune(strings.TrimSpace(name))
+	for index, char := range chars {
+		if unicode.IsUpper(char) {
+			// A new word starts after a lowercase letter, or at the last
+			// capital of an acronym such as the "S" in "MCPServer".
+			if index > 0 && (unicode.IsLower(chars[index-1]) || index+1 < len(chars) && unicode.IsLower(chars[index+1])) {
+				builder.WriteByte('_')
+			}
+			char = unicode.ToLower(char)
+		}
+		builder.WriteRune(char)
+	}
+	return normalizeHookEventType(HookEventType(builder.String()))
+}
+
+// evaluateHookDecisionWithState resolves the static policy decision without
+// running handlers. It backs dry-run paths such as permission explain.
 func evaluateHookDecisionWithState(state *AppState, execution Execution, eventType HookEventType, toolName string) (HookDecision, string) {
+	return evaluateHookDecision(context.Background(), state, execution, eventType, toolName, nil, false)
+}
+
+// runHookDecisionWithState runs the handlers of matching policies and lets
+// their decisions replace the static ones before the usual precedence applies.
+func runHookDecisionWithState(ctx context.Context, state *AppState, execution Execution, eventType HookEventType, toolName string, payload map[string]any) (HookDecision, string) {
+	return evaluateHookDecision(ctx, state, execution, eventType, toolName, payload, true)
+}
+
+func evaluateHookDecision(
+	ctx context.Context,
+	state *AppState,
+	execution Execution,
+	eventType HookEventType,
+	toolName string,
+	payload map[string]any,
+	runHandlers bool,
+) (HookDecision, string) {
 	if state == nil {
 		return HookDecision{Action: HookDecisionActionAllow}, ""
 	}
@@ -25,28 +258,92 @@ func evaluateHookDecisionWithState(state *AppState, execution Execution, eventTy
 	}
 	state.mu.RLock()
 	policies := listHookPoliciesLocked(state)
+	projectRepoPath := ""
 	if conversation, ok := state.conversations[execution.ConversationID]; ok {
 		scopeContext.ProjectID = conversation.ProjectID
+		projectRepoPath = strings.TrimSpace(state.projects[conversation.ProjectID].RepoPath)
 	}
+	runtimeSessionID := strings.TrimSpace(state.conversationSessionIDs[execution.ConversationID])
+	handlers := state.hookHandlers
 	state.mu.RUnlock()
-	if len(policies) == 0 {
-		return HookDecision{
+	event := agentcore.HookEvent{
+		Type:      agentHookEventName(eventType),
+		SessionID: agentcore.SessionID(runtimeSessionID),
+		RunID:     agentcore.RunID(execution.ID),
+		Payload:   hookHandlerPayload(execution, toolName, projectRepoPath, payload),
+	}
+	evaluation := evaluateHookPolicies(ctx, policies, handlers, scopeContext, eventType, event, runHandlers)
+	return evaluation.decision, evaluation.policyID
+}
+
+// hookPolicyPrecedence describes the order evaluateHookPolicies ranks
+// in-scope policies in, for explain and simulate responses.
+const hookPolicyPrecedence = "in-scope policies are ordered by scope, then tool specificity, then deny > ask > allow"
+
+// hookEvaluation is one pass of the hub evaluator: the decision, and the
+// ranked and skipped policies behind it for simulations.
+type hookEvaluation struct {
+	decision  HookDecision
+	policyID  string
+	ranking   []HookSimulationRank
+	unmatched []HookSimulationUnmatched
+}
+
+// evaluateHookPolicies resolves the policies in scope for scopeContext, runs
+// the handlers of matching policies when runHandlers is set, and ranks the
+// result with runtimehooks.Evaluate. Live runs and simulations both go
+// through it so they always agree on the winning policy.
+func evaluateHookPolicies(
+	ctx context.Context,
+	policies []HookPolicy,
+	handlers agenthooks.Handlers,
+	scopeContext controlplanepolicy.HookScopeContext,
+	eventType HookEventType,
+	event agentcore.HookEvent,
+	runHandlers bool,
+) hookEvaluation {
+	toolName := strings.TrimSpace(scopeContext.ToolName)
+	evaluation := hookEvaluation{
+		decision: HookDecision{
 			Action:            HookDecisionActionAllow,
 			UpdatedInput:      map[string]any{},
 			AdditionalContext: map[string]any{},
-		}, ""
+		},
+		ranking:   []HookSimulationRank{},
+		unmatched: []HookSimulationUnmatched{},
 	}
 	scopedPolicies := controlplanepolicy.ResolveHookPolicies(toControlPlaneHookPolicies(policies), scopeContext)
+	inScope := make(map[string]bool, len(scopedPolicies))
+	for _, item := range scopedPolicies {
+		inScope[item.ID] = true
+	}
+	configs := make(map[string]HookPolicy, len(policies))
+	for _, item := range policies {
+		configs[item.ID] = item
+		reason := ""
+		switch {
+		case !item.Enabled:
+			reason = agenthooks.UnmatchedDisabled
+		case !inScope[item.ID]:
+			reason = agenthooks.UnmatchedScope
+		case item.Event != eventType:
+			reason = agenthooks.UnmatchedEvent
+		case strings.TrimSpace(item.ToolName) != "" && !strings.EqualFold(strings.TrimSpace(item.ToolName), toolName):
+			reason = agenthooks.UnmatchedTool
+		}
+		if reason != "" {
+			evaluation.unmatched = append(evaluation.unmatched, HookSimulationUnmatched{PolicyID: item.ID, Reason: reason})
+		}
+	}
 	if len(scopedPolicies) == 0 {
-		return HookDecision{
-			Action:            HookDecisionActionAllow,
-			UpdatedInput:      map[string]any{},
-			AdditionalContext: map[string]any{},
-		}, ""
+		return evaluation
 	}
+
 	hookPolicies := make([]runtimehooks.Policy, 0, len(scopedPolicies))
+	handlerRuns := map[string]agenthooks.HandlerExecution{}
+	handlerResults := []HookHandlerExecution{}
 	for _, item := range scopedPolicies {
-		hookPolicies = append(hookPolicies, runtimehooks.Policy{
+		policy := runtimehooks.Policy{
 			ID:                item.ID,
 			Scope:             runtimehooks.Scope(item.Scope),
 			EventType:         runtimehooks.EventType(item.EventType),
@@ -56,18 +353,174 @@ func evaluateHookDecisionWithState(state *AppState, execution Execution, eventTy
 			Enabled:           item.Enabled,
 			UpdatedInput:      cloneMapAny(item.UpdatedInput),
 			AdditionalContext: cloneMapAny(item.AdditionalContext),
-		})
+		}
+		if configured := configs[item.ID]; runHandlers && configured.Handler != nil && hookPolicyMatchesEvent(policy, eventType, toolName) {
+			result := handlers.Run(ctx, toAgentHookHandler(configured), event)
+			handlerRuns[item.ID] = result
+			handlerResults = append(handlerResults, toHookHandlerExecution(item.ID, result))
+			applyHookHandlerResult(&policy, result)
+		}
+		hookPolicies = append(hookPolicies, policy)
 	}
-	decision := runtimehooks.Evaluate(hookPolicies, runtimehooks.EventInput{
+	input := runtimehooks.EventInput{
 		EventType: runtimehooks.EventType(eventType),
 		ToolName:  toolName,
-	})
-	return HookDecision{
+	}
+	for _, item := range runtimehooks.Rank(hookPolicies, input) {
+		action := HookDecisionAction(item.Action)
+		if action == "" {
+			action = HookDecisionActionAllow
+		}
+		rank := HookSimulationRank{
+			PolicyID:       item.ID,
+			Decision:       action,
+			StaticDecision: configs[item.ID].Decision.Action,
+			Scope:          string(item.Scope),
+			ScopeRank:      item.ScopeRank,
+			ScopeTrace:     hookPolicyScopeTrace(configs[item.ID], scopeContext),
+			Specificity:    item.Specificity,
+		}
+		if result, ok := handlerRuns[item.ID]; ok {
+			rank.Handler = result.Map()
+		}
+		evaluation.ranking = append(evaluation.ranking, rank)
+	}
+	decision := runtimehooks.Evaluate(hookPolicies, input)
+	evaluation.decision = HookDecision{
 		Action:            HookDecisionAction(decision.Action),
 		Reason:            strings.TrimSpace(decision.Reason),
 		UpdatedInput:      cloneMapAny(decision.UpdatedInput),
 		AdditionalContext: cloneMapAny(decision.AdditionalContext),
-	}, strings.TrimSpace(decision.PolicyID)
+	}
+	if len(handlerResults) > 0 {
+		evaluation.decision.Handlers = handlerResults
+	}
+	evaluation.policyID = strings.TrimSpace(decision.PolicyID)
+	return evaluation
+}
+
+// hookPolicyScopeTrace lists why an in-scope policy applies to scopeContext:
+// the scope that matched and the bindings it was checked against.
+func hookPolicyScopeTrace(policy HookPolicy, scopeContext controlplanepolicy.HookScopeContext) []string {
+	trace := []string{"scope_match=" + string(policy.Scope)}
+	if workspaceID := strings.TrimSpace(policy.WorkspaceID); workspaceID != "" {
+		trace = append(trace, "workspace="+workspaceID)
+	}
+	switch policy.Scope {
+	case HookScopeProject:
+		trace = append(trace, "project="+strings.TrimSpace(scopeContext.ProjectID))
+	case HookScopeLocal:
+		trace = append(trace, "session="+strings.TrimSpace(scopeContext.ConversationID), "workspace=local")
+	case HookScopePlugin:
+		trace = append(trace, "tool="+strings.TrimSpace(scopeContext.ToolName))
+	}
+	return trace
+}
+
+func hookPolicyMatchesEvent(policy runtimehooks.Policy, eventType HookEventType, toolName string) bool {
+	if !policy.Enabled || strings.TrimSpace(string(policy.EventType)) != string(eventType) {
+		return false
+	}
+	policyTool := strings.TrimSpace(policy.ToolName)
+	return policyTool == "" || strings.EqualFold(policyTool, strings.TrimSpace(toolName))
+}
+
+// applyHookHandlerResult folds a handler run into its policy. A handler that
+// failed or returned no decision leaves the static decision in place.
+func applyHookHandlerResult(policy *runtimehooks.Policy, result agenthooks.HandlerExecution) {
+	if action := result.Decision(); action != "" {
+		policy.Action = runtimehooks.Action(action)
+		reason := strings.TrimSpace(result.Response.Reason)
+		if reason == "" {
+			reason = strings.TrimSpace(result.Response.StopReason)
+		}
+		if reason != "" {
+			policy.Reason = reason
+		}
+	}
+	if result.Error != "" {
+		return
+	}
+	if len(result.Response.UpdatedInput) > 0 {
+		policy.UpdatedInput = cloneMapAny(result.Response.UpdatedInput)
+	}
+	if updated := strings.TrimSpace(result.Response.UpdatedPrompt); updated != "" {
+		// UserPromptSubmit carries the replacement prompt as the "prompt" input.
+		if policy.UpdatedInput == nil {
+			policy.UpdatedInput = map[string]any{}
+		}
+		policy.UpdatedInput["prompt"] = updated
+	}
+	if additional := strings.TrimSpace(result.Response.AdditionalContext); additional != "" {
+		if policy.AdditionalContext == nil {
+			policy.AdditionalContext = map[string]any{}
+		}
+		policy.AdditionalContext["context"] = additional
+	}
+}
+
+func toAgentHookHandler(policy HookPolicy) agenthooks.Handler {
+	config := policy.Handler
+	return agenthooks.Handler{
+		Type:      agenthooks.HandlerType(policy.HandlerType),
+		Command:   config.Command,
+		URL:       config.URL,
+		Headers:   cloneStringMapForRuntime(config.Headers),
+		Prompt:    config.Prompt,
+		Agent:     config.Agent,
+		TimeoutMS: config.TimeoutMS,
+	}
+}
+
+func toHookHandlerExecution(policyID string, result agenthooks.HandlerExecution) HookHandlerExecution {
+	reason := strings.TrimSpace(result.Response.Reason)
+	if reason == "" {
+		reason = strings.TrimSpace(result.Response.StopReason)
+	}
+	return HookHandlerExecution{
+		PolicyID:    policyID,
+		HandlerType: HookHandlerType(result.Type),
+		Action:      HookDecisionAction(result.Decision()),
+		Reason:      reason,
+		DurationMS:  result.Duration.Milliseconds(),
+		TimedOut:    result.TimedOut,
+		Error:       result.Error,
+	}
+}
+
+func hookHandlerPayload(execution Execution, toolName string, cwd string, payload map[string]any) map[string]any {
+	out := cloneMapAny(payload)
+	if out == nil {
+		out = map[string]any{}
+	}
+	out["conversation_id"] = execution.ConversationID
+	out["workspace_id"] = execution.WorkspaceID
+	if trimmed := strings.TrimSpace(toolName); trimmed != "" {
+		out["tool_name"] = trimmed
+	}
+	if _, ok := out["cwd"]; !ok && cwd != "" {
+		out["cwd"] = cwd
+	}
+	return out
+}
+
+// agentHookEventName converts the snake_case hub event to the PascalCase name
+// hook handlers receive, e.g. pre_tool_use -> PreToolUse.
+func agentHookEventName(eventType HookEventType) string {
+	parts := strings.Split(strings.TrimSpace(string(eventType)), "_")
+	var builder strings.Builder
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		if part == "mcp" {
+			builder.WriteString("MCP")
+			continue
+		}
+		builder.WriteString(strings.ToUpper(part[:1]))
+		builder.WriteString(part[1:])
+	}
+	return builder.String()
 }
 
 func toControlPlaneHookPolicies(policies []HookPolicy) []controlplanepolicy.HookPolicy {
@@ -119,6 +572,9 @@ func appendHookExecutionRecordAndEventWithState(
 		},
 		"source": "hook_policy",
 	}
+	if len(decision.Handlers) > 0 {
+		payload["handlers"] = hookHandlerExecutionMaps(decision.Handlers)
+	}
 	if len(decision.UpdatedInput) > 0 {
 		payload["updated_input"] = cloneMapAny(decision.UpdatedInput)
 	}
@@ -130,7 +586,35 @@ func appendHookExecutionRecordAndEventWithState(
 	}
 
 	state.mu.Lock()
-	appendHookExecutionRecordLocked(state, HookExecutionRecord{
+	appendHookExecutionRecordLocked(state, newHookExecutionRecord(execution, eventType, toolName, policyID, decision, now))
+	if hasMappedExecutionEvent {
+		appendExecutionEventLocked(state, ExecutionEvent{
+			ExecutionID:    execution.ID,
+			ConversationID: execution.ConversationID,
+			TraceID:        execution.TraceID,
+			QueueIndex:     execution.QueueIndex,
+			Type:           eventName,
+			Timestamp:      now,
+			Payload:        payload,
+		})
+	}
+	state.mu.Unlock()
+}
+
+// appendHookExecutionRecordWithState records a decision without an execution
+// event, for evaluations whose execution was never created.
+func appendHookExecutionRecordWithState(state *AppState, execution Execution, eventType HookEventType, toolName string, policyID string, decision HookDecision) {
+	if state == nil {
+		return
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	state.mu.Lock()
+	appendHookExecutionRecordLocked(state, newHookExecutionRecord(execution, eventType, toolName, policyID, decision, now))
+	state.mu.Unlock()
+}
+
+func newHookExecutionRecord(execution Execution, eventType HookEventType, toolName string, policyID string, decision HookDecision, timestamp string) HookExecutionRecord {
+	return HookExecutionRecord{
 		RunID:     execution.ID,
 		TaskID:    execution.ID,
 		SessionID: execution.ConversationID,
@@ -142,21 +626,35 @@ func appendHookExecutionRecordAndEventWithState(
 			Reason:            strings.TrimSpace(decision.Reason),
 			UpdatedInput:      cloneMapAny(decision.UpdatedInput),
 			AdditionalContext: cloneMapAny(decision.AdditionalContext),
+			Handlers:          append([]HookHandlerExecution(nil), decision.Handlers...),
 		},
-		Timestamp: now,
-	})
-	if hasMappedExecutionEvent {
-		appendExecutionEventLocked(state, ExecutionEvent{
-			ExecutionID:    execution.ID,
-			ConversationID: execution.ConversationID,
-			TraceID:        execution.TraceID,
-			QueueIndex:     execution.QueueIndex,
-			Type:           eventName,
-			Timestamp:      now,
-			Payload:        payload,
-		})
+		Timestamp: timestamp,
 	}
-	state.mu.Unlock()
+}
+
+func hookHandlerExecutionMaps(items []HookHandlerExecution) []map[string]any {
+	out := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		entry := map[string]any{
+			"policy_id":    item.PolicyID,
+			"handler_type": string(item.HandlerType),
+			"duration_ms":  item.DurationMS,
+		}
+		if item.Action != "" {
+			entry["action"] = string(item.Action)
+		}
+		if item.Reason != "" {
+			entry["reason"] = item.Reason
+		}
+		if item.TimedOut {
+			entry["timed_out"] = true
+		}
+		if item.Error != "" {
+			entry["error"] = item.Error
+		}
+		out = append(out, entry)
+	}
+	return out
 }
 
 func mapHookEventTypeToRunEventType(eventType HookEventType) (RunEventType, bool) {
@@ -2,6 +2,7 @@ package httpapi
 
 import (
 	"fmt"
+	"net/url"
 	"sort"
 	"strings"
 	"time"
@@ -33,6 +34,10 @@ func upsertHookPolicyLocked(state *AppState, input HookPolicyUpsertRequest) (Hoo
 	if !ok {
 		return HookPolicy{}, fmt.Errorf("invalid decision.action")
 	}
+	handler, err := normalizeHookHandlerConfig(handlerType, input.Handler)
+	if err != nil {
+		return HookPolicy{}, err
+	}
 	workspaceID := strings.TrimSpace(input.WorkspaceID)
 	projectID := strings.TrimSpace(input.ProjectID)
 	sessionID := strings.TrimSpace(input.SessionID)
@@ -53,6 +58,7 @@ func upsertHookPolicyLocked(state *AppState, input HookPolicyUpsertRequest) (Hoo
 		ProjectID:   projectID,
 		SessionID:   sessionID,
 		Enabled:     enabled,
+		Handler:     handler,
 		Decision: HookDecision{
 			Action:            decisionAction,
 			Reason:            strings.TrimSpace(input.Decision.Reason),
@@ -72,6 +78,7 @@ func listHookPoliciesLocked(state *AppState) []HookPolicy {
 	items := make([]HookPolicy, 0, len(state.hookPolicies))
 	for _, policy := range state.hookPolicies {
 		item := policy
+		item.Handler = cloneHookHandlerConfig(item.Handler)
 		item.Decision.UpdatedInput = cloneMapAny(item.Decision.UpdatedInput)
 		item.Decision.AdditionalContext = cloneMapAny(item.Decision.AdditionalContext)
 		items = append(items, item)
@@ -224,6 +231,8 @@ func normalizeHookEventType(value HookEventType) (HookEventType, bool) {
 		return HookEventTypeWorktreeRemove, true
 	case HookEventTypePreCompact:
 		return HookEventTypePreCompact, true
+	case HookEventTypeMCPServerRequest:
+		return HookEventTypeMCPServerRequest, true
 	default:
 		return "", false
 	}
@@ -244,6 +253,57 @@ func normalizeHookHandlerType(value HookHandlerType) (HookHandlerType, bool) {
 	}
 }
 
+// normalizeHookHandlerConfig validates the handler fields required by
+// handlerType. Policies without a handler config keep their static decision.
+func normalizeHookHandlerConfig(handlerType HookHandlerType, input *HookHandlerConfig) (*HookHandlerConfig, error) {
+	if input == nil {
+		return nil, nil
+	}
+	handler := cloneHookHandlerConfig(input)
+	handler.Command = strings.TrimSpace(handler.Command)
+	handler.URL = strings.TrimSpace(handler.URL)
+	handler.Prompt = strings.TrimSpace(handler.Prompt)
+	handler.Agent = strings.TrimSpace(handler.Agent)
+	if handler.TimeoutMS < 0 {
+		return nil, fmt.Errorf("handler.timeout_ms must be >= 0")
+	}
+	if handlerType == HookHandlerTypeCommand || handler.Command != "" {
+		// Command handlers run on the hub host; they are only read from
+		// local settings files.
+		return nil, fmt.Errorf("handler.command is only allowed in local settings files")
+	}
+	switch handlerType {
+	case HookHandlerTypeHTTP:
+		parsed, parseErr := url.Parse(handler.URL)
+		if handler.URL == "" || parseErr != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			return nil, fmt.Errorf("handler.url must be an http(s) url for handler_type=http")
+		}
+	case HookHandlerTypePrompt:
+		if handler.Prompt == "" {
+			return nil, fmt.Errorf("handler.prompt is required for handler_type=prompt")
+		}
+	case HookHandlerTypeAgent:
+		if handler.Prompt == "" && handler.Agent == "" {
+			return nil, fmt.Errorf("handler.prompt or handler.agent is required for handler_type=agent")
+		}
+	}
+	return handler, nil
+}
+
+func cloneHookHandlerConfig(input *HookHandlerConfig) *HookHandlerConfig {
+	if input == nil {
+		return nil
+	}
+	out := *input
+	if len(input.Headers) > 0 {
+		out.Headers = make(map[string]string, len(input.Headers))
+		for key, value := range input.Headers {
+			out.Headers[key] = value
+		}
+	}
+	return &out
+}
+
 func normalizeHookDecisionAction(value HookDecisionAction) (HookDecisionAction, bool) {
 	switch HookDecisionAction(strings.TrimSpace(string(value))) {
 	case HookDecisionActionAllow:
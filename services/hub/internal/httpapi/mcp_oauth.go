@@ -0,0 +1,104 @@
+package httpapi
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	mcpsext "goyais/services/hub/internal/agent/extensions/mcp"
+	"goyais/services/hub/internal/runtime/infra/secrets"
+)
+
+const (
+	// mcpAuthorizeDiscoveryTimeout bounds metadata discovery and client
+	// registration before the authorization url is returned.
+	mcpAuthorizeDiscoveryTimeout = 30 * time.Second
+	// mcpAuthorizeWaitTimeout is how long the localhost callback waits for
+	// the user to approve in the browser.
+	mcpAuthorizeWaitTimeout = 10 * time.Minute
+)
+
+// newMCPTokenStore opens the OAuth token file shared with `goyais mcp
+// login`, encrypted with the resource secret key.
+func newMCPTokenStore() *mcpsext.FileTokenStore {
+	return mcpsext.NewFileTokenStore(mcpsext.DefaultTokenStorePath(), secrets.Cipher{})
+}
+
+// installMCPTokenSource lets pooled MCP sessions send the stored OAuth
+// tokens of their credential scope. Each workspace user logs in on their
+// own; sessions without a scope send no tokens.
+func installMCPTokenSource() {
+	mcpsext.DefaultSessionPool().SetTokenSources(mcpsext.ScopedOAuthTokens(newMCPTokenStore(), nil))
+}
+
+// mcpCredentialScope is the token store scope of one workspace user.
+func mcpCredentialScope(workspaceID string, userID string) string {
+	workspaceID = strings.TrimSpace(workspaceID)
+	userID = strings.TrimSpace(userID)
+	if workspaceID == "" || userID == "" {
+		return ""
+	}
+	return workspaceID + "/" + userID
+}
+
+func isRemoteMCPTransport(transport string) bool {
+	switch strings.TrimSpace(transport) {
+	case "http", "http_sse", "ws":
+		return true
+	default:
+		return false
+	}
+}
+
+// authorizeMCPConfig starts the OAuth login of config for the user of
+// credentialScope. On approval the config is connected again and its status
+// saved.
+func authorizeMCPConfig(state *AppState, config ResourceConfig, credentialScope string) McpAuthorizeResult {
+	result := McpAuthorizeResult{ConfigID: config.ID, Status: "failed"}
+	ctx, cancel := context.WithTimeout(context.Background(), mcpAuthorizeDiscoveryTimeout)
+	defer cancel()
+	if credentialScope == "" {
+		code := mcpsext.ConnectErrorUnauthorized
+		result.ErrorCode = &code
+		result.Message = "a signed-in user is required to authorize mcp servers"
+		return result
+	}
+	login, err := mcpsext.StartOAuthLogin(ctx, mcpServerConfig(config, credentialScope), mcpsext.OAuthLoginOptions{
+		Store:      newMCPTokenStore().Scope(credentialScope),
+		ClientName: "Goyais",
+	})
+	if err != nil {
+		code := "authorization_discovery_failed"
+		result.ErrorCode = &code
+		result.Message = err.Error()
+		return result
+	}
+	go finishMCPAuthorization(state, config, credentialScope, login)
+	result.Status = "pending"
+	result.AuthorizationURL = login.AuthorizationURL
+	result.Message = "open authorization_url to approve access"
+	return result
+}
+
+func finishMCPAuthorization(state *AppState, config ResourceConfig, credentialScope string, login *mcpsext.OAuthLogin) {
+	ctx, cancel := context.WithTimeout(context.Background(), mcpAuthorizeWaitTimeout)
+	defer cancel()
+	if _, err := login.Wait(ctx); err != nil {
+		code := "authorization_failed"
+		appendResourceTestLog(state, config.WorkspaceID, config.ID, "mcp_authorize", "failed", 0, &code, err.Error())
+		return
+	}
+	appendResourceTestLog(state, config.WorkspaceID, config.ID, "mcp_authorize", "authorized", 0, nil, "mcp server authorized")
+
+	latest, exists, err := loadWorkspaceResourceConfigRaw(state, config.WorkspaceID, config.ID)
+	if err != nil || !exists || latest.MCP == nil {
+		return
+	}
+	result := connectMCPConfig(latest, credentialScope)
+	if err := saveMCPConnectResult(state, latest, result); err != nil {
+		log.Printf("persist mcp status after authorization (%s): %v", config.ID, err)
+		return
+	}
+	appendResourceTestLog(state, config.WorkspaceID, config.ID, "mcp_connect", result.Status, 0, result.ErrorCode, result.Message)
+}
@@ -1,15 +1,22 @@
 package httpapi
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"strings"
 	"time"
+
+	mcpsext "goyais/services/hub/internal/agent/extensions/mcp"
 )
 
 const defaultMCPProbeTimeoutMS = 8000
 
-func connectMCPConfig(config ResourceConfig) McpConnectResult {
+// mcpProbeSessionID is the pool slot used by connect probes. It is shared
+// with runtimes that have no hub session.
+const mcpProbeSessionID = ""
+
+// connectMCPConfig probes config signed in as the user of credentialScope.
+func connectMCPConfig(config ResourceConfig, credentialScope string) McpConnectResult {
 	result := McpConnectResult{
 		ConfigID:    config.ID,
 		Status:      "failed",
@@ -26,53 +33,106 @@ func connectMCPConfig(config ResourceConfig) McpConnectResult {
 	}
 
 	spec := config.MCP
-	timeout := resolveMCPProbeTimeout(spec)
-
-	var tools []string
+	server := mcpServerConfig(config, credentialScope)
 	var err error
 	var code string
-	switch strings.TrimSpace(spec.Transport) {
+	switch server.Transport {
 	case "stdio":
-		tools, err, code = probeMCPStdio(spec, timeout)
-	case "http_sse":
-		tools, err, code = probeMCPHTTPSSE(spec, timeout)
+		if server.Command == "" {
+			code = "missing_command"
+			err = errors.New("stdio transport requires command")
+		}
+	case "http", "http_sse":
+		if !isValidURLString(server.Endpoint) {
+			code = "invalid_endpoint"
+			err = errors.New(server.Transport + " transport requires valid endpoint")
+		}
+	case "ws":
+		if !isValidWebSocketURLString(server.Endpoint) {
+			code = "invalid_endpoint"
+			err = errors.New("ws transport requires valid endpoint")
+		}
 	default:
 		code = "unsupported_transport"
-		err = errors.New("transport must be stdio or http_sse")
+		err = errors.New("transport must be stdio, http, http_sse or ws")
+	}
+	if err != nil {
+		result.Message = err.Error()
+		result.ErrorCode = &code
+		return result
 	}
 
+	timeout := resolveMCPProbeTimeout(spec)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	pool := mcpsext.DefaultSessionPool()
+	status, err := pool.Connect(ctx, mcpProbeSessionID, server, timeout)
+	result.Sessions = toMcpSessionStatuses(pool.Sessions(server.Name))
 	if err != nil {
-		result.Status = "failed"
+		if status.Status == mcpsext.SessionRestarting {
+			result.Status = "restarting"
+		}
 		result.Message = err.Error()
-		if code != "" {
-			result.ErrorCode = &code
+		code = "handshake_failed"
+		var connectErr *mcpsext.ConnectError
+		if errors.As(err, &connectErr) && connectErr.Code != "" {
+			code = connectErr.Code
 		}
+		if mcpsext.IsAuthorizationRequired(err) {
+			code = mcpsext.ConnectErrorUnauthorized
+		}
+		result.ErrorCode = &code
 		return result
 	}
 
 	result.Status = "connected"
-	result.Tools = tools
+	result.Tools = append([]string{}, status.Tools...)
 	result.Message = "mcp handshake and tools listing succeeded"
 	result.ErrorCode = nil
 	return result
 }
 
-func extractMCPToolNames(result json.RawMessage) []string {
-	payload := struct {
-		Tools []struct {
-			Name string `json:"name"`
-		} `json:"tools"`
-	}{}
-	if err := json.Unmarshal(result, &payload); err == nil {
-		names := make([]string, 0, len(payload.Tools))
-		for _, tool := range payload.Tools {
-			if strings.TrimSpace(tool.Name) != "" {
-				names = append(names, strings.TrimSpace(tool.Name))
-			}
-		}
-		return names
+func mcpServerConfig(config ResourceConfig, credentialScope string) mcpsext.ServerConfig {
+	spec := config.MCP
+	return mcpsext.ServerConfig{
+		Name:            mcpServerName(config),
+		Transport:       strings.TrimSpace(spec.Transport),
+		Endpoint:        strings.TrimSpace(spec.Endpoint),
+		Command:         strings.TrimSpace(spec.Command),
+		Env:             cloneStringMapForRuntime(spec.Env),
+		CredentialScope: credentialScope,
+	}
+}
+
+// mcpServerName matches the server name runtime snapshots give this config.
+func mcpServerName(config ResourceConfig) string {
+	if name := strings.TrimSpace(config.Name); name != "" {
+		return name
+	}
+	return strings.TrimSpace(config.ID)
+}
+
+func toMcpSessionStatuses(items []mcpsext.SessionStatus) []McpSessionStatus {
+	out := make([]McpSessionStatus, 0, len(items))
+	for _, item := range items {
+		out = append(out, McpSessionStatus{
+			SessionID:       item.SessionID,
+			Status:          item.Status,
+			ProtocolVersion: item.ProtocolVersion,
+			RestartCount:    item.RestartCount,
+			StartedAt:       formatMCPSessionTime(item.StartedAt),
+			LastUsedAt:      formatMCPSessionTime(item.LastUsedAt),
+			LastError:       item.LastError,
+		})
+	}
+	return out
+}
+
+func formatMCPSessionTime(value time.Time) string {
+	if value.IsZero() {
+		return ""
 	}
-	return []string{}
+	return value.UTC().Format(time.RFC3339)
 }
 
 func resolveMCPProbeTimeout(spec *McpSpec) time.Duration {
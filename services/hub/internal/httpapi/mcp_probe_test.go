@@ -2,15 +2,22 @@ package httpapi
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	mcpsext "goyais/services/hub/internal/agent/extensions/mcp"
 )
 
 func TestConnectMCPConfigHTTPSSE(t *testing.T) {
@@ -57,7 +64,7 @@ func TestConnectMCPConfigHTTPSSE(t *testing.T) {
 			Transport: "http_sse",
 			Endpoint:  server.URL + "/sse",
 		},
-	})
+	}, "")
 
 	if result.Status != "connected" {
 		t.Fatalf("expected connected status, got %s (%s)", result.Status, result.Message)
@@ -67,6 +74,162 @@ func TestConnectMCPConfigHTTPSSE(t *testing.T) {
 	}
 }
 
+func TestConnectMCPConfigWebSocket(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		socket, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer socket.Close()
+		for {
+			payload := map[string]any{}
+			if err := socket.ReadJSON(&payload); err != nil {
+				return
+			}
+			id, hasID := payload["id"]
+			if !hasID {
+				continue
+			}
+			result := map[string]any{}
+			if method, _ := payload["method"].(string); method == "tools/list" {
+				result["tools"] = []map[string]any{{"name": "search"}}
+			}
+			_ = socket.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": id, "result": result})
+		}
+	}))
+	defer server.Close()
+
+	result := connectMCPConfig(ResourceConfig{
+		ID: "rc_mcp_ws",
+		MCP: &McpSpec{
+			Transport: "ws",
+			Endpoint:  "ws" + strings.TrimPrefix(server.URL, "http"),
+			Env:       map[string]string{"Authorization": "Bearer token"},
+		},
+	}, "")
+	if result.Status != "connected" {
+		t.Fatalf("expected connected status, got %s (%s)", result.Status, result.Message)
+	}
+	if len(result.Tools) != 1 || result.Tools[0] != "search" {
+		t.Fatalf("expected ws tools, got %+v", result.Tools)
+	}
+
+	invalid := connectMCPConfig(ResourceConfig{
+		ID:  "rc_mcp_ws_invalid",
+		MCP: &McpSpec{Transport: "ws", Endpoint: server.URL},
+	}, "")
+	if invalid.ErrorCode == nil || *invalid.ErrorCode != "invalid_endpoint" {
+		t.Fatalf("expected invalid_endpoint for http url on ws transport, got %+v", invalid)
+	}
+}
+
+func TestAuthorizeMCPConfigReconnectsAfterLogin(t *testing.T) {
+	t.Setenv("GOYAIS_MCP_OAUTH_FILE", filepath.Join(t.TempDir(), "mcp-oauth.json"))
+	installMCPTokenSource()
+	t.Cleanup(func() { mcpsext.DefaultSessionPool().SetTokenSources(nil) })
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/.well-known/oauth-protected-resource/mcp":
+			_ = json.NewEncoder(w).Encode(map[string]any{"resource": server.URL + "/mcp", "authorization_servers": []string{server.URL}})
+			return
+		case "/.well-known/oauth-authorization-server":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"issuer":                 server.URL,
+				"authorization_endpoint": server.URL + "/authorize",
+				"token_endpoint":         server.URL + "/token",
+				"registration_endpoint":  server.URL + "/register",
+			})
+			return
+		case "/register":
+			_ = json.NewEncoder(w).Encode(map[string]any{"client_id": "hub-client"})
+			return
+		case "/authorize":
+			query := r.URL.Query()
+			http.Redirect(w, r, query.Get("redirect_uri")+"?code=code-1&state="+query.Get("state"), http.StatusFound)
+			return
+		case "/token":
+			_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "hub-token", "token_type": "Bearer", "expires_in": 3600})
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer hub-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		payload := map[string]any{}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		id, hasID := payload["id"]
+		if !hasID {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		result := map[string]any{}
+		if payload["method"] == "tools/list" {
+			result["tools"] = []map[string]any{{"name": "search"}}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": id, "result": result})
+	}))
+	defer server.Close()
+
+	state := NewAppState(nil)
+	config := ResourceConfig{
+		ID:          "rc_mcp_oauth",
+		WorkspaceID: localWorkspaceID,
+		Type:        ResourceTypeMCP,
+		Name:        "oauth-docs",
+		Enabled:     true,
+		MCP:         &McpSpec{Transport: "http", Endpoint: server.URL + "/mcp"},
+		CreatedAt:   nowUTC(),
+		UpdatedAt:   nowUTC(),
+	}
+	if _, err := saveWorkspaceResourceConfig(state, config); err != nil {
+		t.Fatalf("save mcp config failed: %v", err)
+	}
+
+	scope := mcpCredentialScope(localWorkspaceID, "user_a")
+	before := connectMCPConfig(config, scope)
+	if before.ErrorCode == nil || *before.ErrorCode != mcpsext.ConnectErrorUnauthorized {
+		t.Fatalf("expected authorization_required before login, got %+v", before)
+	}
+
+	result := authorizeMCPConfig(state, config, scope)
+	if result.Status != "pending" || !strings.HasPrefix(result.AuthorizationURL, server.URL+"/authorize?") {
+		t.Fatalf("expected pending authorization, got %+v", result)
+	}
+	res, err := http.Get(result.AuthorizationURL)
+	if err != nil {
+		t.Fatalf("open authorization url: %v", err)
+	}
+	_ = res.Body.Close()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		saved, _, err := loadWorkspaceResourceConfigRaw(state, localWorkspaceID, config.ID)
+		if err == nil && saved.MCP != nil && saved.MCP.Status == "connected" {
+			if len(saved.MCP.Tools) != 1 || saved.MCP.Tools[0] != "search" {
+				t.Fatalf("expected tools after authorization, got %+v", saved.MCP)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected config to reconnect after authorization, got %+v", saved.MCP)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	other := connectMCPConfig(config, mcpCredentialScope(localWorkspaceID, "user_b"))
+	if other.ErrorCode == nil || *other.ErrorCode != mcpsext.ConnectErrorUnauthorized {
+		t.Fatalf("expected another user to need their own login, got %+v", other)
+	}
+}
+
 func TestConnectMCPConfigStdio(t *testing.T) {
 	command := fmt.Sprintf("GO_WANT_MCP_HELPER=1 %s -test.run ^TestMCPStdioHelperProcess$", strconv.Quote(os.Args[0]))
 	result := connectMCPConfig(ResourceConfig{
@@ -75,7 +238,7 @@ func TestConnectMCPConfigStdio(t *testing.T) {
 			Transport: "stdio",
 			Command:   command,
 		},
-	})
+	}, "")
 
 	if result.Status != "connected" {
 		t.Fatalf("expected connected status, got %s (%s)", result.Status, result.Message)
@@ -83,6 +246,40 @@ func TestConnectMCPConfigStdio(t *testing.T) {
 	if len(result.Tools) != 2 {
 		t.Fatalf("expected two tools, got %+v", result.Tools)
 	}
+	if len(result.Sessions) != 1 || result.Sessions[0].Status != "connected" || result.Sessions[0].StartedAt == "" {
+		t.Fatalf("expected pooled session status, got %+v", result.Sessions)
+	}
+
+	again := connectMCPConfig(ResourceConfig{
+		ID: "rc_mcp_stdio",
+		MCP: &McpSpec{
+			Transport: "stdio",
+			Command:   command,
+		},
+	}, "")
+	if again.Status != "connected" || len(again.Sessions) != 1 || again.Sessions[0].RestartCount != 0 {
+		t.Fatalf("expected reconnect to reuse the pooled session, got %+v", again)
+	}
+}
+
+func TestCloseConversationRuntimeStopsPooledMCPServers(t *testing.T) {
+	state := NewAppState(nil)
+	server := mcpsext.ServerConfig{
+		Name:      "conversation-helper",
+		Transport: "stdio",
+		Command:   fmt.Sprintf("GO_WANT_MCP_HELPER=1 %s -test.run ^TestMCPStdioHelperProcess$", strconv.Quote(os.Args[0])),
+	}
+	pool := mcpsext.DefaultSessionPool()
+	for _, sessionID := range []string{"sess_conversation_runtime", "conv_conversation_runtime"} {
+		if _, err := pool.Connect(context.Background(), sessionID, server, 5*time.Second); err != nil {
+			t.Fatalf("connect %s: %v", sessionID, err)
+		}
+	}
+
+	state.closeConversationRuntime(context.Background(), "conv_conversation_runtime", "sess_conversation_runtime")
+	if sessions := pool.Sessions(server.Name); len(sessions) != 0 {
+		t.Fatalf("expected the conversation's MCP servers to be stopped, got %+v", sessions)
+	}
 }
 
 func TestMCPStdioHelperProcess(t *testing.T) {
@@ -117,6 +117,7 @@ const (
 	HookEventTypeWorktreeCreate     HookEventType = "worktree_create"
 	HookEventTypeWorktreeRemove     HookEventType = "worktree_remove"
 	HookEventTypePreCompact         HookEventType = "pre_compact"
+	HookEventTypeMCPServerRequest   HookEventType = "mcp_server_request"
 )
 
 type HookHandlerType string
@@ -137,43 +138,100 @@ const (
 )
 
 type HookDecision struct {
-	Action            HookDecisionAction `json:"action"`
-	Reason            string             `json:"reason,omitempty"`
-	UpdatedInput      map[string]any     `json:"updated_input,omitempty"`
-	AdditionalContext map[string]any     `json:"additional_context,omitempty"`
+	Action            HookDecisionAction     `json:"action"`
+	Reason            string                 `json:"reason,omitempty"`
+	UpdatedInput      map[string]any         `json:"updated_input,omitempty"`
+	AdditionalContext map[string]any         `json:"additional_context,omitempty"`
+	Handlers          []HookHandlerExecution `json:"handlers,omitempty"`
+}
+
+type HookHandlerConfig struct {
+	Command   string            `json:"command,omitempty"`
+	URL       string            `json:"url,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Prompt    string            `json:"prompt,omitempty"`
+	Agent     string            `json:"agent,omitempty"`
+	TimeoutMS int               `json:"timeout_ms,omitempty"`
+}
+
+type HookHandlerExecution struct {
+	PolicyID    string             `json:"policy_id"`
+	HandlerType HookHandlerType    `json:"handler_type"`
+	Action      HookDecisionAction `json:"action,omitempty"`
+	Reason      string             `json:"reason,omitempty"`
+	DurationMS  int64              `json:"duration_ms"`
+	TimedOut    bool               `json:"timed_out,omitempty"`
+	Error       string             `json:"error,omitempty"`
 }
 
 type HookPolicy struct {
-	ID          string          `json:"id"`
-	Scope       HookScope       `json:"scope"`
-	Event       HookEventType   `json:"event"`
-	HandlerType HookHandlerType `json:"handler_type"`
-	ToolName    string          `json:"tool_name,omitempty"`
-	WorkspaceID string          `json:"workspace_id,omitempty"`
-	ProjectID   string          `json:"project_id,omitempty"`
-	SessionID   string          `json:"session_id,omitempty"`
-	Enabled     bool            `json:"enabled"`
-	Decision    HookDecision    `json:"decision"`
-	UpdatedAt   string          `json:"updated_at"`
+	ID          string             `json:"id"`
+	Scope       HookScope          `json:"scope"`
+	Event       HookEventType      `json:"event"`
+	HandlerType HookHandlerType    `json:"handler_type"`
+	ToolName    string             `json:"tool_name,omitempty"`
+	WorkspaceID string             `json:"workspace_id,omitempty"`
+	ProjectID   string             `json:"project_id,omitempty"`
+	SessionID   string             `json:"session_id,omitempty"`
+	Enabled     bool               `json:"enabled"`
+	Handler     *HookHandlerConfig `json:"handler,omitempty"`
+	Decision    HookDecision       `json:"decision"`
+	UpdatedAt   string             `json:"updated_at"`
 }
 
 type HookPolicyUpsertRequest struct {
-	ID          string          `json:"id"`
-	Scope       HookScope       `json:"scope"`
-	Event       HookEventType   `json:"event"`
-	HandlerType HookHandlerType `json:"handler_type"`
-	ToolName    string          `json:"tool_name,omitempty"`
-	WorkspaceID string          `json:"workspace_id,omitempty"`
-	ProjectID   string          `json:"project_id,omitempty"`
-	SessionID   string          `json:"session_id,omitempty"`
-	Enabled     *bool           `json:"enabled,omitempty"`
-	Decision    HookDecision    `json:"decision"`
+	ID          string             `json:"id"`
+	Scope       HookScope          `json:"scope"`
+	Event       HookEventType      `json:"event"`
+	HandlerType HookHandlerType    `json:"handler_type"`
+	ToolName    string             `json:"tool_name,omitempty"`
+	WorkspaceID string             `json:"workspace_id,omitempty"`
+	ProjectID   string             `json:"project_id,omitempty"`
+	SessionID   string             `json:"session_id,omitempty"`
+	Enabled     *bool              `json:"enabled,omitempty"`
+	Handler     *HookHandlerConfig `json:"handler,omitempty"`
+	Decision    HookDecision       `json:"decision"`
 }
 
 type HookPolicyListResponse struct {
 	Items []HookPolicy `json:"items"`
 }
 
+type HookPolicySimulateRequest struct {
+	Event       HookEventType  `json:"event"`
+	ToolName    string         `json:"tool_name,omitempty"`
+	WorkspaceID string         `json:"workspace_id,omitempty"`
+	ProjectID   string         `json:"project_id,omitempty"`
+	SessionID   string         `json:"session_id,omitempty"`
+	Payload     map[string]any `json:"payload,omitempty"`
+	RunHandlers bool           `json:"run_handlers,omitempty"`
+}
+
+type HookPolicySimulateResponse struct {
+	Decision        HookDecisionAction        `json:"decision"`
+	MatchedPolicyID string                    `json:"matched_policy_id,omitempty"`
+	Reason          string                    `json:"reason,omitempty"`
+	Metadata        map[string]any            `json:"metadata,omitempty"`
+	Ranking         []HookSimulationRank      `json:"ranking"`
+	Unmatched       []HookSimulationUnmatched `json:"unmatched"`
+}
+
+type HookSimulationRank struct {
+	PolicyID       string             `json:"policy_id"`
+	Decision       HookDecisionAction `json:"decision"`
+	StaticDecision HookDecisionAction `json:"static_decision,omitempty"`
+	Scope          string             `json:"scope"`
+	ScopeRank      int                `json:"scope_rank"`
+	ScopeTrace     []string           `json:"scope_trace,omitempty"`
+	Specificity    int                `json:"specificity"`
+	Handler        map[string]any     `json:"handler,omitempty"`
+}
+
+type HookSimulationUnmatched struct {
+	PolicyID string `json:"policy_id"`
+	Reason   string `json:"reason"`
+}
+
 type HookExecutionRecord struct {
 	ID        string        `json:"id"`
 	RunID     string        `json:"run_id"`
@@ -620,6 +678,7 @@ type ExecutionResourceProfile struct {
 	ProjectFilePaths        []string                              `json:"project_file_paths,omitempty"`
 	RulesDSL                string                                `json:"rules_dsl,omitempty"`
 	MCPServers              []ExecutionMCPServerSnapshot          `json:"mcp_servers,omitempty"`
+	MCPCredentialScope      string                                `json:"mcp_credential_scope,omitempty"`
 	AlwaysLoadedCapabilities []ExecutionCapabilityDescriptorSnapshot `json:"always_loaded_capabilities,omitempty"`
 	SearchableCapabilities  []ExecutionCapabilityDescriptorSnapshot `json:"searchable_capabilities,omitempty"`
 }
@@ -711,6 +770,18 @@ type RollbackRequest struct {
 	MessageID string `json:"message_id"`
 }
 
+type PermissionApprovalRevokeRequest struct {
+	Scope string `json:"scope"`
+	Rule  string `json:"rule"`
+}
+
+type PermissionExplainRequest struct {
+	ToolName  string         `json:"tool_name"`
+	Input     map[string]any `json:"input,omitempty"`
+	Arguments string         `json:"arguments,omitempty"`
+	Mode      string         `json:"mode,omitempty"`
+}
+
 type DiffItem struct {
 	ID           string `json:"id"`
 	Path         string `json:"path"`
@@ -810,6 +881,7 @@ const (
 	RunEventTypePermissionRequest       RunEventType = "permission_request"
 	RunEventTypeToolCall                RunEventType = "tool_call"
 	RunEventTypeToolResult              RunEventType = "tool_result"
+	RunEventTypeToolProgress            RunEventType = "tool_progress"
 	RunEventTypePostToolUse             RunEventType = "post_tool_use"
 	RunEventTypePostToolUseFailure      RunEventType = "post_tool_use_failure"
 	RunEventTypeDiffGenerated           RunEventType = "diff_generated"
@@ -41,12 +41,17 @@ func TestOpenAPIContainsV040CriticalRoutes(t *testing.T) {
 		"/v1/sessions/{session_id}/changeset/discard:",
 		"/v1/sessions/{session_id}/changeset/export:",
 		"/v1/sessions/{session_id}/rollback:",
+		"/v1/sessions/{session_id}/permissions:explain:",
+		"/v1/hooks/policies:simulate:",
+		"/v1/sessions/{session_id}/permissions/approvals:",
+		"/v1/sessions/{session_id}/permissions/approvals:revoke:",
 		"/v1/workspaces/{workspace_id}/model-catalog:",
 		"/v1/workspaces/{workspace_id}/catalog-root:",
 		"/v1/workspaces/{workspace_id}/resource-configs:",
 		"/v1/workspaces/{workspace_id}/resource-configs/{config_id}:",
 		"/v1/workspaces/{workspace_id}/resource-configs/{config_id}/test:",
 		"/v1/workspaces/{workspace_id}/resource-configs/{config_id}/connect:",
+		"/v1/workspaces/{workspace_id}/resource-configs/{config_id}/authorize:",
 		"/v1/workspaces/{workspace_id}/mcps/export:",
 		"/v1/workspaces/{workspace_id}/project-configs:",
 		"/v1/workspaces/{workspace_id}/agent-config:",
@@ -107,19 +107,25 @@ func purgeProjectConversations(state *AppState, projectID string) projectConvers
 		return projectConversationsPurgeResult{}
 	}
 	state.mu.Lock()
-	result, executionIDsToCancel := purgeProjectConversationsLocked(state, normalizedProjectID)
+	result, executionIDsToCancel, runtimeSessionIDs := purgeProjectConversationsLocked(state, normalizedProjectID)
 	state.mu.Unlock()
 
 	for _, executionID := range executionIDsToCancel {
 		state.cancelExecutionBestEffort(context.Background(), executionID)
 		state.clearExecutionRuntimeMapping(executionID)
 	}
+	for conversationID, runtimeSessionID := range runtimeSessionIDs {
+		state.closeConversationRuntime(context.Background(), conversationID, runtimeSessionID)
+	}
 	return result
 }
 
-func purgeProjectConversationsLocked(state *AppState, projectID string) (projectConversationsPurgeResult, []string) {
+// purgeProjectConversationsLocked also returns the runtime session of each
+// purged conversation, keyed by conversation id.
+func purgeProjectConversationsLocked(state *AppState, projectID string) (projectConversationsPurgeResult, []string, map[string]string) {
 	result := projectConversationsPurgeResult{}
 	executionIDsToCancel := make([]string, 0)
+	runtimeSessionIDs := map[string]string{}
 
 	for conversationID, conversation := range state.conversations {
 		if conversation.ProjectID != projectID {
@@ -142,6 +148,7 @@ func purgeProjectConversationsLocked(state *AppState, projectID string) (project
 		delete(state.conversationExecutionOrder, conversationID)
 		delete(state.executionEvents, conversationID)
 		delete(state.conversationEventSeq, conversationID)
+		runtimeSessionIDs[conversationID] = state.conversationSessionIDs[conversationID]
 		delete(state.conversationSessionIDs, conversationID)
 		if subscribers, ok := state.conversationEventSubs[conversationID]; ok {
 			for subID := range subscribers {
@@ -150,7 +157,7 @@ func purgeProjectConversationsLocked(state *AppState, projectID string) (project
 		}
 	}
 
-	return result, executionIDsToCancel
+	return result, executionIDsToCancel, runtimeSessionIDs
 }
 
 func getProjectConfigFromStore(state *AppState, project Project) (ProjectConfig, error) {
@@ -57,7 +57,7 @@ func (r *sqliteRuntimeSessionRepository) ReplaceAll(ctx context.Context, items [
 		return nil
 	}
 	return withWriteTx(ctx, r.db, func(tx *sql.Tx) error {
-		if _, err := tx.ExecContext(ctx, `DELETE FROM runtime_sessions`); err != nil {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM runtime_sessions WHERE origin = 'hub'`); err != nil {
 			return err
 		}
 		for _, item := range items {
@@ -119,7 +119,7 @@ func (r *sqliteRuntimeSessionRepository) GetByID(ctx context.Context, sessionID
 		ctx,
 		`SELECT id, workspace_id, project_id, name, default_mode, model_config_id, rule_ids_json, skill_ids_json, mcp_ids_json, active_run_id, created_at, updated_at
 		 FROM runtime_sessions
-		 WHERE id = ?`,
+		 WHERE id = ? AND origin = 'hub'`,
 		sessionID,
 	).Scan(
 		&item.ID,
@@ -166,7 +166,7 @@ func (r *sqliteRuntimeSessionRepository) ListByWorkspace(ctx context.Context, wo
 		ctx,
 		`SELECT id, workspace_id, project_id, name, default_mode, model_config_id, rule_ids_json, skill_ids_json, mcp_ids_json, active_run_id, created_at, updated_at
 		 FROM runtime_sessions
-		 WHERE workspace_id = ?
+		 WHERE workspace_id = ? AND origin = 'hub'
 		 ORDER BY created_at ASC, id ASC
 		 LIMIT ? OFFSET ?`,
 		workspaceID,
@@ -229,7 +229,7 @@ func (r *sqliteRuntimeRunRepository) ReplaceAll(ctx context.Context, items []Run
 		return nil
 	}
 	return withWriteTx(ctx, r.db, func(tx *sql.Tx) error {
-		if _, err := tx.ExecContext(ctx, `DELETE FROM runtime_runs`); err != nil {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM runtime_runs WHERE origin = 'hub'`); err != nil {
 			return err
 		}
 		for _, item := range items {
@@ -268,7 +268,7 @@ func (r *sqliteRuntimeRunRepository) GetByID(ctx context.Context, runID string)
 		ctx,
 		`SELECT id, session_id, workspace_id, message_id, state, mode, model_id, model_config_id, tokens_in, tokens_out, trace_id, created_at, updated_at
 		 FROM runtime_runs
-		 WHERE id = ?`,
+		 WHERE id = ? AND origin = 'hub'`,
 		runID,
 	).Scan(
 		&item.ID,
@@ -303,7 +303,7 @@ func (r *sqliteRuntimeRunRepository) ListBySession(ctx context.Context, sessionI
 		ctx,
 		`SELECT id, session_id, workspace_id, message_id, state, mode, model_id, model_config_id, tokens_in, tokens_out, trace_id, created_at, updated_at
 		 FROM runtime_runs
-		 WHERE session_id = ?
+		 WHERE session_id = ? AND origin = 'hub'
 		 ORDER BY created_at ASC, id ASC
 		 LIMIT ? OFFSET ?`,
 		sessionID,
@@ -352,7 +352,7 @@ func (r *sqliteRuntimeRunRepository) ListByWorkspace(ctx context.Context, worksp
 		ctx,
 		`SELECT id, session_id, workspace_id, message_id, state, mode, model_id, model_config_id, tokens_in, tokens_out, trace_id, created_at, updated_at
 		 FROM runtime_runs
-		 WHERE workspace_id = ?
+		 WHERE workspace_id = ? AND origin = 'hub'
 		 ORDER BY created_at ASC, id ASC
 		 LIMIT ? OFFSET ?`,
 		workspaceID,
@@ -397,7 +397,7 @@ func (r *sqliteRuntimeRunEventRepository) ReplaceAll(ctx context.Context, items
 		return nil
 	}
 	return withWriteTx(ctx, r.db, func(tx *sql.Tx) error {
-		if _, err := tx.ExecContext(ctx, `DELETE FROM runtime_run_events`); err != nil {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM runtime_run_events WHERE origin = 'hub'`); err != nil {
 			return err
 		}
 		for _, item := range items {
@@ -439,7 +439,7 @@ func (r *sqliteRuntimeRunEventRepository) ListBySession(ctx context.Context, ses
 		ctx,
 		`SELECT event_id, run_id, session_id, sequence, type, timestamp, payload_json, occurred_at
 		 FROM runtime_run_events
-		 WHERE session_id = ? AND sequence > ?
+		 WHERE session_id = ? AND sequence > ? AND origin = 'hub'
 		 ORDER BY sequence ASC, event_id ASC
 		 LIMIT ?`,
 		sessionID,
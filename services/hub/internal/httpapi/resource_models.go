@@ -25,9 +25,10 @@ var supportedModelVendors = []ModelVendorName{
 }
 
 type ModelCatalogModel struct {
-	ID      string `json:"id"`
-	Label   string `json:"label"`
-	Enabled bool   `json:"enabled"`
+	ID            string `json:"id"`
+	Label         string `json:"label"`
+	Enabled       bool   `json:"enabled"`
+	ContextWindow int    `json:"context_window,omitempty"`
 }
 
 type ModelCatalogVendorAuth struct {
@@ -147,12 +148,33 @@ type ModelTestResult struct {
 }
 
 type McpConnectResult struct {
-	ConfigID    string   `json:"config_id"`
-	Status      string   `json:"status"`
-	Tools       []string `json:"tools"`
-	ErrorCode   *string  `json:"error_code,omitempty"`
-	Message     string   `json:"message"`
-	ConnectedAt string   `json:"connected_at"`
+	ConfigID    string             `json:"config_id"`
+	Status      string             `json:"status"`
+	Tools       []string           `json:"tools"`
+	ErrorCode   *string            `json:"error_code,omitempty"`
+	Message     string             `json:"message"`
+	ConnectedAt string             `json:"connected_at"`
+	Sessions    []McpSessionStatus `json:"sessions,omitempty"`
+}
+
+// McpAuthorizeResult starts an OAuth login for a remote MCP server. The
+// user opens AuthorizationURL; the hub reconnects once the callback lands.
+type McpAuthorizeResult struct {
+	ConfigID         string  `json:"config_id"`
+	Status           string  `json:"status"`
+	AuthorizationURL string  `json:"authorization_url,omitempty"`
+	ErrorCode        *string `json:"error_code,omitempty"`
+	Message          string  `json:"message"`
+}
+
+type McpSessionStatus struct {
+	SessionID       string `json:"session_id,omitempty"`
+	Status          string `json:"status"`
+	ProtocolVersion string `json:"protocol_version,omitempty"`
+	RestartCount    int    `json:"restart_count"`
+	StartedAt       string `json:"started_at,omitempty"`
+	LastUsedAt      string `json:"last_used_at,omitempty"`
+	LastError       string `json:"last_error,omitempty"`
 }
 
 type ResourceTestLog struct {
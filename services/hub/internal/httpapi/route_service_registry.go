@@ -62,37 +62,42 @@ func (r *handlerServiceRegistry) controlplaneHandlers() controlplaneroutes.Handl
 		AdminAudit:                  r.admin.AdminAuditHandler(),
 		HooksPolicies:               r.hook.HooksPoliciesHandler(),
 		HookExecutions:              r.hook.HookExecutionsHandler(),
+		HooksPolicySimulate:         r.hook.HooksPolicySimulateHandler(),
 	}
 }
 
 func (r *handlerServiceRegistry) runtimeHandlers() runtimeroutes.Handlers {
 	return runtimeroutes.Handlers{
-		Projects:                     r.project.ProjectsHandler(),
-		ProjectsImport:               r.project.ProjectsImportHandler(),
-		ProjectByID:                  r.project.ProjectByIDHandler(),
-		ProjectConversations:         r.project.ProjectConversationsHandler(),
-		ProjectConfig:                r.project.ProjectConfigHandler(),
-		ProjectFiles:                 r.project.ProjectFilesHandler(),
-		ProjectFileContent:           r.project.ProjectFileContentHandler(),
-		Conversations:                r.sessionRun.ConversationsHandler(),
-		ConversationByID:             r.sessionRun.ConversationByIDHandler(),
-		ConversationInputCatalog:     r.sessionRun.ConversationInputCatalogHandler(),
-		ConversationInputSuggest:     r.sessionRun.ConversationInputSuggestHandler(),
-		ConversationInputSubmit:      r.sessionRun.ConversationInputSubmitHandler(),
-		ConversationEvents:           r.sessionRun.ConversationEventsHandler(),
-		ConversationStop:             r.sessionRun.ConversationStopHandler(),
-		ConversationExport:           r.sessionRun.ConversationExportHandler(),
-		ConversationChangeSet:        r.sessionRun.ConversationChangeSetHandler(),
-		ConversationChangeSetCommit:  r.sessionRun.ConversationChangeSetCommitHandler(),
-		ConversationChangeSetDiscard: r.sessionRun.ConversationChangeSetDiscardHandler(),
-		ConversationChangeSetExport:  r.sessionRun.ConversationChangeSetExportHandler(),
-		ConversationRollback:         r.sessionRun.ConversationRollbackHandler(),
-		Executions:                   r.sessionRun.ExecutionsHandler(),
-		RunControl:                   r.sessionRun.RunControlHandler(),
-		RunGraph:                     r.sessionRun.RunGraphHandler(),
-		RunTasks:                     r.sessionRun.RunTasksHandler(),
-		RunTaskByID:                  r.sessionRun.RunTaskByIDHandler(),
-		RunTaskControl:               r.sessionRun.RunTaskControlHandler(),
+		Projects:                             r.project.ProjectsHandler(),
+		ProjectsImport:                       r.project.ProjectsImportHandler(),
+		ProjectByID:                          r.project.ProjectByIDHandler(),
+		ProjectConversations:                 r.project.ProjectConversationsHandler(),
+		ProjectConfig:                        r.project.ProjectConfigHandler(),
+		ProjectFiles:                         r.project.ProjectFilesHandler(),
+		ProjectFileContent:                   r.project.ProjectFileContentHandler(),
+		Conversations:                        r.sessionRun.ConversationsHandler(),
+		ConversationByID:                     r.sessionRun.ConversationByIDHandler(),
+		ConversationInputCatalog:             r.sessionRun.ConversationInputCatalogHandler(),
+		ConversationInputSuggest:             r.sessionRun.ConversationInputSuggestHandler(),
+		ConversationInputSubmit:              r.sessionRun.ConversationInputSubmitHandler(),
+		ConversationEvents:                   r.sessionRun.ConversationEventsHandler(),
+		ConversationStop:                     r.sessionRun.ConversationStopHandler(),
+		ConversationExport:                   r.sessionRun.ConversationExportHandler(),
+		ConversationChangeSet:                r.sessionRun.ConversationChangeSetHandler(),
+		ConversationChangeSetCommit:          r.sessionRun.ConversationChangeSetCommitHandler(),
+		ConversationChangeSetDiscard:         r.sessionRun.ConversationChangeSetDiscardHandler(),
+		ConversationChangeSetExport:          r.sessionRun.ConversationChangeSetExportHandler(),
+		ConversationRollback:                 r.sessionRun.ConversationRollbackHandler(),
+		ConversationPermissionExplain:        r.sessionRun.ConversationPermissionExplainHandler(),
+		ConversationPermissionApprovals:      r.sessionRun.ConversationPermissionApprovalsHandler(),
+		ConversationPermissionApprovalRevoke: r.sessionRun.ConversationPermissionApprovalRevokeHandler(),
+		ConversationCheckpoints:              r.sessionRun.ConversationCheckpointsHandler(),
+		Executions:                           r.sessionRun.ExecutionsHandler(),
+		RunControl:                           r.sessionRun.RunControlHandler(),
+		RunGraph:                             r.sessionRun.RunGraphHandler(),
+		RunTasks:                             r.sessionRun.RunTasksHandler(),
+		RunTaskByID:                          r.sessionRun.RunTaskByIDHandler(),
+		RunTaskControl:                       r.sessionRun.RunTaskControlHandler(),
 	}
 }
 
@@ -108,6 +113,7 @@ func (r *handlerServiceRegistry) integrationHandlers() integrationroutes.Handler
 		ResourceConfigByID:      r.resource.ResourceConfigByIDHandler(),
 		ResourceConfigTest:      r.resource.ResourceConfigTestHandler(),
 		ResourceConfigConnect:   r.resource.ResourceConfigConnectHandler(),
+		ResourceConfigAuthorize: r.resource.ResourceConfigAuthorizeHandler(),
 		MCPExport:               r.resource.MCPExportHandler(),
 		WorkspaceProjectConfigs: r.resource.WorkspaceProjectConfigsHandler(),
 		WorkspaceAgentConfig:    r.resource.WorkspaceAgentConfigHandler(),
@@ -218,6 +224,10 @@ func (s *hookRouteService) HooksPoliciesHandler() http.HandlerFunc {
 	return HooksPoliciesHandler(s.state)
 }
 
+func (s *hookRouteService) HooksPolicySimulateHandler() http.HandlerFunc {
+	return HooksPolicySimulateHandler(s.state)
+}
+
 func (s *hookRouteService) HookExecutionsHandler() http.HandlerFunc {
 	return HookExecutionsHandler(s.state)
 }
@@ -310,6 +320,22 @@ func (s *sessionRunRouteService) ConversationRollbackHandler() http.HandlerFunc
 	return ConversationRollbackHandler(s.state)
 }
 
+func (s *sessionRunRouteService) ConversationPermissionExplainHandler() http.HandlerFunc {
+	return ConversationPermissionExplainHandler(s.state)
+}
+
+func (s *sessionRunRouteService) ConversationPermissionApprovalsHandler() http.HandlerFunc {
+	return ConversationPermissionApprovalsHandler(s.state)
+}
+
+func (s *sessionRunRouteService) ConversationPermissionApprovalRevokeHandler() http.HandlerFunc {
+	return ConversationPermissionApprovalRevokeHandler(s.state)
+}
+
+func (s *sessionRunRouteService) ConversationCheckpointsHandler() http.HandlerFunc {
+	return ConversationCheckpointsHandler(s.state)
+}
+
 func (s *sessionRunRouteService) ExecutionsHandler() http.HandlerFunc {
 	return ExecutionsHandler(s.state)
 }
@@ -378,6 +404,10 @@ func (s *resourceRouteService) ResourceConfigConnectHandler() http.HandlerFunc {
 	return ResourceConfigConnectHandler(s.state)
 }
 
+func (s *resourceRouteService) ResourceConfigAuthorizeHandler() http.HandlerFunc {
+	return ResourceConfigAuthorizeHandler(s.state)
+}
+
 func (s *resourceRouteService) MCPExportHandler() http.HandlerFunc {
 	return MCPExportHandler(s.state)
 }
@@ -27,6 +27,7 @@ func newRouterWithDBPath(dbPath string) http.Handler {
 		log.Printf("failed to open authz db (%s), fallback to memory-only state: %v", dbPath, err)
 	}
 	state := NewAppState(store)
+	installMCPTokenSource()
 	services := newHandlerServiceRegistry(state)
 	mux := http.NewServeMux()
 
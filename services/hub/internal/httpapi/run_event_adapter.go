@@ -119,6 +119,7 @@ func mapExecutionEventToRunEventType(event ExecutionEvent) runtimecore.RunEventT
 		return runtimecore.RunEventTypeRunOutputDelta
 	case RunEventTypeToolCall,
 		RunEventTypeToolResult,
+		RunEventTypeToolProgress,
 		RunEventTypePreToolUse,
 		RunEventTypePermissionRequest,
 		RunEventTypePostToolUse,
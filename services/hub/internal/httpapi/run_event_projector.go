@@ -39,9 +39,11 @@ func (s *AppState) ensureConversationProjection(conversationID string, runtimeSe
 	s.conversationProjectionCancels[normalizedConversationID] = workerCancel
 	s.mu.Unlock()
 
+	// Subscribe replays events after the cursor, so the cursor is the last
+	// projected sequence itself.
 	cursor := ""
 	if lastSequence > 0 {
-		cursor = strconv.FormatInt(lastSequence+1, 10)
+		cursor = strconv.FormatInt(lastSequence, 10)
 	}
 	go s.runConversationProjectionLoop(workerCtx, normalizedConversationID, normalizedRuntimeSessionID, cursor)
 	return nil
@@ -177,12 +179,74 @@ func (s *AppState) projectRuntimeEvent(
 	if stateChanged {
 		syncExecutionDomainBestEffort(s)
 	}
+	switch strings.TrimSpace(asStringValue(mappedPayload["stage"])) {
+	case "secret_redacted":
+		s.auditSecretRedaction(normalizedConversationID, executionID, execution, mappedPayload)
+	case "mcp_server_request":
+		s.auditMCPServerRequest(normalizedConversationID, executionID, execution, mappedPayload)
+	}
 	if nextExecutionToSubmit != "" {
 		s.submitExecutionBestEffort(context.Background(), nextExecutionToSubmit)
 	}
 	return executionID, mappedType, true
 }
 
+// auditSecretRedaction records one secret the runtime replaced before content
+// was sent to the model provider. The payload only carries a fingerprint.
+func (s *AppState) auditSecretRedaction(conversationID string, executionID string, execution Execution, payload map[string]any) {
+	if s.authz == nil {
+		return
+	}
+	details := map[string]any{
+		"operation":    "redact_secret",
+		"execution_id": executionID,
+	}
+	if output, ok := payload["output"].(map[string]any); ok {
+		for _, key := range []string{"kind", "placeholder", "fingerprint", "source"} {
+			if value := strings.TrimSpace(asStringValue(output[key])); value != "" {
+				details[key] = value
+			}
+		}
+	}
+	if name := strings.TrimSpace(asStringValue(payload["name"])); name != "" {
+		details["tool_name"] = name
+	}
+	if callID := strings.TrimSpace(asStringValue(payload["call_id"])); callID != "" {
+		details["call_id"] = callID
+	}
+	_ = s.authz.appendAudit(execution.WorkspaceID, "system", "secret.redact", "conversation", conversationID, "success", details, execution.TraceID)
+}
+
+// auditMCPServerRequest records a sampling or elicitation request an MCP
+// server sent during the run, including the ones that were denied.
+func (s *AppState) auditMCPServerRequest(conversationID string, executionID string, execution Execution, payload map[string]any) {
+	if s.authz == nil {
+		return
+	}
+	output, _ := payload["output"].(map[string]any)
+	action := "mcp.sampling"
+	if strings.TrimSpace(asStringValue(output["method"])) == "elicitation/create" {
+		action = "mcp.elicitation"
+	}
+	result := "success"
+	switch strings.TrimSpace(asStringValue(output["outcome"])) {
+	case "denied", "decline":
+		result = "denied"
+	case "failed", "cancel":
+		result = "failed"
+	}
+	details := map[string]any{"execution_id": executionID}
+	for _, key := range []string{"server", "method", "outcome", "decided_by", "reason", "max_tokens", "usage_tokens"} {
+		if value, exists := output[key]; exists {
+			details[key] = value
+		}
+	}
+	if name := strings.TrimSpace(asStringValue(payload["name"])); name != "" {
+		details["tool_name"] = name
+	}
+	_ = s.authz.appendAudit(execution.WorkspaceID, "system", action, "conversation", conversationID, result, details, execution.TraceID)
+}
+
 func resolveExecutionByRuntimeRunIDLocked(state *AppState, conversationID string, runID string) (string, Execution) {
 	normalizedConversationID := strings.TrimSpace(conversationID)
 	normalizedRunID := strings.TrimSpace(runID)
@@ -420,6 +484,9 @@ func mapRuntimeEnvelopeToExecutionEvent(event agentcore.EventEnvelope) (RunEvent
 			if riskLevel := strings.TrimSpace(typed.RiskLevel); riskLevel != "" {
 				payload["risk_level"] = riskLevel
 			}
+			if typed.Plan != nil {
+				payload["plan"] = agentcore.PlanRecordMap(*typed.Plan)
+			}
 		}
 		return RunEventTypeThinkingDelta, payload
 	case agentcore.RunEventTypeRunOutputDelta:
@@ -508,13 +575,15 @@ func mapRuntimeEnvelopeToExecutionEvent(event agentcore.EventEnvelope) (RunEvent
 			return RunEventTypeToolCall, payload
 		case "tool_result":
 			return RunEventTypeToolResult, payload
+		case "tool_progress":
+			return RunEventTypeToolProgress, payload
 		case "run_approval_needed":
 			payload["run_state"] = "waiting_approval"
 			return RunEventTypeThinkingDelta, payload
 		case "run_user_question_needed":
 			payload["run_state"] = "waiting_user_input"
 			return RunEventTypeThinkingDelta, payload
-		case "run_user_question_resolved", "approval_resolved":
+		case "run_user_question_resolved", "approval_resolved", "plan_resolved":
 			payload["run_state"] = "running"
 			return RunEventTypeThinkingDelta, payload
 		}
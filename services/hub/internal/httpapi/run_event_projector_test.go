@@ -287,6 +287,34 @@ func TestEnsureConversationProjectionDeduplicatesWorker(t *testing.T) {
 	}
 }
 
+func TestEnsureConversationProjectionResumesAfterLastProjectedSequence(t *testing.T) {
+	state := NewAppState(nil)
+	engine := &runtimeEngineCursorStub{cursors: make(chan string, 1)}
+	state.runtimeEngine = engine
+	state.mu.Lock()
+	state.conversationProjectionLastSeq["conv_projector_cursor"] = 7
+	state.mu.Unlock()
+
+	if err := state.ensureConversationProjection("conv_projector_cursor", "sess_projector_cursor"); err != nil {
+		t.Fatalf("ensure projection failed: %v", err)
+	}
+	select {
+	case cursor := <-engine.cursors:
+		if cursor != "7" {
+			t.Fatalf("expected the projection to resume with cursor 7 so event 8 is replayed, got %q", cursor)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected the projection worker to subscribe")
+	}
+
+	state.mu.RLock()
+	cancel := state.conversationProjectionCancels["conv_projector_cursor"]
+	state.mu.RUnlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
 func TestProjectRuntimeEvent_UserQuestionNeededUpdatesAwaitingInputState(t *testing.T) {
 	state := NewAppState(nil)
 	conversationID := "conv_projector_question_needed"
@@ -430,6 +458,202 @@ func TestProjectRuntimeEvent_ToolCallStageMapsToToolCallEvent(t *testing.T) {
 	}
 }
 
+func TestProjectRuntimeEvent_ToolProgressStageMapsToToolProgressEvent(t *testing.T) {
+	state := NewAppState(nil)
+	conversationID := "conv_projector_tool_progress"
+	executionID := "exec_projector_tool_progress"
+	runID := "run_projector_tool_progress"
+	now := "2026-03-05T12:20:00Z"
+
+	state.mu.Lock()
+	state.conversations[conversationID] = Conversation{
+		ID:                conversationID,
+		WorkspaceID:       localWorkspaceID,
+		ProjectID:         "proj_projector_tool_progress",
+		Name:              "Projection Tool Progress",
+		QueueState:        QueueStateRunning,
+		ActiveExecutionID: stringPtrOrNil(executionID),
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	state.executions[executionID] = Execution{
+		ID:             executionID,
+		WorkspaceID:    localWorkspaceID,
+		ConversationID: conversationID,
+		MessageID:      "msg_projector_tool_progress",
+		State:          RunStateExecuting,
+		TraceID:        "trace_projector_tool_progress",
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	state.executionRunIDs[executionID] = runID
+	state.conversationExecutionOrder[conversationID] = []string{executionID}
+	state.mu.Unlock()
+
+	_, mappedType, projected := state.projectRuntimeEvent(conversationID, agentcore.EventEnvelope{
+		Type:      agentcore.RunEventTypeRunOutputDelta,
+		SessionID: "sess_projector_tool_progress",
+		RunID:     agentcore.RunID(runID),
+		Sequence:  4,
+		Timestamp: time.Date(2026, 3, 5, 12, 20, 1, 0, time.UTC),
+		Payload: agentcore.OutputDeltaPayload{
+			Stage:  "tool_progress",
+			CallID: "call_projector_tool_progress",
+			Name:   "mcp__docs__index",
+			Output: map[string]any{"progress": 3.0, "total": 10.0, "message": "indexing"},
+		},
+	})
+	if !projected || mappedType != RunEventTypeToolProgress {
+		t.Fatalf("expected tool_progress event, got projected=%v type=%s", projected, mappedType)
+	}
+
+	state.mu.RLock()
+	buffered := strings.TrimSpace(state.executionOutputBuffers[executionID])
+	events := append([]ExecutionEvent{}, state.executionEvents[conversationID]...)
+	state.mu.RUnlock()
+	if buffered != "" {
+		t.Fatalf("expected tool progress not to be buffered as assistant output, got %q", buffered)
+	}
+	latest := events[len(events)-1]
+	if latest.Type != RunEventTypeToolProgress || latest.Payload["call_id"] != "call_projector_tool_progress" {
+		t.Fatalf("expected latest projected event to be tool progress, got %#v", latest)
+	}
+}
+
+func TestProjectRuntimeEvent_SecretRedactionIsAudited(t *testing.T) {
+	store, err := openAuthzStore(":memory:")
+	if err != nil {
+		t.Fatalf("open authz store: %v", err)
+	}
+	defer store.close()
+	state := NewAppState(store)
+	conversationID := "conv_projector_redaction"
+	executionID := "exec_projector_redaction"
+	runID := "run_projector_redaction"
+	now := "2026-03-05T12:20:00Z"
+
+	state.mu.Lock()
+	state.conversations[conversationID] = Conversation{
+		ID:                conversationID,
+		WorkspaceID:       localWorkspaceID,
+		ProjectID:         "proj_projector_redaction",
+		Name:              "Projection Redaction",
+		QueueState:        QueueStateRunning,
+		ActiveExecutionID: stringPtrOrNil(executionID),
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	state.executions[executionID] = Execution{
+		ID:             executionID,
+		WorkspaceID:    localWorkspaceID,
+		ConversationID: conversationID,
+		MessageID:      "msg_projector_redaction",
+		State:          RunStateExecuting,
+		TraceID:        "trace_projector_redaction",
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	state.executionRunIDs[executionID] = runID
+	state.conversationExecutionOrder[conversationID] = []string{executionID}
+	state.mu.Unlock()
+
+	_, _, projected := state.projectRuntimeEvent(conversationID, agentcore.EventEnvelope{
+		Type:      agentcore.RunEventTypeRunOutputDelta,
+		SessionID: "sess_projector_redaction",
+		RunID:     agentcore.RunID(runID),
+		Sequence:  4,
+		Timestamp: time.Date(2026, 3, 5, 12, 20, 1, 0, time.UTC),
+		Payload: agentcore.OutputDeltaPayload{
+			Stage:  "secret_redacted",
+			CallID: "call_projector_redaction",
+			Name:   "Read",
+			Output: map[string]any{
+				"kind":        "aws_access_key_id",
+				"placeholder": "[REDACTED:aws_access_key_id:1a2b3c4d]",
+				"fingerprint": "sha256:1a2b3c4d5e6f7a8b",
+				"source":      "tool:Read",
+			},
+		},
+	})
+	if !projected {
+		t.Fatalf("expected secret_redacted stage event to be projected")
+	}
+	events, err := store.listAudit(localWorkspaceID)
+	if err != nil {
+		t.Fatalf("list audit: %v", err)
+	}
+	if len(events) != 1 || events[0].Action != "secret.redact" || events[0].Resource != "conversation:"+conversationID || events[0].TraceID != "trace_projector_redaction" {
+		t.Fatalf("expected one secret.redact audit event, got %#v", events)
+	}
+}
+
+func TestProjectRuntimeEvent_MCPServerRequestIsAudited(t *testing.T) {
+	store, err := openAuthzStore(":memory:")
+	if err != nil {
+		t.Fatalf("open authz store: %v", err)
+	}
+	defer store.close()
+	state := NewAppState(store)
+	conversationID := "conv_projector_mcp_request"
+	executionID := "exec_projector_mcp_request"
+	runID := "run_projector_mcp_request"
+	now := "2026-03-05T12:25:00Z"
+
+	state.mu.Lock()
+	state.conversations[conversationID] = Conversation{
+		ID:                conversationID,
+		WorkspaceID:       localWorkspaceID,
+		ProjectID:         "proj_projector_mcp_request",
+		Name:              "Projection MCP Request",
+		QueueState:        QueueStateRunning,
+		ActiveExecutionID: stringPtrOrNil(executionID),
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	state.executions[executionID] = Execution{
+		ID:             executionID,
+		WorkspaceID:    localWorkspaceID,
+		ConversationID: conversationID,
+		MessageID:      "msg_projector_mcp_request",
+		State:          RunStateExecuting,
+		TraceID:        "trace_projector_mcp_request",
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	state.executionRunIDs[executionID] = runID
+	state.conversationExecutionOrder[conversationID] = []string{executionID}
+	state.mu.Unlock()
+
+	_, _, projected := state.projectRuntimeEvent(conversationID, agentcore.EventEnvelope{
+		Type:      agentcore.RunEventTypeRunOutputDelta,
+		SessionID: "sess_projector_mcp_request",
+		RunID:     agentcore.RunID(runID),
+		Sequence:  5,
+		Timestamp: time.Date(2026, 3, 5, 12, 25, 1, 0, time.UTC),
+		Payload: agentcore.OutputDeltaPayload{
+			Stage: "mcp_server_request",
+			Name:  "mcp__docs__sampling",
+			Output: map[string]any{
+				"server":     "docs",
+				"method":     "sampling/createMessage",
+				"outcome":    "denied",
+				"decided_by": "hook",
+				"reason":     "sampling disabled",
+			},
+		},
+	})
+	if !projected {
+		t.Fatalf("expected mcp_server_request stage event to be projected")
+	}
+	events, err := store.listAudit(localWorkspaceID)
+	if err != nil {
+		t.Fatalf("list audit: %v", err)
+	}
+	if len(events) != 1 || events[0].Action != "mcp.sampling" || events[0].Result != "denied" || events[0].TraceID != "trace_projector_mcp_request" {
+		t.Fatalf("expected one denied mcp.sampling audit event, got %#v", events)
+	}
+}
+
 func boolPtr(value bool) *bool {
 	return &value
 }
@@ -491,6 +715,21 @@ func (s *runtimeEngineSubscribeStub) Subscribe(
 	}, nil
 }
 
+// runtimeEngineCursorStub records the cursor each subscription starts from.
+type runtimeEngineCursorStub struct {
+	runtimeEngineSubscribeStub
+	cursors chan string
+}
+
+func (s *runtimeEngineCursorStub) Subscribe(
+	ctx context.Context,
+	sessionID string,
+	cursor string,
+) (agentcore.EventSubscription, error) {
+	s.cursors <- cursor
+	return s.runtimeEngineSubscribeStub.Subscribe(ctx, sessionID, cursor)
+}
+
 type runtimeEventSubscriptionStub struct {
 	ctx context.Context
 	ch  chan agentcore.EventEnvelope
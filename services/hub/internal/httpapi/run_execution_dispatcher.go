@@ -14,6 +14,7 @@ import (
 
 	agenthttpapi "goyais/services/hub/internal/agent/adapters/httpapi"
 	agentcore "goyais/services/hub/internal/agent/core"
+	mcpsext "goyais/services/hub/internal/agent/extensions/mcp"
 )
 
 var (
@@ -170,9 +171,12 @@ func (s *AppState) controlExecutionBestEffort(ctx context.Context, executionID s
 		}
 	}
 	if err := service.Control(ctx, agenthttpapi.ControlRequest{
-		RunID:  runID,
-		Action: action,
-		Answer: answer,
+		RunID:    runID,
+		Action:   action,
+		Answer:   answer,
+		Remember: string(signal.Remember),
+		Mode:     string(signal.Mode),
+		Feedback: signal.Feedback,
 	}); err != nil {
 		s.appendExecutionRuntimeAudit(normalizedExecutionID, "execution.runtime.control", "error")
 		return
@@ -190,6 +194,27 @@ func (s *AppState) clearExecutionRuntimeMapping(executionID string) {
 	s.mu.Unlock()
 }
 
+// runtimeSessionCloser is implemented by engines that hold resources per
+// session outside their own state, such as pooled MCP servers.
+type runtimeSessionCloser interface {
+	CloseSession(ctx context.Context, sessionID string)
+}
+
+// closeConversationRuntime shuts down the pooled MCP servers of a deleted
+// conversation: those of its engine session and those the composer opened
+// under the conversation id.
+func (s *AppState) closeConversationRuntime(ctx context.Context, conversationID string, runtimeSessionID string) {
+	if s == nil {
+		return
+	}
+	if closer, ok := s.runtimeEngine.(runtimeSessionCloser); ok && strings.TrimSpace(runtimeSessionID) != "" {
+		closer.CloseSession(ctx, runtimeSessionID)
+	}
+	if conversationID = strings.TrimSpace(conversationID); conversationID != "" {
+		mcpsext.DefaultSessionPool().CloseSession(conversationID)
+	}
+}
+
 func (s *AppState) runtimeRunService() runtimeRunBridgeService {
 	if s == nil {
 		return nil
@@ -317,13 +342,14 @@ func buildRuntimeSubmitMetadata(submitCtx executionSubmitContext) map[string]str
 func buildExecutionRuntimeConfig(model runtimeModelConfig, tooling runtimeToolingConfig) agentcore.RuntimeConfig {
 	return agentcore.RuntimeConfig{
 		Model: agentcore.RuntimeModelConfig{
-			ProviderName:  strings.TrimSpace(model.Provider),
-			Endpoint:      strings.TrimSpace(model.Endpoint),
-			ModelName:     strings.TrimSpace(model.ModelName),
-			APIKey:        strings.TrimSpace(model.APIKey),
-			Params:        decodeRuntimeParamsJSON(model.ParamsJSON),
-			TimeoutMS:     model.TimeoutMS,
-			MaxModelTurns: model.MaxModelTurns,
+			ProviderName:        strings.TrimSpace(model.Provider),
+			Endpoint:            strings.TrimSpace(model.Endpoint),
+			ModelName:           strings.TrimSpace(model.ModelName),
+			APIKey:              strings.TrimSpace(model.APIKey),
+			Params:              decodeRuntimeParamsJSON(model.ParamsJSON),
+			TimeoutMS:           model.TimeoutMS,
+			MaxModelTurns:       model.MaxModelTurns,
+			ContextWindowTokens: model.ContextWindow,
 		},
 		Tooling: agentcore.RuntimeToolingConfig{
 			PermissionMode:           agentcore.PermissionMode(strings.TrimSpace(tooling.PermissionMode)),
@@ -357,12 +383,13 @@ func cloneRuntimeMCPServers(input []agentcore.MCPServerConfig) []agentcore.MCPSe
 	out := make([]agentcore.MCPServerConfig, 0, len(input))
 	for _, item := range input {
 		out = append(out, agentcore.MCPServerConfig{
-			Name:      strings.TrimSpace(item.Name),
-			Transport: strings.TrimSpace(item.Transport),
-			Endpoint:  strings.TrimSpace(item.Endpoint),
-			Command:   strings.TrimSpace(item.Command),
-			Env:       cloneStringMapForRuntime(item.Env),
-			Tools:     append([]string{}, item.Tools...),
+			Name:            strings.TrimSpace(item.Name),
+			Transport:       strings.TrimSpace(item.Transport),
+			Endpoint:        strings.TrimSpace(item.Endpoint),
+			Command:         strings.TrimSpace(item.Command),
+			Env:             cloneStringMapForRuntime(item.Env),
+			Tools:           append([]string{}, item.Tools...),
+			CredentialScope: strings.TrimSpace(item.CredentialScope),
 		})
 	}
 	return out
@@ -309,10 +309,11 @@ func TestLoadExecutionSubmitContextResolvesRuntimeToolingConfig(t *testing.T) {
 			ModelID:  "gpt-5.3",
 		},
 		ResourceProfileSnapshot: &ExecutionResourceProfile{
-			ModelConfigID: modelConfigID,
-			ModelID:       "gpt-5.3",
-			RuleIDs:       []string{ruleID},
-			MCPIDs:        []string{mcpID},
+			ModelConfigID:      modelConfigID,
+			ModelID:            "gpt-5.3",
+			RuleIDs:            []string{ruleID},
+			MCPIDs:             []string{mcpID},
+			MCPCredentialScope: mcpCredentialScope(localWorkspaceID, "user_submit"),
 		},
 		CreatedAt: now,
 		UpdatedAt: now,
@@ -334,6 +335,9 @@ func TestLoadExecutionSubmitContextResolvesRuntimeToolingConfig(t *testing.T) {
 	if submitCtx.RuntimeTooling.MCPServers[0].Name != "local-mcp" {
 		t.Fatalf("expected runtime mcp server name local-mcp, got %q", submitCtx.RuntimeTooling.MCPServers[0].Name)
 	}
+	if scope := submitCtx.RuntimeTooling.MCPServers[0].CredentialScope; scope != localWorkspaceID+"/user_submit" {
+		t.Fatalf("expected runtime mcp server to use the submitting user's tokens, got %q", scope)
+	}
 	if len(submitCtx.RuntimeTooling.BuiltinTools) == 0 {
 		t.Fatalf("expected runtime builtin tools to be populated")
 	}
@@ -8,11 +8,18 @@ import (
 
 	agenthttpapi "goyais/services/hub/internal/agent/adapters/httpapi"
 	agentcore "goyais/services/hub/internal/agent/core"
+	agenthooks "goyais/services/hub/internal/agent/extensions/hooks"
+	"goyais/services/hub/internal/agent/runtime/compaction"
 	"goyais/services/hub/internal/agent/runtime/loop"
+	"goyais/services/hub/internal/agent/tools/checkpoint"
 )
 
 const localWorkspaceID = "ws_local"
 
+// defaultCompactionWindowTokens is the auto-compaction window for models
+// whose catalog entry has no context_window.
+const defaultCompactionWindowTokens = 128000
+
 type AppState struct {
 	mu sync.RWMutex
 
@@ -55,6 +62,7 @@ type AppState struct {
 
 	runtimeEngine  agentcore.Engine
 	runtimeService runtimeRunBridgeService
+	hookHandlers   agenthooks.Handlers
 }
 
 func NewAppState(store *authzStore) *AppState {
@@ -104,9 +112,26 @@ func NewAppState(store *authzStore) *AppState {
 		}
 		state.hydrateExecutionDomainFromStore()
 	}
-	engine := loop.NewEngine(nil)
+	state.hookHandlers = hubHookHandlers(state)
+	engineDeps := loop.Dependencies{
+		HookDispatcher: hubHookDispatcher{state: state},
+		// Runs override the window with their model's catalog context_window.
+		Compactor: compaction.NewManager(compaction.Config{WindowTokens: defaultCompactionWindowTokens}, compaction.Dependencies{
+			Summarizer: loop.NewModelSummarizer(nil),
+		}),
+	}
+	if state.authz != nil {
+		engineDeps.Journal = newSQLiteEngineJournal(state.authz.db)
+		if checkpointDir := hubCheckpointDir(state.authz.dbPath); checkpointDir != "" {
+			engineDeps.CheckpointDir = checkpointDir
+			engineDeps.CheckpointOptions = checkpoint.DiskOptions{Compress: true}
+		}
+	}
+	configureEngineEventStore(&engineDeps, state.authz)
+	engine := loop.NewEngineWithDeps(engineDeps)
 	state.runtimeEngine = engine
 	state.runtimeService = agenthttpapi.NewService(engine)
+	state.recoverRuntimeEngine(engine)
 
 	state.adminRoles = defaultRoles()
 	state.adminUsers["u_local_admin"] = AdminUser{
@@ -28,6 +28,7 @@ type ExecutionResourceProfileSnapshot struct {
 	ProjectFilePaths         []string                                `json:"project_file_paths,omitempty"`
 	RulesDSL                 string                                  `json:"rules_dsl,omitempty"`
 	MCPServers               []ExecutionMCPServerSnapshot            `json:"mcp_servers,omitempty"`
+	MCPCredentialScope       string                                  `json:"mcp_credential_scope,omitempty"`
 	AlwaysLoadedCapabilities []ExecutionCapabilityDescriptorSnapshot `json:"always_loaded_capabilities,omitempty"`
 	SearchableCapabilities   []ExecutionCapabilityDescriptorSnapshot `json:"searchable_capabilities,omitempty"`
 }
@@ -34,6 +34,7 @@ const (
 	EventTypeWorktreeCreate     EventType = "worktree_create"
 	EventTypeWorktreeRemove     EventType = "worktree_remove"
 	EventTypePreCompact         EventType = "pre_compact"
+	EventTypeMCPServerRequest   EventType = "mcp_server_request"
 )
 
 type Action string
@@ -70,8 +71,19 @@ type Decision struct {
 	AdditionalContext map[string]any
 }
 
-func Evaluate(policies []Policy, event EventInput) Decision {
-	filtered := make([]Policy, 0, len(policies))
+// RankedPolicy is a policy that matched an event, with the keys Evaluate
+// orders it by.
+type RankedPolicy struct {
+	Policy
+	ScopeRank   int
+	Specificity int
+}
+
+// Rank returns the enabled policies that match event in the order Evaluate
+// considers them: scope, then tool specificity, then deny > ask > allow.
+// The first entry decides.
+func Rank(policies []Policy, event EventInput) []RankedPolicy {
+	ranked := make([]RankedPolicy, 0, len(policies))
 	eventTool := strings.TrimSpace(event.ToolName)
 	for _, item := range policies {
 		if !item.Enabled {
@@ -84,36 +96,41 @@ func Evaluate(policies []Policy, event EventInput) Decision {
 		if policyTool != "" && !strings.EqualFold(policyTool, eventTool) {
 			continue
 		}
-		filtered = append(filtered, item)
-	}
-	if len(filtered) == 0 {
-		return Decision{
-			Action:            ActionAllow,
-			UpdatedInput:      map[string]any{},
-			AdditionalContext: map[string]any{},
-		}
+		ranked = append(ranked, RankedPolicy{
+			Policy:      item,
+			ScopeRank:   scopeOrder(normalizeScope(item.Scope)),
+			Specificity: toolSpecificity(item.ToolName, eventTool),
+		})
 	}
 
-	sort.SliceStable(filtered, func(i, j int) bool {
-		left := normalizeScope(filtered[i].Scope)
-		right := normalizeScope(filtered[j].Scope)
-		if left != right {
-			return scopeOrder(left) < scopeOrder(right)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].ScopeRank != ranked[j].ScopeRank {
+			return ranked[i].ScopeRank < ranked[j].ScopeRank
 		}
-		leftSpecificity := toolSpecificity(filtered[i].ToolName, eventTool)
-		rightSpecificity := toolSpecificity(filtered[j].ToolName, eventTool)
-		if leftSpecificity != rightSpecificity {
-			return leftSpecificity < rightSpecificity
+		if ranked[i].Specificity != ranked[j].Specificity {
+			return ranked[i].Specificity < ranked[j].Specificity
 		}
-		leftAction := normalizeAction(filtered[i].Action)
-		rightAction := normalizeAction(filtered[j].Action)
+		leftAction := normalizeAction(ranked[i].Action)
+		rightAction := normalizeAction(ranked[j].Action)
 		if leftAction != rightAction {
 			return actionOrder(leftAction) < actionOrder(rightAction)
 		}
-		return strings.TrimSpace(filtered[i].ID) < strings.TrimSpace(filtered[j].ID)
+		return strings.TrimSpace(ranked[i].ID) < strings.TrimSpace(ranked[j].ID)
 	})
+	return ranked
+}
+
+func Evaluate(policies []Policy, event EventInput) Decision {
+	ranked := Rank(policies, event)
+	if len(ranked) == 0 {
+		return Decision{
+			Action:            ActionAllow,
+			UpdatedInput:      map[string]any{},
+			AdditionalContext: map[string]any{},
+		}
+	}
 
-	selected := filtered[0]
+	selected := ranked[0].Policy
 	action := normalizeAction(selected.Action)
 	if action == "" {
 		action = ActionAllow
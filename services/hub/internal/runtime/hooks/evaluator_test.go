@@ -193,3 +193,27 @@ func TestEvaluateTreatsUnknownScopeAsLowestPriority(t *testing.T) {
 		t.Fatalf("expected global policy id, got %#v", decision)
 	}
 }
+
+func TestRankOrdersByScopeThenSpecificityThenAction(t *testing.T) {
+	ranked := Rank(
+		[]Policy{
+			{ID: "policy_project_deny", Scope: ScopeProject, EventType: EventTypePreToolUse, ToolName: "Bash", Action: ActionDeny, Enabled: true},
+			{ID: "policy_global_any_ask", Scope: ScopeGlobal, EventType: EventTypePreToolUse, Action: ActionAsk, Enabled: true},
+			{ID: "policy_global_bash_allow", Scope: ScopeGlobal, EventType: EventTypePreToolUse, ToolName: "Bash", Action: ActionAllow, Enabled: true},
+			{ID: "policy_global_read_deny", Scope: ScopeGlobal, EventType: EventTypePreToolUse, ToolName: "Read", Action: ActionDeny, Enabled: true},
+		},
+		EventInput{EventType: EventTypePreToolUse, ToolName: "Bash"},
+	)
+	want := []string{"policy_global_bash_allow", "policy_global_any_ask", "policy_project_deny"}
+	if len(ranked) != len(want) {
+		t.Fatalf("expected %d ranked policies, got %#v", len(want), ranked)
+	}
+	for index, id := range want {
+		if ranked[index].ID != id {
+			t.Fatalf("expected %s at %d, got %#v", id, index, ranked)
+		}
+	}
+	if ranked[0].ScopeRank != 0 || ranked[0].Specificity != 0 || ranked[2].ScopeRank != 1 {
+		t.Fatalf("unexpected rank keys %#v", ranked)
+	}
+}
@@ -12,6 +12,7 @@ type HookPolicyRow struct {
 	ProjectID      *string
 	ConversationID *string
 	Enabled        bool
+	HandlerJSON    string
 	DecisionJSON   string
 	UpdatedAt      string
 }
@@ -33,7 +34,7 @@ func (s *HookPolicyStore) LoadAll() ([]HookPolicyRow, error) {
 		return []HookPolicyRow{}, nil
 	}
 	rows, err := s.executor.Query(
-		`SELECT id, scope, event, handler_type, tool_name, workspace_id, project_id, conversation_id, enabled, decision_json, updated_at
+		`SELECT id, scope, event, handler_type, tool_name, workspace_id, project_id, conversation_id, enabled, handler_json, decision_json, updated_at
 		 FROM hook_policies
 		 ORDER BY id ASC`,
 	)
@@ -61,6 +62,7 @@ func (s *HookPolicyStore) LoadAll() ([]HookPolicyRow, error) {
 			&projectIDRaw,
 			&conversationIDRaw,
 			&enabled,
+			&item.HandlerJSON,
 			&item.DecisionJSON,
 			&item.UpdatedAt,
 		); err != nil {
@@ -111,9 +113,13 @@ func (s *HookPolicyStore) ReplaceAll(rows []HookPolicyRow) error {
 		if item.ConversationID != nil {
 			conversationID = *item.ConversationID
 		}
+		handlerJSON := item.HandlerJSON
+		if handlerJSON == "" {
+			handlerJSON = "{}"
+		}
 		if _, err := s.executor.Exec(
-			`INSERT INTO hook_policies(id, scope, event, handler_type, tool_name, workspace_id, project_id, conversation_id, enabled, decision_json, updated_at)
-			 VALUES(?,?,?,?,?,?,?,?,?,?,?)`,
+			`INSERT INTO hook_policies(id, scope, event, handler_type, tool_name, workspace_id, project_id, conversation_id, enabled, handler_json, decision_json, updated_at)
+			 VALUES(?,?,?,?,?,?,?,?,?,?,?,?)`,
 			item.ID,
 			item.Scope,
 			item.Event,
@@ -123,6 +129,7 @@ func (s *HookPolicyStore) ReplaceAll(rows []HookPolicyRow) error {
 			projectID,
 			conversationID,
 			enabled,
+			handlerJSON,
 			item.DecisionJSON,
 			item.UpdatedAt,
 		); err != nil {
@@ -27,6 +27,7 @@ func openHookPolicyTestDB(t *testing.T) *sql.DB {
 		project_id TEXT,
 		conversation_id TEXT,
 		enabled INTEGER NOT NULL DEFAULT 1,
+		handler_json TEXT NOT NULL DEFAULT '{}',
 		decision_json TEXT NOT NULL,
 		updated_at TEXT NOT NULL
 	)`)
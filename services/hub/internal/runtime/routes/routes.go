@@ -3,32 +3,36 @@ package routes
 import "net/http"
 
 type Handlers struct {
-	Projects                     http.HandlerFunc
-	ProjectsImport               http.HandlerFunc
-	ProjectByID                  http.HandlerFunc
-	ProjectConversations         http.HandlerFunc
-	ProjectConfig                http.HandlerFunc
-	ProjectFiles                 http.HandlerFunc
-	ProjectFileContent           http.HandlerFunc
-	Conversations                http.HandlerFunc
-	ConversationByID             http.HandlerFunc
-	ConversationInputCatalog     http.HandlerFunc
-	ConversationInputSuggest     http.HandlerFunc
-	ConversationInputSubmit      http.HandlerFunc
-	ConversationEvents           http.HandlerFunc
-	ConversationStop             http.HandlerFunc
-	ConversationExport           http.HandlerFunc
-	ConversationChangeSet        http.HandlerFunc
-	ConversationChangeSetCommit  http.HandlerFunc
-	ConversationChangeSetDiscard http.HandlerFunc
-	ConversationChangeSetExport  http.HandlerFunc
-	ConversationRollback         http.HandlerFunc
-	Executions                   http.HandlerFunc
-	RunControl                   http.HandlerFunc
-	RunGraph                     http.HandlerFunc
-	RunTasks                     http.HandlerFunc
-	RunTaskByID                  http.HandlerFunc
-	RunTaskControl               http.HandlerFunc
+	Projects                             http.HandlerFunc
+	ProjectsImport                       http.HandlerFunc
+	ProjectByID                          http.HandlerFunc
+	ProjectConversations                 http.HandlerFunc
+	ProjectConfig                        http.HandlerFunc
+	ProjectFiles                         http.HandlerFunc
+	ProjectFileContent                   http.HandlerFunc
+	Conversations                        http.HandlerFunc
+	ConversationByID                     http.HandlerFunc
+	ConversationInputCatalog             http.HandlerFunc
+	ConversationInputSuggest             http.HandlerFunc
+	ConversationInputSubmit              http.HandlerFunc
+	ConversationEvents                   http.HandlerFunc
+	ConversationStop                     http.HandlerFunc
+	ConversationExport                   http.HandlerFunc
+	ConversationChangeSet                http.HandlerFunc
+	ConversationChangeSetCommit          http.HandlerFunc
+	ConversationChangeSetDiscard         http.HandlerFunc
+	ConversationChangeSetExport          http.HandlerFunc
+	ConversationRollback                 http.HandlerFunc
+	ConversationPermissionExplain        http.HandlerFunc
+	ConversationPermissionApprovals      http.HandlerFunc
+	ConversationPermissionApprovalRevoke http.HandlerFunc
+	ConversationCheckpoints              http.HandlerFunc
+	Executions                           http.HandlerFunc
+	RunControl                           http.HandlerFunc
+	RunGraph                             http.HandlerFunc
+	RunTasks                             http.HandlerFunc
+	RunTaskByID                          http.HandlerFunc
+	RunTaskControl                       http.HandlerFunc
 }
 
 func Register(mux *http.ServeMux, handlers Handlers) {
@@ -52,6 +56,10 @@ func Register(mux *http.ServeMux, handlers Handlers) {
 	mustHandle(mux, "/v1/sessions/{session_id}/changeset/discard", handlers.ConversationChangeSetDiscard)
 	mustHandle(mux, "/v1/sessions/{session_id}/changeset/export", handlers.ConversationChangeSetExport)
 	mustHandle(mux, "/v1/sessions/{session_id}/rollback", handlers.ConversationRollback)
+	mustHandle(mux, "/v1/sessions/{session_id}/permissions:explain", handlers.ConversationPermissionExplain)
+	mustHandle(mux, "/v1/sessions/{session_id}/permissions/approvals", handlers.ConversationPermissionApprovals)
+	mustHandle(mux, "/v1/sessions/{session_id}/permissions/approvals:revoke", handlers.ConversationPermissionApprovalRevoke)
+	mustHandle(mux, "/v1/sessions/{session_id}/checkpoints", handlers.ConversationCheckpoints)
 	mustHandle(mux, "/v1/runs", handlers.Executions)
 	mustHandle(mux, "/v1/runs/{run_id}/control", handlers.RunControl)
 	mustHandle(mux, "/v1/runs/{run_id}/graph", handlers.RunGraph)